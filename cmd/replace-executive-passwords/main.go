@@ -0,0 +1,55 @@
+// Command replace-executive-passwords retires the default passwords of executives created before
+// invites were introduced. Those accounts were given their executive number followed by "123" as a
+// password; every executive still on that password has it cleared and is sent an invite to set
+// their own.
+//
+// Usage:
+//
+//	go run ./cmd/replace-executive-passwords -dry-run   # count the affected executives
+//	go run ./cmd/replace-executive-passwords
+//
+// Running it again only affects executives that still have their default password.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"gnaps-api/config"
+	"gnaps-api/repositories"
+	"gnaps-api/services"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count the executives whose passwords would be replaced")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+	config.ConnectDb()
+
+	db := config.DBConn
+	executiveService := services.NewExecutiveService(
+		repositories.NewExecutiveRepository(db),
+		repositories.NewUserRepository(db),
+		repositories.NewExecutiveInviteRepository(db),
+		repositories.NewExecutiveTermRepository(db),
+		services.NewSmsService(db),
+	)
+
+	result, err := executiveService.ReplaceDefaultPasswords(*dryRun)
+	if err != nil {
+		log.Fatalf("Failed to replace default passwords: %v", err)
+	}
+	if *dryRun {
+		log.Printf("%d executives still have their default password", result.Cleared)
+		return
+	}
+	log.Printf("Cleared %d default passwords and sent %d invites", result.Cleared, result.Invited)
+	if len(result.NotDelivered) > 0 {
+		log.Printf("No invite could be delivered to executives %v; fix their contact details and resend", result.NotDelivered)
+	}
+}
@@ -0,0 +1,70 @@
+// Command seed-chart-of-accounts installs the standard association chart of accounts.
+//
+// Usage:
+//
+//	go run ./cmd/seed-chart-of-accounts                          # national office
+//	go run ./cmd/seed-chart-of-accounts -owner-type zone -owner-id 12
+//	go run ./cmd/seed-chart-of-accounts -all                     # national, every region and zone
+//
+// Seeding is idempotent; existing accounts are attached to their headings instead of duplicated.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"gnaps-api/config"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	ownerType := flag.String("owner-type", utils.OwnerTypeNational, "owner level to seed: national, region or zone")
+	ownerID := flag.Int64("owner-id", utils.DefaultNationalOwnerID, "ID of the region or zone to seed")
+	all := flag.Bool("all", false, "seed the national office and every region and zone")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+	config.ConnectDb()
+
+	db := config.DBConn
+	accountService := services.NewFinanceAccountService(repositories.NewFinanceAccountRepository(db))
+
+	type owner struct {
+		ownerType string
+		ownerID   int64
+	}
+	owners := []owner{{*ownerType, *ownerID}}
+
+	if *all {
+		owners = []owner{{utils.OwnerTypeNational, utils.DefaultNationalOwnerID}}
+
+		var regionIDs, zoneIDs []int64
+		if err := db.Model(&models.Region{}).Where("is_deleted = ? OR is_deleted IS NULL", false).Pluck("id", &regionIDs).Error; err != nil {
+			log.Fatalf("Failed to load regions: %v", err)
+		}
+		if err := db.Model(&models.Zone{}).Where("is_deleted = ? OR is_deleted IS NULL", false).Pluck("id", &zoneIDs).Error; err != nil {
+			log.Fatalf("Failed to load zones: %v", err)
+		}
+		for _, id := range regionIDs {
+			owners = append(owners, owner{utils.OwnerTypeRegion, id})
+		}
+		for _, id := range zoneIDs {
+			owners = append(owners, owner{utils.OwnerTypeZone, id})
+		}
+	}
+
+	for _, o := range owners {
+		result, err := accountService.SeedStandardChart(o.ownerType, o.ownerID)
+		if err != nil {
+			log.Fatalf("Failed to seed chart of accounts for %s %d: %v", o.ownerType, o.ownerID, err)
+		}
+		log.Printf("Seeded chart of accounts for %s %d: %d created, %d updated", result.OwnerType, result.OwnerId, result.Created, result.Updated)
+	}
+}
@@ -15,6 +15,18 @@ var PaymentWorker *workers.PaymentWorker
 // MomoPaymentService is exported for use in worker
 var MomoPaymentService *services.MomoPaymentService
 
+// ExportWorker is exported for use in main.go
+var ExportWorker *workers.ExportWorker
+
+// FinanceExportService is exported for use in the export worker
+var FinanceExportService *services.FinanceExportService
+
+// MembershipStatusWorker is exported for use in main.go
+var MembershipStatusWorker *workers.MembershipStatusWorker
+
+// ExecutiveTermWorker is exported for use in main.go
+var ExecutiveTermWorker *workers.ExecutiveTermWorker
+
 // InitializeControllers sets up dependency injection for all refactored controllers
 func InitializeControllers(db *gorm.DB) {
 	// Initialize Repositories
@@ -35,32 +47,69 @@ func InitializeControllers(db *gorm.DB) {
 	billParticularRepo := repositories.NewBillParticularRepository(db)
 	billRepo := repositories.NewBillRepository(db)
 	billItemRepo := repositories.NewBillItemRepository(db)
+	schoolBillRevisionRepo := repositories.NewSchoolBillRevisionRepository(db)
 	momoPaymentRepo := repositories.NewMomoPaymentRepository(db)
 	activityLogRepo := repositories.NewActivityLogRepository(db)
 	schoolBillRepo := repositories.NewSchoolBillRepository(db)
+	financeExpenseRepo := repositories.NewFinanceExpenseRepository(db)
+	ledgerRepo := repositories.NewLedgerRepository(db)
+	budgetRepo := repositories.NewBudgetRepository(db)
+	exportJobRepo := repositories.NewExportJobRepository(db)
+	remittanceRepo := repositories.NewRemittanceRepository(db)
+	bankAccountRepo := repositories.NewBankAccountRepository(db)
+	fiscalPeriodRepo := repositories.NewFiscalPeriodRepository(db)
+	membershipApplicationRepo := repositories.NewMembershipApplicationRepository(db)
+	schoolMergeRepo := repositories.NewSchoolMergeRepository(db)
+	schoolTransferRepo := repositories.NewSchoolTransferRepository(db)
+	censusRepo := repositories.NewCensusRepository(db)
+	memberNoSchemeRepo := repositories.NewMemberNoSchemeRepository(db)
+	executiveInviteRepo := repositories.NewExecutiveInviteRepository(db)
+	executiveTermRepo := repositories.NewExecutiveTermRepository(db)
+	membershipCertificateRepo := repositories.NewMembershipCertificateRepository(db)
+	schoolStatusRepo := repositories.NewSchoolStatusRepository(db)
+	schoolGroupMemberRepo := repositories.NewSchoolGroupMemberRepository(db)
 
 	// Initialize Services
 	eventService := services.NewEventService(eventRepo, registrationRepo)
-	schoolService := services.NewSchoolService(schoolRepo, userRepo)
-	newsService := services.NewNewsService(newsRepo, commentRepo, userRepo)
+	schoolService := services.NewSchoolService(schoolRepo, userRepo, memberNoSchemeRepo)
+	newsService := services.NewNewsService(newsRepo, commentRepo, userRepo, schoolGroupMemberRepo)
 	regionService := services.NewRegionService(regionRepo)
 	zoneService := services.NewZoneService(zoneRepo)
-	groupService := services.NewGroupService(groupRepo)
+	groupService := services.NewGroupService(groupRepo, schoolGroupMemberRepo)
 	positionService := services.NewPositionService(positionRepo)
-	executiveService := services.NewExecutiveService(executiveRepo, userRepo)
 	contactPersonService := services.NewContactPersonService(contactPersonRepo)
 	documentService := services.NewDocumentService(documentRepo)
 	dashboardService := services.NewDashboardService(db)
 	mediaService := services.NewMediaService()
 	financeAccountService := services.NewFinanceAccountService(financeAccountRepo)
 	billParticularService := services.NewBillParticularService(billParticularRepo)
-	billService := services.NewBillService(billRepo, billItemRepo)
+	fiscalPeriodService := services.NewFiscalPeriodService(fiscalPeriodRepo)
+	ledgerService := services.NewLedgerService(ledgerRepo, fiscalPeriodService)
+	billService := services.NewBillService(billRepo, billItemRepo, schoolBillRevisionRepo, schoolBillRepo, schoolRepo)
 	chatService := services.NewChatService()
-	momoPaymentService := services.NewMomoPaymentService(momoPaymentRepo, registrationRepo, eventRepo, db)
 	financeReportsService := services.NewFinanceReportsService(db)
+	financeAnalyticsService := services.NewFinanceAnalyticsService(db)
+	remittanceService := services.NewRemittanceService(remittanceRepo, financeReportsService)
+	bankAccountService := services.NewBankAccountService(bankAccountRepo)
+	bankReconciliationService := services.NewBankReconciliationService(bankAccountRepo)
+	membershipStatusService := services.NewMembershipStatusService(schoolStatusRepo, schoolRepo)
+	momoPaymentService := services.NewMomoPaymentService(momoPaymentRepo, registrationRepo, eventRepo, ledgerService, remittanceService, bankAccountService, membershipStatusService, db)
 	smsService := services.NewSmsService(db)
 	activityLogService := services.NewActivityLogService(activityLogRepo)
-	schoolBillService := services.NewSchoolBillService(schoolBillRepo)
+	schoolBillService := services.NewSchoolBillService(schoolBillRepo, ledgerService, remittanceService, bankAccountService, fiscalPeriodService, membershipStatusService)
+	budgetService := services.NewBudgetService(budgetRepo, financeAccountRepo)
+	financeExpenseService := services.NewFinanceExpenseService(financeExpenseRepo, financeAccountRepo, ledgerService, budgetService, mediaService, bankAccountService, fiscalPeriodService)
+	schoolImportService := services.NewSchoolImportService(schoolRepo, zoneRepo, regionRepo, contactPersonRepo, schoolService)
+	schoolMergeService := services.NewSchoolMergeService(schoolMergeRepo, schoolRepo, zoneRepo)
+	membershipApplicationService := services.NewMembershipApplicationService(membershipApplicationRepo, schoolRepo, userRepo, contactPersonRepo, executiveInviteRepo, schoolService, smsService)
+	schoolLocationService := services.NewSchoolLocationService(schoolRepo)
+	membershipCertificateService := services.NewMembershipCertificateService(membershipCertificateRepo, schoolRepo, regionRepo, membershipStatusService)
+	schoolTransferService := services.NewSchoolTransferService(schoolTransferRepo, schoolRepo, schoolBillRepo, zoneRepo, smsService)
+	censusService := services.NewCensusService(censusRepo, schoolRepo)
+	memberNoSchemeService := services.NewMemberNoSchemeService(memberNoSchemeRepo, zoneRepo, regionRepo)
+	executiveService := services.NewExecutiveService(executiveRepo, userRepo, executiveInviteRepo, executiveTermRepo, smsService)
+	executiveTermService := services.NewExecutiveTermService(executiveTermRepo, executiveRepo, positionRepo, zoneRepo, regionRepo)
+	orgChartService := services.NewOrgChartService(executiveTermRepo, positionRepo, regionRepo, zoneRepo)
 
 	// Store globally for worker access
 	MomoPaymentService = momoPaymentService
@@ -74,34 +123,61 @@ func InitializeControllers(db *gorm.DB) {
 	// Start the payment status checker (runs every 10 seconds to check "pending" payment statuses)
 	PaymentWorker.StartStatusChecker(momoPaymentService.CheckAndUpdatePendingPayments)
 
+	// Initialize Export Worker (large finance exports are generated in the background)
+	ExportWorker = workers.NewExportWorker()
+	FinanceExportService = services.NewFinanceExportService(financeReportsService, exportJobRepo, activityLogService, ExportWorker)
+
+	// Start the hourly deletion of export files past their retention
+	ExportWorker.StartExpiredFileCleanup(FinanceExportService.ExpireExportFiles)
+
+	// Start the nightly membership status evaluation (lapses schools with overdue dues, reinstates paid ones)
+	MembershipStatusWorker = workers.NewMembershipStatusWorker()
+	MembershipStatusWorker.StartNightlyEvaluation(membershipStatusService.EvaluateAll)
+
+	// Start the nightly closing of executive terms past their end date
+	ExecutiveTermWorker = workers.NewExecutiveTermWorker()
+	ExecutiveTermWorker.StartNightlyClosing(executiveTermService.CloseExpiredTerms)
+
 	// Initialize Controllers
-	publicEventsController := controllers.NewPublicEventsController(eventRepo, registrationRepo, schoolRepo, db)
+	publicEventsController := controllers.NewPublicEventsController(eventRepo, registrationRepo, schoolRepo, membershipStatusService, db)
 	publicEventsController.SetPaymentDependencies(momoPaymentService, PaymentWorker)
-	publicController := controllers.NewPublicController(regionRepo, zoneRepo, schoolRepo, contactPersonRepo, db)
+	publicController := controllers.NewPublicController(regionRepo, zoneRepo, schoolRepo, contactPersonRepo, db, membershipApplicationService, membershipCertificateService, executiveService, orgChartService)
 	paymentsController := controllers.NewPaymentsController(momoPaymentService, PaymentWorker)
 
 	// Initialize Refactored Controllers
 	eventsController := controllers.NewEventsController(eventService, schoolService)
 	newsController := controllers.NewNewsController(newsService)
-	schoolsController := controllers.NewSchoolsController(schoolService)
+	schoolsController := controllers.NewSchoolsController(schoolService, schoolImportService, schoolMergeService, membershipStatusService, schoolLocationService)
 	regionsController := controllers.NewRegionsController(regionService)
 	zonesController := controllers.NewZonesController(zoneService)
 	groupsController := controllers.NewGroupsController(groupService)
 	positionsController := controllers.NewPositionsController(positionService)
-	executivesController := controllers.NewExecutivesController(executiveService)
+	executivesController := controllers.NewExecutivesController(executiveService, orgChartService)
 	contactPersonsController := controllers.NewContactPersonsController(contactPersonService)
 	documentsController := controllers.NewDocumentsController(documentService)
-	dashboardController := controllers.NewDashboardController(dashboardService)
+	dashboardController := controllers.NewDashboardController(dashboardService, financeReportsService, financeAnalyticsService)
 	mediaController := controllers.NewMediaController(mediaService)
 	financeAccountsController := controllers.NewFinanceAccountsController(financeAccountService)
 	billParticularsController := controllers.NewBillParticularsController(billParticularService)
 	billsController := controllers.NewBillsController(billService)
 	chatController := controllers.NewChatController(chatService)
-	financeReportsController := controllers.NewFinanceReportsController(financeReportsService)
+	financeReportsController := controllers.NewFinanceReportsController(financeReportsService, FinanceExportService)
 	smsController := controllers.NewSmsController(smsService, db)
 	activityLogsController := controllers.NewActivityLogsController(activityLogService)
-	schoolBillsController := controllers.NewSchoolBillsController(schoolBillService)
+	schoolBillsController := controllers.NewSchoolBillsController(schoolBillService, billService)
 	schoolPaymentsController := controllers.NewSchoolPaymentsController(schoolBillService, momoPaymentService, PaymentWorker)
+	financeExpensesController := controllers.NewFinanceExpensesController(financeExpenseService)
+	ledgerController := controllers.NewLedgerController(ledgerService)
+	budgetsController := controllers.NewBudgetsController(budgetService)
+	remittancesController := controllers.NewRemittancesController(remittanceService, financeReportsService)
+	bankAccountsController := controllers.NewBankAccountsController(bankAccountService, bankReconciliationService)
+	fiscalPeriodsController := controllers.NewFiscalPeriodsController(fiscalPeriodService)
+	membershipApplicationsController := controllers.NewMembershipApplicationsController(membershipApplicationService)
+	schoolTransfersController := controllers.NewSchoolTransfersController(schoolTransferService)
+	membershipCertificatesController := controllers.NewMembershipCertificatesController(membershipCertificateService)
+	censusController := controllers.NewCensusController(censusService)
+	memberNoSchemesController := controllers.NewMemberNoSchemesController(memberNoSchemeService)
+	executiveTermsController := controllers.NewExecutiveTermsController(executiveTermService)
 
 	// Register refactored controllers (these will override the old ones)
 	controllers.RegisterController("events", eventsController)
@@ -128,4 +204,16 @@ func InitializeControllers(db *gorm.DB) {
 	controllers.RegisterController("activity_logs", activityLogsController)
 	controllers.RegisterController("school-bills", schoolBillsController)
 	controllers.RegisterController("school-payments", schoolPaymentsController)
+	controllers.RegisterController("finance-expenses", financeExpensesController)
+	controllers.RegisterController("ledger", ledgerController)
+	controllers.RegisterController("budgets", budgetsController)
+	controllers.RegisterController("remittances", remittancesController)
+	controllers.RegisterController("bank-accounts", bankAccountsController)
+	controllers.RegisterController("fiscal-periods", fiscalPeriodsController)
+	controllers.RegisterController("membership-applications", membershipApplicationsController)
+	controllers.RegisterController("school-transfers", schoolTransfersController)
+	controllers.RegisterController("membership-certificates", membershipCertificatesController)
+	controllers.RegisterController("census", censusController)
+	controllers.RegisterController("member-no-schemes", memberNoSchemesController)
+	controllers.RegisterController("executive-terms", executiveTermsController)
 }
@@ -0,0 +1,503 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type BankAccountsController struct {
+	bankAccountService        *services.BankAccountService
+	bankReconciliationService *services.BankReconciliationService
+}
+
+func NewBankAccountsController(bankAccountService *services.BankAccountService, bankReconciliationService *services.BankReconciliationService) *BankAccountsController {
+	return &BankAccountsController{
+		bankAccountService:        bankAccountService,
+		bankReconciliationService: bankReconciliationService,
+	}
+}
+
+func (b *BankAccountsController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return b.list(c)
+	case "show":
+		return b.show(c)
+	case "create":
+		return b.create(c)
+	case "update":
+		return b.update(c)
+	case "delete":
+		return b.delete(c)
+	case "tag-transaction":
+		return b.tagTransaction(c)
+	case "import-statement":
+		return b.importStatement(c)
+	case "statements":
+		return b.statements(c)
+	case "delete-statement":
+		return b.deleteStatement(c)
+	case "lines":
+		return b.lines(c)
+	case "auto-match":
+		return b.autoMatch(c)
+	case "match":
+		return b.match(c)
+	case "exclude":
+		return b.exclude(c)
+	case "unmatch":
+		return b.unmatch(c)
+	case "reconciliation":
+		return b.reconciliation(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+func (b *BankAccountsController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if accountType := c.Query("account_type"); accountType != "" {
+		filters["account_type"] = accountType
+	}
+	if isActive := c.Query("is_active"); isActive != "" {
+		filters["is_active"] = isActive == "true"
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	accounts, total, err := b.bankAccountService.ListBankAccountsWithOwner(filters, page, limit, ownerCtx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve bank accounts",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": accounts,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (b *BankAccountsController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	accountId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	account, err := b.bankAccountService.GetBankAccountByIDWithOwner(accountId, ownerCtx)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Bank account not found or access denied"})
+	}
+
+	return c.JSON(fiber.Map{"data": account})
+}
+
+func (b *BankAccountsController) create(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var account models.BankAccount
+	if err := c.BodyParser(&account); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := b.bankAccountService.CreateBankAccountWithOwner(&account, ownerCtx); err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Bank account created successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Bank account created successfully",
+			"type": "success",
+		},
+		"data": account,
+	})
+}
+
+func (b *BankAccountsController) update(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	accountId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var updateData models.BankAccount
+	if err := c.BodyParser(&updateData); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	// is_active is only changed when sent, since the zero value would deactivate the account
+	var body struct {
+		IsActive *bool `json:"is_active"`
+	}
+	_ = c.BodyParser(&body)
+
+	if err := b.bankAccountService.UpdateBankAccountWithOwner(accountId, &updateData, body.IsActive, ownerCtx); err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	account, _ := b.bankAccountService.GetBankAccountByIDWithOwner(accountId, ownerCtx)
+
+	return c.JSON(fiber.Map{
+		"message": "Bank account updated successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Bank account updated successfully",
+			"type": "success",
+		},
+		"data": account,
+	})
+}
+
+func (b *BankAccountsController) delete(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	accountId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := b.bankAccountService.DeleteBankAccountWithOwner(accountId, ownerCtx); err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Bank account deleted successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Bank account deleted successfully",
+			"type": "success",
+		},
+	})
+}
+
+// tagTransaction sets the account a finance transaction (id) was received into;
+// a null bank_account_id marks it as cash
+func (b *BankAccountsController) tagTransaction(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	transactionId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		BankAccountId *int64 `json:"bank_account_id"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := b.bankAccountService.TagTransaction(transactionId, body.BankAccountId, ownerCtx); err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Transaction account updated",
+		"flash_message": fiber.Map{
+			"msg":  "Transaction account updated",
+			"type": "success",
+		},
+	})
+}
+
+// importStatement uploads a CSV or XLSX statement (form field "file") for the account (id),
+// with an optional closing_balance
+func (b *BankAccountsController) importStatement(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	accountId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "No file uploaded")
+	}
+
+	var closingBalance *float64
+	if value := c.FormValue("closing_balance"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid closing_balance"})
+		}
+		closingBalance = &parsed
+	}
+
+	result, err := b.bankReconciliationService.ImportStatement(accountId, file, closingBalance, auditUserID(c), ownerCtx)
+	if err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	message := fmt.Sprintf("Imported %d statement lines, %d matched", result.Imported, result.Matched)
+	return c.Status(201).JSON(fiber.Map{
+		"message": message,
+		"flash_message": fiber.Map{
+			"msg":  message,
+			"type": "success",
+		},
+		"data": result,
+	})
+}
+
+func (b *BankAccountsController) statements(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	accountId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	statements, total, err := b.bankReconciliationService.ListStatements(accountId, page, limit, ownerCtx)
+	if err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": statements,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (b *BankAccountsController) deleteStatement(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	statementId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := b.bankReconciliationService.DeleteStatement(statementId, ownerCtx); err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Bank statement deleted successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Bank statement deleted successfully",
+			"type": "success",
+		},
+	})
+}
+
+// lines lists the account's statement lines, optionally filtered by status or bank_statement_id
+func (b *BankAccountsController) lines(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	accountId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if statementId := c.Query("bank_statement_id"); statementId != "" {
+		filters["bank_statement_id"] = statementId
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	lines, total, err := b.bankReconciliationService.ListLines(accountId, filters, page, limit, ownerCtx)
+	if err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": lines,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (b *BankAccountsController) autoMatch(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	accountId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	matched, err := b.bankReconciliationService.AutoMatch(accountId, ownerCtx)
+	if err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	message := fmt.Sprintf("%d statement lines matched", matched)
+	return c.JSON(fiber.Map{
+		"message": message,
+		"flash_message": fiber.Map{
+			"msg":  message,
+			"type": "success",
+		},
+		"data": fiber.Map{"matched": matched},
+	})
+}
+
+// match matches a statement line (id) to a cash-book entry given by entry_type
+// (FinanceTransaction or FinanceExpense) and entry_id
+func (b *BankAccountsController) match(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	lineId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		EntryType string `json:"entry_type"`
+		EntryId   uint   `json:"entry_id"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := b.bankReconciliationService.MatchLine(lineId, body.EntryType, body.EntryId, auditUserID(c), ownerCtx); err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Statement line matched",
+		"flash_message": fiber.Map{
+			"msg":  "Statement line matched",
+			"type": "success",
+		},
+	})
+}
+
+func (b *BankAccountsController) exclude(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	lineId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Note string `json:"note"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := b.bankReconciliationService.ExcludeLine(lineId, body.Note, auditUserID(c), ownerCtx); err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Statement line excluded",
+		"flash_message": fiber.Map{
+			"msg":  "Statement line excluded",
+			"type": "success",
+		},
+	})
+}
+
+func (b *BankAccountsController) unmatch(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	lineId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := b.bankReconciliationService.UnmatchLine(lineId, ownerCtx); err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Statement line unmatched",
+		"flash_message": fiber.Map{
+			"msg":  "Statement line unmatched",
+			"type": "success",
+		},
+	})
+}
+
+// reconciliation returns the account's book, statement and reconciled balances as of as_of
+// (YYYY-MM-DD) with unreconciled items
+func (b *BankAccountsController) reconciliation(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	accountId, err := bankAccountIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	report, err := b.bankReconciliationService.GetReconciliation(accountId, c.Query("as_of"), ownerCtx)
+	if err != nil {
+		return bankAccountErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": report})
+}
+
+func bankAccountIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	parsed, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(parsed), nil
+}
+
+func bankAccountErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case financeAccountSystemAdminError:
+		return utils.ForbiddenResponse(c, err.Error())
+	case "bank account not found", "bank statement not found", "statement line not found",
+		"finance transaction not found", "record not found":
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
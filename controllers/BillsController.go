@@ -358,8 +358,27 @@ func (b *BillsController) updateItem(c *fiber.Ctx) error {
 	if updateData.Amount != nil {
 		updates["amount"] = *updateData.Amount
 	}
+	if updateData.RegionIds != nil {
+		updates["region_ids"] = *updateData.RegionIds
+	}
+	if updateData.ZoneIds != nil {
+		updates["zone_ids"] = *updateData.ZoneIds
+	}
+	if updateData.SchoolGroupIds != nil {
+		updates["school_group_ids"] = *updateData.SchoolGroupIds
+	}
+	if updateData.SchoolIds != nil {
+		updates["school_ids"] = *updateData.SchoolIds
+	}
+
+	// Optional reason shown to schools whose bills are revised
+	var revisionData struct {
+		RevisionReason string `json:"revision_reason"`
+	}
+	c.BodyParser(&revisionData)
 
-	if err := b.billService.UpdateBillItem(uint(itemId), updates); err != nil {
+	revisions, err := b.billService.UpdateBillItem(uint(itemId), updates, auditUserID(c), revisionData.RevisionReason)
+	if err != nil {
 		if err.Error() == "bill item not found" {
 			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -375,7 +394,8 @@ func (b *BillsController) updateItem(c *fiber.Ctx) error {
 			"msg":  "Bill item updated successfully",
 			"type": "success",
 		},
-		"data": billItem,
+		"data":      billItem,
+		"revisions": revisions,
 	})
 }
 
@@ -394,7 +414,7 @@ func (b *BillsController) deleteItem(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "invalid ID"})
 	}
 
-	if err := b.billService.DeleteBillItem(uint(itemId)); err != nil {
+	if err := b.billService.DeleteBillItem(uint(itemId), auditUserID(c)); err != nil {
 		if err.Error() == "bill item not found" {
 			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -409,3 +429,13 @@ func (b *BillsController) deleteItem(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// auditUserID returns the authenticated user's ID for audit fields, or nil if unavailable
+func auditUserID(c *fiber.Ctx) *int64 {
+	userId, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return nil
+	}
+	id := int64(userId)
+	return &id
+}
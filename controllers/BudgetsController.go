@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type BudgetsController struct {
+	budgetService *services.BudgetService
+}
+
+func NewBudgetsController(budgetService *services.BudgetService) *BudgetsController {
+	return &BudgetsController{
+		budgetService: budgetService,
+	}
+}
+
+func (b *BudgetsController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return b.list(c)
+	case "show":
+		return b.show(c)
+	case "create":
+		return b.create(c)
+	case "update":
+		return b.update(c)
+	case "delete":
+		return b.delete(c)
+	case "variance":
+		return b.variance(c)
+	case "alerts":
+		return b.alerts(c)
+	case "acknowledge-alert":
+		return b.acknowledgeAlert(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+func (b *BudgetsController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if fiscalYear := c.Query("fiscal_year"); fiscalYear != "" {
+		filters["fiscal_year"] = fiscalYear
+	}
+	if accountId := c.Query("finance_account_id"); accountId != "" {
+		filters["finance_account_id"] = accountId
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	budgets, total, err := b.budgetService.ListBudgetsWithOwner(filters, page, limit, ownerCtx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve budgets",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": budgets,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (b *BudgetsController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	budgetId, err := budgetIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	budget, err := b.budgetService.GetBudgetByIDWithOwner(budgetId, ownerCtx)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Budget not found or access denied"})
+	}
+
+	return c.JSON(fiber.Map{"data": budget})
+}
+
+func (b *BudgetsController) create(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var budget models.Budget
+	if err := c.BodyParser(&budget); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := b.budgetService.CreateBudgetWithOwner(&budget, auditUserID(c), ownerCtx); err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Budget created successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Budget created successfully",
+			"type": "success",
+		},
+		"data": budget,
+	})
+}
+
+func (b *BudgetsController) update(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	budgetId, err := budgetIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var updateData models.Budget
+	if err := c.BodyParser(&updateData); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := b.budgetService.UpdateBudgetWithOwner(budgetId, &updateData, ownerCtx); err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	budget, _ := b.budgetService.GetBudgetByIDWithOwner(budgetId, ownerCtx)
+
+	return c.JSON(fiber.Map{
+		"message": "Budget updated successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Budget updated successfully",
+			"type": "success",
+		},
+		"data": budget,
+	})
+}
+
+func (b *BudgetsController) delete(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	budgetId, err := budgetIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := b.budgetService.DeleteBudgetWithOwner(budgetId, ownerCtx); err != nil {
+		return budgetErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Budget deleted successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Budget deleted successfully",
+			"type": "success",
+		},
+	})
+}
+
+// variance returns budget vs actual for a fiscal year, or one month of it when month is given
+func (b *BudgetsController) variance(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	fiscalYear, _ := strconv.Atoi(c.Query("fiscal_year", strconv.Itoa(time.Now().Year())))
+	month, _ := strconv.Atoi(c.Query("month", "0"))
+
+	report, err := b.budgetService.GetVarianceReportWithOwner(fiscalYear, month, ownerCtx)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": report})
+}
+
+// alerts lists budget threshold alerts, optionally filtered by acknowledged=true|false
+func (b *BudgetsController) alerts(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var acknowledged *bool
+	if value := c.Query("acknowledged"); value != "" {
+		parsed := value == "true"
+		acknowledged = &parsed
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	alerts, total, err := b.budgetService.ListAlertsWithOwner(acknowledged, page, limit, ownerCtx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve budget alerts",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": alerts,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (b *BudgetsController) acknowledgeAlert(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	alertId, err := budgetIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := b.budgetService.AcknowledgeAlertWithOwner(alertId, auditUserID(c), ownerCtx); err != nil {
+		if err.Error() == financeAccountSystemAdminError {
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		return c.Status(404).JSON(fiber.Map{"error": "Budget alert not found or access denied"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Budget alert acknowledged",
+		"flash_message": fiber.Map{
+			"msg":  "Budget alert acknowledged",
+			"type": "success",
+		},
+	})
+}
+
+func budgetIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	budgetId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(budgetId), nil
+}
+
+func budgetErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case financeAccountSystemAdminError:
+		return utils.ForbiddenResponse(c, err.Error())
+	case "budget not found", "record not found":
+		return c.Status(404).JSON(fiber.Map{"error": "Budget not found or access denied"})
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,389 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
+)
+
+type CensusController struct {
+	censusService *services.CensusService
+}
+
+func NewCensusController(censusService *services.CensusService) *CensusController {
+	return &CensusController{
+		censusService: censusService,
+	}
+}
+
+func (cc *CensusController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "fields":
+		return cc.fields(c)
+	case "create-field":
+		return cc.createField(c)
+	case "update-field":
+		return cc.updateField(c)
+	case "list":
+		return cc.list(c)
+	case "show":
+		return cc.show(c)
+	case "save":
+		return cc.save(c, false)
+	case "submit":
+		return cc.save(c, true)
+	case "verify":
+		return cc.verify(c)
+	case "return":
+		return cc.returnCensus(c)
+	case "years":
+		return cc.years(c)
+	case "summary":
+		return cc.summary(c)
+	case "trends":
+		return cc.trends(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// fields returns the census form; include_inactive=true adds retired fields
+func (cc *CensusController) fields(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	fields, err := cc.censusService.ListFields(c.Query("include_inactive") == "true", ownerCtx)
+	if err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": fields})
+}
+
+func (cc *CensusController) createField(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var field models.CensusField
+	if err := c.BodyParser(&field); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := cc.censusService.CreateField(&field, ownerCtx); err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Census field created",
+		"flash_message": fiber.Map{
+			"msg":  fmt.Sprintf("%s added to the census form", field.Label),
+			"type": "success",
+		},
+		"data": field,
+	})
+}
+
+func (cc *CensusController) updateField(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	fieldId, err := censusIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		FieldKey   *string         `json:"field_key"`
+		Label      *string         `json:"label"`
+		Section    *string         `json:"section"`
+		FieldType  *string         `json:"field_type"`
+		Options    *datatypes.JSON `json:"options"`
+		Unit       *string         `json:"unit"`
+		IsRequired *bool           `json:"is_required"`
+		Position   *int            `json:"position"`
+		IsActive   *bool           `json:"is_active"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	updates := make(map[string]interface{})
+	if body.FieldKey != nil {
+		updates["field_key"] = *body.FieldKey
+	}
+	if body.Label != nil {
+		updates["label"] = *body.Label
+	}
+	if body.Section != nil {
+		updates["section"] = body.Section
+	}
+	if body.FieldType != nil {
+		updates["field_type"] = *body.FieldType
+	}
+	if body.Options != nil {
+		updates["options"] = body.Options
+	}
+	if body.Unit != nil {
+		updates["unit"] = body.Unit
+	}
+	if body.IsRequired != nil {
+		updates["is_required"] = *body.IsRequired
+	}
+	if body.Position != nil {
+		updates["position"] = *body.Position
+	}
+	if body.IsActive != nil {
+		updates["is_active"] = *body.IsActive
+	}
+
+	if len(updates) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "No fields to update"})
+	}
+
+	if err := cc.censusService.UpdateField(fieldId, updates, ownerCtx); err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Census field updated",
+		"flash_message": fiber.Map{
+			"msg":  "Census field updated",
+			"type": "success",
+		},
+	})
+}
+
+// list returns census returns of the schools in the user's zones, optionally for one academic year,
+// school or status
+func (cc *CensusController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if academicYear := c.Query("academic_year"); academicYear != "" {
+		filters["academic_year"] = academicYear
+	}
+	if schoolID := c.Query("school_id"); schoolID != "" {
+		filters["school_id"] = schoolID
+	}
+	if status := c.Query("status"); status != "" && status != "all" {
+		filters["status"] = status
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	censuses, total, err := cc.censusService.ListCensusesWithRole(filters, page, limit, ownerCtx)
+	if err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": censuses,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// show returns a census by id, or a school's census for an academic year (school_id and
+// academic_year; school admins may leave out school_id)
+func (cc *CensusController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	if academicYear := c.Query("academic_year"); academicYear != "" {
+		schoolId, _ := strconv.ParseUint(c.Query("school_id"), 10, 64)
+		census, err := cc.censusService.GetCensus(uint(schoolId), academicYear, ownerCtx)
+		if err != nil {
+			return censusErrorResponse(c, err)
+		}
+		return c.JSON(fiber.Map{"data": census})
+	}
+
+	censusId, err := censusIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	census, err := cc.censusService.GetCensusByID(censusId, ownerCtx)
+	if err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": census})
+}
+
+// save stores a school's census answers for an academic year; submit also sends them to the zone
+// for verification
+func (cc *CensusController) save(c *fiber.Ctx, submit bool) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var body struct {
+		SchoolId     uint                   `json:"school_id"`
+		AcademicYear string                 `json:"academic_year"`
+		Data         map[string]interface{} `json:"data"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	census, err := cc.censusService.SaveCensus(body.SchoolId, body.AcademicYear, body.Data, submit, auditUserID(c), ownerCtx)
+	if err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	msg := fmt.Sprintf("Census for %s saved", census.AcademicYear)
+	if submit {
+		msg = fmt.Sprintf("Census for %s submitted for verification", census.AcademicYear)
+	}
+	return c.JSON(fiber.Map{
+		"message": msg,
+		"flash_message": fiber.Map{
+			"msg":  msg,
+			"type": "success",
+		},
+		"data": census,
+	})
+}
+
+func (cc *CensusController) verify(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	censusId, err := censusIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Notes *string `json:"notes"`
+	}
+	_ = c.BodyParser(&body)
+
+	if err := cc.censusService.VerifyCensus(censusId, body.Notes, auditUserID(c), ownerCtx); err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Census verified",
+		"flash_message": fiber.Map{
+			"msg":  "Census verified",
+			"type": "success",
+		},
+	})
+}
+
+// returnCensus sends a census back to the school with notes on what to correct
+func (cc *CensusController) returnCensus(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	censusId, err := censusIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.BodyParser(&body)
+
+	if err := cc.censusService.ReturnCensus(censusId, body.Notes, ownerCtx); err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Census returned to the school",
+		"flash_message": fiber.Map{
+			"msg":  "Census returned to the school for corrections",
+			"type": "success",
+		},
+	})
+}
+
+func (cc *CensusController) years(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	years, err := cc.censusService.ListYears(ownerCtx)
+	if err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": years})
+}
+
+// summary reports an academic year's census; group_by is national, region or zone and
+// include_unverified=true also counts submitted returns
+func (cc *CensusController) summary(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	regionID, zoneID := censusScopeParams(c)
+	report, err := cc.censusService.Summary(c.Query("academic_year"), c.Query("group_by"), regionID, zoneID, c.Query("include_unverified") == "true", ownerCtx)
+	if err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": report})
+}
+
+// trends compares the census across academic years for the country, a region or a zone
+func (cc *CensusController) trends(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	regionID, zoneID := censusScopeParams(c)
+	trend, err := cc.censusService.Trends(regionID, zoneID, c.Query("include_unverified") == "true", ownerCtx)
+	if err != nil {
+		return censusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": trend})
+}
+
+func censusScopeParams(c *fiber.Ctx) (regionID, zoneID *int64) {
+	if value, err := strconv.ParseInt(c.Query("region_id"), 10, 64); err == nil {
+		regionID = &value
+	}
+	if value, err := strconv.ParseInt(c.Query("zone_id"), 10, 64); err == nil {
+		zoneID = &value
+	}
+	return regionID, zoneID
+}
+
+func censusIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	censusId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(censusId), nil
+}
+
+func censusErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "census not found", "census field not found", "school not found":
+		return utils.NotFoundResponse(c, err.Error())
+	case "census has already been submitted", "census has been verified", "census is not awaiting verification", "field_key already exists":
+		return utils.ConflictResponse(c, err.Error())
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -4,18 +4,24 @@ import (
 	"fmt"
 	"gnaps-api/models"
 	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type DashboardController struct {
-	dashboardService *services.DashboardService
+	dashboardService        *services.DashboardService
+	financeReportsService   *services.FinanceReportsService
+	financeAnalyticsService *services.FinanceAnalyticsService
 }
 
 // NewDashboardController creates a new instance of DashboardController
-func NewDashboardController(dashboardService *services.DashboardService) *DashboardController {
+func NewDashboardController(dashboardService *services.DashboardService, financeReportsService *services.FinanceReportsService, financeAnalyticsService *services.FinanceAnalyticsService) *DashboardController {
 	return &DashboardController{
-		dashboardService: dashboardService,
+		dashboardService:        dashboardService,
+		financeReportsService:   financeReportsService,
+		financeAnalyticsService: financeAnalyticsService,
 	}
 }
 
@@ -26,6 +32,8 @@ func (d *DashboardController) Handle(action string, c *fiber.Ctx) error {
 		return d.stats(c)
 	case "overview":
 		return d.overview(c)
+	case "collections":
+		return d.collections(c)
 	default:
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": fmt.Sprintf("unknown action %s", action),
@@ -154,3 +162,35 @@ func (d *DashboardController) overview(c *fiber.Ctx) error {
 
 	return c.JSON(overview)
 }
+
+// collections returns net collections per day, week or month for the caller's scope, optionally
+// split by payment_mode, network, region, zone or bill, with the previous period for comparison
+// and the collection rate of school bills in scope
+func (d *DashboardController) collections(c *fiber.Ctx) error {
+	regionID, _ := strconv.ParseInt(c.Query("region_id"), 10, 64)
+	zoneID, _ := strconv.ParseInt(c.Query("zone_id"), 10, 64)
+	scope, err := d.financeReportsService.ResolveReportScope(regionID, zoneID, utils.GetOwnerContext(c))
+	if err != nil {
+		return reportScopeError(c, err)
+	}
+
+	billID, _ := strconv.ParseInt(c.Query("bill_id"), 10, 64)
+	series, err := d.financeAnalyticsService.GetCollectionSeries(scope, services.CollectionSeriesRequest{
+		Interval:    c.Query("interval"),
+		GroupBy:     c.Query("group_by"),
+		FromDate:    c.Query("from_date"),
+		ToDate:      c.Query("to_date"),
+		FinanceType: c.Query("finance_type"),
+		BillId:      billID,
+		PaymentMode: c.Query("payment_mode"),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": series,
+	})
+}
@@ -223,6 +223,9 @@ func (e *EventsController) update(c *fiber.Ctx) error {
 	if updates.Price != nil {
 		updateMap["price"] = updates.Price
 	}
+	if updates.MemberPrice != nil {
+		updateMap["member_price"] = updates.MemberPrice
+	}
 	if updates.MaxAttendees != nil {
 		updateMap["max_attendees"] = updates.MaxAttendees
 	}
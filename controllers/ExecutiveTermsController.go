@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ExecutiveTermsController struct {
+	executiveTermService *services.ExecutiveTermService
+}
+
+func NewExecutiveTermsController(executiveTermService *services.ExecutiveTermService) *ExecutiveTermsController {
+	return &ExecutiveTermsController{
+		executiveTermService: executiveTermService,
+	}
+}
+
+func (e *ExecutiveTermsController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return e.list(c)
+	case "show":
+		return e.show(c)
+	case "start":
+		return e.start(c)
+	case "update":
+		return e.update(c)
+	case "end":
+		return e.end(c)
+	case "holders":
+		return e.holders(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// list returns terms of office, optionally of one executive_id, position_id, level, region_id,
+// zone_id or status; current=true keeps only the terms in force today
+func (e *ExecutiveTermsController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	for _, key := range []string{"executive_id", "position_id", "level", "region_id", "zone_id", "status"} {
+		if value := c.Query(key); value != "" && value != "all" {
+			filters[key] = value
+		}
+	}
+	if c.Query("current") == "true" {
+		filters["current"] = true
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	terms, total, err := e.executiveTermService.ListTerms(filters, page, limit, ownerCtx)
+	if err != nil {
+		return executiveTermErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": terms,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (e *ExecutiveTermsController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	termId, err := executiveTermIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	term, err := e.executiveTermService.GetTerm(termId, ownerCtx)
+	if err != nil {
+		return executiveTermErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": term})
+}
+
+// start puts an executive in a position; reassign=true ends their other active terms
+func (e *ExecutiveTermsController) start(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var body services.StartTermRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	term, err := e.executiveTermService.StartTerm(body, auditUserID(c), ownerCtx)
+	if err != nil {
+		return executiveTermErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Term of office started",
+		"flash_message": fiber.Map{
+			"msg":  "Term of office started",
+			"type": "success",
+		},
+		"data": term,
+	})
+}
+
+// update corrects a term's start_date, end_date (empty for open-ended) or notes
+func (e *ExecutiveTermsController) update(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	termId, err := executiveTermIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		StartDate *string `json:"start_date"`
+		EndDate   *string `json:"end_date"`
+		Notes     *string `json:"notes"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	term, err := e.executiveTermService.UpdateTerm(termId, body.StartDate, body.EndDate, body.Notes, ownerCtx)
+	if err != nil {
+		return executiveTermErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Term of office updated",
+		"flash_message": fiber.Map{
+			"msg":  "Term of office updated",
+			"type": "success",
+		},
+		"data": term,
+	})
+}
+
+// end ends a term early (ended_on, default today) for an end_reason; the executive loses the access
+// it gave at once
+func (e *ExecutiveTermsController) end(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	termId, err := executiveTermIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		EndedOn   string  `json:"ended_on"`
+		EndReason string  `json:"end_reason"`
+		Notes     *string `json:"notes"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := e.executiveTermService.EndTerm(termId, body.EndedOn, body.EndReason, body.Notes, auditUserID(c), ownerCtx); err != nil {
+		return executiveTermErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Term of office ended",
+		"flash_message": fiber.Map{
+			"msg":  "Term of office ended",
+			"type": "success",
+		},
+	})
+}
+
+// holders returns the past and present holders of a position (position_id) at a level, in a
+// region (region_id) or zone (zone_id) for region and zone positions
+func (e *ExecutiveTermsController) holders(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	positionID, err := strconv.ParseInt(c.Query("position_id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "position_id is required"})
+	}
+	var regionID, zoneID *int64
+	if value, err := strconv.ParseInt(c.Query("region_id"), 10, 64); err == nil {
+		regionID = &value
+	}
+	if value, err := strconv.ParseInt(c.Query("zone_id"), 10, 64); err == nil {
+		zoneID = &value
+	}
+
+	terms, err := e.executiveTermService.Holders(positionID, c.Query("level"), regionID, zoneID, ownerCtx)
+	if err != nil {
+		return executiveTermErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": terms})
+}
+
+func executiveTermIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	termId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(termId), nil
+}
+
+func executiveTermErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "term not found", "executive not found", "position not found", "region not found", "zone not found":
+		return utils.NotFoundResponse(c, err.Error())
+	case "term has already ended", "executive already holds this position":
+		return utils.ConflictResponse(c, err.Error())
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -6,17 +6,20 @@ import (
 	"gnaps-api/services"
 	"gnaps-api/utils"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type ExecutivesController struct {
 	executiveService *services.ExecutiveService
+	orgChartService  *services.OrgChartService
 }
 
-func NewExecutivesController(executiveService *services.ExecutiveService) *ExecutivesController {
+func NewExecutivesController(executiveService *services.ExecutiveService, orgChartService *services.OrgChartService) *ExecutivesController {
 	return &ExecutivesController{
 		executiveService: executiveService,
+		orgChartService:  orgChartService,
 	}
 }
 
@@ -32,6 +35,14 @@ func (e *ExecutivesController) Handle(action string, c *fiber.Ctx) error {
 		return e.update(c)
 	case "delete":
 		return e.delete(c)
+	case "invites":
+		return e.invites(c)
+	case "resend-invite":
+		return e.resendInvite(c)
+	case "revoke-invite":
+		return e.revokeInvite(c)
+	case "org-chart":
+		return e.orgChart(c)
 	default:
 		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
 	}
@@ -153,7 +164,8 @@ func (e *ExecutivesController) create(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := e.executiveService.CreateExecutive(&executive); err != nil {
+	delivery, err := e.executiveService.CreateExecutive(&executive, auditUserID(c))
+	if err != nil {
 		if err.Error() == "executive with this executive_no already exists" {
 			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
 		}
@@ -167,12 +179,10 @@ func (e *ExecutivesController) create(c *fiber.Ctx) error {
 	}
 
 	return c.Status(201).JSON(fiber.Map{
-		"message": "Executive created successfully",
-		"flash_message": fiber.Map{
-			"msg":  "Executive created successfully",
-			"type": "success",
-		},
-		"data": executive,
+		"message":       "Executive created successfully",
+		"flash_message": inviteFlashMessage("Executive created successfully", delivery),
+		"data":          executive,
+		"invite":        delivery,
 	})
 }
 
@@ -298,3 +308,133 @@ func (e *ExecutivesController) delete(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// invites returns the invites sent to an executive
+func (e *ExecutivesController) invites(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	executiveId, err := executiveIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	invites, err := e.executiveService.ListInvites(executiveId, ownerCtx)
+	if err != nil {
+		return executiveInviteErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": invites})
+}
+
+// resendInvite sends the executive a new invite link; earlier links stop working
+func (e *ExecutivesController) resendInvite(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	executiveId, err := executiveIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	delivery, err := e.executiveService.ResendInvite(executiveId, auditUserID(c), ownerCtx)
+	if err != nil {
+		return executiveInviteErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "Invite sent",
+		"flash_message": inviteFlashMessage("Invite sent", delivery),
+		"data":          delivery,
+	})
+}
+
+func (e *ExecutivesController) revokeInvite(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	executiveId, err := executiveIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := e.executiveService.RevokeInvite(executiveId, auditUserID(c), ownerCtx); err != nil {
+		return executiveInviteErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Invite revoked",
+		"flash_message": fiber.Map{
+			"msg":  "Invite revoked; the link can no longer be used",
+			"type": "success",
+		},
+	})
+}
+
+// inviteFlashMessage warns when the invite could not be sent by SMS or email
+func inviteFlashMessage(msg string, delivery *services.InviteDelivery) fiber.Map {
+	if delivery == nil || len(delivery.Channels) == 0 {
+		return fiber.Map{
+			"msg":  msg + ", but the invite could not be sent by SMS or email. Check the contact details and resend it.",
+			"type": "warning",
+		}
+	}
+	return fiber.Map{
+		"msg":  fmt.Sprintf("%s; invite sent by %s", msg, strings.Join(delivery.Channels, " and ")),
+		"type": "success",
+	}
+}
+
+func executiveIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	executiveId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(executiveId), nil
+}
+
+// orgChart returns the current leadership as a tree of national, region and zone executives with
+// vacant positions; region_id limits it to one region and its zones
+func (e *ExecutivesController) orgChart(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var regionID *int64
+	if value := c.Query("region_id"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid region_id"})
+		}
+		regionID = &parsed
+	}
+
+	chart, err := e.orgChartService.GetOrgChart(regionID, ownerCtx)
+	if err != nil {
+		switch err.Error() {
+		case "access denied":
+			return utils.ForbiddenResponse(c, err.Error())
+		case "region not found":
+			return utils.NotFoundResponse(c, err.Error())
+		}
+		return utils.ServerErrorResponse(c, "Failed to load organisational chart")
+	}
+
+	return c.JSON(fiber.Map{"data": chart})
+}
+
+func executiveInviteErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "executive not found":
+		return utils.NotFoundResponse(c, err.Error())
+	case "executive has no pending invite", "executive has already set their password":
+		return utils.ConflictResponse(c, err.Error())
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -32,6 +32,8 @@ func (f *FinanceAccountsController) Handle(action string, c *fiber.Ctx) error {
 		return f.update(c)
 	case "delete":
 		return f.delete(c)
+	case "tree":
+		return f.tree(c)
 	// Owner-based actions
 	case "ownerList":
 		return f.ownerList(c)
@@ -66,6 +68,9 @@ func (f *FinanceAccountsController) list(c *fiber.Ctx) error {
 	if accountType := c.Query("account_type"); accountType != "" {
 		filters["account_type"] = accountType
 	}
+	if parentId := c.Query("parent_id"); parentId != "" {
+		filters["parent_id"] = parentId
+	}
 
 	// Pagination
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -194,6 +199,12 @@ func (f *FinanceAccountsController) update(c *fiber.Ctx) error {
 	if updateData.ApproverId != nil {
 		updates["approver_id"] = *updateData.ApproverId
 	}
+	if updateData.ParentId != nil {
+		updates["parent_id"] = *updateData.ParentId
+	}
+	if updateData.IsHeader != nil {
+		updates["is_header"] = *updateData.IsHeader
+	}
 
 	if err := f.accountService.UpdateAccountWithOwner(uint(accountId), updates, ownerCtx); err != nil {
 		if err.Error() == financeAccountSystemAdminError {
@@ -202,7 +213,10 @@ func (f *FinanceAccountsController) update(c *fiber.Ctx) error {
 		if err.Error() == "finance account with this code already exists" {
 			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
 		}
-		return c.Status(404).JSON(fiber.Map{"error": "Finance account not found or access denied"})
+		if err.Error() == "finance account not found" || err.Error() == "record not found" {
+			return c.Status(404).JSON(fiber.Map{"error": "Finance account not found or access denied"})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	// Get updated account
@@ -239,6 +253,9 @@ func (f *FinanceAccountsController) delete(c *fiber.Ctx) error {
 		if err.Error() == financeAccountSystemAdminError {
 			return utils.ForbiddenResponse(c, err.Error())
 		}
+		if err.Error() == "finance account has sub-accounts; move or delete them first" {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(404).JSON(fiber.Map{"error": "Finance account not found or access denied"})
 	}
 
@@ -251,6 +268,26 @@ func (f *FinanceAccountsController) delete(c *fiber.Ctx) error {
 	})
 }
 
+// tree returns the chart of accounts as headings with nested sub-accounts
+func (f *FinanceAccountsController) tree(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if accountType := c.Query("account_type"); accountType != "" {
+		filters["account_type"] = accountType
+	}
+
+	tree, err := f.accountService.GetAccountTreeWithOwner(filters, ownerCtx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve chart of accounts",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{"data": tree})
+}
+
 // ============================================
 // Owner-based methods for data filtering
 // ============================================
@@ -273,6 +310,9 @@ func (f *FinanceAccountsController) ownerList(c *fiber.Ctx) error {
 	if accountType := c.Query("account_type"); accountType != "" {
 		filters["account_type"] = accountType
 	}
+	if parentId := c.Query("parent_id"); parentId != "" {
+		filters["parent_id"] = parentId
+	}
 
 	page, _ := strconv.Atoi(c.Query("page", "1"))
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
@@ -395,6 +435,12 @@ func (f *FinanceAccountsController) ownerUpdate(c *fiber.Ctx) error {
 	if updateData.ApproverId != nil {
 		updates["approver_id"] = *updateData.ApproverId
 	}
+	if updateData.ParentId != nil {
+		updates["parent_id"] = *updateData.ParentId
+	}
+	if updateData.IsHeader != nil {
+		updates["is_header"] = *updateData.IsHeader
+	}
 
 	if err := f.accountService.UpdateAccountWithOwner(uint(accountId), updates, ownerCtx); err != nil {
 		if err.Error() == financeAccountSystemAdminError {
@@ -403,7 +449,10 @@ func (f *FinanceAccountsController) ownerUpdate(c *fiber.Ctx) error {
 		if err.Error() == "finance account with this code already exists" {
 			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
 		}
-		return c.Status(404).JSON(fiber.Map{"error": "Finance account not found or access denied"})
+		if err.Error() == "finance account not found" || err.Error() == "record not found" {
+			return c.Status(404).JSON(fiber.Map{"error": "Finance account not found or access denied"})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	account, _ := f.accountService.GetAccountByIDWithOwner(uint(accountId), ownerCtx)
@@ -439,6 +488,9 @@ func (f *FinanceAccountsController) ownerDelete(c *fiber.Ctx) error {
 		if err.Error() == financeAccountSystemAdminError {
 			return utils.ForbiddenResponse(c, err.Error())
 		}
+		if err.Error() == "finance account has sub-accounts; move or delete them first" {
+			return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(404).JSON(fiber.Map{"error": "Finance account not found or access denied"})
 	}
 
@@ -0,0 +1,445 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FinanceExpensesController struct {
+	expenseService *services.FinanceExpenseService
+}
+
+func NewFinanceExpensesController(expenseService *services.FinanceExpenseService) *FinanceExpensesController {
+	return &FinanceExpensesController{
+		expenseService: expenseService,
+	}
+}
+
+func (f *FinanceExpensesController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return f.list(c)
+	case "show":
+		return f.show(c)
+	case "create":
+		return f.create(c)
+	case "update":
+		return f.update(c)
+	case "delete":
+		return f.delete(c)
+	case "submit":
+		return f.submit(c)
+	case "approve":
+		return f.approve(c)
+	case "reject":
+		return f.reject(c)
+	case "mark-paid":
+		return f.markPaid(c)
+	case "upload-attachment":
+		return f.uploadAttachment(c)
+	case "delete-attachment":
+		return f.deleteAttachment(c)
+	case "stats":
+		return f.stats(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+func (f *FinanceExpensesController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if search := c.Query("search"); search != "" {
+		filters["search"] = search
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if accountId := c.Query("budget_account_id"); accountId != "" {
+		filters["budget_account_id"] = accountId
+	}
+	if fromDate := c.Query("from_date"); fromDate != "" {
+		filters["from_date"] = fromDate
+	}
+	if toDate := c.Query("to_date"); toDate != "" {
+		filters["to_date"] = toDate
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+
+	expenses, total, err := f.expenseService.ListExpensesWithOwner(filters, page, limit, ownerCtx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve expenses",
+			"details": err.Error(),
+			"flash_message": fiber.Map{
+				"msg":  "Failed to retrieve expenses",
+				"type": "error",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": expenses,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (f *FinanceExpensesController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	expense, err := f.expenseService.GetExpenseByIDWithOwner(expenseId, ownerCtx)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Expense not found or access denied"})
+	}
+
+	return c.JSON(fiber.Map{"data": expense})
+}
+
+// expenseRequest holds the fields of an expense its recorder may set
+type expenseRequest struct {
+	Title           *string   `json:"title"`
+	Description     *string   `json:"description"`
+	Amount          *float64  `json:"amount"`
+	BudgetAccountId *int64    `json:"budget_account_id"`
+	CategoryId      *int64    `json:"category_id"`
+	TransactionDate time.Time `json:"transaction_date"`
+	FollowUp        *bool     `json:"follow_up"`
+}
+
+func (f *FinanceExpensesController) create(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var body expenseRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	// Only the editable fields come from the request; status, approval, payment and attachments
+	// are set by the workflow actions
+	expense := models.FinanceExpense{
+		Title:           body.Title,
+		Description:     body.Description,
+		Amount:          body.Amount,
+		BudgetAccountId: body.BudgetAccountId,
+		CategoryId:      body.CategoryId,
+		TransactionDate: body.TransactionDate,
+		FollowUp:        body.FollowUp,
+	}
+
+	if err := f.expenseService.CreateExpenseWithOwner(&expense, auditUserID(c), ownerCtx); err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Expense created successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Expense created successfully",
+			"type": "success",
+		},
+		"data": expense,
+	})
+}
+
+func (f *FinanceExpensesController) update(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var updateData expenseRequest
+	if err := c.BodyParser(&updateData); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	// Build updates map
+	updates := make(map[string]interface{})
+	if updateData.Title != nil {
+		updates["title"] = *updateData.Title
+	}
+	if updateData.Description != nil {
+		updates["description"] = *updateData.Description
+	}
+	if updateData.Amount != nil {
+		updates["amount"] = *updateData.Amount
+	}
+	if updateData.BudgetAccountId != nil {
+		updates["budget_account_id"] = *updateData.BudgetAccountId
+	}
+	if updateData.CategoryId != nil {
+		updates["category_id"] = *updateData.CategoryId
+	}
+	if !updateData.TransactionDate.IsZero() {
+		updates["transaction_date"] = updateData.TransactionDate
+	}
+	if updateData.FollowUp != nil {
+		updates["follow_up"] = *updateData.FollowUp
+	}
+
+	if err := f.expenseService.UpdateExpenseWithOwner(expenseId, updates, ownerCtx); err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	expense, _ := f.expenseService.GetExpenseByIDWithOwner(expenseId, ownerCtx)
+
+	return c.JSON(fiber.Map{
+		"message": "Expense updated successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Expense updated successfully",
+			"type": "success",
+		},
+		"data": expense,
+	})
+}
+
+func (f *FinanceExpensesController) delete(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := f.expenseService.DeleteExpenseWithOwner(expenseId, ownerCtx); err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Expense deleted successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Expense deleted successfully",
+			"type": "success",
+		},
+	})
+}
+
+// ============================================
+// Workflow actions
+// ============================================
+
+func (f *FinanceExpensesController) submit(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := f.expenseService.SubmitExpense(expenseId, ownerCtx); err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	return f.workflowResponse(c, expenseId, ownerCtx, "Expense submitted for approval")
+}
+
+func (f *FinanceExpensesController) approve(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userId := auditUserID(c)
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if err := f.expenseService.ApproveExpense(expenseId, *userId, ownerCtx); err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	return f.workflowResponse(c, expenseId, ownerCtx, "Expense approved successfully")
+}
+
+func (f *FinanceExpensesController) reject(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userId := auditUserID(c)
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := f.expenseService.RejectExpense(expenseId, *userId, body.Reason, ownerCtx); err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	return f.workflowResponse(c, expenseId, ownerCtx, "Expense rejected")
+}
+
+func (f *FinanceExpensesController) markPaid(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userId := auditUserID(c)
+	if userId == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var req services.MarkPaidRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := f.expenseService.MarkExpensePaid(expenseId, *userId, req, ownerCtx); err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	return f.workflowResponse(c, expenseId, ownerCtx, "Expense marked as paid")
+}
+
+// ============================================
+// Attachments
+// ============================================
+
+func (f *FinanceExpensesController) uploadAttachment(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "No file uploaded")
+	}
+
+	attachment, err := f.expenseService.AddAttachment(expenseId, file, auditUserID(c), ownerCtx)
+	if err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Receipt uploaded successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Receipt uploaded successfully",
+			"type": "success",
+		},
+		"data": attachment,
+	})
+}
+
+func (f *FinanceExpensesController) deleteAttachment(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	expenseId, err := expenseIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	attachmentId, err := strconv.ParseUint(c.Query("attachment_id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid attachment_id"})
+	}
+
+	if err := f.expenseService.RemoveAttachment(expenseId, uint(attachmentId), ownerCtx); err != nil {
+		return expenseErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Receipt removed successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Receipt removed successfully",
+			"type": "success",
+		},
+	})
+}
+
+func (f *FinanceExpensesController) stats(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	stats := f.expenseService.GetExpenseStatsWithOwner(c.Query("from_date"), c.Query("to_date"), ownerCtx)
+	return c.JSON(fiber.Map{"data": stats})
+}
+
+func (f *FinanceExpensesController) workflowResponse(c *fiber.Ctx, expenseId uint, ownerCtx *utils.OwnerContext, msg string) error {
+	expense, _ := f.expenseService.GetExpenseByIDWithOwner(expenseId, ownerCtx)
+
+	return c.JSON(fiber.Map{
+		"message": msg,
+		"flash_message": fiber.Map{
+			"msg":  msg,
+			"type": "success",
+		},
+		"data": expense,
+	})
+}
+
+func expenseIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	expenseId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(expenseId), nil
+}
+
+func expenseErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case financeAccountSystemAdminError:
+		return utils.ForbiddenResponse(c, err.Error())
+	case "expense not found", "record not found":
+		return c.Status(404).JSON(fiber.Map{"error": "Expense not found or access denied"})
+	case "attachment not found":
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case "you cannot approve an expense you recorded":
+		return utils.ForbiddenResponse(c, err.Error())
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -1,8 +1,10 @@
 package controllers
 
 import (
+	"bytes"
 	"fmt"
 	"gnaps-api/services"
+	"gnaps-api/utils"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,11 +12,13 @@ import (
 
 type FinanceReportsController struct {
 	financeReportsService *services.FinanceReportsService
+	financeExportService  *services.FinanceExportService
 }
 
-func NewFinanceReportsController(financeReportsService *services.FinanceReportsService) *FinanceReportsController {
+func NewFinanceReportsController(financeReportsService *services.FinanceReportsService, financeExportService *services.FinanceExportService) *FinanceReportsController {
 	return &FinanceReportsController{
 		financeReportsService: financeReportsService,
+		financeExportService:  financeExportService,
 	}
 }
 
@@ -28,6 +32,20 @@ func (c *FinanceReportsController) Handle(action string, ctx *fiber.Ctx) error {
 		return c.getFinanceTransactions(ctx)
 	case "transactions-stats":
 		return c.getFinanceTransactionStats(ctx)
+	case "net-income":
+		return c.getNetIncome(ctx)
+	case "income-statement":
+		return c.getIncomeStatement(ctx)
+	case "cash-flow":
+		return c.getCashFlow(ctx)
+	case "export":
+		return c.export(ctx)
+	case "export-jobs":
+		return c.listExportJobs(ctx)
+	case "export-job":
+		return c.getExportJob(ctx)
+	case "download":
+		return c.downloadExport(ctx)
 	default:
 		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": fmt.Sprintf("unknown action %s", action),
@@ -36,12 +54,9 @@ func (c *FinanceReportsController) Handle(action string, ctx *fiber.Ctx) error {
 }
 
 func (c *FinanceReportsController) getMomoPayments(ctx *fiber.Ctx) error {
-	// Check user role - only system_admin and national_admin can access
-	role, ok := ctx.Locals("role").(string)
-	if !ok || (role != "system_admin" && role != "national_admin") {
-		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Access denied. Only system admins and national admins can view finance reports.",
-		})
+	scope, err := c.reportScope(ctx)
+	if err != nil {
+		return reportScopeError(ctx, err)
 	}
 
 	// Parse query parameters
@@ -68,7 +83,7 @@ func (c *FinanceReportsController) getMomoPayments(ctx *fiber.Ctx) error {
 		limit = 20
 	}
 
-	payments, total, err := c.financeReportsService.GetMomoPayments(filters, page, limit)
+	payments, total, err := c.financeReportsService.GetMomoPayments(filters, page, limit, scope)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to fetch momo payments: %v", err),
@@ -86,29 +101,24 @@ func (c *FinanceReportsController) getMomoPayments(ctx *fiber.Ctx) error {
 		"page":        page,
 		"limit":       limit,
 		"total_pages": totalPages,
+		"scope":       scope,
 	})
 }
 
 func (c *FinanceReportsController) getMomoPaymentStats(ctx *fiber.Ctx) error {
-	// Check user role - only system_admin and national_admin can access
-	role, ok := ctx.Locals("role").(string)
-	if !ok || (role != "system_admin" && role != "national_admin") {
-		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Access denied. Only system admins and national admins can view finance reports.",
-		})
+	scope, err := c.reportScope(ctx)
+	if err != nil {
+		return reportScopeError(ctx, err)
 	}
 
-	stats := c.financeReportsService.GetMomoPaymentStats()
+	stats := c.financeReportsService.GetMomoPaymentStats(scope)
 	return ctx.JSON(stats)
 }
 
 func (c *FinanceReportsController) getFinanceTransactions(ctx *fiber.Ctx) error {
-	// Check user role - only system_admin and national_admin can access
-	role, ok := ctx.Locals("role").(string)
-	if !ok || (role != "system_admin" && role != "national_admin") {
-		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Access denied. Only system admins and national admins can view finance reports.",
-		})
+	scope, err := c.reportScope(ctx)
+	if err != nil {
+		return reportScopeError(ctx, err)
 	}
 
 	// Parse query parameters
@@ -140,7 +150,7 @@ func (c *FinanceReportsController) getFinanceTransactions(ctx *fiber.Ctx) error
 		limit = 20
 	}
 
-	transactions, total, err := c.financeReportsService.GetFinanceTransactions(filters, page, limit)
+	transactions, total, err := c.financeReportsService.GetFinanceTransactions(filters, page, limit, scope)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to fetch finance transactions: %v", err),
@@ -158,18 +168,259 @@ func (c *FinanceReportsController) getFinanceTransactions(ctx *fiber.Ctx) error
 		"page":        page,
 		"limit":       limit,
 		"total_pages": totalPages,
+		"scope":       scope,
 	})
 }
 
 func (c *FinanceReportsController) getFinanceTransactionStats(ctx *fiber.Ctx) error {
-	// Check user role - only system_admin and national_admin can access
-	role, ok := ctx.Locals("role").(string)
-	if !ok || (role != "system_admin" && role != "national_admin") {
-		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Access denied. Only system admins and national admins can view finance reports.",
-		})
+	scope, err := c.reportScope(ctx)
+	if err != nil {
+		return reportScopeError(ctx, err)
 	}
 
-	stats := c.financeReportsService.GetFinanceTransactionStats()
+	stats := c.financeReportsService.GetFinanceTransactionStats(scope)
 	return ctx.JSON(stats)
 }
+
+// getNetIncome returns income less approved expenses for the caller's owner scope
+func (c *FinanceReportsController) getNetIncome(ctx *fiber.Ctx) error {
+	scope, err := c.reportScope(ctx)
+	if err != nil {
+		return reportScopeError(ctx, err)
+	}
+
+	summary := c.financeReportsService.GetNetIncome(scope, ctx.Query("from_date"), ctx.Query("to_date"))
+	return ctx.JSON(summary)
+}
+
+// getIncomeStatement returns the income statement for the caller's owner, or for a region/zone
+// they can drill down to (region_id, zone_id). Pass format=pdf to download it as a PDF
+func (c *FinanceReportsController) getIncomeStatement(ctx *fiber.Ctx) error {
+	scope, err := c.reportScope(ctx)
+	if err != nil {
+		return reportScopeError(ctx, err)
+	}
+
+	statement, err := c.financeReportsService.GetIncomeStatement(scope, ctx.Query("from_date"), ctx.Query("to_date"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to build income statement: %v", err),
+		})
+	}
+
+	if ctx.Query("format") == "pdf" {
+		var buf bytes.Buffer
+		if err := c.financeReportsService.IncomeStatementPDF(statement, &buf); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": fmt.Sprintf("Failed to render income statement: %v", err),
+			})
+		}
+		return sendPDF(ctx, "income-statement.pdf", buf.Bytes())
+	}
+
+	return ctx.JSON(fiber.Map{"data": statement})
+}
+
+// getCashFlow returns the cash-flow summary for the caller's owner, or for a region/zone
+// they can drill down to (region_id, zone_id). Pass format=pdf to download it as a PDF
+func (c *FinanceReportsController) getCashFlow(ctx *fiber.Ctx) error {
+	scope, err := c.reportScope(ctx)
+	if err != nil {
+		return reportScopeError(ctx, err)
+	}
+
+	summary, err := c.financeReportsService.GetCashFlowSummary(scope, ctx.Query("from_date"), ctx.Query("to_date"))
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to build cash-flow summary: %v", err),
+		})
+	}
+
+	if ctx.Query("format") == "pdf" {
+		var buf bytes.Buffer
+		if err := c.financeReportsService.CashFlowSummaryPDF(summary, &buf); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": fmt.Sprintf("Failed to render cash-flow summary: %v", err),
+			})
+		}
+		return sendPDF(ctx, "cash-flow.pdf", buf.Bytes())
+	}
+
+	return ctx.JSON(fiber.Map{"data": summary})
+}
+
+// reportScope resolves the owners a report covers. Zone admins see their zone, region admins
+// their region and its zones, and national admins everything, with optional region_id/zone_id
+// drill-down
+func (c *FinanceReportsController) reportScope(ctx *fiber.Ctx) (*services.ReportScope, error) {
+	regionID, _ := strconv.ParseInt(ctx.Query("region_id"), 10, 64)
+	zoneID, _ := strconv.ParseInt(ctx.Query("zone_id"), 10, 64)
+	return c.financeReportsService.ResolveReportScope(regionID, zoneID, utils.GetOwnerContext(ctx))
+}
+
+func reportScopeError(ctx *fiber.Ctx, err error) error {
+	if err.Error() == "zone not found" {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "Access denied. You can only view finance reports for your own region or zone.",
+	})
+}
+
+func sendPDF(ctx *fiber.Ctx, filename string, data []byte) error {
+	ctx.Set(fiber.HeaderContentType, "application/pdf")
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return ctx.Send(data)
+}
+
+// export exports momo-payments, transactions, expenses, income-statement or cash-flow as csv,
+// xlsx or pdf. Small exports are sent in the response; large ones (or async=true) are
+// queued for the export worker and downloaded later from the returned link
+func (c *FinanceReportsController) export(ctx *fiber.Ctx) error {
+	scope, err := c.reportScope(ctx)
+	if err != nil {
+		return reportScopeError(ctx, err)
+	}
+
+	req := services.ExportRequest{
+		ResourceType: ctx.Query("resource"),
+		Format:       ctx.Query("format", services.ExportFormatCSV),
+		Filters:      make(map[string]string),
+	}
+	for _, key := range []string{"status", "school_id", "momo_network", "finance_account_id", "finance_type", "budget_account_id", "from_date", "to_date"} {
+		if value := ctx.Query(key); value != "" {
+			req.Filters[key] = value
+		}
+	}
+
+	if err := c.financeExportService.ValidateRequest(req); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	user := exportUser(ctx)
+	if user.UserID == 0 {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if ctx.Query("async") == "true" || c.financeExportService.ShouldRunInBackground(req, scope) {
+		job, err := c.financeExportService.QueueExport(req, scope, user)
+		if err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.financeExportService.LogExport(user, req, scope)
+
+		job.DownloadUrl = exportDownloadURL(job.ID)
+		return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Export queued. It will be available to download when ready.",
+			"flash_message": fiber.Map{
+				"msg":  "Export queued. It will be available to download when ready.",
+				"type": "success",
+			},
+			"data": job,
+		})
+	}
+
+	// Small exports are built in memory first so a failure is reported as an error rather than
+	// arriving as a truncated file after the headers have gone out
+	var buf bytes.Buffer
+	if _, err := c.financeExportService.Write(req, scope, &buf); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to generate export: %v", err),
+		})
+	}
+	c.financeExportService.LogExport(user, req, scope)
+
+	ctx.Set(fiber.HeaderContentType, c.financeExportService.ContentType(req.Format))
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", c.financeExportService.Filename(req)))
+	return ctx.Send(buf.Bytes())
+}
+
+// listExportJobs lists the caller's background exports
+func (c *FinanceReportsController) listExportJobs(ctx *fiber.Ctx) error {
+	user := exportUser(ctx)
+
+	page, _ := strconv.Atoi(ctx.Query("page", "1"))
+	limit, _ := strconv.Atoi(ctx.Query("limit", "20"))
+
+	jobs, total, err := c.financeExportService.ListExportJobs(int64(user.UserID), page, limit)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to fetch exports: %v", err),
+		})
+	}
+
+	for i := range jobs {
+		if jobs[i].Status == services.ExportStatusCompleted {
+			jobs[i].DownloadUrl = exportDownloadURL(jobs[i].ID)
+		}
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data": jobs,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// getExportJob returns the status of one of the caller's background exports
+func (c *FinanceReportsController) getExportJob(ctx *fiber.Ctx) error {
+	jobID, err := exportJobIDParam(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	job, err := c.financeExportService.GetExportJob(jobID, int64(exportUser(ctx).UserID))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if job.Status == services.ExportStatusCompleted {
+		job.DownloadUrl = exportDownloadURL(job.ID)
+	}
+
+	return ctx.JSON(fiber.Map{"data": job})
+}
+
+// downloadExport sends the file of a completed background export to the user who requested it
+func (c *FinanceReportsController) downloadExport(ctx *fiber.Ctx) error {
+	jobID, err := exportJobIDParam(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	filePath, fileName, err := c.financeExportService.ExportFile(jobID, int64(exportUser(ctx).UserID))
+	if err != nil {
+		if err.Error() == "export not found" {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return ctx.Download(filePath, fileName)
+}
+
+func exportUser(ctx *fiber.Ctx) services.ExportUser {
+	userID, _ := ctx.Locals("user_id").(uint)
+	username, _ := ctx.Locals("username").(string)
+	role, _ := ctx.Locals("role").(string)
+	return services.ExportUser{UserID: userID, Username: username, Role: role}
+}
+
+func exportJobIDParam(ctx *fiber.Ctx) (uint, error) {
+	id := ctx.Params("id")
+	if id == "" {
+		id = ctx.Query("id")
+	}
+
+	jobID, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(jobID), nil
+}
+
+func exportDownloadURL(jobID uint) string {
+	return fmt.Sprintf("/api/finance-reports/download/%d", jobID)
+}
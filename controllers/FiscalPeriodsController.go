@@ -0,0 +1,306 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FiscalPeriodsController struct {
+	fiscalPeriodService *services.FiscalPeriodService
+}
+
+func NewFiscalPeriodsController(fiscalPeriodService *services.FiscalPeriodService) *FiscalPeriodsController {
+	return &FiscalPeriodsController{
+		fiscalPeriodService: fiscalPeriodService,
+	}
+}
+
+func (f *FiscalPeriodsController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return f.list(c)
+	case "show":
+		return f.show(c)
+	case "create":
+		return f.create(c)
+	case "update":
+		return f.update(c)
+	case "delete":
+		return f.delete(c)
+	case "close":
+		return f.close(c)
+	case "reopen":
+		return f.reopen(c)
+	case "history":
+		return f.history(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// fiscalPeriodRequest carries period dates as YYYY-MM-DD strings
+type fiscalPeriodRequest struct {
+	Name      string `json:"name"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+func (r fiscalPeriodRequest) toModel() (*models.FiscalPeriod, error) {
+	period := &models.FiscalPeriod{Name: r.Name}
+	if r.StartDate != "" {
+		parsed, err := time.Parse("2006-01-02", r.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_date, expected YYYY-MM-DD")
+		}
+		period.StartDate = parsed
+	}
+	if r.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", r.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_date, expected YYYY-MM-DD")
+		}
+		period.EndDate = parsed
+	}
+	return period, nil
+}
+
+func (f *FiscalPeriodsController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	periods, total, err := f.fiscalPeriodService.ListFiscalPeriodsWithOwner(filters, page, limit, ownerCtx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve fiscal periods",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": periods,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (f *FiscalPeriodsController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	periodId, err := fiscalPeriodIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	period, err := f.fiscalPeriodService.GetFiscalPeriodByIDWithOwner(periodId, ownerCtx)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Fiscal period not found or access denied"})
+	}
+
+	return c.JSON(fiber.Map{"data": period})
+}
+
+func (f *FiscalPeriodsController) create(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var req fiscalPeriodRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	period, err := req.toModel()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := f.fiscalPeriodService.CreateFiscalPeriodWithOwner(period, ownerCtx); err != nil {
+		return fiscalPeriodErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Fiscal period created successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Fiscal period created successfully",
+			"type": "success",
+		},
+		"data": period,
+	})
+}
+
+func (f *FiscalPeriodsController) update(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	periodId, err := fiscalPeriodIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var req fiscalPeriodRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	updateData, err := req.toModel()
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := f.fiscalPeriodService.UpdateFiscalPeriodWithOwner(periodId, updateData, ownerCtx); err != nil {
+		return fiscalPeriodErrorResponse(c, err)
+	}
+
+	period, _ := f.fiscalPeriodService.GetFiscalPeriodByIDWithOwner(periodId, ownerCtx)
+	return c.JSON(fiber.Map{
+		"message": "Fiscal period updated successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Fiscal period updated successfully",
+			"type": "success",
+		},
+		"data": period,
+	})
+}
+
+func (f *FiscalPeriodsController) delete(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	periodId, err := fiscalPeriodIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := f.fiscalPeriodService.DeleteFiscalPeriodWithOwner(periodId, ownerCtx); err != nil {
+		return fiscalPeriodErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Fiscal period deleted successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Fiscal period deleted successfully",
+			"type": "success",
+		},
+	})
+}
+
+// close locks a period so finance records dated inside it can no longer be changed
+func (f *FiscalPeriodsController) close(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	periodId, err := fiscalPeriodIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.BodyParser(&body)
+
+	if err := f.fiscalPeriodService.ClosePeriod(periodId, body.Notes, auditUserID(c), ownerCtx); err != nil {
+		return fiscalPeriodErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Fiscal period closed",
+		"flash_message": fiber.Map{
+			"msg":  "Fiscal period closed",
+			"type": "success",
+		},
+	})
+}
+
+// reopen unlocks a closed period; the reason is kept in the period's history
+func (f *FiscalPeriodsController) reopen(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	periodId, err := fiscalPeriodIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := f.fiscalPeriodService.ReopenPeriod(periodId, body.Reason, auditUserID(c), ownerCtx); err != nil {
+		return fiscalPeriodErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Fiscal period reopened",
+		"flash_message": fiber.Map{
+			"msg":  "Fiscal period reopened",
+			"type": "success",
+		},
+	})
+}
+
+// history lists who closed and reopened a period, when and why
+func (f *FiscalPeriodsController) history(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	periodId, err := fiscalPeriodIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	audits, err := f.fiscalPeriodService.GetHistory(periodId, ownerCtx)
+	if err != nil {
+		return fiscalPeriodErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": audits})
+}
+
+func fiscalPeriodIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	periodId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(periodId), nil
+}
+
+func fiscalPeriodErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case financeAccountSystemAdminError, "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "fiscal period not found", "record not found":
+		return c.Status(404).JSON(fiber.Map{"error": "Fiscal period not found or access denied"})
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
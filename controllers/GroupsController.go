@@ -43,6 +43,13 @@ func (g *GroupsController) Handle(action string, c *fiber.Ctx) error {
 		return g.ownerUpdate(c)
 	case "ownerDelete":
 		return g.ownerDelete(c)
+	// Group membership
+	case "members":
+		return g.members(c)
+	case "addMembers":
+		return g.addMembers(c)
+	case "removeMember":
+		return g.removeMember(c)
 	default:
 		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
 	}
@@ -415,3 +422,130 @@ func (g *GroupsController) ownerDelete(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// ============================================
+// Group membership
+// ============================================
+
+// members lists the schools in a group, optionally filtered by name
+func (g *GroupsController) members(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	groupId, err := groupIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	schools, total, err := g.groupService.ListGroupMembersWithOwner(groupId, c.Query("name"), page, limit, ownerCtx)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Group not found or access denied"})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": schools,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// addMembers adds schools (school_ids) to a group
+func (g *GroupsController) addMembers(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	groupId, err := groupIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		SchoolIds []int64 `json:"school_ids"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	added, err := g.groupService.AddGroupMembersWithOwner(groupId, body.SchoolIds, auditUserID(c), ownerCtx)
+	if err != nil {
+		return groupMemberErrorResponse(c, err)
+	}
+
+	msg := fmt.Sprintf("%d school(s) added to the group", added)
+	return c.JSON(fiber.Map{
+		"message": msg,
+		"flash_message": fiber.Map{
+			"msg":  msg,
+			"type": "success",
+		},
+		"data": fiber.Map{"added": added},
+	})
+}
+
+// removeMember takes a school (school_id) out of a group
+func (g *GroupsController) removeMember(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	groupId, err := groupIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	schoolId, err := strconv.ParseInt(c.Query("school_id"), 10, 64)
+	if err != nil || schoolId <= 0 {
+		var body struct {
+			SchoolId int64 `json:"school_id"`
+		}
+		c.BodyParser(&body)
+		schoolId = body.SchoolId
+	}
+	if schoolId <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "school_id is required"})
+	}
+
+	if err := g.groupService.RemoveGroupMemberWithOwner(groupId, schoolId, ownerCtx); err != nil {
+		return groupMemberErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "School removed from the group",
+		"flash_message": fiber.Map{
+			"msg":  "School removed from the group",
+			"type": "success",
+		},
+	})
+}
+
+func groupIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	groupId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(groupId), nil
+}
+
+func groupMemberErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case groupSystemAdminError:
+		return utils.ForbiddenResponse(c, err.Error())
+	case "group not found", "school is not a member of this group":
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
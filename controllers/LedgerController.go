@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LedgerController struct {
+	ledgerService *services.LedgerService
+}
+
+func NewLedgerController(ledgerService *services.LedgerService) *LedgerController {
+	return &LedgerController{
+		ledgerService: ledgerService,
+	}
+}
+
+func (l *LedgerController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "entries":
+		return l.entries(c)
+	case "show":
+		return l.show(c)
+	case "post-entry":
+		return l.postEntry(c)
+	case "reverse":
+		return l.reverse(c)
+	case "trial-balance":
+		return l.trialBalance(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// entries lists journal entries with their lines
+func (l *LedgerController) entries(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if sourceType := c.Query("source_type"); sourceType != "" {
+		filters["source_type"] = sourceType
+	}
+	if schoolId := c.Query("school_id"); schoolId != "" {
+		filters["school_id"] = schoolId
+	}
+	if accountId := c.Query("finance_account_id"); accountId != "" {
+		filters["finance_account_id"] = accountId
+	}
+	if fromDate := c.Query("from_date"); fromDate != "" {
+		filters["from_date"] = fromDate
+	}
+	if toDate := c.Query("to_date"); toDate != "" {
+		filters["to_date"] = toDate
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	entries, total, err := l.ledgerService.ListEntriesWithOwner(filters, page, limit, ownerCtx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve journal entries",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": entries,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (l *LedgerController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	entryId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid ID"})
+	}
+
+	entry, err := l.ledgerService.GetEntryByIDWithOwner(uint(entryId), ownerCtx)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": entry})
+}
+
+// postEntry posts a manual, balanced journal entry (e.g. opening balances or adjustments)
+func (l *LedgerController) postEntry(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var req services.JournalEntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	req.PostedBy = auditUserID(c)
+
+	entry, err := l.ledgerService.PostManualEntryWithOwner(req, ownerCtx)
+	if err != nil {
+		if err.Error() == financeAccountSystemAdminError {
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Journal entry posted successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Journal entry posted successfully",
+			"type": "success",
+		},
+		"data": entry,
+	})
+}
+
+// reverse posts an equal and opposite entry for an existing journal entry
+func (l *LedgerController) reverse(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	entryId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid ID"})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	c.BodyParser(&body)
+
+	reversal, err := l.ledgerService.ReverseEntryWithOwner(uint(entryId), body.Reason, auditUserID(c), ownerCtx)
+	if err != nil {
+		if err.Error() == financeAccountSystemAdminError {
+			return utils.ForbiddenResponse(c, err.Error())
+		}
+		if err.Error() == "journal entry not found" {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Journal entry reversed successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Journal entry reversed successfully",
+			"type": "success",
+		},
+		"data": reversal,
+	})
+}
+
+// trialBalance returns debit and credit totals per account for the caller's owner
+func (l *LedgerController) trialBalance(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	tb, err := l.ledgerService.GetTrialBalanceWithOwner(c.Query("as_of"), ownerCtx)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to build trial balance",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{"data": tb})
+}
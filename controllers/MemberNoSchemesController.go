@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MemberNoSchemesController struct {
+	memberNoSchemeService *services.MemberNoSchemeService
+}
+
+func NewMemberNoSchemesController(memberNoSchemeService *services.MemberNoSchemeService) *MemberNoSchemesController {
+	return &MemberNoSchemesController{
+		memberNoSchemeService: memberNoSchemeService,
+	}
+}
+
+func (m *MemberNoSchemesController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return m.list(c)
+	case "plan":
+		return m.plan(c)
+	case "preview":
+		return m.preview(c)
+	case "create":
+		return m.create(c)
+	case "update":
+		return m.update(c)
+	case "delete":
+		return m.delete(c)
+	case "renumber":
+		return m.renumber(c)
+	case "changes":
+		return m.changes(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// list returns the numbering schemes, optionally of one scope_type
+func (m *MemberNoSchemesController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	schemes, err := m.memberNoSchemeService.ListSchemes(c.Query("scope_type"), ownerCtx)
+	if err != nil {
+		return memberNoSchemeErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": schemes})
+}
+
+// plan returns the pattern that applies to a zone (zone_id) and its next sequence number
+func (m *MemberNoSchemesController) plan(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	zoneID, err := strconv.ParseInt(c.Query("zone_id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "zone_id is required"})
+	}
+
+	plan, err := m.memberNoSchemeService.PlanForZone(zoneID, ownerCtx)
+	if err != nil {
+		return memberNoSchemeErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": plan})
+}
+
+// preview checks a pattern and shows the first member number it gives in a zone (zone_id)
+func (m *MemberNoSchemesController) preview(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	zoneID, err := strconv.ParseInt(c.Query("zone_id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "zone_id is required"})
+	}
+
+	example, err := m.memberNoSchemeService.PreviewPattern(c.Query("pattern"), zoneID, ownerCtx)
+	if err != nil {
+		return memberNoSchemeErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": fiber.Map{"example": example}})
+}
+
+func (m *MemberNoSchemesController) create(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var scheme models.MemberNoScheme
+	if err := c.BodyParser(&scheme); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := m.memberNoSchemeService.CreateScheme(&scheme, auditUserID(c), ownerCtx); err != nil {
+		return memberNoSchemeErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Numbering scheme created",
+		"flash_message": fiber.Map{
+			"msg":  "Numbering scheme created; new schools will be numbered with it",
+			"type": "success",
+		},
+		"data": scheme,
+	})
+}
+
+func (m *MemberNoSchemesController) update(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	schemeId, err := memberNoSchemeIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Pattern     *string `json:"pattern"`
+		Description *string `json:"description"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	scheme, err := m.memberNoSchemeService.UpdateScheme(schemeId, body.Pattern, body.Description, ownerCtx)
+	if err != nil {
+		return memberNoSchemeErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Numbering scheme updated",
+		"flash_message": fiber.Map{
+			"msg":  "Numbering scheme updated",
+			"type": "success",
+		},
+		"data": scheme,
+	})
+}
+
+func (m *MemberNoSchemesController) delete(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	schemeId, err := memberNoSchemeIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := m.memberNoSchemeService.DeleteScheme(schemeId, ownerCtx); err != nil {
+		return memberNoSchemeErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Numbering scheme deleted",
+		"flash_message": fiber.Map{
+			"msg":  "Numbering scheme deleted",
+			"type": "success",
+		},
+	})
+}
+
+// renumber re-numbers the schools of a zone, a region or a list under their schemes. It is a dry
+// run returning the old-to-new mapping unless dry_run is false.
+func (m *MemberNoSchemesController) renumber(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var body services.RenumberRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	result, err := m.memberNoSchemeService.Renumber(body, auditUserID(c), ownerCtx)
+	if err != nil {
+		return memberNoSchemeErrorResponse(c, err)
+	}
+
+	if result.DryRun {
+		return c.JSON(fiber.Map{"data": result})
+	}
+	return c.JSON(fiber.Map{
+		"message": "Schools re-numbered",
+		"flash_message": fiber.Map{
+			"msg":  fmt.Sprintf("%d schools re-numbered (batch %s)", result.Schools, result.BatchId),
+			"type": "success",
+		},
+		"data": result,
+	})
+}
+
+// changes returns recorded old-to-new member numbers, optionally of one batch_id, school_id or
+// member_no (old or new)
+func (m *MemberNoSchemesController) changes(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if batchID := c.Query("batch_id"); batchID != "" {
+		filters["batch_id"] = batchID
+	}
+	if schoolID := c.Query("school_id"); schoolID != "" {
+		filters["school_id"] = schoolID
+	}
+	if memberNo := c.Query("member_no"); memberNo != "" {
+		filters["member_no"] = memberNo
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	changes, total, err := m.memberNoSchemeService.ListChanges(filters, page, limit, ownerCtx)
+	if err != nil {
+		return memberNoSchemeErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": changes,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func memberNoSchemeIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	schemeId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(schemeId), nil
+}
+
+func memberNoSchemeErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "numbering scheme not found", "zone not found", "region not found":
+		return utils.NotFoundResponse(c, err.Error())
+	case "a numbering scheme already exists for this scope":
+		return utils.ConflictResponse(c, err.Error())
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MembershipApplicationsController struct {
+	membershipApplicationService *services.MembershipApplicationService
+}
+
+func NewMembershipApplicationsController(membershipApplicationService *services.MembershipApplicationService) *MembershipApplicationsController {
+	return &MembershipApplicationsController{
+		membershipApplicationService: membershipApplicationService,
+	}
+}
+
+func (m *MembershipApplicationsController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return m.list(c)
+	case "show":
+		return m.show(c)
+	case "approve":
+		return m.approve(c)
+	case "reject":
+		return m.reject(c)
+	case "resend-invite":
+		return m.resendInvite(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// list returns the review queue for the user's zones; pending applications by default, oldest first
+func (m *MembershipApplicationsController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	filters["status"] = c.Query("status", services.ApplicationStatusPending)
+	if filters["status"] == "all" {
+		delete(filters, "status")
+	}
+	if zoneID := c.Query("zone_id"); zoneID != "" {
+		filters["zone_id"] = zoneID
+	}
+	if regionID := c.Query("region_id"); regionID != "" {
+		filters["region_id"] = regionID
+	}
+	if name := c.Query("name"); name != "" {
+		filters["name"] = name
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	applications, total, err := m.membershipApplicationService.ListApplicationsWithRole(filters, page, limit, ownerCtx)
+	if err != nil {
+		return membershipApplicationErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": applications,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (m *MembershipApplicationsController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	applicationId, err := membershipApplicationIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	application, err := m.membershipApplicationService.GetApplicationWithRole(applicationId, ownerCtx)
+	if err != nil {
+		return membershipApplicationErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": application})
+}
+
+// approve admits the school, assigning its member number and creating its user account, and sends
+// the school an invite to set its password
+func (m *MembershipApplicationsController) approve(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	applicationId, err := membershipApplicationIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.BodyParser(&body)
+
+	school, err := m.membershipApplicationService.ApproveApplication(applicationId, body.Notes, auditUserID(c), ownerCtx)
+	if err != nil {
+		return membershipApplicationErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Application approved",
+		"flash_message": fiber.Map{
+			"msg":  fmt.Sprintf("%s admitted with member number %s", school.Name, school.MemberNo),
+			"type": "success",
+		},
+		"data": school,
+	})
+}
+
+// reject declines an application; the reason is sent to the applicant
+func (m *MembershipApplicationsController) reject(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	applicationId, err := membershipApplicationIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+		Notes  string `json:"notes"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := m.membershipApplicationService.RejectApplication(applicationId, body.Reason, body.Notes, auditUserID(c), ownerCtx); err != nil {
+		return membershipApplicationErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Application rejected",
+		"flash_message": fiber.Map{
+			"msg":  "Application rejected",
+			"type": "success",
+		},
+	})
+}
+
+// resendInvite sends an admitted school a new link to set its school admin password
+func (m *MembershipApplicationsController) resendInvite(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	applicationId, err := membershipApplicationIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	delivery, err := m.membershipApplicationService.ResendInvite(applicationId, auditUserID(c), ownerCtx)
+	if err != nil {
+		return membershipApplicationErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "Invite created",
+		"flash_message": inviteFlashMessage("Invite created", delivery),
+		"data":          delivery,
+	})
+}
+
+func membershipApplicationIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	applicationId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(applicationId), nil
+}
+
+func membershipApplicationErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "application not found":
+		return c.Status(404).JSON(fiber.Map{"error": "Application not found or access denied"})
+	case "application has already been reviewed", "application has not been approved", "school admin has already set their password":
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
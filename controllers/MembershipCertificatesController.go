@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MembershipCertificatesController struct {
+	membershipCertificateService *services.MembershipCertificateService
+}
+
+func NewMembershipCertificatesController(membershipCertificateService *services.MembershipCertificateService) *MembershipCertificatesController {
+	return &MembershipCertificatesController{
+		membershipCertificateService: membershipCertificateService,
+	}
+}
+
+func (m *MembershipCertificatesController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return m.list(c)
+	case "show":
+		return m.show(c)
+	case "issue":
+		return m.issue(c)
+	case "download":
+		return m.download(c)
+	case "revoke":
+		return m.revoke(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// list returns certificates of the schools in the user's zones, optionally for one school or status
+func (m *MembershipCertificatesController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if schoolID := c.Query("school_id"); schoolID != "" {
+		filters["school_id"] = schoolID
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if name := c.Query("school_name"); name != "" {
+		filters["school_name"] = name
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	certificates, total, err := m.membershipCertificateService.ListCertificatesWithRole(filters, page, limit, ownerCtx)
+	if err != nil {
+		return membershipCertificateErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": certificates,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (m *MembershipCertificatesController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	certificateId, err := membershipCertificateIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	certificate, err := m.membershipCertificateService.GetCertificateWithRole(certificateId, ownerCtx)
+	if err != nil {
+		return membershipCertificateErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": certificate})
+}
+
+// issue issues a certificate to a school in good standing; valid_until defaults to the end of the year
+func (m *MembershipCertificatesController) issue(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var body struct {
+		SchoolId   uint   `json:"school_id"`
+		ValidUntil string `json:"valid_until"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+	if body.SchoolId == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "school_id is required"})
+	}
+
+	certificate, err := m.membershipCertificateService.IssueCertificate(body.SchoolId, body.ValidUntil, auditUserID(c), ownerCtx)
+	if err != nil {
+		return membershipCertificateErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Certificate issued",
+		"flash_message": fiber.Map{
+			"msg":  fmt.Sprintf("Certificate %s issued to %s", certificate.CertificateNo, certificate.SchoolName),
+			"type": "success",
+		},
+		"data": certificate,
+	})
+}
+
+// download renders the certificate PDF
+func (m *MembershipCertificatesController) download(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	certificateId, err := membershipCertificateIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	certificate, err := m.membershipCertificateService.GetCertificateWithRole(certificateId, ownerCtx)
+	if err != nil {
+		return membershipCertificateErrorResponse(c, err)
+	}
+
+	var buf bytes.Buffer
+	if err := m.membershipCertificateService.WriteCertificatePDF(certificate, &buf); err != nil {
+		return membershipCertificateErrorResponse(c, err)
+	}
+
+	return sendPDF(c, fmt.Sprintf("membership-certificate-%s.pdf", certificate.CertificateNo), buf.Bytes())
+}
+
+// revoke withdraws a certificate; it no longer verifies
+func (m *MembershipCertificatesController) revoke(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	certificateId, err := membershipCertificateIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := m.membershipCertificateService.RevokeCertificate(certificateId, body.Reason, auditUserID(c), ownerCtx); err != nil {
+		return membershipCertificateErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Certificate revoked",
+		"flash_message": fiber.Map{
+			"msg":  "Certificate revoked",
+			"type": "success",
+		},
+	})
+}
+
+func membershipCertificateIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	certificateId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(certificateId), nil
+}
+
+func membershipCertificateErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "certificate not found", "school not found":
+		return utils.NotFoundResponse(c, err.Error())
+	case "certificate is no longer active", "certificate has been revoked":
+		return utils.ConflictResponse(c, err.Error())
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -219,6 +219,9 @@ func (n *NewsController) update(c *fiber.Ctx) error {
 	if updateData.SchoolIds != nil {
 		updates["school_ids"] = updateData.SchoolIds
 	}
+	if updateData.SchoolGroupIds != nil {
+		updates["school_group_ids"] = updateData.SchoolGroupIds
+	}
 
 	if err := n.newsService.UpdateNews(uint(newsId), updates, userId, userRole); err != nil {
 		if err.Error() == "news not found" {
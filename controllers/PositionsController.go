@@ -149,6 +149,9 @@ func (p *PositionsController) update(c *fiber.Ctx) error {
 	if updateData.Name != nil {
 		updates["name"] = *updateData.Name
 	}
+	if updateData.Levels != nil {
+		updates["levels"] = *updateData.Levels
+	}
 
 	if err := p.positionService.UpdatePosition(uint(positionId), updates); err != nil {
 		if err.Error() == "position not found" {
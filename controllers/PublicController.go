@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"gnaps-api/models"
 	"gnaps-api/repositories"
+	"gnaps-api/services"
 	"gnaps-api/utils"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -17,6 +19,11 @@ type PublicController struct {
 	schoolRepo        *repositories.SchoolRepository
 	contactPersonRepo *repositories.ContactPersonRepository
 	db                *gorm.DB
+
+	membershipApplicationService *services.MembershipApplicationService
+	membershipCertificateService *services.MembershipCertificateService
+	executiveService             *services.ExecutiveService
+	orgChartService              *services.OrgChartService
 }
 
 // NewPublicController creates a new instance of PublicController
@@ -26,13 +33,21 @@ func NewPublicController(
 	schoolRepo *repositories.SchoolRepository,
 	contactPersonRepo *repositories.ContactPersonRepository,
 	db *gorm.DB,
+	membershipApplicationService *services.MembershipApplicationService,
+	membershipCertificateService *services.MembershipCertificateService,
+	executiveService *services.ExecutiveService,
+	orgChartService *services.OrgChartService,
 ) *PublicController {
 	return &PublicController{
-		regionRepo:        regionRepo,
-		zoneRepo:          zoneRepo,
-		schoolRepo:        schoolRepo,
-		contactPersonRepo: contactPersonRepo,
-		db:                db,
+		regionRepo:                   regionRepo,
+		zoneRepo:                     zoneRepo,
+		schoolRepo:                   schoolRepo,
+		contactPersonRepo:            contactPersonRepo,
+		db:                           db,
+		membershipApplicationService: membershipApplicationService,
+		membershipCertificateService: membershipCertificateService,
+		executiveService:             executiveService,
+		orgChartService:              orgChartService,
 	}
 }
 
@@ -45,6 +60,14 @@ func (p *PublicController) Handle(action string, c *fiber.Ctx) error {
 		return p.listZones(c)
 	case "register":
 		return p.registerSchool(c)
+	case "verify-certificate":
+		return p.verifyCertificate(c)
+	case "invite":
+		return p.showInvite(c)
+	case "accept-invite":
+		return p.acceptInvite(c)
+	case "org-chart":
+		return p.orgChart(c)
 	default:
 		return c.Status(404).JSON(fiber.Map{
 			"error": fmt.Sprintf("unknown action %s", action),
@@ -76,6 +99,18 @@ func (p *PublicController) listZones(c *fiber.Ctx) error {
 	})
 }
 
+// orgChart returns the current national, region and zone executives for the website, with only
+// their names, positions and photos
+func (p *PublicController) orgChart(c *fiber.Ctx) error {
+	chart, err := p.orgChartService.GetPublicOrgChart()
+	if err != nil {
+		return utils.ServerErrorResponse(c, "Failed to load organisational chart")
+	}
+	return c.JSON(fiber.Map{
+		"data": chart,
+	})
+}
+
 // SchoolRegistrationRequest represents the request body for school registration
 type SchoolRegistrationRequest struct {
 	Name                string               `json:"name"`
@@ -97,7 +132,7 @@ type ContactPersonInput struct {
 	Relation  string `json:"relation"`
 }
 
-// registerSchool handles public school registration
+// registerSchool submits a membership application for review by the school's zone
 func (p *PublicController) registerSchool(c *fiber.Ctx) error {
 	var req SchoolRegistrationRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -133,82 +168,44 @@ func (p *PublicController) registerSchool(c *fiber.Ctx) error {
 		return utils.ValidationErrorResponse(c, "Invalid zone selected")
 	}
 
-	// Generate member number
-	memberNo, err := p.schoolRepo.GetNextMemberNoForZone(req.ZoneID)
-	if err != nil {
-		return utils.ServerErrorResponse(c, "Failed to generate member number")
-	}
-
-	// Create school - Name and MemberNo are non-pointer strings in the model
-	school := &models.School{
-		Name:     req.Name,
-		ZoneId:   &req.ZoneID,
-		MemberNo: memberNo,
+	// The school is only created once the zone approves the application
+	application := &models.MembershipApplication{
+		Name:   req.Name,
+		ZoneId: req.ZoneID,
 	}
 
 	// Set optional fields
 	if req.Address != "" {
-		school.Address = &req.Address
+		application.Address = &req.Address
 	}
 	if req.Location != "" {
-		school.Location = &req.Location
+		application.Location = &req.Location
 	}
 	if req.MobileNo != "" {
-		school.MobileNo = &req.MobileNo
+		application.MobileNo = &req.MobileNo
 	}
 	if req.Email != "" {
-		school.Email = &req.Email
+		application.Email = &req.Email
 	}
 	if req.GpsAddress != "" {
-		school.GpsAddress = &req.GpsAddress
+		application.GpsAddress = &req.GpsAddress
 	}
 
 	// Parse date of establishment if provided
 	if req.DateOfEstablishment != "" {
 		parsedDate, err := time.Parse("2006-01-02", req.DateOfEstablishment)
 		if err == nil {
-			school.DateOfEstablishment = parsedDate
+			application.DateOfEstablishment = &parsedDate
 		}
 	}
 
-	// Set joining date to today
-	school.JoiningDate = time.Now()
-
-	// Set is_deleted to false
-	isDeleted := false
-	school.IsDeleted = &isDeleted
-
-	// Create the school
-	if err := p.schoolRepo.Create(school); err != nil {
-		return utils.ServerErrorResponse(c, "Failed to register school. Please try again.")
-	}
-
-	// Create contact persons
-	schoolID := int64(school.ID)
+	contacts := make([]services.ApplicationContactPerson, 0, len(req.ContactPersons))
 	for _, cpInput := range req.ContactPersons {
-		if cpInput.FirstName == "" || cpInput.LastName == "" {
-			continue
-		}
-
-		contactPerson := &models.ContactPerson{
-			SchoolId:  &schoolID,
-			FirstName: &cpInput.FirstName,
-			LastName:  &cpInput.LastName,
-		}
-		if cpInput.Email != "" {
-			contactPerson.Email = &cpInput.Email
-		}
-		if cpInput.MobileNo != "" {
-			contactPerson.MobileNo = &cpInput.MobileNo
-		}
-		if cpInput.Relation != "" {
-			contactPerson.Relation = &cpInput.Relation
-		}
+		contacts = append(contacts, services.ApplicationContactPerson(cpInput))
+	}
 
-		if err := p.contactPersonRepo.Create(contactPerson); err != nil {
-			// Log but don't fail - school is already created
-			fmt.Printf("Failed to create contact person for school %d: %v\n", school.ID, err)
-		}
+	if err := p.membershipApplicationService.SubmitApplication(application, contacts); err != nil {
+		return utils.ValidationErrorResponse(c, err.Error())
 	}
 
 	zoneName := ""
@@ -217,9 +214,76 @@ func (p *PublicController) registerSchool(c *fiber.Ctx) error {
 	}
 
 	return utils.SuccessResponseWithStatus(c, 201, fiber.Map{
-		"school_id": school.ID,
-		"member_no": memberNo,
-		"zone_name": zoneName,
-		"status":    "pending",
+		"application_id": application.ID,
+		"zone_name":      zoneName,
+		"status":         "pending",
 	}, "School registration submitted successfully! Your application is pending review.")
 }
+
+// verifyCertificate checks a membership certificate by the verification code printed on it
+func (p *PublicController) verifyCertificate(c *fiber.Ctx) error {
+	code := c.Query("code")
+	if code == "" {
+		return utils.ValidationErrorResponse(c, "Verification code is required")
+	}
+
+	verification, err := p.membershipCertificateService.VerifyCertificate(code)
+	if err != nil {
+		return utils.NotFoundResponse(c, "No certificate matches this verification code")
+	}
+
+	return c.JSON(fiber.Map{
+		"data": verification,
+	})
+}
+
+// showInvite checks an executive or school admin invite token and returns who it is for
+func (p *PublicController) showInvite(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return utils.ValidationErrorResponse(c, "Invite token is required")
+	}
+
+	details, err := p.executiveService.GetInviteDetails(token)
+	if err != nil {
+		return publicInviteErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": details,
+	})
+}
+
+// acceptInvite lets an invited executive or school admin set their password; the invite can't be used again
+func (p *PublicController) acceptInvite(c *fiber.Ctx) error {
+	var body struct {
+		Token           string `json:"token"`
+		Password        string `json:"password"`
+		ConfirmPassword string `json:"confirm_password"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body")
+	}
+	if body.ConfirmPassword != "" && body.ConfirmPassword != body.Password {
+		return utils.ValidationErrorResponse(c, "Passwords do not match")
+	}
+
+	username, err := p.executiveService.AcceptInvite(body.Token, body.Password)
+	if err != nil {
+		return publicInviteErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{
+		"username": username,
+	}, "Your password has been set. You can now sign in.")
+}
+
+func publicInviteErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "invite not found":
+		return utils.NotFoundResponse(c, "This invite link is not valid")
+	case "invite has already been used", "invite has been revoked", "invite has expired", "invite is no longer valid":
+		return utils.ErrorResponse(c, 410, "This invite link "+strings.TrimPrefix(err.Error(), "invite ")+". Ask your administrator to send a new one.")
+	}
+	return utils.ValidationErrorResponse(c, err.Error())
+}
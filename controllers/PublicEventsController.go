@@ -18,6 +18,7 @@ type PublicEventsController struct {
 	eventRepo        *repositories.EventRepository
 	registrationRepo *repositories.RegistrationRepository
 	schoolRepo       *repositories.SchoolRepository
+	statusService    *services.MembershipStatusService
 	db               *gorm.DB
 	paymentService   *services.MomoPaymentService
 	paymentWorker    *workers.PaymentWorker
@@ -28,12 +29,14 @@ func NewPublicEventsController(
 	eventRepo *repositories.EventRepository,
 	registrationRepo *repositories.RegistrationRepository,
 	schoolRepo *repositories.SchoolRepository,
+	statusService *services.MembershipStatusService,
 	db *gorm.DB,
 ) *PublicEventsController {
 	return &PublicEventsController{
 		eventRepo:        eventRepo,
 		registrationRepo: registrationRepo,
 		schoolRepo:       schoolRepo,
+		statusService:    statusService,
 		db:               db,
 	}
 }
@@ -55,6 +58,8 @@ func (p *PublicEventsController) Handle(action string, c *fiber.Ctx) error {
 		return p.searchSchools(c)
 	case "school-balance":
 		return p.getSchoolBalance(c)
+	case "price":
+		return p.getSchoolPrice(c)
 	case "initiate-payment":
 		return p.initiatePayment(c)
 	case "payment-status":
@@ -319,6 +324,53 @@ func (p *PublicEventsController) getSchoolBalance(c *fiber.Ctx) error {
 	})
 }
 
+// getSchoolPrice returns the price per attendee a school pays for an event (no auth required)
+// Schools with an active membership pay the member price when the event has one
+func (p *PublicEventsController) getSchoolPrice(c *fiber.Ctx) error {
+	code := c.Params("id")
+	if code == "" {
+		return utils.ValidationErrorResponse(c, "Registration code is required")
+	}
+	schoolID, err := strconv.ParseInt(c.Query("school_id"), 10, 64)
+	if err != nil || schoolID <= 0 {
+		return utils.ValidationErrorResponse(c, "School ID is required")
+	}
+
+	event, err := p.eventRepo.FindByCode(code)
+	if err != nil {
+		return utils.NotFoundResponse(c, "Event not found or registration link is invalid")
+	}
+
+	unitPrice, status, err := p.schoolUnitPrice(event, schoolID)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid school selected")
+	}
+
+	return c.JSON(fiber.Map{
+		"price":          event.Price,
+		"member_price":   event.MemberPrice,
+		"unit_price":     unitPrice,
+		"member_pricing": event.MemberPrice != nil && status == services.MembershipStatusActive,
+	})
+}
+
+// schoolUnitPrice is the price per attendee for a school: the member price for active members when
+// the event has one, otherwise the event price
+func (p *PublicEventsController) schoolUnitPrice(event *models.Event, schoolID int64) (float64, string, error) {
+	status, err := p.statusService.CurrentStatus(uint(schoolID))
+	if err != nil {
+		return 0, "", err
+	}
+
+	if event.MemberPrice != nil && status == services.MembershipStatusActive {
+		return *event.MemberPrice, status, nil
+	}
+	if event.Price != nil {
+		return *event.Price, status, nil
+	}
+	return 0, status, nil
+}
+
 // initiatePayment initiates a MoMo payment for event registration (no auth required)
 // Payment must succeed BEFORE registration is created
 func (p *PublicEventsController) initiatePayment(c *fiber.Ctx) error {
@@ -393,6 +445,21 @@ func (p *PublicEventsController) initiatePayment(c *fiber.Ctx) error {
 		req.NumberOfAttendees = 1
 	}
 
+	// The member price only applies to schools whose membership is active
+	unitPrice, _, err := p.schoolUnitPrice(event, req.SchoolID)
+	if err != nil {
+		return c.JSON(fiber.Map{
+			"error":   true,
+			"message": "Invalid school selected",
+		})
+	}
+	if due := unitPrice * float64(req.NumberOfAttendees); req.Amount < due {
+		return c.JSON(fiber.Map{
+			"error":   true,
+			"message": fmt.Sprintf("Amount must be at least GH₵ %.2f for %d attendee(s)", due, req.NumberOfAttendees),
+		})
+	}
+
 	// Create payment request - PayeeID will be set to event ID for now
 	// Registration will be created on successful payment
 	paymentReq := services.MomoPaymentRequest{
@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RemittancesController struct {
+	remittanceService     *services.RemittanceService
+	financeReportsService *services.FinanceReportsService
+}
+
+func NewRemittancesController(remittanceService *services.RemittanceService, financeReportsService *services.FinanceReportsService) *RemittancesController {
+	return &RemittancesController{
+		remittanceService:     remittanceService,
+		financeReportsService: financeReportsService,
+	}
+}
+
+func (r *RemittancesController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return r.list(c)
+	case "show":
+		return r.show(c)
+	case "create":
+		return r.create(c)
+	case "confirm":
+		return r.confirm(c)
+	case "reject":
+		return r.reject(c)
+	case "delete":
+		return r.delete(c)
+	case "balances":
+		return r.balances(c)
+	case "allocations":
+		return r.allocations(c)
+	case "allocate-pending":
+		return r.allocatePending(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// list lists remittances paid or received within the caller's scope (region_id/zone_id drill down)
+func (r *RemittancesController) list(c *fiber.Ctx) error {
+	scope, err := r.scope(c)
+	if err != nil {
+		return reportScopeError(c, err)
+	}
+
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if toOwnerType := c.Query("to_owner_type"); toOwnerType != "" {
+		filters["to_owner_type"] = toOwnerType
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	remittances, total, err := r.remittanceService.ListRemittances(filters, scope, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve remittances",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  remittances,
+		"scope": scope,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+func (r *RemittancesController) show(c *fiber.Ctx) error {
+	scope, err := r.scope(c)
+	if err != nil {
+		return reportScopeError(c, err)
+	}
+
+	remittanceId, err := remittanceIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	remittance, err := r.remittanceService.GetRemittance(remittanceId, scope)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Remittance not found or access denied"})
+	}
+
+	return c.JSON(fiber.Map{"data": remittance})
+}
+
+// create records a remittance from the caller's level to to_owner_type/to_owner_id
+func (r *RemittancesController) create(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var remittance models.Remittance
+	if err := c.BodyParser(&remittance); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := r.remittanceService.CreateRemittance(&remittance, auditUserID(c), ownerCtx); err != nil {
+		return remittanceErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Remittance recorded successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Remittance recorded successfully",
+			"type": "success",
+		},
+		"data": remittance,
+	})
+}
+
+// confirm is used by the receiving level to acknowledge a remittance was received
+func (r *RemittancesController) confirm(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	remittanceId, err := remittanceIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := r.remittanceService.ConfirmRemittance(remittanceId, auditUserID(c), ownerCtx); err != nil {
+		return remittanceErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Remittance confirmed",
+		"flash_message": fiber.Map{
+			"msg":  "Remittance confirmed",
+			"type": "success",
+		},
+	})
+}
+
+func (r *RemittancesController) reject(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	remittanceId, err := remittanceIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := r.remittanceService.RejectRemittance(remittanceId, body.Reason, auditUserID(c), ownerCtx); err != nil {
+		return remittanceErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Remittance rejected",
+		"flash_message": fiber.Map{
+			"msg":  "Remittance rejected",
+			"type": "success",
+		},
+	})
+}
+
+func (r *RemittancesController) delete(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	remittanceId, err := remittanceIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := r.remittanceService.DeleteRemittance(remittanceId, ownerCtx); err != nil {
+		return remittanceErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Remittance deleted successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Remittance deleted successfully",
+			"type": "success",
+		},
+	})
+}
+
+// balances returns amounts due, remitted and outstanding between levels, optionally for a
+// from_date/to_date period
+func (r *RemittancesController) balances(c *fiber.Ctx) error {
+	scope, err := r.scope(c)
+	if err != nil {
+		return reportScopeError(c, err)
+	}
+
+	report, err := r.remittanceService.GetBalances(scope, c.Query("from_date"), c.Query("to_date"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to compute remittance balances",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{"data": report})
+}
+
+// allocations lists how payments were split between levels, filterable by school_bill_id,
+// finance_transaction_id or receiver
+func (r *RemittancesController) allocations(c *fiber.Ctx) error {
+	scope, err := r.scope(c)
+	if err != nil {
+		return reportScopeError(c, err)
+	}
+
+	filters := make(map[string]interface{})
+	for _, key := range []string{"school_bill_id", "finance_transaction_id", "school_id", "receiver_owner_type", "receiver_owner_id"} {
+		if value := c.Query(key); value != "" {
+			filters[key] = value
+		}
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	allocations, total, err := r.remittanceService.ListAllocations(filters, scope, page, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error":   "Failed to retrieve payment allocations",
+			"details": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  allocations,
+		"scope": scope,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// allocatePending allocates school bill payments recorded before allocation existed (national only)
+func (r *RemittancesController) allocatePending(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	limit, _ := strconv.Atoi(c.Query("limit", "500"))
+
+	allocated, err := r.remittanceService.AllocatePendingTransactions(limit, ownerCtx)
+	if err != nil {
+		return remittanceErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": fmt.Sprintf("%d payments allocated", allocated),
+		"flash_message": fiber.Map{
+			"msg":  fmt.Sprintf("%d payments allocated", allocated),
+			"type": "success",
+		},
+		"data": fiber.Map{"allocated": allocated},
+	})
+}
+
+func (r *RemittancesController) scope(c *fiber.Ctx) (*services.ReportScope, error) {
+	regionID, _ := strconv.ParseInt(c.Query("region_id"), 10, 64)
+	zoneID, _ := strconv.ParseInt(c.Query("zone_id"), 10, 64)
+	return r.financeReportsService.ResolveReportScope(regionID, zoneID, utils.GetOwnerContext(c))
+}
+
+func remittanceIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	remittanceId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(remittanceId), nil
+}
+
+func remittanceErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case financeAccountSystemAdminError, "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "remittance not found", "record not found":
+		return c.Status(404).JSON(fiber.Map{"error": "Remittance not found or access denied"})
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	"fmt"
 	"gnaps-api/services"
+	"gnaps-api/utils"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,11 +11,13 @@ import (
 
 type SchoolBillsController struct {
 	schoolBillService *services.SchoolBillService
+	billService       *services.BillService
 }
 
-func NewSchoolBillsController(schoolBillService *services.SchoolBillService) *SchoolBillsController {
+func NewSchoolBillsController(schoolBillService *services.SchoolBillService, billService *services.BillService) *SchoolBillsController {
 	return &SchoolBillsController{
 		schoolBillService: schoolBillService,
+		billService:       billService,
 	}
 }
 
@@ -28,6 +31,8 @@ func (s *SchoolBillsController) Handle(action string, c *fiber.Ctx) error {
 		return s.particulars(c)
 	case "payment-history":
 		return s.paymentHistory(c)
+	case "revisions":
+		return s.revisions(c)
 	default:
 		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
 	}
@@ -167,3 +172,74 @@ func (s *SchoolBillsController) paymentHistory(c *fiber.Ctx) error {
 		"limit": limit,
 	})
 }
+
+// revisions returns the revision history for a school bill (id) or for all of a school's bills (school_id)
+func (s *SchoolBillsController) revisions(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id != "" {
+		schoolBillId, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid school bill ID",
+			})
+		}
+
+		revisions, err := s.billService.GetSchoolBillRevisionsWithOwner(schoolBillId, ownerCtx)
+		if err != nil {
+			return schoolBillRevisionsErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"data": revisions,
+		})
+	}
+
+	schoolIdStr := c.Query("school_id")
+	if schoolIdStr == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "school bill ID or school_id is required",
+		})
+	}
+
+	schoolId, err := strconv.ParseInt(schoolIdStr, 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid school_id",
+		})
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	revisions, total, err := s.billService.GetSchoolRevisionsWithOwner(schoolId, page, limit, ownerCtx)
+	if err != nil {
+		return schoolBillRevisionsErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  revisions,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// schoolBillRevisionsErrorResponse maps revision history errors to a response
+func schoolBillRevisionsErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "school bill not found", "school not found":
+		return utils.NotFoundResponse(c, err.Error())
+	}
+	return c.Status(500).JSON(fiber.Map{
+		"error":   "Failed to retrieve bill revisions",
+		"details": err.Error(),
+	})
+}
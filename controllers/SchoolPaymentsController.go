@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"fmt"
+	"gnaps-api/repositories"
 	"gnaps-api/services"
+	"gnaps-api/utils"
 	"gnaps-api/workers"
 	"strconv"
 
@@ -33,6 +35,10 @@ func (s *SchoolPaymentsController) Handle(action string, c *fiber.Ctx) error {
 		return s.record(c)
 	case "status":
 		return s.status(c)
+	case "discount":
+		return s.discount(c)
+	case "refund":
+		return s.refund(c)
 	default:
 		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
 	}
@@ -222,3 +228,96 @@ func (s *SchoolPaymentsController) status(c *fiber.Ctx) error {
 		"transaction_id": status.TransactionID,
 	})
 }
+
+// discount grants a discount on a school bill
+func (s *SchoolPaymentsController) discount(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var req services.DiscountRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if req.SchoolBillID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "school_bill_id is required",
+		})
+	}
+
+	if userId := auditUserID(c); userId != nil {
+		req.UserID = *userId
+	}
+
+	schoolBill, err := s.schoolBillService.ApplyDiscountWithOwner(req, ownerCtx)
+	if err != nil {
+		return schoolBillAdjustmentErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Discount applied successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Discount applied successfully",
+			"type": "success",
+		},
+		"data": schoolBill,
+	})
+}
+
+// refund records money returned to a school against a school bill
+func (s *SchoolPaymentsController) refund(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var req services.RefundRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if req.SchoolBillID == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "school_bill_id is required",
+		})
+	}
+
+	if userId := auditUserID(c); userId != nil {
+		req.UserID = *userId
+	}
+
+	transaction, err := s.schoolBillService.RecordRefundWithOwner(req, ownerCtx)
+	if err != nil {
+		return schoolBillAdjustmentErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Refund recorded successfully",
+		"flash_message": fiber.Map{
+			"msg":  "Refund recorded successfully",
+			"type": "success",
+		},
+		"data": transaction,
+	})
+}
+
+// schoolBillAdjustmentErrorResponse maps discount and refund errors to a response
+func schoolBillAdjustmentErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied", repositories.ErrSystemAdminCannotWrite.Error():
+		return utils.ForbiddenResponse(c, err.Error())
+	case "school bill not found":
+		return utils.NotFoundResponse(c, err.Error())
+	}
+	return c.Status(400).JSON(fiber.Map{
+		"error": err.Error(),
+		"flash_message": fiber.Map{
+			"msg":  err.Error(),
+			"type": "error",
+		},
+	})
+}
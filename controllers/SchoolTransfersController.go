@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"fmt"
+	"gnaps-api/services"
+	"gnaps-api/utils"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SchoolTransfersController struct {
+	schoolTransferService *services.SchoolTransferService
+}
+
+func NewSchoolTransfersController(schoolTransferService *services.SchoolTransferService) *SchoolTransfersController {
+	return &SchoolTransfersController{
+		schoolTransferService: schoolTransferService,
+	}
+}
+
+func (t *SchoolTransfersController) Handle(action string, c *fiber.Ctx) error {
+	switch action {
+	case "list":
+		return t.list(c)
+	case "show":
+		return t.show(c)
+	case "request":
+		return t.request(c)
+	case "accept":
+		return t.accept(c)
+	case "reject":
+		return t.reject(c)
+	case "cancel":
+		return t.cancel(c)
+	case "history":
+		return t.history(c)
+	default:
+		return c.Status(404).JSON(fiber.Map{"error": fmt.Sprintf("unknown action %s", action)})
+	}
+}
+
+// list returns transfers into or out of the user's zones; direction=incoming|outgoing narrows the
+// list and status defaults to every status
+func (t *SchoolTransfersController) list(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if status := c.Query("status"); status != "" && status != "all" {
+		filters["status"] = status
+	}
+	if schoolID := c.Query("school_id"); schoolID != "" {
+		filters["school_id"] = schoolID
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	transfers, total, err := t.schoolTransferService.ListTransfers(filters, c.Query("direction"), page, limit, ownerCtx)
+	if err != nil {
+		return schoolTransferErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": transfers,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// show returns a transfer; pending transfers include the school's outstanding bills
+func (t *SchoolTransfersController) show(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	transferId, err := schoolTransferIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	transfer, err := t.schoolTransferService.GetTransfer(transferId, ownerCtx)
+	if err != nil {
+		return schoolTransferErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": transfer})
+}
+
+// request asks the receiving zone (to_zone_id) to take over a school in the user's zone
+func (t *SchoolTransfersController) request(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var body services.SchoolTransferRequest
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	transfer, err := t.schoolTransferService.RequestTransfer(body, auditUserID(c), ownerCtx)
+	if err != nil {
+		return schoolTransferErrorResponse(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"message": "Transfer requested",
+		"flash_message": fiber.Map{
+			"msg":  "Transfer requested; the receiving zone must accept it",
+			"type": "success",
+		},
+		"data": transfer,
+	})
+}
+
+// accept moves the school into the receiving zone
+func (t *SchoolTransfersController) accept(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	transferId, err := schoolTransferIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.BodyParser(&body)
+
+	transfer, err := t.schoolTransferService.AcceptTransfer(transferId, body.Notes, auditUserID(c), ownerCtx)
+	if err != nil {
+		return schoolTransferErrorResponse(c, err)
+	}
+
+	msg := "School transferred"
+	if transfer.NewMemberNo != nil {
+		msg = fmt.Sprintf("School transferred with member number %s", *transfer.NewMemberNo)
+	}
+	return c.JSON(fiber.Map{
+		"message": "Transfer accepted",
+		"flash_message": fiber.Map{
+			"msg":  msg,
+			"type": "success",
+		},
+		"data": transfer,
+	})
+}
+
+// reject declines a transfer on behalf of the receiving zone
+func (t *SchoolTransfersController) reject(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	transferId, err := schoolTransferIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error":   "Invalid request body",
+			"details": err.Error(),
+		})
+	}
+
+	if err := t.schoolTransferService.RejectTransfer(transferId, body.Reason, auditUserID(c), ownerCtx); err != nil {
+		return schoolTransferErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Transfer rejected",
+		"flash_message": fiber.Map{
+			"msg":  "Transfer rejected",
+			"type": "success",
+		},
+	})
+}
+
+// cancel withdraws a pending transfer on behalf of the sending zone
+func (t *SchoolTransfersController) cancel(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	transferId, err := schoolTransferIDParam(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body struct {
+		Notes string `json:"notes"`
+	}
+	_ = c.BodyParser(&body)
+
+	if err := t.schoolTransferService.CancelTransfer(transferId, body.Notes, auditUserID(c), ownerCtx); err != nil {
+		return schoolTransferErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Transfer cancelled",
+		"flash_message": fiber.Map{
+			"msg":  "Transfer cancelled",
+			"type": "success",
+		},
+	})
+}
+
+// history returns the zones a school (school_id) has belonged to and its member number in each
+func (t *SchoolTransfersController) history(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	schoolID, err := strconv.ParseUint(c.Query("school_id"), 10, 64)
+	if err != nil || schoolID == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "school_id is required"})
+	}
+
+	history, err := t.schoolTransferService.GetZoneHistory(uint(schoolID), ownerCtx)
+	if err != nil {
+		return schoolTransferErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": history})
+}
+
+func schoolTransferIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	transferId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(transferId), nil
+}
+
+func schoolTransferErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied", "only the receiving zone can accept or reject a transfer", "only the sending zone can cancel a transfer":
+		return utils.ForbiddenResponse(c, err.Error())
+	case "transfer not found", "school not found", "zone not found":
+		return utils.NotFoundResponse(c, err.Error())
+	case "transfer has already been decided", "school already has a transfer awaiting acceptance":
+		return utils.ConflictResponse(c, err.Error())
+	}
+	return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+}
@@ -11,12 +11,20 @@ import (
 )
 
 type SchoolsController struct {
-	schoolService *services.SchoolService
+	schoolService       *services.SchoolService
+	schoolImportService *services.SchoolImportService
+	schoolMergeService  *services.SchoolMergeService
+	statusService       *services.MembershipStatusService
+	locationService     *services.SchoolLocationService
 }
 
-func NewSchoolsController(schoolService *services.SchoolService) *SchoolsController {
+func NewSchoolsController(schoolService *services.SchoolService, schoolImportService *services.SchoolImportService, schoolMergeService *services.SchoolMergeService, statusService *services.MembershipStatusService, locationService *services.SchoolLocationService) *SchoolsController {
 	return &SchoolsController{
-		schoolService: schoolService,
+		schoolService:       schoolService,
+		schoolImportService: schoolImportService,
+		schoolMergeService:  schoolMergeService,
+		statusService:       statusService,
+		locationService:     locationService,
 	}
 }
 
@@ -34,6 +42,34 @@ func (s *SchoolsController) Handle(action string, c *fiber.Ctx) error {
 		return s.delete(c)
 	case "next_member_no":
 		return s.nextMemberNo(c)
+	case "import":
+		return s.importSchools(c)
+	case "import_template":
+		return s.importTemplate(c)
+	case "import_errors":
+		return s.importErrors(c)
+	case "duplicates":
+		return s.duplicates(c)
+	case "merge":
+		return s.merge(c)
+	case "merges":
+		return s.merges(c)
+	case "membership_status":
+		return s.membershipStatus(c)
+	case "suspend":
+		return s.suspend(c)
+	case "lift_suspension":
+		return s.liftSuspension(c)
+	case "voters":
+		return s.voters(c)
+	case "nearby":
+		return s.nearby(c)
+	case "within":
+		return s.within(c)
+	case "geojson":
+		return s.geojson(c)
+	case "parse_gps":
+		return s.parseGPS(c)
 	default:
 		return utils.NotFoundResponse(c, fmt.Sprintf("unknown action %s", action))
 	}
@@ -69,6 +105,9 @@ func (s *SchoolsController) list(c *fiber.Ctx) error {
 	if schoolGroupID := c.Query("school_group_id"); schoolGroupID != "" {
 		filters["school_group_id"] = schoolGroupID
 	}
+	if membershipStatus := c.Query("membership_status"); membershipStatus != "" {
+		filters["membership_status"] = membershipStatus
+	}
 
 	// Pagination
 	page, _ := strconv.Atoi(c.Query("page", "1"))
@@ -189,6 +228,12 @@ func (s *SchoolsController) update(c *fiber.Ctx) error {
 	if updateData.GpsAddress != nil {
 		updates["gps_address"] = updateData.GpsAddress
 	}
+	if updateData.Latitude != nil {
+		updates["latitude"] = updateData.Latitude
+	}
+	if updateData.Longitude != nil {
+		updates["longitude"] = updateData.Longitude
+	}
 	if updateData.UserId != nil {
 		updates["user_id"] = updateData.UserId
 	}
@@ -259,3 +304,338 @@ func (s *SchoolsController) nextMemberNo(c *fiber.Ctx) error {
 		"member_no": memberNo,
 	})
 }
+
+// importSchools imports schools and their contact persons from a CSV or XLSX file (form field "file").
+// It is a dry run unless dry_run=false: the report shows each row's member number and errors without
+// saving anything. Otherwise valid rows are created and failed rows are listed in an error sheet.
+func (s *SchoolsController) importSchools(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+	if ownerCtx == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized - owner context not found"})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "No file uploaded")
+	}
+
+	dryRun := true
+	if value := c.FormValue("dry_run", c.Query("dry_run")); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			return utils.ValidationErrorResponse(c, "Invalid dry_run")
+		}
+	}
+
+	result, err := s.schoolImportService.ImportSchools(file, dryRun, ownerCtx)
+	if err != nil {
+		if err.Error() == "access denied" {
+			return utils.ForbiddenResponse(c, "Access denied")
+		}
+		return utils.ValidationErrorResponse(c, err.Error())
+	}
+
+	data := fiber.Map{"result": result}
+	if result.ErrorSheet != "" {
+		data["error_sheet_url"] = fmt.Sprintf("/api/schools/import_errors?file=%s", result.ErrorSheet)
+	}
+
+	message := fmt.Sprintf("%d of %d rows are valid", result.Valid, result.TotalRows)
+	if !dryRun {
+		message = fmt.Sprintf("Imported %d of %d schools", result.Imported, result.TotalRows)
+	}
+	return utils.SuccessResponse(c, data, message)
+}
+
+// importTemplate downloads an empty XLSX workbook with the import columns
+func (s *SchoolsController) importTemplate(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="school-import-template.xlsx"`)
+	return s.schoolImportService.WriteTemplate(c.Response().BodyWriter())
+}
+
+// importErrors downloads the error sheet of one of the user's imports
+func (s *SchoolsController) importErrors(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(uint)
+
+	path, err := s.schoolImportService.ErrorSheetPath(c.Query("file"), userID)
+	if err != nil {
+		return utils.NotFoundResponse(c, err.Error())
+	}
+	return c.Download(path, "school-import-errors.xlsx")
+}
+
+// duplicates lists pairs of schools in a zone (zone_id) that are likely the same school,
+// scored on name similarity, phone, email and GPS address; min_score defaults to 60
+func (s *SchoolsController) duplicates(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	zoneID, _ := strconv.ParseInt(c.Query("zone_id"), 10, 64)
+	minScore, _ := strconv.Atoi(c.Query("min_score"))
+
+	pairs, err := s.schoolMergeService.FindDuplicates(zoneID, minScore, ownerCtx)
+	if err != nil {
+		return schoolMergeErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": pairs})
+}
+
+// merge merges merged_school_id into surviving_school_id, moving its bills, registrations,
+// submissions, payments, contact persons and finance records
+func (s *SchoolsController) merge(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var body struct {
+		SurvivingSchoolId uint   `json:"surviving_school_id"`
+		MergedSchoolId    uint   `json:"merged_school_id"`
+		Reason            string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body")
+	}
+
+	merge, err := s.schoolMergeService.MergeSchools(body.SurvivingSchoolId, body.MergedSchoolId, body.Reason, auditUserID(c), ownerCtx)
+	if err != nil {
+		return schoolMergeErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, merge, "Schools merged successfully")
+}
+
+// merges lists merge records, optionally for one school (school_id)
+func (s *SchoolsController) merges(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	schoolID, _ := strconv.ParseInt(c.Query("school_id"), 10, 64)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	merges, total, err := s.schoolMergeService.ListMerges(schoolID, page, limit, ownerCtx)
+	if err != nil {
+		return schoolMergeErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": merges,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// membershipStatus returns a school's membership status, what it is entitled to and its status history
+func (s *SchoolsController) membershipStatus(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	schoolId, err := schoolIDParam(c)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err.Error())
+	}
+
+	standing, err := s.statusService.GetStandingWithRole(schoolId, ownerCtx)
+	if err != nil {
+		return membershipStatusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{"data": standing})
+}
+
+// suspend suspends a school's membership; a reason is required
+func (s *SchoolsController) suspend(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	schoolId, err := schoolIDParam(c)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err.Error())
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body")
+	}
+
+	if err := s.statusService.SuspendSchool(schoolId, body.Reason, auditUserID(c), ownerCtx); err != nil {
+		return membershipStatusErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{"membership_status": services.MembershipStatusSuspended}, "School suspended")
+}
+
+// liftSuspension ends a suspension; the school is lapsed instead of active while its dues are overdue
+func (s *SchoolsController) liftSuspension(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	schoolId, err := schoolIDParam(c)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err.Error())
+	}
+
+	var body struct {
+		Notes string `json:"notes"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return utils.ValidationErrorResponse(c, "Invalid request body")
+	}
+
+	status, err := s.statusService.LiftSuspension(schoolId, body.Notes, auditUserID(c), ownerCtx)
+	if err != nil {
+		return membershipStatusErrorResponse(c, err)
+	}
+
+	return utils.SuccessResponse(c, fiber.Map{"membership_status": status}, fmt.Sprintf("Suspension lifted; school is now %s", status))
+}
+
+// voters lists the schools eligible to vote (active members), filterable like list
+func (s *SchoolsController) voters(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	filters := make(map[string]interface{})
+	if regionID := c.Query("region_id"); regionID != "" {
+		filters["region_id"] = regionID
+	}
+	if zoneID := c.Query("zone_id"); zoneID != "" {
+		filters["zone_id"] = zoneID
+	}
+	if name := c.Query("name"); name != "" {
+		filters["name"] = name
+	}
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+
+	schools, total, err := s.statusService.ListVotersWithRole(filters, page, limit, ownerCtx)
+	if err != nil {
+		return membershipStatusErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data": schools,
+		"pagination": fiber.Map{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// nearby lists the schools within radius_km (default 10) of lat/lng, nearest first
+func (s *SchoolsController) nearby(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	latitude, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "lat is required")
+	}
+	longitude, err := strconv.ParseFloat(c.Query("lng"), 64)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, "lng is required")
+	}
+	radiusKm, _ := strconv.ParseFloat(c.Query("radius_km"), 64)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	schools, err := s.locationService.SearchNearby(latitude, longitude, radiusKm, limit, ownerCtx)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err.Error())
+	}
+
+	return c.JSON(fiber.Map{"data": schools})
+}
+
+// within lists the schools inside the box min_lat/min_lng to max_lat/max_lng
+func (s *SchoolsController) within(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var bounds [4]float64
+	for i, param := range []string{"min_lat", "min_lng", "max_lat", "max_lng"} {
+		value, err := strconv.ParseFloat(c.Query(param), 64)
+		if err != nil {
+			return utils.ValidationErrorResponse(c, fmt.Sprintf("%s is required", param))
+		}
+		bounds[i] = value
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	schools, err := s.locationService.SearchWithinBox(bounds[0], bounds[1], bounds[2], bounds[3], limit, ownerCtx)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err.Error())
+	}
+
+	return c.JSON(fiber.Map{"data": schools})
+}
+
+// geojson exports the located schools of a zone (zone_id) or region (region_id) as GeoJSON
+func (s *SchoolsController) geojson(c *fiber.Ctx) error {
+	ownerCtx := utils.GetOwnerContext(c)
+
+	var zoneID, regionID *int64
+	if value, err := strconv.ParseInt(c.Query("zone_id"), 10, 64); err == nil {
+		zoneID = &value
+	}
+	if value, err := strconv.ParseInt(c.Query("region_id"), 10, 64); err == nil {
+		regionID = &value
+	}
+
+	collection, err := s.locationService.ExportGeoJSON(zoneID, regionID, ownerCtx)
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err.Error())
+	}
+
+	if c.Query("download") == "true" {
+		c.Set("Content-Disposition", "attachment; filename=\"schools.geojson\"")
+	}
+	return c.Status(fiber.StatusOK).JSON(collection, "application/geo+json")
+}
+
+// parseGPS validates a Ghana Post GPS address (gps_address) and returns its parts
+func (s *SchoolsController) parseGPS(c *fiber.Ctx) error {
+	address, err := utils.ParseGhanaPostGPS(c.Query("gps_address"))
+	if err != nil {
+		return utils.ValidationErrorResponse(c, err.Error())
+	}
+
+	return c.JSON(fiber.Map{"data": address})
+}
+
+func schoolIDParam(c *fiber.Ctx) (uint, error) {
+	id := c.Params("id")
+	if id == "" {
+		id = c.Query("id")
+	}
+
+	if id == "" {
+		return 0, fmt.Errorf("ID is required")
+	}
+
+	schoolId, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ID")
+	}
+	return uint(schoolId), nil
+}
+
+func membershipStatusErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, "Access denied")
+	case "school not found":
+		return utils.NotFoundResponse(c, err.Error())
+	case "school is already suspended", "school is not suspended", "school status changed, please try again":
+		return utils.ConflictResponse(c, err.Error())
+	}
+	return utils.ValidationErrorResponse(c, err.Error())
+}
+
+func schoolMergeErrorResponse(c *fiber.Ctx, err error) error {
+	switch err.Error() {
+	case "access denied":
+		return utils.ForbiddenResponse(c, "Access denied")
+	case "school not found", "zone not found":
+		return utils.NotFoundResponse(c, err.Error())
+	}
+	return utils.ValidationErrorResponse(c, err.Error())
+}
@@ -0,0 +1,64 @@
+// Package testdb opens in-memory SQLite databases for repository and service tests. It uses the
+// pure-Go SQLite driver, so tests need no cgo.
+package testdb
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// Open returns an empty in-memory database with tables for the given models. MySQL's INSERT IGNORE
+// is written as SQLite's INSERT OR IGNORE; row locks are dropped by the driver.
+func Open(t testing.TB, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	db, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory", name)), &gorm.Config{
+		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	// Every connection to an in-memory database gets its own copy, so keep to one
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	insert := db.ClauseBuilders["INSERT"]
+	db.ClauseBuilders["INSERT"] = func(c clause.Clause, builder clause.Builder) {
+		if expr, ok := c.Expression.(clause.Insert); ok && expr.Modifier == "IGNORE" {
+			expr.Modifier = "OR IGNORE"
+			c.Expression = expr
+		}
+		insert(c, builder)
+	}
+
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+	return db
+}
+
+// Seed inserts rows in order, failing the test on error. Each row is a pointer to a model.
+func Seed(t testing.TB, db *gorm.DB, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("seed %T: %v", row, err)
+		}
+	}
+}
+
+// Ptr returns a pointer to v, for the optional columns of fixture rows
+func Ptr[T any](v T) *T {
+	return &v
+}
@@ -39,6 +39,13 @@ func main() {
 		}
 	}()
 
+	// Start the export worker in a goroutine
+	go func() {
+		if err := config.ExportWorker.StartWorker(config.FinanceExportService.ProcessExportJob); err != nil {
+			log.Printf("Export worker error: %v", err)
+		}
+	}()
+
 	// Generate models from database tables (only if GENERATE_MODELS=true)
 	if os.Getenv("GENERATE_MODELS") == "true" {
 		modelsDir := filepath.Join(".", "models")
@@ -122,5 +129,29 @@ func main() {
 		}
 	}
 
+	// Close export worker
+	if config.ExportWorker != nil {
+		log.Println("Closing export worker...")
+		if err := config.ExportWorker.Close(); err != nil {
+			log.Printf("Error closing export worker: %v", err)
+		}
+	}
+
+	// Stop the membership status job
+	if config.MembershipStatusWorker != nil {
+		log.Println("Closing membership status worker...")
+		if err := config.MembershipStatusWorker.Close(); err != nil {
+			log.Printf("Error closing membership status worker: %v", err)
+		}
+	}
+
+	// Stop the executive term job
+	if config.ExecutiveTermWorker != nil {
+		log.Println("Closing executive term worker...")
+		if err := config.ExecutiveTermWorker.Close(); err != nil {
+			log.Printf("Error closing executive term worker: %v", err)
+		}
+	}
+
 	log.Println("Server stopped gracefully")
 }
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"gnaps-api/models"
+	"gnaps-api/repositories"
 	"gnaps-api/utils"
 	"log"
 	"strings"
@@ -100,6 +101,8 @@ func RequireRole(allowedRoles ...string) fiber.Handler {
 // This should be used after JWTAuth middleware for routes that need owner-based filtering
 // For system_admin users (may not be executive), creates a system admin context for view-only access
 // For other admin roles without executive records, creates a fallback context based on JWT role
+// Executives get the role, region and zone of their current term of office and are refused once
+// they have none
 func AttachOwnerContext(db *gorm.DB) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get user_id from context (set by JWTAuth)
@@ -179,19 +182,31 @@ func AttachOwnerContext(db *gorm.DB) fiber.Handler {
 			return c.Next()
 		}
 
-		// Get role from executive
-		role := ""
-		if executive.Role != nil {
-			role = *executive.Role
+		// Access comes from the executive's current term of office; once their terms have ended or
+		// been revoked they keep their account but lose admin access
+		term, err := repositories.CurrentExecutiveTerm(db, executive.ID)
+		if err != nil {
+			log.Printf("[AttachOwnerContext] Failed to load terms for executive_id=%d: %v", executive.ID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "failed to load executive access",
+			})
+		}
+		if term == nil {
+			log.Printf("[AttachOwnerContext] No current term for executive_id=%d, denying access", executive.ID)
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "your term of office has ended; contact an administrator",
+			})
 		}
 
+		role := repositories.RoleForTermLevel(term.Level)
+
 		log.Printf("[AttachOwnerContext] Found executive record for user_id=%d, executive_role=%s", userID, role)
 
-		// Create owner context based on executive role
+		// Create owner context based on the term's level
 		ownerCtx := utils.GetOwnerContextFromExecutive(
 			role,
-			executive.RegionId,
-			executive.ZoneId,
+			term.RegionId,
+			term.ZoneId,
 			userID,
 		)
 
@@ -0,0 +1,17 @@
+-- Migration: Add parent/child hierarchy to finance_accounts
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Turns the flat finance account list into a chart of accounts. Heading accounts
+--              (is_header) group sub-accounts through parent_id and only carry rolled-up
+--              totals; postings, expenses and budgets go to the sub-accounts. A child shares
+--              its parent's account type and owner, and its code extends the parent's code
+--              (e.g. 41 Dues Income > 4120 Zonal Dues).
+
+-- ============================================
+-- Finance accounts
+-- ============================================
+ALTER TABLE `finance_accounts`
+    ADD COLUMN `parent_id` BIGINT UNSIGNED NULL DEFAULT NULL COMMENT 'Heading account this account rolls up into' AFTER `system_role`,
+    ADD COLUMN `is_header` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Heading accounts group sub-accounts and cannot be posted to' AFTER `parent_id`;
+
+CREATE INDEX `idx_finance_accounts_parent_id` ON `finance_accounts` (`parent_id`);
@@ -0,0 +1,40 @@
+-- Migration: Add expense workflow fields and receipt attachments
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Adds submit/approve/reject/pay tracking to finance_expenses and a table
+--              for receipt attachments uploaded against an expense
+
+-- ============================================
+-- 1. Workflow fields on finance_expenses
+-- ============================================
+ALTER TABLE finance_expenses
+    ADD COLUMN payment_mode VARCHAR(50) NULL COMMENT 'Cash, Cheque, Bank Transfer, MoMo',
+    ADD COLUMN submitted_at DATETIME NULL,
+    ADD COLUMN approved_at DATETIME NULL,
+    ADD COLUMN rejected_at DATETIME NULL,
+    ADD COLUMN paid_at DATETIME NULL,
+    ADD COLUMN paid_by BIGINT NULL,
+    ADD COLUMN is_deleted TINYINT(1) NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_finance_expenses_status ON finance_expenses(status);
+CREATE INDEX idx_finance_expenses_transaction_date ON finance_expenses(transaction_date);
+CREATE INDEX idx_finance_expenses_owner ON finance_expenses(owner_type, owner_id);
+
+-- ============================================
+-- 2. Receipt attachments
+-- ============================================
+CREATE TABLE IF NOT EXISTS `finance_expense_attachments` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `finance_expense_id` BIGINT UNSIGNED NOT NULL,
+    `file_url` VARCHAR(500) NOT NULL,
+    `file_name` VARCHAR(255) NULL DEFAULT NULL,
+    `file_type` VARCHAR(100) NULL DEFAULT NULL,
+    `file_size` BIGINT NULL DEFAULT NULL,
+    `uploaded_by` BIGINT NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_finance_expense_attachments_expense` (`finance_expense_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,17 @@
+-- Migration: Add levels to positions
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Each position records the levels it is held at (national, region and/or zone). The
+--              org chart shows a position as vacant at every region or zone of a level it applies
+--              to when nobody holds it there. Existing positions get the levels they have been held
+--              at so far; positions with no levels are never shown as vacant.
+
+ALTER TABLE positions
+    ADD COLUMN `levels` SET('national', 'region', 'zone') NULL DEFAULT NULL AFTER `name`;
+
+UPDATE positions
+SET levels = (
+    SELECT GROUP_CONCAT(DISTINCT executive_terms.level)
+    FROM executive_terms
+    WHERE executive_terms.position_id = positions.id
+);
@@ -0,0 +1,11 @@
+-- Migration: Add refunded_transaction_id to finance_transactions
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Links a refund to the payment it returns, so the refunds against a payment can be
+--              summed and kept within its amount. Refunds recorded before this migration are not
+--              linked.
+
+ALTER TABLE finance_transactions
+    ADD COLUMN refunded_transaction_id BIGINT UNSIGNED NULL DEFAULT NULL COMMENT 'Payment this refund returns; NULL for payments and unlinked refunds';
+
+CREATE INDEX idx_finance_transactions_refunded_transaction ON finance_transactions(refunded_transaction_id);
@@ -0,0 +1,12 @@
+-- Migration: Add school admin invites
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Schools admitted from a membership application get an invite to set their school
+--              admin password instead of the default member_no + "123". School admin invites use
+--              the executive_invites table with executive_id 0 and the school on school_id; they are
+--              accepted on the same page. Pending invites are now revoked per user account.
+
+ALTER TABLE executive_invites
+    MODIFY COLUMN `executive_id` BIGINT NOT NULL DEFAULT 0 COMMENT '0 for school admin invites',
+    ADD COLUMN `school_id` BIGINT NULL DEFAULT NULL AFTER `executive_id`,
+    ADD INDEX `idx_executive_invites_school_id` (`school_id`);
@@ -0,0 +1,12 @@
+-- Migration: Add geolocation to schools
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Latitude and longitude for each school, used for nearest-school and bounding box
+--              search and GeoJSON exports. gps_address now holds a validated, normalized Ghana Post
+--              GPS digital address (e.g. GA-492-7381).
+
+ALTER TABLE schools
+    ADD COLUMN latitude DECIMAL(10,7) NULL DEFAULT NULL AFTER gps_address,
+    ADD COLUMN longitude DECIMAL(10,7) NULL DEFAULT NULL AFTER latitude;
+
+CREATE INDEX idx_schools_latitude_longitude ON schools(latitude, longitude);
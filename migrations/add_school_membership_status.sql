@@ -0,0 +1,41 @@
+-- Migration: Add membership status to schools
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Tracks whether a school's membership is active, lapsed (dues unpaid for too long)
+--              or suspended by an admin, with a log of every change. Only active schools get
+--              member prices for events, membership certificates and a vote. Events gain a
+--              member price for that discount.
+
+-- ============================================
+-- 1. Status on schools
+-- ============================================
+ALTER TABLE schools
+    ADD COLUMN membership_status VARCHAR(20) NOT NULL DEFAULT 'active' COMMENT 'active, lapsed or suspended',
+    ADD COLUMN status_reason TEXT NULL,
+    ADD COLUMN status_changed_at DATETIME(3) NULL DEFAULT NULL;
+
+CREATE INDEX idx_schools_membership_status ON schools(membership_status);
+
+-- ============================================
+-- 2. Status change log
+-- ============================================
+CREATE TABLE IF NOT EXISTS `school_status_changes` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `school_id` BIGINT NOT NULL,
+    `from_status` VARCHAR(20) NULL DEFAULT NULL,
+    `to_status` VARCHAR(20) NOT NULL,
+    `reason` TEXT NULL,
+    `changed_by` BIGINT NULL DEFAULT NULL COMMENT 'NULL when changed by the nightly status job',
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_school_status_changes_school_id` (`school_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================
+-- 3. Member price on events
+-- ============================================
+ALTER TABLE events
+    ADD COLUMN member_price DECIMAL(10,2) NULL DEFAULT NULL COMMENT 'Price for active member schools; others pay price' AFTER price;
@@ -0,0 +1,94 @@
+-- Migration: Create bank accounts and statement reconciliation tables
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Bank and MoMo wallet accounts per owner, the account each finance transaction
+--              was received into, and imported bank statements whose lines are matched to
+--              cash-book entries (receipts and paid expenses) for reconciliation
+
+-- ============================================
+-- 1. Bank and MoMo wallet accounts
+-- ============================================
+CREATE TABLE IF NOT EXISTS `bank_accounts` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `name` VARCHAR(255) NOT NULL,
+    `account_type` VARCHAR(20) NOT NULL COMMENT 'bank, momo',
+    `bank_name` VARCHAR(255) NULL DEFAULT NULL COMMENT 'Bank name or MoMo network',
+    `branch` VARCHAR(255) NULL DEFAULT NULL,
+    `account_number` VARCHAR(100) NOT NULL COMMENT 'Account number or wallet phone number',
+    `currency` VARCHAR(10) NOT NULL DEFAULT 'GHS',
+    `opening_balance` DECIMAL(15,2) NOT NULL DEFAULT 0,
+    `opening_date` DATE NULL DEFAULT NULL,
+    `is_default` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Default account of its type for the owner',
+    `is_active` TINYINT(1) NOT NULL DEFAULT 1,
+    `is_deleted` TINYINT(1) NOT NULL DEFAULT 0,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_bank_accounts_owner` (`owner_type`, `owner_id`),
+    INDEX `idx_bank_accounts_account_type` (`account_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================
+-- 2. Account each transaction hit
+-- ============================================
+ALTER TABLE finance_transactions
+    ADD COLUMN bank_account_id BIGINT UNSIGNED NULL DEFAULT NULL COMMENT 'Bank or MoMo account received into; NULL for cash';
+
+CREATE INDEX idx_finance_transactions_bank_account ON finance_transactions(bank_account_id);
+CREATE INDEX idx_finance_expenses_bank_account ON finance_expenses(bank_account_id);
+
+-- ============================================
+-- 3. Imported statements
+-- ============================================
+CREATE TABLE IF NOT EXISTS `bank_statements` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `bank_account_id` BIGINT UNSIGNED NOT NULL,
+    `file_name` VARCHAR(255) NULL DEFAULT NULL,
+    `period_start` DATE NULL DEFAULT NULL,
+    `period_end` DATE NULL DEFAULT NULL,
+    `closing_balance` DECIMAL(15,2) NULL DEFAULT NULL,
+    `line_count` INT NOT NULL DEFAULT 0,
+    `duplicate_count` INT NOT NULL DEFAULT 0,
+    `imported_by` BIGINT NULL DEFAULT NULL,
+    `is_deleted` TINYINT(1) NOT NULL DEFAULT 0,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_bank_statements_bank_account` (`bank_account_id`),
+    INDEX `idx_bank_statements_owner` (`owner_type`, `owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `bank_statement_lines` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `bank_statement_id` BIGINT UNSIGNED NOT NULL,
+    `bank_account_id` BIGINT UNSIGNED NOT NULL,
+    `transaction_date` DATE NOT NULL,
+    `description` VARCHAR(500) NULL DEFAULT NULL,
+    `reference` VARCHAR(255) NULL DEFAULT NULL,
+    `amount` DECIMAL(15,2) NOT NULL COMMENT 'Positive for money in, negative for money out',
+    `balance` DECIMAL(15,2) NULL DEFAULT NULL COMMENT 'Running balance as printed on the statement',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'unmatched' COMMENT 'unmatched, matched, excluded',
+    `matched_type` VARCHAR(50) NULL DEFAULT NULL COMMENT 'FinanceTransaction, FinanceExpense',
+    `matched_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `note` VARCHAR(500) NULL DEFAULT NULL,
+    `reconciled_by` BIGINT NULL DEFAULT NULL,
+    `reconciled_at` DATETIME(3) NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_bank_statement_lines_statement` (`bank_statement_id`),
+    INDEX `idx_bank_statement_lines_account_status` (`bank_account_id`, `status`),
+    INDEX `idx_bank_statement_lines_matched` (`matched_type`, `matched_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,53 @@
+-- Migration: Create budgets and budget_alerts tables
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Annual budgets per finance account, per owner and fiscal year, with an optional
+--              monthly split, and a log of alerts raised when spending passes the threshold
+
+CREATE TABLE IF NOT EXISTS `budgets` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `finance_account_id` BIGINT NOT NULL,
+    `fiscal_year` INT NOT NULL,
+    `amount` DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Annual budget',
+    `monthly_amounts` JSON NULL COMMENT 'Optional 12-element array of monthly budgets (Jan..Dec)',
+    `alert_threshold` DECIMAL(5,2) NOT NULL DEFAULT 80.00 COMMENT 'Percentage consumed that raises an alert',
+    `notes` TEXT NULL,
+    `created_by` BIGINT NULL DEFAULT NULL,
+    `is_deleted` TINYINT(1) NOT NULL DEFAULT 0,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_budgets_account_year` (`finance_account_id`, `fiscal_year`),
+    INDEX `idx_budgets_owner` (`owner_type`, `owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `budget_alerts` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `budget_id` BIGINT UNSIGNED NOT NULL,
+    `finance_account_id` BIGINT NOT NULL,
+    `fiscal_year` INT NOT NULL,
+    `budget_amount` DECIMAL(15,2) NOT NULL DEFAULT 0,
+    `actual_amount` DECIMAL(15,2) NOT NULL DEFAULT 0,
+    `percent_consumed` DECIMAL(7,2) NOT NULL DEFAULT 0,
+    `alert_threshold` DECIMAL(5,2) NOT NULL DEFAULT 0,
+    `message` VARCHAR(500) NULL DEFAULT NULL,
+    `is_acknowledged` TINYINT(1) NOT NULL DEFAULT 0,
+    `acknowledged_by` BIGINT NULL DEFAULT NULL,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_budget_alerts_budget` (`budget_id`),
+    INDEX `idx_budget_alerts_owner` (`owner_type`, `owner_id`),
+    CONSTRAINT `fk_budget_alerts_budget`
+        FOREIGN KEY (`budget_id`) REFERENCES `budgets`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
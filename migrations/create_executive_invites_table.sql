@@ -0,0 +1,30 @@
+-- Migration: Create executive_invites table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Invites sent to new executives in place of a default password. Only the SHA-256
+--              hash of the token is stored; the token itself goes out by SMS and email. An invite
+--              can be used once, before it expires, to set the account's password. Resending an
+--              invite revokes the executive's earlier pending ones.
+
+CREATE TABLE IF NOT EXISTS `executive_invites` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `executive_id` BIGINT NOT NULL,
+    `user_id` BIGINT NOT NULL,
+    `token_hash` CHAR(64) NOT NULL,
+    `sent_to_mobile` VARCHAR(50) NULL DEFAULT NULL,
+    `sent_to_email` VARCHAR(255) NULL DEFAULT NULL,
+    `expires_at` DATETIME(3) NOT NULL,
+    `status` VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT 'pending, accepted or revoked',
+    `accepted_at` DATETIME(3) NULL DEFAULT NULL,
+    `revoked_at` DATETIME(3) NULL DEFAULT NULL,
+    `revoked_by` BIGINT NULL DEFAULT NULL,
+    `created_by` BIGINT NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_executive_invites_token_hash` (`token_hash`),
+    INDEX `idx_executive_invites_executive_id` (`executive_id`),
+    INDEX `idx_executive_invites_user_id` (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,56 @@
+-- Migration: Create executive_terms table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Terms of office held by executives. Each term records the position, the level it is
+--              held at (national, region or zone), when it started and ended and how it ended.
+--              Admin access comes from an executive's current terms only; the position, role,
+--              region and zone on the executive record mirror the current term. A term is current
+--              while it is active, has started and has not passed its end date.
+
+-- ============================================================================
+-- 1. executive_terms
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS `executive_terms` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `executive_id` BIGINT NOT NULL,
+    `position_id` BIGINT NULL DEFAULT NULL,
+    `level` VARCHAR(20) NOT NULL COMMENT 'national, region or zone',
+    `region_id` BIGINT NULL DEFAULT NULL,
+    `zone_id` BIGINT NULL DEFAULT NULL,
+    `start_date` DATE NOT NULL,
+    `end_date` DATE NULL DEFAULT NULL COMMENT 'Scheduled end; NULL for an open-ended term',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'active' COMMENT 'active or ended',
+    `ended_on` DATE NULL DEFAULT NULL,
+    `end_reason` VARCHAR(20) NULL DEFAULT NULL COMMENT 'completed, resigned, removed, reassigned, deceased or other',
+    `notes` TEXT NULL,
+    `created_by` BIGINT NULL DEFAULT NULL,
+    `ended_by` BIGINT NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_executive_terms_executive_id` (`executive_id`),
+    INDEX `idx_executive_terms_position_level` (`position_id`, `level`),
+    INDEX `idx_executive_terms_region_id` (`region_id`),
+    INDEX `idx_executive_terms_zone_id` (`zone_id`),
+    INDEX `idx_executive_terms_status_end_date` (`status`, `end_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================================================
+-- 2. Open a term for every active executive, starting when their record was created.
+--    Executives marked inactive get no term and lose admin access.
+-- ============================================================================
+
+INSERT INTO `executive_terms` (`created_at`, `updated_at`, `executive_id`, `position_id`, `level`, `region_id`, `zone_id`, `start_date`, `status`)
+SELECT NOW(3), NOW(3), e.`id`, e.`position_id`,
+       CASE e.`role` WHEN 'national_admin' THEN 'national' WHEN 'region_admin' THEN 'region' ELSE 'zone' END,
+       CASE WHEN e.`role` = 'national_admin' THEN NULL ELSE e.`region_id` END,
+       CASE WHEN e.`role` = 'zone_admin' THEN e.`zone_id` ELSE NULL END,
+       DATE(COALESCE(e.`created_at`, NOW())), 'active'
+FROM `executives` e
+WHERE (e.`is_deleted` = 0 OR e.`is_deleted` IS NULL)
+  AND e.`role` IN ('national_admin', 'region_admin', 'zone_admin')
+  AND (e.`status` = 'active' OR e.`status` IS NULL)
+  AND NOT EXISTS (SELECT 1 FROM `executive_terms` t WHERE t.`executive_id` = e.`id`);
@@ -0,0 +1,32 @@
+-- Migration: Create export_jobs table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Background finance report exports (CSV/XLSX/PDF). Large exports are generated by a
+--              worker and downloaded later from the stored file
+
+CREATE TABLE IF NOT EXISTS `export_jobs` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `resource_type` VARCHAR(50) NOT NULL COMMENT 'momo-payments, transactions, expenses, income-statement, cash-flow',
+    `format` VARCHAR(10) NOT NULL COMMENT 'csv, xlsx, pdf',
+    `params` JSON NULL COMMENT 'Filters and report scope the export was requested with',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'queued' COMMENT 'queued, processing, completed, failed, expired',
+    `file_path` VARCHAR(500) NULL DEFAULT NULL,
+    `file_name` VARCHAR(255) NULL DEFAULT NULL,
+    `row_count` INT NOT NULL DEFAULT 0,
+    `error_message` TEXT NULL,
+    `requested_by` BIGINT NULL DEFAULT NULL,
+    `started_at` DATETIME(3) NULL DEFAULT NULL,
+    `completed_at` DATETIME(3) NULL DEFAULT NULL,
+    `expires_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_export_jobs_requested_by` (`requested_by`),
+    INDEX `idx_export_jobs_status` (`status`),
+    INDEX `idx_export_jobs_owner` (`owner_type`, `owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
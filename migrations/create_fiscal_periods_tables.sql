@@ -0,0 +1,46 @@
+-- Migration: Create fiscal_periods and fiscal_period_audits tables
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Fiscal periods per owner. Once a period is closed, finance transactions, expenses,
+--              school bill payments and manual journal entries dated inside it can no longer be
+--              created, changed or deleted. Every close and reopen is recorded in
+--              fiscal_period_audits.
+
+CREATE TABLE IF NOT EXISTS `fiscal_periods` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `name` VARCHAR(100) NOT NULL,
+    `start_date` DATE NOT NULL,
+    `end_date` DATE NOT NULL,
+    `status` VARCHAR(20) NOT NULL DEFAULT 'open' COMMENT 'open, closed',
+    `closed_by` BIGINT NULL DEFAULT NULL,
+    `closed_at` DATETIME(3) NULL DEFAULT NULL,
+    `is_deleted` TINYINT(1) NOT NULL DEFAULT 0,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_fiscal_periods_owner_dates` (`owner_type`, `owner_id`, `start_date`, `end_date`),
+    INDEX `idx_fiscal_periods_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `fiscal_period_audits` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `fiscal_period_id` BIGINT UNSIGNED NOT NULL,
+    `action` VARCHAR(20) NOT NULL COMMENT 'closed, reopened',
+    `reason` TEXT NULL,
+    `performed_by` BIGINT NULL DEFAULT NULL,
+    `performed_role` VARCHAR(50) NULL DEFAULT NULL,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_fiscal_period_audits_period` (`fiscal_period_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
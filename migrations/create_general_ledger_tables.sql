@@ -0,0 +1,67 @@
+-- Migration: Create general ledger tables
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Double-entry journal behind finance_transactions. Every payment, expense,
+--              refund and discount posts a balanced journal entry against finance_accounts.
+
+-- ============================================
+-- 1. System account roles on finance_accounts
+-- ============================================
+-- Marks the default accounts the ledger posts to for an owner (cash, momo, bank,
+-- receivable, payable, income, expense, discount, refund)
+ALTER TABLE finance_accounts
+    ADD COLUMN system_role VARCHAR(50) NULL COMMENT 'Default ledger account role for the owner';
+
+CREATE INDEX idx_finance_accounts_system_role ON finance_accounts(system_role, owner_type, owner_id);
+
+-- ============================================
+-- 2. Journal entries
+-- ============================================
+CREATE TABLE IF NOT EXISTS `journal_entries` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `entry_no` VARCHAR(50) NOT NULL,
+    `entry_date` DATETIME NOT NULL,
+    `description` TEXT NULL,
+    `source_type` VARCHAR(50) NOT NULL COMMENT 'Payment, ExpenseApproval, ExpensePayment, Refund, Discount, Manual, Reversal',
+    `source_id` BIGINT NULL DEFAULT NULL,
+    `finance_transaction_id` BIGINT NULL DEFAULT NULL,
+    `school_id` BIGINT NULL DEFAULT NULL,
+    `total_debit` DECIMAL(15,2) NOT NULL DEFAULT 0,
+    `total_credit` DECIMAL(15,2) NOT NULL DEFAULT 0,
+    `is_reversed` TINYINT(1) NOT NULL DEFAULT 0,
+    `reversal_of_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+    `posted_by` BIGINT NULL DEFAULT NULL,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_journal_entries_entry_no` (`entry_no`),
+    INDEX `idx_journal_entries_source` (`source_type`, `source_id`),
+    INDEX `idx_journal_entries_entry_date` (`entry_date`),
+    INDEX `idx_journal_entries_owner` (`owner_type`, `owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================
+-- 3. Journal entry lines
+-- ============================================
+CREATE TABLE IF NOT EXISTS `journal_entry_lines` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `journal_entry_id` BIGINT UNSIGNED NOT NULL,
+    `finance_account_id` BIGINT NOT NULL,
+    `debit` DECIMAL(15,2) NOT NULL DEFAULT 0,
+    `credit` DECIMAL(15,2) NOT NULL DEFAULT 0,
+    `description` VARCHAR(255) NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_journal_entry_lines_entry` (`journal_entry_id`),
+    INDEX `idx_journal_entry_lines_account` (`finance_account_id`),
+    CONSTRAINT `fk_journal_entry_lines_entry`
+        FOREIGN KEY (`journal_entry_id`) REFERENCES `journal_entries`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
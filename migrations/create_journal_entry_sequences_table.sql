@@ -0,0 +1,18 @@
+-- Migration: Create journal_entry_sequences table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Holds the last journal entry number issued for each day (JE-YYYYMMDD). The row is
+--              locked while a number is issued, so concurrent postings get distinct entry numbers
+--              instead of colliding on idx_journal_entries_entry_no.
+
+CREATE TABLE IF NOT EXISTS `journal_entry_sequences` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `sequence_key` VARCHAR(50) NOT NULL COMMENT 'entry number prefix, e.g. JE-20261018',
+    `last_value` BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_journal_entry_sequences_sequence_key` (`sequence_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,65 @@
+-- Migration: Create member_no_schemes, member_no_sequences and member_no_changes tables
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Configurable member number patterns. A scheme sets the pattern for a zone, for
+--              every zone of a region, or nationally; the most specific one applies. Patterns are
+--              built from placeholders, e.g. {REGION}/{ZONE}/{YEAR}/{SEQ:4} gives GA/ACC/2026/0001.
+--              Zones without a scheme keep the original numbering ({ZONE_PREFIX}-{SEQ:3}, e.g.
+--              ACC-001), and existing member numbers are left as they are.
+--              member_no_sequences holds the last number allocated for each pattern prefix and is
+--              locked while a number is allocated. member_no_changes maps old to new numbers for
+--              every school re-numbered under a scheme.
+
+-- ============================================
+-- 1. Numbering schemes
+-- ============================================
+CREATE TABLE IF NOT EXISTS `member_no_schemes` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `scope_type` VARCHAR(20) NOT NULL COMMENT 'national, region or zone',
+    `scope_id` BIGINT NOT NULL DEFAULT 0 COMMENT 'region or zone id; 0 for national',
+    `pattern` VARCHAR(100) NOT NULL,
+    `description` VARCHAR(255) NULL DEFAULT NULL,
+    `created_by` BIGINT NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_member_no_schemes_scope` (`scope_type`, `scope_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================
+-- 2. Sequence counters
+-- ============================================
+CREATE TABLE IF NOT EXISTS `member_no_sequences` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `sequence_key` VARCHAR(191) NOT NULL COMMENT 'pattern with everything but the sequence filled in, e.g. GA/ACC/2026/#',
+    `last_value` BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_member_no_sequences_sequence_key` (`sequence_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================
+-- 3. Re-numbering log
+-- ============================================
+CREATE TABLE IF NOT EXISTS `member_no_changes` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `batch_id` VARCHAR(50) NOT NULL COMMENT 'one re-numbering run',
+    `school_id` BIGINT NOT NULL,
+    `old_member_no` VARCHAR(50) NOT NULL,
+    `new_member_no` VARCHAR(50) NOT NULL,
+    `pattern` VARCHAR(100) NOT NULL,
+    `changed_by` BIGINT NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_member_no_changes_batch_id` (`batch_id`),
+    INDEX `idx_member_no_changes_school_id` (`school_id`),
+    INDEX `idx_member_no_changes_old_member_no` (`old_member_no`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,35 @@
+-- Migration: Create membership_applications table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Schools registering through the public form are held as pending applications
+--              until an admin covering the zone approves or rejects them. Approval creates the
+--              school, its contact persons and school admin user and assigns the member number;
+--              rejection records the reason.
+
+CREATE TABLE IF NOT EXISTS `membership_applications` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `name` VARCHAR(255) NOT NULL,
+    `zone_id` BIGINT NOT NULL,
+    `address` VARCHAR(255) NULL DEFAULT NULL,
+    `location` VARCHAR(255) NULL DEFAULT NULL,
+    `mobile_no` VARCHAR(50) NULL DEFAULT NULL,
+    `email` VARCHAR(255) NULL DEFAULT NULL,
+    `gps_address` VARCHAR(100) NULL DEFAULT NULL,
+    `date_of_establishment` DATE NULL DEFAULT NULL,
+    `contact_persons` JSON NULL COMMENT 'Contact persons submitted with the application',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT 'pending, approved, rejected',
+    `review_notes` TEXT NULL,
+    `rejection_reason` TEXT NULL,
+    `reviewed_by` BIGINT NULL DEFAULT NULL,
+    `reviewed_at` DATETIME(3) NULL DEFAULT NULL,
+    `school_id` BIGINT NULL DEFAULT NULL COMMENT 'School created on approval',
+    `member_no` VARCHAR(50) NULL DEFAULT NULL COMMENT 'Member number assigned on approval',
+    `is_deleted` TINYINT(1) NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_membership_applications_zone_status` (`zone_id`, `status`),
+    INDEX `idx_membership_applications_school_id` (`school_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
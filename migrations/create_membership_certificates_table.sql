@@ -0,0 +1,34 @@
+-- Migration: Create membership_certificates table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Certificates of GNAPS membership issued to schools in good standing. The school's
+--              name, member number, zone and region are kept as printed so the certificate still
+--              verifies after the school is renamed or transferred. Anyone can check a certificate
+--              by its verification code; revoked or superseded certificates no longer verify.
+
+CREATE TABLE IF NOT EXISTS `membership_certificates` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `school_id` BIGINT NOT NULL,
+    `certificate_no` VARCHAR(50) NOT NULL,
+    `verification_code` VARCHAR(20) NOT NULL,
+    `school_name` VARCHAR(255) NOT NULL,
+    `member_no` VARCHAR(50) NOT NULL,
+    `zone_id` BIGINT NULL DEFAULT NULL,
+    `zone_name` VARCHAR(255) NULL DEFAULT NULL,
+    `region_name` VARCHAR(255) NULL DEFAULT NULL,
+    `valid_from` DATE NOT NULL,
+    `valid_until` DATE NOT NULL,
+    `status` VARCHAR(20) NOT NULL DEFAULT 'active' COMMENT 'active, revoked or superseded',
+    `issued_by` BIGINT NULL DEFAULT NULL,
+    `revoked_at` DATETIME(3) NULL DEFAULT NULL,
+    `revoked_by` BIGINT NULL DEFAULT NULL,
+    `revocation_reason` TEXT NULL,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_membership_certificates_certificate_no` (`certificate_no`),
+    UNIQUE INDEX `idx_membership_certificates_verification_code` (`verification_code`),
+    INDEX `idx_membership_certificates_school_id` (`school_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
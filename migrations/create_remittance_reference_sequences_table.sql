@@ -0,0 +1,18 @@
+-- Migration: Create remittance_reference_sequences table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Holds the last remittance reference number issued for each day (RMT-YYYYMMDD). The
+--              row is locked while a number is issued, so remittances recorded at the same time get
+--              distinct reference numbers.
+
+CREATE TABLE IF NOT EXISTS `remittance_reference_sequences` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `sequence_key` VARCHAR(50) NOT NULL COMMENT 'reference number prefix, e.g. RMT-20261018',
+    `last_value` BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_remittance_reference_sequences_sequence_key` (`sequence_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
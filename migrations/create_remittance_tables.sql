@@ -0,0 +1,63 @@
+-- Migration: Create payment_allocations and remittances tables
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Splits school bill payments across billing particulars so each level's share
+--              (national levy, regional levy, zonal dues) is known, and records remittances
+--              between levels so outstanding inter-level balances can be reported.
+--              owner_type/owner_id on payment_allocations is the collecting owner; on
+--              remittances it is the paying owner.
+
+CREATE TABLE IF NOT EXISTS `payment_allocations` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `finance_transaction_id` BIGINT UNSIGNED NOT NULL,
+    `school_bill_id` BIGINT UNSIGNED NOT NULL,
+    `school_billing_particular_id` BIGINT UNSIGNED NULL DEFAULT NULL COMMENT 'NULL for overpayments kept by the collector',
+    `school_id` BIGINT NULL DEFAULT NULL,
+    `particular_name` VARCHAR(255) NULL DEFAULT NULL,
+    `amount` DECIMAL(15,2) NOT NULL DEFAULT 0 COMMENT 'Negative for refunds',
+    `allocation_date` DATETIME(3) NOT NULL,
+    `receiver_owner_type` VARCHAR(50) NOT NULL,
+    `receiver_owner_id` BIGINT UNSIGNED NOT NULL,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_payment_allocations_transaction` (`finance_transaction_id`),
+    INDEX `idx_payment_allocations_school_bill` (`school_bill_id`),
+    INDEX `idx_payment_allocations_receiver` (`receiver_owner_type`, `receiver_owner_id`),
+    INDEX `idx_payment_allocations_owner` (`owner_type`, `owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `remittances` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `reference_no` VARCHAR(50) NOT NULL,
+    `to_owner_type` VARCHAR(50) NOT NULL,
+    `to_owner_id` BIGINT UNSIGNED NOT NULL,
+    `amount` DECIMAL(15,2) NOT NULL DEFAULT 0,
+    `remittance_date` DATETIME(3) NOT NULL,
+    `payment_mode` VARCHAR(50) NULL DEFAULT NULL,
+    `transaction_reference` VARCHAR(100) NULL DEFAULT NULL COMMENT 'Bank or MoMo reference',
+    `notes` TEXT NULL,
+    `status` VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT 'pending, confirmed, rejected',
+    `recorded_by` BIGINT NULL DEFAULT NULL,
+    `confirmed_by` BIGINT NULL DEFAULT NULL,
+    `confirmed_at` DATETIME(3) NULL DEFAULT NULL,
+    `rejected_reason` TEXT NULL,
+    `is_deleted` TINYINT(1) NOT NULL DEFAULT 0,
+
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_remittances_reference_no` (`reference_no`),
+    INDEX `idx_remittances_to_owner` (`to_owner_type`, `to_owner_id`),
+    INDEX `idx_remittances_owner` (`owner_type`, `owner_id`),
+    INDEX `idx_remittances_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,43 @@
+-- Migration: Create school_bill_revisions table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Records every adjustment made to an issued school bill when a bill item's
+--              amount or targeting changes, so schools can see why their bill changed
+
+CREATE TABLE IF NOT EXISTS `school_bill_revisions` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    -- What was revised
+    `school_bill_id` BIGINT NOT NULL,
+    `school_id` BIGINT NULL DEFAULT NULL,
+    `bill_id` BIGINT NULL DEFAULT NULL,
+    `bill_item_id` BIGINT NULL DEFAULT NULL,
+    `school_billing_particular_id` BIGINT NULL DEFAULT NULL,
+
+    -- Revision details
+    `revision_type` VARCHAR(50) NOT NULL COMMENT 'item_added, item_amount_changed, item_removed',
+    `particular_name` VARCHAR(255) NULL DEFAULT NULL,
+    `previous_amount` DECIMAL(15,2) NULL DEFAULT 0,
+    `new_amount` DECIMAL(15,2) NULL DEFAULT 0,
+    `delta_amount` DECIMAL(15,2) NULL DEFAULT 0,
+    `previous_bill_amount` DECIMAL(15,2) NULL DEFAULT 0,
+    `new_bill_amount` DECIMAL(15,2) NULL DEFAULT 0,
+    `previous_balance` DECIMAL(15,2) NULL DEFAULT 0,
+    `new_balance` DECIMAL(15,2) NULL DEFAULT 0,
+    `amount_paid` DECIMAL(15,2) NULL DEFAULT 0 COMMENT 'Amount already paid on the bill, kept unchanged',
+    `reason` TEXT NULL,
+    `revised_by` BIGINT NULL DEFAULT NULL,
+
+    -- Ownership (copied from the school bill)
+    `owner_type` VARCHAR(50) NULL DEFAULT NULL,
+    `owner_id` BIGINT UNSIGNED NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_school_bill_revisions_school_bill` (`school_bill_id`),
+    INDEX `idx_school_bill_revisions_school` (`school_id`),
+    INDEX `idx_school_bill_revisions_bill_item` (`bill_item_id`),
+    INDEX `idx_school_bill_revisions_created_at` (`created_at`),
+    INDEX `idx_school_bill_revisions_owner` (`owner_type`, `owner_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
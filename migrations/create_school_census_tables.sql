@@ -0,0 +1,57 @@
+-- Migration: Create census_fields and school_censuses tables
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Annual school census. National admins configure the census form as a list of fields
+--              (enrolment by level, teacher counts, fees band, ...); each school fills in one census
+--              per academic year, which its zone admin verifies. Answers are stored as JSON keyed by
+--              field key, so fields can be added or retired without losing earlier years. Fields are
+--              deactivated rather than deleted. The school's zone is kept on the census so reports
+--              for a year are not changed by later zone transfers.
+
+-- ============================================
+-- 1. Census form fields
+-- ============================================
+CREATE TABLE IF NOT EXISTS `census_fields` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `field_key` VARCHAR(100) NOT NULL COMMENT 'e.g. enrolment_primary',
+    `label` VARCHAR(255) NOT NULL,
+    `section` VARCHAR(100) NULL DEFAULT NULL COMMENT 'e.g. Enrolment, Staff, Fees',
+    `field_type` VARCHAR(20) NOT NULL DEFAULT 'number' COMMENT 'number, text, select or boolean',
+    `options` JSON NULL COMMENT 'choices of a select field, e.g. fees bands',
+    `unit` VARCHAR(50) NULL DEFAULT NULL,
+    `is_required` TINYINT(1) NOT NULL DEFAULT 0,
+    `position` INT NOT NULL DEFAULT 0,
+    `is_active` TINYINT(1) NOT NULL DEFAULT 1,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_census_fields_field_key` (`field_key`),
+    INDEX `idx_census_fields_is_active_position` (`is_active`, `position`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================
+-- 2. School census returns
+-- ============================================
+CREATE TABLE IF NOT EXISTS `school_censuses` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `school_id` BIGINT NOT NULL,
+    `zone_id` BIGINT NULL DEFAULT NULL COMMENT 'zone of the school when the census was saved',
+    `academic_year` VARCHAR(9) NOT NULL COMMENT 'e.g. 2025/2026',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'draft' COMMENT 'draft, submitted, verified or returned',
+    `data` JSON NULL,
+    `submitted_at` DATETIME(3) NULL DEFAULT NULL,
+    `submitted_by` BIGINT NULL DEFAULT NULL,
+    `verified_at` DATETIME(3) NULL DEFAULT NULL,
+    `verified_by` BIGINT NULL DEFAULT NULL,
+    `review_notes` TEXT NULL,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_school_censuses_school_year` (`school_id`, `academic_year`),
+    INDEX `idx_school_censuses_year_status` (`academic_year`, `status`),
+    INDEX `idx_school_censuses_zone_id` (`zone_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
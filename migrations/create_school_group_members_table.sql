@@ -0,0 +1,63 @@
+-- Migration: Create school_group_members and school_group_targets tables
+-- Created: 2026-10-18
+-- Database: MySQL (8.0+ for JSON_TABLE in the backfill)
+-- Description: School group membership and group targeting of news and bill items as join tables,
+--              replacing scans of the JSON id lists. The JSON columns (schools.school_group_ids,
+--              news.school_group_ids, bill_items.school_group_ids) are kept in step with these
+--              tables for existing API clients, but lookups use the tables.
+
+-- ============================================
+-- 1. Group membership
+-- ============================================
+CREATE TABLE IF NOT EXISTS `school_group_members` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `school_group_id` BIGINT NOT NULL,
+    `school_id` BIGINT NOT NULL,
+    `added_by` BIGINT NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_school_group_members_group_school` (`school_group_id`, `school_id`),
+    INDEX `idx_school_group_members_school_id` (`school_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================
+-- 2. Group targeting of news and bill items
+-- ============================================
+CREATE TABLE IF NOT EXISTS `school_group_targets` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `school_group_id` BIGINT NOT NULL,
+    `target_type` VARCHAR(20) NOT NULL COMMENT 'news or bill_item',
+    `target_id` BIGINT NOT NULL,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_school_group_targets_target` (`target_type`, `target_id`, `school_group_id`),
+    INDEX `idx_school_group_targets_group` (`school_group_id`, `target_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- ============================================
+-- 3. Backfill from the JSON columns
+-- ============================================
+INSERT IGNORE INTO school_group_members (created_at, updated_at, school_group_id, school_id)
+SELECT NOW(3), NOW(3), jt.school_group_id, s.id
+FROM schools s
+JOIN JSON_TABLE(s.school_group_ids, '$[*]' COLUMNS (school_group_id BIGINT PATH '$')) jt
+JOIN school_groups g ON g.id = jt.school_group_id AND g.is_deleted = 0
+WHERE s.school_group_ids IS NOT NULL AND s.is_deleted = 0;
+
+INSERT IGNORE INTO school_group_targets (created_at, updated_at, school_group_id, target_type, target_id)
+SELECT NOW(3), NOW(3), jt.school_group_id, 'news', n.id
+FROM news n
+JOIN JSON_TABLE(n.school_group_ids, '$[*]' COLUMNS (school_group_id BIGINT PATH '$')) jt
+WHERE n.school_group_ids IS NOT NULL;
+
+INSERT IGNORE INTO school_group_targets (created_at, updated_at, school_group_id, target_type, target_id)
+SELECT NOW(3), NOW(3), jt.school_group_id, 'bill_item', b.id
+FROM bill_items b
+JOIN JSON_TABLE(b.school_group_ids, '$[*]' COLUMNS (school_group_id BIGINT PATH '$')) jt
+WHERE b.school_group_ids IS NOT NULL;
@@ -0,0 +1,27 @@
+-- Migration: Create school_merges table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Audit record of duplicate schools merged into a surviving school. Keeps a snapshot
+--              of the merged school and its contact persons, the number of rows moved per table
+--              and the school bills that were folded into the survivor's bill for the same bill.
+
+CREATE TABLE IF NOT EXISTS `school_merges` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `surviving_school_id` BIGINT NOT NULL,
+    `merged_school_id` BIGINT NOT NULL,
+    `merged_member_no` VARCHAR(50) NULL DEFAULT NULL,
+    `merged_school_name` VARCHAR(255) NULL DEFAULT NULL,
+    `reason` TEXT NULL,
+    `merged_school_snapshot` JSON NULL COMMENT 'The merged school and its contact persons before the merge',
+    `moved_rows` JSON NULL COMMENT 'Rows moved to the surviving school, per table',
+    `folded_bills` JSON NULL COMMENT 'School bills folded into the surviving school''s bill for the same bill',
+    `performed_by` BIGINT NULL DEFAULT NULL,
+    `performed_role` VARCHAR(50) NULL DEFAULT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_school_merges_surviving_school_id` (`surviving_school_id`),
+    INDEX `idx_school_merges_merged_school_id` (`merged_school_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
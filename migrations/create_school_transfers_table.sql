@@ -0,0 +1,34 @@
+-- Migration: Create school_transfers table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Moves of a member school from one zone to another. The sending zone requests the
+--              transfer and the receiving zone accepts or rejects it. The request decides what
+--              happens to the school's outstanding bills and whether it gets a member number in
+--              the receiving zone; the old and new member numbers are kept on the transfer.
+
+CREATE TABLE IF NOT EXISTS `school_transfers` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `school_id` BIGINT NOT NULL,
+    `from_zone_id` BIGINT NOT NULL,
+    `to_zone_id` BIGINT NOT NULL,
+    `status` VARCHAR(20) NOT NULL DEFAULT 'pending' COMMENT 'pending, accepted, rejected or cancelled',
+    `reason` TEXT NULL,
+    `bill_handling` VARCHAR(20) NOT NULL DEFAULT 'keep' COMMENT 'keep, move or settle_first',
+    `issue_new_member_no` TINYINT(1) NOT NULL DEFAULT 1,
+    `old_member_no` VARCHAR(50) NULL DEFAULT NULL,
+    `new_member_no` VARCHAR(50) NULL DEFAULT NULL,
+    `moved_bill_ids` JSON NULL COMMENT 'Outstanding school bills moved to the receiving zone',
+    `requested_by` BIGINT NULL DEFAULT NULL,
+    `decided_by` BIGINT NULL DEFAULT NULL,
+    `decided_at` DATETIME(3) NULL DEFAULT NULL,
+    `decision_notes` TEXT NULL,
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_school_transfers_school_id` (`school_id`),
+    INDEX `idx_school_transfers_from_zone_id` (`from_zone_id`),
+    INDEX `idx_school_transfers_to_zone_id` (`to_zone_id`),
+    INDEX `idx_school_transfers_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,25 @@
+-- Migration: Create school_zone_histories table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: The zones a school has belonged to and the member number it held in each, so reports
+--              attribute payments to the zone the school was in at the time. Rows are written when a
+--              transfer is accepted; a school without rows has only ever been in its current zone.
+--              A NULL started_at covers everything before the first transfer, a NULL ended_at is the
+--              current zone.
+
+CREATE TABLE IF NOT EXISTS `school_zone_histories` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `school_id` BIGINT NOT NULL,
+    `zone_id` BIGINT NOT NULL,
+    `member_no` VARCHAR(50) NULL DEFAULT NULL,
+    `started_at` DATETIME(3) NULL DEFAULT NULL,
+    `ended_at` DATETIME(3) NULL DEFAULT NULL,
+    `school_transfer_id` BIGINT NULL DEFAULT NULL COMMENT 'The transfer that started this period',
+
+    PRIMARY KEY (`id`),
+    INDEX `idx_school_zone_histories_school_id` (`school_id`, `started_at`),
+    INDEX `idx_school_zone_histories_zone_id` (`zone_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,18 @@
+-- Migration: Create voucher_no_sequences table
+-- Created: 2026-10-18
+-- Database: MySQL
+-- Description: Holds the last payment voucher number issued for each day (PV-YYYYMMDD). The row is
+--              locked while a number is issued, so expenses paid at the same time get distinct
+--              voucher numbers.
+
+CREATE TABLE IF NOT EXISTS `voucher_no_sequences` (
+    `id` BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    `created_at` DATETIME(3) NULL DEFAULT NULL,
+    `updated_at` DATETIME(3) NULL DEFAULT NULL,
+
+    `sequence_key` VARCHAR(50) NOT NULL COMMENT 'voucher number prefix, e.g. PV-20261018',
+    `last_value` BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_voucher_no_sequences_sequence_key` (`sequence_key`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+// BankAccount model generated from database table 'bank_accounts'
+type BankAccount struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name           string     `json:"name" gorm:"column:name"`
+	AccountType    string     `json:"account_type" gorm:"column:account_type"`
+	BankName       *string    `json:"bank_name" gorm:"column:bank_name"`
+	Branch         *string    `json:"branch" gorm:"column:branch"`
+	AccountNumber  string     `json:"account_number" gorm:"column:account_number"`
+	Currency       string     `json:"currency" gorm:"column:currency"`
+	OpeningBalance float64    `json:"opening_balance" gorm:"column:opening_balance"`
+	OpeningDate    *time.Time `json:"opening_date" gorm:"column:opening_date"`
+	IsDefault      bool       `json:"is_default" gorm:"column:is_default"`
+	IsActive       bool       `json:"is_active" gorm:"column:is_active"`
+	IsDeleted      bool       `json:"is_deleted" gorm:"column:is_deleted"`
+	OwnerType      *string    `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId        *int64     `json:"owner_id" gorm:"column:owner_id"`
+}
+
+func (BankAccount) TableName() string {
+	return "bank_accounts"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (b *BankAccount) SetOwner(ownerType string, ownerID int64) {
+	b.OwnerType = &ownerType
+	b.OwnerId = &ownerID
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// BankStatement model generated from database table 'bank_statements'
+type BankStatement struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	BankAccountId  uint       `json:"bank_account_id" gorm:"column:bank_account_id"`
+	FileName       *string    `json:"file_name" gorm:"column:file_name"`
+	PeriodStart    *time.Time `json:"period_start" gorm:"column:period_start"`
+	PeriodEnd      *time.Time `json:"period_end" gorm:"column:period_end"`
+	ClosingBalance *float64   `json:"closing_balance" gorm:"column:closing_balance"`
+	LineCount      int        `json:"line_count" gorm:"column:line_count"`
+	DuplicateCount int        `json:"duplicate_count" gorm:"column:duplicate_count"`
+	ImportedBy     *int64     `json:"imported_by" gorm:"column:imported_by"`
+	IsDeleted      bool       `json:"is_deleted" gorm:"column:is_deleted"`
+	OwnerType      *string    `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId        *int64     `json:"owner_id" gorm:"column:owner_id"`
+}
+
+func (BankStatement) TableName() string {
+	return "bank_statements"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (b *BankStatement) SetOwner(ownerType string, ownerID int64) {
+	b.OwnerType = &ownerType
+	b.OwnerId = &ownerID
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// BankStatementLine model generated from database table 'bank_statement_lines'
+type BankStatementLine struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	BankStatementId uint       `json:"bank_statement_id" gorm:"column:bank_statement_id"`
+	BankAccountId   uint       `json:"bank_account_id" gorm:"column:bank_account_id"`
+	TransactionDate time.Time  `json:"transaction_date" gorm:"column:transaction_date"`
+	Description     *string    `json:"description" gorm:"column:description"`
+	Reference       *string    `json:"reference" gorm:"column:reference"`
+	Amount          float64    `json:"amount" gorm:"column:amount"`
+	Balance         *float64   `json:"balance" gorm:"column:balance"`
+	Status          string     `json:"status" gorm:"column:status"`
+	MatchedType     *string    `json:"matched_type" gorm:"column:matched_type"`
+	MatchedId       *uint      `json:"matched_id" gorm:"column:matched_id"`
+	Note            *string    `json:"note" gorm:"column:note"`
+	ReconciledBy    *int64     `json:"reconciled_by" gorm:"column:reconciled_by"`
+	ReconciledAt    *time.Time `json:"reconciled_at" gorm:"column:reconciled_at"`
+}
+
+func (BankStatementLine) TableName() string {
+	return "bank_statement_lines"
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+// Budget model generated from database table 'budgets'
+type Budget struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	FinanceAccountId int64           `json:"finance_account_id" gorm:"column:finance_account_id"`
+	FiscalYear       int             `json:"fiscal_year" gorm:"column:fiscal_year"`
+	Amount           float64         `json:"amount" gorm:"column:amount"`
+	MonthlyAmounts   *datatypes.JSON `json:"monthly_amounts" gorm:"column:monthly_amounts"`
+	AlertThreshold   float64         `json:"alert_threshold" gorm:"column:alert_threshold"`
+	Notes            *string         `json:"notes" gorm:"column:notes"`
+	CreatedBy        *int64          `json:"created_by" gorm:"column:created_by"`
+	IsDeleted        bool            `json:"is_deleted" gorm:"column:is_deleted"`
+	OwnerType        *string         `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId          *int64          `json:"owner_id" gorm:"column:owner_id"`
+
+	// Transient fields (not in database)
+	FinanceAccount *FinanceAccount `json:"finance_account,omitempty" gorm:"foreignKey:FinanceAccountId"`
+}
+
+func (Budget) TableName() string {
+	return "budgets"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (b *Budget) SetOwner(ownerType string, ownerID int64) {
+	b.OwnerType = &ownerType
+	b.OwnerId = &ownerID
+}
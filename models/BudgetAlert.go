@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// BudgetAlert model generated from database table 'budget_alerts'
+type BudgetAlert struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	BudgetId         uint    `json:"budget_id" gorm:"column:budget_id"`
+	FinanceAccountId int64   `json:"finance_account_id" gorm:"column:finance_account_id"`
+	FiscalYear       int     `json:"fiscal_year" gorm:"column:fiscal_year"`
+	BudgetAmount     float64 `json:"budget_amount" gorm:"column:budget_amount"`
+	ActualAmount     float64 `json:"actual_amount" gorm:"column:actual_amount"`
+	PercentConsumed  float64 `json:"percent_consumed" gorm:"column:percent_consumed"`
+	AlertThreshold   float64 `json:"alert_threshold" gorm:"column:alert_threshold"`
+	Message          *string `json:"message" gorm:"column:message"`
+	IsAcknowledged   bool    `json:"is_acknowledged" gorm:"column:is_acknowledged"`
+	AcknowledgedBy   *int64  `json:"acknowledged_by" gorm:"column:acknowledged_by"`
+	OwnerType        *string `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId          *int64  `json:"owner_id" gorm:"column:owner_id"`
+}
+
+func (BudgetAlert) TableName() string {
+	return "budget_alerts"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (b *BudgetAlert) SetOwner(ownerType string, ownerID int64) {
+	b.OwnerType = &ownerType
+	b.OwnerId = &ownerID
+}
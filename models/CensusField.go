@@ -0,0 +1,27 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+// CensusField model generated from database table 'census_fields'
+type CensusField struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	FieldKey   string          `json:"field_key" gorm:"column:field_key"`
+	Label      string          `json:"label" gorm:"column:label"`
+	Section    *string         `json:"section" gorm:"column:section"`
+	FieldType  string          `json:"field_type" gorm:"column:field_type"`
+	Options    *datatypes.JSON `json:"options" gorm:"column:options"`
+	Unit       *string         `json:"unit" gorm:"column:unit"`
+	IsRequired bool            `json:"is_required" gorm:"column:is_required"`
+	Position   int             `json:"position" gorm:"column:position"`
+	IsActive   bool            `json:"is_active" gorm:"column:is_active"`
+}
+
+func (CensusField) TableName() string {
+	return "census_fields"
+}
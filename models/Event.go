@@ -22,6 +22,7 @@ type Event struct {
 	Venue                *string   `json:"venue" gorm:"column:venue"`
 	IsPaid               *bool     `json:"is_paid" gorm:"column:is_paid"`
 	Price                *float64  `json:"price" gorm:"column:price"`
+	MemberPrice          *float64  `json:"member_price" gorm:"column:member_price"`
 	MaxAttendees         *int      `json:"max_attendees" gorm:"column:max_attendees"`
 	RegistrationDeadline time.Time `json:"registration_deadline" gorm:"column:registration_deadline"`
 	Status               *string   `json:"status" gorm:"column:status"`
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// ExecutiveInvite model generated from database table 'executive_invites'
+type ExecutiveInvite struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ExecutiveId  int64      `json:"executive_id" gorm:"column:executive_id"` // 0 for school admin invites
+	SchoolId     *int64     `json:"school_id" gorm:"column:school_id"`
+	UserId       int64      `json:"user_id" gorm:"column:user_id"`
+	TokenHash    string     `json:"-" gorm:"column:token_hash"`
+	SentToMobile *string    `json:"sent_to_mobile" gorm:"column:sent_to_mobile"`
+	SentToEmail  *string    `json:"sent_to_email" gorm:"column:sent_to_email"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"column:expires_at"`
+	Status       string     `json:"status" gorm:"column:status"`
+	AcceptedAt   *time.Time `json:"accepted_at" gorm:"column:accepted_at"`
+	RevokedAt    *time.Time `json:"revoked_at" gorm:"column:revoked_at"`
+	RevokedBy    *int64     `json:"revoked_by" gorm:"column:revoked_by"`
+	CreatedBy    *int64     `json:"created_by" gorm:"column:created_by"`
+
+	// Transient fields (not in database)
+	IsExpired bool `json:"is_expired" gorm:"-"`
+}
+
+func (ExecutiveInvite) TableName() string {
+	return "executive_invites"
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// ExecutiveTerm model generated from database table 'executive_terms'
+type ExecutiveTerm struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ExecutiveId int64      `json:"executive_id" gorm:"column:executive_id"`
+	PositionId  *int64     `json:"position_id" gorm:"column:position_id"`
+	Level       string     `json:"level" gorm:"column:level"`
+	RegionId    *int64     `json:"region_id" gorm:"column:region_id"`
+	ZoneId      *int64     `json:"zone_id" gorm:"column:zone_id"`
+	StartDate   time.Time  `json:"start_date" gorm:"column:start_date"`
+	EndDate     *time.Time `json:"end_date" gorm:"column:end_date"`
+	Status      string     `json:"status" gorm:"column:status"`
+	EndedOn     *time.Time `json:"ended_on" gorm:"column:ended_on"`
+	EndReason   *string    `json:"end_reason" gorm:"column:end_reason"`
+	Notes       *string    `json:"notes" gorm:"column:notes"`
+	CreatedBy   *int64     `json:"created_by" gorm:"column:created_by"`
+	EndedBy     *int64     `json:"ended_by" gorm:"column:ended_by"`
+
+	// Transient fields (not in database)
+	IsCurrent     bool    `json:"is_current" gorm:"-"`
+	ExecutiveName string  `json:"executive_name,omitempty" gorm:"-"`
+	ImageUrl      *string `json:"image_url,omitempty" gorm:"-"`
+	PositionName  *string `json:"position_name,omitempty" gorm:"-"`
+	RegionName    *string `json:"region_name,omitempty" gorm:"-"`
+	ZoneName      *string `json:"zone_name,omitempty" gorm:"-"`
+}
+
+func (ExecutiveTerm) TableName() string {
+	return "executive_terms"
+}
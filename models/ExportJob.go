@@ -0,0 +1,41 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+// ExportJob model generated from database table 'export_jobs'
+type ExportJob struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ResourceType string          `json:"resource_type" gorm:"column:resource_type"`
+	Format       string          `json:"format" gorm:"column:format"`
+	Params       *datatypes.JSON `json:"params" gorm:"column:params"`
+	Status       string          `json:"status" gorm:"column:status"`
+	FilePath     *string         `json:"-" gorm:"column:file_path"`
+	FileName     *string         `json:"file_name" gorm:"column:file_name"`
+	RowCount     int             `json:"row_count" gorm:"column:row_count"`
+	ErrorMessage *string         `json:"error_message" gorm:"column:error_message"`
+	RequestedBy  *int64          `json:"requested_by" gorm:"column:requested_by"`
+	StartedAt    *time.Time      `json:"started_at" gorm:"column:started_at"`
+	CompletedAt  *time.Time      `json:"completed_at" gorm:"column:completed_at"`
+	ExpiresAt    *time.Time      `json:"expires_at" gorm:"column:expires_at"`
+	OwnerType    *string         `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId      *int64          `json:"owner_id" gorm:"column:owner_id"`
+
+	// Transient fields (not in database)
+	DownloadUrl string `json:"download_url,omitempty" gorm:"-"`
+}
+
+func (ExportJob) TableName() string {
+	return "export_jobs"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (e *ExportJob) SetOwner(ownerType string, ownerID int64) {
+	e.OwnerType = &ownerType
+	e.OwnerId = &ownerID
+}
@@ -19,6 +19,9 @@ type FinanceAccount struct {
 	IsIncome    *bool   `json:"is_income" gorm:"column:is_income"`
 	IsDeleted   bool    `json:"is_deleted" gorm:"column:is_deleted"`
 	ApproverId  *int64  `json:"approver_id" gorm:"column:approver_id"`
+	SystemRole  *string `json:"system_role" gorm:"column:system_role"`
+	ParentId    *int64  `json:"parent_id" gorm:"column:parent_id"`
+	IsHeader    *bool   `json:"is_header" gorm:"column:is_header"`
 	OwnerType   *string `json:"owner_type" gorm:"column:owner_type"`
 	OwnerId     *int64  `json:"owner_id" gorm:"column:owner_id"`
 }
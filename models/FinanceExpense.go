@@ -11,27 +11,43 @@ type FinanceExpense struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Title     *string   `json:"title" gorm:"column:title"`
 
-	Description     *string   `json:"description" gorm:"column:description"`
-	Amount          *float64  `json:"amount" gorm:"column:amount"`
-	BudgetAccountId *int64    `json:"budget_account_id" gorm:"column:budget_account_id"`
-	TransactionDate time.Time `json:"transaction_date" gorm:"column:transaction_date"`
-	VoucherNo       *string   `json:"voucher_no" gorm:"column:voucher_no"`
-	Status          *string   `json:"status" gorm:"column:status"`
-	IsPaid          *bool     `json:"is_paid" gorm:"column:is_paid"`
-	IsApproved      *bool     `json:"is_approved" gorm:"column:is_approved"`
-	ApprovedBy      *int64    `json:"approved_by" gorm:"column:approved_by"`
-	CategoryId      *int64    `json:"category_id" gorm:"column:category_id"`
-	RejectedReason  *string   `json:"rejected_reason" gorm:"column:rejected_reason"`
-	RejectedBy      *int64    `json:"rejected_by" gorm:"column:rejected_by"`
-	UserId          *int64    `json:"user_id" gorm:"column:user_id"`
-	BankFieldId     *int64    `json:"bank_field_id" gorm:"column:bank_field_id"`
-	BankAccountId   *int64    `json:"bank_account_id" gorm:"column:bank_account_id"`
-	ChequeNo        *string   `json:"cheque_no" gorm:"column:cheque_no"`
-	FollowUp        *bool     `json:"follow_up" gorm:"column:follow_up"`
-	OwnerType       *string   `json:"owner_type" gorm:"column:owner_type"`
-	OwnerId         *int64    `json:"owner_id" gorm:"column:owner_id"`
+	Description     *string    `json:"description" gorm:"column:description"`
+	Amount          *float64   `json:"amount" gorm:"column:amount"`
+	BudgetAccountId *int64     `json:"budget_account_id" gorm:"column:budget_account_id"`
+	TransactionDate time.Time  `json:"transaction_date" gorm:"column:transaction_date"`
+	VoucherNo       *string    `json:"voucher_no" gorm:"column:voucher_no"`
+	Status          *string    `json:"status" gorm:"column:status"`
+	IsPaid          *bool      `json:"is_paid" gorm:"column:is_paid"`
+	IsApproved      *bool      `json:"is_approved" gorm:"column:is_approved"`
+	ApprovedBy      *int64     `json:"approved_by" gorm:"column:approved_by"`
+	CategoryId      *int64     `json:"category_id" gorm:"column:category_id"`
+	RejectedReason  *string    `json:"rejected_reason" gorm:"column:rejected_reason"`
+	RejectedBy      *int64     `json:"rejected_by" gorm:"column:rejected_by"`
+	UserId          *int64     `json:"user_id" gorm:"column:user_id"`
+	BankFieldId     *int64     `json:"bank_field_id" gorm:"column:bank_field_id"`
+	BankAccountId   *int64     `json:"bank_account_id" gorm:"column:bank_account_id"`
+	ChequeNo        *string    `json:"cheque_no" gorm:"column:cheque_no"`
+	FollowUp        *bool      `json:"follow_up" gorm:"column:follow_up"`
+	PaymentMode     *string    `json:"payment_mode" gorm:"column:payment_mode"`
+	SubmittedAt     *time.Time `json:"submitted_at" gorm:"column:submitted_at"`
+	ApprovedAt      *time.Time `json:"approved_at" gorm:"column:approved_at"`
+	RejectedAt      *time.Time `json:"rejected_at" gorm:"column:rejected_at"`
+	PaidAt          *time.Time `json:"paid_at" gorm:"column:paid_at"`
+	PaidBy          *int64     `json:"paid_by" gorm:"column:paid_by"`
+	IsDeleted       bool       `json:"is_deleted" gorm:"column:is_deleted"`
+	OwnerType       *string    `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId         *int64     `json:"owner_id" gorm:"column:owner_id"`
+
+	// Transient fields (not in database)
+	Attachments []FinanceExpenseAttachment `json:"attachments,omitempty" gorm:"foreignKey:FinanceExpenseId"`
 }
 
 func (FinanceExpense) TableName() string {
 	return "finance_expenses"
 }
+
+// SetOwner implements the OwnerFieldSetter interface
+func (f *FinanceExpense) SetOwner(ownerType string, ownerID int64) {
+	f.OwnerType = &ownerType
+	f.OwnerId = &ownerID
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// FinanceExpenseAttachment model generated from database table 'finance_expense_attachments'
+type FinanceExpenseAttachment struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	FinanceExpenseId uint    `json:"finance_expense_id" gorm:"column:finance_expense_id"`
+	FileUrl          string  `json:"file_url" gorm:"column:file_url"`
+	FileName         *string `json:"file_name" gorm:"column:file_name"`
+	FileType         *string `json:"file_type" gorm:"column:file_type"`
+	FileSize         *int64  `json:"file_size" gorm:"column:file_size"`
+	UploadedBy       *int64  `json:"uploaded_by" gorm:"column:uploaded_by"`
+}
+
+func (FinanceExpenseAttachment) TableName() string {
+	return "finance_expense_attachments"
+}
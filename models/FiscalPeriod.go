@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// FiscalPeriod model generated from database table 'fiscal_periods'
+type FiscalPeriod struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name      string     `json:"name" gorm:"column:name"`
+	StartDate time.Time  `json:"start_date" gorm:"column:start_date"`
+	EndDate   time.Time  `json:"end_date" gorm:"column:end_date"`
+	Status    string     `json:"status" gorm:"column:status"`
+	ClosedBy  *int64     `json:"closed_by" gorm:"column:closed_by"`
+	ClosedAt  *time.Time `json:"closed_at" gorm:"column:closed_at"`
+	IsDeleted bool       `json:"is_deleted" gorm:"column:is_deleted"`
+	OwnerType *string    `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId   *int64     `json:"owner_id" gorm:"column:owner_id"`
+}
+
+func (FiscalPeriod) TableName() string {
+	return "fiscal_periods"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (f *FiscalPeriod) SetOwner(ownerType string, ownerID int64) {
+	f.OwnerType = &ownerType
+	f.OwnerId = &ownerID
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// FiscalPeriodAudit model generated from database table 'fiscal_period_audits'
+type FiscalPeriodAudit struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	FiscalPeriodId uint    `json:"fiscal_period_id" gorm:"column:fiscal_period_id"`
+	Action         string  `json:"action" gorm:"column:action"`
+	Reason         *string `json:"reason" gorm:"column:reason"`
+	PerformedBy    *int64  `json:"performed_by" gorm:"column:performed_by"`
+	PerformedRole  *string `json:"performed_role" gorm:"column:performed_role"`
+	OwnerType      *string `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId        *int64  `json:"owner_id" gorm:"column:owner_id"`
+}
+
+func (FiscalPeriodAudit) TableName() string {
+	return "fiscal_period_audits"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (f *FiscalPeriodAudit) SetOwner(ownerType string, ownerID int64) {
+	f.OwnerType = &ownerType
+	f.OwnerId = &ownerID
+}
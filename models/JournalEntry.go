@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// JournalEntry model generated from database table 'journal_entries'
+type JournalEntry struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	EntryNo              string    `json:"entry_no" gorm:"column:entry_no"`
+	EntryDate            time.Time `json:"entry_date" gorm:"column:entry_date"`
+	Description          *string   `json:"description" gorm:"column:description"`
+	SourceType           string    `json:"source_type" gorm:"column:source_type"`
+	SourceId             *int64    `json:"source_id" gorm:"column:source_id"`
+	FinanceTransactionId *int64    `json:"finance_transaction_id" gorm:"column:finance_transaction_id"`
+	SchoolId             *int64    `json:"school_id" gorm:"column:school_id"`
+	TotalDebit           float64   `json:"total_debit" gorm:"column:total_debit"`
+	TotalCredit          float64   `json:"total_credit" gorm:"column:total_credit"`
+	IsReversed           bool      `json:"is_reversed" gorm:"column:is_reversed"`
+	ReversalOfId         *uint     `json:"reversal_of_id" gorm:"column:reversal_of_id"`
+	PostedBy             *int64    `json:"posted_by" gorm:"column:posted_by"`
+	OwnerType            *string   `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId              *int64    `json:"owner_id" gorm:"column:owner_id"`
+
+	// Transient fields (not in database)
+	Lines []JournalEntryLine `json:"lines,omitempty" gorm:"foreignKey:JournalEntryId"`
+}
+
+func (JournalEntry) TableName() string {
+	return "journal_entries"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (j *JournalEntry) SetOwner(ownerType string, ownerID int64) {
+	j.OwnerType = &ownerType
+	j.OwnerId = &ownerID
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// JournalEntryLine model generated from database table 'journal_entry_lines'
+type JournalEntryLine struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	JournalEntryId   uint    `json:"journal_entry_id" gorm:"column:journal_entry_id"`
+	FinanceAccountId int64   `json:"finance_account_id" gorm:"column:finance_account_id"`
+	Debit            float64 `json:"debit" gorm:"column:debit"`
+	Credit           float64 `json:"credit" gorm:"column:credit"`
+	Description      *string `json:"description" gorm:"column:description"`
+
+	// Transient fields (not in database)
+	FinanceAccount *FinanceAccount `json:"finance_account,omitempty" gorm:"foreignKey:FinanceAccountId"`
+}
+
+func (JournalEntryLine) TableName() string {
+	return "journal_entry_lines"
+}
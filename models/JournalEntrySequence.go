@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// JournalEntrySequence model generated from database table 'journal_entry_sequences'
+type JournalEntrySequence struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SequenceKey string `json:"sequence_key" gorm:"column:sequence_key;uniqueIndex:idx_journal_entry_sequences_sequence_key"`
+	LastValue   int64  `json:"last_value" gorm:"column:last_value"`
+}
+
+func (JournalEntrySequence) TableName() string {
+	return "journal_entry_sequences"
+}
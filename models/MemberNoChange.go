@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// MemberNoChange model generated from database table 'member_no_changes'
+type MemberNoChange struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	BatchId     string `json:"batch_id" gorm:"column:batch_id"`
+	SchoolId    int64  `json:"school_id" gorm:"column:school_id"`
+	OldMemberNo string `json:"old_member_no" gorm:"column:old_member_no"`
+	NewMemberNo string `json:"new_member_no" gorm:"column:new_member_no"`
+	Pattern     string `json:"pattern" gorm:"column:pattern"`
+	ChangedBy   *int64 `json:"changed_by" gorm:"column:changed_by"`
+
+	// Transient fields (not in database)
+	SchoolName *string `json:"school_name,omitempty" gorm:"-"`
+}
+
+func (MemberNoChange) TableName() string {
+	return "member_no_changes"
+}
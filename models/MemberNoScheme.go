@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// MemberNoScheme model generated from database table 'member_no_schemes'
+type MemberNoScheme struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ScopeType   string  `json:"scope_type" gorm:"column:scope_type"`
+	ScopeId     int64   `json:"scope_id" gorm:"column:scope_id"`
+	Pattern     string  `json:"pattern" gorm:"column:pattern"`
+	Description *string `json:"description" gorm:"column:description"`
+	CreatedBy   *int64  `json:"created_by" gorm:"column:created_by"`
+
+	// Transient fields (not in database)
+	Example   string  `json:"example,omitempty" gorm:"-"`
+	ScopeName *string `json:"scope_name,omitempty" gorm:"-"`
+}
+
+func (MemberNoScheme) TableName() string {
+	return "member_no_schemes"
+}
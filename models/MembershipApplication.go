@@ -0,0 +1,38 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+// MembershipApplication model generated from database table 'membership_applications'
+type MembershipApplication struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Name                string          `json:"name" gorm:"column:name"`
+	ZoneId              int64           `json:"zone_id" gorm:"column:zone_id"`
+	Address             *string         `json:"address" gorm:"column:address"`
+	Location            *string         `json:"location" gorm:"column:location"`
+	MobileNo            *string         `json:"mobile_no" gorm:"column:mobile_no"`
+	Email               *string         `json:"email" gorm:"column:email"`
+	GpsAddress          *string         `json:"gps_address" gorm:"column:gps_address"`
+	DateOfEstablishment *time.Time      `json:"date_of_establishment" gorm:"column:date_of_establishment"`
+	ContactPersons      *datatypes.JSON `json:"contact_persons" gorm:"column:contact_persons"`
+	Status              string          `json:"status" gorm:"column:status"`
+	ReviewNotes         *string         `json:"review_notes" gorm:"column:review_notes"`
+	RejectionReason     *string         `json:"rejection_reason" gorm:"column:rejection_reason"`
+	ReviewedBy          *int64          `json:"reviewed_by" gorm:"column:reviewed_by"`
+	ReviewedAt          *time.Time      `json:"reviewed_at" gorm:"column:reviewed_at"`
+	SchoolId            *int64          `json:"school_id" gorm:"column:school_id"`
+	MemberNo            *string         `json:"member_no" gorm:"column:member_no"`
+	IsDeleted           bool            `json:"is_deleted" gorm:"column:is_deleted"`
+
+	// Transient fields (not in database)
+	Zone *Zone `json:"zone,omitempty" gorm:"foreignKey:ZoneId"`
+}
+
+func (MembershipApplication) TableName() string {
+	return "membership_applications"
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// MembershipCertificate model generated from database table 'membership_certificates'
+type MembershipCertificate struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SchoolId         int64      `json:"school_id" gorm:"column:school_id"`
+	CertificateNo    string     `json:"certificate_no" gorm:"column:certificate_no"`
+	VerificationCode string     `json:"verification_code" gorm:"column:verification_code"`
+	SchoolName       string     `json:"school_name" gorm:"column:school_name"`
+	MemberNo         string     `json:"member_no" gorm:"column:member_no"`
+	ZoneId           *int64     `json:"zone_id" gorm:"column:zone_id"`
+	ZoneName         *string    `json:"zone_name" gorm:"column:zone_name"`
+	RegionName       *string    `json:"region_name" gorm:"column:region_name"`
+	ValidFrom        time.Time  `json:"valid_from" gorm:"column:valid_from"`
+	ValidUntil       time.Time  `json:"valid_until" gorm:"column:valid_until"`
+	Status           string     `json:"status" gorm:"column:status"`
+	IssuedBy         *int64     `json:"issued_by" gorm:"column:issued_by"`
+	RevokedAt        *time.Time `json:"revoked_at" gorm:"column:revoked_at"`
+	RevokedBy        *int64     `json:"revoked_by" gorm:"column:revoked_by"`
+	RevocationReason *string    `json:"revocation_reason" gorm:"column:revocation_reason"`
+}
+
+func (MembershipCertificate) TableName() string {
+	return "membership_certificates"
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// PaymentAllocation model generated from database table 'payment_allocations'
+type PaymentAllocation struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	FinanceTransactionId      uint      `json:"finance_transaction_id" gorm:"column:finance_transaction_id"`
+	SchoolBillId              uint      `json:"school_bill_id" gorm:"column:school_bill_id"`
+	SchoolBillingParticularId *uint     `json:"school_billing_particular_id" gorm:"column:school_billing_particular_id"`
+	SchoolId                  *int64    `json:"school_id" gorm:"column:school_id"`
+	ParticularName            *string   `json:"particular_name" gorm:"column:particular_name"`
+	Amount                    float64   `json:"amount" gorm:"column:amount"`
+	AllocationDate            time.Time `json:"allocation_date" gorm:"column:allocation_date"`
+	ReceiverOwnerType         string    `json:"receiver_owner_type" gorm:"column:receiver_owner_type"`
+	ReceiverOwnerId           int64     `json:"receiver_owner_id" gorm:"column:receiver_owner_id"`
+	OwnerType                 *string   `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId                   *int64    `json:"owner_id" gorm:"column:owner_id"`
+}
+
+func (PaymentAllocation) TableName() string {
+	return "payment_allocations"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (p *PaymentAllocation) SetOwner(ownerType string, ownerID int64) {
+	p.OwnerType = &ownerType
+	p.OwnerId = &ownerID
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"gorm.io/gorm"
+	"strings"
 	"time"
 )
 
@@ -13,9 +14,23 @@ type Position struct {
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
 
 	Name      *string `json:"name" gorm:"column:name"`
+	Levels    *string `json:"levels" gorm:"column:levels"`
 	IsDeleted *bool   `json:"is_deleted" gorm:"column:is_deleted"`
 }
 
+// AppliesAt reports whether the position is held at a level (national, region or zone)
+func (p Position) AppliesAt(level string) bool {
+	if p.Levels == nil {
+		return false
+	}
+	for _, l := range strings.Split(*p.Levels, ",") {
+		if strings.TrimSpace(l) == level {
+			return true
+		}
+	}
+	return false
+}
+
 func (Position) TableName() string {
 	return "positions"
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Remittance model generated from database table 'remittances'
+type Remittance struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ReferenceNo          string     `json:"reference_no" gorm:"column:reference_no"`
+	ToOwnerType          string     `json:"to_owner_type" gorm:"column:to_owner_type"`
+	ToOwnerId            int64      `json:"to_owner_id" gorm:"column:to_owner_id"`
+	Amount               float64    `json:"amount" gorm:"column:amount"`
+	RemittanceDate       time.Time  `json:"remittance_date" gorm:"column:remittance_date"`
+	PaymentMode          *string    `json:"payment_mode" gorm:"column:payment_mode"`
+	TransactionReference *string    `json:"transaction_reference" gorm:"column:transaction_reference"`
+	Notes                *string    `json:"notes" gorm:"column:notes"`
+	Status               string     `json:"status" gorm:"column:status"`
+	RecordedBy           *int64     `json:"recorded_by" gorm:"column:recorded_by"`
+	ConfirmedBy          *int64     `json:"confirmed_by" gorm:"column:confirmed_by"`
+	ConfirmedAt          *time.Time `json:"confirmed_at" gorm:"column:confirmed_at"`
+	RejectedReason       *string    `json:"rejected_reason" gorm:"column:rejected_reason"`
+	IsDeleted            bool       `json:"is_deleted" gorm:"column:is_deleted"`
+	OwnerType            *string    `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId              *int64     `json:"owner_id" gorm:"column:owner_id"`
+}
+
+func (Remittance) TableName() string {
+	return "remittances"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (r *Remittance) SetOwner(ownerType string, ownerID int64) {
+	r.OwnerType = &ownerType
+	r.OwnerId = &ownerID
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// RemittanceReferenceSequence model generated from database table 'remittance_reference_sequences'
+type RemittanceReferenceSequence struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SequenceKey string `json:"sequence_key" gorm:"column:sequence_key;uniqueIndex:idx_remittance_reference_sequences_sequence_key"`
+	LastValue   int64  `json:"last_value" gorm:"column:last_value"`
+}
+
+func (RemittanceReferenceSequence) TableName() string {
+	return "remittance_reference_sequences"
+}
@@ -23,9 +23,14 @@ type School struct {
 	MobileNo            *string         `json:"mobile_no" gorm:"column:mobile_no"`
 	Email               *string         `json:"email" gorm:"column:email"`
 	GpsAddress          *string         `json:"gps_address" gorm:"column:gps_address"`
+	Latitude            *float64        `json:"latitude" gorm:"column:latitude"`
+	Longitude           *float64        `json:"longitude" gorm:"column:longitude"`
 	IsDeleted           *bool           `json:"is_deleted" gorm:"column:is_deleted"`
 	UserId              *int64          `json:"user_id" gorm:"column:user_id"`
 	SchoolGroupIds      *datatypes.JSON `json:"school_group_ids" gorm:"column:school_group_ids"`
+	MembershipStatus    string          `json:"membership_status" gorm:"column:membership_status"`
+	StatusReason        *string         `json:"status_reason" gorm:"column:status_reason"`
+	StatusChangedAt     *time.Time      `json:"status_changed_at" gorm:"column:status_changed_at"`
 
 	// Transient fields (not in database)
 	Zone           *Zone           `json:"zone,omitempty" gorm:"foreignKey:ZoneId"`
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// SchoolBillRevision model generated from database table 'school_bill_revisions'
+type SchoolBillRevision struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SchoolBillId              int64    `json:"school_bill_id" gorm:"column:school_bill_id"`
+	SchoolId                  *int64   `json:"school_id" gorm:"column:school_id"`
+	BillId                    *int64   `json:"bill_id" gorm:"column:bill_id"`
+	BillItemId                *int64   `json:"bill_item_id" gorm:"column:bill_item_id"`
+	SchoolBillingParticularId *int64   `json:"school_billing_particular_id" gorm:"column:school_billing_particular_id"`
+	RevisionType              string   `json:"revision_type" gorm:"column:revision_type"`
+	ParticularName            *string  `json:"particular_name" gorm:"column:particular_name"`
+	PreviousAmount            *float64 `json:"previous_amount" gorm:"column:previous_amount"`
+	NewAmount                 *float64 `json:"new_amount" gorm:"column:new_amount"`
+	DeltaAmount               *float64 `json:"delta_amount" gorm:"column:delta_amount"`
+	PreviousBillAmount        *float64 `json:"previous_bill_amount" gorm:"column:previous_bill_amount"`
+	NewBillAmount             *float64 `json:"new_bill_amount" gorm:"column:new_bill_amount"`
+	PreviousBalance           *float64 `json:"previous_balance" gorm:"column:previous_balance"`
+	NewBalance                *float64 `json:"new_balance" gorm:"column:new_balance"`
+	AmountPaid                *float64 `json:"amount_paid" gorm:"column:amount_paid"`
+	Reason                    *string  `json:"reason" gorm:"column:reason"`
+	RevisedBy                 *int64   `json:"revised_by" gorm:"column:revised_by"`
+	OwnerType                 *string  `json:"owner_type" gorm:"column:owner_type"`
+	OwnerId                   *int64   `json:"owner_id" gorm:"column:owner_id"`
+}
+
+func (SchoolBillRevision) TableName() string {
+	return "school_bill_revisions"
+}
+
+// SetOwner implements the OwnerFieldSetter interface
+func (s *SchoolBillRevision) SetOwner(ownerType string, ownerID int64) {
+	s.OwnerType = &ownerType
+	s.OwnerId = &ownerID
+}
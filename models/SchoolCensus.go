@@ -0,0 +1,33 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+// SchoolCensus model generated from database table 'school_censuses'
+type SchoolCensus struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SchoolId     int64           `json:"school_id" gorm:"column:school_id"`
+	ZoneId       *int64          `json:"zone_id" gorm:"column:zone_id"`
+	AcademicYear string          `json:"academic_year" gorm:"column:academic_year"`
+	Status       string          `json:"status" gorm:"column:status"`
+	Data         *datatypes.JSON `json:"data" gorm:"column:data"`
+	SubmittedAt  *time.Time      `json:"submitted_at" gorm:"column:submitted_at"`
+	SubmittedBy  *int64          `json:"submitted_by" gorm:"column:submitted_by"`
+	VerifiedAt   *time.Time      `json:"verified_at" gorm:"column:verified_at"`
+	VerifiedBy   *int64          `json:"verified_by" gorm:"column:verified_by"`
+	ReviewNotes  *string         `json:"review_notes" gorm:"column:review_notes"`
+
+	// Transient fields (not in database)
+	SchoolName *string `json:"school_name,omitempty" gorm:"-"`
+	MemberNo   *string `json:"member_no,omitempty" gorm:"-"`
+	ZoneName   *string `json:"zone_name,omitempty" gorm:"-"`
+}
+
+func (SchoolCensus) TableName() string {
+	return "school_censuses"
+}
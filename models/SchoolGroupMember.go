@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// SchoolGroupMember model generated from database table 'school_group_members'
+type SchoolGroupMember struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SchoolGroupId int64  `json:"school_group_id" gorm:"column:school_group_id"`
+	SchoolId      int64  `json:"school_id" gorm:"column:school_id"`
+	AddedBy       *int64 `json:"added_by" gorm:"column:added_by"`
+}
+
+func (SchoolGroupMember) TableName() string {
+	return "school_group_members"
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// SchoolGroupTarget model generated from database table 'school_group_targets'
+type SchoolGroupTarget struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SchoolGroupId int64  `json:"school_group_id" gorm:"column:school_group_id"`
+	TargetType    string `json:"target_type" gorm:"column:target_type"`
+	TargetId      int64  `json:"target_id" gorm:"column:target_id"`
+}
+
+func (SchoolGroupTarget) TableName() string {
+	return "school_group_targets"
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+// SchoolMerge model generated from database table 'school_merges'
+type SchoolMerge struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SurvivingSchoolId    int64           `json:"surviving_school_id" gorm:"column:surviving_school_id"`
+	MergedSchoolId       int64           `json:"merged_school_id" gorm:"column:merged_school_id"`
+	MergedMemberNo       *string         `json:"merged_member_no" gorm:"column:merged_member_no"`
+	MergedSchoolName     *string         `json:"merged_school_name" gorm:"column:merged_school_name"`
+	Reason               *string         `json:"reason" gorm:"column:reason"`
+	MergedSchoolSnapshot *datatypes.JSON `json:"merged_school_snapshot" gorm:"column:merged_school_snapshot"`
+	MovedRows            *datatypes.JSON `json:"moved_rows" gorm:"column:moved_rows"`
+	FoldedBills          *datatypes.JSON `json:"folded_bills" gorm:"column:folded_bills"`
+	PerformedBy          *int64          `json:"performed_by" gorm:"column:performed_by"`
+	PerformedRole        *string         `json:"performed_role" gorm:"column:performed_role"`
+}
+
+func (SchoolMerge) TableName() string {
+	return "school_merges"
+}
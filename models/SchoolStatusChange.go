@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// SchoolStatusChange model generated from database table 'school_status_changes'
+type SchoolStatusChange struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SchoolId   int64   `json:"school_id" gorm:"column:school_id"`
+	FromStatus *string `json:"from_status" gorm:"column:from_status"`
+	ToStatus   string  `json:"to_status" gorm:"column:to_status"`
+	Reason     *string `json:"reason" gorm:"column:reason"`
+	ChangedBy  *int64  `json:"changed_by" gorm:"column:changed_by"`
+}
+
+func (SchoolStatusChange) TableName() string {
+	return "school_status_changes"
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"gorm.io/datatypes"
+	"time"
+)
+
+// SchoolTransfer model generated from database table 'school_transfers'
+type SchoolTransfer struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SchoolId         int64           `json:"school_id" gorm:"column:school_id"`
+	FromZoneId       int64           `json:"from_zone_id" gorm:"column:from_zone_id"`
+	ToZoneId         int64           `json:"to_zone_id" gorm:"column:to_zone_id"`
+	Status           string          `json:"status" gorm:"column:status"`
+	Reason           *string         `json:"reason" gorm:"column:reason"`
+	BillHandling     string          `json:"bill_handling" gorm:"column:bill_handling"`
+	IssueNewMemberNo bool            `json:"issue_new_member_no" gorm:"column:issue_new_member_no"`
+	OldMemberNo      *string         `json:"old_member_no" gorm:"column:old_member_no"`
+	NewMemberNo      *string         `json:"new_member_no" gorm:"column:new_member_no"`
+	MovedBillIds     *datatypes.JSON `json:"moved_bill_ids" gorm:"column:moved_bill_ids"`
+	RequestedBy      *int64          `json:"requested_by" gorm:"column:requested_by"`
+	DecidedBy        *int64          `json:"decided_by" gorm:"column:decided_by"`
+	DecidedAt        *time.Time      `json:"decided_at" gorm:"column:decided_at"`
+	DecisionNotes    *string         `json:"decision_notes" gorm:"column:decision_notes"`
+
+	// Transient fields (not in database)
+	School   *School `json:"school,omitempty" gorm:"foreignKey:SchoolId"`
+	FromZone *Zone   `json:"from_zone,omitempty" gorm:"foreignKey:FromZoneId"`
+	ToZone   *Zone   `json:"to_zone,omitempty" gorm:"foreignKey:ToZoneId"`
+}
+
+func (SchoolTransfer) TableName() string {
+	return "school_transfers"
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// SchoolZoneHistory model generated from database table 'school_zone_histories'
+type SchoolZoneHistory struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SchoolId         int64      `json:"school_id" gorm:"column:school_id"`
+	ZoneId           int64      `json:"zone_id" gorm:"column:zone_id"`
+	MemberNo         *string    `json:"member_no" gorm:"column:member_no"`
+	StartedAt        *time.Time `json:"started_at" gorm:"column:started_at"`
+	EndedAt          *time.Time `json:"ended_at" gorm:"column:ended_at"`
+	SchoolTransferId *int64     `json:"school_transfer_id" gorm:"column:school_transfer_id"`
+
+	// Transient fields (not in database)
+	Zone *Zone `json:"zone,omitempty" gorm:"foreignKey:ZoneId"`
+}
+
+func (SchoolZoneHistory) TableName() string {
+	return "school_zone_histories"
+}
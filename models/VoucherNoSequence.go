@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// VoucherNoSequence model generated from database table 'voucher_no_sequences'
+type VoucherNoSequence struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	SequenceKey string `json:"sequence_key" gorm:"column:sequence_key;uniqueIndex:idx_voucher_no_sequences_sequence_key"`
+	LastValue   int64  `json:"last_value" gorm:"column:last_value"`
+}
+
+func (VoucherNoSequence) TableName() string {
+	return "voucher_no_sequences"
+}
@@ -0,0 +1,324 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+	"gnaps-api/utils"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BankAccountRepository struct {
+	db *gorm.DB
+}
+
+func NewBankAccountRepository(db *gorm.DB) *BankAccountRepository {
+	return &BankAccountRepository{db: db}
+}
+
+// FindByID retrieves a bank account without owner filtering
+func (r *BankAccountRepository) FindByID(id uint) (*models.BankAccount, error) {
+	var account models.BankAccount
+	if err := r.db.Where("id = ? AND is_deleted = ?", id, false).First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// FindDefault retrieves an owner's default active account of a type (bank or momo)
+func (r *BankAccountRepository) FindDefault(ownerType string, ownerID int64, accountType string) (*models.BankAccount, error) {
+	var account models.BankAccount
+	err := r.db.Where("owner_type = ? AND owner_id = ? AND account_type = ?", ownerType, ownerID, accountType).
+		Where("is_default = ? AND is_active = ? AND is_deleted = ?", true, true, false).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ClearDefault unsets the default flag on an owner's other accounts of the same type
+func (r *BankAccountRepository) ClearDefault(ownerType string, ownerID int64, accountType string, exceptID uint) error {
+	return r.db.Model(&models.BankAccount{}).
+		Where("owner_type = ? AND owner_id = ? AND account_type = ? AND id != ?", ownerType, ownerID, accountType, exceptID).
+		Update("is_default", false).Error
+}
+
+// TagFinanceTransaction records the account a finance transaction was received into
+func (r *BankAccountRepository) TagFinanceTransaction(transactionId uint, bankAccountId *int64) error {
+	result := r.db.Model(&models.FinanceTransaction{}).Where("id = ?", transactionId).Update("bank_account_id", bankAccountId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// FindFinanceTransactionWithOwner retrieves a finance transaction with owner filtering
+func (r *BankAccountRepository) FindFinanceTransactionWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.FinanceTransaction, error) {
+	var transaction models.FinanceTransaction
+	query := ApplyOwnerFilterToQuery(r.db.Where("id = ?", id), ownerCtx)
+	if err := query.First(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// ============================================
+// Cash book
+// ============================================
+
+const (
+	CashBookEntryTransaction = "FinanceTransaction"
+	CashBookEntryExpense     = "FinanceExpense"
+)
+
+// CashBookEntry is a receipt or payment recorded against a bank account; receipts are positive
+// and payments (paid expenses and refunds) negative
+type CashBookEntry struct {
+	EntryType   string    `json:"entry_type"`
+	EntryId     uint      `json:"entry_id"`
+	Date        time.Time `json:"date"`
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+}
+
+// GetCashBookEntries retrieves the account's receipts and paid expenses up to a date (exclusive),
+// oldest first
+func (r *BankAccountRepository) GetCashBookEntries(bankAccountId uint, to *time.Time) ([]CashBookEntry, error) {
+	var receipts []CashBookEntry
+	query := r.db.Model(&models.FinanceTransaction{}).
+		Select(`? AS entry_type, id AS entry_id, transaction_date AS date,
+			COALESCE(reference_no, receipt_no, '') AS reference, COALESCE(title, '') AS description,
+			CASE WHEN finance_type = 'Refund' THEN -COALESCE(amount, 0) ELSE COALESCE(amount, 0) END AS amount`, CashBookEntryTransaction).
+		Where("bank_account_id = ?", bankAccountId)
+	if to != nil {
+		query = query.Where("transaction_date < ?", *to)
+	}
+	if err := query.Scan(&receipts).Error; err != nil {
+		return nil, err
+	}
+
+	var payments []CashBookEntry
+	query = r.db.Model(&models.FinanceExpense{}).
+		Select(`? AS entry_type, id AS entry_id, COALESCE(paid_at, transaction_date) AS date,
+			COALESCE(cheque_no, voucher_no, '') AS reference, COALESCE(title, '') AS description,
+			-COALESCE(amount, 0) AS amount`, CashBookEntryExpense).
+		Where("bank_account_id = ? AND is_paid = ? AND is_deleted = ?", bankAccountId, true, false)
+	if to != nil {
+		query = query.Where("COALESCE(paid_at, transaction_date) < ?", *to)
+	}
+	if err := query.Scan(&payments).Error; err != nil {
+		return nil, err
+	}
+
+	entries := append(receipts, payments...)
+	sortCashBookEntries(entries)
+	return entries, nil
+}
+
+func sortCashBookEntries(entries []CashBookEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+}
+
+// ============================================
+// Statements
+// ============================================
+
+// CreateStatement stores an imported statement and its lines in a single database transaction
+func (r *BankAccountRepository) CreateStatement(statement *models.BankStatement, lines []models.BankStatementLine) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(statement).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i].BankStatementId = statement.ID
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&lines, 200).Error
+	})
+}
+
+// LineExists checks whether a line with the same date, amount and reference was already imported
+// for the account, so overlapping statements do not duplicate lines
+func (r *BankAccountRepository) LineExists(bankAccountId uint, date time.Time, amount float64, reference string) bool {
+	var count int64
+	r.db.Model(&models.BankStatementLine{}).
+		Joins("JOIN bank_statements ON bank_statements.id = bank_statement_lines.bank_statement_id AND bank_statements.is_deleted = ?", false).
+		Where("bank_statement_lines.bank_account_id = ? AND bank_statement_lines.transaction_date = ? AND bank_statement_lines.amount = ?", bankAccountId, date, amount).
+		Where("COALESCE(bank_statement_lines.reference, '') = ?", reference).
+		Count(&count)
+	return count > 0
+}
+
+// ListStatements retrieves the statements imported for an account, newest first
+func (r *BankAccountRepository) ListStatements(bankAccountId uint, page, limit int) ([]models.BankStatement, int64, error) {
+	var statements []models.BankStatement
+	var total int64
+
+	query := r.db.Model(&models.BankStatement{}).Where("bank_account_id = ? AND is_deleted = ?", bankAccountId, false)
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&statements).Error
+	return statements, total, err
+}
+
+// FindStatementByID retrieves a statement
+func (r *BankAccountRepository) FindStatementByID(id uint) (*models.BankStatement, error) {
+	var statement models.BankStatement
+	if err := r.db.Where("id = ? AND is_deleted = ?", id, false).First(&statement).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// DeleteStatement removes a statement; its lines no longer count towards reconciliation
+func (r *BankAccountRepository) DeleteStatement(id uint) error {
+	return r.db.Model(&models.BankStatement{}).Where("id = ?", id).Update("is_deleted", true).Error
+}
+
+// ListLines retrieves statement lines of an account with filters and pagination
+func (r *BankAccountRepository) ListLines(bankAccountId uint, filters map[string]interface{}, page, limit int) ([]models.BankStatementLine, int64, error) {
+	var lines []models.BankStatementLine
+	var total int64
+
+	query := r.liveLines(bankAccountId)
+	for key, value := range filters {
+		query = query.Where("bank_statement_lines."+key+" = ?", value)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("bank_statement_lines.transaction_date ASC, bank_statement_lines.id ASC").
+		Offset(offset).Limit(limit).Find(&lines).Error
+	return lines, total, err
+}
+
+// GetLines retrieves all live statement lines of an account up to a date (exclusive)
+func (r *BankAccountRepository) GetLines(bankAccountId uint, to *time.Time) ([]models.BankStatementLine, error) {
+	var lines []models.BankStatementLine
+	query := r.liveLines(bankAccountId)
+	if to != nil {
+		query = query.Where("bank_statement_lines.transaction_date < ?", *to)
+	}
+	err := query.Order("bank_statement_lines.transaction_date ASC, bank_statement_lines.id ASC").Find(&lines).Error
+	return lines, err
+}
+
+// FindLineByID retrieves a statement line
+func (r *BankAccountRepository) FindLineByID(id uint) (*models.BankStatementLine, error) {
+	var line models.BankStatementLine
+	if err := r.db.First(&line, id).Error; err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// UpdateLine saves changes to a statement line
+func (r *BankAccountRepository) UpdateLine(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.BankStatementLine{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// IsEntryMatched checks whether a cash-book entry is already matched to a live statement line
+func (r *BankAccountRepository) IsEntryMatched(entryType string, entryId uint) bool {
+	var count int64
+	r.db.Model(&models.BankStatementLine{}).
+		Joins("JOIN bank_statements ON bank_statements.id = bank_statement_lines.bank_statement_id AND bank_statements.is_deleted = ?", false).
+		Where("bank_statement_lines.status = ? AND bank_statement_lines.matched_type = ? AND bank_statement_lines.matched_id = ?", "matched", entryType, entryId).
+		Count(&count)
+	return count > 0
+}
+
+func (r *BankAccountRepository) liveLines(bankAccountId uint) *gorm.DB {
+	return r.db.Model(&models.BankStatementLine{}).
+		Joins("JOIN bank_statements ON bank_statements.id = bank_statement_lines.bank_statement_id AND bank_statements.is_deleted = ?", false).
+		Where("bank_statement_lines.bank_account_id = ?", bankAccountId)
+}
+
+// ============================================
+// Owner-based methods for data filtering
+// ============================================
+
+// CreateWithOwner creates a new bank account with owner fields automatically set
+func (r *BankAccountRepository) CreateWithOwner(account *models.BankAccount, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		ownerType, ownerID := ownerCtx.GetOwnerValues()
+		account.SetOwner(ownerType, ownerID)
+	}
+	return r.db.Create(account).Error
+}
+
+// FindByIDWithOwner retrieves a bank account by ID with owner filtering
+func (r *BankAccountRepository) FindByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.BankAccount, error) {
+	var account models.BankAccount
+	query := r.db.Where("id = ? AND is_deleted = ?", id, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	if err := query.First(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ListWithOwner retrieves bank accounts with filters, pagination, and owner filtering
+func (r *BankAccountRepository) ListWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.BankAccount, int64, error) {
+	var accounts []models.BankAccount
+	var total int64
+
+	query := r.db.Model(&models.BankAccount{}).Where("is_deleted = ?", false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("account_type ASC, name ASC").Offset(offset).Limit(limit).Find(&accounts).Error
+	return accounts, total, err
+}
+
+// UpdateWithOwner updates a bank account with owner verification
+func (r *BankAccountRepository) UpdateWithOwner(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	query := r.db.Model(&models.BankAccount{}).Where("id = ? AND is_deleted = ?", id, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteWithOwner soft deletes a bank account with owner verification
+func (r *BankAccountRepository) DeleteWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	return r.UpdateWithOwner(id, map[string]interface{}{"is_deleted": true, "is_default": false}, ownerCtx)
+}
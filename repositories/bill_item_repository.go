@@ -64,12 +64,27 @@ func (r *BillItemRepository) FindByBillIDWithPagination(billId uint, page, limit
 	return billItems, total, err
 }
 
+// Create creates a bill item and its school group targeting
 func (r *BillItemRepository) Create(billItem *models.BillItem) error {
-	return r.db.Create(billItem).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(billItem).Error; err != nil {
+			return err
+		}
+		return replaceGroupTargets(tx, GroupTargetBillItem, int64(billItem.ID), parseJSONIDs(billItem.SchoolGroupIds))
+	})
 }
 
+// Update updates a bill item; school_group_ids also replaces its group targeting
 func (r *BillItemRepository) Update(id uint, updates map[string]interface{}) error {
-	return r.db.Model(&models.BillItem{}).Where("id = ?", id).Updates(updates).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.BillItem{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if groupIDs, ok := groupIDsUpdate(updates); ok {
+			return replaceGroupTargets(tx, GroupTargetBillItem, int64(id), groupIDs)
+		}
+		return nil
+	})
 }
 
 func (r *BillItemRepository) Delete(id uint) error {
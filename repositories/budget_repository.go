@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+	"gnaps-api/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type BudgetRepository struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+// Exists checks whether the owner already has a budget for the account and fiscal year
+func (r *BudgetRepository) Exists(financeAccountId int64, fiscalYear int, ownerType string, ownerID int64, excludeID *uint) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.Budget{}).
+		Where("finance_account_id = ? AND fiscal_year = ? AND owner_type = ? AND owner_id = ? AND is_deleted = ?",
+			financeAccountId, fiscalYear, ownerType, ownerID, false)
+	if excludeID != nil {
+		query = query.Where("id != ?", *excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// FindForAccount retrieves the owner's budget for an account and fiscal year
+func (r *BudgetRepository) FindForAccount(financeAccountId int64, fiscalYear int, ownerType string, ownerID int64) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.db.Where("finance_account_id = ? AND fiscal_year = ? AND owner_type = ? AND owner_id = ? AND is_deleted = ?",
+		financeAccountId, fiscalYear, ownerType, ownerID, false).
+		First(&budget).Error
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// SumIncome totals finance transactions credited to an account for an owner within a period
+func (r *BudgetRepository) SumIncome(financeAccountId int64, ownerType string, ownerID int64, from, to time.Time) float64 {
+	var total float64
+	r.db.Model(&models.FinanceTransaction{}).
+		Where("finance_account_id = ? AND owner_type = ? AND owner_id = ?", financeAccountId, ownerType, ownerID).
+		Where("transaction_date >= ? AND transaction_date < ?", from, to).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total)
+	return total
+}
+
+// SumExpenses totals approved expenses charged to an account for an owner within a period
+func (r *BudgetRepository) SumExpenses(financeAccountId int64, ownerType string, ownerID int64, from, to time.Time) float64 {
+	var total float64
+	r.db.Model(&models.FinanceExpense{}).
+		Where("budget_account_id = ? AND owner_type = ? AND owner_id = ?", financeAccountId, ownerType, ownerID).
+		Where("is_approved = ? AND is_deleted = ?", true, false).
+		Where("transaction_date >= ? AND transaction_date < ?", from, to).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total)
+	return total
+}
+
+// HasAlert checks whether an alert was already raised for a budget at the given threshold
+func (r *BudgetRepository) HasAlert(budgetId uint, threshold float64) bool {
+	var count int64
+	r.db.Model(&models.BudgetAlert{}).
+		Where("budget_id = ? AND alert_threshold = ?", budgetId, threshold).
+		Count(&count)
+	return count > 0
+}
+
+func (r *BudgetRepository) CreateAlert(alert *models.BudgetAlert) error {
+	return r.db.Create(alert).Error
+}
+
+// ============================================
+// Owner-based methods for data filtering
+// ============================================
+
+// CreateWithOwner creates a new budget with owner fields automatically set
+func (r *BudgetRepository) CreateWithOwner(budget *models.Budget, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		ownerType, ownerID := ownerCtx.GetOwnerValues()
+		budget.SetOwner(ownerType, ownerID)
+	}
+	return r.db.Create(budget).Error
+}
+
+// FindByIDWithOwner retrieves a budget by ID with owner filtering
+func (r *BudgetRepository) FindByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.Budget, error) {
+	var budget models.Budget
+	query := r.db.Preload("FinanceAccount").Where("id = ? AND is_deleted = ?", id, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	err := query.First(&budget).Error
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// ListWithOwner retrieves budgets with filters, pagination, and owner filtering
+func (r *BudgetRepository) ListWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.Budget, int64, error) {
+	var budgets []models.Budget
+	var total int64
+
+	query := r.db.Model(&models.Budget{}).Where("is_deleted = ?", false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	query.Count(&total)
+
+	offset := (page - 1) * limit
+	err := query.Preload("FinanceAccount").Offset(offset).Limit(limit).Order("fiscal_year DESC, finance_account_id ASC").Find(&budgets).Error
+
+	return budgets, total, err
+}
+
+// ListForYearWithOwner retrieves every budget for a fiscal year with owner filtering
+func (r *BudgetRepository) ListForYearWithOwner(fiscalYear int, ownerCtx *utils.OwnerContext) ([]models.Budget, error) {
+	var budgets []models.Budget
+	query := r.db.Preload("FinanceAccount").Where("fiscal_year = ? AND is_deleted = ?", fiscalYear, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	err := query.Order("finance_account_id ASC").Find(&budgets).Error
+	return budgets, err
+}
+
+// UpdateWithOwner updates a budget with owner verification
+func (r *BudgetRepository) UpdateWithOwner(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	query := r.db.Model(&models.Budget{}).Where("id = ? AND is_deleted = ?", id, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	result := query.Updates(updates)
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// DeleteWithOwner soft deletes a budget with owner verification
+func (r *BudgetRepository) DeleteWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	query := r.db.Model(&models.Budget{}).Where("id = ?", id)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	result := query.Update("is_deleted", true)
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// ListAlertsWithOwner retrieves budget alerts with owner filtering
+func (r *BudgetRepository) ListAlertsWithOwner(acknowledged *bool, page, limit int, ownerCtx *utils.OwnerContext) ([]models.BudgetAlert, int64, error) {
+	var alerts []models.BudgetAlert
+	var total int64
+
+	query := r.db.Model(&models.BudgetAlert{})
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+	if acknowledged != nil {
+		query = query.Where("is_acknowledged = ?", *acknowledged)
+	}
+
+	query.Count(&total)
+
+	offset := (page - 1) * limit
+	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&alerts).Error
+
+	return alerts, total, err
+}
+
+// AcknowledgeAlertWithOwner marks a budget alert as seen
+func (r *BudgetRepository) AcknowledgeAlertWithOwner(id uint, acknowledgedBy *int64, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	query := r.db.Model(&models.BudgetAlert{}).Where("id = ?", id)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	result := query.Updates(map[string]interface{}{
+		"is_acknowledged": true,
+		"acknowledged_by": acknowledgedBy,
+	})
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
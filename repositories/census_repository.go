@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// CensusReportRow is a census return with the zone and region it is reported under
+type CensusReportRow struct {
+	AcademicYear string          `gorm:"column:academic_year"`
+	ZoneId       *int64          `gorm:"column:zone_id"`
+	ZoneName     *string         `gorm:"column:zone_name"`
+	RegionId     *int64          `gorm:"column:region_id"`
+	RegionName   *string         `gorm:"column:region_name"`
+	Data         *datatypes.JSON `gorm:"column:data"`
+}
+
+// ZoneSchoolCount is the number of member schools in a zone
+type ZoneSchoolCount struct {
+	ZoneId   int64  `gorm:"column:zone_id"`
+	RegionId *int64 `gorm:"column:region_id"`
+	Schools  int64  `gorm:"column:schools"`
+}
+
+type CensusRepository struct {
+	db *gorm.DB
+}
+
+func NewCensusRepository(db *gorm.DB) *CensusRepository {
+	return &CensusRepository{db: db}
+}
+
+// ListFields retrieves the census form fields in form order
+func (r *CensusRepository) ListFields(activeOnly bool) ([]models.CensusField, error) {
+	var fields []models.CensusField
+	query := r.db.Model(&models.CensusField{})
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("position ASC, id ASC").Find(&fields).Error
+	return fields, err
+}
+
+func (r *CensusRepository) FindField(id uint) (*models.CensusField, error) {
+	var field models.CensusField
+	if err := r.db.First(&field, id).Error; err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+// FieldKeyExists checks if a field key is taken (optionally excluding a specific field)
+func (r *CensusRepository) FieldKeyExists(key string, excludeID *uint) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.CensusField{}).Where("field_key = ?", key)
+	if excludeID != nil {
+		query = query.Where("id != ?", *excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *CensusRepository) CreateField(field *models.CensusField) error {
+	return r.db.Create(field).Error
+}
+
+func (r *CensusRepository) UpdateField(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.CensusField{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// FindCensus retrieves a school's census for an academic year
+func (r *CensusRepository) FindCensus(schoolID uint, academicYear string) (*models.SchoolCensus, error) {
+	var census models.SchoolCensus
+	if err := r.db.Where("school_id = ? AND academic_year = ?", schoolID, academicYear).First(&census).Error; err != nil {
+		return nil, err
+	}
+	return &census, nil
+}
+
+// FindByIDWithRoleFilter retrieves a census if its school is accessible by the user's role
+func (r *CensusRepository) FindByIDWithRoleFilter(id uint, regionID, zoneID *int64) (*models.SchoolCensus, error) {
+	var census models.SchoolCensus
+	query := r.db.Where("id = ?", id)
+	query = applySchoolRoleFilter(query, regionID, zoneID)
+
+	if err := query.First(&census).Error; err != nil {
+		return nil, err
+	}
+	r.attachSchoolDetails([]*models.SchoolCensus{&census})
+	return &census, nil
+}
+
+// SaveCensus creates or updates a census
+func (r *CensusRepository) SaveCensus(census *models.SchoolCensus) error {
+	return r.db.Save(census).Error
+}
+
+// UpdateStatus moves a census on in its workflow; it fails if the census is no longer in one of
+// the from statuses
+func (r *CensusRepository) UpdateStatus(id uint, from []string, updates map[string]interface{}) error {
+	result := r.db.Model(&models.SchoolCensus{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListWithRoleFilter retrieves the census returns of the schools accessible by the user's role
+func (r *CensusRepository) ListWithRoleFilter(filters map[string]interface{}, page, limit int, regionID, zoneID *int64) ([]models.SchoolCensus, int64, error) {
+	var censuses []models.SchoolCensus
+	var total int64
+
+	query := r.db.Model(&models.SchoolCensus{})
+	query = applySchoolRoleFilter(query, regionID, zoneID)
+
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("academic_year DESC, updated_at DESC").Offset(offset).Limit(limit).Find(&censuses).Error; err != nil {
+		return nil, 0, err
+	}
+
+	rows := make([]*models.SchoolCensus, len(censuses))
+	for i := range censuses {
+		rows[i] = &censuses[i]
+	}
+	r.attachSchoolDetails(rows)
+	return censuses, total, nil
+}
+
+// ListYears retrieves the academic years with census returns, newest first
+func (r *CensusRepository) ListYears() ([]string, error) {
+	var years []string
+	err := r.db.Model(&models.SchoolCensus{}).Distinct().Order("academic_year DESC").Pluck("academic_year", &years).Error
+	return years, err
+}
+
+// ListForReport retrieves the census returns in the given statuses for reporting, for one
+// academic year or every year when academicYear is empty, limited to a region or zone when given
+func (r *CensusRepository) ListForReport(academicYear string, statuses []string, regionID, zoneID *int64) ([]CensusReportRow, error) {
+	var rows []CensusReportRow
+	query := r.db.Table("school_censuses").
+		Select("school_censuses.academic_year, school_censuses.zone_id, zones.name AS zone_name, zones.region_id, regions.name AS region_name, school_censuses.data").
+		Joins("LEFT JOIN zones ON zones.id = school_censuses.zone_id").
+		Joins("LEFT JOIN regions ON regions.id = zones.region_id").
+		Where("school_censuses.status IN ?", statuses)
+	if academicYear != "" {
+		query = query.Where("school_censuses.academic_year = ?", academicYear)
+	}
+	if zoneID != nil {
+		query = query.Where("school_censuses.zone_id = ?", *zoneID)
+	}
+	if regionID != nil {
+		query = query.Where("zones.region_id = ?", *regionID)
+	}
+
+	err := query.Order("school_censuses.academic_year ASC").Scan(&rows).Error
+	return rows, err
+}
+
+// CountSchoolsByZone counts the member schools of each zone, limited to a region or zone when given
+func (r *CensusRepository) CountSchoolsByZone(regionID, zoneID *int64) ([]ZoneSchoolCount, error) {
+	var counts []ZoneSchoolCount
+	query := r.db.Table("schools").
+		Select("schools.zone_id, zones.region_id, COUNT(*) AS schools").
+		Joins("JOIN zones ON zones.id = schools.zone_id").
+		Where("schools.is_deleted = ?", false)
+	if zoneID != nil {
+		query = query.Where("schools.zone_id = ?", *zoneID)
+	}
+	if regionID != nil {
+		query = query.Where("zones.region_id = ?", *regionID)
+	}
+
+	err := query.Group("schools.zone_id, zones.region_id").Scan(&counts).Error
+	return counts, err
+}
+
+// attachSchoolDetails fills in the school name, member number and zone name of census returns
+func (r *CensusRepository) attachSchoolDetails(censuses []*models.SchoolCensus) {
+	if len(censuses) == 0 {
+		return
+	}
+	schoolIDs := make([]int64, 0, len(censuses))
+	for _, census := range censuses {
+		schoolIDs = append(schoolIDs, census.SchoolId)
+	}
+
+	var schools []models.School
+	if err := r.db.Preload("Zone").Where("id IN ?", schoolIDs).Find(&schools).Error; err != nil {
+		return
+	}
+	byID := make(map[int64]*models.School, len(schools))
+	for i := range schools {
+		byID[int64(schools[i].ID)] = &schools[i]
+	}
+
+	for _, census := range censuses {
+		school, ok := byID[census.SchoolId]
+		if !ok {
+			continue
+		}
+		census.SchoolName = &school.Name
+		census.MemberNo = &school.MemberNo
+		if school.Zone != nil {
+			census.ZoneName = school.Zone.Name
+		}
+	}
+}
@@ -14,6 +14,11 @@ func NewContactPersonRepository(db *gorm.DB) *ContactPersonRepository {
 	return &ContactPersonRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *ContactPersonRepository) WithTx(tx *gorm.DB) *ContactPersonRepository {
+	return &ContactPersonRepository{db: tx}
+}
+
 func (r *ContactPersonRepository) FindByID(id uint) (*models.ContactPerson, error) {
 	var contactPerson models.ContactPerson
 	err := r.db.First(&contactPerson, id).Error
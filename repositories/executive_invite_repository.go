@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"errors"
+	"gnaps-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExecutiveInviteRepository struct {
+	db *gorm.DB
+}
+
+func NewExecutiveInviteRepository(db *gorm.DB) *ExecutiveInviteRepository {
+	return &ExecutiveInviteRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *ExecutiveInviteRepository) WithTx(tx *gorm.DB) *ExecutiveInviteRepository {
+	return &ExecutiveInviteRepository{db: tx}
+}
+
+// Create stores an invite; earlier pending invites for the same account are revoked
+func (r *ExecutiveInviteRepository) Create(invite *models.ExecutiveInvite) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ExecutiveInvite{}).
+			Where("user_id = ? AND status = ?", invite.UserId, "pending").
+			Updates(map[string]interface{}{
+				"status":     "revoked",
+				"revoked_at": time.Now(),
+				"revoked_by": invite.CreatedBy,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Create(invite).Error
+	})
+}
+
+// FindByTokenHash retrieves an invite by the hash of its token
+func (r *ExecutiveInviteRepository) FindByTokenHash(tokenHash string) (*models.ExecutiveInvite, error) {
+	var invite models.ExecutiveInvite
+	if err := r.db.Where("token_hash = ?", tokenHash).First(&invite).Error; err != nil {
+		return nil, err
+	}
+	markExpired(&invite)
+	return &invite, nil
+}
+
+// ListForExecutive retrieves an executive's invites, newest first
+func (r *ExecutiveInviteRepository) ListForExecutive(executiveID int64) ([]models.ExecutiveInvite, error) {
+	var invites []models.ExecutiveInvite
+	if err := r.db.Where("executive_id = ?", executiveID).Order("id DESC").Find(&invites).Error; err != nil {
+		return nil, err
+	}
+	for i := range invites {
+		markExpired(&invites[i])
+	}
+	return invites, nil
+}
+
+// HasAccepted reports whether the executive has accepted any invite
+func (r *ExecutiveInviteRepository) HasAccepted(executiveID int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.ExecutiveInvite{}).Where("executive_id = ? AND status = ?", executiveID, "accepted").Count(&count).Error
+	return count > 0, err
+}
+
+// RevokePending revokes an executive's pending invites and returns how many there were
+func (r *ExecutiveInviteRepository) RevokePending(executiveID int64, revokedBy *int64) (int64, error) {
+	result := revokePendingInvites(r.db, executiveID, revokedBy)
+	return result.RowsAffected, result.Error
+}
+
+// Accept uses an invite: the account gets the new password, is no longer on its first login and the
+// invite can't be used again. It fails if the invite was used, revoked or expired in the meantime.
+func (r *ExecutiveInviteRepository) Accept(invite *models.ExecutiveInvite, encryptedPassword string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.ExecutiveInvite{}).
+			Where("id = ? AND status = ? AND expires_at > ?", invite.ID, "pending", now).
+			Updates(map[string]interface{}{
+				"status":      "accepted",
+				"accepted_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invite is no longer valid")
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", invite.UserId).Updates(map[string]interface{}{
+			"encrypted_password": encryptedPassword,
+			"is_first_login":     false,
+		}).Error; err != nil {
+			return err
+		}
+
+		invite.Status = "accepted"
+		invite.AcceptedAt = &now
+		return nil
+	})
+}
+
+func revokePendingInvites(tx *gorm.DB, executiveID int64, revokedBy *int64) *gorm.DB {
+	return tx.Model(&models.ExecutiveInvite{}).
+		Where("executive_id = ? AND status = ?", executiveID, "pending").
+		Updates(map[string]interface{}{
+			"status":     "revoked",
+			"revoked_at": time.Now(),
+			"revoked_by": revokedBy,
+		})
+}
+
+func markExpired(invite *models.ExecutiveInvite) {
+	invite.IsExpired = invite.Status == "pending" && !invite.ExpiresAt.After(time.Now())
+}
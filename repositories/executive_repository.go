@@ -14,6 +14,16 @@ func NewExecutiveRepository(db *gorm.DB) *ExecutiveRepository {
 	return &ExecutiveRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *ExecutiveRepository) WithTx(tx *gorm.DB) *ExecutiveRepository {
+	return &ExecutiveRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *ExecutiveRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
 func (r *ExecutiveRepository) FindByID(id uint) (*models.Executive, error) {
 	var executive models.Executive
 	err := r.db.Where("id = ? AND is_deleted = ?", id, false).First(&executive).Error
@@ -142,6 +152,18 @@ func (r *ExecutiveRepository) FindByUserID(userID uint) (*models.Executive, erro
 	return &executive, nil
 }
 
+// FindWithDefaultPasswords finds the executives whose account still has the password it was created
+// with: a password is set, the executive is still on their first login and never accepted an invite
+func (r *ExecutiveRepository) FindWithDefaultPasswords() ([]models.Executive, error) {
+	var executives []models.Executive
+	err := r.db.Where("is_deleted = ?", false).
+		Where("user_id IN (SELECT id FROM users WHERE encrypted_password IS NOT NULL AND is_first_login = ? AND (is_deleted = ? OR is_deleted IS NULL))", true, false).
+		Where("user_id NOT IN (SELECT user_id FROM executive_invites WHERE status = ?)", "accepted").
+		Order("id ASC").
+		Find(&executives).Error
+	return executives, err
+}
+
 // ListWithRoleFilter returns executives filtered by role-based access
 // - system_admin/national_admin: all executives
 // - region_admin: executives in their region or zones within their region
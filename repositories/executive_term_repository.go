@@ -0,0 +1,424 @@
+package repositories
+
+import (
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Levels an executive term is held at
+const (
+	TermLevelNational = "national"
+	TermLevelRegion   = "region"
+	TermLevelZone     = "zone"
+)
+
+// Ways a term can end
+const (
+	TermEndCompleted  = "completed"
+	TermEndResigned   = "resigned"
+	TermEndRemoved    = "removed"
+	TermEndReassigned = "reassigned"
+	TermEndDeceased   = "deceased"
+	TermEndOther      = "other"
+)
+
+// currentTermCondition matches terms that are in force on a date: active, started and not past
+// their end date
+const currentTermCondition = "executive_terms.status = 'active' AND executive_terms.start_date <= ? AND (executive_terms.end_date IS NULL OR executive_terms.end_date >= ?)"
+
+// termLevelOrder puts national terms before region terms before zone terms
+const termLevelOrder = "FIELD(executive_terms.level, 'national', 'region', 'zone'), executive_terms.start_date DESC"
+
+type ExecutiveTermRepository struct {
+	db *gorm.DB
+}
+
+func NewExecutiveTermRepository(db *gorm.DB) *ExecutiveTermRepository {
+	return &ExecutiveTermRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *ExecutiveTermRepository) WithTx(tx *gorm.DB) *ExecutiveTermRepository {
+	return &ExecutiveTermRepository{db: tx}
+}
+
+// RoleForTermLevel is the admin role a term at a level gives
+func RoleForTermLevel(level string) string {
+	switch level {
+	case TermLevelNational:
+		return utils.RoleNationalAdmin
+	case TermLevelRegion:
+		return utils.RoleRegionAdmin
+	case TermLevelZone:
+		return utils.RoleZoneAdmin
+	}
+	return ""
+}
+
+// TermLevelForRole is the level of the terms that give an admin role
+func TermLevelForRole(role string) string {
+	switch role {
+	case utils.RoleNationalAdmin:
+		return TermLevelNational
+	case utils.RoleRegionAdmin:
+		return TermLevelRegion
+	case utils.RoleZoneAdmin:
+		return TermLevelZone
+	}
+	return ""
+}
+
+// CurrentExecutiveTerm returns the executive's highest current term, or nil when none is in force
+func CurrentExecutiveTerm(db *gorm.DB, executiveID uint) (*models.ExecutiveTerm, error) {
+	today := time.Now().Format("2006-01-02")
+	var terms []models.ExecutiveTerm
+	if err := db.Where("executive_terms.executive_id = ?", executiveID).
+		Where(currentTermCondition, today, today).
+		Order(termLevelOrder).Limit(1).Find(&terms).Error; err != nil {
+		return nil, err
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	return &terms[0], nil
+}
+
+// Current returns the executive's highest current term, or nil when none is in force
+func (r *ExecutiveTermRepository) Current(executiveID uint) (*models.ExecutiveTerm, error) {
+	return CurrentExecutiveTerm(r.db, executiveID)
+}
+
+// Create stores a term and brings the executive's record in line with their current terms
+func (r *ExecutiveTermRepository) Create(term *models.ExecutiveTerm) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(term).Error; err != nil {
+			return err
+		}
+		return syncExecutiveWithTerms(tx, term.ExecutiveId)
+	})
+}
+
+// Reassign ends the executive's active terms as reassigned on the new term's start date and starts
+// the new term
+func (r *ExecutiveTermRepository) Reassign(term *models.ExecutiveTerm) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := endActiveTerms(tx, term.ExecutiveId, term.StartDate, TermEndReassigned, term.CreatedBy); err != nil {
+			return err
+		}
+		if err := tx.Create(term).Error; err != nil {
+			return err
+		}
+		return syncExecutiveWithTerms(tx, term.ExecutiveId)
+	})
+}
+
+// EndAll ends all of the executive's active terms, e.g. when the executive is removed
+func (r *ExecutiveTermRepository) EndAll(executiveID int64, endedOn time.Time, reason string, endedBy *int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := endActiveTerms(tx, executiveID, endedOn, reason, endedBy); err != nil {
+			return err
+		}
+		return syncExecutiveWithTerms(tx, executiveID)
+	})
+}
+
+// End ends an active term. It fails if the term has already ended.
+func (r *ExecutiveTermRepository) End(term *models.ExecutiveTerm, endedOn time.Time, reason string, notes *string, endedBy *int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":     "ended",
+			"ended_on":   endedOn,
+			"end_reason": reason,
+			"ended_by":   endedBy,
+		}
+		if notes != nil {
+			updates["notes"] = notes
+		}
+		result := tx.Model(&models.ExecutiveTerm{}).Where("id = ? AND status = ?", term.ID, "active").Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("term has already ended")
+		}
+		return syncExecutiveWithTerms(tx, term.ExecutiveId)
+	})
+}
+
+// Update changes a term's dates or notes and brings the executive's record in line
+func (r *ExecutiveTermRepository) Update(term *models.ExecutiveTerm, updates map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ExecutiveTerm{}).Where("id = ?", term.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return syncExecutiveWithTerms(tx, term.ExecutiveId)
+	})
+}
+
+// CloseExpired ends active terms whose end date has passed as completed, and brings the records of
+// the executives whose terms ended or began today in line. It returns how many terms were ended.
+func (r *ExecutiveTermRepository) CloseExpired(today time.Time) (int64, error) {
+	day := today.Format("2006-01-02")
+
+	var executiveIDs []int64
+	if err := r.db.Model(&models.ExecutiveTerm{}).
+		Where("status = ? AND (end_date < ? OR start_date = ?)", "active", day, day).
+		Distinct().Pluck("executive_id", &executiveIDs).Error; err != nil {
+		return 0, err
+	}
+
+	var closed int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.ExecutiveTerm{}).
+			Where("status = ? AND end_date < ?", "active", day).
+			Updates(map[string]interface{}{
+				"status":     "ended",
+				"ended_on":   gorm.Expr("end_date"),
+				"end_reason": TermEndCompleted,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		closed = result.RowsAffected
+
+		for _, executiveID := range executiveIDs {
+			if err := syncExecutiveWithTerms(tx, executiveID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return closed, err
+}
+
+// FindByIDWithRoleFilter retrieves a term if it is held in the user's region or zone
+func (r *ExecutiveTermRepository) FindByIDWithRoleFilter(id uint, regionID, zoneID *int64) (*models.ExecutiveTerm, error) {
+	var term models.ExecutiveTerm
+	query := applyTermRoleFilter(r.db.Where("executive_terms.id = ?", id), regionID, zoneID)
+	if err := query.First(&term).Error; err != nil {
+		return nil, err
+	}
+	terms := []models.ExecutiveTerm{term}
+	r.attachDetails(terms)
+	return &terms[0], nil
+}
+
+// ListWithRoleFilter retrieves terms held in the user's region or zone, latest first
+func (r *ExecutiveTermRepository) ListWithRoleFilter(filters map[string]interface{}, page, limit int, regionID, zoneID *int64) ([]models.ExecutiveTerm, int64, error) {
+	var terms []models.ExecutiveTerm
+	var total int64
+
+	query := applyTermRoleFilter(r.db.Model(&models.ExecutiveTerm{}), regionID, zoneID)
+	for key, value := range filters {
+		if key == "current" {
+			today := time.Now().Format("2006-01-02")
+			query = query.Where(currentTermCondition, today, today)
+		} else {
+			query = query.Where("executive_terms."+key+" = ?", value)
+		}
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("executive_terms.start_date DESC, executive_terms.id DESC").Offset(offset).Limit(limit).Find(&terms).Error; err != nil {
+		return nil, 0, err
+	}
+	r.attachDetails(terms)
+	return terms, total, nil
+}
+
+// Holders retrieves everyone who has held a position at a level, in a region or zone for region and
+// zone positions, latest first
+func (r *ExecutiveTermRepository) Holders(positionID int64, level string, regionID, zoneID *int64) ([]models.ExecutiveTerm, error) {
+	var terms []models.ExecutiveTerm
+	query := r.db.Where("executive_terms.position_id = ? AND executive_terms.level = ?", positionID, level)
+	switch level {
+	case TermLevelRegion:
+		query = query.Where("executive_terms.region_id = ?", regionID)
+	case TermLevelZone:
+		query = query.Where("executive_terms.zone_id = ?", zoneID)
+	}
+	if err := query.Order("executive_terms.start_date DESC, executive_terms.id DESC").Find(&terms).Error; err != nil {
+		return nil, err
+	}
+	r.attachDetails(terms)
+	return terms, nil
+}
+
+// ListCurrent retrieves the terms in force today of executives who haven't been removed
+func (r *ExecutiveTermRepository) ListCurrent() ([]models.ExecutiveTerm, error) {
+	today := time.Now().Format("2006-01-02")
+	var terms []models.ExecutiveTerm
+	if err := r.db.Where(currentTermCondition, today, today).
+		Where("executive_terms.executive_id IN (SELECT id FROM executives WHERE is_deleted = ? OR is_deleted IS NULL)", false).
+		Order("executive_terms.start_date ASC, executive_terms.id ASC").Find(&terms).Error; err != nil {
+		return nil, err
+	}
+	r.attachDetails(terms)
+	return terms, nil
+}
+
+// OverlapExists checks whether the executive already has an active term for the position at the
+// same level, region and zone
+func (r *ExecutiveTermRepository) OverlapExists(term *models.ExecutiveTerm) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.ExecutiveTerm{}).
+		Where("executive_id = ? AND level = ? AND status = ?", term.ExecutiveId, term.Level, "active").
+		Where("end_date IS NULL OR end_date >= ?", term.StartDate.Format("2006-01-02"))
+	query = whereNullable(query, "position_id", term.PositionId)
+	query = whereNullable(query, "region_id", term.RegionId)
+	query = whereNullable(query, "zone_id", term.ZoneId)
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// attachDetails fills in the executive's name and photo and the position, region and zone names
+func (r *ExecutiveTermRepository) attachDetails(terms []models.ExecutiveTerm) {
+	if len(terms) == 0 {
+		return
+	}
+
+	executiveIDs := make([]int64, 0, len(terms))
+	positionIDs := make([]int64, 0, len(terms))
+	regionIDs := make([]int64, 0, len(terms))
+	zoneIDs := make([]int64, 0, len(terms))
+	for _, term := range terms {
+		executiveIDs = append(executiveIDs, term.ExecutiveId)
+		if term.PositionId != nil {
+			positionIDs = append(positionIDs, *term.PositionId)
+		}
+		if term.RegionId != nil {
+			regionIDs = append(regionIDs, *term.RegionId)
+		}
+		if term.ZoneId != nil {
+			zoneIDs = append(zoneIDs, *term.ZoneId)
+		}
+	}
+
+	var executives []models.Executive
+	r.db.Select("id, first_name, middle_name, last_name, image_url").Where("id IN ?", executiveIDs).Find(&executives)
+	executivesByID := make(map[int64]models.Executive, len(executives))
+	for _, executive := range executives {
+		executivesByID[int64(executive.ID)] = executive
+	}
+
+	positionNames := map[int64]*string{}
+	if len(positionIDs) > 0 {
+		var positions []models.Position
+		r.db.Select("id, name").Where("id IN ?", positionIDs).Find(&positions)
+		for _, position := range positions {
+			positionNames[int64(position.ID)] = position.Name
+		}
+	}
+	regionNames := map[int64]*string{}
+	if len(regionIDs) > 0 {
+		var regions []models.Region
+		r.db.Select("id, name").Where("id IN ?", regionIDs).Find(&regions)
+		for _, region := range regions {
+			regionNames[int64(region.ID)] = region.Name
+		}
+	}
+	zoneNames := map[int64]*string{}
+	if len(zoneIDs) > 0 {
+		var zones []models.Zone
+		r.db.Select("id, name").Where("id IN ?", zoneIDs).Find(&zones)
+		for _, zone := range zones {
+			zoneNames[int64(zone.ID)] = zone.Name
+		}
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for i := range terms {
+		term := &terms[i]
+		term.IsCurrent = term.Status == "active" && term.StartDate.Format("2006-01-02") <= today &&
+			(term.EndDate == nil || term.EndDate.Format("2006-01-02") >= today)
+		if executive, ok := executivesByID[term.ExecutiveId]; ok {
+			term.ExecutiveName = ExecutiveFullName(&executive)
+			term.ImageUrl = executive.ImageUrl
+		}
+		if term.PositionId != nil {
+			term.PositionName = positionNames[*term.PositionId]
+		}
+		if term.RegionId != nil {
+			term.RegionName = regionNames[*term.RegionId]
+		}
+		if term.ZoneId != nil {
+			term.ZoneName = zoneNames[*term.ZoneId]
+		}
+	}
+}
+
+// ExecutiveFullName joins an executive's first, middle and last names
+func ExecutiveFullName(executive *models.Executive) string {
+	parts := make([]string, 0, 3)
+	for _, name := range []*string{executive.FirstName, executive.MiddleName, executive.LastName} {
+		if name != nil && strings.TrimSpace(*name) != "" {
+			parts = append(parts, strings.TrimSpace(*name))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// applyTermRoleFilter limits terms to those held in a region admin's region (including its zones)
+// or a zone admin's zone
+func applyTermRoleFilter(query *gorm.DB, regionID, zoneID *int64) *gorm.DB {
+	if zoneID != nil {
+		return query.Where("executive_terms.zone_id = ?", *zoneID)
+	}
+	if regionID != nil {
+		return query.Where("executive_terms.region_id = ?", *regionID)
+	}
+	return query
+}
+
+func endActiveTerms(tx *gorm.DB, executiveID int64, endedOn time.Time, reason string, endedBy *int64) error {
+	return tx.Model(&models.ExecutiveTerm{}).
+		Where("executive_id = ? AND status = ?", executiveID, "active").
+		Updates(map[string]interface{}{
+			"status":     "ended",
+			"ended_on":   endedOn,
+			"end_reason": reason,
+			"ended_by":   endedBy,
+		}).Error
+}
+
+// syncExecutiveWithTerms copies the executive's highest current term onto their record and user
+// account, or marks the executive inactive when no term is in force
+func syncExecutiveWithTerms(tx *gorm.DB, executiveID int64) error {
+	term, err := CurrentExecutiveTerm(tx, uint(executiveID))
+	if err != nil {
+		return err
+	}
+	if term == nil {
+		return tx.Model(&models.Executive{}).Where("id = ?", executiveID).Update("status", "inactive").Error
+	}
+
+	role := RoleForTermLevel(term.Level)
+	if err := tx.Model(&models.Executive{}).Where("id = ?", executiveID).Updates(map[string]interface{}{
+		"position_id": term.PositionId,
+		"role":        role,
+		"region_id":   term.RegionId,
+		"zone_id":     term.ZoneId,
+		"status":      "active",
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.User{}).
+		Where("id = (SELECT user_id FROM executives WHERE id = ?)", executiveID).
+		Update("role", role).Error
+}
+
+func whereNullable(query *gorm.DB, column string, value *int64) *gorm.DB {
+	if value == nil {
+		return query.Where(column + " IS NULL")
+	}
+	return query.Where(column+" = ?", *value)
+}
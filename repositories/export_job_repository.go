@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExportJobRepository struct {
+	db *gorm.DB
+}
+
+func NewExportJobRepository(db *gorm.DB) *ExportJobRepository {
+	return &ExportJobRepository{db: db}
+}
+
+func (r *ExportJobRepository) Create(job *models.ExportJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *ExportJobRepository) FindByID(id uint) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FindForUser retrieves an export job requested by the given user
+func (r *ExportJobRepository) FindForUser(id uint, userID int64) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.Where("id = ? AND requested_by = ?", id, userID).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListForUser retrieves the export jobs requested by a user, newest first
+func (r *ExportJobRepository) ListForUser(userID int64, page, limit int) ([]models.ExportJob, int64, error) {
+	var jobs []models.ExportJob
+	var total int64
+
+	query := r.db.Model(&models.ExportJob{}).Where("requested_by = ?", userID)
+	query.Count(&total)
+
+	offset := (page - 1) * limit
+	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&jobs).Error
+
+	return jobs, total, err
+}
+
+// FindExpired retrieves the completed export jobs whose files expired before the given time
+func (r *ExportJobRepository) FindExpired(before time.Time) ([]models.ExportJob, error) {
+	var jobs []models.ExportJob
+	err := r.db.Where("status = ? AND expires_at < ?", "completed", before).Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *ExportJobRepository) Update(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.ExportJob{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -82,6 +82,74 @@ func (r *FinanceAccountRepository) CodeExists(code string, excludeID *uint) (boo
 	return count > 0, err
 }
 
+// FindByCode retrieves an active finance account by its code
+func (r *FinanceAccountRepository) FindByCode(code string) (*models.FinanceAccount, error) {
+	var account models.FinanceAccount
+	err := r.db.Where("code = ? AND is_deleted = ?", code, false).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// FindSystemAccount retrieves the owner's default account for a ledger role
+func (r *FinanceAccountRepository) FindSystemAccount(role, ownerType string, ownerID int64) (*models.FinanceAccount, error) {
+	var account models.FinanceAccount
+	err := r.db.Where("system_role = ? AND owner_type = ? AND owner_id = ? AND is_deleted = ?", role, ownerType, ownerID, false).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// HasChildren checks whether any active account rolls up into the account
+func (r *FinanceAccountRepository) HasChildren(id uint) bool {
+	var count int64
+	r.db.Model(&models.FinanceAccount{}).Where("parent_id = ? AND is_deleted = ?", id, false).Count(&count)
+	return count > 0
+}
+
+// FindWithAncestors retrieves the accounts and every heading above them
+func (r *FinanceAccountRepository) FindWithAncestors(ids []int64) ([]models.FinanceAccount, error) {
+	return FindAccountsWithAncestors(r.db, ids)
+}
+
+// FindAccountsWithAncestors loads the given finance accounts together with all of their parent
+// headings, walking up the chart one level per query
+func FindAccountsWithAncestors(db *gorm.DB, ids []int64) ([]models.FinanceAccount, error) {
+	var result []models.FinanceAccount
+	seen := make(map[int64]bool)
+
+	pending := ids
+	for len(pending) > 0 {
+		var batch []int64
+		for _, id := range pending {
+			if id > 0 && !seen[id] {
+				seen[id] = true
+				batch = append(batch, id)
+			}
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		var accounts []models.FinanceAccount
+		if err := db.Where("id IN ?", batch).Find(&accounts).Error; err != nil {
+			return nil, err
+		}
+
+		pending = nil
+		for _, account := range accounts {
+			result = append(result, account)
+			if account.ParentId != nil {
+				pending = append(pending, *account.ParentId)
+			}
+		}
+	}
+	return result, nil
+}
+
 // ============================================
 // Owner-based methods for data filtering
 // ============================================
@@ -144,6 +212,21 @@ func (r *FinanceAccountRepository) ListWithOwner(filters map[string]interface{},
 	return accounts, total, err
 }
 
+// ListAllWithOwner retrieves every active finance account visible to the owner, ordered by code
+func (r *FinanceAccountRepository) ListAllWithOwner(filters map[string]interface{}, ownerCtx *utils.OwnerContext) ([]models.FinanceAccount, error) {
+	var accounts []models.FinanceAccount
+
+	query := r.db.Model(&models.FinanceAccount{}).Where("is_deleted = ?", false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	err := query.Order("code ASC, name ASC").Find(&accounts).Error
+	return accounts, err
+}
+
 // UpdateWithOwner updates a finance account with owner verification
 func (r *FinanceAccountRepository) UpdateWithOwner(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
 	if err := CanWrite(ownerCtx); err != nil {
@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+	"gnaps-api/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FinanceExpenseRepository struct {
+	db *gorm.DB
+}
+
+func NewFinanceExpenseRepository(db *gorm.DB) *FinanceExpenseRepository {
+	return &FinanceExpenseRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *FinanceExpenseRepository) WithTx(tx *gorm.DB) *FinanceExpenseRepository {
+	return &FinanceExpenseRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *FinanceExpenseRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// GenerateVoucherNo issues the next payment voucher number for today (PV-YYYYMMDD-XXXXX). Call it
+// on a repository bound to the transaction that saves the voucher number: the day's sequence row
+// stays locked until that transaction ends, so concurrent payments queue up instead of taking the
+// same number. Numbers already in use are skipped.
+func (r *FinanceExpenseRepository) GenerateVoucherNo() (string, error) {
+	key := "PV-" + time.Now().Format("20060102")
+	var voucherNo string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&models.VoucherNoSequence{SequenceKey: key}).Error; err != nil {
+			return err
+		}
+		var sequence models.VoucherNoSequence
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("sequence_key = ?", key).First(&sequence).Error; err != nil {
+			return err
+		}
+
+		next := sequence.LastValue + 1
+		for {
+			var count int64
+			if err := tx.Model(&models.FinanceExpense{}).Where("voucher_no = ?", key+"-"+padLeft(next, 5)).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				break
+			}
+			next++
+		}
+
+		if err := tx.Model(&models.VoucherNoSequence{}).Where("id = ?", sequence.ID).Update("last_value", next).Error; err != nil {
+			return err
+		}
+		voucherNo = key + "-" + padLeft(next, 5)
+		return nil
+	})
+	return voucherNo, err
+}
+
+// ============================================
+// Owner-based methods for data filtering
+// ============================================
+
+// CreateWithOwner creates a new expense with owner fields automatically set
+func (r *FinanceExpenseRepository) CreateWithOwner(expense *models.FinanceExpense, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		ownerType, ownerID := ownerCtx.GetOwnerValues()
+		expense.SetOwner(ownerType, ownerID)
+	}
+	return r.db.Create(expense).Error
+}
+
+// FindByIDWithOwner retrieves an expense and its attachments with owner filtering
+func (r *FinanceExpenseRepository) FindByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.FinanceExpense, error) {
+	var expense models.FinanceExpense
+	query := r.db.Preload("Attachments").Where("id = ? AND is_deleted = ?", id, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	err := query.First(&expense).Error
+	if err != nil {
+		return nil, err
+	}
+	return &expense, nil
+}
+
+// ListWithOwner retrieves expenses with filters, pagination, and owner filtering
+func (r *FinanceExpenseRepository) ListWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.FinanceExpense, int64, error) {
+	var expenses []models.FinanceExpense
+	var total int64
+
+	query := r.db.Model(&models.FinanceExpense{}).Where("is_deleted = ?", false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	// Handle search filter
+	if search, ok := filters["search"]; ok {
+		searchPattern := "%" + search.(string) + "%"
+		query = query.Where("title LIKE ? OR description LIKE ? OR voucher_no LIKE ?", searchPattern, searchPattern, searchPattern)
+		delete(filters, "search")
+	}
+
+	// Handle date range filters
+	if fromDate, ok := filters["from_date"]; ok {
+		if fromTime, err := time.Parse("2006-01-02", fromDate.(string)); err == nil {
+			query = query.Where("transaction_date >= ?", fromTime)
+		}
+		delete(filters, "from_date")
+	}
+	if toDate, ok := filters["to_date"]; ok {
+		if toTime, err := time.Parse("2006-01-02", toDate.(string)); err == nil {
+			query = query.Where("transaction_date < ?", toTime.Add(24*time.Hour))
+		}
+		delete(filters, "to_date")
+	}
+
+	// Apply other filters
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	query.Count(&total)
+
+	offset := (page - 1) * limit
+	err := query.Preload("Attachments").Offset(offset).Limit(limit).Order("transaction_date DESC, created_at DESC").Find(&expenses).Error
+
+	return expenses, total, err
+}
+
+// UpdateWithOwner updates an expense with owner verification
+func (r *FinanceExpenseRepository) UpdateWithOwner(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	query := r.db.Model(&models.FinanceExpense{}).Where("id = ? AND is_deleted = ?", id, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	result := query.Updates(updates)
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// DeleteWithOwner soft deletes an expense with owner verification
+func (r *FinanceExpenseRepository) DeleteWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	query := r.db.Model(&models.FinanceExpense{}).Where("id = ?", id)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	result := query.Update("is_deleted", true)
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// ============================================
+// Attachments
+// ============================================
+
+func (r *FinanceExpenseRepository) CreateAttachment(attachment *models.FinanceExpenseAttachment) error {
+	return r.db.Create(attachment).Error
+}
+
+func (r *FinanceExpenseRepository) FindAttachment(expenseId, attachmentId uint) (*models.FinanceExpenseAttachment, error) {
+	var attachment models.FinanceExpenseAttachment
+	err := r.db.Where("id = ? AND finance_expense_id = ?", attachmentId, expenseId).First(&attachment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *FinanceExpenseRepository) DeleteAttachment(id uint) error {
+	return r.db.Delete(&models.FinanceExpenseAttachment{}, id).Error
+}
+
+// ============================================
+// Reporting
+// ============================================
+
+// ExpenseStats summarises expenses by workflow status
+type ExpenseStats struct {
+	Total           int64   `json:"total"`
+	Pending         int64   `json:"pending"`
+	Approved        int64   `json:"approved"`
+	Rejected        int64   `json:"rejected"`
+	Paid            int64   `json:"paid"`
+	PendingAmount   float64 `json:"pending_amount"`
+	ApprovedAmount  float64 `json:"approved_amount"`
+	PaidAmount      float64 `json:"paid_amount"`
+	UnpaidApprovals float64 `json:"unpaid_approvals"`
+}
+
+// GetStatsWithOwner returns expense counts and totals for the owner within an optional date range
+func (r *FinanceExpenseRepository) GetStatsWithOwner(fromDate, toDate *time.Time, ownerCtx *utils.OwnerContext) ExpenseStats {
+	return r.stats(fromDate, toDate, func(query *gorm.DB) *gorm.DB {
+		return ApplyOwnerFilterToQuery(query, ownerCtx)
+	})
+}
+
+// GetStatsForScope returns expense counts and totals for every owner in a consolidated scope
+func (r *FinanceExpenseRepository) GetStatsForScope(fromDate, toDate *time.Time, scope *OwnerScope) ExpenseStats {
+	return r.stats(fromDate, toDate, func(query *gorm.DB) *gorm.DB {
+		return ApplyOwnerScopeToQuery(query, "", scope)
+	})
+}
+
+func (r *FinanceExpenseRepository) stats(fromDate, toDate *time.Time, filter func(*gorm.DB) *gorm.DB) ExpenseStats {
+	var stats ExpenseStats
+
+	base := func() *gorm.DB {
+		query := r.db.Model(&models.FinanceExpense{}).Where("is_deleted = ?", false)
+		if fromDate != nil {
+			query = query.Where("transaction_date >= ?", *fromDate)
+		}
+		if toDate != nil {
+			query = query.Where("transaction_date < ?", *toDate)
+		}
+		return filter(query)
+	}
+
+	base().Count(&stats.Total)
+	base().Where("status = ?", "submitted").Count(&stats.Pending)
+	base().Where("is_approved = ?", true).Count(&stats.Approved)
+	base().Where("status = ?", "rejected").Count(&stats.Rejected)
+	base().Where("is_paid = ?", true).Count(&stats.Paid)
+
+	base().Where("status = ?", "submitted").Select("COALESCE(SUM(amount), 0)").Scan(&stats.PendingAmount)
+	base().Where("is_approved = ?", true).Select("COALESCE(SUM(amount), 0)").Scan(&stats.ApprovedAmount)
+	base().Where("is_paid = ?", true).Select("COALESCE(SUM(amount), 0)").Scan(&stats.PaidAmount)
+	stats.UnpaidApprovals = stats.ApprovedAmount - stats.PaidAmount
+
+	return stats
+}
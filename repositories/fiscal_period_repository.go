@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+	"gnaps-api/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FiscalPeriodRepository struct {
+	db *gorm.DB
+}
+
+func NewFiscalPeriodRepository(db *gorm.DB) *FiscalPeriodRepository {
+	return &FiscalPeriodRepository{db: db}
+}
+
+// Overlaps checks whether the owner already has a period covering any day of the range
+func (r *FiscalPeriodRepository) Overlaps(ownerType string, ownerID int64, start, end time.Time, excludeID *uint) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.FiscalPeriod{}).
+		Where("owner_type = ? AND owner_id = ? AND is_deleted = ?", ownerType, ownerID, false).
+		Where("start_date <= ? AND end_date >= ?", end.Format("2006-01-02"), start.Format("2006-01-02"))
+	if excludeID != nil {
+		query = query.Where("id != ?", *excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// FindClosedContaining retrieves the owner's closed period covering a date, if any
+func (r *FiscalPeriodRepository) FindClosedContaining(ownerType string, ownerID int64, date time.Time) (*models.FiscalPeriod, error) {
+	var period models.FiscalPeriod
+	day := date.Format("2006-01-02")
+	err := r.db.Where("owner_type = ? AND owner_id = ? AND status = ? AND is_deleted = ?", ownerType, ownerID, "closed", false).
+		Where("start_date <= ? AND end_date >= ?", day, day).
+		First(&period).Error
+	if err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// SetStatus changes a period's status and records the audit entry in a single database transaction
+func (r *FiscalPeriodRepository) SetStatus(id uint, updates map[string]interface{}, audit *models.FiscalPeriodAudit) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.FiscalPeriod{}).Where("id = ? AND is_deleted = ?", id, false).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// HasAudits checks whether a period has ever been closed
+func (r *FiscalPeriodRepository) HasAudits(id uint) bool {
+	var count int64
+	r.db.Model(&models.FiscalPeriodAudit{}).Where("fiscal_period_id = ?", id).Count(&count)
+	return count > 0
+}
+
+// ListAudits retrieves the close and reopen history of a period, newest first
+func (r *FiscalPeriodRepository) ListAudits(id uint) ([]models.FiscalPeriodAudit, error) {
+	var audits []models.FiscalPeriodAudit
+	err := r.db.Where("fiscal_period_id = ?", id).Order("created_at DESC, id DESC").Find(&audits).Error
+	return audits, err
+}
+
+// ============================================
+// Owner-based methods for data filtering
+// ============================================
+
+// CreateWithOwner creates a new fiscal period with owner fields automatically set
+func (r *FiscalPeriodRepository) CreateWithOwner(period *models.FiscalPeriod, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		ownerType, ownerID := ownerCtx.GetOwnerValues()
+		period.SetOwner(ownerType, ownerID)
+	}
+	return r.db.Create(period).Error
+}
+
+// FindByIDWithOwner retrieves a fiscal period by ID with owner filtering
+func (r *FiscalPeriodRepository) FindByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.FiscalPeriod, error) {
+	var period models.FiscalPeriod
+	query := r.db.Where("id = ? AND is_deleted = ?", id, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	if err := query.First(&period).Error; err != nil {
+		return nil, err
+	}
+	return &period, nil
+}
+
+// ListWithOwner retrieves fiscal periods with filters, pagination, and owner filtering
+func (r *FiscalPeriodRepository) ListWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.FiscalPeriod, int64, error) {
+	var periods []models.FiscalPeriod
+	var total int64
+
+	query := r.db.Model(&models.FiscalPeriod{}).Where("is_deleted = ?", false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("start_date DESC").Offset(offset).Limit(limit).Find(&periods).Error
+	return periods, total, err
+}
+
+// UpdateWithOwner updates a fiscal period with owner verification
+func (r *FiscalPeriodRepository) UpdateWithOwner(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	query := r.db.Model(&models.FiscalPeriod{}).Where("id = ? AND is_deleted = ?", id, false)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteWithOwner soft deletes a fiscal period with owner verification
+func (r *FiscalPeriodRepository) DeleteWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	return r.UpdateWithOwner(id, map[string]interface{}{"is_deleted": true}, ownerCtx)
+}
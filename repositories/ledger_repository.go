@@ -0,0 +1,291 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+	"gnaps-api/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *LedgerRepository) WithTx(tx *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: tx}
+}
+
+// CreateEntry numbers a journal entry and saves it with its lines in a single transaction
+func (r *LedgerRepository) CreateEntry(entry *models.JournalEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		entryNo, err := allocateEntryNo(tx)
+		if err != nil {
+			return err
+		}
+		entry.EntryNo = entryNo
+
+		lines := entry.Lines
+		entry.Lines = nil
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i].JournalEntryId = entry.ID
+		}
+		if len(lines) > 0 {
+			if err := tx.Create(&lines).Error; err != nil {
+				return err
+			}
+		}
+		entry.Lines = lines
+		return nil
+	})
+}
+
+// CreateReversal saves a reversing entry and flags the original as reversed
+func (r *LedgerRepository) CreateReversal(original *models.JournalEntry, reversal *models.JournalEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		entryNo, err := allocateEntryNo(tx)
+		if err != nil {
+			return err
+		}
+		reversal.EntryNo = entryNo
+
+		lines := reversal.Lines
+		reversal.Lines = nil
+		if err := tx.Create(reversal).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i].JournalEntryId = reversal.ID
+		}
+		if err := tx.Create(&lines).Error; err != nil {
+			return err
+		}
+		reversal.Lines = lines
+		return tx.Model(&models.JournalEntry{}).Where("id = ?", original.ID).Update("is_reversed", true).Error
+	})
+}
+
+// FindEntryBySource retrieves the active (non-reversed) entry posted for a source record
+func (r *LedgerRepository) FindEntryBySource(sourceType string, sourceId int64) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	err := r.db.Preload("Lines").
+		Where("source_type = ? AND source_id = ? AND is_reversed = ?", sourceType, sourceId, false).
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// allocateEntryNo issues the next journal entry number for today (JE-YYYYMMDD-XXXXX) inside a
+// transaction. The day's sequence row is locked until the transaction ends, so concurrent postings
+// queue up instead of taking the same number. Numbers already in use are skipped.
+func allocateEntryNo(tx *gorm.DB) (string, error) {
+	key := "JE-" + time.Now().Format("20060102")
+	if err := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&models.JournalEntrySequence{SequenceKey: key}).Error; err != nil {
+		return "", err
+	}
+	var sequence models.JournalEntrySequence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("sequence_key = ?", key).First(&sequence).Error; err != nil {
+		return "", err
+	}
+
+	next := sequence.LastValue + 1
+	for {
+		var count int64
+		if err := tx.Model(&models.JournalEntry{}).Where("entry_no = ?", key+"-"+padLeft(next, 5)).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			break
+		}
+		next++
+	}
+
+	if err := tx.Model(&models.JournalEntrySequence{}).Where("id = ?", sequence.ID).Update("last_value", next).Error; err != nil {
+		return "", err
+	}
+	return key + "-" + padLeft(next, 5), nil
+}
+
+// ============================================
+// Accounts
+// ============================================
+
+// FindAccountByID retrieves an active finance account
+func (r *LedgerRepository) FindAccountByID(id int64) (*models.FinanceAccount, error) {
+	var account models.FinanceAccount
+	err := r.db.Where("id = ? AND is_deleted = ?", id, false).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// FindSystemAccount retrieves the owner's default account for a ledger role
+func (r *LedgerRepository) FindSystemAccount(role, ownerType string, ownerID int64) (*models.FinanceAccount, error) {
+	var account models.FinanceAccount
+	err := r.db.Where("system_role = ? AND owner_type = ? AND owner_id = ? AND is_deleted = ?", role, ownerType, ownerID, false).
+		First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// FindAccountsWithAncestors retrieves the accounts and every heading above them
+func (r *LedgerRepository) FindAccountsWithAncestors(ids []int64) ([]models.FinanceAccount, error) {
+	return FindAccountsWithAncestors(r.db, ids)
+}
+
+func (r *LedgerRepository) CreateAccount(account *models.FinanceAccount) error {
+	return r.db.Create(account).Error
+}
+
+// FindParticularAccountForSchoolBill returns the finance account of the highest-priority
+// particular on a school bill that still has an outstanding amount
+func (r *LedgerRepository) FindParticularAccountForSchoolBill(schoolBillId int64) *int64 {
+	var particular models.SchoolBillingParticular
+	err := r.db.Where("school_billing_id = ? AND finance_account_id IS NOT NULL AND (is_deleted = ? OR is_deleted IS NULL)", schoolBillId, false).
+		Order("(COALESCE(amount, 0) - COALESCE(discount_amount, 0) - COALESCE(amount_paid, 0)) > 0 DESC, priority ASC").
+		First(&particular).Error
+	if err != nil || particular.FinanceAccountId == nil {
+		return nil
+	}
+	id := int64(*particular.FinanceAccountId)
+	return &id
+}
+
+// FindBillItemAccountForBill returns the finance account of the first item on a bill
+func (r *LedgerRepository) FindBillItemAccountForBill(billId int64) *int64 {
+	var item models.BillItem
+	err := r.db.Where("bill_id = ? AND finance_account_id IS NOT NULL AND (is_deleted = ? OR is_deleted IS NULL)", billId, false).
+		Order("priority ASC, id ASC").
+		First(&item).Error
+	if err != nil {
+		return nil
+	}
+	return item.FinanceAccountId
+}
+
+// FindEventBillID returns the bill attached to an event, looked up directly or through a registration
+func (r *LedgerRepository) FindEventBillID(financeType string, financeId int64) *int64 {
+	var billId *int64
+	switch financeType {
+	case "Event":
+		r.db.Table("events").Select("bill_id").Where("id = ?", financeId).Scan(&billId)
+	case "EventRegistration":
+		r.db.Table("event_registrations").
+			Select("events.bill_id").
+			Joins("JOIN events ON events.id = event_registrations.event_id").
+			Where("event_registrations.id = ?", financeId).
+			Scan(&billId)
+	}
+	return billId
+}
+
+// ============================================
+// Owner-based methods for data filtering
+// ============================================
+
+// FindEntryByIDWithOwner retrieves a journal entry with its lines with owner filtering
+func (r *LedgerRepository) FindEntryByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.JournalEntry, error) {
+	var entry models.JournalEntry
+	query := r.db.Preload("Lines").Preload("Lines.FinanceAccount").Where("id = ?", id)
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	err := query.First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// ListEntriesWithOwner retrieves journal entries with filters, pagination, and owner filtering
+func (r *LedgerRepository) ListEntriesWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.JournalEntry, int64, error) {
+	var entries []models.JournalEntry
+	var total int64
+
+	query := r.db.Model(&models.JournalEntry{})
+	query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+	if fromDate, ok := filters["from_date"]; ok {
+		if fromTime, err := time.Parse("2006-01-02", fromDate.(string)); err == nil {
+			query = query.Where("entry_date >= ?", fromTime)
+		}
+		delete(filters, "from_date")
+	}
+	if toDate, ok := filters["to_date"]; ok {
+		if toTime, err := time.Parse("2006-01-02", toDate.(string)); err == nil {
+			query = query.Where("entry_date < ?", toTime.Add(24*time.Hour))
+		}
+		delete(filters, "to_date")
+	}
+	if accountId, ok := filters["finance_account_id"]; ok {
+		query = query.Where("id IN (?)", r.db.Model(&models.JournalEntryLine{}).Select("journal_entry_id").Where("finance_account_id = ?", accountId))
+		delete(filters, "finance_account_id")
+	}
+
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	query.Count(&total)
+
+	offset := (page - 1) * limit
+	err := query.Preload("Lines").Preload("Lines.FinanceAccount").
+		Offset(offset).Limit(limit).Order("entry_date DESC, id DESC").Find(&entries).Error
+
+	return entries, total, err
+}
+
+// TrialBalanceRow is the debit and credit total for one account
+type TrialBalanceRow struct {
+	FinanceAccountId int64   `json:"finance_account_id" gorm:"column:finance_account_id"`
+	Code             string  `json:"code" gorm:"column:code"`
+	Name             string  `json:"name" gorm:"column:name"`
+	AccountType      string  `json:"account_type" gorm:"column:account_type"`
+	TotalDebit       float64 `json:"total_debit" gorm:"column:total_debit"`
+	TotalCredit      float64 `json:"total_credit" gorm:"column:total_credit"`
+	Balance          float64 `json:"balance" gorm:"-"`
+	BalanceSide      string  `json:"balance_side" gorm:"-"`
+}
+
+// TrialBalanceWithOwner sums journal lines per account for entries owned by the owner up to a date
+func (r *LedgerRepository) TrialBalanceWithOwner(asOf *time.Time, ownerCtx *utils.OwnerContext) ([]TrialBalanceRow, error) {
+	var rows []TrialBalanceRow
+
+	query := r.db.Table("journal_entry_lines").
+		Select(`journal_entry_lines.finance_account_id,
+			COALESCE(finance_accounts.code, '') as code,
+			COALESCE(finance_accounts.name, '') as name,
+			COALESCE(finance_accounts.account_type, '') as account_type,
+			COALESCE(SUM(journal_entry_lines.debit), 0) as total_debit,
+			COALESCE(SUM(journal_entry_lines.credit), 0) as total_credit`).
+		Joins("JOIN journal_entries ON journal_entries.id = journal_entry_lines.journal_entry_id").
+		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = journal_entry_lines.finance_account_id")
+
+	if asOf != nil {
+		query = query.Where("journal_entries.entry_date < ?", *asOf)
+	}
+	if ownerCtx != nil {
+		if filter := ownerCtx.GetOwnerFilter(); filter != nil {
+			query = query.Where("journal_entries.owner_type = ? AND journal_entries.owner_id = ?", filter.OwnerType, filter.OwnerID)
+		}
+	}
+
+	err := query.Group("journal_entry_lines.finance_account_id, finance_accounts.code, finance_accounts.name, finance_accounts.account_type").
+		Order("finance_accounts.code ASC").
+		Scan(&rows).Error
+
+	return rows, err
+}
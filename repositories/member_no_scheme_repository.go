@@ -0,0 +1,559 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Member number scheme scopes (member_no_schemes.scope_type)
+const (
+	MemberNoScopeNational = "national"
+	MemberNoScopeRegion   = "region"
+	MemberNoScopeZone     = "zone"
+)
+
+// DefaultMemberNoPattern is the original numbering, used where no scheme applies: the first three
+// letters of the zone name and a three digit sequence, e.g. ACC-001
+const DefaultMemberNoPattern = "{ZONE_PREFIX}-{SEQ:3}"
+
+const defaultMemberNoSeqWidth = 3
+
+// memberNoPlaceholder matches a pattern placeholder such as {ZONE} or {SEQ:4}
+var memberNoPlaceholder = regexp.MustCompile(`\{([A-Z_]+)(?::([0-9]+))?\}`)
+
+// MemberNoPlan is a zone's member number pattern with everything but the sequence filled in
+type MemberNoPlan struct {
+	Pattern string `json:"pattern"`
+	Prefix  string `json:"prefix"`
+	Suffix  string `json:"suffix"`
+	Width   int    `json:"width"`
+	Next    int64  `json:"next"` // next sequence number not allocated or in use
+}
+
+// Format builds the member number with the given sequence number
+func (p *MemberNoPlan) Format(number int64) string {
+	return fmt.Sprintf("%s%0*d%s", p.Prefix, p.Width, number, p.Suffix)
+}
+
+// Matches reports whether a member number follows the plan
+func (p *MemberNoPlan) Matches(memberNo string) bool {
+	_, ok := p.sequenceOf(memberNo)
+	return ok
+}
+
+func (p *MemberNoPlan) sequenceOf(memberNo string) (int64, bool) {
+	if !strings.HasPrefix(memberNo, p.Prefix) || !strings.HasSuffix(memberNo, p.Suffix) ||
+		len(memberNo) <= len(p.Prefix)+len(p.Suffix) {
+		return 0, false
+	}
+	digits := memberNo[len(p.Prefix) : len(memberNo)-len(p.Suffix)]
+	number, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || number < 0 || strings.HasPrefix(digits, "+") {
+		return 0, false
+	}
+	return number, true
+}
+
+func (p *MemberNoPlan) sequenceKey() string {
+	return p.Prefix + "#" + p.Suffix
+}
+
+// MemberNoMapping is a school's old and new member number in a re-numbering
+type MemberNoMapping struct {
+	SchoolId    uint   `json:"school_id"`
+	SchoolName  string `json:"school_name"`
+	ZoneId      int64  `json:"zone_id"`
+	OldMemberNo string `json:"old_member_no"`
+	NewMemberNo string `json:"new_member_no"`
+}
+
+type MemberNoSchemeRepository struct {
+	db *gorm.DB
+}
+
+func NewMemberNoSchemeRepository(db *gorm.DB) *MemberNoSchemeRepository {
+	return &MemberNoSchemeRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *MemberNoSchemeRepository) WithTx(tx *gorm.DB) *MemberNoSchemeRepository {
+	return &MemberNoSchemeRepository{db: tx}
+}
+
+// List retrieves the schemes, optionally of one scope type, limited to a region's own scheme and
+// those of its zones when regionID is given, or to one zone's when zoneID is given
+func (r *MemberNoSchemeRepository) List(scopeType string, regionID, zoneID *int64) ([]models.MemberNoScheme, error) {
+	var schemes []models.MemberNoScheme
+	query := r.db.Model(&models.MemberNoScheme{})
+	if scopeType != "" {
+		query = query.Where("scope_type = ?", scopeType)
+	}
+	if zoneID != nil {
+		query = query.Where("scope_type = ? AND scope_id = ?", MemberNoScopeZone, *zoneID)
+	} else if regionID != nil {
+		query = query.Where("(scope_type = ? AND scope_id = ?) OR (scope_type = ? AND scope_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?))",
+			MemberNoScopeRegion, *regionID, MemberNoScopeZone, *regionID, false)
+	}
+	err := query.Order("scope_type ASC, scope_id ASC").Find(&schemes).Error
+	return schemes, err
+}
+
+func (r *MemberNoSchemeRepository) FindByID(id uint) (*models.MemberNoScheme, error) {
+	var scheme models.MemberNoScheme
+	if err := r.db.First(&scheme, id).Error; err != nil {
+		return nil, err
+	}
+	return &scheme, nil
+}
+
+// ScopeExists checks if a scope already has a scheme
+func (r *MemberNoSchemeRepository) ScopeExists(scopeType string, scopeID int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.MemberNoScheme{}).Where("scope_type = ? AND scope_id = ?", scopeType, scopeID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *MemberNoSchemeRepository) Create(scheme *models.MemberNoScheme) error {
+	return r.db.Create(scheme).Error
+}
+
+func (r *MemberNoSchemeRepository) Update(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.MemberNoScheme{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *MemberNoSchemeRepository) Delete(id uint) error {
+	return r.db.Delete(&models.MemberNoScheme{}, id).Error
+}
+
+// Plan resolves a zone's member number pattern and its next sequence number
+func (r *MemberNoSchemeRepository) Plan(zoneID int64) (*MemberNoPlan, error) {
+	return memberNoPlan(r.db, zoneID, time.Now())
+}
+
+// NextMemberNo previews the member number a new school in the zone would get, without allocating it
+func (r *MemberNoSchemeRepository) NextMemberNo(zoneID int64) (string, error) {
+	plan, err := r.Plan(zoneID)
+	if err != nil {
+		return "", err
+	}
+	schoolRepo := NewSchoolRepository(r.db)
+	for next := plan.Next; ; next++ {
+		memberNo := plan.Format(next)
+		taken, err := schoolRepo.MemberNoExists(memberNo, nil)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return memberNo, nil
+		}
+	}
+}
+
+// AllocateMemberNo allocates the next member number of a zone. The number is reserved even if the
+// school is then not created.
+func (r *MemberNoSchemeRepository) AllocateMemberNo(zoneID int64) (string, error) {
+	var memberNo string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		allocated, err := allocateMemberNo(tx, zoneID, nil)
+		memberNo = allocated
+		return err
+	})
+	return memberNo, err
+}
+
+// PreviewRenumber works out the numbers a re-numbering would give the schools, in order, without
+// allocating them
+func (r *MemberNoSchemeRepository) PreviewRenumber(schools []models.School) ([]MemberNoMapping, error) {
+	now := time.Now()
+	schoolRepo := NewSchoolRepository(r.db)
+	plans := map[int64]*MemberNoPlan{}
+	next := map[string]int64{}
+	planned := map[string]bool{}
+
+	mappings := make([]MemberNoMapping, 0, len(schools))
+	for _, school := range schools {
+		if school.ZoneId == nil {
+			continue
+		}
+		plan, ok := plans[*school.ZoneId]
+		if !ok {
+			var err error
+			if plan, err = memberNoPlan(r.db, *school.ZoneId, now); err != nil {
+				return nil, err
+			}
+			plans[*school.ZoneId] = plan
+		}
+		key := plan.sequenceKey()
+		if _, ok := next[key]; !ok {
+			next[key] = plan.Next
+		}
+
+		for {
+			memberNo := plan.Format(next[key])
+			next[key]++
+			if planned[memberNo] {
+				continue
+			}
+			taken, err := schoolRepo.MemberNoExists(memberNo, &school.ID)
+			if err != nil {
+				return nil, err
+			}
+			if taken {
+				continue
+			}
+			planned[memberNo] = true
+			mappings = append(mappings, MemberNoMapping{
+				SchoolId:    school.ID,
+				SchoolName:  school.Name,
+				ZoneId:      *school.ZoneId,
+				OldMemberNo: school.MemberNo,
+				NewMemberNo: memberNo,
+			})
+			break
+		}
+	}
+	return mappings, nil
+}
+
+// Renumber gives the schools, in order, new member numbers under their zone's scheme and records
+// each old and new number under batchID. School admins sign in with the member number, so their
+// usernames change too.
+func (r *MemberNoSchemeRepository) Renumber(schools []models.School, batchID string, changedBy *int64) ([]MemberNoMapping, error) {
+	mappings := make([]MemberNoMapping, 0, len(schools))
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, school := range schools {
+			if school.ZoneId == nil {
+				continue
+			}
+			plan, err := memberNoPlan(tx, *school.ZoneId, time.Now())
+			if err != nil {
+				return err
+			}
+			memberNo, err := allocateMemberNo(tx, *school.ZoneId, &school.ID)
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&models.School{}).Where("id = ?", school.ID).Update("member_no", memberNo).Error; err != nil {
+				return err
+			}
+			if school.UserId != nil && *school.UserId > 0 {
+				if err := tx.Model(&models.User{}).Where("id = ?", *school.UserId).Update("username", memberNo).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(&models.MemberNoChange{
+				BatchId:     batchID,
+				SchoolId:    int64(school.ID),
+				OldMemberNo: school.MemberNo,
+				NewMemberNo: memberNo,
+				Pattern:     plan.Pattern,
+				ChangedBy:   changedBy,
+			}).Error; err != nil {
+				return err
+			}
+
+			mappings = append(mappings, MemberNoMapping{
+				SchoolId:    school.ID,
+				SchoolName:  school.Name,
+				ZoneId:      *school.ZoneId,
+				OldMemberNo: school.MemberNo,
+				NewMemberNo: memberNo,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+// ListChanges retrieves re-numbering records of the schools accessible by the user's role, newest first
+func (r *MemberNoSchemeRepository) ListChanges(filters map[string]interface{}, page, limit int, regionID, zoneID *int64) ([]models.MemberNoChange, int64, error) {
+	var changes []models.MemberNoChange
+	var total int64
+
+	query := r.db.Model(&models.MemberNoChange{})
+	query = applySchoolRoleFilter(query, regionID, zoneID)
+	for key, value := range filters {
+		if key == "member_no" {
+			query = query.Where("old_member_no = ? OR new_member_no = ?", value, value)
+		} else {
+			query = query.Where(key+" = ?", value)
+		}
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC, id ASC").Offset(offset).Limit(limit).Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	schoolIDs := make([]int64, 0, len(changes))
+	for _, change := range changes {
+		schoolIDs = append(schoolIDs, change.SchoolId)
+	}
+	var schools []models.School
+	if len(schoolIDs) > 0 && r.db.Select("id, name").Where("id IN ?", schoolIDs).Find(&schools).Error == nil {
+		names := make(map[int64]string, len(schools))
+		for _, school := range schools {
+			names[int64(school.ID)] = school.Name
+		}
+		for i := range changes {
+			if name, ok := names[changes[i].SchoolId]; ok {
+				changes[i].SchoolName = &name
+			}
+		}
+	}
+	return changes, total, nil
+}
+
+// ValidateMemberNoPattern checks that a pattern uses known placeholders and exactly one sequence
+func ValidateMemberNoPattern(pattern string) error {
+	zoneCode, regionCode, zoneName := "ZONE", "REGION", "ZONE"
+	_, _, _, err := renderMemberNoPattern(pattern, &models.Zone{Code: &zoneCode, Name: &zoneName}, &models.Region{Code: &regionCode}, time.Now())
+	return err
+}
+
+// ExampleMemberNo shows a pattern as it would look for a zone, with sequence number 1
+func (r *MemberNoSchemeRepository) ExampleMemberNo(pattern string, zoneID int64) (string, error) {
+	zone, region, err := memberNoZone(r.db, zoneID)
+	if err != nil {
+		return "", err
+	}
+	prefix, suffix, width, err := renderMemberNoPattern(pattern, zone, region, time.Now())
+	if err != nil {
+		return "", err
+	}
+	plan := &MemberNoPlan{Prefix: prefix, Suffix: suffix, Width: width}
+	return plan.Format(1), nil
+}
+
+// allocateMemberNo allocates a zone's next member number inside a transaction. The sequence row is
+// locked until the transaction ends, so concurrent allocations for the same pattern queue up.
+// Numbers already held by another school are skipped.
+func allocateMemberNo(tx *gorm.DB, zoneID int64, schoolID *uint) (string, error) {
+	plan, err := memberNoPlan(tx, zoneID, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	key := plan.sequenceKey()
+	if err := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&models.MemberNoSequence{SequenceKey: key}).Error; err != nil {
+		return "", err
+	}
+	var sequence models.MemberNoSequence
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("sequence_key = ?", key).First(&sequence).Error; err != nil {
+		return "", err
+	}
+
+	next := sequence.LastValue + 1
+	if plan.Next > next {
+		next = plan.Next
+	}
+	schoolRepo := NewSchoolRepository(tx)
+	for {
+		memberNo := plan.Format(next)
+		taken, err := schoolRepo.MemberNoExists(memberNo, schoolID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			break
+		}
+		next++
+	}
+
+	if err := tx.Model(&models.MemberNoSequence{}).Where("id = ?", sequence.ID).Update("last_value", next).Error; err != nil {
+		return "", err
+	}
+	return plan.Format(next), nil
+}
+
+// memberNoPlan resolves the scheme that applies to a zone (its own, its region's, the national one
+// or the default) and the next sequence number after both the last allocated and the highest in use
+func memberNoPlan(db *gorm.DB, zoneID int64, at time.Time) (*MemberNoPlan, error) {
+	zone, region, err := memberNoZone(db, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern := DefaultMemberNoPattern
+	var schemes []models.MemberNoScheme
+	query := db.Where("(scope_type = ? AND scope_id = ?) OR scope_type = ?", MemberNoScopeZone, zoneID, MemberNoScopeNational)
+	if zone.RegionId != nil {
+		query = query.Or("scope_type = ? AND scope_id = ?", MemberNoScopeRegion, *zone.RegionId)
+	}
+	if err := query.Find(&schemes).Error; err != nil {
+		return nil, err
+	}
+	rank := 0
+	for _, scheme := range schemes {
+		schemeRank := map[string]int{MemberNoScopeNational: 1, MemberNoScopeRegion: 2, MemberNoScopeZone: 3}[scheme.ScopeType]
+		if schemeRank > rank {
+			rank, pattern = schemeRank, scheme.Pattern
+		}
+	}
+
+	prefix, suffix, width, err := renderMemberNoPattern(pattern, zone, region, at)
+	if err != nil {
+		return nil, err
+	}
+	plan := &MemberNoPlan{Pattern: pattern, Prefix: prefix, Suffix: suffix, Width: width}
+
+	var highest int64
+	var sequence models.MemberNoSequence
+	if err := db.Where("sequence_key = ?", plan.sequenceKey()).Limit(1).Find(&sequence).Error; err != nil {
+		return nil, err
+	}
+	highest = sequence.LastValue
+
+	var inUse []string
+	if err := db.Model(&models.School{}).
+		Where("member_no LIKE ? AND is_deleted = ?", escapeLike(prefix)+"%"+escapeLike(suffix), false).
+		Pluck("member_no", &inUse).Error; err != nil {
+		return nil, err
+	}
+	for _, memberNo := range inUse {
+		if number, ok := plan.sequenceOf(memberNo); ok && number > highest {
+			highest = number
+		}
+	}
+	plan.Next = highest + 1
+	return plan, nil
+}
+
+func memberNoZone(db *gorm.DB, zoneID int64) (*models.Zone, *models.Region, error) {
+	var zone models.Zone
+	if err := db.Where("id = ? AND is_deleted = ?", zoneID, false).First(&zone).Error; err != nil {
+		return nil, nil, err
+	}
+	var region *models.Region
+	if zone.RegionId != nil {
+		var found models.Region
+		if err := db.Where("id = ?", *zone.RegionId).Limit(1).Find(&found).Error; err != nil {
+			return nil, nil, err
+		}
+		if found.ID != 0 {
+			region = &found
+		}
+	}
+	return &zone, region, nil
+}
+
+// renderMemberNoPattern fills in a pattern's placeholders for a zone, returning the text before and
+// after the sequence number and its zero-padded width. Placeholders:
+//
+//	{REGION}       region code (or the first three letters of its name)
+//	{ZONE}         zone code (or the first three letters of its name)
+//	{ZONE_PREFIX}  first three letters of the zone name, as in the original numbering
+//	{YEAR}, {YY}   year of allocation
+//	{SEQ}, {SEQ:n} sequence number, zero-padded to n digits (default 3)
+func renderMemberNoPattern(pattern string, zone *models.Zone, region *models.Region, at time.Time) (string, string, int, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return "", "", 0, errors.New("pattern is required")
+	}
+
+	var prefix, suffix strings.Builder
+	out := &prefix
+	width, sequences, last := 0, 0, 0
+	for _, match := range memberNoPlaceholder.FindAllStringSubmatchIndex(pattern, -1) {
+		out.WriteString(pattern[last:match[0]])
+		last = match[1]
+
+		name := pattern[match[2]:match[3]]
+		switch name {
+		case "SEQ":
+			sequences++
+			width = defaultMemberNoSeqWidth
+			if match[4] >= 0 {
+				width, _ = strconv.Atoi(pattern[match[4]:match[5]])
+			}
+			if width < 1 || width > 10 {
+				return "", "", 0, errors.New("sequence width must be between 1 and 10 digits")
+			}
+			out = &suffix
+			continue
+		case "REGION":
+			if region == nil {
+				return "", "", 0, errors.New("the zone has no region for {REGION}")
+			}
+			out.WriteString(codeOrPrefix(region.Code, region.Name))
+		case "ZONE":
+			out.WriteString(codeOrPrefix(zone.Code, zone.Name))
+		case "ZONE_PREFIX":
+			out.WriteString(codeOrPrefix(nil, zone.Name))
+		case "YEAR":
+			out.WriteString(strconv.Itoa(at.Year()))
+		case "YY":
+			out.WriteString(fmt.Sprintf("%02d", at.Year()%100))
+		default:
+			return "", "", 0, fmt.Errorf("unknown placeholder {%s}", name)
+		}
+		if match[4] >= 0 {
+			return "", "", 0, fmt.Errorf("only {SEQ} takes a width, not {%s}", name)
+		}
+	}
+	out.WriteString(pattern[last:])
+
+	if sequences != 1 {
+		return "", "", 0, errors.New("pattern must contain {SEQ} exactly once")
+	}
+	if strings.ContainsAny(prefix.String()+suffix.String(), "{}") {
+		return "", "", 0, errors.New("pattern has an unclosed or malformed placeholder")
+	}
+	if len(prefix.String())+len(suffix.String())+width > 50 {
+		return "", "", 0, errors.New("pattern gives member numbers longer than 50 characters")
+	}
+	return prefix.String(), suffix.String(), width, nil
+}
+
+// codeOrPrefix returns a code, or the upper-cased first three letters of the name without one
+func codeOrPrefix(code, name *string) string {
+	if code != nil && strings.TrimSpace(*code) != "" {
+		return strings.ToUpper(strings.TrimSpace(*code))
+	}
+	prefix := ""
+	if name != nil {
+		prefix = *name
+	}
+	if len(prefix) > 3 {
+		prefix = prefix[:3]
+	}
+	return strings.ToUpper(prefix)
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// ListSchoolsForRenumber retrieves the schools of a zone, a region or a list, oldest members first,
+// limited to a region when roleRegionID is given
+func (r *MemberNoSchemeRepository) ListSchoolsForRenumber(zoneID, regionID *int64, schoolIDs []uint, roleRegionID *int64) ([]models.School, error) {
+	var schools []models.School
+	query := r.db.Model(&models.School{}).Where("schools.is_deleted = ? AND schools.zone_id IS NOT NULL", false)
+	query = applySchoolsTableRoleFilter(query, roleRegionID, nil)
+	if zoneID != nil {
+		query = query.Where("schools.zone_id = ?", *zoneID)
+	}
+	if regionID != nil {
+		query = query.Where("schools.zone_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?)", *regionID, false)
+	}
+	if len(schoolIDs) > 0 {
+		query = query.Where("schools.id IN ?", schoolIDs)
+	}
+	err := query.Order("schools.joining_date ASC, schools.id ASC").Find(&schools).Error
+	return schools, err
+}
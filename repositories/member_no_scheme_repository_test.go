@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"gnaps-api/internal/testdb"
+	"gnaps-api/models"
+	"strconv"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var memberNoTestTables = []interface{}{&models.Region{}, &models.Zone{}, &models.School{}, &models.MemberNoScheme{}, &models.MemberNoSequence{}}
+
+// memberNoTestRows are zone 10, Accra Central (code ACZ), in region 1, Greater Accra (code GAR)
+func memberNoTestRows() []interface{} {
+	return []interface{}{
+		&models.Region{ID: 1, Name: testdb.Ptr("Greater Accra"), Code: testdb.Ptr("GAR"), IsDeleted: testdb.Ptr(false)},
+		&models.Zone{ID: 10, Name: testdb.Ptr("Accra Central"), Code: testdb.Ptr("ACZ"), RegionId: testdb.Ptr(int64(1)), IsDeleted: testdb.Ptr(false)},
+	}
+}
+
+func memberNoTestSchool(memberNo string) *models.School {
+	return &models.School{MemberNo: memberNo, Name: memberNo, IsDeleted: testdb.Ptr(false)}
+}
+
+func TestAllocateMemberNo(t *testing.T) {
+	year := strconv.Itoa(time.Now().Year())
+
+	tests := []struct {
+		name    string
+		zoneID  int64
+		seed    []interface{}
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "default pattern starts at one",
+			want: "ACC-001",
+		},
+		{
+			name: "continues after the highest number in use",
+			seed: []interface{}{memberNoTestSchool("ACC-001"), memberNoTestSchool("ACC-004"), memberNoTestSchool("OTHER-009")},
+			want: "ACC-005",
+		},
+		{
+			name: "continues after the last allocated number",
+			seed: []interface{}{
+				&models.MemberNoSequence{SequenceKey: "ACC-#", LastValue: 7},
+				memberNoTestSchool("ACC-002"),
+			},
+			want: "ACC-008",
+		},
+		{
+			name: "national scheme applies without a region or zone scheme",
+			seed: []interface{}{
+				&models.MemberNoScheme{ScopeType: MemberNoScopeNational, Pattern: "GNAPS/{SEQ:5}"},
+			},
+			want: "GNAPS/00001",
+		},
+		{
+			name: "region scheme overrides the national scheme",
+			seed: []interface{}{
+				&models.MemberNoScheme{ScopeType: MemberNoScopeNational, Pattern: "GNAPS/{SEQ:5}"},
+				&models.MemberNoScheme{ScopeType: MemberNoScopeRegion, ScopeId: 1, Pattern: "{REGION}-{SEQ:4}"},
+			},
+			want: "GAR-0001",
+		},
+		{
+			name: "zone scheme overrides the region scheme",
+			seed: []interface{}{
+				&models.MemberNoScheme{ScopeType: MemberNoScopeRegion, ScopeId: 1, Pattern: "{REGION}-{SEQ:4}"},
+				&models.MemberNoScheme{ScopeType: MemberNoScopeZone, ScopeId: 10, Pattern: "{ZONE}/{YEAR}/{SEQ:2}"},
+				memberNoTestSchool("ACZ/" + year + "/01"),
+			},
+			want: "ACZ/" + year + "/02",
+		},
+		{
+			name: "another zone's scheme does not apply",
+			seed: []interface{}{
+				&models.MemberNoScheme{ScopeType: MemberNoScopeZone, ScopeId: 11, Pattern: "{ZONE}/{SEQ:2}"},
+			},
+			want: "ACC-001",
+		},
+		{
+			name:    "unknown zone",
+			zoneID:  99,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.Open(t, memberNoTestTables...)
+			testdb.Seed(t, db, memberNoTestRows()...)
+			testdb.Seed(t, db, tt.seed...)
+			zoneID := tt.zoneID
+			if zoneID == 0 {
+				zoneID = 10
+			}
+
+			var got string
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				got, err = allocateMemberNo(tx, zoneID, nil)
+				return err
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("allocateMemberNo() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("allocateMemberNo() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("allocateMemberNo() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAllocateMemberNoAdvancesSequence(t *testing.T) {
+	db := testdb.Open(t, memberNoTestTables...)
+	testdb.Seed(t, db, memberNoTestRows()...)
+
+	for _, want := range []string{"ACC-001", "ACC-002", "ACC-003"} {
+		got, err := allocateMemberNo(db, 10, nil)
+		if err != nil {
+			t.Fatalf("allocateMemberNo() error = %v", err)
+		}
+		if got != want {
+			t.Errorf("allocateMemberNo() = %q, want %q", got, want)
+		}
+	}
+
+	var sequences []models.MemberNoSequence
+	if err := db.Find(&sequences).Error; err != nil {
+		t.Fatalf("load sequences: %v", err)
+	}
+	if len(sequences) != 1 || sequences[0].LastValue != 3 {
+		t.Errorf("sequences = %+v, want one row at 3", sequences)
+	}
+}
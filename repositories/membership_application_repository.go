@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+
+	"gorm.io/gorm"
+)
+
+type MembershipApplicationRepository struct {
+	db *gorm.DB
+}
+
+func NewMembershipApplicationRepository(db *gorm.DB) *MembershipApplicationRepository {
+	return &MembershipApplicationRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *MembershipApplicationRepository) WithTx(tx *gorm.DB) *MembershipApplicationRepository {
+	return &MembershipApplicationRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *MembershipApplicationRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// Create stores a new application
+func (r *MembershipApplicationRepository) Create(application *models.MembershipApplication) error {
+	return r.db.Create(application).Error
+}
+
+// PendingExists checks whether a pending application with the same school name is already queued in the zone
+func (r *MembershipApplicationRepository) PendingExists(zoneID int64, name string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.MembershipApplication{}).
+		Where("zone_id = ? AND LOWER(name) = LOWER(?) AND status = ? AND is_deleted = ?", zoneID, name, "pending", false).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// FindByIDWithRoleFilter retrieves an application if its zone is accessible by the user's role
+func (r *MembershipApplicationRepository) FindByIDWithRoleFilter(id uint, regionID, zoneID *int64) (*models.MembershipApplication, error) {
+	var application models.MembershipApplication
+	query := r.db.Where("id = ? AND is_deleted = ?", id, false)
+	query = applyZoneRoleFilter(query, regionID, zoneID)
+
+	if err := query.Preload("Zone").First(&application).Error; err != nil {
+		return nil, err
+	}
+	return &application, nil
+}
+
+// ListWithRoleFilter retrieves applications in the zones accessible by the user's role, oldest first
+// - system_admin/national_admin: all zones
+// - region_admin: zones within their region
+// - zone_admin: their zone
+func (r *MembershipApplicationRepository) ListWithRoleFilter(filters map[string]interface{}, page, limit int, regionID, zoneID *int64) ([]models.MembershipApplication, int64, error) {
+	var applications []models.MembershipApplication
+	var total int64
+
+	query := r.db.Model(&models.MembershipApplication{}).Where("is_deleted = ?", false)
+	query = applyZoneRoleFilter(query, regionID, zoneID)
+
+	for key, value := range filters {
+		if key == "name" {
+			query = query.Where("name LIKE ?", "%"+value.(string)+"%")
+		} else if key == "region_id" {
+			query = query.Where("zone_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?)", value, false)
+		} else {
+			query = query.Where(key+" = ?", value)
+		}
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Zone").Order("created_at ASC").Offset(offset).Limit(limit).Find(&applications).Error
+	return applications, total, err
+}
+
+// Review records the outcome of a pending application; it fails if the application was already reviewed
+func (r *MembershipApplicationRepository) Review(id uint, updates map[string]interface{}) error {
+	result := r.db.Model(&models.MembershipApplication{}).
+		Where("id = ? AND status = ? AND is_deleted = ?", id, "pending", false).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Update updates an application
+func (r *MembershipApplicationRepository) Update(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.MembershipApplication{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// applyZoneRoleFilter restricts a query on a zone_id column to the zones a region or zone admin covers
+func applyZoneRoleFilter(query *gorm.DB, regionID, zoneID *int64) *gorm.DB {
+	if zoneID != nil {
+		return query.Where("zone_id = ?", *zoneID)
+	}
+	if regionID != nil {
+		return query.Where("zone_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?)", *regionID, false)
+	}
+	return query
+}
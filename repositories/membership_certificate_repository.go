@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"fmt"
+	"gnaps-api/models"
+
+	"gorm.io/gorm"
+)
+
+type MembershipCertificateRepository struct {
+	db *gorm.DB
+}
+
+func NewMembershipCertificateRepository(db *gorm.DB) *MembershipCertificateRepository {
+	return &MembershipCertificateRepository{db: db}
+}
+
+// Issue numbers and stores a certificate; the school's earlier active certificates are superseded
+func (r *MembershipCertificateRepository) Issue(certificate *models.MembershipCertificate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.MembershipCertificate{}).
+			Where("school_id = ? AND status = ?", certificate.SchoolId, "active").
+			Update("status", "superseded").Error; err != nil {
+			return err
+		}
+
+		// Format: CERT-YYYY-XXXXX, numbered per year of issue
+		year := certificate.ValidFrom.Year()
+		var count int64
+		if err := tx.Model(&models.MembershipCertificate{}).
+			Where("certificate_no LIKE ?", fmt.Sprintf("CERT-%d-%%", year)).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		certificate.CertificateNo = fmt.Sprintf("CERT-%d-%s", year, padLeft(count+1, 5))
+
+		return tx.Create(certificate).Error
+	})
+}
+
+// CodeExists checks whether a verification code is already in use
+func (r *MembershipCertificateRepository) CodeExists(code string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.MembershipCertificate{}).Where("verification_code = ?", code).Count(&count).Error
+	return count > 0, err
+}
+
+// FindByCode retrieves a certificate by its verification code
+func (r *MembershipCertificateRepository) FindByCode(code string) (*models.MembershipCertificate, error) {
+	var certificate models.MembershipCertificate
+	if err := r.db.Where("verification_code = ?", code).First(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// FindByIDWithRoleFilter retrieves a certificate if its school is accessible by the user's role
+func (r *MembershipCertificateRepository) FindByIDWithRoleFilter(id uint, regionID, zoneID *int64) (*models.MembershipCertificate, error) {
+	var certificate models.MembershipCertificate
+	query := r.db.Where("id = ?", id)
+	query = applySchoolRoleFilter(query, regionID, zoneID)
+
+	if err := query.First(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// ListWithRoleFilter retrieves certificates of the schools accessible by the user's role, newest first
+func (r *MembershipCertificateRepository) ListWithRoleFilter(filters map[string]interface{}, page, limit int, regionID, zoneID *int64) ([]models.MembershipCertificate, int64, error) {
+	var certificates []models.MembershipCertificate
+	var total int64
+
+	query := r.db.Model(&models.MembershipCertificate{})
+	query = applySchoolRoleFilter(query, regionID, zoneID)
+
+	for key, value := range filters {
+		if key == "school_name" {
+			query = query.Where("school_name LIKE ?", "%"+value.(string)+"%")
+		} else {
+			query = query.Where(key+" = ?", value)
+		}
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&certificates).Error
+	return certificates, total, err
+}
+
+// Revoke records the revocation of an active certificate; it fails if the certificate is no longer active
+func (r *MembershipCertificateRepository) Revoke(id uint, updates map[string]interface{}) error {
+	result := r.db.Model(&models.MembershipCertificate{}).
+		Where("id = ? AND status = ?", id, "active").
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// applySchoolRoleFilter restricts a query on a school_id column to the schools in the zones a
+// region or zone admin covers
+func applySchoolRoleFilter(query *gorm.DB, regionID, zoneID *int64) *gorm.DB {
+	if zoneID != nil {
+		return query.Where("school_id IN (SELECT id FROM schools WHERE zone_id = ?)", *zoneID)
+	}
+	if regionID != nil {
+		return query.Where("school_id IN (SELECT id FROM schools WHERE zone_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?))", *regionID, false)
+	}
+	return query
+}
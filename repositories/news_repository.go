@@ -17,7 +17,12 @@ func NewNewsRepository(db *gorm.DB) *NewsRepository {
 
 // Create creates a new news item
 func (r *NewsRepository) Create(news *models.New) error {
-	return r.db.Create(news).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(news).Error; err != nil {
+			return err
+		}
+		return replaceGroupTargets(tx, GroupTargetNews, int64(news.ID), parseJSONIDs(news.SchoolGroupIds))
+	})
 }
 
 // FindByID retrieves a news item by ID
@@ -104,9 +109,17 @@ func (r *NewsRepository) ListAll(filters map[string]interface{}) ([]models.New,
 	return news, nil
 }
 
-// Update updates a news item
+// Update updates a news item; school_group_ids also replaces its group targeting
 func (r *NewsRepository) Update(id uint, updates map[string]interface{}) error {
-	return r.db.Model(&models.New{}).Where("id = ?", id).Updates(updates).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.New{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if groupIDs, ok := groupIDsUpdate(updates); ok {
+			return replaceGroupTargets(tx, GroupTargetNews, int64(id), groupIDs)
+		}
+		return nil
+	})
 }
 
 // Delete soft deletes a news item
@@ -165,7 +178,7 @@ func (r *NewsRepository) CreateWithOwner(news *models.New, ownerCtx *utils.Owner
 		ownerType, ownerID := ownerCtx.GetOwnerValues()
 		news.SetOwner(ownerType, ownerID)
 	}
-	return r.db.Create(news).Error
+	return r.Create(news)
 }
 
 // FindByIDWithOwner retrieves a news item by ID with owner filtering
@@ -234,16 +247,24 @@ func (r *NewsRepository) UpdateWithOwner(id uint, updates map[string]interface{}
 		return err
 	}
 
-	query := r.db.Model(&models.New{}).Where("id = ?", id)
-
-	// Apply owner filter to ensure user can only update their own data
-	query = ApplyOwnerFilterToQuery(query, ownerCtx)
-
-	result := query.Updates(updates)
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
-	return result.Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&models.New{}).Where("id = ?", id)
+
+		// Apply owner filter to ensure user can only update their own data
+		query = ApplyOwnerFilterToQuery(query, ownerCtx)
+
+		result := query.Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if groupIDs, ok := groupIDsUpdate(updates); ok {
+			return replaceGroupTargets(tx, GroupTargetNews, int64(id), groupIDs)
+		}
+		return nil
+	})
 }
 
 // DeleteWithOwner soft deletes a news item with owner verification
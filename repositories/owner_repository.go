@@ -388,3 +388,163 @@ func GetOwnerFromEvent(db *gorm.DB, eventCode string) *OwnerInfo {
 
 	return nil
 }
+
+// ============================================
+// Hierarchical owner scopes for consolidated reporting
+// ============================================
+
+// OwnerScope describes the owners covered by a consolidated report
+// A zone scope covers the zone, a region scope covers the region and its zones,
+// and a national scope covers every owner
+type OwnerScope struct {
+	OwnerType string
+	OwnerID   int64
+	ZoneIDs   []int64 // zones within a region scope
+}
+
+// IsNational reports whether the scope covers every owner
+func (s *OwnerScope) IsNational() bool {
+	return s == nil || s.OwnerType == utils.OwnerTypeNational
+}
+
+// ResolveOwnerScope builds the scope for an owner, loading the zones of a region
+func ResolveOwnerScope(db *gorm.DB, ownerType string, ownerID int64) *OwnerScope {
+	scope := &OwnerScope{OwnerType: ownerType, OwnerID: ownerID}
+
+	if ownerType == utils.OwnerTypeRegion {
+		db.Model(&models.Zone{}).
+			Where("region_id = ? AND (is_deleted IS NULL OR is_deleted = ?)", ownerID, false).
+			Pluck("id", &scope.ZoneIDs)
+	}
+	return scope
+}
+
+// ZoneIDsInScope returns the zones covered by a region or zone scope
+func (s *OwnerScope) ZoneIDsInScope() []int64 {
+	if s.IsNational() {
+		return nil
+	}
+	if s.OwnerType == utils.OwnerTypeZone {
+		return []int64{s.OwnerID}
+	}
+	return s.ZoneIDs
+}
+
+// Covers reports whether an owner falls within the scope
+func (s *OwnerScope) Covers(ownerType string, ownerID int64) bool {
+	if s.IsNational() {
+		return true
+	}
+	if ownerType == s.OwnerType && ownerID == s.OwnerID {
+		return true
+	}
+	if s.OwnerType == utils.OwnerTypeRegion && ownerType == utils.OwnerTypeZone {
+		for _, zoneID := range s.ZoneIDs {
+			if zoneID == ownerID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ApplyOwnerScopeToQuery restricts a query to the owners in the scope
+// tableName qualifies the owner columns for joined queries and may be empty
+func ApplyOwnerScopeToQuery(query *gorm.DB, tableName string, scope *OwnerScope) *gorm.DB {
+	if scope.IsNational() {
+		return query
+	}
+
+	condition, args := ownerScopeCondition(tableName, scope)
+	return query.Where("("+condition+")", args...)
+}
+
+// ApplySchoolScopeToQuery restricts a query to rows owned within the scope or paid by
+// schools in the scope's zones, so zones also see their schools' payments on regional
+// or national bills and events. The table must have a school_id column
+func ApplySchoolScopeToQuery(query *gorm.DB, tableName string, scope *OwnerScope) *gorm.DB {
+	if scope.IsNational() {
+		return query
+	}
+
+	condition, args := ownerScopeCondition(tableName, scope)
+	if zoneIDs := scope.ZoneIDsInScope(); len(zoneIDs) > 0 {
+		schoolID := "school_id"
+		if tableName != "" {
+			schoolID = tableName + ".school_id"
+		}
+		condition = "(" + condition + ") OR " + schoolID + " IN (SELECT id FROM schools WHERE zone_id IN ?)"
+		args = append(args, zoneIDs)
+	}
+	return query.Where("("+condition+")", args...)
+}
+
+// ApplySchoolScopeAtDateToQuery is ApplySchoolScopeToQuery for dated rows: a school's rows count
+// for the zone it was in on the row's date (dateColumn), so transferred schools' past payments
+// stay with their old zone. Schools that were never transferred match on their current zone
+func ApplySchoolScopeAtDateToQuery(query *gorm.DB, tableName, dateColumn string, scope *OwnerScope) *gorm.DB {
+	if scope.IsNational() {
+		return query
+	}
+
+	condition, args := ownerScopeCondition(tableName, scope)
+	if zoneIDs := scope.ZoneIDsInScope(); len(zoneIDs) > 0 {
+		schoolID := "school_id"
+		if tableName != "" {
+			schoolID = tableName + ".school_id"
+			dateColumn = tableName + "." + dateColumn
+		}
+		condition = "(" + condition + ") OR " + schoolID + " IN (SELECT id FROM schools WHERE zone_id IN ? AND id NOT IN (SELECT school_id FROM school_zone_histories))" +
+			" OR EXISTS (SELECT 1 FROM school_zone_histories WHERE " + schoolZoneHistoryAt(schoolID, dateColumn) + " AND school_zone_histories.zone_id IN ?)"
+		args = append(args, zoneIDs, zoneIDs)
+	}
+	return query.Where("("+condition+")", args...)
+}
+
+// ApplySchoolBillScopeToQuery restricts a school_bills query to bills owned within the scope or
+// raised against a zone in the scope; bills keep the zone they were issued under when a school is
+// transferred unless the transfer moved them
+func ApplySchoolBillScopeToQuery(query *gorm.DB, scope *OwnerScope) *gorm.DB {
+	if scope.IsNational() {
+		return query
+	}
+
+	condition, args := ownerScopeCondition("school_bills", scope)
+	if zoneIDs := scope.ZoneIDsInScope(); len(zoneIDs) > 0 {
+		condition = "(" + condition + ") OR school_bills.zone_id IN ?" +
+			" OR (school_bills.zone_id IS NULL AND school_bills.school_id IN (SELECT id FROM schools WHERE zone_id IN ?))"
+		args = append(args, zoneIDs, zoneIDs)
+	}
+	return query.Where("("+condition+")", args...)
+}
+
+// SchoolZoneAtExpr is an SQL expression for the zone a school was in on a date, falling back to
+// the school's current zone (the query must join schools)
+func SchoolZoneAtExpr(schoolIDColumn, dateColumn string) string {
+	return "COALESCE((SELECT school_zone_histories.zone_id FROM school_zone_histories WHERE " +
+		schoolZoneHistoryAt(schoolIDColumn, dateColumn) + " LIMIT 1), schools.zone_id)"
+}
+
+// schoolZoneHistoryAt matches the history period of a school covering a date
+func schoolZoneHistoryAt(schoolIDColumn, dateColumn string) string {
+	return "school_zone_histories.school_id = " + schoolIDColumn +
+		" AND (school_zone_histories.started_at IS NULL OR school_zone_histories.started_at <= " + dateColumn + ")" +
+		" AND (school_zone_histories.ended_at IS NULL OR school_zone_histories.ended_at > " + dateColumn + ")"
+}
+
+func ownerScopeCondition(tableName string, scope *OwnerScope) (string, []interface{}) {
+	ownerType, ownerID := "owner_type", "owner_id"
+	if tableName != "" {
+		ownerType, ownerID = tableName+".owner_type", tableName+".owner_id"
+	}
+	return ownerColumnsCondition(ownerType, ownerID, scope)
+}
+
+// ownerColumnsCondition matches an owner type/id column pair against the scope
+func ownerColumnsCondition(typeColumn, idColumn string, scope *OwnerScope) (string, []interface{}) {
+	if scope.OwnerType == utils.OwnerTypeRegion && len(scope.ZoneIDs) > 0 {
+		return "(" + typeColumn + " = ? AND " + idColumn + " = ?) OR (" + typeColumn + " = ? AND " + idColumn + " IN ?)",
+			[]interface{}{utils.OwnerTypeRegion, scope.OwnerID, utils.OwnerTypeZone, scope.ZoneIDs}
+	}
+	return typeColumn + " = ? AND " + idColumn + " = ?", []interface{}{scope.OwnerType, scope.OwnerID}
+}
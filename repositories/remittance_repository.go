@@ -0,0 +1,304 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+	"gnaps-api/utils"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RemittanceRepository struct {
+	db *gorm.DB
+}
+
+func NewRemittanceRepository(db *gorm.DB) *RemittanceRepository {
+	return &RemittanceRepository{db: db}
+}
+
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *RemittanceRepository) WithTx(tx *gorm.DB) *RemittanceRepository {
+	return &RemittanceRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *RemittanceRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// GenerateReferenceNo issues the next remittance reference number for today (RMT-YYYYMMDD-XXXXX).
+// Call it on a repository bound to the transaction that saves the remittance: the day's sequence
+// row stays locked until that transaction ends, so concurrent remittances queue up instead of
+// taking the same number. Numbers already in use are skipped.
+func (r *RemittanceRepository) GenerateReferenceNo() (string, error) {
+	key := "RMT-" + time.Now().Format("20060102")
+	var referenceNo string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&models.RemittanceReferenceSequence{SequenceKey: key}).Error; err != nil {
+			return err
+		}
+		var sequence models.RemittanceReferenceSequence
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("sequence_key = ?", key).First(&sequence).Error; err != nil {
+			return err
+		}
+
+		next := sequence.LastValue + 1
+		for {
+			var count int64
+			if err := tx.Model(&models.Remittance{}).Where("reference_no = ?", key+"-"+padLeft(next, 5)).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				break
+			}
+			next++
+		}
+
+		if err := tx.Model(&models.RemittanceReferenceSequence{}).Where("id = ?", sequence.ID).Update("last_value", next).Error; err != nil {
+			return err
+		}
+		referenceNo = key + "-" + padLeft(next, 5)
+		return nil
+	})
+	return referenceNo, err
+}
+
+// ============================================
+// Payment allocations
+// ============================================
+
+// HasAllocations checks whether a finance transaction has already been allocated
+func (r *RemittanceRepository) HasAllocations(financeTransactionId uint) bool {
+	var count int64
+	r.db.Model(&models.PaymentAllocation{}).
+		Where("finance_transaction_id = ?", financeTransactionId).
+		Count(&count)
+	return count > 0
+}
+
+// GetAllocatableParticulars retrieves the live particulars of a school bill in allocation order
+func (r *RemittanceRepository) GetAllocatableParticulars(schoolBillId uint) ([]models.SchoolBillingParticular, error) {
+	var particulars []models.SchoolBillingParticular
+	err := r.db.Where("school_billing_id = ? AND (is_deleted IS NULL OR is_deleted = ?)", schoolBillId, false).
+		Order("COALESCE(priority, 0) ASC, id ASC").
+		Find(&particulars).Error
+	return particulars, err
+}
+
+// SaveAllocations stores allocation rows and moves each particular's amount_paid by the allocated
+// amount in a single database transaction
+func (r *RemittanceRepository) SaveAllocations(allocations []models.PaymentAllocation) error {
+	if len(allocations) == 0 {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&allocations).Error; err != nil {
+			return err
+		}
+		for _, allocation := range allocations {
+			if allocation.SchoolBillingParticularId == nil {
+				continue
+			}
+			err := tx.Model(&models.SchoolBillingParticular{}).
+				Where("id = ?", *allocation.SchoolBillingParticularId).
+				Update("amount_paid", gorm.Expr("COALESCE(amount_paid, 0) + ?", allocation.Amount)).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindUnallocatedTransactions retrieves school bill payments and refunds that have no allocations,
+// oldest first, so earlier payments fill particulars before later ones
+func (r *RemittanceRepository) FindUnallocatedTransactions(limit int) ([]models.FinanceTransaction, error) {
+	var transactions []models.FinanceTransaction
+	err := r.db.Where("finance_type IN ? AND finance_id IS NOT NULL AND amount > 0", []string{"SchoolBill", "Refund"}).
+		Where("NOT EXISTS (SELECT 1 FROM payment_allocations WHERE payment_allocations.finance_transaction_id = finance_transactions.id)").
+		Order("transaction_date ASC, id ASC").
+		Limit(limit).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// GetSchoolZoneID returns the zone a school belongs to, or 0 when unknown
+func (r *RemittanceRepository) GetSchoolZoneID(schoolId int64) int64 {
+	var zoneID *int64
+	r.db.Model(&models.School{}).Where("id = ?", schoolId).Select("zone_id").Scan(&zoneID)
+	if zoneID == nil {
+		return 0
+	}
+	return *zoneID
+}
+
+// GetZoneRegionID returns the region a zone belongs to, or 0 when unknown
+func (r *RemittanceRepository) GetZoneRegionID(zoneId int64) int64 {
+	var regionID *int64
+	r.db.Model(&models.Zone{}).Where("id = ?", zoneId).Select("region_id").Scan(&regionID)
+	if regionID == nil {
+		return 0
+	}
+	return *regionID
+}
+
+// GetBillParticularOwnerType returns the owner type of the bill particular a school
+// billing particular was generated from
+func (r *RemittanceRepository) GetBillParticularOwnerType(billParticularId int64) string {
+	var ownerType *string
+	r.db.Model(&models.BillParticular{}).Where("id = ?", billParticularId).Select("owner_type").Scan(&ownerType)
+	if ownerType == nil {
+		return ""
+	}
+	return *ownerType
+}
+
+// ListAllocations retrieves allocations with filters and pagination, limited to rows collected
+// or receivable by owners in the scope
+func (r *RemittanceRepository) ListAllocations(filters map[string]interface{}, scope *OwnerScope, page, limit int) ([]models.PaymentAllocation, int64, error) {
+	var allocations []models.PaymentAllocation
+	var total int64
+
+	query := r.db.Model(&models.PaymentAllocation{})
+	query = applyPartyScope(query, "owner_type", "owner_id", "receiver_owner_type", "receiver_owner_id", scope)
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("allocation_date DESC, id DESC").Offset(offset).Limit(limit).Find(&allocations).Error
+	return allocations, total, err
+}
+
+// InterLevelTotal is an amount grouped by the owner that holds it and the owner it belongs to
+type InterLevelTotal struct {
+	FromOwnerType string
+	FromOwnerId   int64
+	ToOwnerType   string
+	ToOwnerId     int64
+	Status        string
+	Total         float64
+}
+
+// SumAmountsDue totals allocations collected by one owner on behalf of another
+func (r *RemittanceRepository) SumAmountsDue(from, to *time.Time) ([]InterLevelTotal, error) {
+	var rows []InterLevelTotal
+	query := r.db.Model(&models.PaymentAllocation{}).
+		Select("owner_type AS from_owner_type, owner_id AS from_owner_id, receiver_owner_type AS to_owner_type, receiver_owner_id AS to_owner_id, COALESCE(SUM(amount), 0) AS total").
+		Where("owner_type IS NOT NULL AND owner_id IS NOT NULL").
+		Where("NOT (owner_type = receiver_owner_type AND owner_id = receiver_owner_id)")
+	if from != nil {
+		query = query.Where("allocation_date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("allocation_date < ?", *to)
+	}
+	err := query.Group("owner_type, owner_id, receiver_owner_type, receiver_owner_id").Scan(&rows).Error
+	return rows, err
+}
+
+// SumRemittances totals remittances that have not been rejected, by payer, receiver and status
+func (r *RemittanceRepository) SumRemittances(from, to *time.Time) ([]InterLevelTotal, error) {
+	var rows []InterLevelTotal
+	query := r.db.Model(&models.Remittance{}).
+		Select("owner_type AS from_owner_type, owner_id AS from_owner_id, to_owner_type, to_owner_id, status, COALESCE(SUM(amount), 0) AS total").
+		Where("is_deleted = ? AND status != ?", false, "rejected")
+	if from != nil {
+		query = query.Where("remittance_date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("remittance_date < ?", *to)
+	}
+	err := query.Group("owner_type, owner_id, to_owner_type, to_owner_id, status").Scan(&rows).Error
+	return rows, err
+}
+
+// ============================================
+// Remittances
+// ============================================
+
+// CreateWithOwner creates a new remittance paid by the caller's owner
+func (r *RemittanceRepository) CreateWithOwner(remittance *models.Remittance, ownerCtx *utils.OwnerContext) error {
+	if err := CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		ownerType, ownerID := ownerCtx.GetOwnerValues()
+		remittance.SetOwner(ownerType, ownerID)
+	}
+	return r.db.Create(remittance).Error
+}
+
+// FindByIDForScope retrieves a remittance paid or received by an owner in the scope
+func (r *RemittanceRepository) FindByIDForScope(id uint, scope *OwnerScope) (*models.Remittance, error) {
+	var remittance models.Remittance
+	query := r.db.Where("id = ? AND is_deleted = ?", id, false)
+	query = applyPartyScope(query, "owner_type", "owner_id", "to_owner_type", "to_owner_id", scope)
+
+	if err := query.First(&remittance).Error; err != nil {
+		return nil, err
+	}
+	return &remittance, nil
+}
+
+// ListForScope retrieves remittances paid or received by owners in the scope
+func (r *RemittanceRepository) ListForScope(filters map[string]interface{}, scope *OwnerScope, page, limit int) ([]models.Remittance, int64, error) {
+	var remittances []models.Remittance
+	var total int64
+
+	query := r.db.Model(&models.Remittance{}).Where("is_deleted = ?", false)
+	query = applyPartyScope(query, "owner_type", "owner_id", "to_owner_type", "to_owner_id", scope)
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("remittance_date DESC, id DESC").Offset(offset).Limit(limit).Find(&remittances).Error
+	return remittances, total, err
+}
+
+// Update saves changes to a remittance
+func (r *RemittanceRepository) Update(id uint, updates map[string]interface{}) error {
+	result := r.db.Model(&models.Remittance{}).Where("id = ? AND is_deleted = ?", id, false).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// applyPartyScope restricts a query to rows where either party is an owner in the scope
+func applyPartyScope(query *gorm.DB, fromType, fromID, toType, toID string, scope *OwnerScope) *gorm.DB {
+	if scope.IsNational() {
+		return query
+	}
+
+	fromCondition, fromArgs := ownerColumnsCondition(fromType, fromID, scope)
+	toCondition, toArgs := ownerColumnsCondition(toType, toID, scope)
+	return query.Where("(("+fromCondition+") OR ("+toCondition+"))", append(fromArgs, toArgs...)...)
+}
+
+// OwnerExists checks whether a region or zone owner exists
+func (r *RemittanceRepository) OwnerExists(ownerType string, ownerID int64) bool {
+	var count int64
+	switch ownerType {
+	case utils.OwnerTypeRegion:
+		r.db.Model(&models.Region{}).Where("id = ?", ownerID).Count(&count)
+	case utils.OwnerTypeZone:
+		r.db.Model(&models.Zone{}).Where("id = ?", ownerID).Count(&count)
+	}
+	return count > 0
+}
@@ -5,8 +5,12 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// outstandingSchoolBill matches unpaid, undeleted school bills
+const outstandingSchoolBill = "deleted_at IS NULL AND (COALESCE(amount, 0) - COALESCE(discounts, 0) - COALESCE(amount_paid, 0)) > 0"
+
 type SchoolBillRepository struct {
 	db *gorm.DB
 }
@@ -15,6 +19,16 @@ func NewSchoolBillRepository(db *gorm.DB) *SchoolBillRepository {
 	return &SchoolBillRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *SchoolBillRepository) WithTx(tx *gorm.DB) *SchoolBillRepository {
+	return &SchoolBillRepository{db: tx}
+}
+
+// Transaction runs fn in a database transaction
+func (r *SchoolBillRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
 // SchoolBillWithName extends SchoolBill with bill name
 type SchoolBillWithName struct {
 	models.SchoolBill
@@ -30,6 +44,21 @@ func (r *SchoolBillRepository) FindByID(id uint) (*models.SchoolBill, error) {
 	return &schoolBill, nil
 }
 
+// FindByIDWithRoleFilter retrieves a school bill whose school is in the caller's region or zone
+func (r *SchoolBillRepository) FindByIDWithRoleFilter(id uint, regionID, zoneID *int64) (*models.SchoolBill, error) {
+	var schoolBill models.SchoolBill
+	query := r.db.Where("id = ?", id)
+	if zoneID != nil {
+		query = query.Where("school_id IN (SELECT id FROM schools WHERE zone_id = ?)", *zoneID)
+	} else if regionID != nil {
+		query = query.Where("school_id IN (SELECT schools.id FROM schools JOIN zones ON zones.id = schools.zone_id WHERE zones.region_id = ?)", *regionID)
+	}
+	if err := query.First(&schoolBill).Error; err != nil {
+		return nil, err
+	}
+	return &schoolBill, nil
+}
+
 func (r *SchoolBillRepository) FindBySchoolID(schoolId int64) ([]SchoolBillWithName, error) {
 	var schoolBills []SchoolBillWithName
 
@@ -106,6 +135,20 @@ func (r *SchoolBillRepository) UpdatePayment(schoolBillId uint, amountPaid float
 	}).Error
 }
 
+// OutstandingBills counts a school's unpaid bills and their total balance
+func (r *SchoolBillRepository) OutstandingBills(schoolID int64) (int64, float64, error) {
+	var result struct {
+		Count   int64
+		Balance float64
+	}
+	err := r.db.Table("school_bills").
+		Select("COUNT(*) AS count, COALESCE(SUM(COALESCE(amount, 0) - COALESCE(discounts, 0) - COALESCE(amount_paid, 0)), 0) AS balance").
+		Where("school_id = ?", schoolID).
+		Where(outstandingSchoolBill).
+		Scan(&result).Error
+	return result.Count, result.Balance, err
+}
+
 func (r *SchoolBillRepository) GetSchoolBillingParticulars(schoolBillId uint) ([]models.SchoolBillingParticular, error) {
 	var particulars []models.SchoolBillingParticular
 
@@ -116,6 +159,71 @@ func (r *SchoolBillRepository) GetSchoolBillingParticulars(schoolBillId uint) ([
 	return particulars, err
 }
 
+// UpdateDiscount adds a discount to a school bill and recalculates its balance
+func (r *SchoolBillRepository) UpdateDiscount(schoolBillId uint, discount float64) error {
+	var schoolBill models.SchoolBill
+	if err := r.db.First(&schoolBill, schoolBillId).Error; err != nil {
+		return err
+	}
+
+	newDiscounts := discount
+	if schoolBill.Discounts != nil {
+		newDiscounts += *schoolBill.Discounts
+	}
+
+	totalAmount := float64(0)
+	if schoolBill.Amount != nil {
+		totalAmount = *schoolBill.Amount
+	}
+
+	amountPaid := float64(0)
+	if schoolBill.AmountPaid != nil {
+		amountPaid = *schoolBill.AmountPaid
+	}
+
+	newBalance := totalAmount - newDiscounts - amountPaid
+	isPaid := newBalance <= 0
+
+	return r.db.Model(&schoolBill).Updates(map[string]interface{}{
+		"discounts": newDiscounts,
+		"balance":   newBalance,
+		"is_paid":   isPaid,
+	}).Error
+}
+
+// GetOwnerForSchoolBill returns the owner of a school bill, falling back to its bill's owner
+func (r *SchoolBillRepository) GetOwnerForSchoolBill(schoolBillId uint) *OwnerInfo {
+	return GetOwnerFromFinance(r.db, "SchoolBill", int64(schoolBillId))
+}
+
+// FindFinanceTransactionByID retrieves a finance transaction
+func (r *SchoolBillRepository) FindFinanceTransactionByID(id uint) (*models.FinanceTransaction, error) {
+	var transaction models.FinanceTransaction
+	if err := r.db.First(&transaction, id).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// LockFinanceTransaction retrieves a finance transaction and locks it until the transaction ends
+func (r *SchoolBillRepository) LockFinanceTransaction(id uint) (*models.FinanceTransaction, error) {
+	var transaction models.FinanceTransaction
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&transaction).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+// SumRefundsOf returns the total already refunded against a payment
+func (r *SchoolBillRepository) SumRefundsOf(financeTransactionId uint) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.FinanceTransaction{}).
+		Where("refunded_transaction_id = ?", financeTransactionId).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
 // CreateFinanceTransaction creates a finance transaction record
 func (r *SchoolBillRepository) CreateFinanceTransaction(transaction *models.FinanceTransaction) error {
 	return r.db.Create(transaction).Error
@@ -192,7 +300,7 @@ func padLeft(n int64, width int) string {
 	}
 	numStr := ""
 	for n > 0 {
-		numStr = string('0'+n%10) + numStr
+		numStr = string(rune('0'+n%10)) + numStr
 		n /= 10
 	}
 	if numStr == "" {
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SchoolBillRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewSchoolBillRevisionRepository(db *gorm.DB) *SchoolBillRevisionRepository {
+	return &SchoolBillRevisionRepository{db: db}
+}
+
+// SchoolBillTarget holds the school details used to decide whether a bill item applies to a school bill
+type SchoolBillTarget struct {
+	SchoolBill models.SchoolBill
+	RegionId   *int64
+	// InTargetedGroup is true when the school belongs to a school group the bill item targets
+	InTargetedGroup bool
+}
+
+// FindSchoolBillsByBillID retrieves every issued school bill for a bill along with the school's region
+// and whether the school is in one of the groups a bill item targets
+func (r *SchoolBillRevisionRepository) FindSchoolBillsByBillID(billId int64, billItemId uint) ([]SchoolBillTarget, error) {
+	var rows []struct {
+		models.SchoolBill
+		SchoolZoneId *int64 `gorm:"column:school_zone_id"`
+		RegionId     *int64 `gorm:"column:region_id"`
+	}
+	if err := r.db.Table("school_bills").
+		Select("school_bills.*, schools.zone_id AS school_zone_id, zones.region_id").
+		Joins("LEFT JOIN schools ON schools.id = school_bills.school_id").
+		Joins("LEFT JOIN zones ON zones.id = schools.zone_id").
+		Where("school_bills.bill_id = ?", billId).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var groupSchoolIds []int64
+	if err := r.db.Table("school_group_members").
+		Joins("JOIN school_group_targets ON school_group_targets.school_group_id = school_group_members.school_group_id").
+		Where("school_group_targets.target_type = ? AND school_group_targets.target_id = ?", GroupTargetBillItem, billItemId).
+		Distinct().Pluck("school_group_members.school_id", &groupSchoolIds).Error; err != nil {
+		return nil, err
+	}
+	inTargetedGroup := make(map[int64]bool, len(groupSchoolIds))
+	for _, id := range groupSchoolIds {
+		inTargetedGroup[id] = true
+	}
+
+	targets := make([]SchoolBillTarget, 0, len(rows))
+	for _, row := range rows {
+		target := SchoolBillTarget{SchoolBill: row.SchoolBill, RegionId: row.RegionId}
+		if row.SchoolBill.SchoolId != nil {
+			target.InTargetedGroup = inTargetedGroup[*row.SchoolBill.SchoolId]
+		}
+		if target.SchoolBill.ZoneId == nil {
+			target.SchoolBill.ZoneId = row.SchoolZoneId
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// FindParticularForItem retrieves the billing particular created on a school bill for a bill item
+func (r *SchoolBillRevisionRepository) FindParticularForItem(schoolBillId uint, billItemId uint) (*models.SchoolBillingParticular, error) {
+	var particular models.SchoolBillingParticular
+	err := r.db.Where("school_billing_id = ? AND billing_item_id = ? AND (is_deleted = ? OR is_deleted IS NULL)", schoolBillId, billItemId, false).
+		First(&particular).Error
+	if err != nil {
+		return nil, err
+	}
+	return &particular, nil
+}
+
+// ApplyRevision saves a revised particular, adjusts its school bill by the revision's delta and
+// records the revision in a single transaction. The school bill and particular are re-read under a
+// row lock and their balance, credit amounts and paid flag recomputed from the locked rows, so a
+// payment recorded since they were first read is kept. A particular marked deleted stays if
+// anything was paid against it.
+func (r *SchoolBillRevisionRepository) ApplyRevision(particular *models.SchoolBillingParticular, schoolBillId uint, revision *models.SchoolBillRevision) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var schoolBill models.SchoolBill
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", schoolBillId).First(&schoolBill).Error; err != nil {
+			return err
+		}
+
+		if particular.ID == 0 {
+			if err := tx.Create(particular).Error; err != nil {
+				return err
+			}
+		} else {
+			var locked models.SchoolBillingParticular
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", particular.ID).First(&locked).Error; err != nil {
+				return err
+			}
+			amountPaid := floatOrZero(locked.AmountPaid)
+			particularCredit := amountPaid - (floatOrZero(particular.Amount) - floatOrZero(locked.DiscountAmount))
+			if particularCredit < 0 {
+				particularCredit = 0
+			}
+			particular.CreditAmount = &particularCredit
+			if particular.IsDeleted != nil && *particular.IsDeleted && amountPaid > 0 {
+				notDeleted := false
+				particular.IsDeleted = &notDeleted
+			}
+			if err := tx.Model(&models.SchoolBillingParticular{}).Where("id = ?", particular.ID).Updates(map[string]interface{}{
+				"amount":        particular.Amount,
+				"credit_amount": particular.CreditAmount,
+				"is_deleted":    particular.IsDeleted,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Recompute the bill from the locked row; an overpayment moves to the credit amount
+		previousBillAmount := floatOrZero(schoolBill.Amount)
+		previousBalance := floatOrZero(schoolBill.Balance)
+		amountPaid := floatOrZero(schoolBill.AmountPaid)
+		newBillAmount := previousBillAmount + floatOrZero(revision.DeltaAmount)
+		newBalance := newBillAmount - floatOrZero(schoolBill.Discounts) - amountPaid
+		creditAmount := float64(0)
+		if newBalance < 0 {
+			creditAmount = -newBalance
+			newBalance = 0
+		}
+		if err := tx.Model(&models.SchoolBill{}).Where("id = ?", schoolBill.ID).Updates(map[string]interface{}{
+			"amount":        newBillAmount,
+			"balance":       newBalance,
+			"credit_amount": creditAmount,
+			"is_paid":       newBalance <= 0,
+		}).Error; err != nil {
+			return err
+		}
+
+		particularId := int64(particular.ID)
+		revision.SchoolBillingParticularId = &particularId
+		revision.PreviousBillAmount = &previousBillAmount
+		revision.NewBillAmount = &newBillAmount
+		revision.PreviousBalance = &previousBalance
+		revision.NewBalance = &newBalance
+		revision.AmountPaid = &amountPaid
+		return tx.Create(revision).Error
+	})
+}
+
+// FindBySchoolBillID retrieves all revisions for a school bill
+func (r *SchoolBillRevisionRepository) FindBySchoolBillID(schoolBillId int64) ([]models.SchoolBillRevision, error) {
+	var revisions []models.SchoolBillRevision
+	err := r.db.Where("school_bill_id = ?", schoolBillId).
+		Order("created_at DESC").
+		Find(&revisions).Error
+	return revisions, err
+}
+
+// FindBySchoolID retrieves all revisions across a school's bills
+func (r *SchoolBillRevisionRepository) FindBySchoolID(schoolId int64, page, limit int) ([]models.SchoolBillRevision, int64, error) {
+	var revisions []models.SchoolBillRevision
+	var total int64
+
+	query := r.db.Model(&models.SchoolBillRevision{}).Where("school_id = ?", schoolId)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&revisions).Error
+
+	return revisions, total, err
+}
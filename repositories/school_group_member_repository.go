@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"encoding/json"
+	"gnaps-api/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kinds of records that can target school groups (school_group_targets.target_type)
+const (
+	GroupTargetNews     = "news"
+	GroupTargetBillItem = "bill_item"
+)
+
+type SchoolGroupMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewSchoolGroupMemberRepository(db *gorm.DB) *SchoolGroupMemberRepository {
+	return &SchoolGroupMemberRepository{db: db}
+}
+
+// ListMembers retrieves the schools in a group that are accessible by the user's role
+func (r *SchoolGroupMemberRepository) ListMembers(groupID uint, name string, page, limit int, regionID, zoneID *int64) ([]models.School, int64, error) {
+	var schools []models.School
+	var total int64
+
+	query := r.db.Model(&models.School{}).
+		Joins("JOIN school_group_members ON school_group_members.school_id = schools.id").
+		Where("school_group_members.school_group_id = ? AND schools.is_deleted = ?", groupID, false)
+	query = applySchoolsTableRoleFilter(query, regionID, zoneID)
+	if name != "" {
+		query = query.Where("schools.name LIKE ?", "%"+name+"%")
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Zone").Order("schools.name ASC").Offset(offset).Limit(limit).Find(&schools).Error
+	return schools, total, err
+}
+
+// AddMembers adds the given schools that are accessible by the user's role to a group, skipping
+// schools already in it, and returns how many were added
+func (r *SchoolGroupMemberRepository) AddMembers(groupID uint, schoolIDs []int64, addedBy *int64, regionID, zoneID *int64) (int64, error) {
+	var added int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var accessible []int64
+		query := tx.Model(&models.School{}).Where("schools.id IN ? AND schools.is_deleted = ?", schoolIDs, false)
+		query = applySchoolsTableRoleFilter(query, regionID, zoneID)
+		if err := query.Pluck("schools.id", &accessible).Error; err != nil {
+			return err
+		}
+		if len(accessible) == 0 {
+			return nil
+		}
+
+		members := make([]models.SchoolGroupMember, 0, len(accessible))
+		for _, schoolID := range accessible {
+			members = append(members, models.SchoolGroupMember{SchoolGroupId: int64(groupID), SchoolId: schoolID, AddedBy: addedBy})
+		}
+		result := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&members)
+		if result.Error != nil {
+			return result.Error
+		}
+		added = result.RowsAffected
+
+		return syncSchoolGroupIDs(tx, accessible)
+	})
+	return added, err
+}
+
+// RemoveMember takes a school out of a group; it fails if the school is not a member
+func (r *SchoolGroupMemberRepository) RemoveMember(groupID uint, schoolID int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("school_group_id = ? AND school_id = ?", groupID, schoolID).Delete(&models.SchoolGroupMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return syncSchoolGroupIDs(tx, []int64{schoolID})
+	})
+}
+
+// RemoveGroup drops the memberships and targeting of a deleted group
+func (r *SchoolGroupMemberRepository) RemoveGroup(groupID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var schoolIDs []int64
+		if err := tx.Model(&models.SchoolGroupMember{}).Where("school_group_id = ?", groupID).Pluck("school_id", &schoolIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("school_group_id = ?", groupID).Delete(&models.SchoolGroupMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("school_group_id = ?", groupID).Delete(&models.SchoolGroupTarget{}).Error; err != nil {
+			return err
+		}
+		return syncSchoolGroupIDs(tx, schoolIDs)
+	})
+}
+
+// GroupIDsForSchools retrieves the groups any of the given schools belong to
+func (r *SchoolGroupMemberRepository) GroupIDsForSchools(schoolIDs []int64) ([]int64, error) {
+	groupIDs := []int64{}
+	if len(schoolIDs) == 0 {
+		return groupIDs, nil
+	}
+	err := r.db.Model(&models.SchoolGroupMember{}).
+		Where("school_id IN ?", schoolIDs).
+		Distinct().Pluck("school_group_id", &groupIDs).Error
+	return groupIDs, err
+}
+
+// TargetIDsForGroups retrieves the records of a kind that target any of the given groups
+func (r *SchoolGroupMemberRepository) TargetIDsForGroups(targetType string, groupIDs []int64) ([]int64, error) {
+	targetIDs := []int64{}
+	if len(groupIDs) == 0 {
+		return targetIDs, nil
+	}
+	err := r.db.Model(&models.SchoolGroupTarget{}).
+		Where("target_type = ? AND school_group_id IN ?", targetType, groupIDs).
+		Distinct().Pluck("target_id", &targetIDs).Error
+	return targetIDs, err
+}
+
+// replaceSchoolGroups makes a school a member of exactly the given groups
+func replaceSchoolGroups(tx *gorm.DB, schoolID int64, groupIDs []int64) error {
+	remove := tx.Where("school_id = ?", schoolID)
+	if len(groupIDs) > 0 {
+		remove = remove.Where("school_group_id NOT IN ?", groupIDs)
+	}
+	if err := remove.Delete(&models.SchoolGroupMember{}).Error; err != nil {
+		return err
+	}
+
+	if len(groupIDs) > 0 {
+		members := make([]models.SchoolGroupMember, 0, len(groupIDs))
+		for _, groupID := range groupIDs {
+			members = append(members, models.SchoolGroupMember{SchoolGroupId: groupID, SchoolId: schoolID})
+		}
+		if err := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&members).Error; err != nil {
+			return err
+		}
+	}
+
+	return syncSchoolGroupIDs(tx, []int64{schoolID})
+}
+
+// moveSchoolGroupMemberships moves a school's group memberships to another school
+func moveSchoolGroupMemberships(tx *gorm.DB, fromSchoolID, toSchoolID int64) error {
+	if err := tx.Exec(
+		"INSERT IGNORE INTO school_group_members (created_at, updated_at, school_group_id, school_id, added_by) "+
+			"SELECT created_at, NOW(3), school_group_id, ?, added_by FROM school_group_members WHERE school_id = ?",
+		toSchoolID, fromSchoolID).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("school_id = ?", fromSchoolID).Delete(&models.SchoolGroupMember{}).Error; err != nil {
+		return err
+	}
+	return syncSchoolGroupIDs(tx, []int64{fromSchoolID, toSchoolID})
+}
+
+// syncSchoolGroupIDs rewrites the schools.school_group_ids copy of the schools' memberships
+func syncSchoolGroupIDs(tx *gorm.DB, schoolIDs []int64) error {
+	if len(schoolIDs) == 0 {
+		return nil
+	}
+	return tx.Exec(
+		"UPDATE schools SET school_group_ids = (SELECT COALESCE(JSON_ARRAYAGG(m.school_group_id), JSON_ARRAY()) "+
+			"FROM school_group_members m WHERE m.school_id = schools.id) WHERE id IN ?",
+		schoolIDs).Error
+}
+
+// replaceGroupTargets makes a news item or bill item target exactly the given groups
+func replaceGroupTargets(tx *gorm.DB, targetType string, targetID int64, groupIDs []int64) error {
+	if err := tx.Where("target_type = ? AND target_id = ?", targetType, targetID).Delete(&models.SchoolGroupTarget{}).Error; err != nil {
+		return err
+	}
+	if len(groupIDs) == 0 {
+		return nil
+	}
+
+	targets := make([]models.SchoolGroupTarget, 0, len(groupIDs))
+	for _, groupID := range groupIDs {
+		targets = append(targets, models.SchoolGroupTarget{SchoolGroupId: groupID, TargetType: targetType, TargetId: targetID})
+	}
+	return tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&targets).Error
+}
+
+// groupIDsUpdate reads the school_group_ids of an updates map; ok is false when it is not being updated
+func groupIDsUpdate(updates map[string]interface{}) (groupIDs []int64, ok bool) {
+	value, ok := updates["school_group_ids"]
+	if !ok {
+		return nil, false
+	}
+	switch v := value.(type) {
+	case *datatypes.JSON:
+		return parseJSONIDs(v), true
+	case datatypes.JSON:
+		return parseJSONIDs(&v), true
+	}
+	return nil, true
+}
+
+// parseJSONIDs reads a JSON id array, skipping anything that is not one
+func parseJSONIDs(data *datatypes.JSON) []int64 {
+	if data == nil {
+		return nil
+	}
+	var ids []int64
+	if err := json.Unmarshal(*data, &ids); err != nil {
+		return nil
+	}
+	return ids
+}
+
+// applySchoolsTableRoleFilter restricts a query on the schools table to the zones a region or
+// zone admin covers
+func applySchoolsTableRoleFilter(query *gorm.DB, regionID, zoneID *int64) *gorm.DB {
+	if zoneID != nil {
+		return query.Where("schools.zone_id = ?", *zoneID)
+	}
+	if regionID != nil {
+		return query.Where("schools.zone_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?)", *regionID, false)
+	}
+	return query
+}
@@ -0,0 +1,422 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"gnaps-api/models"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// schoolMergeTables are the tables whose school_id rows move to the surviving school
+var schoolMergeTables = []string{
+	"school_bills",
+	"school_billing_particulars",
+	"school_bill_revisions",
+	"payment_allocations",
+	"finance_transactions",
+	"journal_entries",
+	"particular_payments",
+	"momo_payments",
+	"event_registrations",
+	"document_submissions",
+	"membership_applications",
+	"school_transfers",
+	"school_status_changes",
+	"member_no_changes",
+}
+
+// school_zone_histories stays with the merged school: its periods describe where that school was,
+// and on the survivor they would move the survivor's own past payments to another zone
+
+// schoolMergeJSONTables are the tables targeting schools through a school_ids JSON array
+var schoolMergeJSONTables = []string{"news", "documents", "bill_items"}
+
+// FoldedSchoolBill records a merged school's bill folded into the survivor's bill for the same bill
+type FoldedSchoolBill struct {
+	BillId                int64   `json:"bill_id"`
+	MergedSchoolBillId    uint    `json:"merged_school_bill_id"`
+	SurvivingSchoolBillId uint    `json:"surviving_school_bill_id"`
+	AmountPaid            float64 `json:"amount_paid"`
+	CreditAmount          float64 `json:"credit_amount"`
+	DroppedAmount         float64 `json:"dropped_amount"`   // the duplicate charge that no longer applies, net of its discounts
+	DroppedDiscount       float64 `json:"dropped_discount"` // discounts granted on the duplicate charge, dropped with it
+}
+
+type SchoolMergeRepository struct {
+	db *gorm.DB
+}
+
+func NewSchoolMergeRepository(db *gorm.DB) *SchoolMergeRepository {
+	return &SchoolMergeRepository{db: db}
+}
+
+// ListByZone retrieves the active schools of a zone with their contact persons
+func (r *SchoolMergeRepository) ListByZone(zoneID int64) ([]models.School, error) {
+	var schools []models.School
+	err := r.db.Where("zone_id = ? AND is_deleted = ?", zoneID, false).
+		Preload("ContactPersons").
+		Order("id ASC").
+		Find(&schools).Error
+	return schools, err
+}
+
+// List retrieves merge records involving a school, or all merges in the given zones, newest first
+func (r *SchoolMergeRepository) List(schoolID int64, zoneIDs []int64, page, limit int) ([]models.SchoolMerge, int64, error) {
+	var merges []models.SchoolMerge
+	var total int64
+
+	query := r.db.Model(&models.SchoolMerge{})
+	if schoolID > 0 {
+		query = query.Where("surviving_school_id = ? OR merged_school_id = ?", schoolID, schoolID)
+	}
+	if zoneIDs != nil {
+		query = query.Where("surviving_school_id IN (SELECT id FROM schools WHERE zone_id IN ?)", zoneIDs)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&merges).Error
+	return merges, total, err
+}
+
+// Merge moves everything belonging to the merged school onto the surviving school, retires the
+// merged school and its user account, and stores the audit record, all in one database transaction
+func (r *SchoolMergeRepository) Merge(surviving, merged *models.School, audit *models.SchoolMerge) error {
+	survivingID, mergedID := int64(surviving.ID), int64(merged.ID)
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		moved := map[string]int64{}
+
+		folded, err := foldSchoolBills(tx, survivingID, mergedID)
+		if err != nil {
+			return err
+		}
+
+		count, err := mergeContactPersons(tx, survivingID, mergedID)
+		if err != nil {
+			return err
+		}
+		moved["contact_persons"] = count
+
+		count, err = mergeSchoolCensuses(tx, survivingID, mergedID)
+		if err != nil {
+			return err
+		}
+		moved["school_censuses"] = count
+
+		for _, table := range schoolMergeTables {
+			result := tx.Table(table).Where("school_id = ?", mergedID).Update("school_id", survivingID)
+			if result.Error != nil {
+				return fmt.Errorf("failed to move %s: %v", table, result.Error)
+			}
+			moved[table] = result.RowsAffected
+		}
+
+		// The merged school's certificates stay with it for the record but no longer verify as valid
+		result := tx.Model(&models.MembershipCertificate{}).
+			Where("school_id = ? AND status = ?", mergedID, "active").
+			Update("status", "superseded")
+		if result.Error != nil {
+			return fmt.Errorf("failed to supersede membership certificates: %v", result.Error)
+		}
+		moved["membership_certificates.superseded"] = result.RowsAffected
+
+		for _, table := range schoolMergeJSONTables {
+			count, err := replaceSchoolInJSON(tx, table, mergedID, survivingID)
+			if err != nil {
+				return fmt.Errorf("failed to retarget %s: %v", table, err)
+			}
+			moved[table+".school_ids"] = count
+		}
+
+		// The survivor keeps its own details and takes the merged school's where it has none
+		updates := map[string]interface{}{}
+		fillBlank := func(column string, survivor, other *string) {
+			if (survivor == nil || strings.TrimSpace(*survivor) == "") && other != nil && strings.TrimSpace(*other) != "" {
+				updates[column] = *other
+			}
+		}
+		fillBlank("address", surviving.Address, merged.Address)
+		fillBlank("location", surviving.Location, merged.Location)
+		fillBlank("mobile_no", surviving.MobileNo, merged.MobileNo)
+		fillBlank("gps_address", surviving.GpsAddress, merged.GpsAddress)
+		fillBlank("email", surviving.Email, merged.Email)
+
+		// Retire the merged school first so its email is free for the survivor
+		if err := tx.Model(&models.School{}).Where("id = ?", mergedID).Updates(map[string]interface{}{
+			"is_deleted": true,
+			"email":      nil,
+		}).Error; err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&models.School{}).Where("id = ?", survivingID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if err := moveSchoolGroupMemberships(tx, mergedID, survivingID); err != nil {
+			return fmt.Errorf("failed to move group memberships: %v", err)
+		}
+		if merged.UserId != nil && *merged.UserId > 0 && (surviving.UserId == nil || *surviving.UserId != *merged.UserId) {
+			if err := tx.Model(&models.User{}).Where("id = ?", *merged.UserId).Update("is_deleted", true).Error; err != nil {
+				return err
+			}
+		}
+
+		movedJSON, _ := json.Marshal(moved)
+		foldedJSON, _ := json.Marshal(folded)
+		movedRows, foldedBills := datatypes.JSON(movedJSON), datatypes.JSON(foldedJSON)
+		audit.MovedRows = &movedRows
+		audit.FoldedBills = &foldedBills
+		return tx.Create(audit).Error
+	})
+}
+
+// foldSchoolBills folds each of the merged school's bills that the survivor was also billed for
+// into the survivor's bill: payments, refunds, allocations, revisions and MoMo payments are
+// repointed, amounts paid are added to the survivor's bill and particulars, and the duplicate
+// bill is deleted. The duplicate's discounts go with its charge: the survivor keeps its own
+// discounts and the dropped ones are recorded on the merge. As on a bill revision, the balance
+// never goes below zero and any overpayment becomes the bill's credit amount. Bills only the
+// merged school had are moved with the other school_id rows.
+func foldSchoolBills(tx *gorm.DB, survivingID, mergedID int64) ([]FoldedSchoolBill, error) {
+	var survivorBills, mergedBills []models.SchoolBill
+	if err := tx.Where("school_id = ? AND bill_id IS NOT NULL", survivingID).Find(&survivorBills).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Where("school_id = ? AND bill_id IS NOT NULL", mergedID).Find(&mergedBills).Error; err != nil {
+		return nil, err
+	}
+
+	byBill := make(map[int64]models.SchoolBill, len(survivorBills))
+	for _, bill := range survivorBills {
+		byBill[*bill.BillId] = bill
+	}
+
+	var folded []FoldedSchoolBill
+	for _, duplicate := range mergedBills {
+		target, ok := byBill[*duplicate.BillId]
+		if !ok {
+			continue
+		}
+
+		if err := foldBillingParticulars(tx, target.ID, duplicate.ID); err != nil {
+			return nil, err
+		}
+
+		repoint := []struct {
+			table, column, condition string
+		}{
+			{"finance_transactions", "finance_id", "finance_type IN ('SchoolBill', 'Refund')"},
+			{"momo_payments", "payee_id", "payee_type = 'SchoolBillPayment'"},
+			{"payment_allocations", "school_bill_id", ""},
+			{"school_bill_revisions", "school_bill_id", ""},
+		}
+		for _, item := range repoint {
+			query := tx.Table(item.table).Where(item.column+" = ?", duplicate.ID)
+			if item.condition != "" {
+				query = query.Where(item.condition)
+			}
+			if err := query.Update(item.column, target.ID).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		amountPaid := floatOrZero(target.AmountPaid) + floatOrZero(duplicate.AmountPaid)
+		balance := floatOrZero(target.Amount) - floatOrZero(target.Discounts) - amountPaid
+		creditAmount := float64(0)
+		if balance < 0 {
+			creditAmount = -balance
+			balance = 0
+		}
+		if err := tx.Model(&models.SchoolBill{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+			"amount_paid":   amountPaid,
+			"credit_amount": creditAmount,
+			"balance":       balance,
+			"is_paid":       balance <= 0,
+		}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(&models.SchoolBill{}, duplicate.ID).Error; err != nil {
+			return nil, err
+		}
+
+		folded = append(folded, FoldedSchoolBill{
+			BillId:                *duplicate.BillId,
+			MergedSchoolBillId:    duplicate.ID,
+			SurvivingSchoolBillId: target.ID,
+			AmountPaid:            floatOrZero(duplicate.AmountPaid),
+			CreditAmount:          floatOrZero(duplicate.CreditAmount),
+			DroppedAmount:         floatOrZero(duplicate.Amount) - floatOrZero(duplicate.Discounts),
+			DroppedDiscount:       floatOrZero(duplicate.Discounts),
+		})
+	}
+	return folded, nil
+}
+
+// foldBillingParticulars adds what was paid on a duplicate bill's particulars to the matching
+// particulars of the surviving bill; particulars without a match move to the surviving bill
+func foldBillingParticulars(tx *gorm.DB, targetBillID, duplicateBillID uint) error {
+	var targets, duplicates []models.SchoolBillingParticular
+	if err := tx.Where("school_billing_id = ? AND (is_deleted IS NULL OR is_deleted = ?)", targetBillID, false).Find(&targets).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("school_billing_id = ? AND (is_deleted IS NULL OR is_deleted = ?)", duplicateBillID, false).Find(&duplicates).Error; err != nil {
+		return err
+	}
+
+	for _, duplicate := range duplicates {
+		var match *models.SchoolBillingParticular
+		for i := range targets {
+			sameParticular := duplicate.BillParticularId != nil && targets[i].BillParticularId != nil && *duplicate.BillParticularId == *targets[i].BillParticularId
+			sameName := duplicate.BillParticularId == nil && duplicate.ParticularName != nil && targets[i].ParticularName != nil &&
+				strings.EqualFold(*duplicate.ParticularName, *targets[i].ParticularName)
+			if sameParticular || sameName {
+				match = &targets[i]
+				break
+			}
+		}
+
+		if match == nil {
+			if err := tx.Model(&models.SchoolBillingParticular{}).Where("id = ?", duplicate.ID).
+				Update("school_billing_id", targetBillID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := tx.Model(&models.SchoolBillingParticular{}).Where("id = ?", match.ID).Updates(map[string]interface{}{
+			"amount_paid":   floatOrZero(match.AmountPaid) + floatOrZero(duplicate.AmountPaid),
+			"credit_amount": floatOrZero(match.CreditAmount) + floatOrZero(duplicate.CreditAmount),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table("payment_allocations").Where("school_billing_particular_id = ?", duplicate.ID).
+			Update("school_billing_particular_id", match.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SchoolBillingParticular{}).Where("id = ?", duplicate.ID).
+			Update("is_deleted", true).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeContactPersons moves the merged school's contact persons, dropping those the survivor already has
+func mergeContactPersons(tx *gorm.DB, survivingID, mergedID int64) (int64, error) {
+	var existing, incoming []models.ContactPerson
+	if err := tx.Where("school_id = ?", survivingID).Find(&existing).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Where("school_id = ?", mergedID).Find(&incoming).Error; err != nil {
+		return 0, err
+	}
+
+	key := func(contact models.ContactPerson) string {
+		return strings.ToLower(strings.TrimSpace(stringOrEmpty(contact.FirstName)+" "+stringOrEmpty(contact.LastName))) +
+			"|" + strings.TrimSpace(stringOrEmpty(contact.MobileNo))
+	}
+	known := map[string]bool{}
+	for _, contact := range existing {
+		known[key(contact)] = true
+	}
+
+	var moved int64
+	for _, contact := range incoming {
+		if known[key(contact)] {
+			if err := tx.Delete(&models.ContactPerson{}, contact.ID).Error; err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err := tx.Model(&models.ContactPerson{}).Where("id = ?", contact.ID).Update("school_id", survivingID).Error; err != nil {
+			return 0, err
+		}
+		known[key(contact)] = true
+		moved++
+	}
+	return moved, nil
+}
+
+// mergeSchoolCensuses moves the merged school's census returns for the academic years the survivor
+// has none of. Where both schools made a return for the same year the survivor's is kept and the
+// merged school's stays with it, so reports for that year still count both schools.
+func mergeSchoolCensuses(tx *gorm.DB, survivingID, mergedID int64) (int64, error) {
+	var survivorYears []string
+	if err := tx.Model(&models.SchoolCensus{}).Where("school_id = ?", survivingID).Pluck("academic_year", &survivorYears).Error; err != nil {
+		return 0, err
+	}
+
+	query := tx.Model(&models.SchoolCensus{}).Where("school_id = ?", mergedID)
+	if len(survivorYears) > 0 {
+		query = query.Where("academic_year NOT IN ?", survivorYears)
+	}
+	result := query.Update("school_id", survivingID)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to move school_censuses: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// replaceSchoolInJSON swaps one school for another in a table's school_ids JSON arrays
+func replaceSchoolInJSON(tx *gorm.DB, table string, fromID, toID int64) (int64, error) {
+	var rows []struct {
+		ID        uint
+		SchoolIds *datatypes.JSON
+	}
+	if err := tx.Table(table).Select("id, school_ids").
+		Where("JSON_CONTAINS(school_ids, ?)", fmt.Sprintf("%d", fromID)).
+		Scan(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, row := range rows {
+		var ids []int64
+		if row.SchoolIds == nil || json.Unmarshal(*row.SchoolIds, &ids) != nil {
+			continue
+		}
+		replaced := make([]int64, 0, len(ids))
+		seen := map[int64]bool{}
+		for _, id := range ids {
+			if id == fromID {
+				id = toID
+			}
+			if !seen[id] {
+				seen[id] = true
+				replaced = append(replaced, id)
+			}
+		}
+		encoded, _ := json.Marshal(replaced)
+		if err := tx.Table(table).Where("id = ?", row.ID).Updates(map[string]interface{}{
+			"school_ids": datatypes.JSON(encoded),
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func floatOrZero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func stringOrEmpty(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
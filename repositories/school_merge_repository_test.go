@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"gnaps-api/internal/testdb"
+	"gnaps-api/models"
+	"testing"
+)
+
+var schoolBillFoldTestTables = []interface{}{
+	&models.SchoolBill{}, &models.SchoolBillingParticular{}, &models.FinanceTransaction{},
+	&models.MomoPayment{}, &models.PaymentAllocation{}, &models.SchoolBillRevision{},
+}
+
+func TestFoldSchoolBills(t *testing.T) {
+	type bill struct {
+		amount, discounts, amountPaid, creditAmount float64
+	}
+	tests := []struct {
+		name                string
+		survivor, duplicate bill
+		wantPaid            float64
+		wantBalance         float64
+		wantCredit          float64
+		wantIsPaid          bool
+		wantDroppedAmount   float64
+		wantDroppedDiscount float64
+	}{
+		{
+			name:              "payments on both bills still leave a balance",
+			survivor:          bill{amount: 500, amountPaid: 100},
+			duplicate:         bill{amount: 500, amountPaid: 150},
+			wantPaid:          250,
+			wantBalance:       250,
+			wantDroppedAmount: 500,
+		},
+		{
+			name:              "payments on both bills settle the bill exactly",
+			survivor:          bill{amount: 500, amountPaid: 300},
+			duplicate:         bill{amount: 500, amountPaid: 200},
+			wantPaid:          500,
+			wantIsPaid:        true,
+			wantDroppedAmount: 500,
+		},
+		{
+			name:              "overpayment becomes credit instead of a negative balance",
+			survivor:          bill{amount: 500, amountPaid: 400},
+			duplicate:         bill{amount: 500, amountPaid: 500},
+			wantPaid:          900,
+			wantCredit:        400,
+			wantIsPaid:        true,
+			wantDroppedAmount: 500,
+		},
+		{
+			name:              "credit on the duplicate is recomputed, not added twice",
+			survivor:          bill{amount: 500, amountPaid: 200},
+			duplicate:         bill{amount: 400, amountPaid: 450, creditAmount: 50},
+			wantPaid:          650,
+			wantCredit:        150,
+			wantIsPaid:        true,
+			wantDroppedAmount: 400,
+		},
+		{
+			name:                "survivor keeps its discounts and the duplicate's are dropped",
+			survivor:            bill{amount: 500, discounts: 50, amountPaid: 100},
+			duplicate:           bill{amount: 500, discounts: 80, amountPaid: 100},
+			wantPaid:            200,
+			wantBalance:         250,
+			wantDroppedAmount:   420,
+			wantDroppedDiscount: 80,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schoolBill := func(id uint, schoolID int64, b bill) *models.SchoolBill {
+				return &models.SchoolBill{
+					ID: id, SchoolId: testdb.Ptr(schoolID), BillId: testdb.Ptr(int64(9)),
+					Amount: testdb.Ptr(b.amount), Discounts: testdb.Ptr(b.discounts),
+					AmountPaid: testdb.Ptr(b.amountPaid), CreditAmount: testdb.Ptr(b.creditAmount),
+				}
+			}
+			db := testdb.Open(t, schoolBillFoldTestTables...)
+			testdb.Seed(t, db, schoolBill(1, 10, tt.survivor), schoolBill(2, 20, tt.duplicate))
+
+			folded, err := foldSchoolBills(db, 10, 20)
+			if err != nil {
+				t.Fatalf("foldSchoolBills() error = %v", err)
+			}
+
+			var got models.SchoolBill
+			if err := db.First(&got, 1).Error; err != nil {
+				t.Fatalf("load surviving bill: %v", err)
+			}
+			if floatOrZero(got.AmountPaid) != tt.wantPaid || floatOrZero(got.Balance) != tt.wantBalance || floatOrZero(got.CreditAmount) != tt.wantCredit {
+				t.Errorf("paid/balance/credit = %.2f/%.2f/%.2f, want %.2f/%.2f/%.2f", floatOrZero(got.AmountPaid), floatOrZero(got.Balance),
+					floatOrZero(got.CreditAmount), tt.wantPaid, tt.wantBalance, tt.wantCredit)
+			}
+			if floatOrZero(got.Discounts) != tt.survivor.discounts {
+				t.Errorf("discounts = %.2f, want the survivor's %.2f", floatOrZero(got.Discounts), tt.survivor.discounts)
+			}
+			if got.IsPaid == nil || *got.IsPaid != tt.wantIsPaid {
+				t.Errorf("is_paid = %v, want %v", got.IsPaid, tt.wantIsPaid)
+			}
+
+			var remaining int64
+			db.Model(&models.SchoolBill{}).Where("id = ?", 2).Count(&remaining)
+			if remaining != 0 {
+				t.Error("the duplicate bill was not deleted")
+			}
+			if len(folded) != 1 || folded[0].DroppedAmount != tt.wantDroppedAmount || folded[0].DroppedDiscount != tt.wantDroppedDiscount {
+				t.Errorf("folded = %+v, want dropped amount %.2f and discount %.2f", folded, tt.wantDroppedAmount, tt.wantDroppedDiscount)
+			}
+		})
+	}
+}
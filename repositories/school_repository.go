@@ -1,9 +1,7 @@
 package repositories
 
 import (
-	"fmt"
 	"gnaps-api/models"
-	"strings"
 
 	"gorm.io/gorm"
 )
@@ -16,6 +14,11 @@ func NewSchoolRepository(db *gorm.DB) *SchoolRepository {
 	return &SchoolRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *SchoolRepository) WithTx(tx *gorm.DB) *SchoolRepository {
+	return &SchoolRepository{db: tx}
+}
+
 func (r *SchoolRepository) Search(keyword string, limit int) ([]models.School, error) {
 	var schools []models.School
 	query := r.db.Model(&models.School{}).Where("is_deleted = ?", false)
@@ -55,6 +58,16 @@ func (r *SchoolRepository) FindByID(id uint) (*models.School, error) {
 	return &school, nil
 }
 
+// FindByUserID retrieves the school a school admin user account belongs to
+func (r *SchoolRepository) FindByUserID(userID uint) (*models.School, error) {
+	var school models.School
+	err := r.db.Preload("Zone").Where("user_id = ? AND is_deleted = ?", userID, false).First(&school).Error
+	if err != nil {
+		return nil, err
+	}
+	return &school, nil
+}
+
 func (r *SchoolRepository) List(filters map[string]interface{}, page, limit int) ([]models.School, int64, error) {
 	var schools []models.School
 	var total int64
@@ -67,9 +80,7 @@ func (r *SchoolRepository) List(filters map[string]interface{}, page, limit int)
 		if key == "name" || key == "member_no" {
 			query = query.Where(key+" LIKE ?", "%"+value.(string)+"%")
 		} else if key == "school_group_id" {
-			// Handle JSON array contains for school_group_ids
-			// MySQL JSON_CONTAINS syntax
-			query = query.Where("JSON_CONTAINS(school_group_ids, ?, '$')", fmt.Sprintf("%v", value))
+			query = query.Where("id IN (SELECT school_id FROM school_group_members WHERE school_group_id = ?)", value)
 		} else if key == "region_id" {
 			// Handle region_id by filtering through zones
 			query = query.Where("zone_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?)", value, false)
@@ -86,12 +97,35 @@ func (r *SchoolRepository) List(filters map[string]interface{}, page, limit int)
 	return schools, total, err
 }
 
+// Create creates a school and its group memberships
 func (r *SchoolRepository) Create(school *models.School) error {
-	return r.db.Create(school).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(school).Error; err != nil {
+			return err
+		}
+		if groupIDs := parseJSONIDs(school.SchoolGroupIds); len(groupIDs) > 0 {
+			return replaceSchoolGroups(tx, int64(school.ID), groupIDs)
+		}
+		return nil
+	})
 }
 
+// Update updates a school; school_group_ids replaces its group memberships
 func (r *SchoolRepository) Update(id uint, updates map[string]interface{}) error {
-	return r.db.Model(&models.School{}).Where("id = ?", id).Updates(updates).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		groupIDs, groupsChanged := groupIDsUpdate(updates)
+		delete(updates, "school_group_ids")
+
+		if len(updates) > 0 {
+			if err := tx.Model(&models.School{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if groupsChanged {
+			return replaceSchoolGroups(tx, int64(id), groupIDs)
+		}
+		return nil
+	})
 }
 
 func (r *SchoolRepository) Delete(id uint) error {
@@ -127,35 +161,13 @@ func (r *SchoolRepository) VerifyZoneExists(zoneID int64) (bool, error) {
 	return count > 0, err
 }
 
-// GetNextMemberNoForZone returns the next member number for a zone
-// Member number format: ZONE_CODE-XXX where XXX is an incrementing number
-func (r *SchoolRepository) GetNextMemberNoForZone(zoneID int64) (string, error) {
-	// Get the zone to get its code/name
-	var zone models.Zone
-	if err := r.db.Where("id = ? AND is_deleted = ?", zoneID, false).First(&zone).Error; err != nil {
-		return "", err
-	}
-
-	// Count existing schools in this zone
-	var count int64
-	if err := r.db.Model(&models.School{}).Where("zone_id = ? AND is_deleted = ?", zoneID, false).Count(&count).Error; err != nil {
-		return "", err
-	}
-
-	// Generate next member number: ZONE_CODE-XXX (e.g., ACC-001, KUM-002)
-	// Use first 3 letters of zone name as prefix
-	prefix := ""
-	if zone.Name != nil {
-		prefix = *zone.Name
-	}
-	if len(prefix) > 3 {
-		prefix = prefix[:3]
-	}
-
-	nextNumber := count + 1
-	memberNo := fmt.Sprintf("%s-%03d", strings.ToUpper(prefix), nextNumber)
-
-	return memberNo, nil
+// ListMobileNumbers returns the mobile numbers of all active schools
+func (r *SchoolRepository) ListMobileNumbers() ([]string, error) {
+	var numbers []string
+	err := r.db.Model(&models.School{}).
+		Where("is_deleted = ? AND mobile_no IS NOT NULL AND mobile_no != ?", false, "").
+		Pluck("mobile_no", &numbers).Error
+	return numbers, err
 }
 
 // ListWithRoleFilter returns schools filtered by role-based access
@@ -184,8 +196,7 @@ func (r *SchoolRepository) ListWithRoleFilter(filters map[string]interface{}, pa
 		if key == "name" || key == "member_no" {
 			query = query.Where(key+" LIKE ?", "%"+value.(string)+"%")
 		} else if key == "school_group_id" {
-			// Handle JSON array contains for school_group_ids
-			query = query.Where("JSON_CONTAINS(school_group_ids, ?, '$')", fmt.Sprintf("%v", value))
+			query = query.Where("id IN (SELECT school_id FROM school_group_members WHERE school_group_id = ?)", value)
 		} else if key == "region_id" {
 			// Handle region_id by filtering through zones
 			query = query.Where("zone_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?)", value, false)
@@ -222,3 +233,33 @@ func (r *SchoolRepository) FindByIDWithRoleFilter(id uint, regionID, zoneID *int
 	}
 	return &school, nil
 }
+
+// ListWithinBox returns located schools inside a bounding box, filtered by role-based access
+func (r *SchoolRepository) ListWithinBox(minLat, minLng, maxLat, maxLng float64, limit int, regionID, zoneID *int64) ([]models.School, error) {
+	var schools []models.School
+	query := r.db.Model(&models.School{}).
+		Where("is_deleted = ?", false).
+		Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", minLat, maxLat, minLng, maxLng)
+	query = applySchoolsTableRoleFilter(query, regionID, zoneID)
+
+	err := query.Preload("Zone").Order("name ASC").Limit(limit).Find(&schools).Error
+	return schools, err
+}
+
+// ListLocated returns the located schools of a zone or of every zone in a region, filtered by
+// role-based access
+func (r *SchoolRepository) ListLocated(filterZoneID, filterRegionID *int64, regionID, zoneID *int64) ([]models.School, error) {
+	var schools []models.School
+	query := r.db.Model(&models.School{}).
+		Where("is_deleted = ? AND latitude IS NOT NULL AND longitude IS NOT NULL", false)
+	query = applySchoolsTableRoleFilter(query, regionID, zoneID)
+	if filterZoneID != nil {
+		query = query.Where("zone_id = ?", *filterZoneID)
+	}
+	if filterRegionID != nil {
+		query = query.Where("zone_id IN (SELECT id FROM zones WHERE region_id = ? AND is_deleted = ?)", *filterRegionID, false)
+	}
+
+	err := query.Preload("Zone").Order("name ASC").Find(&schools).Error
+	return schools, err
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"gnaps-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchoolDuesState is a school's membership status with the date of its oldest unpaid bill
+type SchoolDuesState struct {
+	ID               uint       `gorm:"column:id"`
+	MembershipStatus string     `gorm:"column:membership_status"`
+	OldestUnpaidAt   *time.Time `gorm:"column:oldest_unpaid_at"`
+}
+
+type SchoolStatusRepository struct {
+	db *gorm.DB
+}
+
+func NewSchoolStatusRepository(db *gorm.DB) *SchoolStatusRepository {
+	return &SchoolStatusRepository{db: db}
+}
+
+// ListDuesStates retrieves every member school that is not suspended with its oldest unpaid bill
+func (r *SchoolStatusRepository) ListDuesStates() ([]SchoolDuesState, error) {
+	var states []SchoolDuesState
+	err := r.duesStateQuery().
+		Where("schools.membership_status <> ?", "suspended").
+		Scan(&states).Error
+	return states, err
+}
+
+// FindDuesState retrieves one school's membership status and oldest unpaid bill
+func (r *SchoolStatusRepository) FindDuesState(schoolID uint) (*SchoolDuesState, error) {
+	var state SchoolDuesState
+	result := r.duesStateQuery().Where("schools.id = ?", schoolID).Limit(1).Scan(&state)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &state, nil
+}
+
+// ChangeStatus moves a school from one status to another and logs the change. It reports false
+// when the school was no longer in the from status, so concurrent changes are not overwritten.
+func (r *SchoolStatusRepository) ChangeStatus(schoolID uint, from, to string, reason *string, changedBy *int64) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.School{}).
+			Where("id = ? AND membership_status = ?", schoolID, from).
+			Updates(map[string]interface{}{
+				"membership_status": to,
+				"status_reason":     reason,
+				"status_changed_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		changed = true
+
+		return tx.Create(&models.SchoolStatusChange{
+			SchoolId:   int64(schoolID),
+			FromStatus: &from,
+			ToStatus:   to,
+			Reason:     reason,
+			ChangedBy:  changedBy,
+		}).Error
+	})
+	return changed, err
+}
+
+// ListChanges retrieves a school's status changes, newest first
+func (r *SchoolStatusRepository) ListChanges(schoolID uint) ([]models.SchoolStatusChange, error) {
+	var changes []models.SchoolStatusChange
+	err := r.db.Where("school_id = ?", schoolID).Order("created_at DESC, id DESC").Find(&changes).Error
+	return changes, err
+}
+
+func (r *SchoolStatusRepository) duesStateQuery() *gorm.DB {
+	return r.db.Table("schools").
+		Select("schools.id, schools.membership_status, "+
+			"(SELECT MIN(school_bills.created_at) FROM school_bills WHERE school_bills.school_id = schools.id AND "+outstandingSchoolBill+") AS oldest_unpaid_at").
+		Where("schools.is_deleted = ?", false)
+}
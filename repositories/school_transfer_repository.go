@@ -0,0 +1,259 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/utils"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ErrSchoolTransferNotPending is returned when a transfer was already accepted, rejected or cancelled
+var ErrSchoolTransferNotPending = errors.New("transfer is no longer pending")
+
+// ErrSchoolLeftSendingZone is returned when a school is no longer in the zone a transfer moves it from
+var ErrSchoolLeftSendingZone = errors.New("school is no longer in the sending zone")
+
+// ErrSchoolHasOutstandingBills is returned when a settle-first transfer is accepted before the bills are paid
+var ErrSchoolHasOutstandingBills = errors.New("school has outstanding bills")
+
+// Outstanding bill handling on a transfer
+const (
+	TransferBillsKeep        = "keep"         // outstanding bills stay with the sending zone
+	TransferBillsMove        = "move"         // outstanding bills not issued by the sending zone move with the school
+	TransferBillsSettleFirst = "settle_first" // the transfer can only be accepted once every bill is paid
+)
+
+type SchoolTransferRepository struct {
+	db *gorm.DB
+}
+
+func NewSchoolTransferRepository(db *gorm.DB) *SchoolTransferRepository {
+	return &SchoolTransferRepository{db: db}
+}
+
+// Create stores a new transfer request
+func (r *SchoolTransferRepository) Create(transfer *models.SchoolTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+// FindByID retrieves a transfer with its school and zones
+func (r *SchoolTransferRepository) FindByID(id uint) (*models.SchoolTransfer, error) {
+	var transfer models.SchoolTransfer
+	err := r.db.Preload("School").Preload("FromZone").Preload("ToZone").First(&transfer, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// PendingExists checks whether a school already has a transfer awaiting the receiving zone
+func (r *SchoolTransferRepository) PendingExists(schoolID int64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.SchoolTransfer{}).
+		Where("school_id = ? AND status = ?", schoolID, "pending").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List retrieves transfers into ("incoming") or out of ("outgoing") the given zones, or both when
+// direction is empty; every transfer matches when zoneIDs is nil. Newest first
+func (r *SchoolTransferRepository) List(filters map[string]interface{}, zoneIDs []int64, direction string, page, limit int) ([]models.SchoolTransfer, int64, error) {
+	var transfers []models.SchoolTransfer
+	var total int64
+
+	query := r.db.Model(&models.SchoolTransfer{})
+	if zoneIDs != nil {
+		switch direction {
+		case "incoming":
+			query = query.Where("to_zone_id IN ?", zoneIDs)
+		case "outgoing":
+			query = query.Where("from_zone_id IN ?", zoneIDs)
+		default:
+			query = query.Where("from_zone_id IN ? OR to_zone_id IN ?", zoneIDs, zoneIDs)
+		}
+	}
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("School").Preload("FromZone").Preload("ToZone").
+		Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&transfers).Error
+	return transfers, total, err
+}
+
+// Decide records the rejection or cancellation of a pending transfer
+func (r *SchoolTransferRepository) Decide(id uint, updates map[string]interface{}) error {
+	result := r.db.Model(&models.SchoolTransfer{}).Where("id = ? AND status = ?", id, "pending").Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSchoolTransferNotPending
+	}
+	return nil
+}
+
+// ListHistory retrieves the zones a school has belonged to, oldest first
+func (r *SchoolTransferRepository) ListHistory(schoolID int64) ([]models.SchoolZoneHistory, error) {
+	var history []models.SchoolZoneHistory
+	err := r.db.Where("school_id = ?", schoolID).
+		Preload("Zone").
+		Order("started_at IS NULL DESC, started_at ASC, id ASC").
+		Find(&history).Error
+	return history, err
+}
+
+// Accept completes a pending transfer in one transaction: the school moves to the receiving zone,
+// takes its next member number when one is to be issued, its outstanding bills move when the
+// transfer says so, and the zone history closes the old period and opens the new one
+func (r *SchoolTransferRepository) Accept(transfer *models.SchoolTransfer, decidedBy *int64, notes *string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// Claim the transfer first so it cannot be accepted twice
+		claim := tx.Model(&models.SchoolTransfer{}).Where("id = ? AND status = ?", transfer.ID, "pending").Updates(map[string]interface{}{
+			"status":         "accepted",
+			"decided_by":     decidedBy,
+			"decided_at":     now,
+			"decision_notes": notes,
+		})
+		if claim.Error != nil {
+			return claim.Error
+		}
+		if claim.RowsAffected == 0 {
+			return ErrSchoolTransferNotPending
+		}
+
+		var school models.School
+		if err := tx.Where("id = ? AND is_deleted = ?", transfer.SchoolId, false).First(&school).Error; err != nil {
+			return err
+		}
+		if school.ZoneId == nil || *school.ZoneId != transfer.FromZoneId {
+			return ErrSchoolLeftSendingZone
+		}
+
+		if transfer.BillHandling == TransferBillsSettleFirst {
+			var outstanding int64
+			if err := tx.Table("school_bills").Where("school_id = ?", school.ID).Where(outstandingSchoolBill).Count(&outstanding).Error; err != nil {
+				return err
+			}
+			if outstanding > 0 {
+				return ErrSchoolHasOutstandingBills
+			}
+		}
+
+		memberNo := school.MemberNo
+		if transfer.IssueNewMemberNo {
+			allocated, err := allocateMemberNo(tx, transfer.ToZoneId, &school.ID)
+			if err != nil {
+				return err
+			}
+			memberNo = allocated
+		}
+
+		if err := tx.Model(&models.School{}).Where("id = ?", school.ID).Updates(map[string]interface{}{
+			"zone_id":   transfer.ToZoneId,
+			"member_no": memberNo,
+		}).Error; err != nil {
+			return err
+		}
+		// The school admin signs in with the member number
+		if memberNo != school.MemberNo && school.UserId != nil && *school.UserId > 0 {
+			if err := tx.Model(&models.User{}).Where("id = ?", *school.UserId).Update("username", memberNo).Error; err != nil {
+				return err
+			}
+		}
+
+		var movedBillIDs []uint
+		if transfer.BillHandling == TransferBillsMove {
+			// Bills the sending zone issued itself remain its receivables
+			if err := tx.Model(&models.SchoolBill{}).
+				Where("school_id = ?", school.ID).
+				Where(outstandingSchoolBill).
+				Where("NOT (COALESCE(owner_type, '') = ? AND COALESCE(owner_id, 0) = ?)", utils.OwnerTypeZone, transfer.FromZoneId).
+				Pluck("id", &movedBillIDs).Error; err != nil {
+				return err
+			}
+			if len(movedBillIDs) > 0 {
+				if err := tx.Model(&models.SchoolBill{}).Where("id IN ?", movedBillIDs).Update("zone_id", transfer.ToZoneId).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := recordZoneChange(tx, &school, transfer, memberNo, now); err != nil {
+			return err
+		}
+
+		oldMemberNo := school.MemberNo
+		updates := map[string]interface{}{"old_member_no": oldMemberNo}
+		if memberNo != oldMemberNo {
+			updates["new_member_no"] = memberNo
+			transfer.NewMemberNo = &memberNo
+		}
+		if len(movedBillIDs) > 0 {
+			encoded, err := json.Marshal(movedBillIDs)
+			if err != nil {
+				return err
+			}
+			moved := datatypes.JSON(encoded)
+			updates["moved_bill_ids"] = moved
+			transfer.MovedBillIds = &moved
+		}
+		if err := tx.Model(&models.SchoolTransfer{}).Where("id = ?", transfer.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		transfer.Status = "accepted"
+		transfer.OldMemberNo = &oldMemberNo
+		transfer.DecidedBy = decidedBy
+		transfer.DecidedAt = &now
+		transfer.DecisionNotes = notes
+		return nil
+	})
+}
+
+// recordZoneChange closes the school's current zone period and opens one in the receiving zone.
+// A school transferred for the first time gets an open-ended period for its original zone.
+func recordZoneChange(tx *gorm.DB, school *models.School, transfer *models.SchoolTransfer, memberNo string, at time.Time) error {
+	var periods int64
+	if err := tx.Model(&models.SchoolZoneHistory{}).Where("school_id = ?", school.ID).Count(&periods).Error; err != nil {
+		return err
+	}
+
+	if periods == 0 {
+		oldMemberNo := school.MemberNo
+		original := &models.SchoolZoneHistory{
+			SchoolId: int64(school.ID),
+			ZoneId:   transfer.FromZoneId,
+			MemberNo: &oldMemberNo,
+			EndedAt:  &at,
+		}
+		if err := tx.Create(original).Error; err != nil {
+			return err
+		}
+	} else if err := tx.Model(&models.SchoolZoneHistory{}).
+		Where("school_id = ? AND ended_at IS NULL", school.ID).
+		Update("ended_at", at).Error; err != nil {
+		return err
+	}
+
+	transferID := int64(transfer.ID)
+	current := &models.SchoolZoneHistory{
+		SchoolId:         int64(school.ID),
+		ZoneId:           transfer.ToZoneId,
+		MemberNo:         &memberNo,
+		StartedAt:        &at,
+		SchoolTransferId: &transferID,
+	}
+	return tx.Create(current).Error
+}
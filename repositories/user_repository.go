@@ -15,6 +15,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx returns a copy of the repository that runs its queries in tx
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 // FindByID retrieves a user by ID
 func (r *UserRepository) FindByID(id uint) (*models.User, error) {
 	var user models.User
@@ -83,6 +88,11 @@ func (r *UserRepository) Delete(id uint) error {
 	return r.db.Model(&models.User{}).Where("id = ?", id).Update("is_deleted", &trueVal).Error
 }
 
+// ClearPassword removes a user's password so the account can't sign in until a new one is set
+func (r *UserRepository) ClearPassword(id uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("encrypted_password", nil).Error
+}
+
 // HashPassword hashes a password using bcrypt
 func (r *UserRepository) HashPassword(password string) (string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -92,8 +102,9 @@ func (r *UserRepository) HashPassword(password string) (string, error) {
 	return string(hashedPassword), nil
 }
 
-// CreateUserForExecutive creates a user account for an executive
-func (r *UserRepository) CreateUserForExecutive(firstName, lastName, email, mobileNo, role, executiveNo string) (*models.User, error) {
+// CreateUserForExecutive creates a user account for an executive. The account has no password and
+// can't sign in until the executive accepts their invite and sets one.
+func (r *UserRepository) CreateUserForExecutive(firstName, lastName, email, mobileNo, role string) (*models.User, error) {
 	// Generate username from email (part before @)
 	username := email
 	if atIdx := len(email); atIdx > 0 {
@@ -105,28 +116,18 @@ func (r *UserRepository) CreateUserForExecutive(firstName, lastName, email, mobi
 		}
 	}
 
-	// Default password is executive_no + "123"
-	defaultPassword := executiveNo + "123"
-
-	// Hash the default password
-	hashedPassword, err := r.HashPassword(defaultPassword)
-	if err != nil {
-		return nil, err
-	}
-
 	isFirstLogin := true
 	isDeleted := false
 
 	user := &models.User{
-		Username:          &username,
-		FirstName:         &firstName,
-		LastName:          &lastName,
-		Email:             &email,
-		MobileNo:          &mobileNo,
-		Role:              &role,
-		EncryptedPassword: &hashedPassword,
-		IsFirstLogin:      &isFirstLogin,
-		IsDeleted:         &isDeleted,
+		Username:     &username,
+		FirstName:    &firstName,
+		LastName:     &lastName,
+		Email:        &email,
+		MobileNo:     &mobileNo,
+		Role:         &role,
+		IsFirstLogin: &isFirstLogin,
+		IsDeleted:    &isDeleted,
 	}
 
 	if err := r.Create(user); err != nil {
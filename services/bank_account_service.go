@@ -0,0 +1,213 @@
+package services
+
+import (
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"strings"
+)
+
+const (
+	BankAccountTypeBank = "bank"
+	BankAccountTypeMomo = "momo"
+)
+
+type BankAccountService struct {
+	bankAccountRepo *repositories.BankAccountRepository
+}
+
+func NewBankAccountService(bankAccountRepo *repositories.BankAccountRepository) *BankAccountService {
+	return &BankAccountService{
+		bankAccountRepo: bankAccountRepo,
+	}
+}
+
+// GetBankAccountByIDWithOwner retrieves a bank account with owner filtering
+func (s *BankAccountService) GetBankAccountByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.BankAccount, error) {
+	account, err := s.bankAccountRepo.FindByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return nil, errors.New("bank account not found")
+	}
+	return account, nil
+}
+
+// ListBankAccountsWithOwner lists bank and MoMo wallet accounts with owner filtering
+func (s *BankAccountService) ListBankAccountsWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.BankAccount, int64, error) {
+	return s.bankAccountRepo.ListWithOwner(filters, page, limit, ownerCtx)
+}
+
+// CreateBankAccountWithOwner creates a bank or MoMo wallet account for the caller's owner
+func (s *BankAccountService) CreateBankAccountWithOwner(account *models.BankAccount, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+	if err := validateBankAccount(account); err != nil {
+		return err
+	}
+
+	account.ID = 0
+	account.IsActive = true
+	account.IsDeleted = false
+	if account.Currency == "" {
+		account.Currency = "GHS"
+	}
+
+	if err := s.bankAccountRepo.CreateWithOwner(account, ownerCtx); err != nil {
+		return err
+	}
+	if account.IsDefault {
+		ownerType, ownerID := ledgerOwner(account.OwnerType, account.OwnerId)
+		return s.bankAccountRepo.ClearDefault(ownerType, ownerID, account.AccountType, account.ID)
+	}
+	return nil
+}
+
+// UpdateBankAccountWithOwner updates a bank account. Making an account the default clears the
+// default flag on the owner's other accounts of the same type
+func (s *BankAccountService) UpdateBankAccountWithOwner(id uint, updateData *models.BankAccount, isActive *bool, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	existing, err := s.GetBankAccountByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	updateData.AccountType = existing.AccountType
+	if updateData.Name == "" {
+		updateData.Name = existing.Name
+	}
+	if updateData.AccountNumber == "" {
+		updateData.AccountNumber = existing.AccountNumber
+	}
+	if err := validateBankAccount(updateData); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"name":            updateData.Name,
+		"account_number":  updateData.AccountNumber,
+		"opening_balance": updateData.OpeningBalance,
+		"is_default":      updateData.IsDefault,
+	}
+	if updateData.BankName != nil {
+		updates["bank_name"] = *updateData.BankName
+	}
+	if updateData.Branch != nil {
+		updates["branch"] = *updateData.Branch
+	}
+	if updateData.Currency != "" {
+		updates["currency"] = updateData.Currency
+	}
+	if updateData.OpeningDate != nil {
+		updates["opening_date"] = *updateData.OpeningDate
+	}
+	if isActive != nil {
+		updates["is_active"] = *isActive
+		if !*isActive {
+			updates["is_default"] = false
+		}
+	}
+
+	if err := s.bankAccountRepo.UpdateWithOwner(id, updates, ownerCtx); err != nil {
+		return err
+	}
+	if isDefault, _ := updates["is_default"].(bool); isDefault {
+		ownerType, ownerID := ledgerOwner(existing.OwnerType, existing.OwnerId)
+		return s.bankAccountRepo.ClearDefault(ownerType, ownerID, existing.AccountType, existing.ID)
+	}
+	return nil
+}
+
+// DeleteBankAccountWithOwner soft deletes a bank account
+func (s *BankAccountService) DeleteBankAccountWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	return s.bankAccountRepo.DeleteWithOwner(id, ownerCtx)
+}
+
+// ResolveAccount picks the account a payment hit. An explicitly requested account must be an
+// active account of the record's owner; otherwise MoMo payments go to the owner's default MoMo
+// wallet and bank, cheque and transfer payments to the default bank account. Cash payments
+// have no account
+func (s *BankAccountService) ResolveAccount(ownerType *string, ownerId *int64, paymentMode string, requested *int64) (*int64, error) {
+	recordOwnerType, recordOwnerID := ledgerOwner(ownerType, ownerId)
+
+	if requested != nil && *requested > 0 {
+		account, err := s.bankAccountRepo.FindByID(uint(*requested))
+		if err != nil || !account.IsActive || account.OwnerType == nil || account.OwnerId == nil ||
+			*account.OwnerType != recordOwnerType || *account.OwnerId != recordOwnerID {
+			return nil, errors.New("bank account not found for this owner")
+		}
+		id := int64(account.ID)
+		return &id, nil
+	}
+
+	var accountType string
+	switch cashRoleForMode(paymentMode) {
+	case LedgerRoleMomo:
+		accountType = BankAccountTypeMomo
+	case LedgerRoleBank:
+		accountType = BankAccountTypeBank
+	default:
+		return nil, nil
+	}
+
+	account, err := s.bankAccountRepo.FindDefault(recordOwnerType, recordOwnerID, accountType)
+	if err != nil {
+		return nil, nil
+	}
+	id := int64(account.ID)
+	return &id, nil
+}
+
+// TagTransaction sets (or clears, with a nil account) the account a finance transaction was
+// received into. Transactions already matched to a statement line cannot be re-tagged
+func (s *BankAccountService) TagTransaction(transactionId uint, bankAccountId *int64, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	transaction, err := s.bankAccountRepo.FindFinanceTransactionWithOwner(transactionId, ownerCtx)
+	if err != nil {
+		return errors.New("finance transaction not found")
+	}
+	if s.bankAccountRepo.IsEntryMatched(repositories.CashBookEntryTransaction, transaction.ID) {
+		return errors.New("transaction is reconciled; unmatch it before changing its account")
+	}
+
+	if bankAccountId != nil && *bankAccountId > 0 {
+		resolved, err := s.ResolveAccount(transaction.OwnerType, transaction.OwnerId, "", bankAccountId)
+		if err != nil {
+			return err
+		}
+		bankAccountId = resolved
+	} else {
+		bankAccountId = nil
+	}
+
+	return s.bankAccountRepo.TagFinanceTransaction(transaction.ID, bankAccountId)
+}
+
+func validateBankAccount(account *models.BankAccount) error {
+	account.Name = strings.TrimSpace(account.Name)
+	account.AccountNumber = strings.TrimSpace(account.AccountNumber)
+
+	if account.Name == "" {
+		return errors.New("name is required")
+	}
+	if account.AccountType != BankAccountTypeBank && account.AccountType != BankAccountTypeMomo {
+		return errors.New("account_type must be bank or momo")
+	}
+	if account.AccountNumber == "" {
+		return errors.New("account_number is required")
+	}
+	if account.AccountType == BankAccountTypeMomo {
+		for _, r := range account.AccountNumber {
+			if r < '0' || r > '9' {
+				return errors.New("MoMo wallet number must contain digits only")
+			}
+		}
+	}
+	return nil
+}
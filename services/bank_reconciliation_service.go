@@ -0,0 +1,668 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"io"
+	"math"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	StatementLineUnmatched = "unmatched"
+	StatementLineMatched   = "matched"
+	StatementLineExcluded  = "excluded"
+
+	// autoMatchDays is how far apart a statement line and a cash-book entry may be dated and
+	// still be matched automatically
+	autoMatchDays = 3
+)
+
+// BankReconciliationService imports bank statements and reconciles their lines against the
+// cash book of a bank or MoMo wallet account
+type BankReconciliationService struct {
+	bankAccountRepo *repositories.BankAccountRepository
+}
+
+func NewBankReconciliationService(bankAccountRepo *repositories.BankAccountRepository) *BankReconciliationService {
+	return &BankReconciliationService{
+		bankAccountRepo: bankAccountRepo,
+	}
+}
+
+// StatementImportError describes a statement row that could not be imported
+type StatementImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// StatementImportResult summarises an imported statement
+type StatementImportResult struct {
+	Statement  *models.BankStatement  `json:"statement"`
+	Imported   int                    `json:"imported"`
+	Duplicates int                    `json:"duplicates"`
+	Matched    int                    `json:"matched"`
+	Errors     []StatementImportError `json:"errors"`
+}
+
+// ImportStatement reads a CSV or XLSX bank statement, stores its lines and auto-matches them to
+// the account's cash book. The header row is located by its column names, so preamble rows
+// above it are ignored. Lines already imported from an overlapping statement are skipped
+func (s *BankReconciliationService) ImportStatement(bankAccountId uint, file *multipart.FileHeader, closingBalance *float64, importedBy *int64, ownerCtx *utils.OwnerContext) (*StatementImportResult, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return nil, err
+	}
+
+	account, err := s.bankAccountRepo.FindByIDWithOwner(bankAccountId, ownerCtx)
+	if err != nil {
+		return nil, errors.New("bank account not found")
+	}
+
+	rows, err := readStatementRows(file)
+	if err != nil {
+		return nil, err
+	}
+
+	headerRow, columns := findStatementHeader(rows)
+	if headerRow < 0 {
+		return nil, errors.New("statement must have a header row with a date column and an amount, debit or credit column")
+	}
+
+	result := &StatementImportResult{Errors: []StatementImportError{}}
+	var lines []models.BankStatementLine
+	var periodStart, periodEnd *time.Time
+	var lastBalance *float64
+
+	for i := headerRow + 1; i < len(rows); i++ {
+		row := rows[i]
+		rowNumber := i + 1
+		if isBlankRow(row) {
+			continue
+		}
+
+		date, ok := parseStatementDate(statementCell(row, columns["date"]))
+		if !ok {
+			result.Errors = append(result.Errors, StatementImportError{Row: rowNumber, Message: "invalid or missing date"})
+			continue
+		}
+
+		amount, ok := statementLineAmount(row, columns)
+		if !ok {
+			result.Errors = append(result.Errors, StatementImportError{Row: rowNumber, Message: "invalid or missing amount"})
+			continue
+		}
+
+		reference := statementCell(row, columns["reference"])
+		if s.bankAccountRepo.LineExists(account.ID, date, amount, reference) {
+			result.Duplicates++
+			continue
+		}
+
+		line := models.BankStatementLine{
+			BankAccountId:   account.ID,
+			TransactionDate: date,
+			Amount:          amount,
+			Status:          StatementLineUnmatched,
+		}
+		if description := statementCell(row, columns["description"]); description != "" {
+			line.Description = &description
+		}
+		if reference != "" {
+			line.Reference = &reference
+		}
+		if balance, ok := parseStatementAmount(statementCell(row, columns["balance"])); ok {
+			line.Balance = &balance
+			lastBalance = &balance
+		}
+		lines = append(lines, line)
+
+		if periodStart == nil || date.Before(*periodStart) {
+			d := date
+			periodStart = &d
+		}
+		if periodEnd == nil || date.After(*periodEnd) {
+			d := date
+			periodEnd = &d
+		}
+	}
+
+	if len(lines) == 0 && result.Duplicates == 0 {
+		return nil, errors.New("no statement lines found")
+	}
+
+	fileName := file.Filename
+	statement := &models.BankStatement{
+		BankAccountId:  account.ID,
+		FileName:       &fileName,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd,
+		ClosingBalance: closingBalance,
+		LineCount:      len(lines),
+		DuplicateCount: result.Duplicates,
+		ImportedBy:     importedBy,
+	}
+	if statement.ClosingBalance == nil {
+		statement.ClosingBalance = lastBalance
+	}
+	ownerType, ownerID := ledgerOwner(account.OwnerType, account.OwnerId)
+	statement.SetOwner(ownerType, ownerID)
+
+	if err := s.bankAccountRepo.CreateStatement(statement, lines); err != nil {
+		return nil, err
+	}
+
+	result.Statement = statement
+	result.Imported = len(lines)
+	result.Matched, err = s.autoMatch(account.ID)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// ListStatements lists the statements imported for an account
+func (s *BankReconciliationService) ListStatements(bankAccountId uint, page, limit int, ownerCtx *utils.OwnerContext) ([]models.BankStatement, int64, error) {
+	if _, err := s.bankAccountRepo.FindByIDWithOwner(bankAccountId, ownerCtx); err != nil {
+		return nil, 0, errors.New("bank account not found")
+	}
+	return s.bankAccountRepo.ListStatements(bankAccountId, page, limit)
+}
+
+// DeleteStatement removes an imported statement; entries matched to its lines become
+// unreconciled again
+func (s *BankReconciliationService) DeleteStatement(statementId uint, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	statement, err := s.bankAccountRepo.FindStatementByID(statementId)
+	if err != nil {
+		return errors.New("bank statement not found")
+	}
+	if _, err := s.bankAccountRepo.FindByIDWithOwner(statement.BankAccountId, ownerCtx); err != nil {
+		return errors.New("bank statement not found")
+	}
+	return s.bankAccountRepo.DeleteStatement(statement.ID)
+}
+
+// ListLines lists an account's statement lines, optionally filtered by status
+func (s *BankReconciliationService) ListLines(bankAccountId uint, filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.BankStatementLine, int64, error) {
+	if _, err := s.bankAccountRepo.FindByIDWithOwner(bankAccountId, ownerCtx); err != nil {
+		return nil, 0, errors.New("bank account not found")
+	}
+	return s.bankAccountRepo.ListLines(bankAccountId, filters, page, limit)
+}
+
+// AutoMatch matches an account's unmatched statement lines to unreconciled cash-book entries
+func (s *BankReconciliationService) AutoMatch(bankAccountId uint, ownerCtx *utils.OwnerContext) (int, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return 0, err
+	}
+	if _, err := s.bankAccountRepo.FindByIDWithOwner(bankAccountId, ownerCtx); err != nil {
+		return 0, errors.New("bank account not found")
+	}
+	return s.autoMatch(bankAccountId)
+}
+
+// autoMatch pairs each unmatched line with an unreconciled entry of exactly the same amount
+// dated within autoMatchDays, preferring an entry whose reference appears on the line and then
+// the closest date
+func (s *BankReconciliationService) autoMatch(bankAccountId uint) (int, error) {
+	entries, err := s.bankAccountRepo.GetCashBookEntries(bankAccountId, nil)
+	if err != nil {
+		return 0, err
+	}
+	lines, err := s.bankAccountRepo.GetLines(bankAccountId, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	available := unreconciledEntries(entries, lines)
+	matched := 0
+	now := time.Now()
+
+	for _, line := range lines {
+		if line.Status != StatementLineUnmatched {
+			continue
+		}
+
+		best := -1
+		bestDays := math.MaxFloat64
+		bestRef := false
+		lineText := strings.ToLower(stringValue(line.Reference) + " " + stringValue(line.Description))
+		for i, entry := range available {
+			if math.Abs(entry.Amount-line.Amount) >= 0.005 {
+				continue
+			}
+			days := math.Abs(dateOnly(entry.Date).Sub(dateOnly(line.TransactionDate)).Hours() / 24)
+			if days > autoMatchDays {
+				continue
+			}
+			refMatch := entry.Reference != "" && strings.Contains(lineText, strings.ToLower(entry.Reference))
+			if best < 0 || (refMatch && !bestRef) || (refMatch == bestRef && days < bestDays) {
+				best, bestDays, bestRef = i, days, refMatch
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		entry := available[best]
+		note := "Auto-matched"
+		err := s.bankAccountRepo.UpdateLine(line.ID, map[string]interface{}{
+			"status":        StatementLineMatched,
+			"matched_type":  entry.EntryType,
+			"matched_id":    entry.EntryId,
+			"note":          note,
+			"reconciled_at": &now,
+		})
+		if err != nil {
+			return matched, err
+		}
+		available = append(available[:best], available[best+1:]...)
+		matched++
+	}
+	return matched, nil
+}
+
+// MatchLine manually matches a statement line to a cash-book entry of the same account and amount
+func (s *BankReconciliationService) MatchLine(lineId uint, entryType string, entryId uint, userID *int64, ownerCtx *utils.OwnerContext) error {
+	line, err := s.lineForUpdate(lineId, ownerCtx)
+	if err != nil {
+		return err
+	}
+	if line.Status != StatementLineUnmatched {
+		return errors.New("statement line is already reconciled")
+	}
+
+	entries, err := s.bankAccountRepo.GetCashBookEntries(line.BankAccountId, nil)
+	if err != nil {
+		return err
+	}
+
+	var entry *repositories.CashBookEntry
+	for i := range entries {
+		if entries[i].EntryType == entryType && entries[i].EntryId == entryId {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return errors.New("cash-book entry not found for this account")
+	}
+	if s.bankAccountRepo.IsEntryMatched(entryType, entryId) {
+		return errors.New("cash-book entry is already matched")
+	}
+	if math.Abs(entry.Amount-line.Amount) >= 0.005 {
+		return errors.New("statement line and cash-book entry amounts do not match")
+	}
+
+	now := time.Now()
+	return s.bankAccountRepo.UpdateLine(line.ID, map[string]interface{}{
+		"status":        StatementLineMatched,
+		"matched_type":  entry.EntryType,
+		"matched_id":    entry.EntryId,
+		"note":          nil,
+		"reconciled_by": userID,
+		"reconciled_at": &now,
+	})
+}
+
+// ExcludeLine marks a statement line that has no cash-book entry (e.g. a reversed duplicate)
+// as reconciled with an explanation
+func (s *BankReconciliationService) ExcludeLine(lineId uint, note string, userID *int64, ownerCtx *utils.OwnerContext) error {
+	if strings.TrimSpace(note) == "" {
+		return errors.New("note is required")
+	}
+
+	line, err := s.lineForUpdate(lineId, ownerCtx)
+	if err != nil {
+		return err
+	}
+	if line.Status != StatementLineUnmatched {
+		return errors.New("statement line is already reconciled")
+	}
+
+	now := time.Now()
+	return s.bankAccountRepo.UpdateLine(line.ID, map[string]interface{}{
+		"status":        StatementLineExcluded,
+		"note":          note,
+		"reconciled_by": userID,
+		"reconciled_at": &now,
+	})
+}
+
+// UnmatchLine returns a matched or excluded statement line to unmatched
+func (s *BankReconciliationService) UnmatchLine(lineId uint, ownerCtx *utils.OwnerContext) error {
+	line, err := s.lineForUpdate(lineId, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	return s.bankAccountRepo.UpdateLine(line.ID, map[string]interface{}{
+		"status":        StatementLineUnmatched,
+		"matched_type":  nil,
+		"matched_id":    nil,
+		"note":          nil,
+		"reconciled_by": nil,
+		"reconciled_at": nil,
+	})
+}
+
+func (s *BankReconciliationService) lineForUpdate(lineId uint, ownerCtx *utils.OwnerContext) (*models.BankStatementLine, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return nil, err
+	}
+
+	line, err := s.bankAccountRepo.FindLineByID(lineId)
+	if err != nil {
+		return nil, errors.New("statement line not found")
+	}
+	if _, err := s.bankAccountRepo.FindByIDWithOwner(line.BankAccountId, ownerCtx); err != nil {
+		return nil, errors.New("statement line not found")
+	}
+	return line, nil
+}
+
+// BankReconciliation compares an account's cash book with its imported statements
+type BankReconciliation struct {
+	Account                    *models.BankAccount          `json:"account"`
+	AsOf                       string                       `json:"as_of,omitempty"`
+	OpeningBalance             float64                      `json:"opening_balance"`
+	BookBalance                float64                      `json:"book_balance"`
+	StatementBalance           float64                      `json:"statement_balance"`
+	ReconciledBalance          float64                      `json:"reconciled_balance"`
+	UnreconciledBookTotal      float64                      `json:"unreconciled_book_total"`
+	UnreconciledStatementTotal float64                      `json:"unreconciled_statement_total"`
+	ExcludedTotal              float64                      `json:"excluded_total"`
+	AdjustedBookBalance        float64                      `json:"adjusted_book_balance"`
+	Difference                 float64                      `json:"difference"`
+	IsReconciled               bool                         `json:"is_reconciled"`
+	UnreconciledBookItems      []repositories.CashBookEntry `json:"unreconciled_book_items"`
+	UnreconciledStatementLines []models.BankStatementLine   `json:"unreconciled_statement_lines"`
+}
+
+// GetReconciliation reports an account's book, statement and reconciled balances as of a date
+// (inclusive; defaults to today) with the items that are not yet reconciled. The reconciled
+// balance is the opening balance plus cash-book entries matched to statement lines; the adjusted
+// book balance removes uncleared entries and adds statement-only lines, and should equal the
+// statement balance when the account is reconciled
+func (s *BankReconciliationService) GetReconciliation(bankAccountId uint, asOf string, ownerCtx *utils.OwnerContext) (*BankReconciliation, error) {
+	account, err := s.bankAccountRepo.FindByIDWithOwner(bankAccountId, ownerCtx)
+	if err != nil {
+		return nil, errors.New("bank account not found")
+	}
+
+	_, to := parseDateRange("", asOf)
+
+	entries, err := s.bankAccountRepo.GetCashBookEntries(account.ID, to)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := s.bankAccountRepo.GetLines(account.ID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BankReconciliation{
+		Account:                    account,
+		AsOf:                       asOf,
+		OpeningBalance:             account.OpeningBalance,
+		UnreconciledBookItems:      unreconciledEntries(entries, lines),
+		UnreconciledStatementLines: []models.BankStatementLine{},
+	}
+
+	report.BookBalance = account.OpeningBalance
+	for _, entry := range entries {
+		report.BookBalance += entry.Amount
+	}
+	for _, entry := range report.UnreconciledBookItems {
+		report.UnreconciledBookTotal += entry.Amount
+	}
+	report.ReconciledBalance = report.BookBalance - report.UnreconciledBookTotal
+
+	statementBalance := account.OpeningBalance
+	var printedBalance *float64
+	for _, line := range lines {
+		statementBalance += line.Amount
+		if line.Balance != nil {
+			printedBalance = line.Balance
+		}
+		switch line.Status {
+		case StatementLineUnmatched:
+			report.UnreconciledStatementTotal += line.Amount
+			report.UnreconciledStatementLines = append(report.UnreconciledStatementLines, line)
+		case StatementLineExcluded:
+			report.ExcludedTotal += line.Amount
+		}
+	}
+	if printedBalance != nil {
+		statementBalance = *printedBalance
+	}
+	report.StatementBalance = statementBalance
+
+	report.AdjustedBookBalance = report.BookBalance - report.UnreconciledBookTotal + report.UnreconciledStatementTotal + report.ExcludedTotal
+	report.Difference = roundAmount(report.StatementBalance - report.AdjustedBookBalance)
+
+	report.BookBalance = roundAmount(report.BookBalance)
+	report.ReconciledBalance = roundAmount(report.ReconciledBalance)
+	report.UnreconciledBookTotal = roundAmount(report.UnreconciledBookTotal)
+	report.UnreconciledStatementTotal = roundAmount(report.UnreconciledStatementTotal)
+	report.ExcludedTotal = roundAmount(report.ExcludedTotal)
+	report.AdjustedBookBalance = roundAmount(report.AdjustedBookBalance)
+	report.IsReconciled = report.Difference == 0 && len(report.UnreconciledBookItems) == 0 && len(report.UnreconciledStatementLines) == 0
+	return report, nil
+}
+
+// unreconciledEntries returns the cash-book entries not matched to any of the lines
+func unreconciledEntries(entries []repositories.CashBookEntry, lines []models.BankStatementLine) []repositories.CashBookEntry {
+	matched := make(map[string]bool)
+	for _, line := range lines {
+		if line.Status == StatementLineMatched && line.MatchedType != nil && line.MatchedId != nil {
+			matched[*line.MatchedType+":"+strconv.FormatUint(uint64(*line.MatchedId), 10)] = true
+		}
+	}
+
+	result := []repositories.CashBookEntry{}
+	for _, entry := range entries {
+		if !matched[entry.EntryType+":"+strconv.FormatUint(uint64(entry.EntryId), 10)] {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ============================================
+// Statement file parsing
+// ============================================
+
+// statementColumnAliases maps each statement field to the header names banks use for it,
+// in order of preference
+var statementColumnAliases = map[string][]string{
+	"date":        {"transaction date", "trans date", "txn date", "date", "posting date", "booking date", "value date"},
+	"description": {"description", "narration", "details", "transaction details", "particulars", "remarks"},
+	"reference":   {"reference", "reference no", "ref no", "ref", "transaction id", "transaction reference", "cheque no", "cheque number"},
+	"debit":       {"debit", "debit amount", "withdrawal", "withdrawals", "money out", "paid out", "dr"},
+	"credit":      {"credit", "credit amount", "deposit", "deposits", "money in", "paid in", "cr"},
+	"amount":      {"amount", "transaction amount"},
+	"balance":     {"balance", "running balance", "available balance", "closing balance"},
+}
+
+func readStatementRows(file *multipart.FileHeader) ([][]string, error) {
+	return readSpreadsheetRows(file, "statement")
+}
+
+// readSpreadsheetRows reads the rows of an uploaded .csv file or the first sheet of an .xlsx file;
+// kind names the upload in error messages
+func readSpreadsheetRows(file *multipart.FileHeader, kind string) ([][]string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.New("failed to read " + kind + " file")
+	}
+	defer src.Close()
+
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".csv":
+		reader := csv.NewReader(src)
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil && err != io.EOF {
+			return nil, errors.New("failed to parse CSV " + kind + ": " + err.Error())
+		}
+		return rows, nil
+	case ".xlsx":
+		workbook, err := excelize.OpenReader(src)
+		if err != nil {
+			return nil, errors.New("failed to parse XLSX " + kind + ": " + err.Error())
+		}
+		defer workbook.Close()
+		sheets := workbook.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New(kind + " workbook has no sheets")
+		}
+		return workbook.GetRows(sheets[0])
+	default:
+		return nil, errors.New(kind + " must be a .csv or .xlsx file")
+	}
+}
+
+// findStatementHeader finds the first of the opening rows that names a date column and an
+// amount, debit or credit column, returning its index and each field's column index
+func findStatementHeader(rows [][]string) (int, map[string]int) {
+	for i := 0; i < len(rows) && i < 20; i++ {
+		columns := map[string]int{}
+		for field, aliases := range statementColumnAliases {
+			columns[field] = -1
+			best := len(aliases)
+			for col, cell := range rows[i] {
+				header := normalizeStatementHeader(cell)
+				for rank, alias := range aliases {
+					if rank < best && (header == alias || strings.HasPrefix(header, alias+" ")) {
+						columns[field], best = col, rank
+					}
+				}
+			}
+		}
+		if columns["date"] >= 0 && (columns["amount"] >= 0 || columns["debit"] >= 0 || columns["credit"] >= 0) {
+			return i, columns
+		}
+	}
+	return -1, nil
+}
+
+func normalizeStatementHeader(value string) string {
+	value = strings.ToLower(value)
+	value = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return ' '
+	}, value)
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func statementCell(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[col])
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// statementLineAmount returns the signed amount of a row: money in is positive, money out negative
+func statementLineAmount(row []string, columns map[string]int) (float64, bool) {
+	if columns["amount"] >= 0 {
+		if amount, ok := parseStatementAmount(statementCell(row, columns["amount"])); ok && amount != 0 {
+			return amount, true
+		}
+	}
+
+	credit, hasCredit := parseStatementAmount(statementCell(row, columns["credit"]))
+	debit, hasDebit := parseStatementAmount(statementCell(row, columns["debit"]))
+	if (!hasCredit || credit == 0) && (!hasDebit || debit == 0) {
+		return 0, false
+	}
+	return roundAmount(math.Abs(credit) - math.Abs(debit)), true
+}
+
+// parseStatementAmount parses amounts such as "1,250.00", "GHS 50", "(20.00)" or "75.00 DR"
+func parseStatementAmount(value string) (float64, bool) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if value == "" || value == "-" {
+		return 0, false
+	}
+
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = strings.Trim(value, "()")
+	}
+	if strings.HasSuffix(value, "DR") {
+		negative = true
+		value = strings.TrimSuffix(value, "DR")
+	}
+	value = strings.TrimSuffix(value, "CR")
+	for _, symbol := range []string{"GHS", "GH¢", "GH₵", "₵", "¢", ",", " "} {
+		value = strings.ReplaceAll(value, symbol, "")
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	if negative {
+		amount = -amount
+	}
+	return roundAmount(amount), true
+}
+
+var statementDateLayouts = []string{
+	"2006-01-02", "2006-01-02 15:04:05", "2006/01/02",
+	"02/01/2006", "2/1/2006", "02/01/2006 15:04", "02/01/2006 15:04:05", "02/01/06",
+	"02-01-2006", "2-1-2006", "02.01.2006",
+	"02-Jan-2006", "2-Jan-2006", "02-Jan-06", "02 Jan 2006", "2 Jan 2006", "Jan 2, 2006", "02 January 2006",
+}
+
+// parseStatementDate parses day-first statement dates and Excel serial dates
+func parseStatementDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	for _, layout := range statementDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return dateOnly(t), true
+		}
+	}
+
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 20000 && serial < 80000 {
+		if t, err := excelize.ExcelDateToTime(serial, false); err == nil {
+			return dateOnly(t), true
+		}
+	}
+	return time.Time{}, false
+}
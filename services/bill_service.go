@@ -1,24 +1,36 @@
 package services
 
 import (
+	"encoding/json"
 	"errors"
 	"gnaps-api/models"
 	"gnaps-api/repositories"
 	"gnaps-api/utils"
+
+	"gorm.io/datatypes"
 )
 
 type BillService struct {
-	billRepo     *repositories.BillRepository
-	billItemRepo *repositories.BillItemRepository
+	billRepo       *repositories.BillRepository
+	billItemRepo   *repositories.BillItemRepository
+	revisionRepo   *repositories.SchoolBillRevisionRepository
+	schoolBillRepo *repositories.SchoolBillRepository
+	schoolRepo     *repositories.SchoolRepository
 }
 
 func NewBillService(
 	billRepo *repositories.BillRepository,
 	billItemRepo *repositories.BillItemRepository,
+	revisionRepo *repositories.SchoolBillRevisionRepository,
+	schoolBillRepo *repositories.SchoolBillRepository,
+	schoolRepo *repositories.SchoolRepository,
 ) *BillService {
 	return &BillService{
-		billRepo:     billRepo,
-		billItemRepo: billItemRepo,
+		billRepo:       billRepo,
+		billItemRepo:   billItemRepo,
+		revisionRepo:   revisionRepo,
+		schoolBillRepo: schoolBillRepo,
+		schoolRepo:     schoolRepo,
 	}
 }
 
@@ -99,26 +111,280 @@ func (s *BillService) CreateBillItem(billItem *models.BillItem) error {
 	isDeleted := false
 	billItem.IsDeleted = &isDeleted
 
-	return s.billItemRepo.Create(billItem)
+	if err := s.billItemRepo.Create(billItem); err != nil {
+		return err
+	}
+
+	// Add the new item to school bills that have already been issued
+	created, err := s.billItemRepo.FindByID(billItem.ID)
+	if err != nil {
+		return err
+	}
+	_, err = s.ReviseSchoolBillsForItem(created, nil, "bill item added")
+	return err
 }
 
-func (s *BillService) UpdateBillItem(id uint, updates map[string]interface{}) error {
+// UpdateBillItem updates a bill item and revises every issued school bill it affects.
+// The returned revisions describe how each school bill changed.
+func (s *BillService) UpdateBillItem(id uint, updates map[string]interface{}, revisedBy *int64, reason string) ([]models.SchoolBillRevision, error) {
 	// Verify bill item exists
 	_, err := s.billItemRepo.FindByID(id)
 	if err != nil {
-		return errors.New("bill item not found")
+		return nil, errors.New("bill item not found")
+	}
+
+	if err := s.billItemRepo.Update(id, updates); err != nil {
+		return nil, err
 	}
 
-	return s.billItemRepo.Update(id, updates)
+	billItem, err := s.billItemRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("bill item not found")
+	}
+
+	if reason == "" {
+		reason = "bill item updated"
+	}
+
+	return s.ReviseSchoolBillsForItem(billItem, revisedBy, reason)
 }
 
-func (s *BillService) DeleteBillItem(id uint) error {
-	_, err := s.billItemRepo.FindByID(id)
+func (s *BillService) DeleteBillItem(id uint, revisedBy *int64) error {
+	billItem, err := s.billItemRepo.FindByID(id)
 	if err != nil {
 		return errors.New("bill item not found")
 	}
 
-	return s.billItemRepo.Delete(id)
+	if err := s.billItemRepo.Delete(id); err != nil {
+		return err
+	}
+
+	// Remove the item from issued school bills
+	deleted := true
+	billItem.IsDeleted = &deleted
+	_, err = s.ReviseSchoolBillsForItem(billItem, revisedBy, "bill item removed")
+	return err
+}
+
+// ============================================
+// School bill revisions
+// ============================================
+
+// Revision types recorded on school_bill_revisions
+const (
+	RevisionTypeItemAdded         = "item_added"
+	RevisionTypeItemAmountChanged = "item_amount_changed"
+	RevisionTypeItemRemoved       = "item_removed"
+)
+
+// ReviseSchoolBillsForItem applies the difference between a bill item's current amount and what
+// each issued school bill was charged for it. Amounts already paid are never changed; any
+// overpayment caused by a reduction is moved to the bill's credit amount.
+func (s *BillService) ReviseSchoolBillsForItem(billItem *models.BillItem, revisedBy *int64, reason string) ([]models.SchoolBillRevision, error) {
+	revisions := []models.SchoolBillRevision{}
+	if billItem.BillId == nil {
+		return revisions, nil
+	}
+
+	targets, err := s.revisionRepo.FindSchoolBillsByBillID(*billItem.BillId, billItem.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := billItem.IsDeleted != nil && *billItem.IsDeleted
+
+	for _, target := range targets {
+		schoolBill := target.SchoolBill
+
+		newAmount := float64(0)
+		if !removed && billItemAppliesToSchool(billItem, &target) && billItem.Amount != nil {
+			newAmount = *billItem.Amount
+		}
+
+		particular, err := s.revisionRepo.FindParticularForItem(schoolBill.ID, billItem.ID)
+		if err != nil {
+			if newAmount == 0 {
+				// Item was never charged to this school and still does not apply
+				continue
+			}
+			particular = newParticularForItem(billItem, &schoolBill)
+		}
+
+		previousAmount := floatValue(particular.Amount)
+		delta := newAmount - previousAmount
+		if delta == 0 {
+			continue
+		}
+
+		revisionType := RevisionTypeItemAmountChanged
+		if particular.ID == 0 {
+			revisionType = RevisionTypeItemAdded
+		} else if newAmount == 0 {
+			revisionType = RevisionTypeItemRemoved
+		}
+
+		// Adjust the particular; a removed particular is only deleted if nothing was paid against it.
+		// ApplyRevision recomputes the credit amounts and the school bill's balance under a row lock.
+		particular.Amount = &newAmount
+		if revisionType == RevisionTypeItemRemoved {
+			deleted := true
+			particular.IsDeleted = &deleted
+		}
+
+		schoolBillId := int64(schoolBill.ID)
+		billItemId := int64(billItem.ID)
+		revisionReason := reason
+		revision := models.SchoolBillRevision{
+			SchoolBillId:   schoolBillId,
+			SchoolId:       schoolBill.SchoolId,
+			BillId:         billItem.BillId,
+			BillItemId:     &billItemId,
+			RevisionType:   revisionType,
+			ParticularName: particular.ParticularName,
+			PreviousAmount: &previousAmount,
+			NewAmount:      &newAmount,
+			DeltaAmount:    &delta,
+			Reason:         &revisionReason,
+			RevisedBy:      revisedBy,
+			OwnerType:      schoolBill.OwnerType,
+			OwnerId:        schoolBill.OwnerId,
+		}
+
+		if err := s.revisionRepo.ApplyRevision(particular, schoolBill.ID, &revision); err != nil {
+			return revisions, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+// GetSchoolBillRevisionsWithOwner retrieves the revision history of a school bill the caller can view
+func (s *BillService) GetSchoolBillRevisionsWithOwner(schoolBillId int64, ownerCtx *utils.OwnerContext) ([]models.SchoolBillRevision, error) {
+	if ownerCtx != nil && ownerCtx.Role == utils.RoleSchoolAdmin {
+		school, err := s.schoolRepo.FindByUserID(ownerCtx.UserID)
+		if err != nil {
+			return nil, errors.New("school bill not found")
+		}
+		schoolBill, err := s.schoolBillRepo.FindByID(uint(schoolBillId))
+		if err != nil || schoolBill.SchoolId == nil || *schoolBill.SchoolId != int64(school.ID) {
+			return nil, errors.New("school bill not found")
+		}
+		return s.revisionRepo.FindBySchoolBillID(schoolBillId)
+	}
+
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	if _, err := s.schoolBillRepo.FindByIDWithRoleFilter(uint(schoolBillId), ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return nil, errors.New("school bill not found")
+	}
+	return s.revisionRepo.FindBySchoolBillID(schoolBillId)
+}
+
+// GetSchoolRevisionsWithOwner retrieves the revision history across all of a school's bills: a
+// school admin's own school, or for other admins a school in their region or zone
+func (s *BillService) GetSchoolRevisionsWithOwner(schoolId int64, page, limit int, ownerCtx *utils.OwnerContext) ([]models.SchoolBillRevision, int64, error) {
+	if ownerCtx != nil && ownerCtx.Role == utils.RoleSchoolAdmin {
+		school, err := s.schoolRepo.FindByUserID(ownerCtx.UserID)
+		if err != nil || (schoolId != 0 && schoolId != int64(school.ID)) {
+			return nil, 0, errors.New("school not found")
+		}
+		return s.revisionRepo.FindBySchoolID(int64(school.ID), page, limit)
+	}
+
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+	if _, err := s.schoolRepo.FindByIDWithRoleFilter(uint(schoolId), ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return nil, 0, errors.New("school not found")
+	}
+	return s.revisionRepo.FindBySchoolID(schoolId, page, limit)
+}
+
+// billItemAppliesToSchool checks a bill item's targeting against a school bill.
+// An item without any targeting applies to every school on the bill.
+func billItemAppliesToSchool(billItem *models.BillItem, target *repositories.SchoolBillTarget) bool {
+	if isEmptyJSONArray(billItem.RegionIds) && isEmptyJSONArray(billItem.ZoneIds) &&
+		isEmptyJSONArray(billItem.SchoolGroupIds) && isEmptyJSONArray(billItem.SchoolIds) {
+		return true
+	}
+
+	if target.RegionId != nil && containsInJSON(billItem.RegionIds, *target.RegionId) {
+		return true
+	}
+	if target.SchoolBill.ZoneId != nil && containsInJSON(billItem.ZoneIds, *target.SchoolBill.ZoneId) {
+		return true
+	}
+	if target.SchoolBill.SchoolId != nil && containsInJSON(billItem.SchoolIds, *target.SchoolBill.SchoolId) {
+		return true
+	}
+	if target.InTargetedGroup {
+		return true
+	}
+
+	return false
+}
+
+// newParticularForItem builds the billing particular for an item being added to an issued school bill
+func newParticularForItem(billItem *models.BillItem, schoolBill *models.SchoolBill) *models.SchoolBillingParticular {
+	zero := float64(0)
+	isDeleted := false
+	schoolBillId := int64(schoolBill.ID)
+	billingItemId := int(billItem.ID)
+
+	particular := &models.SchoolBillingParticular{
+		SchoolId:         schoolBill.SchoolId,
+		Amount:           &zero,
+		DiscountAmount:   &zero,
+		AmountPaid:       &zero,
+		CreditAmount:     &zero,
+		BillParticularId: billItem.BillParticularId,
+		ZoneId:           schoolBill.ZoneId,
+		BillId:           schoolBill.BillId,
+		SchoolBillingId:  &schoolBillId,
+		BillingItemId:    &billingItemId,
+		IsApproved:       billItem.IsApproved,
+		IsDeleted:        &isDeleted,
+	}
+
+	if billItem.FinanceAccountId != nil {
+		financeAccountId := int(*billItem.FinanceAccountId)
+		particular.FinanceAccountId = &financeAccountId
+	}
+
+	if billItem.BillParticular != nil {
+		particular.ParticularName = billItem.BillParticular.Name
+		particular.Priority = billItem.BillParticular.Priority
+		if particular.FinanceAccountId == nil && billItem.BillParticular.FinanceAccountId != nil {
+			financeAccountId := int(*billItem.BillParticular.FinanceAccountId)
+			particular.FinanceAccountId = &financeAccountId
+		}
+	} else if billItem.Name != nil {
+		particular.ParticularName = billItem.Name
+	}
+
+	return particular
+}
+
+// isEmptyJSONArray reports whether a JSON column is null or an empty array
+func isEmptyJSONArray(jsonData *datatypes.JSON) bool {
+	if jsonData == nil {
+		return true
+	}
+	var arr []interface{}
+	if err := json.Unmarshal(*jsonData, &arr); err != nil {
+		return true
+	}
+	return len(arr) == 0
+}
+
+func floatValue(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
 }
 
 // ============================================
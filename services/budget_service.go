@@ -0,0 +1,426 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"math"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// DefaultBudgetAlertThreshold is the percentage consumed that raises an alert when none is set
+const DefaultBudgetAlertThreshold = 80.0
+
+type BudgetService struct {
+	budgetRepo  *repositories.BudgetRepository
+	accountRepo *repositories.FinanceAccountRepository
+}
+
+func NewBudgetService(budgetRepo *repositories.BudgetRepository, accountRepo *repositories.FinanceAccountRepository) *BudgetService {
+	return &BudgetService{
+		budgetRepo:  budgetRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+func (s *BudgetService) GetBudgetByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.Budget, error) {
+	budget, err := s.budgetRepo.FindByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return nil, errors.New("budget not found")
+	}
+	return budget, nil
+}
+
+func (s *BudgetService) ListBudgetsWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.Budget, int64, error) {
+	return s.budgetRepo.ListWithOwner(filters, page, limit, ownerCtx)
+}
+
+// CreateBudgetWithOwner sets the owner's budget for a finance account and fiscal year
+func (s *BudgetService) CreateBudgetWithOwner(budget *models.Budget, createdBy *int64, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		budget.SetOwner(ownerCtx.GetOwnerValues())
+	}
+	if err := s.validateBudget(budget); err != nil {
+		return err
+	}
+
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		ownerType, ownerID := ownerCtx.GetOwnerValues()
+		exists, err := s.budgetRepo.Exists(budget.FinanceAccountId, budget.FiscalYear, ownerType, ownerID, nil)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("a budget for this account and fiscal year already exists")
+		}
+	}
+
+	budget.CreatedBy = createdBy
+	budget.IsDeleted = false
+
+	return s.budgetRepo.CreateWithOwner(budget, ownerCtx)
+}
+
+// UpdateBudgetWithOwner updates a budget's amounts, monthly split, threshold or notes
+func (s *BudgetService) UpdateBudgetWithOwner(id uint, changes *models.Budget, ownerCtx *utils.OwnerContext) error {
+	budget, err := s.GetBudgetByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+	if changes.Amount > 0 {
+		budget.Amount = changes.Amount
+		updates["amount"] = changes.Amount
+	}
+	if changes.MonthlyAmounts != nil {
+		budget.MonthlyAmounts = changes.MonthlyAmounts
+		updates["monthly_amounts"] = *changes.MonthlyAmounts
+	}
+	if changes.AlertThreshold > 0 {
+		budget.AlertThreshold = changes.AlertThreshold
+		updates["alert_threshold"] = changes.AlertThreshold
+	}
+	if changes.Notes != nil {
+		updates["notes"] = *changes.Notes
+	}
+
+	if err := s.validateBudget(budget); err != nil {
+		return err
+	}
+	// validateBudget may derive the annual amount from the monthly split
+	if _, ok := updates["monthly_amounts"]; ok {
+		updates["amount"] = budget.Amount
+	}
+
+	return s.budgetRepo.UpdateWithOwner(id, updates, ownerCtx)
+}
+
+func (s *BudgetService) DeleteBudgetWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	return s.budgetRepo.DeleteWithOwner(id, ownerCtx)
+}
+
+// validateBudget checks a budget against its finance account, which must be a sub-account
+// belonging to the budget's owner
+func (s *BudgetService) validateBudget(budget *models.Budget) error {
+	if budget.FinanceAccountId == 0 {
+		return errors.New("finance_account_id is required")
+	}
+	account, err := s.accountRepo.FindByID(uint(budget.FinanceAccountId))
+	if err != nil {
+		return errors.New("finance account not found")
+	}
+	if account.IsHeader != nil && *account.IsHeader {
+		return errors.New("budgets are set on sub-accounts; heading totals are rolled up in the variance report")
+	}
+	accountOwnerType, accountOwnerID := ledgerOwner(account.OwnerType, account.OwnerId)
+	if ownerType, ownerID := ledgerOwner(budget.OwnerType, budget.OwnerId); ownerType != accountOwnerType || ownerID != accountOwnerID {
+		return fmt.Errorf("finance account %s does not belong to you", stringValue(account.Code))
+	}
+	if budget.FiscalYear < 2000 || budget.FiscalYear > 2100 {
+		return errors.New("fiscal_year is invalid")
+	}
+	if budget.Amount < 0 {
+		return errors.New("amount cannot be negative")
+	}
+	if budget.AlertThreshold == 0 {
+		budget.AlertThreshold = DefaultBudgetAlertThreshold
+	}
+	if budget.AlertThreshold < 0 || budget.AlertThreshold > 1000 {
+		return errors.New("alert_threshold must be a percentage between 0 and 1000")
+	}
+
+	if budget.MonthlyAmounts != nil {
+		months, err := parseMonthlyAmounts(budget.MonthlyAmounts)
+		if err != nil {
+			return err
+		}
+		var total float64
+		for _, m := range months {
+			if m < 0 {
+				return errors.New("monthly amounts cannot be negative")
+			}
+			total += m
+		}
+		total = roundAmount(total)
+		if budget.Amount == 0 {
+			budget.Amount = total
+		} else if total != roundAmount(budget.Amount) {
+			return fmt.Errorf("monthly amounts total %.2f but the annual budget is %.2f", total, budget.Amount)
+		}
+	}
+
+	if budget.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	return nil
+}
+
+// ============================================
+// Variance reporting
+// ============================================
+
+// BudgetVarianceRow compares one account's budget with its actual income or spending
+type BudgetVarianceRow struct {
+	BudgetId         uint    `json:"budget_id"`
+	FinanceAccountId int64   `json:"finance_account_id"`
+	AccountCode      string  `json:"account_code"`
+	AccountName      string  `json:"account_name"`
+	AccountKind      string  `json:"account_kind"` // income or expense
+	OwnerType        string  `json:"owner_type"`
+	OwnerId          int64   `json:"owner_id"`
+	Budgeted         float64 `json:"budgeted"`
+	Actual           float64 `json:"actual"`
+	Variance         float64 `json:"variance"` // positive is favourable
+	PercentConsumed  float64 `json:"percent_consumed"`
+	AlertThreshold   float64 `json:"alert_threshold"`
+	Status           string  `json:"status"` // on_track, above_threshold, over_budget
+}
+
+// BudgetVarianceReport is the budget vs actual report for a fiscal year or month
+type BudgetVarianceReport struct {
+	FiscalYear       int                 `json:"fiscal_year"`
+	Month            int                 `json:"month,omitempty"`
+	Rows             []BudgetVarianceRow `json:"rows"`
+	BudgetedIncome   float64             `json:"budgeted_income"`
+	ActualIncome     float64             `json:"actual_income"`
+	BudgetedExpenses float64             `json:"budgeted_expenses"`
+	ActualExpenses   float64             `json:"actual_expenses"`
+	BudgetedNet      float64             `json:"budgeted_net"`
+	ActualNet        float64             `json:"actual_net"`
+	Headings         []BudgetHeadingRow  `json:"headings"`
+}
+
+// BudgetHeadingRow rolls the budget and actuals of sub-accounts up into a chart of accounts heading
+type BudgetHeadingRow struct {
+	FinanceAccountId int64   `json:"finance_account_id"`
+	ParentId         *int64  `json:"parent_id"`
+	AccountCode      string  `json:"account_code"`
+	AccountName      string  `json:"account_name"`
+	AccountKind      string  `json:"account_kind"`
+	Depth            int     `json:"depth"`
+	Budgeted         float64 `json:"budgeted"`
+	Actual           float64 `json:"actual"`
+	Variance         float64 `json:"variance"` // positive is favourable
+}
+
+// GetVarianceReportWithOwner compares budgets with actuals for a fiscal year, or a single month when month is 1-12
+func (s *BudgetService) GetVarianceReportWithOwner(fiscalYear, month int, ownerCtx *utils.OwnerContext) (*BudgetVarianceReport, error) {
+	if month < 0 || month > 12 {
+		return nil, errors.New("month must be between 1 and 12")
+	}
+
+	budgets, err := s.budgetRepo.ListForYearWithOwner(fiscalYear, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	from, to := budgetPeriod(fiscalYear, month)
+	report := &BudgetVarianceReport{FiscalYear: fiscalYear, Month: month, Rows: []BudgetVarianceRow{}}
+
+	for i := range budgets {
+		row := s.varianceRow(&budgets[i], month, from, to)
+		report.Rows = append(report.Rows, row)
+
+		if row.AccountKind == "income" {
+			report.BudgetedIncome += row.Budgeted
+			report.ActualIncome += row.Actual
+		} else {
+			report.BudgetedExpenses += row.Budgeted
+			report.ActualExpenses += row.Actual
+		}
+	}
+
+	report.BudgetedIncome = roundAmount(report.BudgetedIncome)
+	report.ActualIncome = roundAmount(report.ActualIncome)
+	report.BudgetedExpenses = roundAmount(report.BudgetedExpenses)
+	report.ActualExpenses = roundAmount(report.ActualExpenses)
+	report.BudgetedNet = roundAmount(report.BudgetedIncome - report.BudgetedExpenses)
+	report.ActualNet = roundAmount(report.ActualIncome - report.ActualExpenses)
+	report.Headings = s.budgetHeadings(report.Rows)
+
+	return report, nil
+}
+
+// budgetHeadings sums budgeted and actual amounts of the report rows under each heading
+func (s *BudgetService) budgetHeadings(rows []BudgetVarianceRow) []BudgetHeadingRow {
+	budgeted := make(map[int64]float64)
+	actual := make(map[int64]float64)
+	for _, row := range rows {
+		budgeted[row.FinanceAccountId] += row.Budgeted
+		actual[row.FinanceAccountId] += row.Actual
+	}
+
+	// Both maps have the same accounts, so the two roll-ups list the same headings in order
+	budgetedHeadings := accountHeadings(s.accountRepo.FindWithAncestors, budgeted)
+	actualHeadings := accountHeadings(s.accountRepo.FindWithAncestors, actual)
+
+	headings := make([]BudgetHeadingRow, 0, len(budgetedHeadings))
+	for i, heading := range budgetedHeadings {
+		row := BudgetHeadingRow{
+			FinanceAccountId: heading.FinanceAccountId,
+			ParentId:         heading.ParentId,
+			AccountCode:      heading.AccountCode,
+			AccountName:      heading.AccountName,
+			AccountKind:      "expense",
+			Depth:            heading.Depth,
+			Budgeted:         heading.Amount,
+		}
+		if i < len(actualHeadings) && actualHeadings[i].FinanceAccountId == heading.FinanceAccountId {
+			row.Actual = actualHeadings[i].Amount
+		}
+		if heading.AccountType == FinanceAccountTypeIncome {
+			row.AccountKind = "income"
+			row.Variance = roundAmount(row.Actual - row.Budgeted)
+		} else {
+			row.Variance = roundAmount(row.Budgeted - row.Actual)
+		}
+		headings = append(headings, row)
+	}
+	return headings
+}
+
+func (s *BudgetService) varianceRow(budget *models.Budget, month int, from, to time.Time) BudgetVarianceRow {
+	ownerType, ownerID := ledgerOwner(budget.OwnerType, budget.OwnerId)
+
+	row := BudgetVarianceRow{
+		BudgetId:         budget.ID,
+		FinanceAccountId: budget.FinanceAccountId,
+		AccountKind:      "expense",
+		OwnerType:        ownerType,
+		OwnerId:          ownerID,
+		Budgeted:         budgetForMonth(budget, month),
+		AlertThreshold:   budget.AlertThreshold,
+	}
+	if budget.FinanceAccount != nil {
+		row.AccountCode = stringValue(budget.FinanceAccount.Code)
+		row.AccountName = stringValue(budget.FinanceAccount.Name)
+		if budget.FinanceAccount.IsIncome != nil && *budget.FinanceAccount.IsIncome {
+			row.AccountKind = "income"
+		}
+	}
+
+	if row.AccountKind == "income" {
+		row.Actual = s.budgetRepo.SumIncome(budget.FinanceAccountId, ownerType, ownerID, from, to)
+		row.Variance = row.Actual - row.Budgeted
+	} else {
+		row.Actual = s.budgetRepo.SumExpenses(budget.FinanceAccountId, ownerType, ownerID, from, to)
+		row.Variance = row.Budgeted - row.Actual
+	}
+	row.Actual = roundAmount(row.Actual)
+	row.Variance = roundAmount(row.Variance)
+
+	if row.Budgeted > 0 {
+		row.PercentConsumed = math.Round(row.Actual/row.Budgeted*10000) / 100
+	}
+
+	switch {
+	case row.AccountKind == "expense" && row.PercentConsumed > 100:
+		row.Status = "over_budget"
+	case row.AccountKind == "expense" && row.PercentConsumed >= row.AlertThreshold:
+		row.Status = "above_threshold"
+	default:
+		row.Status = "on_track"
+	}
+
+	return row
+}
+
+// CheckExpenseThreshold raises a budget alert when approved spending on the expense's account
+// passes the budget's alert threshold, and again when it passes the full budget
+func (s *BudgetService) CheckExpenseThreshold(expense *models.FinanceExpense) {
+	if expense.BudgetAccountId == nil {
+		return
+	}
+
+	ownerType, ownerID := ledgerOwner(expense.OwnerType, expense.OwnerId)
+	fiscalYear := expense.TransactionDate.Year()
+
+	budget, err := s.budgetRepo.FindForAccount(*expense.BudgetAccountId, fiscalYear, ownerType, ownerID)
+	if err != nil || budget.Amount <= 0 {
+		return
+	}
+
+	from, to := budgetPeriod(fiscalYear, 0)
+	actual := s.budgetRepo.SumExpenses(budget.FinanceAccountId, ownerType, ownerID, from, to)
+	percent := math.Round(actual/budget.Amount*10000) / 100
+
+	for _, threshold := range []float64{budget.AlertThreshold, 100} {
+		if percent < threshold || s.budgetRepo.HasAlert(budget.ID, threshold) {
+			continue
+		}
+
+		message := fmt.Sprintf("Spending on account %d has reached %.2f%% of the %d budget (threshold %.0f%%)",
+			budget.FinanceAccountId, percent, fiscalYear, threshold)
+		if threshold >= 100 {
+			message = fmt.Sprintf("Spending on account %d has exceeded the %d budget: %.2f of %.2f",
+				budget.FinanceAccountId, fiscalYear, actual, budget.Amount)
+		}
+
+		alert := &models.BudgetAlert{
+			BudgetId:         budget.ID,
+			FinanceAccountId: budget.FinanceAccountId,
+			FiscalYear:       fiscalYear,
+			BudgetAmount:     budget.Amount,
+			ActualAmount:     roundAmount(actual),
+			PercentConsumed:  percent,
+			AlertThreshold:   threshold,
+			Message:          &message,
+		}
+		alert.SetOwner(ownerType, ownerID)
+
+		if err := s.budgetRepo.CreateAlert(alert); err != nil {
+			fmt.Printf("Error creating budget alert for budget %d: %v\n", budget.ID, err)
+		}
+	}
+}
+
+func (s *BudgetService) ListAlertsWithOwner(acknowledged *bool, page, limit int, ownerCtx *utils.OwnerContext) ([]models.BudgetAlert, int64, error) {
+	return s.budgetRepo.ListAlertsWithOwner(acknowledged, page, limit, ownerCtx)
+}
+
+func (s *BudgetService) AcknowledgeAlertWithOwner(id uint, acknowledgedBy *int64, ownerCtx *utils.OwnerContext) error {
+	return s.budgetRepo.AcknowledgeAlertWithOwner(id, acknowledgedBy, ownerCtx)
+}
+
+// budgetForMonth returns the budget for a month: the monthly split if set, otherwise an even share
+func budgetForMonth(budget *models.Budget, month int) float64 {
+	if month == 0 {
+		return budget.Amount
+	}
+	if budget.MonthlyAmounts != nil {
+		if months, err := parseMonthlyAmounts(budget.MonthlyAmounts); err == nil {
+			return months[month-1]
+		}
+	}
+	return roundAmount(budget.Amount / 12)
+}
+
+// budgetPeriod returns the half-open date range for a fiscal year, or one of its months
+func budgetPeriod(fiscalYear, month int) (time.Time, time.Time) {
+	if month == 0 {
+		from := time.Date(fiscalYear, time.January, 1, 0, 0, 0, 0, time.Local)
+		return from, from.AddDate(1, 0, 0)
+	}
+	from := time.Date(fiscalYear, time.Month(month), 1, 0, 0, 0, 0, time.Local)
+	return from, from.AddDate(0, 1, 0)
+}
+
+func parseMonthlyAmounts(jsonData *datatypes.JSON) ([]float64, error) {
+	var months []float64
+	if err := json.Unmarshal(*jsonData, &months); err != nil {
+		return nil, errors.New("monthly_amounts must be an array of 12 numbers")
+	}
+	if len(months) != 12 {
+		return nil, errors.New("monthly_amounts must be an array of 12 numbers")
+	}
+	return months, nil
+}
@@ -0,0 +1,783 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// School census statuses
+const (
+	CensusStatusDraft     = "draft"
+	CensusStatusSubmitted = "submitted"
+	CensusStatusVerified  = "verified"
+	CensusStatusReturned  = "returned" // sent back to the school for corrections
+)
+
+// Census field types
+const (
+	CensusFieldNumber  = "number"
+	CensusFieldText    = "text"
+	CensusFieldSelect  = "select"
+	CensusFieldBoolean = "boolean"
+)
+
+// Census report groupings
+const (
+	CensusGroupNational = "national"
+	CensusGroupRegion   = "region"
+	CensusGroupZone     = "zone"
+)
+
+// academicYearPattern matches an academic year such as 2025/2026
+var academicYearPattern = regexp.MustCompile(`^([0-9]{4})/([0-9]{4})$`)
+
+// censusFieldKeyPattern keeps field keys usable as JSON keys and report columns
+var censusFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+// CensusFieldSummary aggregates the answers to one census field. Number fields are totalled and
+// averaged over the schools that answered; select and boolean fields are counted per answer.
+type CensusFieldSummary struct {
+	FieldKey  string         `json:"field_key"`
+	Label     string         `json:"label"`
+	FieldType string         `json:"field_type"`
+	Unit      *string        `json:"unit,omitempty"`
+	Responses int            `json:"responses"`
+	Total     *float64       `json:"total,omitempty"`
+	Average   *float64       `json:"average,omitempty"`
+	Min       *float64       `json:"min,omitempty"`
+	Max       *float64       `json:"max,omitempty"`
+	Counts    map[string]int `json:"counts,omitempty"`
+}
+
+// CensusGroupSummary is the census of a zone, a region or the whole country
+type CensusGroupSummary struct {
+	ID              *int64               `json:"id"`
+	Name            string               `json:"name"`
+	SchoolsReported int                  `json:"schools_reported"`
+	SchoolsTotal    int64                `json:"schools_total"`
+	ResponseRate    float64              `json:"response_rate"` // percentage of member schools that reported
+	Fields          []CensusFieldSummary `json:"fields"`
+}
+
+// CensusReport is the census of an academic year, overall and by zone or region
+type CensusReport struct {
+	AcademicYear string               `json:"academic_year"`
+	GroupBy      string               `json:"group_by"`
+	Statuses     []string             `json:"statuses"`
+	Overall      CensusGroupSummary   `json:"overall"`
+	Groups       []CensusGroupSummary `json:"groups"`
+}
+
+// CensusChange compares a number field's total with the previous academic year
+type CensusChange struct {
+	Previous      float64  `json:"previous"`
+	Change        float64  `json:"change"`
+	PercentChange *float64 `json:"percent_change"` // nil when the previous total was zero
+}
+
+// CensusTrendYear is one academic year of a census trend
+type CensusTrendYear struct {
+	AcademicYear    string                   `json:"academic_year"`
+	SchoolsReported int                      `json:"schools_reported"`
+	Fields          []CensusFieldSummary     `json:"fields"`
+	Changes         map[string]*CensusChange `json:"changes"` // by field key; empty for the first year
+}
+
+type CensusService struct {
+	censusRepo *repositories.CensusRepository
+	schoolRepo *repositories.SchoolRepository
+}
+
+func NewCensusService(censusRepo *repositories.CensusRepository, schoolRepo *repositories.SchoolRepository) *CensusService {
+	return &CensusService{censusRepo: censusRepo, schoolRepo: schoolRepo}
+}
+
+// ListFields returns the census form; retired fields are only included when asked for
+func (s *CensusService) ListFields(includeInactive bool, ownerCtx *utils.OwnerContext) ([]models.CensusField, error) {
+	if ownerCtx == nil {
+		return nil, errors.New("access denied")
+	}
+	return s.censusRepo.ListFields(!includeInactive)
+}
+
+// CreateField adds a field to the census form
+func (s *CensusService) CreateField(field *models.CensusField, ownerCtx *utils.OwnerContext) error {
+	if err := canConfigureCensus(ownerCtx); err != nil {
+		return err
+	}
+
+	field.FieldKey = strings.ToLower(strings.TrimSpace(field.FieldKey))
+	if !censusFieldKeyPattern.MatchString(field.FieldKey) {
+		return errors.New("field_key must start with a letter and contain only lowercase letters, digits and underscores")
+	}
+	exists, err := s.censusRepo.FieldKeyExists(field.FieldKey, nil)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("field_key already exists")
+	}
+
+	field.Label = strings.TrimSpace(field.Label)
+	if field.Label == "" {
+		return errors.New("label is required")
+	}
+	if field.FieldType == "" {
+		field.FieldType = CensusFieldNumber
+	}
+	if err := validateCensusFieldType(field.FieldType, field.Options); err != nil {
+		return err
+	}
+	field.IsActive = true
+
+	return s.censusRepo.CreateField(field)
+}
+
+// UpdateField changes a census field. The key cannot change since earlier answers are stored by key;
+// retire a field with is_active false instead of deleting it.
+func (s *CensusService) UpdateField(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
+	if err := canConfigureCensus(ownerCtx); err != nil {
+		return err
+	}
+	field, err := s.censusRepo.FindField(id)
+	if err != nil {
+		return errors.New("census field not found")
+	}
+	if _, ok := updates["field_key"]; ok {
+		return errors.New("field_key cannot be changed")
+	}
+	if label, ok := updates["label"].(string); ok && strings.TrimSpace(label) == "" {
+		return errors.New("label is required")
+	}
+
+	fieldType := field.FieldType
+	if value, ok := updates["field_type"].(string); ok {
+		fieldType = value
+	}
+	options := field.Options
+	if value, ok := updates["options"].(*datatypes.JSON); ok {
+		options = value
+	}
+	if err := validateCensusFieldType(fieldType, options); err != nil {
+		return err
+	}
+
+	return s.censusRepo.UpdateField(id, updates)
+}
+
+// GetCensus returns a school's census for an academic year. School admins see their own school's.
+func (s *CensusService) GetCensus(schoolID uint, academicYear string, ownerCtx *utils.OwnerContext) (*models.SchoolCensus, error) {
+	school, err := s.censusSchool(schoolID, ownerCtx, false)
+	if err != nil {
+		return nil, err
+	}
+	census, err := s.censusRepo.FindCensus(school.ID, academicYear)
+	if err != nil {
+		return nil, errors.New("census not found")
+	}
+	return census, nil
+}
+
+// GetCensusByID returns a census of a school accessible by the user's role
+func (s *CensusService) GetCensusByID(id uint, ownerCtx *utils.OwnerContext) (*models.SchoolCensus, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	census, err := s.censusRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("census not found")
+	}
+	return census, nil
+}
+
+// ListCensusesWithRole lists census returns of the schools accessible by the user's role; school
+// admins only see their own school's
+func (s *CensusService) ListCensusesWithRole(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.SchoolCensus, int64, error) {
+	if ownerCtx != nil && ownerCtx.Role == utils.RoleSchoolAdmin {
+		school, err := s.schoolRepo.FindByUserID(ownerCtx.UserID)
+		if err != nil {
+			return nil, 0, errors.New("school not found")
+		}
+		filters["school_id"] = school.ID
+		return s.censusRepo.ListWithRoleFilter(filters, page, limit, nil, nil)
+	}
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+	return s.censusRepo.ListWithRoleFilter(filters, page, limit, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+// SaveCensus stores a school's answers for an academic year, replacing earlier answers, and submits
+// them for verification when submit is true. Required fields are only enforced on submission so a
+// school can save a partly completed census. A submitted or verified census cannot be changed
+// until it is returned.
+func (s *CensusService) SaveCensus(schoolID uint, academicYear string, data map[string]interface{}, submit bool, userID *int64, ownerCtx *utils.OwnerContext) (*models.SchoolCensus, error) {
+	if err := validateAcademicYear(academicYear); err != nil {
+		return nil, err
+	}
+	school, err := s.censusSchool(schoolID, ownerCtx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	census, err := s.censusRepo.FindCensus(school.ID, academicYear)
+	if err != nil {
+		census = &models.SchoolCensus{SchoolId: int64(school.ID), AcademicYear: academicYear, Status: CensusStatusDraft}
+	}
+	switch census.Status {
+	case CensusStatusSubmitted:
+		return nil, errors.New("census has already been submitted")
+	case CensusStatusVerified:
+		return nil, errors.New("census has been verified")
+	}
+
+	fields, err := s.censusRepo.ListFields(true)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := validateCensusAnswers(fields, data, submit)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(answers)
+	if err != nil {
+		return nil, err
+	}
+	censusData := datatypes.JSON(encoded)
+
+	census.Data = &censusData
+	census.ZoneId = school.ZoneId
+	if submit {
+		now := time.Now()
+		census.Status = CensusStatusSubmitted
+		census.SubmittedAt = &now
+		census.SubmittedBy = userID
+	}
+
+	if err := s.censusRepo.SaveCensus(census); err != nil {
+		return nil, err
+	}
+	return census, nil
+}
+
+// VerifyCensus accepts a submitted census into the association's statistics
+func (s *CensusService) VerifyCensus(id uint, notes *string, verifiedBy *int64, ownerCtx *utils.OwnerContext) error {
+	if err := s.reviewableCensus(id, ownerCtx); err != nil {
+		return err
+	}
+
+	err := s.censusRepo.UpdateStatus(id, []string{CensusStatusSubmitted}, map[string]interface{}{
+		"status":       CensusStatusVerified,
+		"verified_at":  time.Now(),
+		"verified_by":  verifiedBy,
+		"review_notes": notes,
+	})
+	if err != nil {
+		return errors.New("census is not awaiting verification")
+	}
+	return nil
+}
+
+// ReturnCensus sends a submitted or verified census back to the school for corrections
+func (s *CensusService) ReturnCensus(id uint, notes string, ownerCtx *utils.OwnerContext) error {
+	if strings.TrimSpace(notes) == "" {
+		return errors.New("review notes are required when returning a census")
+	}
+	if err := s.reviewableCensus(id, ownerCtx); err != nil {
+		return err
+	}
+
+	err := s.censusRepo.UpdateStatus(id, []string{CensusStatusSubmitted, CensusStatusVerified}, map[string]interface{}{
+		"status":       CensusStatusReturned,
+		"verified_at":  nil,
+		"verified_by":  nil,
+		"review_notes": notes,
+	})
+	if err != nil {
+		return errors.New("census is not awaiting verification")
+	}
+	return nil
+}
+
+// ListYears returns the academic years with census returns, newest first
+func (s *CensusService) ListYears(ownerCtx *utils.OwnerContext) ([]string, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	return s.censusRepo.ListYears()
+}
+
+// Summary reports an academic year's census overall and by region or zone, within the user's
+// region or zone and optionally narrowed to one region or zone. Only verified returns count unless
+// includeUnverified also takes in submitted ones.
+func (s *CensusService) Summary(academicYear, groupBy string, regionID, zoneID *int64, includeUnverified bool, ownerCtx *utils.OwnerContext) (*CensusReport, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	if err := validateAcademicYear(academicYear); err != nil {
+		return nil, err
+	}
+	regionID, zoneID = censusScope(regionID, zoneID, ownerCtx)
+	if groupBy == "" {
+		groupBy = CensusGroupRegion
+		if regionID != nil || zoneID != nil {
+			groupBy = CensusGroupZone
+		}
+	}
+	if groupBy != CensusGroupNational && groupBy != CensusGroupRegion && groupBy != CensusGroupZone {
+		return nil, errors.New("group_by must be national, region or zone")
+	}
+
+	statuses := censusReportStatuses(includeUnverified)
+	fields, err := s.censusRepo.ListFields(false)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.censusRepo.ListForReport(academicYear, statuses, regionID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.censusRepo.CountSchoolsByZone(regionID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	overall := newCensusAggregate(nil, "National")
+	if len(rows) > 0 {
+		if zoneID != nil {
+			overall.id, overall.name = zoneID, stringValue(rows[0].ZoneName)
+		} else if regionID != nil {
+			overall.id, overall.name = regionID, stringValue(rows[0].RegionName)
+		}
+	}
+	groups := map[string]*censusAggregate{}
+	var order []string
+	for _, row := range rows {
+		overall.add(row.Data)
+		if groupBy == CensusGroupNational {
+			continue
+		}
+
+		id, name := row.ZoneId, row.ZoneName
+		if groupBy == CensusGroupRegion {
+			id, name = row.RegionId, row.RegionName
+		}
+		key := censusGroupKey(id)
+		group, ok := groups[key]
+		if !ok {
+			group = newCensusAggregate(id, stringValue(name))
+			if group.name == "" {
+				group.name = "Unassigned"
+			}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.add(row.Data)
+	}
+
+	for _, count := range counts {
+		overall.schoolsTotal += count.Schools
+		id := &count.ZoneId
+		if groupBy == CensusGroupRegion {
+			id = count.RegionId
+		}
+		if group, ok := groups[censusGroupKey(id)]; ok {
+			group.schoolsTotal += count.Schools
+		}
+	}
+
+	report := &CensusReport{
+		AcademicYear: academicYear,
+		GroupBy:      groupBy,
+		Statuses:     statuses,
+		Overall:      overall.summary(fields),
+		Groups:       make([]CensusGroupSummary, 0, len(order)),
+	}
+	for _, key := range order {
+		report.Groups = append(report.Groups, groups[key].summary(fields))
+	}
+	sort.SliceStable(report.Groups, func(i, j int) bool { return report.Groups[i].Name < report.Groups[j].Name })
+	return report, nil
+}
+
+// Trends reports the census year by year for the country, a region or a zone, with the change in
+// each number field's total from the year before
+func (s *CensusService) Trends(regionID, zoneID *int64, includeUnverified bool, ownerCtx *utils.OwnerContext) ([]CensusTrendYear, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	regionID, zoneID = censusScope(regionID, zoneID, ownerCtx)
+
+	fields, err := s.censusRepo.ListFields(false)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.censusRepo.ListForReport("", censusReportStatuses(includeUnverified), regionID, zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	years := map[string]*censusAggregate{}
+	var order []string
+	for _, row := range rows {
+		year, ok := years[row.AcademicYear]
+		if !ok {
+			year = newCensusAggregate(nil, row.AcademicYear)
+			years[row.AcademicYear] = year
+			order = append(order, row.AcademicYear)
+		}
+		year.add(row.Data)
+	}
+	sort.Strings(order)
+
+	trend := make([]CensusTrendYear, 0, len(order))
+	previous := map[string]float64{}
+	for i, academicYear := range order {
+		summary := years[academicYear].summary(fields)
+		entry := CensusTrendYear{
+			AcademicYear:    academicYear,
+			SchoolsReported: summary.SchoolsReported,
+			Fields:          summary.Fields,
+			Changes:         map[string]*CensusChange{},
+		}
+
+		totals := map[string]float64{}
+		for _, field := range summary.Fields {
+			if field.Total == nil || field.Responses == 0 {
+				continue
+			}
+			totals[field.FieldKey] = *field.Total
+			last, ok := previous[field.FieldKey]
+			if i == 0 || !ok {
+				continue
+			}
+			change := &CensusChange{Previous: last, Change: roundAmount(*field.Total - last)}
+			if last != 0 {
+				percent := roundAmount(change.Change / last * 100)
+				change.PercentChange = &percent
+			}
+			entry.Changes[field.FieldKey] = change
+		}
+		previous = totals
+
+		trend = append(trend, entry)
+	}
+	return trend, nil
+}
+
+// censusSchool finds the school a census is for: a school admin's own school, or for other roles a
+// school they can view (or manage, when writing)
+func (s *CensusService) censusSchool(schoolID uint, ownerCtx *utils.OwnerContext, write bool) (*models.School, error) {
+	if ownerCtx != nil && ownerCtx.Role == utils.RoleSchoolAdmin {
+		school, err := s.schoolRepo.FindByUserID(ownerCtx.UserID)
+		if err != nil || (schoolID != 0 && schoolID != school.ID) {
+			return nil, errors.New("school not found")
+		}
+		return school, nil
+	}
+
+	check := canViewSchoolRecords
+	if write {
+		check = canManageSchoolRecords
+	}
+	if err := check(ownerCtx); err != nil {
+		return nil, err
+	}
+	if schoolID == 0 {
+		return nil, errors.New("school_id is required")
+	}
+	school, err := s.schoolRepo.FindByIDWithRoleFilter(schoolID, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("school not found")
+	}
+	return school, nil
+}
+
+// reviewableCensus checks that the user may verify or return a census
+func (s *CensusService) reviewableCensus(id uint, ownerCtx *utils.OwnerContext) error {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return err
+	}
+	if _, err := s.censusRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return errors.New("census not found")
+	}
+	return nil
+}
+
+// canConfigureCensus allows national admins to change the census form
+func canConfigureCensus(ownerCtx *utils.OwnerContext) error {
+	if ownerCtx == nil || !ownerCtx.IsNationalAdmin() {
+		return errors.New("access denied")
+	}
+	return nil
+}
+
+// censusScope narrows a requested region or zone to the user's own region or zone
+func censusScope(regionID, zoneID *int64, ownerCtx *utils.OwnerContext) (*int64, *int64) {
+	if roleRegion := ownerCtx.GetRegionIDFilter(); roleRegion != nil {
+		regionID = roleRegion
+	}
+	if roleZone := ownerCtx.GetZoneIDFilter(); roleZone != nil {
+		zoneID = roleZone
+	}
+	return regionID, zoneID
+}
+
+func censusReportStatuses(includeUnverified bool) []string {
+	if includeUnverified {
+		return []string{CensusStatusVerified, CensusStatusSubmitted}
+	}
+	return []string{CensusStatusVerified}
+}
+
+func censusGroupKey(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
+
+func validateAcademicYear(academicYear string) error {
+	match := academicYearPattern.FindStringSubmatch(academicYear)
+	if match == nil {
+		return errors.New("academic_year must be like 2025/2026")
+	}
+	start, _ := strconv.Atoi(match[1])
+	end, _ := strconv.Atoi(match[2])
+	if end != start+1 {
+		return errors.New("academic_year must span two consecutive years")
+	}
+	return nil
+}
+
+func validateCensusFieldType(fieldType string, options *datatypes.JSON) error {
+	switch fieldType {
+	case CensusFieldNumber, CensusFieldText, CensusFieldBoolean:
+		return nil
+	case CensusFieldSelect:
+		if len(censusFieldOptions(options)) == 0 {
+			return errors.New("a select field needs a list of options")
+		}
+		return nil
+	}
+	return errors.New("field_type must be number, text, select or boolean")
+}
+
+func censusFieldOptions(options *datatypes.JSON) []string {
+	if options == nil {
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(*options, &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// validateCensusAnswers checks answers against the census form and converts them to their field's
+// type; blank answers are dropped
+func validateCensusAnswers(fields []models.CensusField, data map[string]interface{}, requireAll bool) (map[string]interface{}, error) {
+	byKey := make(map[string]models.CensusField, len(fields))
+	for _, field := range fields {
+		byKey[field.FieldKey] = field
+	}
+
+	answers := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		field, ok := byKey[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown census field %s", key)
+		}
+		if value == nil {
+			continue
+		}
+		if text, ok := value.(string); ok && strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		answer, err := censusAnswer(field, value)
+		if err != nil {
+			return nil, err
+		}
+		answers[key] = answer
+	}
+
+	if requireAll {
+		for _, field := range fields {
+			if _, ok := answers[field.FieldKey]; field.IsRequired && !ok {
+				return nil, fmt.Errorf("%s is required", field.Label)
+			}
+		}
+	}
+	return answers, nil
+}
+
+func censusAnswer(field models.CensusField, value interface{}) (interface{}, error) {
+	switch field.FieldType {
+	case CensusFieldNumber:
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a number", field.Label)
+			}
+			number = parsed
+		default:
+			return nil, fmt.Errorf("%s must be a number", field.Label)
+		}
+		if number < 0 || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("%s cannot be negative", field.Label)
+		}
+		return number, nil
+	case CensusFieldBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			parsed, err := strconv.ParseBool(v)
+			if err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("%s must be true or false", field.Label)
+	case CensusFieldSelect:
+		text, ok := value.(string)
+		if ok {
+			for _, option := range censusFieldOptions(field.Options) {
+				if option == text {
+					return text, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%s must be one of the listed options", field.Label)
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s must be text", field.Label)
+		}
+		return strings.TrimSpace(text), nil
+	}
+}
+
+// censusAggregate accumulates census returns for a report
+type censusAggregate struct {
+	id           *int64
+	name         string
+	reported     int
+	schoolsTotal int64
+	numbers      map[string][]float64
+	counts       map[string]map[string]int
+	responses    map[string]int
+}
+
+func newCensusAggregate(id *int64, name string) *censusAggregate {
+	return &censusAggregate{
+		id:        id,
+		name:      name,
+		numbers:   map[string][]float64{},
+		counts:    map[string]map[string]int{},
+		responses: map[string]int{},
+	}
+}
+
+func (a *censusAggregate) add(data *datatypes.JSON) {
+	a.reported++
+	if data == nil {
+		return
+	}
+	var answers map[string]interface{}
+	if err := json.Unmarshal(*data, &answers); err != nil {
+		return
+	}
+
+	for key, value := range answers {
+		a.responses[key]++
+		switch v := value.(type) {
+		case float64:
+			a.numbers[key] = append(a.numbers[key], v)
+		case bool:
+			a.count(key, strconv.FormatBool(v))
+		case string:
+			a.count(key, v)
+		}
+	}
+}
+
+func (a *censusAggregate) count(key, answer string) {
+	if a.counts[key] == nil {
+		a.counts[key] = map[string]int{}
+	}
+	a.counts[key][answer]++
+}
+
+// summary reports the active fields and any retired field that was answered; free text is not
+// aggregated
+func (a *censusAggregate) summary(fields []models.CensusField) CensusGroupSummary {
+	summary := CensusGroupSummary{
+		ID:              a.id,
+		Name:            a.name,
+		SchoolsReported: a.reported,
+		SchoolsTotal:    a.schoolsTotal,
+		Fields:          []CensusFieldSummary{},
+	}
+	if a.schoolsTotal > 0 {
+		summary.ResponseRate = roundAmount(float64(a.reported) / float64(a.schoolsTotal) * 100)
+	}
+
+	for _, field := range fields {
+		if field.FieldType == CensusFieldText || (!field.IsActive && a.responses[field.FieldKey] == 0) {
+			continue
+		}
+		fieldSummary := CensusFieldSummary{
+			FieldKey:  field.FieldKey,
+			Label:     field.Label,
+			FieldType: field.FieldType,
+			Unit:      field.Unit,
+			Responses: a.responses[field.FieldKey],
+		}
+
+		if field.FieldType == CensusFieldNumber {
+			values := a.numbers[field.FieldKey]
+			total := 0.0
+			for _, value := range values {
+				total += value
+			}
+			total = roundAmount(total)
+			fieldSummary.Total = &total
+			if len(values) > 0 {
+				average := roundAmount(total / float64(len(values)))
+				minimum, maximum := values[0], values[0]
+				for _, value := range values[1:] {
+					minimum = math.Min(minimum, value)
+					maximum = math.Max(maximum, value)
+				}
+				fieldSummary.Average = &average
+				fieldSummary.Min = &minimum
+				fieldSummary.Max = &maximum
+			}
+		} else {
+			fieldSummary.Counts = a.counts[field.FieldKey]
+			if fieldSummary.Counts == nil {
+				fieldSummary.Counts = map[string]int{}
+			}
+		}
+
+		summary.Fields = append(summary.Fields, fieldSummary)
+	}
+	return summary
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/utils"
+)
+
+// standardChartAccount is one account of the standard association chart of accounts. Codes
+// are suffixed with the owner when installed (e.g. 4120-Z12).
+type standardChartAccount struct {
+	Code        string
+	Name        string
+	AccountType string
+	Parent      string
+	IsHeader    bool
+	SystemRole  string
+}
+
+// standardChartOfAccounts lists headings before their sub-accounts. Accounts with a system
+// role are the ones the ledger posts to automatically, so an owner's existing system
+// accounts are adopted instead of duplicated.
+var standardChartOfAccounts = []standardChartAccount{
+	{Code: "1", Name: "Assets", AccountType: FinanceAccountTypeAsset, IsHeader: true},
+	{Code: "10", Name: "Cash and Bank", AccountType: FinanceAccountTypeAsset, Parent: "1", IsHeader: true},
+	{Code: "1000", Name: "Cash on Hand", AccountType: FinanceAccountTypeAsset, Parent: "10", SystemRole: LedgerRoleCash},
+	{Code: "1010", Name: "Mobile Money Wallet", AccountType: FinanceAccountTypeAsset, Parent: "10", SystemRole: LedgerRoleMomo},
+	{Code: "1020", Name: "Bank Account", AccountType: FinanceAccountTypeAsset, Parent: "10", SystemRole: LedgerRoleBank},
+	{Code: "11", Name: "Receivables", AccountType: FinanceAccountTypeAsset, Parent: "1", IsHeader: true},
+	{Code: "1100", Name: "Dues Receivable", AccountType: FinanceAccountTypeAsset, Parent: "11", SystemRole: LedgerRoleReceivable},
+	{Code: "1110", Name: "Remittances Receivable", AccountType: FinanceAccountTypeAsset, Parent: "11"},
+
+	{Code: "2", Name: "Liabilities", AccountType: FinanceAccountTypeLiability, IsHeader: true},
+	{Code: "20", Name: "Current Liabilities", AccountType: FinanceAccountTypeLiability, Parent: "2", IsHeader: true},
+	{Code: "2000", Name: "Accounts Payable", AccountType: FinanceAccountTypeLiability, Parent: "20", SystemRole: LedgerRolePayable},
+	{Code: "2010", Name: "Remittances Payable", AccountType: FinanceAccountTypeLiability, Parent: "20"},
+
+	{Code: "3", Name: "Equity", AccountType: FinanceAccountTypeEquity, IsHeader: true},
+	{Code: "3000", Name: "Accumulated Fund", AccountType: FinanceAccountTypeEquity, Parent: "3"},
+
+	{Code: "4", Name: "Income", AccountType: FinanceAccountTypeIncome, IsHeader: true},
+	{Code: "40", Name: "General Income", AccountType: FinanceAccountTypeIncome, Parent: "4", IsHeader: true},
+	{Code: "4000", Name: "General Income", AccountType: FinanceAccountTypeIncome, Parent: "40", SystemRole: LedgerRoleIncome},
+	{Code: "41", Name: "Dues Income", AccountType: FinanceAccountTypeIncome, Parent: "4", IsHeader: true},
+	{Code: "4100", Name: "National Dues", AccountType: FinanceAccountTypeIncome, Parent: "41"},
+	{Code: "4110", Name: "Regional Dues", AccountType: FinanceAccountTypeIncome, Parent: "41"},
+	{Code: "4120", Name: "Zonal Dues", AccountType: FinanceAccountTypeIncome, Parent: "41"},
+	{Code: "42", Name: "Event Income", AccountType: FinanceAccountTypeIncome, Parent: "4", IsHeader: true},
+	{Code: "4200", Name: "Event Registration Fees", AccountType: FinanceAccountTypeIncome, Parent: "42"},
+	{Code: "43", Name: "Other Income", AccountType: FinanceAccountTypeIncome, Parent: "4", IsHeader: true},
+	{Code: "4300", Name: "Donations and Grants", AccountType: FinanceAccountTypeIncome, Parent: "43"},
+	{Code: "4310", Name: "Sponsorship", AccountType: FinanceAccountTypeIncome, Parent: "43"},
+	{Code: "49", Name: "Refunds", AccountType: FinanceAccountTypeIncome, Parent: "4", IsHeader: true},
+	{Code: "4900", Name: "Refunds to Schools", AccountType: FinanceAccountTypeIncome, Parent: "49", SystemRole: LedgerRoleRefund},
+
+	{Code: "5", Name: "Expenses", AccountType: FinanceAccountTypeExpense, IsHeader: true},
+	{Code: "50", Name: "Administrative Expenses", AccountType: FinanceAccountTypeExpense, Parent: "5", IsHeader: true},
+	{Code: "5000", Name: "General Expenses", AccountType: FinanceAccountTypeExpense, Parent: "50", SystemRole: LedgerRoleExpense},
+	{Code: "5010", Name: "Office Supplies", AccountType: FinanceAccountTypeExpense, Parent: "50"},
+	{Code: "5020", Name: "Communication", AccountType: FinanceAccountTypeExpense, Parent: "50"},
+	{Code: "5030", Name: "Bank and MoMo Charges", AccountType: FinanceAccountTypeExpense, Parent: "50"},
+	{Code: "51", Name: "Meetings and Events", AccountType: FinanceAccountTypeExpense, Parent: "5", IsHeader: true},
+	{Code: "5100", Name: "Event Expenses", AccountType: FinanceAccountTypeExpense, Parent: "51"},
+	{Code: "5110", Name: "Transport and Travel", AccountType: FinanceAccountTypeExpense, Parent: "51"},
+	{Code: "5120", Name: "Meals and Refreshments", AccountType: FinanceAccountTypeExpense, Parent: "51"},
+	{Code: "52", Name: "Allowances", AccountType: FinanceAccountTypeExpense, Parent: "5", IsHeader: true},
+	{Code: "5200", Name: "Executive Allowances", AccountType: FinanceAccountTypeExpense, Parent: "52"},
+	{Code: "5210", Name: "Honoraria", AccountType: FinanceAccountTypeExpense, Parent: "52"},
+	{Code: "59", Name: "Discounts", AccountType: FinanceAccountTypeExpense, Parent: "5", IsHeader: true},
+	{Code: "5900", Name: "Discounts Allowed", AccountType: FinanceAccountTypeExpense, Parent: "59", SystemRole: LedgerRoleDiscount},
+}
+
+// ChartSeedResult reports what installing the standard chart changed for one owner
+type ChartSeedResult struct {
+	OwnerType string `json:"owner_type"`
+	OwnerId   int64  `json:"owner_id"`
+	Created   int    `json:"created"`
+	Updated   int    `json:"updated"`
+}
+
+// SeedStandardChart installs the standard chart of accounts for an owner. It can be run more
+// than once: accounts that already exist (matched by code, or by ledger role for system
+// accounts) are attached to their headings rather than created again.
+func (s *FinanceAccountService) SeedStandardChart(ownerType string, ownerID int64) (*ChartSeedResult, error) {
+	switch ownerType {
+	case utils.OwnerTypeNational, utils.OwnerTypeRegion, utils.OwnerTypeZone:
+	default:
+		return nil, errors.New("owner_type must be national, region or zone")
+	}
+	if ownerID <= 0 {
+		return nil, errors.New("owner_id is required")
+	}
+
+	result := &ChartSeedResult{OwnerType: ownerType, OwnerId: ownerID}
+	installed := make(map[string]int64)
+
+	for _, def := range standardChartOfAccounts {
+		code := ownerAccountCode(def.Code, ownerType, ownerID)
+		isHeader := def.IsHeader
+		isIncome := def.AccountType == FinanceAccountTypeIncome
+
+		var parentID *int64
+		if def.Parent != "" {
+			id, ok := installed[def.Parent]
+			if !ok {
+				return nil, errors.New("standard chart lists " + def.Code + " before its heading " + def.Parent)
+			}
+			parentID = &id
+		}
+
+		existing, err := s.accountRepo.FindByCode(code)
+		if err != nil && def.SystemRole != "" {
+			existing, err = s.accountRepo.FindSystemAccount(def.SystemRole, ownerType, ownerID)
+		}
+
+		if err == nil {
+			updates := map[string]interface{}{
+				"parent_id":    parentID,
+				"is_header":    isHeader,
+				"account_type": def.AccountType,
+				"is_income":    isIncome,
+			}
+			if err := s.accountRepo.Update(existing.ID, updates); err != nil {
+				return nil, err
+			}
+			installed[def.Code] = int64(existing.ID)
+			result.Updated++
+			continue
+		}
+
+		name := def.Name
+		accountType := def.AccountType
+		account := &models.FinanceAccount{
+			Name:        &name,
+			Code:        &code,
+			AccountType: &accountType,
+			IsIncome:    &isIncome,
+			IsHeader:    &isHeader,
+			ParentId:    parentID,
+		}
+		if def.SystemRole != "" {
+			systemRole := def.SystemRole
+			account.SystemRole = &systemRole
+		}
+		account.SetOwner(ownerType, ownerID)
+
+		if err := s.accountRepo.Create(account); err != nil {
+			return nil, err
+		}
+		installed[def.Code] = int64(account.ID)
+		result.Created++
+	}
+
+	return result, nil
+}
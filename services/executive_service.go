@@ -1,23 +1,59 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"gnaps-api/models"
 	"gnaps-api/repositories"
 	"gnaps-api/utils"
+	"log"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultExecutiveInviteHours = 72
+	minPasswordLength           = 8
 )
 
+// InviteDelivery reports an invite sent to an executive and the channels it went out on
+type InviteDelivery struct {
+	InviteId  uint      `json:"invite_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Channels  []string  `json:"channels"` // sms and/or email; empty when neither could be used
+}
+
+// InviteDetails is what the invite page shows before the executive sets a password
+type InviteDetails struct {
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type ExecutiveService struct {
 	executiveRepo *repositories.ExecutiveRepository
 	userRepo      *repositories.UserRepository
+	inviteRepo    *repositories.ExecutiveInviteRepository
+	termRepo      *repositories.ExecutiveTermRepository
+	smsService    *SmsService
 }
 
-func NewExecutiveService(executiveRepo *repositories.ExecutiveRepository, userRepo *repositories.UserRepository) *ExecutiveService {
+func NewExecutiveService(executiveRepo *repositories.ExecutiveRepository, userRepo *repositories.UserRepository, inviteRepo *repositories.ExecutiveInviteRepository, termRepo *repositories.ExecutiveTermRepository, smsService *SmsService) *ExecutiveService {
 	return &ExecutiveService{
 		executiveRepo: executiveRepo,
 		userRepo:      userRepo,
+		inviteRepo:    inviteRepo,
+		termRepo:      termRepo,
+		smsService:    smsService,
 	}
 }
 
@@ -33,19 +69,23 @@ func (s *ExecutiveService) ListExecutives(filters map[string]interface{}, page,
 	return s.executiveRepo.List(filters, page, limit)
 }
 
-func (s *ExecutiveService) CreateExecutive(executive *models.Executive) error {
+// CreateExecutive creates the executive, their user account and a term of office in their position
+// starting today, then invites them to set a password.
+// The account can't sign in until the invite is accepted; a failed delivery can be retried with
+// ResendInvite.
+func (s *ExecutiveService) CreateExecutive(executive *models.Executive, invitedBy *int64) (*InviteDelivery, error) {
 	// Validate required fields
 	if executive.FirstName == nil || *executive.FirstName == "" {
-		return errors.New("first name is required")
+		return nil, errors.New("first name is required")
 	}
 	if executive.LastName == nil || *executive.LastName == "" {
-		return errors.New("last name is required")
+		return nil, errors.New("last name is required")
 	}
 	if executive.Email == nil || *executive.Email == "" {
-		return errors.New("email is required")
+		return nil, errors.New("email is required")
 	}
 	if executive.Role == nil || *executive.Role == "" {
-		return errors.New("role is required")
+		return nil, errors.New("role is required")
 	}
 
 	// Validate role
@@ -58,19 +98,19 @@ func (s *ExecutiveService) CreateExecutive(executive *models.Executive) error {
 		}
 	}
 	if !isValidRole {
-		return errors.New("invalid role. Must be one of: national_admin, region_admin, zone_admin")
+		return nil, errors.New("invalid role. Must be one of: national_admin, region_admin, zone_admin")
 	}
 
 	// Validate role-based assignments
 	if *executive.Role == "region_admin" && (executive.RegionId == nil || *executive.RegionId == 0) {
-		return errors.New("region is required for region admin")
+		return nil, errors.New("region is required for region admin")
 	}
 	if *executive.Role == "zone_admin" {
 		if executive.RegionId == nil || *executive.RegionId == 0 {
-			return errors.New("region is required for zonal admin")
+			return nil, errors.New("region is required for zonal admin")
 		}
 		if executive.ZoneId == nil || *executive.ZoneId == 0 {
-			return errors.New("zone is required for zonal admin")
+			return nil, errors.New("zone is required for zonal admin")
 		}
 	}
 
@@ -82,20 +122,20 @@ func (s *ExecutiveService) CreateExecutive(executive *models.Executive) error {
 		// Check if executive_no already exists
 		exists, err := s.executiveRepo.ExecutiveNoExists(*executive.ExecutiveNo, nil)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if exists {
-			return errors.New("executive with this executive number already exists")
+			return nil, errors.New("executive with this executive number already exists")
 		}
 	}
 
 	// Check if email already exists
 	exists, err := s.executiveRepo.EmailExists(*executive.Email, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if exists {
-		return errors.New("executive with this email already exists")
+		return nil, errors.New("executive with this email already exists")
 	}
 
 	// Validate gender if provided
@@ -109,7 +149,7 @@ func (s *ExecutiveService) CreateExecutive(executive *models.Executive) error {
 			}
 		}
 		if !isValid {
-			return errors.New("invalid gender. Must be one of: Male, Female, Other")
+			return nil, errors.New("invalid gender. Must be one of: Male, Female, Other")
 		}
 	}
 
@@ -128,23 +168,49 @@ func (s *ExecutiveService) CreateExecutive(executive *models.Executive) error {
 		mobileNo = *executive.MobileNo
 	}
 
-	user, err := s.userRepo.CreateUserForExecutive(
-		*executive.FirstName,
-		*executive.LastName,
-		*executive.Email,
-		mobileNo,
-		*executive.Role,
-		*executive.ExecutiveNo, // Password will be executive_no + "123"
-	)
+	// The account, the executive, their term and the invite are saved together, so a failure leaves
+	// nothing behind and the email can be used again
+	var user *models.User
+	var invite *models.ExecutiveInvite
+	var token string
+	err = s.executiveRepo.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = s.userRepo.WithTx(tx).CreateUserForExecutive(
+			*executive.FirstName,
+			*executive.LastName,
+			*executive.Email,
+			mobileNo,
+			*executive.Role,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create user account: %v", err)
+		}
+
+		// Set the user_id on the executive
+		userID := int64(user.ID)
+		executive.UserId = &userID
+
+		if err := s.executiveRepo.WithTx(tx).Create(executive); err != nil {
+			return err
+		}
+
+		// The executive's access comes from their term of office, which starts today
+		if err := s.termRepo.WithTx(tx).Create(executiveTerm(executive, invitedBy)); err != nil {
+			return fmt.Errorf("failed to start term of office: %v", err)
+		}
+
+		invite, token, err = createInvite(s.inviteRepo.WithTx(tx), user, int64(executive.ID), nil, invitedBy)
+		if err != nil {
+			return fmt.Errorf("failed to create invite: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create user account: %v", err)
+		return nil, err
 	}
 
-	// Set the user_id on the executive
-	userID := int64(user.ID)
-	executive.UserId = &userID
-
-	return s.executiveRepo.Create(executive)
+	// A failed delivery leaves the invite unused; it can be resent
+	return s.deliverInvite(invite, token, executive, user), nil
 }
 
 func (s *ExecutiveService) UpdateExecutive(id uint, updates map[string]interface{}) error {
@@ -235,36 +301,71 @@ func (s *ExecutiveService) UpdateExecutive(id uint, updates map[string]interface
 		}
 	}
 
-	// Update the associated user if executive has a user_id
-	if executive.UserId != nil && *executive.UserId > 0 {
-		userUpdates := make(map[string]interface{})
+	// The executive, their user account and their terms are updated together
+	return s.executiveRepo.Transaction(func(tx *gorm.DB) error {
+		executiveRepo, termRepo := s.executiveRepo.WithTx(tx), s.termRepo.WithTx(tx)
+
+		// Update the associated user if executive has a user_id
+		if executive.UserId != nil && *executive.UserId > 0 {
+			userUpdates := make(map[string]interface{})
+
+			// Sync relevant fields to user
+			if firstName, ok := updates["first_name"]; ok {
+				userUpdates["first_name"] = firstName
+			}
+			if lastName, ok := updates["last_name"]; ok {
+				userUpdates["last_name"] = lastName
+			}
+			if email, ok := updates["email"]; ok {
+				userUpdates["email"] = email
+			}
+			if mobileNo, ok := updates["mobile_no"]; ok {
+				userUpdates["mobile_no"] = mobileNo
+			}
+			if role, ok := updates["role"]; ok {
+				userUpdates["role"] = role
+			}
 
-		// Sync relevant fields to user
-		if firstName, ok := updates["first_name"]; ok {
-			userUpdates["first_name"] = firstName
+			// Only update user if there are changes to sync
+			if len(userUpdates) > 0 {
+				if err := s.userRepo.WithTx(tx).Update(uint(*executive.UserId), userUpdates); err != nil {
+					return fmt.Errorf("failed to update user account: %v", err)
+				}
+			}
 		}
-		if lastName, ok := updates["last_name"]; ok {
-			userUpdates["last_name"] = lastName
+
+		if err := executiveRepo.Update(id, updates); err != nil {
+			return err
 		}
-		if email, ok := updates["email"]; ok {
-			userUpdates["email"] = email
+
+		// A new position, role, region or zone ends the current term and starts another, so the old one
+		// stays in the executive's history. Marking the executive inactive ends their terms and marking
+		// them active again starts a new one.
+		status, _ := updates["status"].(string)
+		if status == "inactive" {
+			return termRepo.EndAll(int64(id), today(), repositories.TermEndRemoved, nil)
 		}
-		if mobileNo, ok := updates["mobile_no"]; ok {
-			userUpdates["mobile_no"] = mobileNo
+		reassign := termFieldsChanged(executive, updates)
+		if !reassign && status == "active" {
+			current, err := termRepo.Current(id)
+			if err != nil {
+				return err
+			}
+			reassign = current == nil
 		}
-		if role, ok := updates["role"]; ok {
-			userUpdates["role"] = role
+		if !reassign {
+			return nil
 		}
-
-		// Only update user if there are changes to sync
-		if len(userUpdates) > 0 {
-			if err := s.userRepo.Update(uint(*executive.UserId), userUpdates); err != nil {
-				return fmt.Errorf("failed to update user account: %v", err)
-			}
+		updated, err := executiveRepo.FindByID(id)
+		if err != nil {
+			return err
 		}
-	}
-
-	return s.executiveRepo.Update(id, updates)
+		term := executiveTerm(updated, nil)
+		if term.Level == "" {
+			return nil
+		}
+		return termRepo.Reassign(term)
+	})
 }
 
 func (s *ExecutiveService) DeleteExecutive(id uint) error {
@@ -273,6 +374,10 @@ func (s *ExecutiveService) DeleteExecutive(id uint) error {
 		return errors.New("executive not found")
 	}
 
+	if err := s.termRepo.EndAll(int64(executive.ID), today(), repositories.TermEndRemoved, nil); err != nil {
+		return fmt.Errorf("failed to end terms of office: %v", err)
+	}
+
 	// Also soft delete the associated user
 	if executive.UserId != nil && *executive.UserId > 0 {
 		if err := s.userRepo.Delete(uint(*executive.UserId)); err != nil {
@@ -304,3 +409,305 @@ func (s *ExecutiveService) GetExecutiveByIDWithRole(id uint, ownerCtx *utils.Own
 	}
 	return executive, nil
 }
+
+// termFieldsChanged reports whether updates move the executive to another position, role, region
+// or zone
+func termFieldsChanged(executive *models.Executive, updates map[string]interface{}) bool {
+	if role, ok := updates["role"].(string); ok && role != stringValue(executive.Role) {
+		return true
+	}
+	current := map[string]*int64{
+		"position_id": executive.PositionId,
+		"region_id":   executive.RegionId,
+		"zone_id":     executive.ZoneId,
+	}
+	for key, value := range current {
+		if id, ok := updates[key].(int64); ok && (value == nil || *value != id) {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================
+// Invite Methods
+// ============================================
+
+// ResendInvite sends the executive a new invite; earlier pending invites stop working. Once the
+// executive has set their password the account is theirs, so no further invites are sent.
+func (s *ExecutiveService) ResendInvite(id uint, invitedBy *int64, ownerCtx *utils.OwnerContext) (*InviteDelivery, error) {
+	executive, user, err := s.invitableExecutive(id, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+	accepted, err := s.inviteRepo.HasAccepted(int64(executive.ID))
+	if err != nil {
+		return nil, err
+	}
+	if accepted || user.IsFirstLogin == nil || !*user.IsFirstLogin {
+		return nil, errors.New("executive has already set their password")
+	}
+	return s.sendInvite(executive, user, invitedBy)
+}
+
+// DefaultPasswordReset reports the executives whose default passwords were replaced by invites
+type DefaultPasswordReset struct {
+	Cleared      int    `json:"cleared"`
+	Invited      int    `json:"invited"`
+	NotDelivered []uint `json:"not_delivered"` // executives no invite could be sent to; resend once their contacts are fixed
+}
+
+// ReplaceDefaultPasswords clears the passwords of executives created before invites, which were their
+// executive number followed by "123" and are still in use, and sends each of them an invite instead.
+// With dryRun the executives are counted but nothing is changed.
+func (s *ExecutiveService) ReplaceDefaultPasswords(dryRun bool) (*DefaultPasswordReset, error) {
+	executives, err := s.executiveRepo.FindWithDefaultPasswords()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DefaultPasswordReset{NotDelivered: []uint{}}
+	for i := range executives {
+		executive := &executives[i]
+		if dryRun {
+			result.Cleared++
+			continue
+		}
+
+		user, err := s.userRepo.FindByID(uint(*executive.UserId))
+		if err != nil {
+			return result, fmt.Errorf("failed to load the account of executive %d: %v", executive.ID, err)
+		}
+		if err := s.userRepo.ClearPassword(user.ID); err != nil {
+			return result, fmt.Errorf("failed to clear the password of executive %d: %v", executive.ID, err)
+		}
+		result.Cleared++
+
+		delivery, err := s.sendInvite(executive, user, nil)
+		if err != nil || len(delivery.Channels) == 0 {
+			result.NotDelivered = append(result.NotDelivered, executive.ID)
+			continue
+		}
+		result.Invited++
+	}
+	return result, nil
+}
+
+// RevokeInvite cancels the executive's pending invites
+func (s *ExecutiveService) RevokeInvite(id uint, revokedBy *int64, ownerCtx *utils.OwnerContext) error {
+	executive, _, err := s.invitableExecutive(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+	revoked, err := s.inviteRepo.RevokePending(int64(executive.ID), revokedBy)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return errors.New("executive has no pending invite")
+	}
+	return nil
+}
+
+// ListInvites returns the invites sent to an executive, newest first
+func (s *ExecutiveService) ListInvites(id uint, ownerCtx *utils.OwnerContext) ([]models.ExecutiveInvite, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	executive, err := s.GetExecutiveByIDWithRole(id, ownerCtx)
+	if err != nil {
+		return nil, errors.New("executive not found")
+	}
+	return s.inviteRepo.ListForExecutive(int64(executive.ID))
+}
+
+// GetInviteDetails checks an invite token and returns who it is for
+func (s *ExecutiveService) GetInviteDetails(token string) (*InviteDetails, error) {
+	invite, err := s.usableInvite(token)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.FindByID(uint(invite.UserId))
+	if err != nil {
+		return nil, errors.New("invite not found")
+	}
+	return &InviteDetails{
+		FirstName: stringValue(user.FirstName),
+		LastName:  stringValue(user.LastName),
+		Username:  stringValue(user.Username),
+		ExpiresAt: invite.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvite sets the password of the invited executive's account and uses up the invite. It
+// returns the username to sign in with.
+func (s *ExecutiveService) AcceptInvite(token, password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	invite, err := s.usableInvite(token)
+	if err != nil {
+		return "", err
+	}
+	user, err := s.userRepo.FindByID(uint(invite.UserId))
+	if err != nil {
+		return "", errors.New("invite not found")
+	}
+
+	hashedPassword, err := s.userRepo.HashPassword(password)
+	if err != nil {
+		return "", err
+	}
+	if err := s.inviteRepo.Accept(invite, hashedPassword); err != nil {
+		return "", err
+	}
+	return stringValue(user.Username), nil
+}
+
+// invitableExecutive returns an executive the user may send invites to, with their user account
+func (s *ExecutiveService) invitableExecutive(id uint, ownerCtx *utils.OwnerContext) (*models.Executive, *models.User, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, nil, err
+	}
+	executive, err := s.GetExecutiveByIDWithRole(id, ownerCtx)
+	if err != nil {
+		return nil, nil, errors.New("executive not found")
+	}
+	if executive.UserId == nil || *executive.UserId == 0 {
+		return nil, nil, errors.New("executive has no user account")
+	}
+	user, err := s.userRepo.FindByID(uint(*executive.UserId))
+	if err != nil {
+		return nil, nil, errors.New("executive has no user account")
+	}
+	return executive, user, nil
+}
+
+// usableInvite finds the pending, unexpired invite a token belongs to
+func (s *ExecutiveService) usableInvite(token string) (*models.ExecutiveInvite, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.New("token is required")
+	}
+	invite, err := s.inviteRepo.FindByTokenHash(hashInviteToken(token))
+	if err != nil {
+		return nil, errors.New("invite not found")
+	}
+	switch {
+	case invite.Status == "accepted":
+		return nil, errors.New("invite has already been used")
+	case invite.Status == "revoked":
+		return nil, errors.New("invite has been revoked")
+	case invite.IsExpired:
+		return nil, errors.New("invite has expired")
+	}
+	return invite, nil
+}
+
+// sendInvite issues a new invite token and sends the link by SMS and email. Only the token's hash
+// is stored, so the link can't be shown again; a lost link is replaced by resending.
+func (s *ExecutiveService) sendInvite(executive *models.Executive, user *models.User, invitedBy *int64) (*InviteDelivery, error) {
+	invite, token, err := createInvite(s.inviteRepo, user, int64(executive.ID), nil, invitedBy)
+	if err != nil {
+		return nil, err
+	}
+	return s.deliverInvite(invite, token, executive, user), nil
+}
+
+// createInvite stores a new invite to set the password of a user account, for an executive or (with
+// executiveID 0) a school admin, and returns it with its token
+func createInvite(inviteRepo *repositories.ExecutiveInviteRepository, user *models.User, executiveID int64, schoolID *int64, invitedBy *int64) (*models.ExecutiveInvite, string, error) {
+	token, err := generateInviteToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	invite := &models.ExecutiveInvite{
+		ExecutiveId: executiveID,
+		SchoolId:    schoolID,
+		UserId:      int64(user.ID),
+		TokenHash:   hashInviteToken(token),
+		ExpiresAt:   time.Now().Add(time.Duration(executiveInviteHours()) * time.Hour),
+		Status:      "pending",
+		CreatedBy:   invitedBy,
+	}
+	if user.MobileNo != nil && *user.MobileNo != "" {
+		invite.SentToMobile = user.MobileNo
+	}
+	if user.Email != nil && *user.Email != "" && utils.MailConfigured() {
+		invite.SentToEmail = user.Email
+	}
+	if err := inviteRepo.Create(invite); err != nil {
+		return nil, "", err
+	}
+	return invite, token, nil
+}
+
+// deliverInvite sends an invite link by SMS and email and reports the channels that worked
+func (s *ExecutiveService) deliverInvite(invite *models.ExecutiveInvite, token string, executive *models.Executive, user *models.User) *InviteDelivery {
+	hours := executiveInviteHours()
+	link := executiveInviteURL(token)
+	delivery := &InviteDelivery{InviteId: invite.ID, ExpiresAt: invite.ExpiresAt, Channels: []string{}}
+
+	if invite.SentToMobile != nil && s.smsService != nil {
+		message := fmt.Sprintf("Hello %s, a GNAPS executive account has been created for you (username: %s). Set your password within %d hours: %s",
+			stringValue(user.FirstName), stringValue(user.Username), hours, link)
+		if err := s.smsService.EnqueueSMS(message, *invite.SentToMobile, utils.OwnerTypeNational, utils.DefaultNationalOwnerID, "", true); err != nil {
+			log.Printf("Failed to send invite %d to executive %d by SMS: %v", invite.ID, executive.ID, err)
+		} else {
+			delivery.Channels = append(delivery.Channels, "sms")
+		}
+	}
+
+	if invite.SentToEmail != nil {
+		body := fmt.Sprintf("Hello %s,\n\nA GNAPS executive account has been created for you.\n\nUsername: %s\n\nOpen the link below to set your password. It can be used once and expires in %d hours.\n\n%s\n\nIf you were not expecting this, you can ignore this email.\n",
+			stringValue(user.FirstName), stringValue(user.Username), hours, link)
+		if err := utils.SendMail(*invite.SentToEmail, "Set up your GNAPS account", body); err != nil {
+			log.Printf("Failed to send invite %d to executive %d by email: %v", invite.ID, executive.ID, err)
+		} else {
+			delivery.Channels = append(delivery.Channels, "email")
+		}
+	}
+
+	return delivery
+}
+
+// executiveInviteHours is how long invites stay valid: EXECUTIVE_INVITE_HOURS, default 72
+func executiveInviteHours() int {
+	if hours, err := strconv.Atoi(os.Getenv("EXECUTIVE_INVITE_HOURS")); err == nil && hours > 0 {
+		return hours
+	}
+	return defaultExecutiveInviteHours
+}
+
+// executiveInviteURL is the link the executive opens to set a password. EXECUTIVE_INVITE_URL may
+// contain a {token} placeholder; otherwise the token is added as a query parameter. It defaults to
+// the frontend's /accept-invite page.
+func executiveInviteURL(token string) string {
+	base := os.Getenv("EXECUTIVE_INVITE_URL")
+	if base == "" {
+		frontend := strings.TrimSpace(strings.Split(os.Getenv("FRONTEND_URL"), ",")[0])
+		base = strings.TrimRight(frontend, "/") + "/accept-invite"
+	}
+	if strings.Contains(base, "{token}") {
+		return strings.ReplaceAll(base, "{token}", token)
+	}
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	return base + separator + "token=" + token
+}
+
+func generateInviteToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"gnaps-api/internal/testdb"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// inviteTestRows are user 1 on their first login, with one invite in each state
+func inviteTestRows() []interface{} {
+	now := time.Now()
+	invite := func(token, status string, expiresAt time.Time) *models.ExecutiveInvite {
+		return &models.ExecutiveInvite{ExecutiveId: 3, UserId: 1, TokenHash: hashInviteToken(token), Status: status, ExpiresAt: expiresAt}
+	}
+	return []interface{}{
+		&models.User{ID: 1, Username: testdb.Ptr("kofi.mensah"), EncryptedPassword: testdb.Ptr("default-password"), IsFirstLogin: testdb.Ptr(true)},
+		invite("pending-token", "pending", now.Add(time.Hour)),
+		invite("accepted-token", "accepted", now.Add(time.Hour)),
+		invite("revoked-token", "revoked", now.Add(time.Hour)),
+		invite("expired-token", "pending", now.Add(-time.Minute)),
+	}
+}
+
+func newInviteTestService(t *testing.T) (*ExecutiveService, *gorm.DB) {
+	db := testdb.Open(t, &models.User{}, &models.ExecutiveInvite{})
+	testdb.Seed(t, db, inviteTestRows()...)
+	return &ExecutiveService{
+		userRepo:   repositories.NewUserRepository(db),
+		inviteRepo: repositories.NewExecutiveInviteRepository(db),
+	}, db
+}
+
+func TestUsableInvite(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"pending invite", "pending-token", ""},
+		{"token with surrounding spaces", "  pending-token\n", ""},
+		{"missing token", "", "token is required"},
+		{"blank token", "   ", "token is required"},
+		{"unknown token", "unknown-token", "invite not found"},
+		{"accepted invite", "accepted-token", "invite has already been used"},
+		{"revoked invite", "revoked-token", "invite has been revoked"},
+		{"expired invite", "expired-token", "invite has expired"},
+	}
+
+	s, _ := newInviteTestService(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invite, err := s.usableInvite(tt.token)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("usableInvite() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("usableInvite() error = %v", err)
+			}
+			if invite.TokenHash != hashInviteToken("pending-token") {
+				t.Errorf("usableInvite() returned invite %d, want the pending one", invite.ID)
+			}
+		})
+	}
+}
+
+func TestAcceptInvite(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		password string
+		wantErr  string
+	}{
+		{"sets the password", "pending-token", "new-password", ""},
+		{"rejects a short password", "pending-token", "short", "password must be at least 8 characters"},
+		{"rejects a used invite", "accepted-token", "new-password", "invite has already been used"},
+		{"rejects a revoked invite", "revoked-token", "new-password", "invite has been revoked"},
+		{"rejects an expired invite", "expired-token", "new-password", "invite has expired"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db := newInviteTestService(t)
+			username, err := s.AcceptInvite(tt.token, tt.password)
+
+			var user models.User
+			if err := db.First(&user, 1).Error; err != nil {
+				t.Fatalf("load user: %v", err)
+			}
+			passwordSet := bcrypt.CompareHashAndPassword([]byte(stringValue(user.EncryptedPassword)), []byte(tt.password)) == nil
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("AcceptInvite() error = %v, want %q", err, tt.wantErr)
+				}
+				if passwordSet || stringValue(user.EncryptedPassword) != "default-password" || user.IsFirstLogin == nil || !*user.IsFirstLogin {
+					t.Error("a rejected invite changed the user's password")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AcceptInvite() error = %v", err)
+			}
+			if username != "kofi.mensah" {
+				t.Errorf("AcceptInvite() username = %q, want %q", username, "kofi.mensah")
+			}
+			if !passwordSet {
+				t.Error("the new password was not set")
+			}
+			if user.IsFirstLogin == nil || *user.IsFirstLogin {
+				t.Error("the user is still on their first login")
+			}
+
+			var invite models.ExecutiveInvite
+			if err := db.Where("token_hash = ?", hashInviteToken(tt.token)).First(&invite).Error; err != nil {
+				t.Fatalf("load invite: %v", err)
+			}
+			if invite.Status != "accepted" || invite.AcceptedAt == nil {
+				t.Errorf("invite status = %q, accepted at %v; want accepted", invite.Status, invite.AcceptedAt)
+			}
+
+			if _, err := s.AcceptInvite(tt.token, "another-password"); err == nil || err.Error() != "invite has already been used" {
+				t.Errorf("second AcceptInvite() error = %v, want the invite to be used up", err)
+			}
+		})
+	}
+}
+
+// Accept re-checks the invite in the database, so an invite used, revoked or expired after it was
+// looked up can't set the password
+func TestExecutiveInviteAcceptRechecksInvite(t *testing.T) {
+	tests := []struct {
+		name   string
+		update map[string]interface{}
+	}{
+		{"accepted meanwhile", map[string]interface{}{"status": "accepted"}},
+		{"revoked meanwhile", map[string]interface{}{"status": "revoked"}},
+		{"expired meanwhile", map[string]interface{}{"expires_at": time.Now().Add(-time.Second)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, db := newInviteTestService(t)
+			invite, err := s.usableInvite("pending-token")
+			if err != nil {
+				t.Fatalf("usableInvite() error = %v", err)
+			}
+			if err := db.Model(&models.ExecutiveInvite{}).Where("id = ?", invite.ID).Updates(tt.update).Error; err != nil {
+				t.Fatalf("update invite: %v", err)
+			}
+
+			err = s.inviteRepo.Accept(invite, "hashed-password")
+			if err == nil || !strings.Contains(err.Error(), "no longer valid") {
+				t.Errorf("Accept() error = %v, want the invite to be no longer valid", err)
+			}
+			var user models.User
+			if err := db.First(&user, 1).Error; err != nil {
+				t.Fatalf("load user: %v", err)
+			}
+			if stringValue(user.EncryptedPassword) != "default-password" {
+				t.Error("Accept() changed the password of a stale invite")
+			}
+		})
+	}
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+// StartTermRequest starts an executive's term of office in a position
+type StartTermRequest struct {
+	ExecutiveId uint    `json:"executive_id"`
+	PositionId  *int64  `json:"position_id"`
+	Level       string  `json:"level"` // national, region or zone
+	RegionId    *int64  `json:"region_id"`
+	ZoneId      *int64  `json:"zone_id"`
+	StartDate   string  `json:"start_date"` // YYYY-MM-DD, defaults to today
+	EndDate     *string `json:"end_date"`   // YYYY-MM-DD; leave out for an open-ended term
+	Notes       *string `json:"notes"`
+	Reassign    bool    `json:"reassign"` // end the executive's other active terms as reassigned
+}
+
+type ExecutiveTermService struct {
+	termRepo      *repositories.ExecutiveTermRepository
+	executiveRepo *repositories.ExecutiveRepository
+	positionRepo  *repositories.PositionRepository
+	zoneRepo      *repositories.ZoneRepository
+	regionRepo    *repositories.RegionRepository
+}
+
+func NewExecutiveTermService(termRepo *repositories.ExecutiveTermRepository, executiveRepo *repositories.ExecutiveRepository, positionRepo *repositories.PositionRepository, zoneRepo *repositories.ZoneRepository, regionRepo *repositories.RegionRepository) *ExecutiveTermService {
+	return &ExecutiveTermService{
+		termRepo:      termRepo,
+		executiveRepo: executiveRepo,
+		positionRepo:  positionRepo,
+		zoneRepo:      zoneRepo,
+		regionRepo:    regionRepo,
+	}
+}
+
+// ListTerms returns the terms held in the user's region or zone
+func (s *ExecutiveTermService) ListTerms(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.ExecutiveTerm, int64, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+	return s.termRepo.ListWithRoleFilter(filters, page, limit, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+func (s *ExecutiveTermService) GetTerm(id uint, ownerCtx *utils.OwnerContext) (*models.ExecutiveTerm, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	term, err := s.termRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("term not found")
+	}
+	return term, nil
+}
+
+// Holders returns everyone who has held a position at a level (in a region or zone for region and
+// zone positions), the current holder first
+func (s *ExecutiveTermService) Holders(positionID int64, level string, regionID, zoneID *int64, ownerCtx *utils.OwnerContext) ([]models.ExecutiveTerm, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	if _, err := s.positionRepo.FindByID(uint(positionID)); err != nil {
+		return nil, errors.New("position not found")
+	}
+	switch level {
+	case repositories.TermLevelNational:
+	case repositories.TermLevelRegion:
+		if regionID == nil {
+			return nil, errors.New("region_id is required for region positions")
+		}
+	case repositories.TermLevelZone:
+		if zoneID == nil {
+			return nil, errors.New("zone_id is required for zone positions")
+		}
+	default:
+		return nil, errors.New("level must be national, region or zone")
+	}
+	return s.termRepo.Holders(positionID, level, regionID, zoneID)
+}
+
+// StartTerm puts an executive in a position from a start date. The executive's admin access follows
+// their current terms, so a term starting later gives access from its start date.
+func (s *ExecutiveTermService) StartTerm(request StartTermRequest, createdBy *int64, ownerCtx *utils.OwnerContext) (*models.ExecutiveTerm, error) {
+	executive, err := s.executiveRepo.FindByID(request.ExecutiveId)
+	if err != nil {
+		return nil, errors.New("executive not found")
+	}
+	if request.PositionId != nil {
+		if _, err := s.positionRepo.FindByID(uint(*request.PositionId)); err != nil {
+			return nil, errors.New("position not found")
+		}
+	}
+
+	term := &models.ExecutiveTerm{
+		ExecutiveId: int64(executive.ID),
+		PositionId:  request.PositionId,
+		Level:       request.Level,
+		RegionId:    request.RegionId,
+		ZoneId:      request.ZoneId,
+		Status:      "active",
+		Notes:       request.Notes,
+		CreatedBy:   createdBy,
+	}
+	if term.StartDate, err = parseTermDate(request.StartDate, "start_date"); err != nil {
+		return nil, err
+	}
+	if request.EndDate != nil && *request.EndDate != "" {
+		endDate, err := parseTermDate(*request.EndDate, "end_date")
+		if err != nil {
+			return nil, err
+		}
+		term.EndDate = &endDate
+	}
+	if term.EndDate != nil && term.EndDate.Before(term.StartDate) {
+		return nil, errors.New("end_date can't be before start_date")
+	}
+	if err := s.resolveTermScope(term); err != nil {
+		return nil, err
+	}
+	if err := s.canManageTermScope(term, ownerCtx); err != nil {
+		return nil, err
+	}
+
+	if request.Reassign {
+		if err := s.termRepo.Reassign(term); err != nil {
+			return nil, err
+		}
+	} else {
+		overlaps, err := s.termRepo.OverlapExists(term)
+		if err != nil {
+			return nil, err
+		}
+		if overlaps {
+			return nil, errors.New("executive already holds this position")
+		}
+		if err := s.termRepo.Create(term); err != nil {
+			return nil, err
+		}
+	}
+	return s.termRepo.FindByIDWithRoleFilter(term.ID, nil, nil)
+}
+
+// EndTerm ends an active term early, on a date up to today (default today), for a reason. Admin
+// access given by the term stops at once. To end a term later, set its end_date instead.
+func (s *ExecutiveTermService) EndTerm(id uint, endedOn, reason string, notes *string, endedBy *int64, ownerCtx *utils.OwnerContext) error {
+	term, err := s.manageableTerm(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+	if term.Status != "active" {
+		return errors.New("term has already ended")
+	}
+	switch reason {
+	case repositories.TermEndCompleted, repositories.TermEndResigned, repositories.TermEndRemoved,
+		repositories.TermEndReassigned, repositories.TermEndDeceased, repositories.TermEndOther:
+	case "":
+		return errors.New("end_reason is required")
+	default:
+		return errors.New("end_reason must be completed, resigned, removed, reassigned, deceased or other")
+	}
+
+	endDate, err := parseTermDate(endedOn, "ended_on")
+	if err != nil {
+		return err
+	}
+	if endDate.After(today()) {
+		return errors.New("ended_on can't be in the future; set the term's end_date to end it later")
+	}
+	if endDate.Before(term.StartDate) {
+		return errors.New("ended_on can't be before the term's start date")
+	}
+	return s.termRepo.End(term, endDate, reason, notes, endedBy)
+}
+
+// UpdateTerm corrects a term's dates or notes
+func (s *ExecutiveTermService) UpdateTerm(id uint, startDate, endDate, notes *string, ownerCtx *utils.OwnerContext) (*models.ExecutiveTerm, error) {
+	term, err := s.manageableTerm(id, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	start, end := term.StartDate, term.EndDate
+	if startDate != nil {
+		if start, err = parseTermDate(*startDate, "start_date"); err != nil {
+			return nil, err
+		}
+		updates["start_date"] = start
+	}
+	if endDate != nil {
+		if *endDate == "" {
+			end = nil
+		} else {
+			parsed, err := parseTermDate(*endDate, "end_date")
+			if err != nil {
+				return nil, err
+			}
+			end = &parsed
+		}
+		updates["end_date"] = end
+	}
+	if notes != nil {
+		updates["notes"] = notes
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+	if end != nil && end.Before(start) {
+		return nil, errors.New("end_date can't be before start_date")
+	}
+	if term.Status != "active" && (startDate != nil || endDate != nil) {
+		return nil, errors.New("the dates of an ended term can't be changed")
+	}
+
+	if err := s.termRepo.Update(term, updates); err != nil {
+		return nil, err
+	}
+	return s.termRepo.FindByIDWithRoleFilter(term.ID, nil, nil)
+}
+
+// CloseExpiredTerms ends the terms whose end date has passed; run nightly
+func (s *ExecutiveTermService) CloseExpiredTerms() error {
+	closed, err := s.termRepo.CloseExpired(today())
+	if err != nil {
+		return err
+	}
+	if closed > 0 {
+		log.Printf("Closed %d expired executive terms", closed)
+	}
+	return nil
+}
+
+func (s *ExecutiveTermService) manageableTerm(id uint, ownerCtx *utils.OwnerContext) (*models.ExecutiveTerm, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	term, err := s.termRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("term not found")
+	}
+	if err := s.canManageTermScope(term, ownerCtx); err != nil {
+		return nil, err
+	}
+	return term, nil
+}
+
+// resolveTermScope checks the region or zone a term is held in and keeps only the ones its level
+// uses; zone terms also record the zone's region
+func (s *ExecutiveTermService) resolveTermScope(term *models.ExecutiveTerm) error {
+	switch term.Level {
+	case repositories.TermLevelNational:
+		term.RegionId, term.ZoneId = nil, nil
+	case repositories.TermLevelRegion:
+		if term.RegionId == nil || *term.RegionId == 0 {
+			return errors.New("region_id is required for region terms")
+		}
+		if _, err := s.regionRepo.FindByID(uint(*term.RegionId)); err != nil {
+			return errors.New("region not found")
+		}
+		term.ZoneId = nil
+	case repositories.TermLevelZone:
+		if term.ZoneId == nil || *term.ZoneId == 0 {
+			return errors.New("zone_id is required for zone terms")
+		}
+		zone, err := s.zoneRepo.FindByID(uint(*term.ZoneId))
+		if err != nil {
+			return errors.New("zone not found")
+		}
+		term.RegionId = zone.RegionId
+	default:
+		return errors.New("level must be national, region or zone")
+	}
+	return nil
+}
+
+// canManageTermScope allows national admins to manage any term, region admins the terms in their
+// region and its zones and zone admins the terms in their zone
+func (s *ExecutiveTermService) canManageTermScope(term *models.ExecutiveTerm, ownerCtx *utils.OwnerContext) error {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return err
+	}
+	switch {
+	case ownerCtx.IsNationalAdmin():
+		return nil
+	case ownerCtx.IsRegionAdmin():
+		if term.Level != repositories.TermLevelNational && term.RegionId != nil && *term.RegionId == ownerCtx.OwnerID {
+			return nil
+		}
+	case ownerCtx.IsZoneAdmin():
+		if term.Level == repositories.TermLevelZone && term.ZoneId != nil && *term.ZoneId == ownerCtx.OwnerID {
+			return nil
+		}
+	}
+	return errors.New("access denied")
+}
+
+// executiveTerm is the term an executive's role, region, zone and position describe, starting today
+func executiveTerm(executive *models.Executive, createdBy *int64) *models.ExecutiveTerm {
+	term := &models.ExecutiveTerm{
+		ExecutiveId: int64(executive.ID),
+		PositionId:  executive.PositionId,
+		Level:       repositories.TermLevelForRole(stringValue(executive.Role)),
+		StartDate:   today(),
+		Status:      "active",
+		CreatedBy:   createdBy,
+	}
+	switch term.Level {
+	case repositories.TermLevelRegion:
+		term.RegionId = executive.RegionId
+	case repositories.TermLevelZone:
+		term.RegionId = executive.RegionId
+		term.ZoneId = executive.ZoneId
+	}
+	return term
+}
+
+func parseTermDate(value, field string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return today(), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date (YYYY-MM-DD)", field)
+	}
+	return date, nil
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
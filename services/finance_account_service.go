@@ -2,11 +2,28 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"gnaps-api/models"
 	"gnaps-api/repositories"
 	"gnaps-api/utils"
+	"regexp"
+	"sort"
+	"strings"
 )
 
+// Finance account types. The first digit of an account code follows the type:
+// 1 assets, 2 liabilities, 3 equity, 4 income and 5-9 expenses.
+const (
+	FinanceAccountTypeAsset     = "asset"
+	FinanceAccountTypeLiability = "liability"
+	FinanceAccountTypeEquity    = "equity"
+	FinanceAccountTypeIncome    = "income"
+	FinanceAccountTypeExpense   = "expense"
+)
+
+// accountCodePattern matches a 1-6 digit code with an optional owner suffix such as -Z12
+var accountCodePattern = regexp.MustCompile(`^([1-9][0-9]{0,5})(-[NRZ][0-9]+)?$`)
+
 type FinanceAccountService struct {
 	accountRepo *repositories.FinanceAccountRepository
 }
@@ -28,22 +45,9 @@ func (s *FinanceAccountService) ListAccounts(filters map[string]interface{}, pag
 }
 
 func (s *FinanceAccountService) CreateAccount(account *models.FinanceAccount) error {
-	// Validate required fields
-	if account.Name == nil || *account.Name == "" {
-		return errors.New("name is required")
-	}
-	if account.Code == nil || *account.Code == "" {
-		return errors.New("code is required")
-	}
-
-	// Check if code already exists
-	exists, err := s.accountRepo.CodeExists(*account.Code, nil)
-	if err != nil {
+	if err := s.validateNewAccount(account, nil); err != nil {
 		return err
 	}
-	if exists {
-		return errors.New("finance account with this code already exists")
-	}
 
 	// Set defaults
 	account.IsDeleted = false
@@ -58,18 +62,8 @@ func (s *FinanceAccountService) UpdateAccount(id uint, updates map[string]interf
 		return errors.New("finance account not found")
 	}
 
-	// Check if code is being changed and if new code already exists
-	if code, ok := updates["code"]; ok {
-		codeStr := code.(string)
-		if codeStr != "" && (account.Code == nil || codeStr != *account.Code) {
-			exists, err := s.accountRepo.CodeExists(codeStr, &id)
-			if err != nil {
-				return err
-			}
-			if exists {
-				return errors.New("finance account with this code already exists")
-			}
-		}
+	if err := s.validateAccountUpdate(account, updates, nil); err != nil {
+		return err
 	}
 
 	return s.accountRepo.Update(id, updates)
@@ -80,6 +74,9 @@ func (s *FinanceAccountService) DeleteAccount(id uint) error {
 	if err != nil {
 		return errors.New("finance account not found")
 	}
+	if s.accountRepo.HasChildren(id) {
+		return errors.New("finance account has sub-accounts; move or delete them first")
+	}
 
 	return s.accountRepo.Delete(id)
 }
@@ -97,16 +94,185 @@ func (s *FinanceAccountService) ListAccountsWithOwner(filters map[string]interfa
 }
 
 func (s *FinanceAccountService) CreateAccountWithOwner(account *models.FinanceAccount, ownerCtx *utils.OwnerContext) error {
-	// Validate required fields
-	if account.Name == nil || *account.Name == "" {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+	if err := s.validateNewAccount(account, ownerCtx); err != nil {
+		return err
+	}
+
+	// Set defaults
+	account.IsDeleted = false
+
+	return s.accountRepo.CreateWithOwner(account, ownerCtx)
+}
+
+func (s *FinanceAccountService) UpdateAccountWithOwner(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.FindByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return errors.New("finance account not found")
+	}
+
+	if err := s.validateAccountUpdate(account, updates, ownerCtx); err != nil {
+		return err
+	}
+
+	return s.accountRepo.UpdateWithOwner(id, updates, ownerCtx)
+}
+
+func (s *FinanceAccountService) DeleteAccountWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	if s.accountRepo.HasChildren(id) {
+		return errors.New("finance account has sub-accounts; move or delete them first")
+	}
+	return s.accountRepo.DeleteWithOwner(id, ownerCtx)
+}
+
+// ============================================
+// Chart of accounts
+// ============================================
+
+// FinanceAccountNode is a finance account with its sub-accounts
+type FinanceAccountNode struct {
+	models.FinanceAccount
+	Children []*FinanceAccountNode `json:"children"`
+}
+
+// GetAccountTreeWithOwner returns the owner's chart of accounts as a tree. Accounts whose
+// parent is not visible to the caller are shown at the top level.
+func (s *FinanceAccountService) GetAccountTreeWithOwner(filters map[string]interface{}, ownerCtx *utils.OwnerContext) ([]*FinanceAccountNode, error) {
+	accounts, err := s.accountRepo.ListAllWithOwner(filters, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[int64]*FinanceAccountNode, len(accounts))
+	for _, account := range accounts {
+		nodes[int64(account.ID)] = &FinanceAccountNode{FinanceAccount: account, Children: []*FinanceAccountNode{}}
+	}
+
+	roots := []*FinanceAccountNode{}
+	for _, account := range accounts {
+		node := nodes[int64(account.ID)]
+		if account.ParentId != nil {
+			if parent, ok := nodes[*account.ParentId]; ok && parent != node {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots, nil
+}
+
+// AccountHeading is a heading account's rolled-up total on a report
+type AccountHeading struct {
+	FinanceAccountId int64   `json:"finance_account_id"`
+	ParentId         *int64  `json:"parent_id"`
+	AccountCode      string  `json:"account_code"`
+	AccountName      string  `json:"account_name"`
+	AccountType      string  `json:"account_type"`
+	Depth            int     `json:"depth"`
+	Amount           float64 `json:"amount"`
+}
+
+// accountHeadings rolls account totals up through the chart of accounts. Every heading above
+// an account in totals gets a line, even when the rolled-up amount is zero, so callers that
+// pass the same accounts always get the same headings in the same order.
+func accountHeadings(load func(ids []int64) ([]models.FinanceAccount, error), totals map[int64]float64) []AccountHeading {
+	headings := []AccountHeading{}
+	if len(totals) == 0 {
+		return headings
+	}
+
+	ids := make([]int64, 0, len(totals))
+	for id := range totals {
+		ids = append(ids, id)
+	}
+	accounts, err := load(ids)
+	if err != nil {
+		return headings
+	}
+
+	byID := make(map[int64]models.FinanceAccount, len(accounts))
+	for _, account := range accounts {
+		byID[int64(account.ID)] = account
+	}
+
+	// ancestors lists the headings above an account, nearest first
+	ancestors := func(id int64) []int64 {
+		var chain []int64
+		account, ok := byID[id]
+		for ok && account.ParentId != nil && len(chain) < len(byID) {
+			parentID := *account.ParentId
+			if parentID == id {
+				break
+			}
+			chain = append(chain, parentID)
+			account, ok = byID[parentID]
+		}
+		return chain
+	}
+
+	rolled := make(map[int64]float64)
+	for id, amount := range totals {
+		for _, headingID := range ancestors(id) {
+			rolled[headingID] += amount
+		}
+	}
+
+	for id, amount := range rolled {
+		account, ok := byID[id]
+		if !ok {
+			continue
+		}
+		heading := AccountHeading{
+			FinanceAccountId: id,
+			ParentId:         account.ParentId,
+			AccountCode:      stringValue(account.Code),
+			AccountName:      stringValue(account.Name),
+			AccountType:      stringValue(account.AccountType),
+			Depth:            len(ancestors(id)),
+			// A heading should not carry postings of its own, but count any that predate it
+			Amount: roundAmount(amount + totals[id]),
+		}
+		headings = append(headings, heading)
+	}
+	sort.Slice(headings, func(i, j int) bool {
+		if headings[i].AccountCode != headings[j].AccountCode {
+			return headings[i].AccountCode < headings[j].AccountCode
+		}
+		return headings[i].AccountName < headings[j].AccountName
+	})
+	return headings
+}
+
+// validateNewAccount checks the fields, code format, type and parent of an account being created
+func (s *FinanceAccountService) validateNewAccount(account *models.FinanceAccount, ownerCtx *utils.OwnerContext) error {
+	if account.Name == nil || strings.TrimSpace(*account.Name) == "" {
 		return errors.New("name is required")
 	}
 	if account.Code == nil || *account.Code == "" {
 		return errors.New("code is required")
 	}
+	if account.AccountType == nil || *account.AccountType == "" {
+		return errors.New("account_type is required")
+	}
+
+	code := strings.TrimSpace(*account.Code)
+	accountType := strings.ToLower(strings.TrimSpace(*account.AccountType))
+	account.Code = &code
+	account.AccountType = &accountType
+
+	if err := validateAccountCode(code, accountType); err != nil {
+		return err
+	}
 
 	// Check if code already exists
-	exists, err := s.accountRepo.CodeExists(*account.Code, nil)
+	exists, err := s.accountRepo.CodeExists(code, nil)
 	if err != nil {
 		return err
 	}
@@ -114,30 +280,181 @@ func (s *FinanceAccountService) CreateAccountWithOwner(account *models.FinanceAc
 		return errors.New("finance account with this code already exists")
 	}
 
-	// Set defaults
-	account.IsDeleted = false
+	isIncome := accountType == FinanceAccountTypeIncome
+	account.IsIncome = &isIncome
+	if account.IsHeader == nil {
+		isHeader := false
+		account.IsHeader = &isHeader
+	}
 
-	return s.accountRepo.CreateWithOwner(account, ownerCtx)
+	if account.ParentId != nil && *account.ParentId == 0 {
+		account.ParentId = nil
+	}
+	if account.ParentId != nil {
+		ownerType, ownerID := callerOwner(ownerCtx)
+		if err := s.validateParent(0, *account.ParentId, code, accountType, ownerType, ownerID, ownerCtx); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *FinanceAccountService) UpdateAccountWithOwner(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
-	// Check if code is being changed and if new code already exists
-	if code, ok := updates["code"]; ok {
-		codeStr := code.(string)
-		if codeStr != "" {
-			exists, err := s.accountRepo.CodeExists(codeStr, &id)
+// validateAccountUpdate checks an update against the account's current values. Codes are only
+// re-validated when the code or type changes, so accounts created before code formats were
+// enforced can still be renamed.
+func (s *FinanceAccountService) validateAccountUpdate(account *models.FinanceAccount, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
+	code := stringValue(account.Code)
+	accountType := stringValue(account.AccountType)
+	codeChanged := false
+
+	if value, ok := updates["code"].(string); ok {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			delete(updates, "code")
+		} else if value != code {
+			exists, err := s.accountRepo.CodeExists(value, &account.ID)
 			if err != nil {
 				return err
 			}
 			if exists {
 				return errors.New("finance account with this code already exists")
 			}
+			code = value
+			codeChanged = true
+			updates["code"] = value
 		}
 	}
 
-	return s.accountRepo.UpdateWithOwner(id, updates, ownerCtx)
+	if value, ok := updates["account_type"].(string); ok {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value != accountType {
+			if account.SystemRole != nil && *account.SystemRole != "" {
+				return errors.New("the account type of a system account cannot be changed")
+			}
+			if s.accountRepo.HasChildren(account.ID) {
+				return errors.New("the account type of a heading with sub-accounts cannot be changed")
+			}
+			accountType = value
+			codeChanged = true
+		}
+		updates["account_type"] = value
+		updates["is_income"] = value == FinanceAccountTypeIncome
+	} else {
+		// is_income always follows the account type
+		delete(updates, "is_income")
+	}
+
+	if codeChanged {
+		if err := validateAccountCode(code, accountType); err != nil {
+			return err
+		}
+	}
+
+	if isHeader, ok := updates["is_header"].(bool); ok && !isHeader && s.accountRepo.HasChildren(account.ID) {
+		return errors.New("an account with sub-accounts must remain a heading")
+	}
+
+	parentID := account.ParentId
+	if value, ok := updates["parent_id"].(int64); ok {
+		if value == 0 {
+			updates["parent_id"] = nil
+			parentID = nil
+		} else {
+			parentID = &value
+		}
+	}
+	if parentID != nil && (codeChanged || updates["parent_id"] != nil) {
+		ownerType, ownerID := ledgerOwner(account.OwnerType, account.OwnerId)
+		if err := s.validateParent(account.ID, *parentID, code, accountType, ownerType, ownerID, ownerCtx); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *FinanceAccountService) DeleteAccountWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
-	return s.accountRepo.DeleteWithOwner(id, ownerCtx)
+// validateParent checks that an account can roll up into a heading: the heading must belong to
+// the same owner, have the same account type, have a code the child's code extends, and must
+// not sit below the account itself
+func (s *FinanceAccountService) validateParent(accountID uint, parentID int64, code, accountType, ownerType string, ownerID int64, ownerCtx *utils.OwnerContext) error {
+	if parentID == int64(accountID) {
+		return errors.New("an account cannot be its own parent")
+	}
+
+	parent, err := s.accountRepo.FindByIDWithOwner(uint(parentID), ownerCtx)
+	if err != nil {
+		return errors.New("parent account not found")
+	}
+	if parent.IsHeader == nil || !*parent.IsHeader {
+		return errors.New("parent account must be a heading account")
+	}
+	if stringValue(parent.AccountType) != accountType {
+		return fmt.Errorf("parent account is %s; sub-accounts must have the same account type", stringValue(parent.AccountType))
+	}
+	parentOwnerType, parentOwnerID := ledgerOwner(parent.OwnerType, parent.OwnerId)
+	if parentOwnerType != ownerType || parentOwnerID != ownerID {
+		return errors.New("parent account belongs to a different owner")
+	}
+
+	parentBase := accountCodeBase(stringValue(parent.Code))
+	childBase := accountCodeBase(code)
+	if parentBase == "" || childBase == "" || len(childBase) <= len(parentBase) || !strings.HasPrefix(childBase, parentBase) {
+		return fmt.Errorf("sub-account codes must extend the heading code %s", stringValue(parent.Code))
+	}
+
+	// Walk up from the new parent to make sure the account is not one of its ancestors
+	if accountID > 0 {
+		current := parent
+		for depth := 0; current.ParentId != nil && depth < 20; depth++ {
+			if *current.ParentId == int64(accountID) {
+				return errors.New("parent account cannot be one of the account's own sub-accounts")
+			}
+			next, err := s.accountRepo.FindByID(uint(*current.ParentId))
+			if err != nil {
+				break
+			}
+			current = next
+		}
+	}
+	return nil
+}
+
+// validateAccountCode checks the code format and that its leading digit matches the account type
+func validateAccountCode(code, accountType string) error {
+	expected, ok := accountTypeDigits[accountType]
+	if !ok {
+		return errors.New("account_type must be asset, liability, equity, income or expense")
+	}
+	base := accountCodeBase(code)
+	if base == "" {
+		return errors.New("code must be 1 to 6 digits, optionally followed by an owner suffix such as -Z12")
+	}
+	if !strings.ContainsRune(expected, rune(base[0])) {
+		return fmt.Errorf("%s account codes must start with %s", accountType, strings.Join(strings.Split(expected, ""), " or "))
+	}
+	return nil
+}
+
+// accountTypeDigits lists the leading code digits allowed for each account type
+var accountTypeDigits = map[string]string{
+	FinanceAccountTypeAsset:     "1",
+	FinanceAccountTypeLiability: "2",
+	FinanceAccountTypeEquity:    "3",
+	FinanceAccountTypeIncome:    "4",
+	FinanceAccountTypeExpense:   "56789",
+}
+
+// accountCodeBase returns the digits of a code without its owner suffix, or "" when the code
+// does not follow the chart of accounts format
+func accountCodeBase(code string) string {
+	match := accountCodePattern.FindStringSubmatch(code)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// ownerAccountCode suffixes a chart code with its owner (e.g. 1000-Z12); codes are unique
+// across owners
+func ownerAccountCode(base, ownerType string, ownerID int64) string {
+	return fmt.Sprintf("%s-%s%d", base, strings.ToUpper(ownerType[:1]), ownerID)
 }
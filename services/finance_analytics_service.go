@@ -0,0 +1,517 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Time series intervals
+const (
+	SeriesIntervalDay   = "day"
+	SeriesIntervalWeek  = "week"
+	SeriesIntervalMonth = "month"
+)
+
+// Time series splits
+const (
+	SeriesGroupPaymentMode = "payment_mode"
+	SeriesGroupNetwork     = "network"
+	SeriesGroupRegion      = "region"
+	SeriesGroupZone        = "zone"
+	SeriesGroupBill        = "bill"
+)
+
+// seriesMaxPoints caps the number of buckets one request may return per interval
+var seriesMaxPoints = map[string]int{
+	SeriesIntervalDay:   366,
+	SeriesIntervalWeek:  260,
+	SeriesIntervalMonth: 120,
+}
+
+type FinanceAnalyticsService struct {
+	db *gorm.DB
+}
+
+func NewFinanceAnalyticsService(db *gorm.DB) *FinanceAnalyticsService {
+	return &FinanceAnalyticsService{db: db}
+}
+
+// CollectionSeriesRequest selects the collections to chart
+type CollectionSeriesRequest struct {
+	Interval    string // day, week or month
+	GroupBy     string // optional split: payment_mode, network, region, zone or bill
+	FromDate    string
+	ToDate      string
+	FinanceType string // e.g. SchoolBill to chart dues only
+	BillId      int64
+	PaymentMode string
+}
+
+// SeriesPoint is the net amount collected in one period
+type SeriesPoint struct {
+	Period     string  `json:"period"`
+	Amount     float64 `json:"amount"`
+	Count      int64   `json:"count"`
+	Cumulative float64 `json:"cumulative,omitempty"`
+}
+
+// CollectionSeries is one slice of a split, e.g. one network or one zone
+type CollectionSeries struct {
+	Key            string          `json:"key"`
+	Label          string          `json:"label"`
+	Total          float64         `json:"total"`
+	Count          int64           `json:"count"`
+	PreviousTotal  float64         `json:"previous_total"`
+	ChangePercent  *float64        `json:"change_percent"`
+	CollectionRate *CollectionRate `json:"collection_rate,omitempty"`
+	Points         []SeriesPoint   `json:"points"`
+}
+
+// PeriodComparison compares a range with the range of the same length just before it
+type PeriodComparison struct {
+	PreviousFrom  string   `json:"previous_from"`
+	PreviousTo    string   `json:"previous_to"`
+	PreviousTotal float64  `json:"previous_total"`
+	PreviousCount int64    `json:"previous_count"`
+	Change        float64  `json:"change"`
+	ChangePercent *float64 `json:"change_percent"`
+}
+
+// CollectionRate compares what school bills in scope have paid with what they were billed
+type CollectionRate struct {
+	Billed      float64 `json:"billed"`
+	Discounts   float64 `json:"discounts"`
+	Paid        float64 `json:"paid"`
+	Outstanding float64 `json:"outstanding"`
+	Rate        float64 `json:"rate"` // percentage of the billed amount net of discounts
+}
+
+// CollectionTimeSeries is the collections chart for a scope
+type CollectionTimeSeries struct {
+	Scope          *ReportScope       `json:"scope"`
+	Interval       string             `json:"interval"`
+	GroupBy        string             `json:"group_by,omitempty"`
+	FromDate       string             `json:"from_date"`
+	ToDate         string             `json:"to_date"`
+	Total          float64            `json:"total"`
+	Count          int64              `json:"count"`
+	Points         []SeriesPoint      `json:"points"`
+	Series         []CollectionSeries `json:"series,omitempty"`
+	Comparison     PeriodComparison   `json:"comparison"`
+	CollectionRate CollectionRate     `json:"collection_rate"`
+}
+
+// seriesRow is an aggregate row grouped by period and split key
+type seriesRow struct {
+	Period string
+	Key    string
+	Total  float64
+	Count  int64
+}
+
+// GetCollectionSeries charts net collections (payments less refunds) per day, week or month,
+// optionally split by payment mode, MoMo network, region, zone or bill, with the previous
+// period's totals for comparison and the collection rate of the school bills in scope
+func (s *FinanceAnalyticsService) GetCollectionSeries(scope *ReportScope, req CollectionSeriesRequest) (*CollectionTimeSeries, error) {
+	if req.Interval == "" {
+		req.Interval = SeriesIntervalDay
+	}
+	maxPoints, ok := seriesMaxPoints[req.Interval]
+	if !ok {
+		return nil, errors.New("interval must be day, week or month")
+	}
+	switch req.GroupBy {
+	case "", SeriesGroupPaymentMode, SeriesGroupNetwork, SeriesGroupRegion, SeriesGroupZone, SeriesGroupBill:
+	default:
+		return nil, errors.New("group_by must be payment_mode, network, region, zone or bill")
+	}
+
+	from, to, err := seriesRange(req.Interval, req.FromDate, req.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	periods := seriesPeriods(req.Interval, from, to)
+	if len(periods) > maxPoints {
+		return nil, fmt.Errorf("too many %s periods requested (maximum %d); narrow the date range or use a longer interval", req.Interval, maxPoints)
+	}
+
+	rows, err := s.collectionRows(scope, req, from, to, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous range has as many periods as the requested one and ends where it starts
+	prevFrom := seriesStep(req.Interval, from, -len(periods))
+	previousRows, err := s.collectionRows(scope, req, prevFrom, from, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CollectionTimeSeries{
+		Scope:    scope,
+		Interval: req.Interval,
+		GroupBy:  req.GroupBy,
+		FromDate: from.Format("2006-01-02"),
+		ToDate:   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		Points:   make([]SeriesPoint, len(periods)),
+	}
+
+	index := make(map[string]int, len(periods))
+	for i, period := range periods {
+		index[period] = i
+		result.Points[i] = SeriesPoint{Period: period}
+	}
+
+	series := make(map[string]*CollectionSeries)
+	var order []string
+	seriesFor := func(key string) *CollectionSeries {
+		item, ok := series[key]
+		if !ok {
+			item = &CollectionSeries{Key: key, Points: make([]SeriesPoint, len(periods))}
+			for i, period := range periods {
+				item.Points[i] = SeriesPoint{Period: period}
+			}
+			series[key] = item
+			order = append(order, key)
+		}
+		return item
+	}
+
+	for _, row := range rows {
+		i, ok := index[row.Period]
+		if !ok {
+			continue
+		}
+		result.Points[i].Amount += row.Total
+		result.Points[i].Count += row.Count
+		result.Total += row.Total
+		result.Count += row.Count
+
+		if req.GroupBy != "" {
+			item := seriesFor(row.Key)
+			item.Points[i].Amount += row.Total
+			item.Points[i].Count += row.Count
+			item.Total += row.Total
+			item.Count += row.Count
+		}
+	}
+
+	var previousTotal float64
+	var previousCount int64
+	for _, row := range previousRows {
+		previousTotal += row.Total
+		previousCount += row.Count
+		if req.GroupBy != "" {
+			seriesFor(row.Key).PreviousTotal += row.Total
+		}
+	}
+
+	var cumulative float64
+	for i := range result.Points {
+		result.Points[i].Amount = roundAmount(result.Points[i].Amount)
+		cumulative += result.Points[i].Amount
+		result.Points[i].Cumulative = roundAmount(cumulative)
+	}
+	result.Total = roundAmount(result.Total)
+	result.Comparison = PeriodComparison{
+		PreviousFrom:  prevFrom.Format("2006-01-02"),
+		PreviousTo:    from.AddDate(0, 0, -1).Format("2006-01-02"),
+		PreviousTotal: roundAmount(previousTotal),
+		PreviousCount: previousCount,
+		Change:        roundAmount(result.Total - previousTotal),
+		ChangePercent: changePercent(result.Total, previousTotal),
+	}
+
+	rates, overall, err := s.collectionRates(scope, req)
+	if err != nil {
+		return nil, err
+	}
+	result.CollectionRate = overall
+
+	if req.GroupBy != "" {
+		labels := s.seriesLabels(req.GroupBy, order)
+		result.Series = make([]CollectionSeries, 0, len(order))
+		for _, key := range order {
+			item := series[key]
+			item.Label = labels[key]
+			item.Total = roundAmount(item.Total)
+			item.PreviousTotal = roundAmount(item.PreviousTotal)
+			item.ChangePercent = changePercent(item.Total, item.PreviousTotal)
+			if rate, ok := rates[key]; ok {
+				rate := rate
+				item.CollectionRate = &rate
+			}
+			for i := range item.Points {
+				item.Points[i].Amount = roundAmount(item.Points[i].Amount)
+			}
+			result.Series = append(result.Series, *item)
+		}
+		sort.SliceStable(result.Series, func(i, j int) bool { return result.Series[i].Total > result.Series[j].Total })
+	}
+
+	return result, nil
+}
+
+// collectionRows sums net collections in [from, to), by period when bucketed, and by the split key
+func (s *FinanceAnalyticsService) collectionRows(scope *ReportScope, req CollectionSeriesRequest, from, to time.Time, bucketed bool) ([]seriesRow, error) {
+	var rows []seriesRow
+
+	query := s.db.Table("finance_transactions").
+		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_transactions.finance_account_id").
+		Where("finance_transactions.deleted_at IS NULL").
+		Where("finance_transactions.transaction_date >= ? AND finance_transactions.transaction_date < ?", from, to).
+		// Receipts into income accounts (or not yet assigned one) and refunds; other transactions are legacy outflows
+		Where("finance_transactions.finance_type = ? OR finance_transactions.finance_account_id IS NULL OR finance_accounts.is_income = ?", "Refund", true)
+	query = repositories.ApplySchoolScopeAtDateToQuery(query, "finance_transactions", "transaction_date", scope.owners)
+
+	if req.FinanceType != "" {
+		// Refunds of the selected kind of payment are netted off too
+		if req.FinanceType == "SchoolBill" {
+			query = query.Where("finance_transactions.finance_type IN ?", []string{"SchoolBill", "Refund"})
+		} else {
+			query = query.Where("finance_transactions.finance_type = ?", req.FinanceType)
+		}
+	}
+	if req.PaymentMode != "" {
+		query = query.Where("finance_transactions.payment_mode = ?", req.PaymentMode)
+	}
+	if req.BillId > 0 || req.GroupBy == SeriesGroupBill {
+		query = query.Joins("LEFT JOIN school_bills ON finance_transactions.finance_type IN ('SchoolBill', 'Refund') AND school_bills.id = finance_transactions.finance_id")
+		if req.BillId > 0 {
+			query = query.Where("school_bills.bill_id = ?", req.BillId)
+		}
+	}
+
+	keyExpr := "''"
+	switch req.GroupBy {
+	case SeriesGroupPaymentMode:
+		keyExpr = "COALESCE(NULLIF(finance_transactions.payment_mode, ''), 'unspecified')"
+	case SeriesGroupNetwork:
+		// Networks are only known for MoMo receipts, recorded as "MTN - 0244123456"
+		query = query.Where("LOWER(finance_transactions.payment_mode) LIKE ? OR LOWER(finance_transactions.payment_mode) LIKE ?", "%momo%", "%mobile%")
+		keyExpr = "CASE WHEN finance_transactions.mode_info LIKE '% - %' THEN UPPER(TRIM(SUBSTRING_INDEX(finance_transactions.mode_info, ' - ', 1))) ELSE 'unknown' END"
+	case SeriesGroupZone:
+		// Transferred schools' receipts count for the zone the school was in when it paid
+		query = query.Joins("LEFT JOIN schools ON schools.id = finance_transactions.school_id")
+		keyExpr = "CAST(COALESCE(" + repositories.SchoolZoneAtExpr("finance_transactions.school_id", "finance_transactions.transaction_date") + ", 0) AS CHAR)"
+	case SeriesGroupRegion:
+		query = query.Joins("LEFT JOIN schools ON schools.id = finance_transactions.school_id").
+			Joins("LEFT JOIN zones ON zones.id = " + repositories.SchoolZoneAtExpr("finance_transactions.school_id", "finance_transactions.transaction_date"))
+		keyExpr = "CAST(COALESCE(zones.region_id, 0) AS CHAR)"
+	case SeriesGroupBill:
+		keyExpr = "CAST(COALESCE(school_bills.bill_id, 0) AS CHAR)"
+	}
+
+	periodExpr := "''"
+	if bucketed {
+		periodExpr = seriesPeriodExpr(req.Interval, "finance_transactions.transaction_date")
+	}
+
+	err := query.Select(periodExpr + " AS period, " + keyExpr + " AS `key`, " +
+		"COALESCE(SUM(CASE WHEN finance_transactions.finance_type = 'Refund' THEN -finance_transactions.amount ELSE finance_transactions.amount END), 0) AS total, " +
+		"COUNT(*) AS count").
+		Group("period, `key`").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// collectionRates computes the collection rate of the school bills in scope, overall and by the
+// split key when the split is by region, zone or bill
+func (s *FinanceAnalyticsService) collectionRates(scope *ReportScope, req CollectionSeriesRequest) (map[string]CollectionRate, CollectionRate, error) {
+	var rows []struct {
+		Key       string
+		Billed    float64
+		Discounts float64
+		Paid      float64
+	}
+
+	query := s.db.Table("school_bills").Where("school_bills.deleted_at IS NULL")
+	query = repositories.ApplySchoolBillScopeToQuery(query, scope.owners)
+	if req.BillId > 0 {
+		query = query.Where("school_bills.bill_id = ?", req.BillId)
+	}
+
+	keyExpr := "''"
+	switch req.GroupBy {
+	case SeriesGroupZone:
+		query = query.Joins("LEFT JOIN schools ON schools.id = school_bills.school_id")
+		keyExpr = "CAST(COALESCE(school_bills.zone_id, schools.zone_id, 0) AS CHAR)"
+	case SeriesGroupRegion:
+		query = query.Joins("LEFT JOIN schools ON schools.id = school_bills.school_id").
+			Joins("LEFT JOIN zones ON zones.id = COALESCE(school_bills.zone_id, schools.zone_id)")
+		keyExpr = "CAST(COALESCE(zones.region_id, 0) AS CHAR)"
+	case SeriesGroupBill:
+		keyExpr = "CAST(COALESCE(school_bills.bill_id, 0) AS CHAR)"
+	}
+
+	err := query.Select(keyExpr + " AS `key`, COALESCE(SUM(school_bills.amount), 0) AS billed, " +
+		"COALESCE(SUM(school_bills.discounts), 0) AS discounts, COALESCE(SUM(school_bills.amount_paid), 0) AS paid").
+		Group("`key`").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, CollectionRate{}, err
+	}
+
+	byKey := make(map[string]CollectionRate)
+	var overall CollectionRate
+	for _, row := range rows {
+		byKey[row.Key] = collectionRate(row.Billed, row.Discounts, row.Paid)
+		overall.Billed += row.Billed
+		overall.Discounts += row.Discounts
+		overall.Paid += row.Paid
+	}
+	if req.GroupBy == "" || req.GroupBy == SeriesGroupPaymentMode || req.GroupBy == SeriesGroupNetwork {
+		byKey = map[string]CollectionRate{}
+	}
+	return byKey, collectionRate(overall.Billed, overall.Discounts, overall.Paid), nil
+}
+
+// seriesLabels names the split keys: regions, zones and bills by name, other keys as they are
+func (s *FinanceAnalyticsService) seriesLabels(groupBy string, keys []string) map[string]string {
+	labels := make(map[string]string, len(keys))
+	ids := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		labels[key] = key
+		if id, err := strconv.ParseInt(key, 10, 64); err == nil && id > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	var named []struct {
+		ID   int64
+		Name string
+	}
+	switch groupBy {
+	case SeriesGroupRegion:
+		s.db.Model(&models.Region{}).Where("id IN ?", ids).Select("id, name").Scan(&named)
+		labels["0"] = "No region"
+	case SeriesGroupZone:
+		s.db.Model(&models.Zone{}).Where("id IN ?", ids).Select("id, name").Scan(&named)
+		labels["0"] = "No zone"
+	case SeriesGroupBill:
+		s.db.Model(&models.Bill{}).Where("id IN ?", ids).Select("id, name").Scan(&named)
+		labels["0"] = "Other collections"
+	}
+	for _, item := range named {
+		labels[strconv.FormatInt(item.ID, 10)] = item.Name
+	}
+	return labels
+}
+
+// seriesRange resolves the requested dates to [from, to) aligned to whole periods. Without
+// dates it covers the last 30 days, 12 weeks or 12 months up to today.
+func seriesRange(interval, fromDate, toDate string) (time.Time, time.Time, error) {
+	now := time.Now()
+	last := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if toDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to_date, expected YYYY-MM-DD")
+		}
+		last = parsed
+	}
+
+	var first time.Time
+	if fromDate != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from_date, expected YYYY-MM-DD")
+		}
+		first = parsed
+	} else {
+		switch interval {
+		case SeriesIntervalWeek:
+			first = last.AddDate(0, 0, -7*11)
+		case SeriesIntervalMonth:
+			first = last.AddDate(0, -11, 0)
+		default:
+			first = last.AddDate(0, 0, -29)
+		}
+	}
+	if last.Before(first) {
+		return time.Time{}, time.Time{}, errors.New("to_date cannot be before from_date")
+	}
+
+	from := seriesPeriodStart(interval, first)
+	to := seriesStep(interval, seriesPeriodStart(interval, last), 1)
+	return from, to, nil
+}
+
+// seriesPeriods lists the period labels from from up to (not including) to
+func seriesPeriods(interval string, from, to time.Time) []string {
+	var periods []string
+	for current := from; current.Before(to); current = seriesStep(interval, current, 1) {
+		periods = append(periods, current.Format("2006-01-02"))
+		if len(periods) > seriesMaxPoints[interval] {
+			break
+		}
+	}
+	return periods
+}
+
+// seriesPeriodStart returns the start of the day, the Monday of the week, or the first of the month
+func seriesPeriodStart(interval string, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case SeriesIntervalWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case SeriesIntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+// seriesStep moves a period start by n periods
+func seriesStep(interval string, t time.Time, n int) time.Time {
+	switch interval {
+	case SeriesIntervalWeek:
+		return t.AddDate(0, 0, 7*n)
+	case SeriesIntervalMonth:
+		return t.AddDate(0, n, 0)
+	}
+	return t.AddDate(0, 0, n)
+}
+
+// seriesPeriodExpr is the SQL for the start date of a column's period, matching seriesPeriodStart
+func seriesPeriodExpr(interval, column string) string {
+	switch interval {
+	case SeriesIntervalWeek:
+		return "DATE_FORMAT(DATE_SUB(DATE(" + column + "), INTERVAL WEEKDAY(" + column + ") DAY), '%Y-%m-%d')"
+	case SeriesIntervalMonth:
+		return "DATE_FORMAT(" + column + ", '%Y-%m-01')"
+	}
+	return "DATE_FORMAT(" + column + ", '%Y-%m-%d')"
+}
+
+func collectionRate(billed, discounts, paid float64) CollectionRate {
+	rate := CollectionRate{
+		Billed:    roundAmount(billed),
+		Discounts: roundAmount(discounts),
+		Paid:      roundAmount(paid),
+	}
+	net := roundAmount(billed - discounts)
+	rate.Outstanding = roundAmount(net - paid)
+	if net > 0 {
+		rate.Rate = math.Round(paid/net*10000) / 100
+	}
+	return rate
+}
+
+// changePercent is the change from previous to current as a percentage, or nil when there was
+// nothing in the previous period to compare with
+func changePercent(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := math.Round((current-previous)/math.Abs(previous)*10000) / 100
+	return &change
+}
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Expense workflow statuses
+const (
+	ExpenseStatusDraft     = "draft"
+	ExpenseStatusSubmitted = "submitted"
+	ExpenseStatusApproved  = "approved"
+	ExpenseStatusRejected  = "rejected"
+	ExpenseStatusPaid      = "paid"
+)
+
+type FinanceExpenseService struct {
+	expenseRepo         *repositories.FinanceExpenseRepository
+	accountRepo         *repositories.FinanceAccountRepository
+	ledgerService       *LedgerService
+	budgetService       *BudgetService
+	mediaService        *MediaService
+	bankAccountService  *BankAccountService
+	fiscalPeriodService *FiscalPeriodService
+}
+
+func NewFinanceExpenseService(
+	expenseRepo *repositories.FinanceExpenseRepository,
+	accountRepo *repositories.FinanceAccountRepository,
+	ledgerService *LedgerService,
+	budgetService *BudgetService,
+	mediaService *MediaService,
+	bankAccountService *BankAccountService,
+	fiscalPeriodService *FiscalPeriodService,
+) *FinanceExpenseService {
+	return &FinanceExpenseService{
+		expenseRepo:         expenseRepo,
+		accountRepo:         accountRepo,
+		ledgerService:       ledgerService,
+		budgetService:       budgetService,
+		mediaService:        mediaService,
+		bankAccountService:  bankAccountService,
+		fiscalPeriodService: fiscalPeriodService,
+	}
+}
+
+func (s *FinanceExpenseService) GetExpenseByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.FinanceExpense, error) {
+	expense, err := s.expenseRepo.FindByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return nil, errors.New("expense not found")
+	}
+	return expense, nil
+}
+
+func (s *FinanceExpenseService) ListExpensesWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.FinanceExpense, int64, error) {
+	return s.expenseRepo.ListWithOwner(filters, page, limit, ownerCtx)
+}
+
+// CreateExpenseWithOwner records a new expense as a draft
+func (s *FinanceExpenseService) CreateExpenseWithOwner(expense *models.FinanceExpense, userId *int64, ownerCtx *utils.OwnerContext) error {
+	ownerType, ownerID := callerOwner(ownerCtx)
+	if err := s.validateExpense(expense.Title, expense.Amount, expense.BudgetAccountId, ownerType, ownerID); err != nil {
+		return err
+	}
+
+	if expense.TransactionDate.IsZero() {
+		expense.TransactionDate = time.Now()
+	}
+	if err := s.fiscalPeriodService.EnsureOpenForOwner(ownerCtx, expense.TransactionDate); err != nil {
+		return err
+	}
+
+	status := ExpenseStatusDraft
+	isApproved := false
+	isPaid := false
+	expense.Status = &status
+	expense.IsApproved = &isApproved
+	expense.IsPaid = &isPaid
+	expense.IsDeleted = false
+	expense.UserId = userId
+	expense.ApprovedBy = nil
+	expense.ApprovedAt = nil
+	expense.SubmittedAt = nil
+	expense.RejectedBy = nil
+	expense.RejectedAt = nil
+	expense.RejectedReason = nil
+	expense.PaidBy = nil
+	expense.PaidAt = nil
+	expense.VoucherNo = nil
+	expense.Attachments = nil
+
+	return s.expenseRepo.CreateWithOwner(expense, ownerCtx)
+}
+
+// UpdateExpenseWithOwner updates an expense that has not yet been submitted or was rejected
+func (s *FinanceExpenseService) UpdateExpenseWithOwner(id uint, updates map[string]interface{}, ownerCtx *utils.OwnerContext) error {
+	expense, err := s.GetExpenseByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	if status := expenseStatus(expense); status != ExpenseStatusDraft && status != ExpenseStatusRejected {
+		return errors.New("only draft or rejected expenses can be edited")
+	}
+	// Neither the current nor the new date may fall in a closed fiscal period
+	if err := s.ensureExpensePeriodOpen(expense); err != nil {
+		return err
+	}
+	if date, ok := updates["transaction_date"].(time.Time); ok {
+		if err := s.fiscalPeriodService.EnsureOpen(expense.OwnerType, expense.OwnerId, date); err != nil {
+			return err
+		}
+	}
+
+	if title, ok := updates["title"].(string); ok && strings.TrimSpace(title) == "" {
+		return errors.New("title is required")
+	}
+	if amount, ok := updates["amount"]; ok && amount.(float64) <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	if accountId, ok := updates["budget_account_id"]; ok {
+		id := accountId.(int64)
+		ownerType, ownerID := ledgerOwner(expense.OwnerType, expense.OwnerId)
+		if err := s.validateExpense(expense.Title, expense.Amount, &id, ownerType, ownerID); err != nil {
+			return err
+		}
+	}
+
+	return s.expenseRepo.UpdateWithOwner(id, updates, ownerCtx)
+}
+
+// DeleteExpenseWithOwner removes an expense that has not been approved
+func (s *FinanceExpenseService) DeleteExpenseWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	expense, err := s.GetExpenseByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	if expense.IsApproved != nil && *expense.IsApproved {
+		return errors.New("approved expenses cannot be deleted")
+	}
+	if err := s.ensureExpensePeriodOpen(expense); err != nil {
+		return err
+	}
+
+	return s.expenseRepo.DeleteWithOwner(id, ownerCtx)
+}
+
+// ============================================
+// Workflow
+// ============================================
+
+// SubmitExpense sends a draft or rejected expense for approval
+func (s *FinanceExpenseService) SubmitExpense(id uint, ownerCtx *utils.OwnerContext) error {
+	expense, err := s.GetExpenseByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	if status := expenseStatus(expense); status != ExpenseStatusDraft && status != ExpenseStatusRejected {
+		return errors.New("only draft or rejected expenses can be submitted")
+	}
+	if err := s.ensureExpensePeriodOpen(expense); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.expenseRepo.UpdateWithOwner(id, map[string]interface{}{
+		"status":          ExpenseStatusSubmitted,
+		"submitted_at":    now,
+		"rejected_reason": nil,
+		"rejected_by":     nil,
+		"rejected_at":     nil,
+	}, ownerCtx)
+}
+
+// ApproveExpense approves a submitted expense. The person who recorded the expense cannot approve it.
+func (s *FinanceExpenseService) ApproveExpense(id uint, approverId int64, ownerCtx *utils.OwnerContext) error {
+	expense, err := s.GetExpenseByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	if expenseStatus(expense) != ExpenseStatusSubmitted {
+		return errors.New("only submitted expenses can be approved")
+	}
+	if err := s.ensureExpensePeriodOpen(expense); err != nil {
+		return err
+	}
+	if expense.UserId != nil && *expense.UserId == approverId {
+		return errors.New("you cannot approve an expense you recorded")
+	}
+
+	// The approval and the accrual in the ledger are saved together
+	now := time.Now()
+	err = s.expenseRepo.Transaction(func(tx *gorm.DB) error {
+		if err := s.expenseRepo.WithTx(tx).UpdateWithOwner(id, map[string]interface{}{
+			"status":      ExpenseStatusApproved,
+			"is_approved": true,
+			"approved_by": approverId,
+			"approved_at": now,
+		}, ownerCtx); err != nil {
+			return err
+		}
+
+		if _, err := s.ledgerService.WithTx(tx).PostExpenseApproval(expense, &approverId); err != nil {
+			return errors.New("ledger posting failed: " + err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Warn the owner when approved spending passes the account's budget threshold
+	s.budgetService.CheckExpenseThreshold(expense)
+	return nil
+}
+
+// RejectExpense rejects a submitted expense with a reason
+func (s *FinanceExpenseService) RejectExpense(id uint, rejectedBy int64, reason string, ownerCtx *utils.OwnerContext) error {
+	if reason == "" {
+		return errors.New("rejection reason is required")
+	}
+
+	expense, err := s.GetExpenseByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	if expenseStatus(expense) != ExpenseStatusSubmitted {
+		return errors.New("only submitted expenses can be rejected")
+	}
+	if err := s.ensureExpensePeriodOpen(expense); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.expenseRepo.UpdateWithOwner(id, map[string]interface{}{
+		"status":          ExpenseStatusRejected,
+		"is_approved":     false,
+		"rejected_by":     rejectedBy,
+		"rejected_reason": reason,
+		"rejected_at":     now,
+	}, ownerCtx)
+}
+
+// MarkPaidRequest holds payment details for an approved expense
+type MarkPaidRequest struct {
+	PaymentMode   string `json:"payment_mode"`
+	ChequeNo      string `json:"cheque_no"`
+	BankAccountId *int64 `json:"bank_account_id"`
+	PaidDate      string `json:"paid_date"`
+}
+
+// MarkExpensePaid records payment of an approved expense and issues a payment voucher
+func (s *FinanceExpenseService) MarkExpensePaid(id uint, paidBy int64, req MarkPaidRequest, ownerCtx *utils.OwnerContext) error {
+	expense, err := s.GetExpenseByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	if expenseStatus(expense) != ExpenseStatusApproved {
+		return errors.New("only approved expenses can be marked as paid")
+	}
+	if req.PaymentMode == "" {
+		return errors.New("payment_mode is required")
+	}
+
+	paidAt := time.Now()
+	if req.PaidDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.PaidDate)
+		if err != nil {
+			return errors.New("invalid paid_date, expected YYYY-MM-DD")
+		}
+		paidAt = parsed
+	}
+	if err := s.ensureExpensePeriodOpen(expense); err != nil {
+		return err
+	}
+	if err := s.fiscalPeriodService.EnsureOpen(expense.OwnerType, expense.OwnerId, paidAt); err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"status":       ExpenseStatusPaid,
+		"is_paid":      true,
+		"paid_by":      paidBy,
+		"paid_at":      paidAt,
+		"payment_mode": req.PaymentMode,
+	}
+	if req.ChequeNo != "" {
+		updates["cheque_no"] = req.ChequeNo
+	}
+	bankAccountId, err := s.bankAccountService.ResolveAccount(expense.OwnerType, expense.OwnerId, req.PaymentMode, req.BankAccountId)
+	if err != nil {
+		return err
+	}
+	if bankAccountId != nil {
+		updates["bank_account_id"] = *bankAccountId
+	}
+
+	if req.ChequeNo != "" {
+		expense.ChequeNo = &req.ChequeNo
+	}
+
+	// The voucher number, the payment and the settlement of the accrued payable in the ledger are saved together
+	return s.expenseRepo.Transaction(func(tx *gorm.DB) error {
+		if expense.VoucherNo == nil || *expense.VoucherNo == "" {
+			voucherNo, err := s.expenseRepo.WithTx(tx).GenerateVoucherNo()
+			if err != nil {
+				return err
+			}
+			updates["voucher_no"] = voucherNo
+			expense.VoucherNo = &voucherNo
+		}
+		if err := s.expenseRepo.WithTx(tx).UpdateWithOwner(id, updates, ownerCtx); err != nil {
+			return err
+		}
+		if _, err := s.ledgerService.WithTx(tx).PostExpensePayment(expense, req.PaymentMode, paidAt, &paidBy); err != nil {
+			return errors.New("ledger posting failed: " + err.Error())
+		}
+		return nil
+	})
+}
+
+// ============================================
+// Attachments
+// ============================================
+
+// AddAttachment uploads a receipt and attaches it to an expense
+func (s *FinanceExpenseService) AddAttachment(id uint, file *multipart.FileHeader, uploadedBy *int64, ownerCtx *utils.OwnerContext) (*models.FinanceExpenseAttachment, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetExpenseByIDWithOwner(id, ownerCtx); err != nil {
+		return nil, err
+	}
+
+	upload, err := s.mediaService.UploadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := file.Filename
+	fileType := file.Header.Get("Content-Type")
+	fileSize := file.Size
+	attachment := &models.FinanceExpenseAttachment{
+		FinanceExpenseId: id,
+		FileUrl:          upload.URL,
+		FileName:         &fileName,
+		FileType:         &fileType,
+		FileSize:         &fileSize,
+		UploadedBy:       uploadedBy,
+	}
+
+	if err := s.expenseRepo.CreateAttachment(attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// RemoveAttachment deletes a receipt from an expense that has not been paid
+func (s *FinanceExpenseService) RemoveAttachment(id, attachmentId uint, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	expense, err := s.GetExpenseByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+	if expenseStatus(expense) == ExpenseStatusPaid {
+		return errors.New("attachments on paid expenses cannot be removed")
+	}
+
+	if _, err := s.expenseRepo.FindAttachment(id, attachmentId); err != nil {
+		return errors.New("attachment not found")
+	}
+
+	return s.expenseRepo.DeleteAttachment(attachmentId)
+}
+
+// GetExpenseStatsWithOwner summarises expenses for the owner within an optional date range
+func (s *FinanceExpenseService) GetExpenseStatsWithOwner(fromDate, toDate string, ownerCtx *utils.OwnerContext) repositories.ExpenseStats {
+	from, to := parseDateRange(fromDate, toDate)
+	return s.expenseRepo.GetStatsWithOwner(from, to, ownerCtx)
+}
+
+// validateExpense checks an expense's title and amount, and that its budget account is an expense
+// sub-account of the expense's owner
+func (s *FinanceExpenseService) validateExpense(title *string, amount *float64, accountId *int64, ownerType string, ownerID int64) error {
+	if title == nil || strings.TrimSpace(*title) == "" {
+		return errors.New("title is required")
+	}
+	if amount == nil || *amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	if accountId != nil {
+		account, err := s.accountRepo.FindByID(uint(*accountId))
+		if err != nil {
+			return errors.New("finance account not found")
+		}
+		if account.IsIncome != nil && *account.IsIncome {
+			return errors.New("expenses cannot be recorded against an income account")
+		}
+		if account.IsHeader != nil && *account.IsHeader {
+			return errors.New("expenses must be recorded against a sub-account, not a heading")
+		}
+		if accountOwnerType, accountOwnerID := ledgerOwner(account.OwnerType, account.OwnerId); accountOwnerType != ownerType || accountOwnerID != ownerID {
+			return fmt.Errorf("finance account %s does not belong to you", stringValue(account.Code))
+		}
+	}
+	return nil
+}
+
+// ensureExpensePeriodOpen rejects changes to an expense dated inside a closed fiscal period
+func (s *FinanceExpenseService) ensureExpensePeriodOpen(expense *models.FinanceExpense) error {
+	return s.fiscalPeriodService.EnsureOpen(expense.OwnerType, expense.OwnerId, expense.TransactionDate)
+}
+
+func expenseStatus(expense *models.FinanceExpense) string {
+	if expense.Status == nil || *expense.Status == "" {
+		return ExpenseStatusDraft
+	}
+	return *expense.Status
+}
+
+// parseDateRange converts YYYY-MM-DD bounds into a half-open time range; the end date is inclusive
+func parseDateRange(fromDate, toDate string) (*time.Time, *time.Time) {
+	var from, to *time.Time
+	if fromDate != "" {
+		if t, err := time.Parse("2006-01-02", fromDate); err == nil {
+			from = &t
+		}
+	}
+	if toDate != "" {
+		if t, err := time.Parse("2006-01-02", toDate); err == nil {
+			end := t.Add(24 * time.Hour)
+			to = &end
+		}
+	}
+	return from, to
+}
@@ -0,0 +1,757 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"gnaps-api/workers"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Export formats
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+	ExportFormatPDF  = "pdf"
+)
+
+// Export job statuses
+const (
+	ExportStatusQueued     = "queued"
+	ExportStatusProcessing = "processing"
+	ExportStatusCompleted  = "completed"
+	ExportStatusFailed     = "failed"
+	ExportStatusExpired    = "expired"
+)
+
+// Exportable finance resources
+const (
+	ExportResourceMomoPayments    = "momo-payments"
+	ExportResourceTransactions    = "transactions"
+	ExportResourceExpenses        = "expenses"
+	ExportResourceIncomeStatement = "income-statement"
+	ExportResourceCashFlow        = "cash-flow"
+)
+
+// exportFileRetention is how long a background export file stays downloadable before the
+// export worker deletes it
+const exportFileRetention = 7 * 24 * time.Hour
+
+// ExportRequest describes a finance export: what to export, in which format, with which filters
+type ExportRequest struct {
+	ResourceType string            `json:"resource_type"`
+	Format       string            `json:"format"`
+	Filters      map[string]string `json:"filters"`
+	ScopeType    string            `json:"scope_type"`
+	ScopeId      int64             `json:"scope_id"`
+}
+
+// ExportUser identifies who requested an export, for the activity log
+type ExportUser struct {
+	UserID   uint
+	Username string
+	Role     string
+}
+
+type FinanceExportService struct {
+	reportsService     *FinanceReportsService
+	exportJobRepo      *repositories.ExportJobRepository
+	activityLogService *ActivityLogService
+	exportWorker       *workers.ExportWorker
+	exportDir          string
+	asyncThreshold     int64
+}
+
+func NewFinanceExportService(
+	reportsService *FinanceReportsService,
+	exportJobRepo *repositories.ExportJobRepository,
+	activityLogService *ActivityLogService,
+	exportWorker *workers.ExportWorker,
+) *FinanceExportService {
+	exportDir := os.Getenv("EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = "./exports"
+	}
+
+	asyncThreshold, _ := strconv.ParseInt(os.Getenv("EXPORT_ASYNC_THRESHOLD"), 10, 64)
+	if asyncThreshold <= 0 {
+		asyncThreshold = 10000
+	}
+
+	return &FinanceExportService{
+		reportsService:     reportsService,
+		exportJobRepo:      exportJobRepo,
+		activityLogService: activityLogService,
+		exportWorker:       exportWorker,
+		exportDir:          exportDir,
+		asyncThreshold:     asyncThreshold,
+	}
+}
+
+// ValidateRequest checks the resource type and format of an export request
+func (s *FinanceExportService) ValidateRequest(req ExportRequest) error {
+	switch req.ResourceType {
+	case ExportResourceMomoPayments, ExportResourceTransactions, ExportResourceExpenses,
+		ExportResourceIncomeStatement, ExportResourceCashFlow:
+	default:
+		return errors.New("resource must be one of momo-payments, transactions, expenses, income-statement, cash-flow")
+	}
+
+	switch req.Format {
+	case ExportFormatCSV, ExportFormatXLSX, ExportFormatPDF:
+	default:
+		return errors.New("format must be one of csv, xlsx, pdf")
+	}
+	return nil
+}
+
+// ShouldRunInBackground reports whether an export is large enough to be generated by the worker
+func (s *FinanceExportService) ShouldRunInBackground(req ExportRequest, scope *ReportScope) bool {
+	dataset := s.dataset(req, scope)
+	if dataset.count == nil {
+		return false
+	}
+	return dataset.count() > s.asyncThreshold
+}
+
+// Filename returns the download file name for an export
+func (s *FinanceExportService) Filename(req ExportRequest) string {
+	return fmt.Sprintf("%s-%s.%s", req.ResourceType, time.Now().Format("20060102-150405"), req.Format)
+}
+
+// ContentType returns the MIME type for an export format
+func (s *FinanceExportService) ContentType(format string) string {
+	switch format {
+	case ExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ExportFormatPDF:
+		return "application/pdf"
+	}
+	return "text/csv"
+}
+
+// LogExport records an export in the activity log
+func (s *FinanceExportService) LogExport(user ExportUser, req ExportRequest, scope *ReportScope) {
+	if user.UserID == 0 {
+		return
+	}
+	title := fmt.Sprintf("Exported %s for %s", req.ResourceType, scope.Name)
+	if err := s.activityLogService.LogExport(user.UserID, user.Username, user.Role, req.ResourceType, req.Format, title); err != nil {
+		fmt.Printf("Error logging export: %v\n", err)
+	}
+}
+
+// Write generates an export and writes it to w, returning the number of data rows written.
+// CSV and XLSX rows are streamed from the database in batches
+func (s *FinanceExportService) Write(req ExportRequest, scope *ReportScope, w io.Writer) (int, error) {
+	// Statements have their own PDF layout
+	if req.Format == ExportFormatPDF {
+		switch req.ResourceType {
+		case ExportResourceIncomeStatement:
+			statement, err := s.reportsService.GetIncomeStatement(scope, req.Filters["from_date"], req.Filters["to_date"])
+			if err != nil {
+				return 0, err
+			}
+			return len(statement.Income) + len(statement.Refunds) + len(statement.Expenses), s.reportsService.IncomeStatementPDF(statement, w)
+		case ExportResourceCashFlow:
+			summary, err := s.reportsService.GetCashFlowSummary(scope, req.Filters["from_date"], req.Filters["to_date"])
+			if err != nil {
+				return 0, err
+			}
+			return len(summary.ReceiptsByMode) + len(summary.PaymentsByAccount), s.reportsService.CashFlowSummaryPDF(summary, w)
+		}
+	}
+
+	dataset := s.dataset(req, scope)
+	switch req.Format {
+	case ExportFormatXLSX:
+		return writeXLSX(dataset, w)
+	case ExportFormatPDF:
+		return writePDF(dataset, scope, req, w)
+	}
+	return writeCSV(dataset, w)
+}
+
+// ============================================
+// Background exports
+// ============================================
+
+// QueueExport records an export job and hands it to the export worker
+func (s *FinanceExportService) QueueExport(req ExportRequest, scope *ReportScope, user ExportUser) (*models.ExportJob, error) {
+	if s.exportWorker == nil {
+		return nil, errors.New("background exports are not available")
+	}
+
+	req.ScopeType = scope.OwnerType
+	req.ScopeId = scope.OwnerId
+	params, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	paramsJSON := datatypes.JSON(params)
+
+	requestedBy := int64(user.UserID)
+	job := &models.ExportJob{
+		ResourceType: req.ResourceType,
+		Format:       req.Format,
+		Params:       &paramsJSON,
+		Status:       ExportStatusQueued,
+		RequestedBy:  &requestedBy,
+	}
+	job.SetOwner(scope.OwnerType, scope.OwnerId)
+
+	if err := s.exportJobRepo.Create(job); err != nil {
+		return nil, err
+	}
+
+	if err := s.exportWorker.EnqueueExport(job.ID); err != nil {
+		message := err.Error()
+		s.exportJobRepo.Update(job.ID, map[string]interface{}{
+			"status":        ExportStatusFailed,
+			"error_message": message,
+		})
+		return nil, errors.New("failed to queue export: " + message)
+	}
+
+	return job, nil
+}
+
+// ProcessExportJob generates the file for a queued export job. It is called by the export worker
+func (s *FinanceExportService) ProcessExportJob(id uint) error {
+	job, err := s.exportJobRepo.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	var req ExportRequest
+	if job.Params != nil {
+		if err := json.Unmarshal(*job.Params, &req); err != nil {
+			return s.failJob(job.ID, err)
+		}
+	}
+
+	now := time.Now()
+	s.exportJobRepo.Update(job.ID, map[string]interface{}{
+		"status":     ExportStatusProcessing,
+		"started_at": now,
+	})
+
+	if err := os.MkdirAll(s.exportDir, 0755); err != nil {
+		return s.failJob(job.ID, err)
+	}
+
+	fileName := s.Filename(req)
+	filePath := filepath.Join(s.exportDir, fmt.Sprintf("%d-%s", job.ID, fileName))
+	file, err := os.Create(filePath)
+	if err != nil {
+		return s.failJob(job.ID, err)
+	}
+
+	scope := s.reportsService.NewReportScope(req.ScopeType, req.ScopeId)
+	buffered := bufio.NewWriter(file)
+	rowCount, err := s.Write(req, scope, buffered)
+	if err == nil {
+		err = buffered.Flush()
+	}
+	file.Close()
+	if err != nil {
+		os.Remove(filePath)
+		return s.failJob(job.ID, err)
+	}
+
+	completedAt := time.Now()
+	return s.exportJobRepo.Update(job.ID, map[string]interface{}{
+		"status":       ExportStatusCompleted,
+		"file_path":    filePath,
+		"file_name":    fileName,
+		"row_count":    rowCount,
+		"completed_at": completedAt,
+		"expires_at":   completedAt.Add(exportFileRetention),
+	})
+}
+
+func (s *FinanceExportService) failJob(id uint, cause error) error {
+	s.exportJobRepo.Update(id, map[string]interface{}{
+		"status":        ExportStatusFailed,
+		"error_message": cause.Error(),
+	})
+	return cause
+}
+
+// ExpireExportFiles deletes the files of completed exports past their expiry and marks the jobs
+// expired. It is called periodically by the export worker
+func (s *FinanceExportService) ExpireExportFiles() error {
+	jobs, err := s.exportJobRepo.FindExpired(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if job.FilePath != nil {
+			if err := os.Remove(*job.FilePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to delete expired export file for job %d: %v", job.ID, err)
+				continue
+			}
+		}
+		if err := s.exportJobRepo.Update(job.ID, map[string]interface{}{
+			"status":    ExportStatusExpired,
+			"file_path": nil,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(jobs) > 0 {
+		log.Printf("Expired %d export files", len(jobs))
+	}
+	return nil
+}
+
+// GetExportJob retrieves an export job requested by the user
+func (s *FinanceExportService) GetExportJob(id uint, userID int64) (*models.ExportJob, error) {
+	job, err := s.exportJobRepo.FindForUser(id, userID)
+	if err != nil {
+		return nil, errors.New("export not found")
+	}
+	return job, nil
+}
+
+// ListExportJobs lists the user's export jobs, newest first
+func (s *FinanceExportService) ListExportJobs(userID int64, page, limit int) ([]models.ExportJob, int64, error) {
+	return s.exportJobRepo.ListForUser(userID, page, limit)
+}
+
+// ExportFile returns the stored file of a completed export job
+func (s *FinanceExportService) ExportFile(id uint, userID int64) (string, string, error) {
+	job, err := s.GetExportJob(id, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if job.Status == ExportStatusExpired || (job.ExpiresAt != nil && job.ExpiresAt.Before(time.Now())) {
+		return "", "", errors.New("export has expired")
+	}
+	if job.Status != ExportStatusCompleted || job.FilePath == nil {
+		return "", "", errors.New("export is not ready")
+	}
+	return *job.FilePath, stringValue(job.FileName), nil
+}
+
+// ============================================
+// Datasets
+// ============================================
+
+// exportDataset describes a tabular export. rows streams each data row to emit;
+// cells are strings, except amounts which are float64
+type exportDataset struct {
+	title        string
+	headers      []string
+	widths       []float64
+	amountColumn int // summed into the totals row, -1 for none
+	count        func() int64
+	rows         func(emit func([]interface{}) error) error
+}
+
+func (s *FinanceExportService) dataset(req ExportRequest, scope *ReportScope) exportDataset {
+	switch req.ResourceType {
+	case ExportResourceMomoPayments:
+		return s.momoPaymentsDataset(req.Filters, scope)
+	case ExportResourceTransactions:
+		return s.transactionsDataset(req.Filters, scope)
+	case ExportResourceExpenses:
+		return s.expensesDataset(req.Filters, scope)
+	case ExportResourceIncomeStatement:
+		return s.incomeStatementDataset(req.Filters, scope)
+	}
+	return s.cashFlowDataset(req.Filters, scope)
+}
+
+func (s *FinanceExportService) momoPaymentsDataset(filters map[string]string, scope *ReportScope) exportDataset {
+	schoolID, _ := strconv.ParseInt(filters["school_id"], 10, 64)
+	query := s.reportsService.momoPaymentsQuery(MomoPaymentFilters{
+		Status:      filters["status"],
+		SchoolID:    schoolID,
+		MomoNetwork: filters["momo_network"],
+		FromDate:    filters["from_date"],
+		ToDate:      filters["to_date"],
+	}, scope)
+
+	return exportDataset{
+		title:        "MoMo Payments",
+		headers:      []string{"ID", "Date", "School", "Fee", "Network", "MoMo Number", "Transaction ID", "Status", "Amount"},
+		widths:       []float64{15, 32, 0, 40, 20, 28, 38, 18, 25},
+		amountColumn: 8,
+		count:        countQuery(query),
+		rows: func(emit func([]interface{}) error) error {
+			return streamRows(query.Session(&gorm.Session{}).
+				Select("momo_payments.*, schools.name as school_name").
+				Order("momo_payments.created_at DESC"),
+				func(scan func(interface{}) error) error {
+					var payment MomoPaymentWithSchool
+					if err := scan(&payment); err != nil {
+						return err
+					}
+					return emit([]interface{}{
+						strconv.FormatUint(uint64(payment.ID), 10),
+						payment.CreatedAt.Format("2006-01-02 15:04"),
+						payment.SchoolName,
+						stringValue(payment.FeeName),
+						stringValue(payment.MomoNetwork),
+						stringValue(payment.MomoNumber),
+						stringValue(payment.MomoTransactionId),
+						stringValue(payment.Status),
+						floatValue(payment.Amount),
+					})
+				})
+		},
+	}
+}
+
+func (s *FinanceExportService) transactionsDataset(filters map[string]string, scope *ReportScope) exportDataset {
+	schoolID, _ := strconv.ParseInt(filters["school_id"], 10, 64)
+	accountID, _ := strconv.ParseInt(filters["finance_account_id"], 10, 64)
+	query := s.reportsService.financeTransactionsQuery(FinanceTransactionFilters{
+		SchoolID:         schoolID,
+		FinanceAccountID: accountID,
+		FinanceType:      filters["finance_type"],
+		FromDate:         filters["from_date"],
+		ToDate:           filters["to_date"],
+	}, scope)
+
+	return exportDataset{
+		title:        "Finance Transactions",
+		headers:      []string{"ID", "Date", "Receipt No", "School", "Account", "Type", "Mode", "Reference", "Amount"},
+		widths:       []float64{15, 22, 30, 0, 40, 25, 20, 30, 25},
+		amountColumn: 8,
+		count:        countQuery(query),
+		rows: func(emit func([]interface{}) error) error {
+			return streamRows(query.Session(&gorm.Session{}).
+				Select("finance_transactions.*, schools.name as school_name, finance_accounts.name as finance_account_name").
+				Order("finance_transactions.transaction_date DESC"),
+				func(scan func(interface{}) error) error {
+					var transaction FinanceTransactionWithDetails
+					if err := scan(&transaction); err != nil {
+						return err
+					}
+					return emit([]interface{}{
+						strconv.FormatUint(uint64(transaction.ID), 10),
+						transaction.TransactionDate.Format("2006-01-02"),
+						stringValue(transaction.ReceiptNo),
+						transaction.SchoolName,
+						transaction.FinanceAccountName,
+						stringValue(transaction.FinanceType),
+						stringValue(transaction.PaymentMode),
+						stringValue(transaction.ReferenceNo),
+						floatValue(transaction.Amount),
+					})
+				})
+		},
+	}
+}
+
+// expenseWithAccount is an expense row joined with its budget account name
+type expenseWithAccount struct {
+	models.FinanceExpense
+	AccountName string
+}
+
+func (s *FinanceExportService) expensesDataset(filters map[string]string, scope *ReportScope) exportDataset {
+	query := s.reportsService.db.Table("finance_expenses").
+		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_expenses.budget_account_id").
+		Where("finance_expenses.is_deleted = ?", false)
+	query = repositories.ApplyOwnerScopeToQuery(query, "finance_expenses", scope.owners)
+
+	if status := filters["status"]; status != "" {
+		query = query.Where("finance_expenses.status = ?", status)
+	}
+	if accountID := filters["budget_account_id"]; accountID != "" {
+		query = query.Where("finance_expenses.budget_account_id = ?", accountID)
+	}
+	from, to := parseDateRange(filters["from_date"], filters["to_date"])
+	if from != nil {
+		query = query.Where("finance_expenses.transaction_date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("finance_expenses.transaction_date < ?", *to)
+	}
+
+	return exportDataset{
+		title:        "Expenses",
+		headers:      []string{"Voucher No", "Date", "Title", "Account", "Status", "Mode", "Paid At", "Amount"},
+		widths:       []float64{35, 22, 0, 45, 22, 22, 25, 25},
+		amountColumn: 7,
+		count:        countQuery(query),
+		rows: func(emit func([]interface{}) error) error {
+			return streamRows(query.Session(&gorm.Session{}).
+				Select("finance_expenses.*, finance_accounts.name as account_name").
+				Order("finance_expenses.transaction_date DESC, finance_expenses.id DESC"),
+				func(scan func(interface{}) error) error {
+					var expense expenseWithAccount
+					if err := scan(&expense); err != nil {
+						return err
+					}
+					paidAt := ""
+					if expense.PaidAt != nil {
+						paidAt = expense.PaidAt.Format("2006-01-02")
+					}
+					return emit([]interface{}{
+						stringValue(expense.VoucherNo),
+						expense.TransactionDate.Format("2006-01-02"),
+						stringValue(expense.Title),
+						expense.AccountName,
+						expenseStatus(&expense.FinanceExpense),
+						stringValue(expense.PaymentMode),
+						paidAt,
+						floatValue(expense.Amount),
+					})
+				})
+		},
+	}
+}
+
+func (s *FinanceExportService) incomeStatementDataset(filters map[string]string, scope *ReportScope) exportDataset {
+	return exportDataset{
+		title:        "Income Statement",
+		headers:      []string{"Section", "Account Code", "Account", "Amount"},
+		amountColumn: -1,
+		rows: func(emit func([]interface{}) error) error {
+			statement, err := s.reportsService.GetIncomeStatement(scope, filters["from_date"], filters["to_date"])
+			if err != nil {
+				return err
+			}
+			sections := []struct {
+				name  string
+				lines []StatementLine
+				total float64
+			}{
+				{"Income", statement.Income, statement.GrossIncome},
+				{"Refunds", statement.Refunds, statement.TotalRefunds},
+				{"Expenses", statement.Expenses, statement.TotalExpenses},
+			}
+			for _, section := range sections {
+				if err := emitStatementLines(emit, section.name, section.lines, section.total); err != nil {
+					return err
+				}
+			}
+			if err := emitHeadings(emit, "Income headings", statement.IncomeHeadings); err != nil {
+				return err
+			}
+			if err := emitHeadings(emit, "Refund headings", statement.RefundHeadings); err != nil {
+				return err
+			}
+			if err := emitHeadings(emit, "Expense headings", statement.ExpenseHeadings); err != nil {
+				return err
+			}
+			if err := emit([]interface{}{"Result", "", "Net income", statement.NetIncome}); err != nil {
+				return err
+			}
+			return emit([]interface{}{"Result", "", "Net surplus / (deficit)", statement.NetSurplus})
+		},
+	}
+}
+
+func (s *FinanceExportService) cashFlowDataset(filters map[string]string, scope *ReportScope) exportDataset {
+	return exportDataset{
+		title:        "Cash Flow Summary",
+		headers:      []string{"Section", "Account Code", "Account", "Amount"},
+		amountColumn: -1,
+		rows: func(emit func([]interface{}) error) error {
+			summary, err := s.reportsService.GetCashFlowSummary(scope, filters["from_date"], filters["to_date"])
+			if err != nil {
+				return err
+			}
+			if err := emit([]interface{}{"Opening", "", "Opening balance", summary.OpeningBalance}); err != nil {
+				return err
+			}
+			if err := emitStatementLines(emit, "Receipts", summary.ReceiptsByMode, summary.TotalReceipts); err != nil {
+				return err
+			}
+			if err := emitStatementLines(emit, "Payments", summary.PaymentsByAccount, summary.TotalPayments); err != nil {
+				return err
+			}
+			if err := emit([]interface{}{"Payments", "", "Refunds paid", summary.RefundsPaid}); err != nil {
+				return err
+			}
+			if err := emitHeadings(emit, "Receipt headings", summary.ReceiptHeadings); err != nil {
+				return err
+			}
+			if err := emitHeadings(emit, "Payment headings", summary.PaymentHeadings); err != nil {
+				return err
+			}
+			if err := emit([]interface{}{"Movement", "", "Net cash flow", summary.NetCashFlow}); err != nil {
+				return err
+			}
+			return emit([]interface{}{"Closing", "", "Closing balance", summary.ClosingBalance})
+		},
+	}
+}
+
+func emitStatementLines(emit func([]interface{}) error, section string, lines []StatementLine, total float64) error {
+	for _, line := range lines {
+		if err := emit([]interface{}{section, line.AccountCode, line.AccountName, line.Amount}); err != nil {
+			return err
+		}
+	}
+	return emit([]interface{}{section, "", "Total " + section, total})
+}
+
+// emitHeadings writes rolled-up heading totals, indenting sub-headings under their parents
+func emitHeadings(emit func([]interface{}) error, section string, headings []AccountHeading) error {
+	for _, heading := range headings {
+		if err := emit([]interface{}{section, heading.AccountCode, strings.Repeat("  ", heading.Depth) + heading.AccountName, heading.Amount}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func countQuery(query *gorm.DB) func() int64 {
+	return func() int64 {
+		var total int64
+		query.Session(&gorm.Session{}).Count(&total)
+		return total
+	}
+}
+
+// streamRows iterates a query's result set one row at a time instead of loading it into memory
+func streamRows(query *gorm.DB, each func(scan func(interface{}) error) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	scan := func(dest interface{}) error {
+		return query.ScanRows(rows, dest)
+	}
+	for rows.Next() {
+		if err := each(scan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ============================================
+// Writers
+// ============================================
+
+func writeCSV(dataset exportDataset, w io.Writer) (int, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(dataset.headers); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err := dataset.rows(func(row []interface{}) error {
+		count++
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = exportCell(cell)
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	writer.Flush()
+	return count, writer.Error()
+}
+
+func writeXLSX(dataset exportDataset, w io.Writer) (int, error) {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	sheet := file.GetSheetName(0)
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		return 0, err
+	}
+
+	headers := make([]interface{}, len(dataset.headers))
+	for i, header := range dataset.headers {
+		headers[i] = header
+	}
+	if err := stream.SetRow("A1", headers); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = dataset.rows(func(row []interface{}) error {
+		count++
+		cell, _ := excelize.CoordinatesToCellName(1, count+1)
+		return stream.SetRow(cell, row)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err := stream.Flush(); err != nil {
+		return count, err
+	}
+	return count, file.Write(w)
+}
+
+func writePDF(dataset exportDataset, scope *ReportScope, req ExportRequest, w io.Writer) (int, error) {
+	orientation := "P"
+	if len(dataset.headers) > 5 {
+		orientation = "L"
+	}
+	report := utils.NewPDFReportWithOrientation(orientation, dataset.title+" - "+scope.Name,
+		statementPeriod(req.Filters["from_date"], req.Filters["to_date"]))
+	report.BeginTable(dataset.headers, dataset.widths)
+
+	count := 0
+	var total float64
+	err := dataset.rows(func(row []interface{}) error {
+		count++
+		record := make([]string, len(row))
+		for i, cell := range row {
+			record[i] = exportCell(cell)
+			if i == dataset.amountColumn {
+				if amount, ok := cell.(float64); ok {
+					total += amount
+				}
+			}
+		}
+		report.TableRow(record)
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if dataset.amountColumn >= 0 {
+		totals := make([]string, len(dataset.headers))
+		totals[0] = fmt.Sprintf("Total (%d)", count)
+		totals[dataset.amountColumn] = utils.FormatAmount(roundAmount(total))
+		report.TableTotalRow(totals)
+	}
+
+	return count, report.Output(w)
+}
+
+func exportCell(cell interface{}) string {
+	switch value := cell.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', 2, 64)
+	}
+	return fmt.Sprint(cell)
+}
@@ -1,7 +1,14 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"io"
+	"sort"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -56,14 +63,31 @@ type FinanceTransactionStats struct {
 	TotalExpense float64 `json:"total_expense"`
 }
 
-func (s *FinanceReportsService) GetMomoPayments(filters MomoPaymentFilters, page, limit int) ([]MomoPaymentWithSchool, int64, error) {
+// GetMomoPayments lists MoMo payments within a report scope: payments owned by the scope's
+// owners or made by schools in its zones
+func (s *FinanceReportsService) GetMomoPayments(filters MomoPaymentFilters, page, limit int, scope *ReportScope) ([]MomoPaymentWithSchool, int64, error) {
 	var payments []MomoPaymentWithSchool
 	var total int64
 
+	query := s.momoPaymentsQuery(filters, scope)
+
+	// Get total count
+	query.Session(&gorm.Session{}).Count(&total)
+
+	// Pagination
+	offset := (page - 1) * limit
+	err := query.Select("momo_payments.*, schools.name as school_name").
+		Order("momo_payments.created_at DESC").Offset(offset).Limit(limit).Scan(&payments).Error
+
+	return payments, total, err
+}
+
+// momoPaymentsQuery builds the filtered, scoped MoMo payments query shared by listings and exports
+func (s *FinanceReportsService) momoPaymentsQuery(filters MomoPaymentFilters, scope *ReportScope) *gorm.DB {
 	query := s.db.Table("momo_payments").
-		Select("momo_payments.*, schools.name as school_name").
 		Joins("LEFT JOIN schools ON schools.id = momo_payments.school_id").
 		Where("momo_payments.is_deleted IS NULL OR momo_payments.is_deleted = ?", false)
+	query = repositories.ApplySchoolScopeAtDateToQuery(query, "momo_payments", "created_at", scope.owners)
 
 	// Apply filters
 	if filters.Status != "" {
@@ -90,63 +114,26 @@ func (s *FinanceReportsService) GetMomoPayments(filters MomoPaymentFilters, page
 		}
 	}
 
-	// Get total count
-	countQuery := s.db.Table("momo_payments").
-		Where("momo_payments.is_deleted IS NULL OR momo_payments.is_deleted = ?", false)
-
-	if filters.Status != "" {
-		countQuery = countQuery.Where("momo_payments.status = ?", filters.Status)
-	}
-	if filters.SchoolID > 0 {
-		countQuery = countQuery.Where("momo_payments.school_id = ?", filters.SchoolID)
-	}
-	if filters.MomoNetwork != "" {
-		countQuery = countQuery.Where("momo_payments.momo_network = ?", filters.MomoNetwork)
-	}
-	if filters.FromDate != "" {
-		fromTime, err := time.Parse("2006-01-02", filters.FromDate)
-		if err == nil {
-			countQuery = countQuery.Where("momo_payments.created_at >= ?", fromTime)
-		}
-	}
-	if filters.ToDate != "" {
-		toTime, err := time.Parse("2006-01-02", filters.ToDate)
-		if err == nil {
-			toTime = toTime.Add(24 * time.Hour)
-			countQuery = countQuery.Where("momo_payments.created_at < ?", toTime)
-		}
-	}
-	countQuery.Count(&total)
-
-	// Pagination
-	offset := (page - 1) * limit
-	err := query.Order("momo_payments.created_at DESC").Offset(offset).Limit(limit).Scan(&payments).Error
-
-	return payments, total, err
+	return query
 }
 
-func (s *FinanceReportsService) GetMomoPaymentStats() MomoPaymentStats {
+// GetMomoPaymentStats counts MoMo payments by status within a report scope
+func (s *FinanceReportsService) GetMomoPaymentStats(scope *ReportScope) MomoPaymentStats {
 	var stats MomoPaymentStats
 
-	s.db.Table("momo_payments").
-		Where("is_deleted IS NULL OR is_deleted = ?", false).
-		Count(&stats.Total)
-
-	s.db.Table("momo_payments").
-		Where("(is_deleted IS NULL OR is_deleted = ?) AND status = ?", false, "success").
-		Count(&stats.Successful)
-
-	s.db.Table("momo_payments").
-		Where("(is_deleted IS NULL OR is_deleted = ?) AND status IN ?", false, []string{"pending", "created"}).
-		Count(&stats.Pending)
+	base := func() *gorm.DB {
+		query := s.db.Table("momo_payments").
+			Where("momo_payments.is_deleted IS NULL OR momo_payments.is_deleted = ?", false)
+		return repositories.ApplySchoolScopeAtDateToQuery(query, "momo_payments", "created_at", scope.owners)
+	}
 
-	s.db.Table("momo_payments").
-		Where("(is_deleted IS NULL OR is_deleted = ?) AND status = ?", false, "failed").
-		Count(&stats.Failed)
+	base().Count(&stats.Total)
+	base().Where("status = ?", "success").Count(&stats.Successful)
+	base().Where("status IN ?", []string{"pending", "created"}).Count(&stats.Pending)
+	base().Where("status = ?", "failed").Count(&stats.Failed)
 
 	var totalAmount *float64
-	s.db.Table("momo_payments").
-		Where("(is_deleted IS NULL OR is_deleted = ?) AND status = ?", false, "success").
+	base().Where("status = ?", "success").
 		Select("COALESCE(SUM(amount), 0)").
 		Scan(&totalAmount)
 
@@ -157,14 +144,32 @@ func (s *FinanceReportsService) GetMomoPaymentStats() MomoPaymentStats {
 	return stats
 }
 
-func (s *FinanceReportsService) GetFinanceTransactions(filters FinanceTransactionFilters, page, limit int) ([]FinanceTransactionWithDetails, int64, error) {
+// GetFinanceTransactions lists finance transactions within a report scope: transactions owned by
+// the scope's owners or paid by schools in its zones
+func (s *FinanceReportsService) GetFinanceTransactions(filters FinanceTransactionFilters, page, limit int, scope *ReportScope) ([]FinanceTransactionWithDetails, int64, error) {
 	var transactions []FinanceTransactionWithDetails
 	var total int64
 
+	query := s.financeTransactionsQuery(filters, scope)
+
+	// Get total count
+	query.Session(&gorm.Session{}).Count(&total)
+
+	// Pagination
+	offset := (page - 1) * limit
+	err := query.Select("finance_transactions.*, schools.name as school_name, finance_accounts.name as finance_account_name").
+		Order("finance_transactions.transaction_date DESC").Offset(offset).Limit(limit).Scan(&transactions).Error
+
+	return transactions, total, err
+}
+
+// financeTransactionsQuery builds the filtered, scoped finance transactions query shared by listings and exports
+func (s *FinanceReportsService) financeTransactionsQuery(filters FinanceTransactionFilters, scope *ReportScope) *gorm.DB {
 	query := s.db.Table("finance_transactions").
-		Select("finance_transactions.*, schools.name as school_name, finance_accounts.name as finance_account_name").
 		Joins("LEFT JOIN schools ON schools.id = finance_transactions.school_id").
-		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_transactions.finance_account_id")
+		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_transactions.finance_account_id").
+		Where("finance_transactions.deleted_at IS NULL")
+	query = repositories.ApplySchoolScopeAtDateToQuery(query, "finance_transactions", "transaction_date", scope.owners)
 
 	// Apply filters
 	if filters.SchoolID > 0 {
@@ -190,49 +195,24 @@ func (s *FinanceReportsService) GetFinanceTransactions(filters FinanceTransactio
 		}
 	}
 
-	// Get total count
-	countQuery := s.db.Table("finance_transactions")
-	if filters.SchoolID > 0 {
-		countQuery = countQuery.Where("school_id = ?", filters.SchoolID)
-	}
-	if filters.FinanceAccountID > 0 {
-		countQuery = countQuery.Where("finance_account_id = ?", filters.FinanceAccountID)
-	}
-	if filters.FinanceType != "" {
-		countQuery = countQuery.Where("finance_type = ?", filters.FinanceType)
-	}
-	if filters.FromDate != "" {
-		fromTime, err := time.Parse("2006-01-02", filters.FromDate)
-		if err == nil {
-			countQuery = countQuery.Where("transaction_date >= ?", fromTime)
-		}
-	}
-	if filters.ToDate != "" {
-		toTime, err := time.Parse("2006-01-02", filters.ToDate)
-		if err == nil {
-			toTime = toTime.Add(24 * time.Hour)
-			countQuery = countQuery.Where("transaction_date < ?", toTime)
-		}
-	}
-	countQuery.Count(&total)
-
-	// Pagination
-	offset := (page - 1) * limit
-	err := query.Order("finance_transactions.transaction_date DESC").Offset(offset).Limit(limit).Scan(&transactions).Error
-
-	return transactions, total, err
+	return query
 }
 
-func (s *FinanceReportsService) GetFinanceTransactionStats() FinanceTransactionStats {
+// GetFinanceTransactionStats totals income and expense transactions within a report scope
+func (s *FinanceReportsService) GetFinanceTransactionStats(scope *ReportScope) FinanceTransactionStats {
 	var stats FinanceTransactionStats
 
-	s.db.Table("finance_transactions").Count(&stats.Total)
+	base := func() *gorm.DB {
+		query := s.db.Table("finance_transactions").
+			Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_transactions.finance_account_id")
+		return repositories.ApplySchoolScopeAtDateToQuery(query, "finance_transactions", "transaction_date", scope.owners)
+	}
+
+	base().Count(&stats.Total)
 
-	// Calculate income (finance_type = 'income' or based on finance_account.is_income)
+	// Calculate income (based on finance_account.is_income)
 	var totalIncome *float64
-	s.db.Table("finance_transactions").
-		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_transactions.finance_account_id").
-		Where("finance_accounts.is_income = ?", true).
+	base().Where("finance_accounts.is_income = ?", true).
 		Select("COALESCE(SUM(finance_transactions.amount), 0)").
 		Scan(&totalIncome)
 
@@ -240,11 +220,9 @@ func (s *FinanceReportsService) GetFinanceTransactionStats() FinanceTransactionS
 		stats.TotalIncome = *totalIncome
 	}
 
-	// Calculate expense (finance_type = 'expense' or based on finance_account.is_income = false)
+	// Calculate expense (based on finance_account.is_income = false)
 	var totalExpense *float64
-	s.db.Table("finance_transactions").
-		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_transactions.finance_account_id").
-		Where("finance_accounts.is_income = ?", false).
+	base().Where("finance_accounts.is_income = ?", false).
 		Select("COALESCE(SUM(finance_transactions.amount), 0)").
 		Scan(&totalExpense)
 
@@ -254,3 +232,648 @@ func (s *FinanceReportsService) GetFinanceTransactionStats() FinanceTransactionS
 
 	return stats
 }
+
+// NetIncomeSummary compares income received with approved expenses for a period
+type NetIncomeSummary struct {
+	TotalIncome          float64 `json:"total_income"`
+	ApprovedExpenses     float64 `json:"approved_expenses"`
+	PaidExpenses         float64 `json:"paid_expenses"`
+	NetIncome            float64 `json:"net_income"`
+	NetCashPosition      float64 `json:"net_cash_position"`
+	IncomeCount          int64   `json:"income_count"`
+	ApprovedExpenseCount int64   `json:"approved_expense_count"`
+	FromDate             string  `json:"from_date,omitempty"`
+	ToDate               string  `json:"to_date,omitempty"`
+}
+
+// GetNetIncome returns income from finance transactions less approved expenses within a report scope
+func (s *FinanceReportsService) GetNetIncome(scope *ReportScope, fromDate, toDate string) NetIncomeSummary {
+	summary := NetIncomeSummary{FromDate: fromDate, ToDate: toDate}
+	from, to := parseDateRange(fromDate, toDate)
+
+	incomeQuery := s.db.Table("finance_transactions").
+		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_transactions.finance_account_id").
+		Where("finance_accounts.is_income = ?", true)
+	if from != nil {
+		incomeQuery = incomeQuery.Where("finance_transactions.transaction_date >= ?", *from)
+	}
+	if to != nil {
+		incomeQuery = incomeQuery.Where("finance_transactions.transaction_date < ?", *to)
+	}
+	incomeQuery = repositories.ApplyOwnerScopeToQuery(incomeQuery, "finance_transactions", scope.owners)
+
+	incomeQuery.Session(&gorm.Session{}).Count(&summary.IncomeCount)
+	incomeQuery.Session(&gorm.Session{}).Select("COALESCE(SUM(finance_transactions.amount), 0)").Scan(&summary.TotalIncome)
+
+	expenseStats := repositories.NewFinanceExpenseRepository(s.db).GetStatsForScope(from, to, scope.owners)
+	summary.ApprovedExpenses = expenseStats.ApprovedAmount
+	summary.PaidExpenses = expenseStats.PaidAmount
+	summary.ApprovedExpenseCount = expenseStats.Approved
+
+	summary.NetIncome = summary.TotalIncome - summary.ApprovedExpenses
+	summary.NetCashPosition = summary.TotalIncome - summary.PaidExpenses
+
+	return summary
+}
+
+// ============================================
+// Financial statements (income statement and cash-flow summary)
+// ============================================
+
+// ReportScope is the owner a statement is prepared for, with the owners it consolidates
+type ReportScope struct {
+	OwnerType string `json:"owner_type"`
+	OwnerId   int64  `json:"owner_id"`
+	Name      string `json:"name"`
+
+	owners *repositories.OwnerScope
+}
+
+// ResolveReportScope picks the owner a report covers. By default this is the caller's own owner;
+// national users may drill down to any region or zone, and region users to zones in their region
+func (s *FinanceReportsService) ResolveReportScope(regionID, zoneID int64, ownerCtx *utils.OwnerContext) (*ReportScope, error) {
+	if ownerCtx == nil {
+		return nil, errors.New("access denied")
+	}
+
+	var ownerType string
+	var ownerID int64
+
+	switch {
+	case ownerCtx.CanViewAllHierarchyData():
+		ownerType, ownerID = utils.OwnerTypeNational, utils.DefaultNationalOwnerID
+		if regionID > 0 {
+			ownerType, ownerID = utils.OwnerTypeRegion, regionID
+		}
+		if zoneID > 0 {
+			ownerType, ownerID = utils.OwnerTypeZone, zoneID
+		}
+	case ownerCtx.IsRegionAdmin():
+		if regionID > 0 && regionID != ownerCtx.OwnerID {
+			return nil, errors.New("access denied")
+		}
+		ownerType, ownerID = utils.OwnerTypeRegion, ownerCtx.OwnerID
+		if zoneID > 0 {
+			var zone models.Zone
+			if err := s.db.Select("id, region_id").First(&zone, zoneID).Error; err != nil {
+				return nil, errors.New("zone not found")
+			}
+			if zone.RegionId == nil || *zone.RegionId != ownerCtx.OwnerID {
+				return nil, errors.New("access denied")
+			}
+			ownerType, ownerID = utils.OwnerTypeZone, zoneID
+		}
+	case ownerCtx.IsZoneAdmin():
+		if zoneID > 0 && zoneID != ownerCtx.OwnerID {
+			return nil, errors.New("access denied")
+		}
+		if regionID > 0 {
+			return nil, errors.New("access denied")
+		}
+		ownerType, ownerID = utils.OwnerTypeZone, ownerCtx.OwnerID
+	default:
+		return nil, errors.New("access denied")
+	}
+
+	return s.NewReportScope(ownerType, ownerID), nil
+}
+
+// NewReportScope builds the scope for an owner without access checks, e.g. to rebuild the
+// scope a background export was requested with
+func (s *FinanceReportsService) NewReportScope(ownerType string, ownerID int64) *ReportScope {
+	return &ReportScope{
+		OwnerType: ownerType,
+		OwnerId:   ownerID,
+		Name:      s.ownerName(ownerType, ownerID),
+		owners:    repositories.ResolveOwnerScope(s.db, ownerType, ownerID),
+	}
+}
+
+// StatementLine is one finance account's total on a statement
+type StatementLine struct {
+	FinanceAccountId int64   `json:"finance_account_id"`
+	AccountCode      string  `json:"account_code"`
+	AccountName      string  `json:"account_name"`
+	Amount           float64 `json:"amount"`
+}
+
+// StatementBreakdown is a child owner's share of a consolidated statement
+// (the regions of a national report, or the zones of a region report)
+type StatementBreakdown struct {
+	OwnerType string  `json:"owner_type"`
+	OwnerId   int64   `json:"owner_id"`
+	Name      string  `json:"name"`
+	Inflows   float64 `json:"inflows"`
+	Outflows  float64 `json:"outflows"`
+	Net       float64 `json:"net"`
+}
+
+// IncomeStatement reports income less refunds and expenses for a period, by finance account
+type IncomeStatement struct {
+	Scope         *ReportScope         `json:"scope"`
+	FromDate      string               `json:"from_date,omitempty"`
+	ToDate        string               `json:"to_date,omitempty"`
+	Income        []StatementLine      `json:"income"`
+	GrossIncome   float64              `json:"gross_income"`
+	Refunds       []StatementLine      `json:"refunds"`
+	TotalRefunds  float64              `json:"total_refunds"`
+	NetIncome     float64              `json:"net_income"`
+	Expenses      []StatementLine      `json:"expenses"`
+	TotalExpenses float64              `json:"total_expenses"`
+	NetSurplus    float64              `json:"net_surplus"`
+	Breakdown     []StatementBreakdown `json:"breakdown"`
+	// Heading totals roll the account lines up the chart of accounts
+	IncomeHeadings  []AccountHeading `json:"income_headings"`
+	RefundHeadings  []AccountHeading `json:"refund_headings"`
+	ExpenseHeadings []AccountHeading `json:"expense_headings"`
+}
+
+// CashFlowSummary reports cash received and paid out for a period, with opening and closing balances
+type CashFlowSummary struct {
+	Scope             *ReportScope         `json:"scope"`
+	FromDate          string               `json:"from_date,omitempty"`
+	ToDate            string               `json:"to_date,omitempty"`
+	OpeningBalance    float64              `json:"opening_balance"`
+	ReceiptsByMode    []StatementLine      `json:"receipts_by_mode"`
+	ReceiptsByAccount []StatementLine      `json:"receipts_by_account"`
+	TotalReceipts     float64              `json:"total_receipts"`
+	RefundsPaid       float64              `json:"refunds_paid"`
+	PaymentsByAccount []StatementLine      `json:"payments_by_account"`
+	TotalPayments     float64              `json:"total_payments"`
+	NetCashFlow       float64              `json:"net_cash_flow"`
+	ClosingBalance    float64              `json:"closing_balance"`
+	Breakdown         []StatementBreakdown `json:"breakdown"`
+	ReceiptHeadings   []AccountHeading     `json:"receipt_headings"`
+	PaymentHeadings   []AccountHeading     `json:"payment_headings"`
+}
+
+// ownerAccountTotal is an aggregate row grouped by owner and finance account (or payment mode)
+type ownerAccountTotal struct {
+	OwnerType        string
+	OwnerId          int64
+	FinanceAccountId int64
+	AccountCode      string
+	AccountName      string
+	Total            float64
+}
+
+// GetIncomeStatement builds the income statement for a scope. Income is recognised from finance
+// transactions on income accounts, refunds are shown separately, and expenses are approved
+// expenses by budget account plus legacy transactions posted to non-income accounts
+func (s *FinanceReportsService) GetIncomeStatement(scope *ReportScope, fromDate, toDate string) (*IncomeStatement, error) {
+	from, to := parseDateRange(fromDate, toDate)
+
+	income, err := s.transactionTotals(scope, from, to, "income", "finance_transactions.finance_account_id")
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.transactionTotals(scope, from, to, "refund", "finance_transactions.finance_account_id")
+	if err != nil {
+		return nil, err
+	}
+	legacyOutflows, err := s.transactionTotals(scope, from, to, "outflow", "finance_transactions.finance_account_id")
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.expenseTotals(scope, from, to, false)
+	if err != nil {
+		return nil, err
+	}
+	expenses = append(expenses, legacyOutflows...)
+
+	statement := &IncomeStatement{
+		Scope:    scope,
+		FromDate: fromDate,
+		ToDate:   toDate,
+		Income:   accountLines(income),
+		Refunds:  accountLines(refunds),
+		Expenses: accountLines(expenses),
+	}
+	statement.GrossIncome = sumLines(statement.Income)
+	statement.TotalRefunds = sumLines(statement.Refunds)
+	statement.NetIncome = roundAmount(statement.GrossIncome - statement.TotalRefunds)
+	statement.TotalExpenses = sumLines(statement.Expenses)
+	statement.NetSurplus = roundAmount(statement.NetIncome - statement.TotalExpenses)
+	statement.Breakdown = s.breakdown(scope, income, append(refunds, expenses...))
+	statement.IncomeHeadings = s.lineHeadings(statement.Income)
+	statement.RefundHeadings = s.lineHeadings(statement.Refunds)
+	statement.ExpenseHeadings = s.lineHeadings(statement.Expenses)
+
+	return statement, nil
+}
+
+// GetCashFlowSummary builds the cash-flow summary for a scope. Receipts are income transactions,
+// payments are refunds and expenses marked paid, and the opening balance is the net of all
+// earlier cash movements for the same owners
+func (s *FinanceReportsService) GetCashFlowSummary(scope *ReportScope, fromDate, toDate string) (*CashFlowSummary, error) {
+	from, to := parseDateRange(fromDate, toDate)
+
+	receiptsByMode, err := s.transactionTotals(scope, from, to, "income", "finance_transactions.payment_mode")
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := s.transactionTotals(scope, from, to, "income", "finance_transactions.finance_account_id")
+	if err != nil {
+		return nil, err
+	}
+	refunds, err := s.transactionTotals(scope, from, to, "refund", "finance_transactions.finance_account_id")
+	if err != nil {
+		return nil, err
+	}
+	legacyOutflows, err := s.transactionTotals(scope, from, to, "outflow", "finance_transactions.finance_account_id")
+	if err != nil {
+		return nil, err
+	}
+	paidExpenses, err := s.expenseTotals(scope, from, to, true)
+	if err != nil {
+		return nil, err
+	}
+	payments := append(paidExpenses, legacyOutflows...)
+
+	summary := &CashFlowSummary{
+		Scope:             scope,
+		FromDate:          fromDate,
+		ToDate:            toDate,
+		ReceiptsByMode:    modeLines(receiptsByMode),
+		ReceiptsByAccount: accountLines(receipts),
+		PaymentsByAccount: accountLines(payments),
+	}
+	summary.TotalReceipts = sumLines(summary.ReceiptsByAccount)
+	summary.RefundsPaid = sumLines(accountLines(refunds))
+	summary.TotalPayments = sumLines(summary.PaymentsByAccount)
+	summary.NetCashFlow = roundAmount(summary.TotalReceipts - summary.RefundsPaid - summary.TotalPayments)
+
+	if from != nil {
+		summary.OpeningBalance = s.cashBalanceBefore(scope, *from)
+	}
+	summary.ClosingBalance = roundAmount(summary.OpeningBalance + summary.NetCashFlow)
+	summary.Breakdown = s.breakdown(scope, receipts, append(refunds, payments...))
+	summary.ReceiptHeadings = s.lineHeadings(summary.ReceiptsByAccount)
+	summary.PaymentHeadings = s.lineHeadings(summary.PaymentsByAccount)
+
+	return summary, nil
+}
+
+// cashBalanceBefore nets every receipt, refund and paid expense dated before a cut-off
+func (s *FinanceReportsService) cashBalanceBefore(scope *ReportScope, before time.Time) float64 {
+	var balance float64
+	for _, kind := range []string{"income", "refund", "outflow"} {
+		rows, _ := s.transactionTotals(scope, nil, &before, kind, "finance_transactions.finance_account_id")
+		for _, row := range rows {
+			if kind == "income" {
+				balance += row.Total
+			} else {
+				balance -= row.Total
+			}
+		}
+	}
+	paid, _ := s.expenseTotals(scope, nil, &before, true)
+	for _, row := range paid {
+		balance -= row.Total
+	}
+	return roundAmount(balance)
+}
+
+// transactionTotals sums finance transactions by owner and the given grouping column.
+// kind selects income (income accounts, excluding refunds), refund, or outflow
+// (non-income accounts, excluding refunds)
+func (s *FinanceReportsService) transactionTotals(scope *ReportScope, from, to *time.Time, kind, groupBy string) ([]ownerAccountTotal, error) {
+	var rows []ownerAccountTotal
+
+	query := s.db.Table("finance_transactions").
+		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_transactions.finance_account_id").
+		Where("finance_transactions.deleted_at IS NULL")
+
+	switch kind {
+	case "refund":
+		query = query.Where("finance_transactions.finance_type = ?", "Refund")
+	case "income":
+		query = query.Where("finance_accounts.is_income = ?", true).
+			Where("finance_transactions.finance_type IS NULL OR finance_transactions.finance_type != ?", "Refund")
+	case "outflow":
+		query = query.Where("finance_accounts.is_income = ?", false).
+			Where("finance_transactions.finance_type IS NULL OR finance_transactions.finance_type != ?", "Refund")
+	}
+	if from != nil {
+		query = query.Where("finance_transactions.transaction_date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("finance_transactions.transaction_date < ?", *to)
+	}
+	query = repositories.ApplyOwnerScopeToQuery(query, "finance_transactions", scope.owners)
+
+	selectAccount := "COALESCE(finance_transactions.finance_account_id, 0) AS finance_account_id, COALESCE(MAX(finance_accounts.code), '') AS account_code, COALESCE(MAX(finance_accounts.name), '') AS account_name"
+	if groupBy == "finance_transactions.payment_mode" {
+		selectAccount = "0 AS finance_account_id, '' AS account_code, COALESCE(finance_transactions.payment_mode, '') AS account_name"
+	}
+
+	err := query.Select("COALESCE(finance_transactions.owner_type, '') AS owner_type, COALESCE(finance_transactions.owner_id, 0) AS owner_id, " +
+		selectAccount + ", COALESCE(SUM(finance_transactions.amount), 0) AS total").
+		Group("finance_transactions.owner_type, finance_transactions.owner_id, " + groupBy).
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// expenseTotals sums approved expenses (or, when paid is set, paid expenses by payment date)
+// by owner and budget account
+func (s *FinanceReportsService) expenseTotals(scope *ReportScope, from, to *time.Time, paid bool) ([]ownerAccountTotal, error) {
+	var rows []ownerAccountTotal
+
+	dateColumn := "finance_expenses.transaction_date"
+	query := s.db.Table("finance_expenses").
+		Joins("LEFT JOIN finance_accounts ON finance_accounts.id = finance_expenses.budget_account_id").
+		Where("finance_expenses.is_deleted = ? AND finance_expenses.is_approved = ?", false, true)
+	if paid {
+		dateColumn = "finance_expenses.paid_at"
+		query = query.Where("finance_expenses.status = ?", ExpenseStatusPaid)
+	}
+	if from != nil {
+		query = query.Where(dateColumn+" >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where(dateColumn+" < ?", *to)
+	}
+	query = repositories.ApplyOwnerScopeToQuery(query, "finance_expenses", scope.owners)
+
+	err := query.Select("COALESCE(finance_expenses.owner_type, '') AS owner_type, COALESCE(finance_expenses.owner_id, 0) AS owner_id, " +
+		"COALESCE(finance_expenses.budget_account_id, 0) AS finance_account_id, COALESCE(MAX(finance_accounts.code), '') AS account_code, " +
+		"COALESCE(MAX(finance_accounts.name), '') AS account_name, COALESCE(SUM(finance_expenses.amount), 0) AS total").
+		Group("finance_expenses.owner_type, finance_expenses.owner_id, finance_expenses.budget_account_id").
+		Scan(&rows).Error
+
+	return rows, err
+}
+
+// breakdown splits inflows and outflows between the scope's children: regions (and the national
+// office) for a national scope, or the region office and its zones for a region scope
+func (s *FinanceReportsService) breakdown(scope *ReportScope, inflows, outflows []ownerAccountTotal) []StatementBreakdown {
+	if scope.OwnerType == utils.OwnerTypeZone {
+		return []StatementBreakdown{}
+	}
+
+	zoneRegions := make(map[int64]int64)
+	if scope.OwnerType == utils.OwnerTypeNational {
+		var zones []models.Zone
+		s.db.Select("id, region_id").Find(&zones)
+		for _, zone := range zones {
+			if zone.RegionId != nil {
+				zoneRegions[int64(zone.ID)] = *zone.RegionId
+			}
+		}
+	}
+
+	// childOf maps a row's owner to the child it rolls up into
+	childOf := func(ownerType string, ownerID int64) (string, int64) {
+		if scope.OwnerType == utils.OwnerTypeNational && ownerType == utils.OwnerTypeZone {
+			if regionID, ok := zoneRegions[ownerID]; ok {
+				return utils.OwnerTypeRegion, regionID
+			}
+		}
+		if ownerType == "" {
+			return utils.OwnerTypeNational, utils.DefaultNationalOwnerID
+		}
+		return ownerType, ownerID
+	}
+
+	children := make(map[string]*StatementBreakdown)
+	var order []string
+	add := func(row ownerAccountTotal, inflow bool) {
+		ownerType, ownerID := childOf(row.OwnerType, row.OwnerId)
+		key := fmt.Sprintf("%s:%d", ownerType, ownerID)
+		child, ok := children[key]
+		if !ok {
+			child = &StatementBreakdown{OwnerType: ownerType, OwnerId: ownerID, Name: s.ownerName(ownerType, ownerID)}
+			children[key] = child
+			order = append(order, key)
+		}
+		if inflow {
+			child.Inflows += row.Total
+		} else {
+			child.Outflows += row.Total
+		}
+	}
+	for _, row := range inflows {
+		add(row, true)
+	}
+	for _, row := range outflows {
+		add(row, false)
+	}
+
+	result := make([]StatementBreakdown, 0, len(order))
+	for _, key := range order {
+		child := children[key]
+		child.Inflows = roundAmount(child.Inflows)
+		child.Outflows = roundAmount(child.Outflows)
+		child.Net = roundAmount(child.Inflows - child.Outflows)
+		result = append(result, *child)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].OwnerType != result[j].OwnerType {
+			return result[i].OwnerType < result[j].OwnerType
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// ownerName returns the display name of a region or zone, or "National" for the national office
+func (s *FinanceReportsService) ownerName(ownerType string, ownerID int64) string {
+	var name *string
+	switch ownerType {
+	case utils.OwnerTypeRegion:
+		s.db.Model(&models.Region{}).Where("id = ?", ownerID).Select("name").Scan(&name)
+	case utils.OwnerTypeZone:
+		s.db.Model(&models.Zone{}).Where("id = ?", ownerID).Select("name").Scan(&name)
+	default:
+		return "National"
+	}
+	if name == nil {
+		return fmt.Sprintf("%s %d", ownerType, ownerID)
+	}
+	return *name
+}
+
+// accountLines merges owner-level rows into one line per finance account, ordered by account code
+func accountLines(rows []ownerAccountTotal) []StatementLine {
+	byAccount := make(map[int64]*StatementLine)
+	for _, row := range rows {
+		line, ok := byAccount[row.FinanceAccountId]
+		if !ok {
+			line = &StatementLine{FinanceAccountId: row.FinanceAccountId, AccountCode: row.AccountCode, AccountName: row.AccountName}
+			if row.FinanceAccountId == 0 {
+				line.AccountName = "Unallocated"
+			}
+			byAccount[row.FinanceAccountId] = line
+		}
+		line.Amount += row.Total
+	}
+
+	lines := make([]StatementLine, 0, len(byAccount))
+	for _, line := range byAccount {
+		line.Amount = roundAmount(line.Amount)
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].AccountCode != lines[j].AccountCode {
+			return lines[i].AccountCode < lines[j].AccountCode
+		}
+		return lines[i].AccountName < lines[j].AccountName
+	})
+	return lines
+}
+
+// modeLines merges owner-level rows grouped by payment mode into one line per mode
+func modeLines(rows []ownerAccountTotal) []StatementLine {
+	byMode := make(map[string]float64)
+	for _, row := range rows {
+		mode := row.AccountName
+		if mode == "" {
+			mode = "unspecified"
+		}
+		byMode[mode] += row.Total
+	}
+
+	lines := make([]StatementLine, 0, len(byMode))
+	for mode, total := range byMode {
+		lines = append(lines, StatementLine{AccountName: mode, Amount: roundAmount(total)})
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].AccountName < lines[j].AccountName })
+	return lines
+}
+
+// lineHeadings rolls statement lines up into their chart of accounts headings
+func (s *FinanceReportsService) lineHeadings(lines []StatementLine) []AccountHeading {
+	totals := make(map[int64]float64)
+	for _, line := range lines {
+		if line.FinanceAccountId > 0 {
+			totals[line.FinanceAccountId] += line.Amount
+		}
+	}
+	return accountHeadings(func(ids []int64) ([]models.FinanceAccount, error) {
+		return repositories.FindAccountsWithAncestors(s.db, ids)
+	}, totals)
+}
+
+func sumLines(lines []StatementLine) float64 {
+	var total float64
+	for _, line := range lines {
+		total += line.Amount
+	}
+	return roundAmount(total)
+}
+
+// IncomeStatementPDF renders an income statement as a PDF document
+func (s *FinanceReportsService) IncomeStatementPDF(statement *IncomeStatement, w io.Writer) error {
+	report := utils.NewPDFReport("Income Statement - "+statement.Scope.Name, statementPeriod(statement.FromDate, statement.ToDate))
+
+	report.Heading("Income")
+	for _, line := range statement.Income {
+		report.AmountRow(statementLabel(line), line.Amount, false)
+	}
+	report.AmountRow("Gross income", statement.GrossIncome, true)
+
+	if len(statement.Refunds) > 0 {
+		report.Heading("Less: Refunds")
+		for _, line := range statement.Refunds {
+			report.AmountRow(statementLabel(line), line.Amount, false)
+		}
+		report.AmountRow("Total refunds", statement.TotalRefunds, true)
+	}
+	report.AmountRow("Net income", statement.NetIncome, true)
+
+	report.Heading("Expenses")
+	for _, line := range statement.Expenses {
+		report.AmountRow(statementLabel(line), line.Amount, false)
+	}
+	report.AmountRow("Total expenses", statement.TotalExpenses, true)
+
+	report.Heading("Result")
+	report.AmountRow("Net surplus / (deficit)", statement.NetSurplus, true)
+
+	headingTotals(report, "Income by heading", statement.IncomeHeadings)
+	headingTotals(report, "Expenses by heading", statement.ExpenseHeadings)
+
+	breakdownTable(report, statement.Breakdown, "Income", "Refunds & expenses")
+	return report.Output(w)
+}
+
+// CashFlowSummaryPDF renders a cash-flow summary as a PDF document
+func (s *FinanceReportsService) CashFlowSummaryPDF(summary *CashFlowSummary, w io.Writer) error {
+	report := utils.NewPDFReport("Cash Flow Summary - "+summary.Scope.Name, statementPeriod(summary.FromDate, summary.ToDate))
+
+	report.AmountRow("Opening balance", summary.OpeningBalance, true)
+
+	report.Heading("Receipts by payment mode")
+	for _, line := range summary.ReceiptsByMode {
+		report.AmountRow(line.AccountName, line.Amount, false)
+	}
+	report.AmountRow("Total receipts", summary.TotalReceipts, true)
+
+	report.Heading("Payments")
+	for _, line := range summary.PaymentsByAccount {
+		report.AmountRow(statementLabel(line), line.Amount, false)
+	}
+	report.AmountRow("Refunds paid", summary.RefundsPaid, false)
+	report.AmountRow("Total payments", roundAmount(summary.TotalPayments+summary.RefundsPaid), true)
+
+	report.Heading("Movement")
+	report.AmountRow("Net cash flow", summary.NetCashFlow, false)
+	report.AmountRow("Closing balance", summary.ClosingBalance, true)
+
+	headingTotals(report, "Receipts by heading", summary.ReceiptHeadings)
+	headingTotals(report, "Payments by heading", summary.PaymentHeadings)
+
+	breakdownTable(report, summary.Breakdown, "Receipts", "Payments")
+	return report.Output(w)
+}
+
+// headingTotals lists rolled-up heading totals, indented by their depth in the chart
+func headingTotals(report *utils.PDFReport, title string, headings []AccountHeading) {
+	if len(headings) == 0 {
+		return
+	}
+
+	report.Heading(title)
+	for _, heading := range headings {
+		label := strings.Repeat("    ", heading.Depth) + statementLabel(StatementLine{AccountCode: heading.AccountCode, AccountName: heading.AccountName})
+		report.AmountRow(label, heading.Amount, heading.Depth == 0)
+	}
+}
+
+func breakdownTable(report *utils.PDFReport, breakdown []StatementBreakdown, inflowLabel, outflowLabel string) {
+	if len(breakdown) == 0 {
+		return
+	}
+
+	rows := make([][]string, 0, len(breakdown))
+	for _, child := range breakdown {
+		rows = append(rows, []string{
+			child.Name,
+			utils.FormatAmount(child.Inflows),
+			utils.FormatAmount(child.Outflows),
+			utils.FormatAmount(child.Net),
+		})
+	}
+	report.Heading("Breakdown")
+	report.Table([]string{"Owner", inflowLabel, outflowLabel, "Net"}, []float64{0, 35, 40, 35}, rows)
+}
+
+func statementLabel(line StatementLine) string {
+	if line.AccountCode == "" {
+		return line.AccountName
+	}
+	return line.AccountCode + " " + line.AccountName
+}
+
+func statementPeriod(fromDate, toDate string) string {
+	switch {
+	case fromDate != "" && toDate != "":
+		return "Period: " + fromDate + " to " + toDate
+	case fromDate != "":
+		return "Period: from " + fromDate
+	case toDate != "":
+		return "Period: up to " + toDate
+	}
+	return "Period: all dates"
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"strings"
+	"time"
+)
+
+// Fiscal period statuses and audit actions
+const (
+	FiscalPeriodStatusOpen   = "open"
+	FiscalPeriodStatusClosed = "closed"
+
+	FiscalPeriodActionClosed   = "closed"
+	FiscalPeriodActionReopened = "reopened"
+)
+
+// ErrFiscalPeriodClosed is returned when a finance record dated inside a closed period is
+// created, changed or deleted
+var ErrFiscalPeriodClosed = errors.New("fiscal period is closed")
+
+type FiscalPeriodService struct {
+	fiscalPeriodRepo *repositories.FiscalPeriodRepository
+}
+
+func NewFiscalPeriodService(fiscalPeriodRepo *repositories.FiscalPeriodRepository) *FiscalPeriodService {
+	return &FiscalPeriodService{
+		fiscalPeriodRepo: fiscalPeriodRepo,
+	}
+}
+
+// EnsureOpen rejects a change to a finance record of the given owner dated inside one of the
+// owner's closed periods. Records without an owner fall back to the national level.
+func (s *FiscalPeriodService) EnsureOpen(ownerType *string, ownerId *int64, date time.Time) error {
+	recordOwnerType, recordOwnerID := ledgerOwner(ownerType, ownerId)
+	period, err := s.fiscalPeriodRepo.FindClosedContaining(recordOwnerType, recordOwnerID, date)
+	if err != nil {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (%s to %s) does not accept changes dated %s", ErrFiscalPeriodClosed,
+		period.Name, period.StartDate.Format("2006-01-02"), period.EndDate.Format("2006-01-02"), date.Format("2006-01-02"))
+}
+
+// EnsureOpenForOwner is EnsureOpen for records that take their owner from the caller
+func (s *FiscalPeriodService) EnsureOpenForOwner(ownerCtx *utils.OwnerContext, date time.Time) error {
+	ownerType, ownerID := callerOwner(ownerCtx)
+	return s.EnsureOpen(&ownerType, &ownerID, date)
+}
+
+// GetFiscalPeriodByIDWithOwner retrieves a fiscal period with owner filtering
+func (s *FiscalPeriodService) GetFiscalPeriodByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.FiscalPeriod, error) {
+	period, err := s.fiscalPeriodRepo.FindByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return nil, errors.New("fiscal period not found")
+	}
+	return period, nil
+}
+
+// ListFiscalPeriodsWithOwner lists fiscal periods with owner filtering
+func (s *FiscalPeriodService) ListFiscalPeriodsWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.FiscalPeriod, int64, error) {
+	return s.fiscalPeriodRepo.ListWithOwner(filters, page, limit, ownerCtx)
+}
+
+// CreateFiscalPeriodWithOwner creates an open period for the caller's owner. Periods of the
+// same owner cannot overlap.
+func (s *FiscalPeriodService) CreateFiscalPeriodWithOwner(period *models.FiscalPeriod, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+	if err := validateFiscalPeriod(period); err != nil {
+		return err
+	}
+
+	ownerType, ownerID := callerOwner(ownerCtx)
+	overlaps, err := s.fiscalPeriodRepo.Overlaps(ownerType, ownerID, period.StartDate, period.EndDate, nil)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return errors.New("fiscal period overlaps an existing period")
+	}
+
+	period.ID = 0
+	period.Status = FiscalPeriodStatusOpen
+	period.ClosedBy = nil
+	period.ClosedAt = nil
+	period.IsDeleted = false
+	return s.fiscalPeriodRepo.CreateWithOwner(period, ownerCtx)
+}
+
+// UpdateFiscalPeriodWithOwner renames or re-dates an open period
+func (s *FiscalPeriodService) UpdateFiscalPeriodWithOwner(id uint, updateData *models.FiscalPeriod, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	existing, err := s.GetFiscalPeriodByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+	if existing.Status != FiscalPeriodStatusOpen {
+		return errors.New("closed fiscal periods cannot be edited; reopen the period first")
+	}
+
+	if updateData.Name == "" {
+		updateData.Name = existing.Name
+	}
+	if updateData.StartDate.IsZero() {
+		updateData.StartDate = existing.StartDate
+	}
+	if updateData.EndDate.IsZero() {
+		updateData.EndDate = existing.EndDate
+	}
+	if err := validateFiscalPeriod(updateData); err != nil {
+		return err
+	}
+
+	ownerType, ownerID := ledgerOwner(existing.OwnerType, existing.OwnerId)
+	overlaps, err := s.fiscalPeriodRepo.Overlaps(ownerType, ownerID, updateData.StartDate, updateData.EndDate, &existing.ID)
+	if err != nil {
+		return err
+	}
+	if overlaps {
+		return errors.New("fiscal period overlaps an existing period")
+	}
+
+	return s.fiscalPeriodRepo.UpdateWithOwner(id, map[string]interface{}{
+		"name":       updateData.Name,
+		"start_date": updateData.StartDate,
+		"end_date":   updateData.EndDate,
+	}, ownerCtx)
+}
+
+// DeleteFiscalPeriodWithOwner removes a period that has never been closed
+func (s *FiscalPeriodService) DeleteFiscalPeriodWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	existing, err := s.GetFiscalPeriodByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+	if existing.Status != FiscalPeriodStatusOpen || s.fiscalPeriodRepo.HasAudits(existing.ID) {
+		return errors.New("fiscal periods that have been closed cannot be deleted")
+	}
+
+	return s.fiscalPeriodRepo.DeleteWithOwner(id, ownerCtx)
+}
+
+// ClosePeriod locks a period. Only an executive of the period's own level can close it.
+func (s *FiscalPeriodService) ClosePeriod(id uint, notes string, performedBy *int64, ownerCtx *utils.OwnerContext) error {
+	period, err := s.periodForAction(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+	if period.Status == FiscalPeriodStatusClosed {
+		return errors.New("fiscal period is already closed")
+	}
+
+	now := time.Now()
+	return s.fiscalPeriodRepo.SetStatus(period.ID, map[string]interface{}{
+		"status":    FiscalPeriodStatusClosed,
+		"closed_by": performedBy,
+		"closed_at": now,
+	}, newFiscalPeriodAudit(period, FiscalPeriodActionClosed, notes, performedBy, ownerCtx))
+}
+
+// ReopenPeriod unlocks a closed period. A reason is required and recorded in the period's
+// audit history.
+func (s *FiscalPeriodService) ReopenPeriod(id uint, reason string, performedBy *int64, ownerCtx *utils.OwnerContext) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("reason is required to reopen a fiscal period")
+	}
+
+	period, err := s.periodForAction(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+	if period.Status != FiscalPeriodStatusClosed {
+		return errors.New("only closed fiscal periods can be reopened")
+	}
+
+	return s.fiscalPeriodRepo.SetStatus(period.ID, map[string]interface{}{
+		"status":    FiscalPeriodStatusOpen,
+		"closed_by": nil,
+		"closed_at": nil,
+	}, newFiscalPeriodAudit(period, FiscalPeriodActionReopened, reason, performedBy, ownerCtx))
+}
+
+// GetHistory lists the close and reopen actions taken on a period
+func (s *FiscalPeriodService) GetHistory(id uint, ownerCtx *utils.OwnerContext) ([]models.FiscalPeriodAudit, error) {
+	period, err := s.GetFiscalPeriodByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+	return s.fiscalPeriodRepo.ListAudits(period.ID)
+}
+
+// periodForAction loads a period the caller may close or reopen: higher levels can see a
+// lower level's periods but only the owning level's executives can lock or unlock them
+func (s *FiscalPeriodService) periodForAction(id uint, ownerCtx *utils.OwnerContext) (*models.FiscalPeriod, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return nil, err
+	}
+
+	period, err := s.GetFiscalPeriodByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerType, ownerID := callerOwner(ownerCtx)
+	periodOwnerType, periodOwnerID := ledgerOwner(period.OwnerType, period.OwnerId)
+	if ownerType != periodOwnerType || ownerID != periodOwnerID {
+		return nil, errors.New("access denied")
+	}
+	return period, nil
+}
+
+func newFiscalPeriodAudit(period *models.FiscalPeriod, action, reason string, performedBy *int64, ownerCtx *utils.OwnerContext) *models.FiscalPeriodAudit {
+	audit := &models.FiscalPeriodAudit{
+		FiscalPeriodId: period.ID,
+		Action:         action,
+		PerformedBy:    performedBy,
+	}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		audit.Reason = &reason
+	}
+	if ownerCtx != nil && ownerCtx.Role != "" {
+		role := ownerCtx.Role
+		audit.PerformedRole = &role
+	}
+	ownerType, ownerID := ledgerOwner(period.OwnerType, period.OwnerId)
+	audit.SetOwner(ownerType, ownerID)
+	return audit
+}
+
+func callerOwner(ownerCtx *utils.OwnerContext) (string, int64) {
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		return ownerCtx.GetOwnerValues()
+	}
+	return ledgerOwner(nil, nil)
+}
+
+func validateFiscalPeriod(period *models.FiscalPeriod) error {
+	period.Name = strings.TrimSpace(period.Name)
+	if period.Name == "" {
+		return errors.New("name is required")
+	}
+	if period.StartDate.IsZero() || period.EndDate.IsZero() {
+		return errors.New("start_date and end_date are required")
+	}
+	if period.EndDate.Before(period.StartDate) {
+		return errors.New("end_date cannot be before start_date")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"errors"
+	"gnaps-api/internal/testdb"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"testing"
+	"time"
+)
+
+// fiscalPeriodTestRows are a closed national period and three zone 10 periods: closed, open and
+// closed but deleted
+func fiscalPeriodTestRows() []interface{} {
+	period := func(name, start, end, status, ownerType string, ownerID int64) *models.FiscalPeriod {
+		return &models.FiscalPeriod{Name: name, StartDate: testDate(start), EndDate: testDate(end), Status: status, OwnerType: &ownerType, OwnerId: &ownerID}
+	}
+	deleted := period("April", "2026-04-01", "2026-04-30", FiscalPeriodStatusClosed, utils.OwnerTypeZone, 10)
+	deleted.IsDeleted = true
+	return []interface{}{
+		period("January", "2026-01-01", "2026-01-31", FiscalPeriodStatusClosed, utils.OwnerTypeNational, utils.DefaultNationalOwnerID),
+		period("February", "2026-02-01", "2026-02-28", FiscalPeriodStatusClosed, utils.OwnerTypeZone, 10),
+		period("March", "2026-03-01", "2026-03-31", FiscalPeriodStatusOpen, utils.OwnerTypeZone, 10),
+		deleted,
+	}
+}
+
+func TestEnsureOpen(t *testing.T) {
+	db := testdb.Open(t, &models.FiscalPeriod{})
+	testdb.Seed(t, db, fiscalPeriodTestRows()...)
+	// start_date and end_date are DATE columns in MySQL
+	if err := db.Exec("UPDATE fiscal_periods SET start_date = date(start_date), end_date = date(end_date)").Error; err != nil {
+		t.Fatalf("truncate period dates: %v", err)
+	}
+	s := NewFiscalPeriodService(repositories.NewFiscalPeriodRepository(db))
+
+	national, zone := testdb.Ptr(utils.OwnerTypeNational), testdb.Ptr(utils.OwnerTypeZone)
+	nationalID, zoneID, otherZoneID := testdb.Ptr(utils.DefaultNationalOwnerID), testdb.Ptr(int64(10)), testdb.Ptr(int64(11))
+	tests := []struct {
+		name       string
+		ownerType  *string
+		ownerID    *int64
+		date       string
+		wantClosed bool
+	}{
+		{"inside a closed period", national, nationalID, "2026-01-15", true},
+		{"first day of a closed period", national, nationalID, "2026-01-01", true},
+		{"last day of a closed period", national, nationalID, "2026-01-31", true},
+		{"day after a closed period", national, nationalID, "2026-02-01", false},
+		{"no owner falls back to national", nil, nil, "2026-01-15", true},
+		{"another owner's closed period", zone, zoneID, "2026-01-15", false},
+		{"own closed period", zone, zoneID, "2026-02-10", true},
+		{"same dates for another zone", zone, otherZoneID, "2026-02-10", false},
+		{"open period", zone, zoneID, "2026-03-10", false},
+		{"deleted closed period", zone, zoneID, "2026-04-10", false},
+		{"outside any period", zone, zoneID, "2026-06-01", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.EnsureOpen(tt.ownerType, tt.ownerID, testDate(tt.date))
+			if tt.wantClosed {
+				if !errors.Is(err, ErrFiscalPeriodClosed) {
+					t.Errorf("EnsureOpen() error = %v, want %v", err, ErrFiscalPeriodClosed)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("EnsureOpen() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func testDate(value string) time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
@@ -5,14 +5,17 @@ import (
 	"gnaps-api/models"
 	"gnaps-api/repositories"
 	"gnaps-api/utils"
+
+	"gorm.io/gorm"
 )
 
 type GroupService struct {
-	groupRepo *repositories.GroupRepository
+	groupRepo  *repositories.GroupRepository
+	memberRepo *repositories.SchoolGroupMemberRepository
 }
 
-func NewGroupService(groupRepo *repositories.GroupRepository) *GroupService {
-	return &GroupService{groupRepo: groupRepo}
+func NewGroupService(groupRepo *repositories.GroupRepository, memberRepo *repositories.SchoolGroupMemberRepository) *GroupService {
+	return &GroupService{groupRepo: groupRepo, memberRepo: memberRepo}
 }
 
 func (s *GroupService) GetGroupByID(id uint) (*models.SchoolGroup, error) {
@@ -74,7 +77,10 @@ func (s *GroupService) DeleteGroup(id uint) error {
 		return errors.New("group not found")
 	}
 
-	return s.groupRepo.Delete(id)
+	if err := s.groupRepo.Delete(id); err != nil {
+		return err
+	}
+	return s.memberRepo.RemoveGroup(id)
 }
 
 // ============================================
@@ -123,5 +129,52 @@ func (s *GroupService) UpdateGroupWithOwner(id uint, updates map[string]interfac
 }
 
 func (s *GroupService) DeleteGroupWithOwner(id uint, ownerCtx *utils.OwnerContext) error {
-	return s.groupRepo.DeleteWithOwner(id, ownerCtx)
+	if err := s.groupRepo.DeleteWithOwner(id, ownerCtx); err != nil {
+		return err
+	}
+	return s.memberRepo.RemoveGroup(id)
+}
+
+// ============================================
+// Group membership
+// ============================================
+
+// ListGroupMembersWithOwner returns the schools in a group that the user can see
+func (s *GroupService) ListGroupMembersWithOwner(groupID uint, name string, page, limit int, ownerCtx *utils.OwnerContext) ([]models.School, int64, error) {
+	if _, err := s.groupRepo.FindByIDWithOwner(groupID, ownerCtx); err != nil {
+		return nil, 0, errors.New("group not found")
+	}
+	return s.memberRepo.ListMembers(groupID, name, page, limit, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+// AddGroupMembersWithOwner adds schools the user can see to a group and returns how many were added;
+// schools already in the group are skipped
+func (s *GroupService) AddGroupMembersWithOwner(groupID uint, schoolIDs []int64, addedBy *int64, ownerCtx *utils.OwnerContext) (int64, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return 0, err
+	}
+	if len(schoolIDs) == 0 {
+		return 0, errors.New("school_ids is required")
+	}
+	if _, err := s.groupRepo.FindByIDWithOwner(groupID, ownerCtx); err != nil {
+		return 0, errors.New("group not found")
+	}
+	return s.memberRepo.AddMembers(groupID, schoolIDs, addedBy, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+// RemoveGroupMemberWithOwner takes a school out of a group
+func (s *GroupService) RemoveGroupMemberWithOwner(groupID uint, schoolID int64, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+	if _, err := s.groupRepo.FindByIDWithOwner(groupID, ownerCtx); err != nil {
+		return errors.New("group not found")
+	}
+	if err := s.memberRepo.RemoveMember(groupID, schoolID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("school is not a member of this group")
+		}
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,647 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Journal entry source types
+const (
+	JournalSourcePayment         = "Payment"
+	JournalSourceExpenseApproval = "ExpenseApproval"
+	JournalSourceExpensePayment  = "ExpensePayment"
+	JournalSourceRefund          = "Refund"
+	JournalSourceDiscount        = "Discount"
+	JournalSourceManual          = "Manual"
+	JournalSourceReversal        = "Reversal"
+)
+
+// System account roles the ledger posts to when no specific account is given
+const (
+	LedgerRoleCash       = "cash"
+	LedgerRoleMomo       = "momo"
+	LedgerRoleBank       = "bank"
+	LedgerRoleReceivable = "receivable"
+	LedgerRolePayable    = "payable"
+	LedgerRoleIncome     = "income"
+	LedgerRoleExpense    = "expense"
+	LedgerRoleDiscount   = "discount"
+	LedgerRoleRefund     = "refund"
+)
+
+// ledgerSystemAccount describes the default account created for a role when an owner has none
+type ledgerSystemAccount struct {
+	Code        string
+	Name        string
+	AccountType string
+	IsIncome    bool
+}
+
+var ledgerSystemAccounts = map[string]ledgerSystemAccount{
+	LedgerRoleCash:       {"1000", "Cash on Hand", "asset", false},
+	LedgerRoleMomo:       {"1010", "Mobile Money Wallet", "asset", false},
+	LedgerRoleBank:       {"1020", "Bank Account", "asset", false},
+	LedgerRoleReceivable: {"1100", "Dues Receivable", "asset", false},
+	LedgerRolePayable:    {"2000", "Accounts Payable", "liability", false},
+	LedgerRoleIncome:     {"4000", "General Income", "income", true},
+	LedgerRoleRefund:     {"4900", "Refunds to Schools", "income", true},
+	LedgerRoleExpense:    {"5000", "General Expenses", "expense", false},
+	LedgerRoleDiscount:   {"5900", "Discounts Allowed", "expense", false},
+}
+
+type LedgerService struct {
+	ledgerRepo          *repositories.LedgerRepository
+	fiscalPeriodService *FiscalPeriodService
+}
+
+func NewLedgerService(ledgerRepo *repositories.LedgerRepository, fiscalPeriodService *FiscalPeriodService) *LedgerService {
+	return &LedgerService{ledgerRepo: ledgerRepo, fiscalPeriodService: fiscalPeriodService}
+}
+
+// WithTx returns a copy of the service that posts inside tx, so a journal entry is saved or
+// rolled back together with the record it is posted for
+func (s *LedgerService) WithTx(tx *gorm.DB) *LedgerService {
+	return &LedgerService{ledgerRepo: s.ledgerRepo.WithTx(tx), fiscalPeriodService: s.fiscalPeriodService}
+}
+
+// JournalLineRequest is one debit or credit line of a journal entry
+type JournalLineRequest struct {
+	FinanceAccountId int64   `json:"finance_account_id"`
+	Debit            float64 `json:"debit"`
+	Credit           float64 `json:"credit"`
+	Description      string  `json:"description"`
+}
+
+// JournalEntryRequest holds the data needed to post a journal entry
+type JournalEntryRequest struct {
+	EntryDate            string               `json:"entry_date"`
+	Description          string               `json:"description"`
+	SourceType           string               `json:"-"`
+	SourceId             *int64               `json:"-"`
+	FinanceTransactionId *int64               `json:"-"`
+	SchoolId             *int64               `json:"school_id"`
+	PostedBy             *int64               `json:"-"`
+	OwnerType            string               `json:"-"`
+	OwnerId              int64                `json:"-"`
+	Lines                []JournalLineRequest `json:"lines"`
+}
+
+// PostEntry validates that the lines balance and saves the journal entry
+func (s *LedgerService) PostEntry(req JournalEntryRequest) (*models.JournalEntry, error) {
+	if len(req.Lines) < 2 {
+		return nil, errors.New("a journal entry needs at least two lines")
+	}
+
+	var totalDebit, totalCredit float64
+	lines := make([]models.JournalEntryLine, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		if line.Debit < 0 || line.Credit < 0 {
+			return nil, errors.New("debit and credit amounts cannot be negative")
+		}
+		if (line.Debit > 0) == (line.Credit > 0) {
+			return nil, errors.New("each line must have either a debit or a credit amount")
+		}
+		account, err := s.ledgerRepo.FindAccountByID(line.FinanceAccountId)
+		if err != nil {
+			return nil, fmt.Errorf("finance account %d not found", line.FinanceAccountId)
+		}
+		if account.IsHeader != nil && *account.IsHeader {
+			return nil, fmt.Errorf("finance account %s is a heading; post to one of its sub-accounts", stringValue(account.Code))
+		}
+
+		totalDebit += line.Debit
+		totalCredit += line.Credit
+
+		newLine := models.JournalEntryLine{
+			FinanceAccountId: line.FinanceAccountId,
+			Debit:            roundAmount(line.Debit),
+			Credit:           roundAmount(line.Credit),
+		}
+		if line.Description != "" {
+			description := line.Description
+			newLine.Description = &description
+		}
+		lines = append(lines, newLine)
+	}
+
+	totalDebit = roundAmount(totalDebit)
+	totalCredit = roundAmount(totalCredit)
+	if totalDebit != totalCredit {
+		return nil, fmt.Errorf("journal entry is not balanced: debits %.2f, credits %.2f", totalDebit, totalCredit)
+	}
+
+	entryDate := time.Now()
+	if req.EntryDate != "" {
+		if parsed, err := time.Parse("2006-01-02", req.EntryDate); err == nil {
+			entryDate = parsed
+		}
+	}
+
+	sourceType := req.SourceType
+	if sourceType == "" {
+		sourceType = JournalSourceManual
+	}
+
+	entry := &models.JournalEntry{
+		EntryDate:            entryDate,
+		SourceType:           sourceType,
+		SourceId:             req.SourceId,
+		FinanceTransactionId: req.FinanceTransactionId,
+		SchoolId:             req.SchoolId,
+		TotalDebit:           totalDebit,
+		TotalCredit:          totalCredit,
+		PostedBy:             req.PostedBy,
+		Lines:                lines,
+	}
+	if req.Description != "" {
+		description := req.Description
+		entry.Description = &description
+	}
+	if req.OwnerType != "" && req.OwnerId > 0 {
+		entry.SetOwner(req.OwnerType, req.OwnerId)
+	}
+
+	if err := s.ledgerRepo.CreateEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// PostManualEntryWithOwner posts a journal entry keyed in by an executive for their owner
+func (s *LedgerService) PostManualEntryWithOwner(req JournalEntryRequest, ownerCtx *utils.OwnerContext) (*models.JournalEntry, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return nil, err
+	}
+	if ownerCtx != nil && ownerCtx.IsValid() {
+		req.OwnerType, req.OwnerId = ownerCtx.GetOwnerValues()
+	}
+	req.SourceType = JournalSourceManual
+	req.SourceId = nil
+
+	entryDate := time.Now()
+	if req.EntryDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.EntryDate)
+		if err != nil {
+			return nil, errors.New("invalid entry_date, expected YYYY-MM-DD")
+		}
+		entryDate = parsed
+	}
+	if err := s.fiscalPeriodService.EnsureOpen(&req.OwnerType, &req.OwnerId, entryDate); err != nil {
+		return nil, err
+	}
+
+	// Manual entries may only touch the owner's own accounts; accounts without an owner are national
+	entryOwnerType, entryOwnerID := ledgerOwner(&req.OwnerType, &req.OwnerId)
+	for _, line := range req.Lines {
+		account, err := s.ledgerRepo.FindAccountByID(line.FinanceAccountId)
+		if err != nil {
+			return nil, fmt.Errorf("finance account %d not found", line.FinanceAccountId)
+		}
+		if ownerType, ownerID := ledgerOwner(account.OwnerType, account.OwnerId); ownerType != entryOwnerType || ownerID != entryOwnerID {
+			return nil, fmt.Errorf("finance account %s does not belong to you", stringValue(account.Code))
+		}
+	}
+	return s.PostEntry(req)
+}
+
+// ReverseEntryWithOwner posts an equal and opposite entry and marks the original as reversed
+func (s *LedgerService) ReverseEntryWithOwner(id uint, reason string, postedBy *int64, ownerCtx *utils.OwnerContext) (*models.JournalEntry, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return nil, err
+	}
+
+	original, err := s.ledgerRepo.FindEntryByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return nil, errors.New("journal entry not found")
+	}
+	// The reversal is dated today, so it is today's period that must be open
+	if err := s.fiscalPeriodService.EnsureOpen(original.OwnerType, original.OwnerId, time.Now()); err != nil {
+		return nil, err
+	}
+
+	reversal, err := s.buildReversal(original, reason, postedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ledgerRepo.CreateReversal(original, reversal); err != nil {
+		return nil, err
+	}
+	return reversal, nil
+}
+
+// ReverseSourceEntry reverses the active entry posted for a source record, if any
+func (s *LedgerService) ReverseSourceEntry(sourceType string, sourceId int64, reason string, postedBy *int64) error {
+	original, err := s.ledgerRepo.FindEntryBySource(sourceType, sourceId)
+	if err != nil {
+		return nil
+	}
+
+	reversal, err := s.buildReversal(original, reason, postedBy)
+	if err != nil {
+		return err
+	}
+	return s.ledgerRepo.CreateReversal(original, reversal)
+}
+
+func (s *LedgerService) buildReversal(original *models.JournalEntry, reason string, postedBy *int64) (*models.JournalEntry, error) {
+	if original.IsReversed {
+		return nil, errors.New("journal entry has already been reversed")
+	}
+	if original.SourceType == JournalSourceReversal {
+		return nil, errors.New("a reversal entry cannot be reversed")
+	}
+
+	lines := make([]models.JournalEntryLine, 0, len(original.Lines))
+	for _, line := range original.Lines {
+		lines = append(lines, models.JournalEntryLine{
+			FinanceAccountId: line.FinanceAccountId,
+			Debit:            line.Credit,
+			Credit:           line.Debit,
+			Description:      line.Description,
+		})
+	}
+
+	description := fmt.Sprintf("Reversal of %s", original.EntryNo)
+	if reason != "" {
+		description += ": " + reason
+	}
+	originalId := original.ID
+	sourceId := int64(original.ID)
+
+	return &models.JournalEntry{
+		EntryDate:            time.Now(),
+		Description:          &description,
+		SourceType:           JournalSourceReversal,
+		SourceId:             &sourceId,
+		FinanceTransactionId: original.FinanceTransactionId,
+		SchoolId:             original.SchoolId,
+		TotalDebit:           original.TotalCredit,
+		TotalCredit:          original.TotalDebit,
+		ReversalOfId:         &originalId,
+		PostedBy:             postedBy,
+		OwnerType:            original.OwnerType,
+		OwnerId:              original.OwnerId,
+		Lines:                lines,
+	}, nil
+}
+
+// ============================================
+// Postings for finance events
+// ============================================
+
+// PostPayment posts a receipt: debit the cash/MoMo/bank account for the payment mode and
+// credit the income account on the transaction (or the owner's general income account)
+func (s *LedgerService) PostPayment(txn *models.FinanceTransaction) (*models.JournalEntry, error) {
+	ownerType, ownerID := ledgerOwner(txn.OwnerType, txn.OwnerId)
+	transactionId := int64(txn.ID)
+	if existing, err := s.ledgerRepo.FindEntryBySource(JournalSourcePayment, transactionId); err == nil {
+		return existing, nil
+	}
+
+	amount := floatValue(txn.Amount)
+	if amount <= 0 {
+		return nil, errors.New("payment amount must be greater than 0")
+	}
+
+	cashAccount, err := s.ResolveSystemAccount(cashRoleForMode(stringValue(txn.PaymentMode)), ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	incomeAccountId := txn.FinanceAccountId
+	if incomeAccountId == nil {
+		incomeAccount, err := s.ResolveSystemAccount(LedgerRoleIncome, ownerType, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		id := int64(incomeAccount.ID)
+		incomeAccountId = &id
+	}
+
+	return s.PostEntry(JournalEntryRequest{
+		EntryDate:            txn.TransactionDate.Format("2006-01-02"),
+		Description:          stringValue(txn.Title),
+		SourceType:           JournalSourcePayment,
+		SourceId:             &transactionId,
+		FinanceTransactionId: &transactionId,
+		SchoolId:             txn.SchoolId,
+		PostedBy:             txn.UserId,
+		OwnerType:            ownerType,
+		OwnerId:              ownerID,
+		Lines: []JournalLineRequest{
+			{FinanceAccountId: int64(cashAccount.ID), Debit: amount, Description: stringValue(txn.ReceiptNo)},
+			{FinanceAccountId: *incomeAccountId, Credit: amount, Description: stringValue(txn.Title)},
+		},
+	})
+}
+
+// PostRefund posts money returned to a school: debit the refunds (contra-income) account and
+// credit the cash/MoMo/bank account the refund was paid from
+func (s *LedgerService) PostRefund(refund *models.FinanceTransaction) (*models.JournalEntry, error) {
+	ownerType, ownerID := ledgerOwner(refund.OwnerType, refund.OwnerId)
+	refundId := int64(refund.ID)
+
+	refundAccount, err := s.ResolveSystemAccount(LedgerRoleRefund, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	cashAccount, err := s.ResolveSystemAccount(cashRoleForMode(stringValue(refund.PaymentMode)), ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := floatValue(refund.Amount)
+	return s.PostEntry(JournalEntryRequest{
+		EntryDate:            refund.TransactionDate.Format("2006-01-02"),
+		Description:          stringValue(refund.Title),
+		SourceType:           JournalSourceRefund,
+		SourceId:             &refundId,
+		FinanceTransactionId: &refundId,
+		SchoolId:             refund.SchoolId,
+		PostedBy:             refund.UserId,
+		OwnerType:            ownerType,
+		OwnerId:              ownerID,
+		Lines: []JournalLineRequest{
+			{FinanceAccountId: int64(refundAccount.ID), Debit: amount, Description: stringValue(refund.PaymentNote)},
+			{FinanceAccountId: int64(cashAccount.ID), Credit: amount, Description: stringValue(refund.ReferenceNo)},
+		},
+	})
+}
+
+// PostDiscount posts a discount granted on a school bill. Income is recorded gross, so the
+// discount is shown as an expense (discounts allowed) against the income it reduces.
+func (s *LedgerService) PostDiscount(schoolBill *models.SchoolBill, amount float64, reason string, postedBy *int64) (*models.JournalEntry, error) {
+	ownerType, ownerID := ledgerOwner(schoolBill.OwnerType, schoolBill.OwnerId)
+	schoolBillId := int64(schoolBill.ID)
+
+	discountAccount, err := s.ResolveSystemAccount(LedgerRoleDiscount, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	incomeAccountId := s.ledgerRepo.FindParticularAccountForSchoolBill(schoolBillId)
+	if incomeAccountId == nil {
+		incomeAccount, err := s.ResolveSystemAccount(LedgerRoleIncome, ownerType, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		id := int64(incomeAccount.ID)
+		incomeAccountId = &id
+	}
+
+	return s.PostEntry(JournalEntryRequest{
+		Description: reason,
+		SourceType:  JournalSourceDiscount,
+		SourceId:    &schoolBillId,
+		SchoolId:    schoolBill.SchoolId,
+		PostedBy:    postedBy,
+		OwnerType:   ownerType,
+		OwnerId:     ownerID,
+		Lines: []JournalLineRequest{
+			{FinanceAccountId: int64(discountAccount.ID), Debit: amount, Description: reason},
+			{FinanceAccountId: *incomeAccountId, Credit: amount, Description: reason},
+		},
+	})
+}
+
+// PostExpenseApproval accrues an approved expense: debit the expense account and credit payables
+func (s *LedgerService) PostExpenseApproval(expense *models.FinanceExpense, postedBy *int64) (*models.JournalEntry, error) {
+	ownerType, ownerID := ledgerOwner(expense.OwnerType, expense.OwnerId)
+	expenseId := int64(expense.ID)
+	if existing, err := s.ledgerRepo.FindEntryBySource(JournalSourceExpenseApproval, expenseId); err == nil {
+		return existing, nil
+	}
+
+	expenseAccountId := expense.BudgetAccountId
+	if expenseAccountId == nil {
+		expenseAccount, err := s.ResolveSystemAccount(LedgerRoleExpense, ownerType, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		id := int64(expenseAccount.ID)
+		expenseAccountId = &id
+	}
+	payableAccount, err := s.ResolveSystemAccount(LedgerRolePayable, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := floatValue(expense.Amount)
+	return s.PostEntry(JournalEntryRequest{
+		EntryDate:   expense.TransactionDate.Format("2006-01-02"),
+		Description: stringValue(expense.Title),
+		SourceType:  JournalSourceExpenseApproval,
+		SourceId:    &expenseId,
+		PostedBy:    postedBy,
+		OwnerType:   ownerType,
+		OwnerId:     ownerID,
+		Lines: []JournalLineRequest{
+			{FinanceAccountId: *expenseAccountId, Debit: amount, Description: stringValue(expense.Title)},
+			{FinanceAccountId: int64(payableAccount.ID), Credit: amount, Description: stringValue(expense.Title)},
+		},
+	})
+}
+
+// PostExpensePayment settles an approved expense: debit payables and credit the account paid from
+func (s *LedgerService) PostExpensePayment(expense *models.FinanceExpense, paymentMode string, paidAt time.Time, postedBy *int64) (*models.JournalEntry, error) {
+	ownerType, ownerID := ledgerOwner(expense.OwnerType, expense.OwnerId)
+	expenseId := int64(expense.ID)
+	if existing, err := s.ledgerRepo.FindEntryBySource(JournalSourceExpensePayment, expenseId); err == nil {
+		return existing, nil
+	}
+
+	payableAccount, err := s.ResolveSystemAccount(LedgerRolePayable, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	cashAccount, err := s.ResolveSystemAccount(cashRoleForMode(paymentMode), ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := floatValue(expense.Amount)
+	return s.PostEntry(JournalEntryRequest{
+		EntryDate:   paidAt.Format("2006-01-02"),
+		Description: "Payment: " + stringValue(expense.Title),
+		SourceType:  JournalSourceExpensePayment,
+		SourceId:    &expenseId,
+		PostedBy:    postedBy,
+		OwnerType:   ownerType,
+		OwnerId:     ownerID,
+		Lines: []JournalLineRequest{
+			{FinanceAccountId: int64(payableAccount.ID), Debit: amount, Description: stringValue(expense.VoucherNo)},
+			{FinanceAccountId: int64(cashAccount.ID), Credit: amount, Description: stringValue(expense.ChequeNo)},
+		},
+	})
+}
+
+// ResolveIncomeAccount picks the income account a payment should be credited to:
+// the particular on the school bill, or the first item on the event's bill
+func (s *LedgerService) ResolveIncomeAccount(financeType string, financeId int64) *int64 {
+	switch financeType {
+	case "SchoolBill", "SchoolBillPayment":
+		return s.ledgerRepo.FindParticularAccountForSchoolBill(financeId)
+	case "Event", "EventRegistration":
+		if billId := s.ledgerRepo.FindEventBillID(financeType, financeId); billId != nil {
+			return s.ledgerRepo.FindBillItemAccountForBill(*billId)
+		}
+	}
+	return nil
+}
+
+// IncomeAccountForPayment returns the account a payment is credited to: the income account of
+// what is being paid for, or the owner's general income account when none is configured. It is
+// stored on the finance transaction so income reports see the same account as the ledger.
+func (s *LedgerService) IncomeAccountForPayment(financeType string, financeId int64, ownerType *string, ownerId *int64) (*int64, error) {
+	if accountId := s.ResolveIncomeAccount(financeType, financeId); accountId != nil {
+		return accountId, nil
+	}
+	incomeOwnerType, incomeOwnerID := ledgerOwner(ownerType, ownerId)
+	account, err := s.ResolveSystemAccount(LedgerRoleIncome, incomeOwnerType, incomeOwnerID)
+	if err != nil {
+		return nil, err
+	}
+	id := int64(account.ID)
+	return &id, nil
+}
+
+// ResolveSystemAccount returns the owner's default account for a role, creating it on first use
+func (s *LedgerService) ResolveSystemAccount(role, ownerType string, ownerID int64) (*models.FinanceAccount, error) {
+	if account, err := s.ledgerRepo.FindSystemAccount(role, ownerType, ownerID); err == nil {
+		return account, nil
+	}
+
+	def, ok := ledgerSystemAccounts[role]
+	if !ok {
+		return nil, fmt.Errorf("unknown ledger account role %s", role)
+	}
+
+	// Codes are unique across owners, so suffix them with the owner (e.g. 1000-Z12)
+	code := ownerAccountCode(def.Code, ownerType, ownerID)
+	name := def.Name
+	accountType := def.AccountType
+	isIncome := def.IsIncome
+	systemRole := role
+	account := &models.FinanceAccount{
+		Name:        &name,
+		Code:        &code,
+		AccountType: &accountType,
+		IsIncome:    &isIncome,
+		SystemRole:  &systemRole,
+	}
+	account.SetOwner(ownerType, ownerID)
+
+	if err := s.ledgerRepo.CreateAccount(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ============================================
+// Owner-based queries
+// ============================================
+
+func (s *LedgerService) GetEntryByIDWithOwner(id uint, ownerCtx *utils.OwnerContext) (*models.JournalEntry, error) {
+	entry, err := s.ledgerRepo.FindEntryByIDWithOwner(id, ownerCtx)
+	if err != nil {
+		return nil, errors.New("journal entry not found")
+	}
+	return entry, nil
+}
+
+func (s *LedgerService) ListEntriesWithOwner(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.JournalEntry, int64, error) {
+	return s.ledgerRepo.ListEntriesWithOwner(filters, page, limit, ownerCtx)
+}
+
+// TrialBalance lists debit and credit totals per account and whether they agree
+type TrialBalance struct {
+	AsOf        string                         `json:"as_of,omitempty"`
+	Accounts    []repositories.TrialBalanceRow `json:"accounts"`
+	TotalDebit  float64                        `json:"total_debit"`
+	TotalCredit float64                        `json:"total_credit"`
+	Difference  float64                        `json:"difference"`
+	IsBalanced  bool                           `json:"is_balanced"`
+	// Headings roll account balances up the chart of accounts; amounts are net debits
+	// (negative for a net credit)
+	Headings []AccountHeading `json:"headings"`
+}
+
+// GetTrialBalanceWithOwner builds the trial balance for the owner as of a date (inclusive)
+func (s *LedgerService) GetTrialBalanceWithOwner(asOf string, ownerCtx *utils.OwnerContext) (*TrialBalance, error) {
+	_, end := parseDateRange("", asOf)
+
+	rows, err := s.ledgerRepo.TrialBalanceWithOwner(end, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	tb := &TrialBalance{AsOf: asOf, Accounts: rows}
+	netDebits := make(map[int64]float64)
+	for i := range tb.Accounts {
+		row := &tb.Accounts[i]
+		netDebits[row.FinanceAccountId] += row.TotalDebit - row.TotalCredit
+		// Present each account's net balance on its natural side
+		net := roundAmount(row.TotalDebit - row.TotalCredit)
+		if net >= 0 {
+			row.Balance = net
+			row.BalanceSide = "debit"
+		} else {
+			row.Balance = -net
+			row.BalanceSide = "credit"
+		}
+		tb.TotalDebit += row.TotalDebit
+		tb.TotalCredit += row.TotalCredit
+	}
+	if tb.Accounts == nil {
+		tb.Accounts = []repositories.TrialBalanceRow{}
+	}
+
+	tb.TotalDebit = roundAmount(tb.TotalDebit)
+	tb.TotalCredit = roundAmount(tb.TotalCredit)
+	tb.Difference = roundAmount(tb.TotalDebit - tb.TotalCredit)
+	tb.IsBalanced = tb.Difference == 0
+	tb.Headings = accountHeadings(s.ledgerRepo.FindAccountsWithAncestors, netDebits)
+
+	return tb, nil
+}
+
+// cashRoleForMode maps a payment mode to the asset account that receives or pays the money
+func cashRoleForMode(paymentMode string) string {
+	mode := strings.ToLower(paymentMode)
+	switch {
+	case strings.Contains(mode, "momo"), strings.Contains(mode, "mobile"):
+		return LedgerRoleMomo
+	case strings.Contains(mode, "bank"), strings.Contains(mode, "cheque"), strings.Contains(mode, "transfer"):
+		return LedgerRoleBank
+	default:
+		return LedgerRoleCash
+	}
+}
+
+// ledgerOwner returns the record's owner, falling back to the national owner
+func ledgerOwner(ownerType *string, ownerId *int64) (string, int64) {
+	if ownerType != nil && *ownerType != "" && ownerId != nil && *ownerId > 0 {
+		return *ownerType, *ownerId
+	}
+	return utils.OwnerTypeNational, utils.DefaultNationalOwnerID
+}
+
+func roundAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func stringValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
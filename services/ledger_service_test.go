@@ -0,0 +1,162 @@
+package services
+
+import (
+	"gnaps-api/internal/testdb"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"strings"
+	"testing"
+	"time"
+)
+
+var ledgerTestTables = []interface{}{&models.FinanceAccount{}, &models.JournalEntry{}, &models.JournalEntryLine{}, &models.JournalEntrySequence{}}
+
+// ledgerTestAccounts are three sub-accounts and a heading
+func ledgerTestAccounts() []interface{} {
+	return []interface{}{
+		&models.FinanceAccount{ID: 1, Code: testdb.Ptr("1000")},
+		&models.FinanceAccount{ID: 2, Code: testdb.Ptr("4000")},
+		&models.FinanceAccount{ID: 3, Code: testdb.Ptr("5000")},
+		&models.FinanceAccount{ID: 4, Code: testdb.Ptr("4"), IsHeader: testdb.Ptr(true)},
+	}
+}
+
+func newLedgerTestService(t *testing.T) *LedgerService {
+	db := testdb.Open(t, ledgerTestTables...)
+	testdb.Seed(t, db, ledgerTestAccounts()...)
+	return NewLedgerService(repositories.NewLedgerRepository(db), nil)
+}
+
+func TestPostEntryBalancing(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []JournalLineRequest
+		wantErr   string
+		wantTotal float64
+	}{
+		{
+			name:    "needs two lines",
+			lines:   []JournalLineRequest{{FinanceAccountId: 1, Debit: 10}},
+			wantErr: "at least two lines",
+		},
+		{
+			name: "rejects negative amounts",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 1, Debit: -10},
+				{FinanceAccountId: 2, Credit: -10},
+			},
+			wantErr: "cannot be negative",
+		},
+		{
+			name: "rejects a line with both a debit and a credit",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 1, Debit: 10, Credit: 10},
+				{FinanceAccountId: 2, Credit: 10},
+			},
+			wantErr: "either a debit or a credit",
+		},
+		{
+			name: "rejects a line with no amount",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 1},
+				{FinanceAccountId: 2, Credit: 10},
+			},
+			wantErr: "either a debit or a credit",
+		},
+		{
+			name: "rejects unknown accounts",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 1, Debit: 10},
+				{FinanceAccountId: 99, Credit: 10},
+			},
+			wantErr: "finance account 99 not found",
+		},
+		{
+			name: "rejects heading accounts",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 1, Debit: 10},
+				{FinanceAccountId: 4, Credit: 10},
+			},
+			wantErr: "is a heading",
+		},
+		{
+			name: "rejects unbalanced entries",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 1, Debit: 100},
+				{FinanceAccountId: 2, Credit: 99.99},
+			},
+			wantErr: "not balanced: debits 100.00, credits 99.99",
+		},
+		{
+			name: "balances two lines",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 1, Debit: 250},
+				{FinanceAccountId: 2, Credit: 250},
+			},
+			wantTotal: 250,
+		},
+		{
+			name: "balances split lines",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 3, Debit: 60},
+				{FinanceAccountId: 1, Debit: 40},
+				{FinanceAccountId: 1, Credit: 100},
+			},
+			wantTotal: 100,
+		},
+		{
+			name: "balances after rounding to the cent",
+			lines: []JournalLineRequest{
+				{FinanceAccountId: 1, Debit: 0.1},
+				{FinanceAccountId: 1, Debit: 0.2},
+				{FinanceAccountId: 2, Credit: 0.3},
+			},
+			wantTotal: 0.3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newLedgerTestService(t)
+			entry, err := s.PostEntry(JournalEntryRequest{EntryDate: "2026-03-01", Lines: tt.lines})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("PostEntry() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("PostEntry() error = %v", err)
+			}
+			if entry.TotalDebit != tt.wantTotal || entry.TotalCredit != tt.wantTotal {
+				t.Errorf("totals = %.2f/%.2f, want %.2f", entry.TotalDebit, entry.TotalCredit, tt.wantTotal)
+			}
+			if len(entry.Lines) != len(tt.lines) {
+				t.Errorf("saved %d lines, want %d", len(entry.Lines), len(tt.lines))
+			}
+			if entry.SourceType != JournalSourceManual {
+				t.Errorf("source type = %q, want %q", entry.SourceType, JournalSourceManual)
+			}
+		})
+	}
+}
+
+func TestPostEntryNumbersEntriesInSequence(t *testing.T) {
+	s := newLedgerTestService(t)
+	lines := []JournalLineRequest{
+		{FinanceAccountId: 1, Debit: 10},
+		{FinanceAccountId: 2, Credit: 10},
+	}
+
+	prefix := "JE-" + time.Now().Format("20060102") + "-"
+	for _, want := range []string{prefix + "00001", prefix + "00002", prefix + "00003"} {
+		entry, err := s.PostEntry(JournalEntryRequest{Lines: lines})
+		if err != nil {
+			t.Fatalf("PostEntry() error = %v", err)
+		}
+		if entry.EntryNo != want {
+			t.Errorf("entry number = %q, want %q", entry.EntryNo, want)
+		}
+	}
+}
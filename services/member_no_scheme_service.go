@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"strings"
+	"time"
+)
+
+const maxRenumberSchools = 5000
+
+// RenumberRequest selects the schools to re-number: a zone, a region or a list of schools
+type RenumberRequest struct {
+	ZoneId            *int64 `json:"zone_id"`
+	RegionId          *int64 `json:"region_id"`
+	SchoolIds         []uint `json:"school_ids"`
+	IncludeConforming bool   `json:"include_conforming"` // also re-number schools whose numbers already follow the scheme
+	DryRun            *bool  `json:"dry_run"`            // defaults to true
+}
+
+// RenumberResult is the old-to-new mapping of a re-numbering, or of a dry run of one
+type RenumberResult struct {
+	DryRun   bool                           `json:"dry_run"`
+	BatchId  string                         `json:"batch_id,omitempty"`
+	Schools  int                            `json:"schools"`
+	Skipped  int                            `json:"skipped"` // schools already numbered under the scheme
+	Mappings []repositories.MemberNoMapping `json:"mappings"`
+}
+
+type MemberNoSchemeService struct {
+	schemeRepo *repositories.MemberNoSchemeRepository
+	zoneRepo   *repositories.ZoneRepository
+	regionRepo *repositories.RegionRepository
+}
+
+func NewMemberNoSchemeService(schemeRepo *repositories.MemberNoSchemeRepository, zoneRepo *repositories.ZoneRepository, regionRepo *repositories.RegionRepository) *MemberNoSchemeService {
+	return &MemberNoSchemeService{
+		schemeRepo: schemeRepo,
+		zoneRepo:   zoneRepo,
+		regionRepo: regionRepo,
+	}
+}
+
+// ListSchemes returns the numbering schemes the user can see: all of them for national admins, their
+// region's and its zones' for region admins and their zone's for zone admins
+func (s *MemberNoSchemeService) ListSchemes(scopeType string, ownerCtx *utils.OwnerContext) ([]models.MemberNoScheme, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	schemes, err := s.schemeRepo.List(scopeType, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, err
+	}
+	for i := range schemes {
+		s.describeScheme(&schemes[i])
+	}
+	return schemes, nil
+}
+
+// PlanForZone returns the pattern a zone's member numbers follow and its next sequence number
+func (s *MemberNoSchemeService) PlanForZone(zoneID int64, ownerCtx *utils.OwnerContext) (*repositories.MemberNoPlan, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	if _, err := s.zoneRepo.FindByIDWithRoleFilter(uint(zoneID), ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return nil, errors.New("zone not found")
+	}
+	return s.schemeRepo.Plan(zoneID)
+}
+
+// PreviewPattern checks a pattern and shows the first member number it would give in a zone
+func (s *MemberNoSchemeService) PreviewPattern(pattern string, zoneID int64, ownerCtx *utils.OwnerContext) (string, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return "", err
+	}
+	if err := repositories.ValidateMemberNoPattern(pattern); err != nil {
+		return "", err
+	}
+	if _, err := s.zoneRepo.FindByIDWithRoleFilter(uint(zoneID), ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return "", errors.New("zone not found")
+	}
+	return s.schemeRepo.ExampleMemberNo(pattern, zoneID)
+}
+
+// CreateScheme sets the numbering pattern of a zone, a region or the whole country. Existing member
+// numbers are not changed; use Renumber for that.
+func (s *MemberNoSchemeService) CreateScheme(scheme *models.MemberNoScheme, createdBy *int64, ownerCtx *utils.OwnerContext) error {
+	if scheme.ScopeType == repositories.MemberNoScopeNational {
+		scheme.ScopeId = 0
+	}
+	if err := s.canConfigureScope(scheme.ScopeType, scheme.ScopeId, ownerCtx); err != nil {
+		return err
+	}
+	scheme.Pattern = strings.TrimSpace(scheme.Pattern)
+	if err := repositories.ValidateMemberNoPattern(scheme.Pattern); err != nil {
+		return err
+	}
+	exists, err := s.schemeRepo.ScopeExists(scheme.ScopeType, scheme.ScopeId)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.New("a numbering scheme already exists for this scope")
+	}
+
+	scheme.CreatedBy = createdBy
+	if err := s.schemeRepo.Create(scheme); err != nil {
+		return err
+	}
+	s.describeScheme(scheme)
+	return nil
+}
+
+// UpdateScheme changes a scheme's pattern or description
+func (s *MemberNoSchemeService) UpdateScheme(id uint, pattern, description *string, ownerCtx *utils.OwnerContext) (*models.MemberNoScheme, error) {
+	scheme, err := s.configurableScheme(id, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if pattern != nil {
+		trimmed := strings.TrimSpace(*pattern)
+		if err := repositories.ValidateMemberNoPattern(trimmed); err != nil {
+			return nil, err
+		}
+		updates["pattern"] = trimmed
+		scheme.Pattern = trimmed
+	}
+	if description != nil {
+		updates["description"] = description
+		scheme.Description = description
+	}
+	if len(updates) == 0 {
+		return nil, errors.New("no fields to update")
+	}
+
+	if err := s.schemeRepo.Update(id, updates); err != nil {
+		return nil, err
+	}
+	s.describeScheme(scheme)
+	return scheme, nil
+}
+
+// DeleteScheme removes a scheme; its zones fall back to the region's, the national or the default pattern
+func (s *MemberNoSchemeService) DeleteScheme(id uint, ownerCtx *utils.OwnerContext) error {
+	if _, err := s.configurableScheme(id, ownerCtx); err != nil {
+		return err
+	}
+	return s.schemeRepo.Delete(id)
+}
+
+// Renumber gives the selected schools new member numbers under their zone's scheme, oldest members
+// first. Schools already numbered under the scheme keep their numbers unless IncludeConforming is
+// set. A dry run only returns the mapping; otherwise the mapping is recorded under a batch id.
+func (s *MemberNoSchemeService) Renumber(request RenumberRequest, changedBy *int64, ownerCtx *utils.OwnerContext) (*RenumberResult, error) {
+	switch {
+	case request.ZoneId != nil:
+		if err := s.canConfigureScope(repositories.MemberNoScopeZone, *request.ZoneId, ownerCtx); err != nil {
+			return nil, err
+		}
+	case request.RegionId != nil:
+		if err := s.canConfigureScope(repositories.MemberNoScopeRegion, *request.RegionId, ownerCtx); err != nil {
+			return nil, err
+		}
+	case len(request.SchoolIds) > 0:
+		if err := canConfigureMemberNumbers(ownerCtx); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("zone_id, region_id or school_ids is required")
+	}
+
+	schools, err := s.schemeRepo.ListSchoolsForRenumber(request.ZoneId, request.RegionId, request.SchoolIds, ownerCtx.GetRegionIDFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RenumberResult{DryRun: request.DryRun == nil || *request.DryRun}
+	selected := make([]models.School, 0, len(schools))
+	plans := map[int64]*repositories.MemberNoPlan{}
+	for _, school := range schools {
+		plan, ok := plans[*school.ZoneId]
+		if !ok {
+			if plan, err = s.schemeRepo.Plan(*school.ZoneId); err != nil {
+				return nil, fmt.Errorf("zone %d: %v", *school.ZoneId, err)
+			}
+			plans[*school.ZoneId] = plan
+		}
+		if !request.IncludeConforming && plan.Matches(school.MemberNo) {
+			result.Skipped++
+			continue
+		}
+		selected = append(selected, school)
+	}
+	if len(selected) > maxRenumberSchools {
+		return nil, fmt.Errorf("%d schools selected; re-number at most %d at a time", len(selected), maxRenumberSchools)
+	}
+
+	if result.DryRun {
+		result.Mappings, err = s.schemeRepo.PreviewRenumber(selected)
+	} else {
+		result.BatchId = "RN-" + time.Now().Format("20060102-150405")
+		result.Mappings, err = s.schemeRepo.Renumber(selected, result.BatchId, changedBy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Schools = len(result.Mappings)
+	return result, nil
+}
+
+// ListChanges returns the recorded old-to-new member numbers of the schools the user can see
+func (s *MemberNoSchemeService) ListChanges(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.MemberNoChange, int64, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+	return s.schemeRepo.ListChanges(filters, page, limit, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+func (s *MemberNoSchemeService) configurableScheme(id uint, ownerCtx *utils.OwnerContext) (*models.MemberNoScheme, error) {
+	scheme, err := s.schemeRepo.FindByID(id)
+	if err != nil {
+		return nil, errors.New("numbering scheme not found")
+	}
+	if err := s.canConfigureScope(scheme.ScopeType, scheme.ScopeId, ownerCtx); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// canConfigureScope allows national admins to set any scheme and region admins to set their
+// region's and its zones'
+func (s *MemberNoSchemeService) canConfigureScope(scopeType string, scopeID int64, ownerCtx *utils.OwnerContext) error {
+	if err := canConfigureMemberNumbers(ownerCtx); err != nil {
+		return err
+	}
+
+	switch scopeType {
+	case repositories.MemberNoScopeNational:
+		if !ownerCtx.IsNationalAdmin() {
+			return errors.New("access denied")
+		}
+	case repositories.MemberNoScopeRegion:
+		if _, err := s.regionRepo.FindByIDWithRoleFilter(uint(scopeID), ownerCtx.GetRegionIDFilter(), nil); err != nil {
+			return errors.New("region not found")
+		}
+	case repositories.MemberNoScopeZone:
+		if _, err := s.zoneRepo.FindByIDWithRoleFilter(uint(scopeID), ownerCtx.GetRegionIDFilter(), nil); err != nil {
+			return errors.New("zone not found")
+		}
+	default:
+		return errors.New("scope_type must be national, region or zone")
+	}
+	return nil
+}
+
+// describeScheme fills in the scheme's scope name and an example number
+func (s *MemberNoSchemeService) describeScheme(scheme *models.MemberNoScheme) {
+	switch scheme.ScopeType {
+	case repositories.MemberNoScopeRegion:
+		if region, err := s.regionRepo.FindByID(uint(scheme.ScopeId)); err == nil {
+			scheme.ScopeName = region.Name
+		}
+	case repositories.MemberNoScopeZone:
+		if zone, err := s.zoneRepo.FindByID(uint(scheme.ScopeId)); err == nil {
+			scheme.ScopeName = zone.Name
+			if example, err := s.schemeRepo.ExampleMemberNo(scheme.Pattern, int64(zone.ID)); err == nil {
+				scheme.Example = example
+			}
+		}
+	}
+}
+
+// canConfigureMemberNumbers allows national and region admins to change member numbering
+func canConfigureMemberNumbers(ownerCtx *utils.OwnerContext) error {
+	if ownerCtx == nil || !(ownerCtx.IsNationalAdmin() || ownerCtx.IsRegionAdmin()) {
+		return errors.New("access denied")
+	}
+	return nil
+}
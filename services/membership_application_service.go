@@ -0,0 +1,445 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Membership application statuses
+const (
+	ApplicationStatusPending  = "pending"
+	ApplicationStatusApproved = "approved"
+	ApplicationStatusRejected = "rejected"
+)
+
+// ApplicationContactPerson is a contact person submitted with a membership application
+type ApplicationContactPerson struct {
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	MobileNo  string `json:"mobile_no"`
+	Relation  string `json:"relation"`
+}
+
+type MembershipApplicationService struct {
+	applicationRepo   *repositories.MembershipApplicationRepository
+	schoolRepo        *repositories.SchoolRepository
+	userRepo          *repositories.UserRepository
+	contactPersonRepo *repositories.ContactPersonRepository
+	inviteRepo        *repositories.ExecutiveInviteRepository
+	schoolService     *SchoolService
+	smsService        *SmsService
+}
+
+func NewMembershipApplicationService(
+	applicationRepo *repositories.MembershipApplicationRepository,
+	schoolRepo *repositories.SchoolRepository,
+	userRepo *repositories.UserRepository,
+	contactPersonRepo *repositories.ContactPersonRepository,
+	inviteRepo *repositories.ExecutiveInviteRepository,
+	schoolService *SchoolService,
+	smsService *SmsService,
+) *MembershipApplicationService {
+	return &MembershipApplicationService{
+		applicationRepo:   applicationRepo,
+		schoolRepo:        schoolRepo,
+		userRepo:          userRepo,
+		contactPersonRepo: contactPersonRepo,
+		inviteRepo:        inviteRepo,
+		schoolService:     schoolService,
+		smsService:        smsService,
+	}
+}
+
+// SubmitApplication queues a publicly registered school for review by its zone
+func (s *MembershipApplicationService) SubmitApplication(application *models.MembershipApplication, contacts []ApplicationContactPerson) error {
+	application.Name = strings.TrimSpace(application.Name)
+	if application.Name == "" {
+		return errors.New("school name is required")
+	}
+
+	exists, err := s.schoolRepo.VerifyZoneExists(application.ZoneId)
+	if err != nil || !exists {
+		return errors.New("invalid zone selected")
+	}
+
+	gpsAddress, err := normalizeGPSAddress(application.GpsAddress)
+	if err != nil {
+		return err
+	}
+	application.GpsAddress = gpsAddress
+
+	var valid []ApplicationContactPerson
+	for _, contact := range contacts {
+		if contact.FirstName != "" && contact.LastName != "" {
+			valid = append(valid, contact)
+		}
+	}
+	if len(valid) == 0 {
+		return errors.New("at least one contact person is required")
+	}
+
+	if application.Email != nil && *application.Email != "" {
+		exists, err := s.schoolRepo.EmailExists(*application.Email, nil)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.New("a school with this email is already a member")
+		}
+	}
+
+	pending, err := s.applicationRepo.PendingExists(application.ZoneId, application.Name)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return errors.New("an application for this school is already awaiting review")
+	}
+
+	encoded, err := json.Marshal(valid)
+	if err != nil {
+		return err
+	}
+	contactsJSON := datatypes.JSON(encoded)
+	application.ContactPersons = &contactsJSON
+	application.Status = ApplicationStatusPending
+	application.IsDeleted = false
+
+	return s.applicationRepo.Create(application)
+}
+
+// ListApplicationsWithRole returns the applications in the zones the user administers
+func (s *MembershipApplicationService) ListApplicationsWithRole(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.MembershipApplication, int64, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+	return s.applicationRepo.ListWithRoleFilter(filters, page, limit, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+// GetApplicationWithRole returns an application if its zone is accessible by the user's role
+func (s *MembershipApplicationService) GetApplicationWithRole(id uint, ownerCtx *utils.OwnerContext) (*models.MembershipApplication, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	application, err := s.applicationRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("application not found")
+	}
+	return application, nil
+}
+
+// ApproveApplication admits the applicant as a member school. The member number, the school with its
+// contact persons and school admin user, the link back to the application and an invite to set the
+// school admin password are saved in one transaction, so a failure leaves the application pending
+// and the member number unused. The contacts are then texted the invite.
+func (s *MembershipApplicationService) ApproveApplication(id uint, notes string, reviewedBy *int64, ownerCtx *utils.OwnerContext) (*models.School, error) {
+	application, err := s.pendingApplication(id, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+	contacts := applicationContacts(application)
+
+	var school *models.School
+	var user *models.User
+	var invite *models.ExecutiveInvite
+	var token string
+	err = s.applicationRepo.Transaction(func(tx *gorm.DB) error {
+		applicationRepo := s.applicationRepo.WithTx(tx)
+
+		// Claim the application first so two reviewers cannot admit the same school twice
+		review := map[string]interface{}{
+			"status":      ApplicationStatusApproved,
+			"reviewed_by": reviewedBy,
+			"reviewed_at": time.Now(),
+		}
+		if notes != "" {
+			review["review_notes"] = notes
+		}
+		if err := applicationRepo.Review(id, review); err != nil {
+			return errors.New("application has already been reviewed")
+		}
+
+		var err error
+		school, user, err = s.admitSchool(tx, application, contacts)
+		if err != nil {
+			return err
+		}
+
+		schoolID := int64(school.ID)
+		if err := applicationRepo.Update(id, map[string]interface{}{
+			"school_id": schoolID,
+			"member_no": school.MemberNo,
+		}); err != nil {
+			return err
+		}
+
+		invite, token, err = createInvite(s.inviteRepo.WithTx(tx), user, 0, &schoolID, reviewedBy)
+		if err != nil {
+			return fmt.Errorf("failed to create invite: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.sendSchoolInvite(application, school, user, invite, token, contacts)
+	return school, nil
+}
+
+// ResendInvite sends an admitted school a new invite to set its school admin password; earlier
+// pending invites stop working. Once the password has been set no further invites are sent.
+func (s *MembershipApplicationService) ResendInvite(id uint, invitedBy *int64, ownerCtx *utils.OwnerContext) (*InviteDelivery, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	application, err := s.applicationRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("application not found")
+	}
+	if application.Status != ApplicationStatusApproved || application.SchoolId == nil {
+		return nil, errors.New("application has not been approved")
+	}
+	school, err := s.schoolRepo.FindByID(uint(*application.SchoolId))
+	if err != nil || school.UserId == nil {
+		return nil, errors.New("school has no user account")
+	}
+	user, err := s.userRepo.FindByID(uint(*school.UserId))
+	if err != nil {
+		return nil, errors.New("school has no user account")
+	}
+	if user.IsFirstLogin == nil || !*user.IsFirstLogin {
+		return nil, errors.New("school admin has already set their password")
+	}
+
+	schoolID := int64(school.ID)
+	invite, token, err := createInvite(s.inviteRepo, user, 0, &schoolID, invitedBy)
+	if err != nil {
+		return nil, err
+	}
+	return s.sendSchoolInvite(application, school, user, invite, token, applicationContacts(application)), nil
+}
+
+// RejectApplication declines an application with a reason and notifies the applicant
+func (s *MembershipApplicationService) RejectApplication(id uint, reason, notes string, reviewedBy *int64, ownerCtx *utils.OwnerContext) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("a reason is required to reject an application")
+	}
+
+	application, err := s.pendingApplication(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	review := map[string]interface{}{
+		"status":           ApplicationStatusRejected,
+		"rejection_reason": reason,
+		"reviewed_by":      reviewedBy,
+		"reviewed_at":      time.Now(),
+	}
+	if notes != "" {
+		review["review_notes"] = notes
+	}
+	if err := s.applicationRepo.Review(id, review); err != nil {
+		return errors.New("application has already been reviewed")
+	}
+
+	recipients := contactNumbers(applicationContacts(application))
+	if application.MobileNo != nil && *application.MobileNo != "" {
+		recipients = appendUnique(recipients, *application.MobileNo)
+	}
+	message := fmt.Sprintf("Your GNAPS membership application for %s was not approved. Reason: %s", application.Name, reason)
+	s.notify(application, recipients, message)
+
+	return nil
+}
+
+// pendingApplication loads an application the user may review and checks it is still pending
+func (s *MembershipApplicationService) pendingApplication(id uint, ownerCtx *utils.OwnerContext) (*models.MembershipApplication, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	application, err := s.applicationRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("application not found")
+	}
+	if application.Status != ApplicationStatusPending {
+		return nil, errors.New("application has already been reviewed")
+	}
+	return application, nil
+}
+
+// admitSchool creates the member school, its school admin user and contact persons in tx. The user
+// gets no password; the school sets one through its invite.
+func (s *MembershipApplicationService) admitSchool(tx *gorm.DB, application *models.MembershipApplication, contacts []ApplicationContactPerson) (*models.School, *models.User, error) {
+	schoolRepo, userRepo := s.schoolRepo.WithTx(tx), s.userRepo.WithTx(tx)
+
+	// CreateSchool allocates the member number from the zone's numbering scheme
+	zoneID := application.ZoneId
+	school := &models.School{
+		Name:        application.Name,
+		ZoneId:      &zoneID,
+		Address:     application.Address,
+		Location:    application.Location,
+		MobileNo:    application.MobileNo,
+		Email:       application.Email,
+		GpsAddress:  application.GpsAddress,
+		JoiningDate: time.Now(),
+	}
+	if application.DateOfEstablishment != nil {
+		school.DateOfEstablishment = *application.DateOfEstablishment
+	}
+
+	// CreateSchool validates the school and creates its user when the school has an email
+	if err := s.schoolService.WithTx(tx).CreateSchool(school); err != nil {
+		return nil, nil, err
+	}
+
+	if school.UserId == nil {
+		// Without a school email, the user is reached through the first contact person
+		email, mobileNo := "", ""
+		if school.MobileNo != nil {
+			mobileNo = *school.MobileNo
+		}
+		for _, contact := range contacts {
+			if email == "" && contact.Email != "" {
+				email = contact.Email
+			}
+			if mobileNo == "" && contact.MobileNo != "" {
+				mobileNo = contact.MobileNo
+			}
+		}
+
+		user, err := userRepo.CreateUserForSchool(school.Name, email, mobileNo, school.MemberNo)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create user account: %v", err)
+		}
+		userID := int64(user.ID)
+		school.UserId = &userID
+		if err := schoolRepo.Update(school.ID, map[string]interface{}{"user_id": userID}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := userRepo.ClearPassword(uint(*school.UserId)); err != nil {
+		return nil, nil, fmt.Errorf("failed to create user account: %v", err)
+	}
+	user, err := userRepo.FindByID(uint(*school.UserId))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create user account: %v", err)
+	}
+
+	schoolID := int64(school.ID)
+	contactPersonRepo := s.contactPersonRepo.WithTx(tx)
+	for _, contact := range contacts {
+		contact := contact
+		contactPerson := &models.ContactPerson{
+			SchoolId:  &schoolID,
+			FirstName: &contact.FirstName,
+			LastName:  &contact.LastName,
+		}
+		if contact.Email != "" {
+			contactPerson.Email = &contact.Email
+		}
+		if contact.MobileNo != "" {
+			contactPerson.MobileNo = &contact.MobileNo
+		}
+		if contact.Relation != "" {
+			contactPerson.Relation = &contact.Relation
+		}
+
+		if err := contactPersonRepo.Create(contactPerson); err != nil {
+			return nil, nil, fmt.Errorf("failed to create contact person: %v", err)
+		}
+	}
+
+	return school, user, nil
+}
+
+// sendSchoolInvite texts the school's contacts and the school admin the link to set the school admin
+// password, and emails it when the invite has an email address
+func (s *MembershipApplicationService) sendSchoolInvite(application *models.MembershipApplication, school *models.School, user *models.User, invite *models.ExecutiveInvite, token string, contacts []ApplicationContactPerson) *InviteDelivery {
+	hours := executiveInviteHours()
+	link := executiveInviteURL(token)
+	delivery := &InviteDelivery{InviteId: invite.ID, ExpiresAt: invite.ExpiresAt, Channels: []string{}}
+
+	recipients := contactNumbers(contacts)
+	if invite.SentToMobile != nil {
+		recipients = appendUnique(recipients, *invite.SentToMobile)
+	}
+	message := fmt.Sprintf("Congratulations! %s has been admitted as a GNAPS member school. Member No: %s. Set the password for username %s within %d hours: %s",
+		school.Name, school.MemberNo, stringValue(user.Username), hours, link)
+	if s.notify(application, recipients, message) > 0 {
+		delivery.Channels = append(delivery.Channels, "sms")
+	}
+
+	if invite.SentToEmail != nil {
+		body := fmt.Sprintf("Hello,\n\n%s has been admitted as a GNAPS member school with member number %s.\n\nUsername: %s\n\nOpen the link below to set the school's password. It can be used once and expires in %d hours.\n\n%s\n",
+			school.Name, school.MemberNo, stringValue(user.Username), hours, link)
+		if err := utils.SendMail(*invite.SentToEmail, "Set up your GNAPS school account", body); err != nil {
+			log.Printf("Failed to send invite %d to school %d by email: %v", invite.ID, school.ID, err)
+		} else {
+			delivery.Channels = append(delivery.Channels, "email")
+		}
+	}
+	return delivery
+}
+
+// notify texts the applicant through the zone's SMS package and returns how many messages went out;
+// failures are logged, not returned
+func (s *MembershipApplicationService) notify(application *models.MembershipApplication, recipients []string, message string) int {
+	if s.smsService == nil {
+		return 0
+	}
+	sent := 0
+	for _, recipient := range recipients {
+		if err := s.smsService.EnqueueSMS(message, recipient, utils.OwnerTypeZone, application.ZoneId, "", false); err != nil {
+			log.Printf("Failed to notify %s about membership application %d: %v", recipient, application.ID, err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// applicationContacts decodes the contact persons stored with an application
+func applicationContacts(application *models.MembershipApplication) []ApplicationContactPerson {
+	var contacts []ApplicationContactPerson
+	if application.ContactPersons != nil {
+		if err := json.Unmarshal(*application.ContactPersons, &contacts); err != nil {
+			log.Printf("Invalid contact persons on membership application %d: %v", application.ID, err)
+		}
+	}
+	return contacts
+}
+
+func contactNumbers(contacts []ApplicationContactPerson) []string {
+	var numbers []string
+	for _, contact := range contacts {
+		if contact.MobileNo != "" {
+			numbers = appendUnique(numbers, contact.MobileNo)
+		}
+	}
+	return numbers
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"image/png"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+	"github.com/go-pdf/fpdf"
+)
+
+// Membership certificate statuses
+const (
+	CertificateStatusActive     = "active"
+	CertificateStatusRevoked    = "revoked"
+	CertificateStatusSuperseded = "superseded" // replaced by a later certificate for the same school
+)
+
+// certificateCodeAlphabet leaves out characters that are easily misread (0/O, 1/I)
+const certificateCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const certificateCodeLength = 10
+
+// CertificateVerification is what the public sees when checking a certificate code
+type CertificateVerification struct {
+	Valid         bool       `json:"valid"`
+	Status        string     `json:"status"` // valid, expired, revoked, superseded, lapsed, suspended or invalid
+	CertificateNo string     `json:"certificate_no"`
+	SchoolName    string     `json:"school_name"`
+	MemberNo      string     `json:"member_no"`
+	ZoneName      *string    `json:"zone_name"`
+	RegionName    *string    `json:"region_name"`
+	ValidFrom     string     `json:"valid_from"`
+	ValidUntil    string     `json:"valid_until"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+type MembershipCertificateService struct {
+	certificateRepo *repositories.MembershipCertificateRepository
+	schoolRepo      *repositories.SchoolRepository
+	regionRepo      *repositories.RegionRepository
+	statusService   *MembershipStatusService
+}
+
+func NewMembershipCertificateService(
+	certificateRepo *repositories.MembershipCertificateRepository,
+	schoolRepo *repositories.SchoolRepository,
+	regionRepo *repositories.RegionRepository,
+	statusService *MembershipStatusService,
+) *MembershipCertificateService {
+	return &MembershipCertificateService{
+		certificateRepo: certificateRepo,
+		schoolRepo:      schoolRepo,
+		regionRepo:      regionRepo,
+		statusService:   statusService,
+	}
+}
+
+// IssueCertificate issues a membership certificate to a school whose membership is active, valid from today
+// until validUntil (YYYY-MM-DD), or the end of the year when empty. The school's previous
+// certificate is superseded.
+func (s *MembershipCertificateService) IssueCertificate(schoolID uint, validUntil string, issuedBy *int64, ownerCtx *utils.OwnerContext) (*models.MembershipCertificate, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	school, err := s.schoolRepo.FindByIDWithRoleFilter(schoolID, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("school not found")
+	}
+
+	if err := s.statusService.RequireActive(school.ID); err != nil {
+		return nil, err
+	}
+
+	today := time.Now().Truncate(24 * time.Hour)
+	until := time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, time.UTC)
+	if validUntil != "" {
+		until, err = time.Parse("2006-01-02", validUntil)
+		if err != nil {
+			return nil, errors.New("valid_until must be a date (YYYY-MM-DD)")
+		}
+		if until.Before(today) {
+			return nil, errors.New("valid_until cannot be in the past")
+		}
+	}
+
+	code, err := s.newVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	certificate := &models.MembershipCertificate{
+		SchoolId:         int64(school.ID),
+		VerificationCode: code,
+		SchoolName:       school.Name,
+		MemberNo:         school.MemberNo,
+		ZoneId:           school.ZoneId,
+		ValidFrom:        today,
+		ValidUntil:       until,
+		Status:           CertificateStatusActive,
+		IssuedBy:         issuedBy,
+	}
+	if school.Zone != nil {
+		certificate.ZoneName = school.Zone.Name
+		if school.Zone.RegionId != nil {
+			if region, err := s.regionRepo.FindByID(uint(*school.Zone.RegionId)); err == nil {
+				certificate.RegionName = region.Name
+			}
+		}
+	}
+
+	if err := s.certificateRepo.Issue(certificate); err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// ListCertificatesWithRole returns the certificates of the schools in the user's zones
+func (s *MembershipCertificateService) ListCertificatesWithRole(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.MembershipCertificate, int64, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+	return s.certificateRepo.ListWithRoleFilter(filters, page, limit, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+// GetCertificateWithRole returns a certificate if its school is accessible by the user's role
+func (s *MembershipCertificateService) GetCertificateWithRole(id uint, ownerCtx *utils.OwnerContext) (*models.MembershipCertificate, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	certificate, err := s.certificateRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("certificate not found")
+	}
+	return certificate, nil
+}
+
+// RevokeCertificate withdraws an active certificate; it stops verifying immediately
+func (s *MembershipCertificateService) RevokeCertificate(id uint, reason string, revokedBy *int64, ownerCtx *utils.OwnerContext) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("a reason is required to revoke a certificate")
+	}
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return err
+	}
+	if _, err := s.certificateRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return errors.New("certificate not found")
+	}
+
+	if err := s.certificateRepo.Revoke(id, map[string]interface{}{
+		"status":            CertificateStatusRevoked,
+		"revoked_at":        time.Now(),
+		"revoked_by":        revokedBy,
+		"revocation_reason": reason,
+	}); err != nil {
+		return errors.New("certificate is no longer active")
+	}
+	return nil
+}
+
+// VerifyCertificate looks up a certificate by the code printed on it. Codes are matched without
+// regard to case, spaces or dashes.
+func (s *MembershipCertificateService) VerifyCertificate(code string) (*CertificateVerification, error) {
+	code = normalizeCertificateCode(code)
+	if len(code) != certificateCodeLength {
+		return nil, errors.New("certificate not found")
+	}
+	certificate, err := s.certificateRepo.FindByCode(code)
+	if err != nil {
+		return nil, errors.New("certificate not found")
+	}
+
+	verification := &CertificateVerification{
+		CertificateNo: certificate.CertificateNo,
+		SchoolName:    certificate.SchoolName,
+		MemberNo:      certificate.MemberNo,
+		ZoneName:      certificate.ZoneName,
+		RegionName:    certificate.RegionName,
+		ValidFrom:     certificate.ValidFrom.Format("2006-01-02"),
+		ValidUntil:    certificate.ValidUntil.Format("2006-01-02"),
+	}
+	switch {
+	case certificate.Status == CertificateStatusRevoked:
+		verification.Status = CertificateStatusRevoked
+		verification.RevokedAt = certificate.RevokedAt
+	case certificate.Status == CertificateStatusSuperseded:
+		verification.Status = CertificateStatusSuperseded
+	case time.Now().Truncate(24 * time.Hour).After(certificate.ValidUntil):
+		verification.Status = "expired"
+	case s.membershipLapsedOrSuspended(certificate, verification):
+		// Status set by the check: the certificate stands but the membership behind it does not
+	default:
+		verification.Status = "valid"
+		verification.Valid = true
+	}
+	return verification, nil
+}
+
+// membershipLapsedOrSuspended reports the school's membership status on the verification when it
+// is no longer active. A school that has been deleted or merged into another, or whose status
+// cannot be read, makes the certificate invalid.
+func (s *MembershipCertificateService) membershipLapsedOrSuspended(certificate *models.MembershipCertificate, verification *CertificateVerification) bool {
+	status, err := s.statusService.CurrentStatus(uint(certificate.SchoolId))
+	if err != nil {
+		verification.Status = "invalid"
+		return true
+	}
+	if status == MembershipStatusActive {
+		return false
+	}
+	verification.Status = status
+	return true
+}
+
+// WriteCertificatePDF renders a certificate as a landscape A4 PDF with a QR code linking to its
+// public verification
+func (s *MembershipCertificateService) WriteCertificatePDF(certificate *models.MembershipCertificate, w io.Writer) error {
+	if certificate.Status == CertificateStatusRevoked {
+		return errors.New("certificate has been revoked")
+	}
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+
+	// Double border
+	pdf.SetDrawColor(20, 70, 40)
+	pdf.SetLineWidth(1.5)
+	pdf.Rect(10, 10, pageWidth-20, pageHeight-20, "D")
+	pdf.SetLineWidth(0.4)
+	pdf.Rect(14, 14, pageWidth-28, pageHeight-28, "D")
+
+	centered := func(y float64, size float64, style, text string) {
+		pdf.SetFont("Helvetica", style, size)
+		pdf.SetXY(20, y)
+		pdf.CellFormat(pageWidth-40, size*0.5, tr(text), "", 0, "C", false, 0, "")
+	}
+
+	pdf.SetTextColor(20, 70, 40)
+	centered(28, 14, "B", "GHANA NATIONAL ASSOCIATION OF PRIVATE SCHOOLS")
+	centered(42, 30, "B", "Certificate of Membership")
+	pdf.SetTextColor(0, 0, 0)
+	centered(64, 12, "", "This is to certify that")
+	centered(76, 24, "B", certificate.SchoolName)
+	centered(94, 12, "", "is a registered member school in good standing of GNAPS")
+
+	location := stringValue(certificate.ZoneName)
+	if location != "" {
+		location += " Zone"
+	}
+	if region := stringValue(certificate.RegionName); region != "" {
+		if location != "" {
+			location += ", "
+		}
+		location += region + " Region"
+	}
+	if location != "" {
+		centered(104, 12, "", location)
+	}
+
+	centered(118, 13, "B", "Member No: "+certificate.MemberNo)
+	centered(128, 11, "", fmt.Sprintf("Valid from %s to %s",
+		certificate.ValidFrom.Format("2 January 2006"), certificate.ValidUntil.Format("2 January 2006")))
+
+	// Certificate details and QR code along the bottom
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetXY(24, pageHeight-46)
+	pdf.CellFormat(120, 5, "Certificate No: "+certificate.CertificateNo, "", 2, "L", false, 0, "")
+	pdf.CellFormat(120, 5, "Verification code: "+formatCertificateCode(certificate.VerificationCode), "", 2, "L", false, 0, "")
+	pdf.CellFormat(120, 5, "Issued: "+certificate.CreatedAt.Format("2 January 2006"), "", 2, "L", false, 0, "")
+
+	qrSize := 32.0
+	if err := addQRCode(pdf, certificateVerifyURL(certificate.VerificationCode), pageWidth-24-qrSize, pageHeight-24-qrSize-6, qrSize); err != nil {
+		return err
+	}
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.SetXY(pageWidth-24-qrSize-10, pageHeight-28)
+	pdf.CellFormat(qrSize+10, 4, "Scan to verify", "", 0, "C", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+// newVerificationCode generates an unused random verification code
+func (s *MembershipCertificateService) newVerificationCode() (string, error) {
+	max := big.NewInt(int64(len(certificateCodeAlphabet)))
+	for attempt := 0; attempt < 5; attempt++ {
+		var b strings.Builder
+		for i := 0; i < certificateCodeLength; i++ {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte(certificateCodeAlphabet[n.Int64()])
+		}
+
+		code := b.String()
+		exists, err := s.certificateRepo.CodeExists(code)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return code, nil
+		}
+	}
+	return "", errors.New("failed to generate a verification code")
+}
+
+// addQRCode draws a QR code of content as a size x size square at (x, y)
+func addQRCode(pdf *fpdf.Fpdf, content string, x, y, size float64) error {
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return err
+	}
+	code, err = barcode.Scale(code, 300, 300)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, code); err != nil {
+		return err
+	}
+	options := fpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("qr", options, &buf)
+	pdf.ImageOptions("qr", x, y, size, size, false, options, 0, "")
+	return pdf.Error()
+}
+
+// certificateVerifyURL is the address the QR code points to. CERTIFICATE_VERIFY_URL may contain
+// a {code} placeholder; otherwise the code is added as a query parameter. It defaults to the
+// frontend's /verify-certificate page.
+func certificateVerifyURL(code string) string {
+	base := os.Getenv("CERTIFICATE_VERIFY_URL")
+	if base == "" {
+		frontend := strings.TrimSpace(strings.Split(os.Getenv("FRONTEND_URL"), ",")[0])
+		if frontend == "" {
+			return formatCertificateCode(code)
+		}
+		base = strings.TrimRight(frontend, "/") + "/verify-certificate"
+	}
+	if strings.Contains(base, "{code}") {
+		return strings.ReplaceAll(base, "{code}", code)
+	}
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	return base + separator + "code=" + code
+}
+
+// formatCertificateCode prints a code in two groups for reading aloud, e.g. ABCDE-FGH23
+func formatCertificateCode(code string) string {
+	if len(code) != certificateCodeLength {
+		return code
+	}
+	return code[:5] + "-" + code[5:]
+}
+
+func normalizeCertificateCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
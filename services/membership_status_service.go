@@ -0,0 +1,275 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// School membership statuses
+const (
+	MembershipStatusActive    = "active"
+	MembershipStatusLapsed    = "lapsed"    // dues unpaid for longer than the lapse period
+	MembershipStatusSuspended = "suspended" // suspended by an admin; only an admin lifts it
+)
+
+// defaultLapseMonths is used when MEMBERSHIP_LAPSE_MONTHS is not set
+const defaultLapseMonths = 3
+
+// MembershipStanding is a school's current membership status and what it entitles the school to
+type MembershipStanding struct {
+	SchoolId        uint                        `json:"school_id"`
+	Status          string                      `json:"status"`
+	StatusReason    *string                     `json:"status_reason"`
+	StatusChangedAt *time.Time                  `json:"status_changed_at"`
+	OldestUnpaidAt  *time.Time                  `json:"oldest_unpaid_at"`
+	LapsesOn        *time.Time                  `json:"lapses_on"` // when unpaid dues will lapse an active school
+	LapseMonths     int                         `json:"lapse_months"`
+	MemberPricing   bool                        `json:"member_pricing"`
+	Certificates    bool                        `json:"certificates"`
+	CanVote         bool                        `json:"can_vote"`
+	History         []models.SchoolStatusChange `json:"history"`
+}
+
+type MembershipStatusService struct {
+	statusRepo *repositories.SchoolStatusRepository
+	schoolRepo *repositories.SchoolRepository
+}
+
+func NewMembershipStatusService(statusRepo *repositories.SchoolStatusRepository, schoolRepo *repositories.SchoolRepository) *MembershipStatusService {
+	return &MembershipStatusService{
+		statusRepo: statusRepo,
+		schoolRepo: schoolRepo,
+	}
+}
+
+// EvaluateAll lapses schools whose oldest unpaid bill is older than the lapse period and reinstates
+// lapsed schools that have paid. Suspended schools are left alone. Run nightly by the status worker.
+func (s *MembershipStatusService) EvaluateAll() error {
+	states, err := s.statusRepo.ListDuesStates()
+	if err != nil {
+		return err
+	}
+
+	lapsed, reinstated := 0, 0
+	for _, state := range states {
+		to, changed, err := s.applyDuesStatus(state)
+		if err != nil {
+			log.Printf("Error evaluating membership status of school %d: %v", state.ID, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if to == MembershipStatusLapsed {
+			lapsed++
+		} else {
+			reinstated++
+		}
+	}
+
+	log.Printf("Membership status evaluation: %d schools checked, %d lapsed, %d reinstated", len(states), lapsed, reinstated)
+	return nil
+}
+
+// CurrentStatus returns the membership status a school's dues call for without changing anything, so
+// a school that has just paid already counts as active. Status changes are left to the nightly
+// evaluation and to ReevaluateDues after payments.
+func (s *MembershipStatusService) CurrentStatus(schoolID uint) (string, error) {
+	state, err := s.statusRepo.FindDuesState(schoolID)
+	if err != nil {
+		return "", errors.New("school not found")
+	}
+	if state.MembershipStatus == MembershipStatusSuspended {
+		return MembershipStatusSuspended, nil
+	}
+	return duesStatus(state.OldestUnpaidAt), nil
+}
+
+// ReevaluateDues moves a school to the status its dues call for; called after the school pays so it
+// is reinstated at once rather than at the next nightly run
+func (s *MembershipStatusService) ReevaluateDues(schoolID uint) error {
+	state, err := s.statusRepo.FindDuesState(schoolID)
+	if err != nil {
+		return errors.New("school not found")
+	}
+	if state.MembershipStatus == MembershipStatusSuspended {
+		return nil
+	}
+	_, _, err = s.applyDuesStatus(*state)
+	return err
+}
+
+// RequireActive fails unless a school's membership is currently active
+func (s *MembershipStatusService) RequireActive(schoolID uint) error {
+	status, err := s.CurrentStatus(schoolID)
+	if err != nil {
+		return err
+	}
+	if status != MembershipStatusActive {
+		return fmt.Errorf("school membership is %s", status)
+	}
+	return nil
+}
+
+// GetStandingWithRole returns a school's membership status, entitlements and status history
+func (s *MembershipStatusService) GetStandingWithRole(schoolID uint, ownerCtx *utils.OwnerContext) (*MembershipStanding, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	if _, err := s.schoolRepo.FindByIDWithRoleFilter(schoolID, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return nil, errors.New("school not found")
+	}
+
+	school, err := s.schoolRepo.FindByID(schoolID)
+	if err != nil {
+		return nil, errors.New("school not found")
+	}
+	state, err := s.statusRepo.FindDuesState(schoolID)
+	if err != nil {
+		return nil, errors.New("school not found")
+	}
+	history, err := s.statusRepo.ListChanges(schoolID)
+	if err != nil {
+		return nil, err
+	}
+
+	months := lapseMonths()
+	standing := &MembershipStanding{
+		SchoolId:        school.ID,
+		Status:          school.MembershipStatus,
+		StatusReason:    school.StatusReason,
+		StatusChangedAt: school.StatusChangedAt,
+		OldestUnpaidAt:  state.OldestUnpaidAt,
+		LapseMonths:     months,
+		History:         history,
+	}
+	if state.OldestUnpaidAt != nil && school.MembershipStatus == MembershipStatusActive {
+		lapsesOn := state.OldestUnpaidAt.AddDate(0, months, 0)
+		standing.LapsesOn = &lapsesOn
+	}
+	active := school.MembershipStatus == MembershipStatusActive
+	standing.MemberPricing = active
+	standing.Certificates = active
+	standing.CanVote = active
+	return standing, nil
+}
+
+// SuspendSchool suspends a school's membership; a reason is required
+func (s *MembershipStatusService) SuspendSchool(schoolID uint, reason string, suspendedBy *int64, ownerCtx *utils.OwnerContext) error {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("a reason is required to suspend a school")
+	}
+	school, err := s.schoolRepo.FindByIDWithRoleFilter(schoolID, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return errors.New("school not found")
+	}
+	if school.MembershipStatus == MembershipStatusSuspended {
+		return errors.New("school is already suspended")
+	}
+
+	changed, err := s.statusRepo.ChangeStatus(school.ID, school.MembershipStatus, MembershipStatusSuspended, &reason, suspendedBy)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return errors.New("school status changed, please try again")
+	}
+	return nil
+}
+
+// LiftSuspension ends a school's suspension; the school returns to active, or to lapsed when its
+// dues are still overdue
+func (s *MembershipStatusService) LiftSuspension(schoolID uint, notes string, liftedBy *int64, ownerCtx *utils.OwnerContext) (string, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return "", err
+	}
+	if _, err := s.schoolRepo.FindByIDWithRoleFilter(schoolID, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return "", errors.New("school not found")
+	}
+	state, err := s.statusRepo.FindDuesState(schoolID)
+	if err != nil {
+		return "", errors.New("school not found")
+	}
+	if state.MembershipStatus != MembershipStatusSuspended {
+		return "", errors.New("school is not suspended")
+	}
+
+	to := duesStatus(state.OldestUnpaidAt)
+	reason := "Suspension lifted"
+	if notes = strings.TrimSpace(notes); notes != "" {
+		reason += ": " + notes
+	}
+	if to == MembershipStatusLapsed {
+		reason += "; dues are overdue"
+	}
+
+	changed, err := s.statusRepo.ChangeStatus(schoolID, MembershipStatusSuspended, to, &reason, liftedBy)
+	if err != nil {
+		return "", err
+	}
+	if !changed {
+		return "", errors.New("school status changed, please try again")
+	}
+	return to, nil
+}
+
+// ListVotersWithRole returns the schools eligible to vote: those whose membership is active
+func (s *MembershipStatusService) ListVotersWithRole(filters map[string]interface{}, page, limit int, ownerCtx *utils.OwnerContext) ([]models.School, int64, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+	filters["membership_status"] = MembershipStatusActive
+	return s.schoolRepo.ListWithRoleFilter(filters, page, limit, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+// applyDuesStatus moves a school that is not suspended to the status its dues call for
+func (s *MembershipStatusService) applyDuesStatus(state repositories.SchoolDuesState) (string, bool, error) {
+	to := duesStatus(state.OldestUnpaidAt)
+	if to == state.MembershipStatus {
+		return to, false, nil
+	}
+
+	var reason string
+	if to == MembershipStatusLapsed {
+		reason = fmt.Sprintf("Dues unpaid since %s", state.OldestUnpaidAt.Format("2006-01-02"))
+	} else {
+		reason = "Outstanding dues paid"
+	}
+	changed, err := s.statusRepo.ChangeStatus(state.ID, state.MembershipStatus, to, &reason, nil)
+	if err != nil {
+		return state.MembershipStatus, false, err
+	}
+	if !changed {
+		// Changed elsewhere in the meantime, e.g. suspended; leave it to the next evaluation
+		return state.MembershipStatus, false, nil
+	}
+	return to, true, nil
+}
+
+// duesStatus is the status called for by a school's oldest unpaid bill
+func duesStatus(oldestUnpaidAt *time.Time) string {
+	if oldestUnpaidAt != nil && !oldestUnpaidAt.AddDate(0, lapseMonths(), 0).After(time.Now()) {
+		return MembershipStatusLapsed
+	}
+	return MembershipStatusActive
+}
+
+// lapseMonths is how long dues may stay unpaid before a school lapses (MEMBERSHIP_LAPSE_MONTHS)
+func lapseMonths() int {
+	if months, err := strconv.Atoi(os.Getenv("MEMBERSHIP_LAPSE_MONTHS")); err == nil && months > 0 {
+		return months
+	}
+	return defaultLapseMonths
+}
@@ -17,10 +17,14 @@ import (
 )
 
 type MomoPaymentService struct {
-	paymentRepo      *repositories.MomoPaymentRepository
-	registrationRepo *repositories.RegistrationRepository
-	eventRepo        *repositories.EventRepository
-	db               *gorm.DB
+	paymentRepo        *repositories.MomoPaymentRepository
+	registrationRepo   *repositories.RegistrationRepository
+	eventRepo          *repositories.EventRepository
+	ledgerService      *LedgerService
+	remittanceService  *RemittanceService
+	bankAccountService *BankAccountService
+	statusService      *MembershipStatusService
+	db                 *gorm.DB
 }
 
 // GatewayCredentials holds the parsed gateway parameters
@@ -30,12 +34,16 @@ type GatewayCredentials struct {
 	CallbackURL string `json:"callbackUrl"`
 }
 
-func NewMomoPaymentService(paymentRepo *repositories.MomoPaymentRepository, registrationRepo *repositories.RegistrationRepository, eventRepo *repositories.EventRepository, db *gorm.DB) *MomoPaymentService {
+func NewMomoPaymentService(paymentRepo *repositories.MomoPaymentRepository, registrationRepo *repositories.RegistrationRepository, eventRepo *repositories.EventRepository, ledgerService *LedgerService, remittanceService *RemittanceService, bankAccountService *BankAccountService, statusService *MembershipStatusService, db *gorm.DB) *MomoPaymentService {
 	return &MomoPaymentService{
-		paymentRepo:      paymentRepo,
-		registrationRepo: registrationRepo,
-		eventRepo:        eventRepo,
-		db:               db,
+		paymentRepo:        paymentRepo,
+		registrationRepo:   registrationRepo,
+		eventRepo:          eventRepo,
+		ledgerService:      ledgerService,
+		remittanceService:  remittanceService,
+		bankAccountService: bankAccountService,
+		statusService:      statusService,
+		db:                 db,
 	}
 }
 
@@ -713,17 +721,6 @@ func (s *MomoPaymentService) createFinanceTransaction(payment *models.MomoPaymen
 		referenceNo = *payment.MomoTransactionId
 	}
 
-	// Get finance account for payments (find or use default)
-	var financeAccountId *int64
-	var financeAccount models.FinanceAccount
-	// Try to find an income account
-	err := s.db.Where("account_type = ? AND is_income = ? AND (is_deleted = ? OR is_deleted IS NULL)",
-		"income", true, false).First(&financeAccount).Error
-	if err == nil {
-		id := int64(financeAccount.ID)
-		financeAccountId = &id
-	}
-
 	// Determine finance type - normalize payment types for consistency
 	financeType := payment.PayeeType
 	if payment.PayeeType != nil {
@@ -753,18 +750,17 @@ func (s *MomoPaymentService) createFinanceTransaction(payment *models.MomoPaymen
 
 	// Create the finance transaction
 	financeTransaction := &models.FinanceTransaction{
-		Title:            &title,
-		Description:      &description,
-		Amount:           payment.Amount,
-		FinanceAccountId: financeAccountId,
-		TransactionDate:  time.Now(),
-		FinanceId:        payment.PayeeId,
-		FinanceType:      financeType,
-		SchoolId:         schoolId, // Include school ID for proper tracking
-		PaymentMode:      &paymentMode,
-		ModeInfo:         &modeInfo,
-		ReferenceNo:      &referenceNo,
-		UserId:           payment.UserId,
+		Title:           &title,
+		Description:     &description,
+		Amount:          payment.Amount,
+		TransactionDate: time.Now(),
+		FinanceId:       payment.PayeeId,
+		FinanceType:     financeType,
+		SchoolId:        schoolId, // Include school ID for proper tracking
+		PaymentMode:     &paymentMode,
+		ModeInfo:        &modeInfo,
+		ReferenceNo:     &referenceNo,
+		UserId:          payment.UserId,
 	}
 
 	// Copy payment details if available
@@ -784,9 +780,41 @@ func (s *MomoPaymentService) createFinanceTransaction(payment *models.MomoPaymen
 		}
 	}
 
-	// Save the finance transaction
-	if err := s.db.Create(financeTransaction).Error; err != nil {
-		return fmt.Errorf("failed to create finance transaction: %w", err)
+	// Credit the income account of what is being paid for (the school bill particular or the
+	// event's bill item), or the owner's general income account when none is configured
+	payeeId := int64(0)
+	if payment.PayeeId != nil {
+		payeeId = *payment.PayeeId
+	}
+	accountId, err := s.ledgerService.IncomeAccountForPayment(stringValue(financeType), payeeId, financeTransaction.OwnerType, financeTransaction.OwnerId)
+	if err != nil {
+		return fmt.Errorf("failed to resolve income account: %w", err)
+	}
+	financeTransaction.FinanceAccountId = accountId
+
+	// Gateway collections land in the owner's default MoMo wallet
+	financeTransaction.BankAccountId, _ = s.bankAccountService.ResolveAccount(financeTransaction.OwnerType, financeTransaction.OwnerId, paymentMode, nil)
+
+	// Save the finance transaction with its journal entry and allocation; on failure nothing is
+	// saved and the payment is left without finance_transaction_ids
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(financeTransaction).Error; err != nil {
+			return fmt.Errorf("failed to create finance transaction: %w", err)
+		}
+
+		// Post the balanced journal entry for the receipt
+		if _, err := s.ledgerService.WithTx(tx).PostPayment(financeTransaction); err != nil {
+			return fmt.Errorf("failed to post finance transaction to the ledger: %w", err)
+		}
+
+		// Split school bill payments between the levels their bill particulars are owed to
+		if err := s.remittanceService.WithTx(tx).AllocateTransaction(financeTransaction); err != nil {
+			return fmt.Errorf("failed to allocate finance transaction: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Update momo_payment with the finance_transaction_ids as JSON array
@@ -1050,6 +1078,13 @@ func (s *MomoPaymentService) processSchoolBillPaymentSuccess(payment *models.Mom
 
 	fmt.Printf("School bill payment %d successful: Bill %d - AmountPaid %.2f -> %.2f, Balance %.2f -> %.2f\n",
 		payment.ID, schoolBillID, currentAmountPaid, newAmountPaid, currentBalance, newBalance)
+
+	// A lapsed school is reinstated once its overdue dues are paid; otherwise the nightly run catches up
+	if schoolBill.SchoolId != nil {
+		if err := s.statusService.ReevaluateDues(uint(*schoolBill.SchoolId)); err != nil {
+			fmt.Printf("Failed to re-evaluate membership status of school %d: %v\n", *schoolBill.SchoolId, err)
+		}
+	}
 	return nil
 }
 
@@ -12,16 +12,18 @@ import (
 )
 
 type NewsService struct {
-	newsRepo    *repositories.NewsRepository
-	commentRepo *repositories.CommentRepository
-	userRepo    *repositories.UserRepository
+	newsRepo        *repositories.NewsRepository
+	commentRepo     *repositories.CommentRepository
+	userRepo        *repositories.UserRepository
+	groupMemberRepo *repositories.SchoolGroupMemberRepository
 }
 
-func NewNewsService(newsRepo *repositories.NewsRepository, commentRepo *repositories.CommentRepository, userRepo *repositories.UserRepository) *NewsService {
+func NewNewsService(newsRepo *repositories.NewsRepository, commentRepo *repositories.CommentRepository, userRepo *repositories.UserRepository, groupMemberRepo *repositories.SchoolGroupMemberRepository) *NewsService {
 	return &NewsService{
-		newsRepo:    newsRepo,
-		commentRepo: commentRepo,
-		userRepo:    userRepo,
+		newsRepo:        newsRepo,
+		commentRepo:     commentRepo,
+		userRepo:        userRepo,
+		groupMemberRepo: groupMemberRepo,
 	}
 }
 
@@ -105,11 +107,31 @@ func (s *NewsService) GetAccessibleEntities(userId uint, userRole string) (regio
 		}
 	}
 
+	// Groups that any of the accessible schools belong to
+	groupIds, err = s.groupMemberRepo.GroupIDsForSchools(schoolIds)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
 	return regionIds, zoneIds, groupIds, schoolIds, nil
 }
 
-// CanAccessNews checks if user can access a news item
-func (s *NewsService) CanAccessNews(newsItem models.New, regionIds, zoneIds, groupIds, schoolIds []int64, userRole string) bool {
+// newsTargetingGroups returns the news items targeted at any of the given school groups
+func (s *NewsService) newsTargetingGroups(groupIds []int64) (map[uint]bool, error) {
+	newsIds, err := s.groupMemberRepo.TargetIDsForGroups(repositories.GroupTargetNews, groupIds)
+	if err != nil {
+		return nil, err
+	}
+	targeted := make(map[uint]bool, len(newsIds))
+	for _, id := range newsIds {
+		targeted[uint(id)] = true
+	}
+	return targeted, nil
+}
+
+// CanAccessNews checks if user can access a news item; groupNewsIds holds the news targeted at the
+// user's school groups
+func (s *NewsService) CanAccessNews(newsItem models.New, regionIds, zoneIds []int64, groupNewsIds map[uint]bool, schoolIds []int64, userRole string) bool {
 	// System and National admins can see all news
 	if userRole == "system_admin" || userRole == "national_admin" {
 		return true
@@ -145,7 +167,8 @@ func (s *NewsService) CanAccessNews(newsItem models.New, regionIds, zoneIds, gro
 		}
 	}
 
-	return false
+	// Check school groups
+	return groupNewsIds[newsItem.ID]
 }
 
 // ValidateTargeting validates news targeting based on user role
@@ -278,6 +301,11 @@ func (s *NewsService) ListNews(userId uint, userRole string, filters map[string]
 		return nil, 0, err
 	}
 
+	groupNewsIds, err := s.newsTargetingGroups(groupIds)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Get all news matching filters (without pagination)
 	allNews, err := s.newsRepo.ListAll(filters)
 	if err != nil {
@@ -288,7 +316,7 @@ func (s *NewsService) ListNews(userId uint, userRole string, filters map[string]
 	// Initialize as empty slice (not nil) to ensure JSON marshals as [] not null
 	accessibleNews := make([]models.New, 0)
 	for _, item := range allNews {
-		if s.CanAccessNews(item, regionIds, zoneIds, groupIds, schoolIds, userRole) {
+		if s.CanAccessNews(item, regionIds, zoneIds, groupNewsIds, schoolIds, userRole) {
 			accessibleNews = append(accessibleNews, item)
 		}
 	}
@@ -322,7 +350,12 @@ func (s *NewsService) GetNewsByID(id uint, userId uint, userRole string) (*model
 		return nil, err
 	}
 
-	if !s.CanAccessNews(*newsItem, regionIds, zoneIds, groupIds, schoolIds, userRole) {
+	groupNewsIds, err := s.newsTargetingGroups(groupIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.CanAccessNews(*newsItem, regionIds, zoneIds, groupNewsIds, schoolIds, userRole) {
 		return nil, errors.New("you do not have permission to view this news")
 	}
 
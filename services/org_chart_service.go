@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OrgChartHolder is an executive currently holding a position
+type OrgChartHolder struct {
+	ExecutiveId int64      `json:"executive_id,omitempty"`
+	TermId      uint       `json:"term_id,omitempty"`
+	Name        string     `json:"name"`
+	ImageUrl    *string    `json:"image_url"`
+	StartDate   *time.Time `json:"start_date,omitempty"`
+	EndDate     *time.Time `json:"end_date,omitempty"`
+}
+
+// OrgChartSeat is a position in the national, a region's or a zone's leadership and who holds it
+type OrgChartSeat struct {
+	PositionId *int64           `json:"position_id,omitempty"`
+	Position   string           `json:"position"`
+	Vacant     bool             `json:"vacant,omitempty"`
+	Holders    []OrgChartHolder `json:"holders"`
+}
+
+// OrgChartNode is the national leadership, a region's or a zone's, with the regions or zones below it
+type OrgChartNode struct {
+	Level    string         `json:"level"`
+	Id       int64          `json:"id,omitempty"`
+	Name     string         `json:"name"`
+	Seats    []OrgChartSeat `json:"seats"`
+	Children []OrgChartNode `json:"children,omitempty"`
+}
+
+type OrgChartService struct {
+	termRepo     *repositories.ExecutiveTermRepository
+	positionRepo *repositories.PositionRepository
+	regionRepo   *repositories.RegionRepository
+	zoneRepo     *repositories.ZoneRepository
+}
+
+func NewOrgChartService(termRepo *repositories.ExecutiveTermRepository, positionRepo *repositories.PositionRepository, regionRepo *repositories.RegionRepository, zoneRepo *repositories.ZoneRepository) *OrgChartService {
+	return &OrgChartService{
+		termRepo:     termRepo,
+		positionRepo: positionRepo,
+		regionRepo:   regionRepo,
+		zoneRepo:     zoneRepo,
+	}
+}
+
+// GetOrgChart returns the leadership structure from the national executives down through the
+// regions to the zones, or a region's part of it. A position is shown as vacant at every node of a
+// level the position applies to when nobody holds it there now.
+func (s *OrgChartService) GetOrgChart(regionID *int64, ownerCtx *utils.OwnerContext) (*OrgChartNode, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	chart, err := s.buildOrgChart(false)
+	if err != nil {
+		return nil, err
+	}
+	if regionID == nil {
+		return chart, nil
+	}
+	for i := range chart.Children {
+		if chart.Children[i].Id == *regionID {
+			return &chart.Children[i], nil
+		}
+	}
+	return nil, errors.New("region not found")
+}
+
+// GetPublicOrgChart returns the leadership structure for the website: only the names, positions and
+// photos of the current executives, without vacant positions
+func (s *OrgChartService) GetPublicOrgChart() (*OrgChartNode, error) {
+	return s.buildOrgChart(true)
+}
+
+func (s *OrgChartService) buildOrgChart(public bool) (*OrgChartNode, error) {
+	positions, _, err := s.positionRepo.List(map[string]interface{}{}, 1, 1000)
+	if err != nil {
+		return nil, err
+	}
+	regions, _, err := s.regionRepo.List(map[string]interface{}{}, 1, 1000)
+	if err != nil {
+		return nil, err
+	}
+	zones, _, err := s.zoneRepo.List(map[string]interface{}{}, 1, 10000)
+	if err != nil {
+		return nil, err
+	}
+	terms, err := s.termRepo.ListCurrent()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(positions, func(i, j int) bool { return positions[i].ID < positions[j].ID })
+	sort.Slice(regions, func(i, j int) bool { return stringValue(regions[i].Name) < stringValue(regions[j].Name) })
+	sort.Slice(zones, func(i, j int) bool { return stringValue(zones[i].Name) < stringValue(zones[j].Name) })
+
+	// Current terms by the node they are held at
+	termsAt := map[string][]models.ExecutiveTerm{}
+	for _, term := range terms {
+		key := orgChartKey(term.Level, term.RegionId, term.ZoneId)
+		termsAt[key] = append(termsAt[key], term)
+	}
+
+	chartBuilder := orgChartBuilder{
+		positions: positions,
+		termsAt:   termsAt,
+		public:    public,
+	}
+
+	national := chartBuilder.node(repositories.TermLevelNational, 0, "National")
+	zonesByRegion := map[int64][]models.Zone{}
+	for _, zone := range zones {
+		if zone.RegionId != nil {
+			zonesByRegion[*zone.RegionId] = append(zonesByRegion[*zone.RegionId], zone)
+		}
+	}
+	for _, region := range regions {
+		regionNode := chartBuilder.node(repositories.TermLevelRegion, int64(region.ID), stringValue(region.Name))
+		for _, zone := range zonesByRegion[int64(region.ID)] {
+			regionNode.Children = append(regionNode.Children, chartBuilder.node(repositories.TermLevelZone, int64(zone.ID), stringValue(zone.Name)))
+		}
+		national.Children = append(national.Children, regionNode)
+	}
+	return &national, nil
+}
+
+// orgChartBuilder lays out the seats of each node of the chart
+type orgChartBuilder struct {
+	positions []models.Position
+	termsAt   map[string][]models.ExecutiveTerm
+	public    bool
+}
+
+func (b *orgChartBuilder) node(level string, id int64, name string) OrgChartNode {
+	node := OrgChartNode{Level: level, Name: name, Seats: []OrgChartSeat{}}
+	if !b.public {
+		node.Id = id
+	}
+
+	key := orgChartKey(level, &id, &id)
+	holders := map[int64][]OrgChartHolder{}
+	var unassigned []OrgChartHolder
+	for _, term := range b.termsAt[key] {
+		if term.PositionId == nil {
+			unassigned = append(unassigned, b.holder(term))
+		} else {
+			holders[*term.PositionId] = append(holders[*term.PositionId], b.holder(term))
+		}
+	}
+
+	for _, position := range b.positions {
+		positionID := int64(position.ID)
+		seat := OrgChartSeat{Position: stringValue(position.Name), Holders: holders[positionID]}
+		if !b.public {
+			seat.PositionId = &positionID
+		}
+		if len(seat.Holders) == 0 {
+			if b.public || !position.AppliesAt(level) {
+				continue
+			}
+			seat.Vacant = true
+			seat.Holders = []OrgChartHolder{}
+		}
+		node.Seats = append(node.Seats, seat)
+	}
+	if len(unassigned) > 0 {
+		node.Seats = append(node.Seats, OrgChartSeat{Position: "No position", Holders: unassigned})
+	}
+	return node
+}
+
+func (b *orgChartBuilder) holder(term models.ExecutiveTerm) OrgChartHolder {
+	holder := OrgChartHolder{Name: strings.TrimSpace(term.ExecutiveName), ImageUrl: term.ImageUrl}
+	if !b.public {
+		holder.ExecutiveId = term.ExecutiveId
+		holder.TermId = term.ID
+		startDate := term.StartDate
+		holder.StartDate = &startDate
+		holder.EndDate = term.EndDate
+	}
+	return holder
+}
+
+// orgChartKey identifies the national leadership, a region's or a zone's
+func orgChartKey(level string, regionID, zoneID *int64) string {
+	switch level {
+	case repositories.TermLevelRegion:
+		if regionID != nil {
+			return level + ":" + strconv.FormatInt(*regionID, 10)
+		}
+	case repositories.TermLevelZone:
+		if zoneID != nil {
+			return level + ":" + strconv.FormatInt(*zoneID, 10)
+		}
+	}
+	return level
+}
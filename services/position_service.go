@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"gnaps-api/models"
 	"gnaps-api/repositories"
+	"strings"
 )
 
 type PositionService struct {
@@ -42,6 +43,12 @@ func (s *PositionService) CreatePosition(position *models.Position) error {
 		return errors.New("position with this name already exists")
 	}
 
+	levels, err := normalizePositionLevels(position.Levels)
+	if err != nil {
+		return err
+	}
+	position.Levels = levels
+
 	// Set defaults
 	falseVal := false
 	position.IsDeleted = &falseVal
@@ -70,6 +77,14 @@ func (s *PositionService) UpdatePosition(id uint, updates map[string]interface{}
 		}
 	}
 
+	if levels, ok := updates["levels"].(string); ok {
+		normalized, err := normalizePositionLevels(&levels)
+		if err != nil {
+			return err
+		}
+		updates["levels"] = normalized
+	}
+
 	return s.positionRepo.Update(id, updates)
 }
 
@@ -91,3 +106,36 @@ func (s *PositionService) DeletePosition(id uint) error {
 
 	return s.positionRepo.Delete(id)
 }
+
+// normalizePositionLevels checks a comma-separated list of levels a position is held at and
+// returns it in national, region, zone order, or nil when no level is given
+func normalizePositionLevels(levels *string) (*string, error) {
+	if levels == nil {
+		return nil, nil
+	}
+	given := map[string]bool{}
+	for _, level := range strings.Split(*levels, ",") {
+		level = strings.ToLower(strings.TrimSpace(level))
+		if level == "" {
+			continue
+		}
+		switch level {
+		case repositories.TermLevelNational, repositories.TermLevelRegion, repositories.TermLevelZone:
+			given[level] = true
+		default:
+			return nil, fmt.Errorf("invalid level %q, expected national, region or zone", level)
+		}
+	}
+
+	var ordered []string
+	for _, level := range []string{repositories.TermLevelNational, repositories.TermLevelRegion, repositories.TermLevelZone} {
+		if given[level] {
+			ordered = append(ordered, level)
+		}
+	}
+	if len(ordered) == 0 {
+		return nil, nil
+	}
+	normalized := strings.Join(ordered, ",")
+	return &normalized, nil
+}
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RemittanceStatusPending   = "pending"
+	RemittanceStatusConfirmed = "confirmed"
+	RemittanceStatusRejected  = "rejected"
+)
+
+// RemittanceService splits school bill payments between the levels that are owed a share of
+// them and tracks remittances of those shares between national, region and zone
+type RemittanceService struct {
+	remittanceRepo *repositories.RemittanceRepository
+	reportsService *FinanceReportsService
+}
+
+func NewRemittanceService(remittanceRepo *repositories.RemittanceRepository, reportsService *FinanceReportsService) *RemittanceService {
+	return &RemittanceService{
+		remittanceRepo: remittanceRepo,
+		reportsService: reportsService,
+	}
+}
+
+// WithTx returns a copy of the service that allocates inside tx
+func (s *RemittanceService) WithTx(tx *gorm.DB) *RemittanceService {
+	return &RemittanceService{
+		remittanceRepo: s.remittanceRepo.WithTx(tx),
+		reportsService: s.reportsService,
+	}
+}
+
+// ============================================
+// Payment allocation
+// ============================================
+
+// AllocateTransaction splits a school bill payment across the bill's particulars in priority
+// order, or takes a refund back from them in reverse order. Each share is receivable by the
+// level named in the particular's reciever_type; anything that cannot be placed stays with the
+// collecting owner. Transactions that are already allocated are skipped
+func (s *RemittanceService) AllocateTransaction(transaction *models.FinanceTransaction) error {
+	if transaction == nil || transaction.Amount == nil || transaction.FinanceId == nil || transaction.FinanceType == nil {
+		return nil
+	}
+
+	refund := false
+	switch *transaction.FinanceType {
+	case "SchoolBill":
+	case "Refund":
+		refund = true
+	default:
+		return nil
+	}
+
+	if s.remittanceRepo.HasAllocations(transaction.ID) {
+		return nil
+	}
+
+	schoolBillID := uint(*transaction.FinanceId)
+	particulars, err := s.remittanceRepo.GetAllocatableParticulars(schoolBillID)
+	if err != nil {
+		return err
+	}
+
+	collectorType, collectorID := ledgerOwner(transaction.OwnerType, transaction.OwnerId)
+	schoolZoneID := int64(0)
+	if transaction.SchoolId != nil {
+		schoolZoneID = s.remittanceRepo.GetSchoolZoneID(*transaction.SchoolId)
+	}
+
+	newAllocation := func(particular *models.SchoolBillingParticular, amount float64, receiverType string, receiverID int64) models.PaymentAllocation {
+		allocation := models.PaymentAllocation{
+			FinanceTransactionId: transaction.ID,
+			SchoolBillId:         schoolBillID,
+			SchoolId:             transaction.SchoolId,
+			Amount:               roundAmount(amount),
+			AllocationDate:       transaction.TransactionDate,
+			ReceiverOwnerType:    receiverType,
+			ReceiverOwnerId:      receiverID,
+		}
+		if particular != nil {
+			particularID := particular.ID
+			allocation.SchoolBillingParticularId = &particularID
+			allocation.ParticularName = particular.ParticularName
+		}
+		allocation.SetOwner(collectorType, collectorID)
+		return allocation
+	}
+
+	var allocations []models.PaymentAllocation
+	remaining := roundAmount(*transaction.Amount)
+
+	if refund {
+		for i := len(particulars) - 1; i >= 0 && remaining > 0; i-- {
+			particular := &particulars[i]
+			share := minAmount(remaining, floatValue(particular.AmountPaid))
+			if share <= 0 {
+				continue
+			}
+			receiverType, receiverID := s.particularReceiver(particular, schoolZoneID, collectorType, collectorID)
+			allocations = append(allocations, newAllocation(particular, -share, receiverType, receiverID))
+			remaining = roundAmount(remaining - share)
+		}
+		if remaining > 0 {
+			allocations = append(allocations, newAllocation(nil, -remaining, collectorType, collectorID))
+		}
+	} else {
+		for i := range particulars {
+			if remaining <= 0 {
+				break
+			}
+			particular := &particulars[i]
+			outstanding := roundAmount(floatValue(particular.Amount) - floatValue(particular.DiscountAmount) - floatValue(particular.AmountPaid))
+			share := minAmount(remaining, outstanding)
+			if share <= 0 {
+				continue
+			}
+			receiverType, receiverID := s.particularReceiver(particular, schoolZoneID, collectorType, collectorID)
+			allocations = append(allocations, newAllocation(particular, share, receiverType, receiverID))
+			remaining = roundAmount(remaining - share)
+		}
+		if remaining > 0 {
+			allocations = append(allocations, newAllocation(nil, remaining, collectorType, collectorID))
+		}
+	}
+
+	return s.remittanceRepo.SaveAllocations(allocations)
+}
+
+// AllocatePendingTransactions allocates school bill payments and refunds recorded before
+// allocation was introduced, oldest first. Returns the number of transactions allocated
+func (s *RemittanceService) AllocatePendingTransactions(limit int, ownerCtx *utils.OwnerContext) (int, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return 0, err
+	}
+	if ownerCtx == nil || !ownerCtx.CanViewAllHierarchyData() {
+		return 0, errors.New("access denied")
+	}
+	if limit <= 0 || limit > 5000 {
+		limit = 500
+	}
+
+	transactions, err := s.remittanceRepo.FindUnallocatedTransactions(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	allocated := 0
+	for i := range transactions {
+		if err := s.AllocateTransaction(&transactions[i]); err != nil {
+			return allocated, err
+		}
+		allocated++
+	}
+	return allocated, nil
+}
+
+// ListAllocations lists payment allocations collected or receivable within a scope
+func (s *RemittanceService) ListAllocations(filters map[string]interface{}, scope *ReportScope, page, limit int) ([]models.PaymentAllocation, int64, error) {
+	return s.remittanceRepo.ListAllocations(filters, scope.owners, page, limit)
+}
+
+// particularReceiver resolves the owner a particular's share belongs to. The level comes from
+// the particular's reciever_type, falling back to the owner type of the bill particular it was
+// generated from; the zone is the particular's own or the paying school's
+func (s *RemittanceService) particularReceiver(particular *models.SchoolBillingParticular, schoolZoneID int64, collectorType string, collectorID int64) (string, int64) {
+	level := receiverLevel(stringValue(particular.RecieverType))
+	if level == "" && particular.BillParticularId != nil {
+		level = receiverLevel(s.remittanceRepo.GetBillParticularOwnerType(*particular.BillParticularId))
+	}
+
+	zoneID := schoolZoneID
+	if particular.ZoneId != nil && *particular.ZoneId > 0 {
+		zoneID = *particular.ZoneId
+	}
+
+	switch level {
+	case utils.OwnerTypeNational:
+		return utils.OwnerTypeNational, utils.DefaultNationalOwnerID
+	case utils.OwnerTypeRegion:
+		if zoneID > 0 {
+			if regionID := s.remittanceRepo.GetZoneRegionID(zoneID); regionID > 0 {
+				return utils.OwnerTypeRegion, regionID
+			}
+		}
+	case utils.OwnerTypeZone:
+		if zoneID > 0 {
+			return utils.OwnerTypeZone, zoneID
+		}
+	}
+	return collectorType, collectorID
+}
+
+// receiverLevel normalises reciever_type values such as "National", "Regional" or "Zonal"
+func receiverLevel(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case strings.HasPrefix(value, "nat"):
+		return utils.OwnerTypeNational
+	case strings.HasPrefix(value, "reg"):
+		return utils.OwnerTypeRegion
+	case strings.HasPrefix(value, "zon"):
+		return utils.OwnerTypeZone
+	}
+	return ""
+}
+
+func minAmount(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ============================================
+// Inter-level balances
+// ============================================
+
+// InterLevelBalance is what one owner has collected on behalf of another and not yet remitted
+type InterLevelBalance struct {
+	FromOwnerType       string  `json:"from_owner_type"`
+	FromOwnerId         int64   `json:"from_owner_id"`
+	FromName            string  `json:"from_name"`
+	ToOwnerType         string  `json:"to_owner_type"`
+	ToOwnerId           int64   `json:"to_owner_id"`
+	ToName              string  `json:"to_name"`
+	AmountDue           float64 `json:"amount_due"`
+	AmountRemitted      float64 `json:"amount_remitted"`
+	PendingConfirmation float64 `json:"pending_confirmation"`
+	Outstanding         float64 `json:"outstanding"`
+}
+
+// InterLevelBalanceReport lists the inter-level balances involving owners in a scope
+type InterLevelBalanceReport struct {
+	Scope            *ReportScope        `json:"scope"`
+	FromDate         string              `json:"from_date,omitempty"`
+	ToDate           string              `json:"to_date,omitempty"`
+	Balances         []InterLevelBalance `json:"balances"`
+	TotalPayable     float64             `json:"total_payable"`
+	TotalReceivable  float64             `json:"total_receivable"`
+	TotalOutstanding float64             `json:"total_outstanding"`
+}
+
+type interLevelPair struct {
+	fromType string
+	fromID   int64
+	toType   string
+	toID     int64
+}
+
+// GetBalances reports, for every pair of owners where either side is in the scope, the shares
+// collected on the other's behalf, what has been remitted (confirmed or awaiting confirmation)
+// and what is still outstanding. Payable is outstanding owed by owners in the scope to owners
+// outside it; receivable is outstanding owed to the scope from outside
+func (s *RemittanceService) GetBalances(scope *ReportScope, fromDate, toDate string) (*InterLevelBalanceReport, error) {
+	from, to := parseDateRange(fromDate, toDate)
+
+	due, err := s.remittanceRepo.SumAmountsDue(from, to)
+	if err != nil {
+		return nil, err
+	}
+	remitted, err := s.remittanceRepo.SumRemittances(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[interLevelPair]*InterLevelBalance)
+	balanceFor := func(row repositories.InterLevelTotal) *InterLevelBalance {
+		key := interLevelPair{row.FromOwnerType, row.FromOwnerId, row.ToOwnerType, row.ToOwnerId}
+		balance, ok := balances[key]
+		if !ok {
+			balance = &InterLevelBalance{
+				FromOwnerType: row.FromOwnerType,
+				FromOwnerId:   row.FromOwnerId,
+				ToOwnerType:   row.ToOwnerType,
+				ToOwnerId:     row.ToOwnerId,
+			}
+			balances[key] = balance
+		}
+		return balance
+	}
+
+	for _, row := range due {
+		if !scope.owners.Covers(row.FromOwnerType, row.FromOwnerId) && !scope.owners.Covers(row.ToOwnerType, row.ToOwnerId) {
+			continue
+		}
+		balanceFor(row).AmountDue += row.Total
+	}
+	for _, row := range remitted {
+		if !scope.owners.Covers(row.FromOwnerType, row.FromOwnerId) && !scope.owners.Covers(row.ToOwnerType, row.ToOwnerId) {
+			continue
+		}
+		balance := balanceFor(row)
+		if row.Status == RemittanceStatusConfirmed {
+			balance.AmountRemitted += row.Total
+		} else {
+			balance.PendingConfirmation += row.Total
+		}
+	}
+
+	report := &InterLevelBalanceReport{
+		Scope:    scope,
+		FromDate: fromDate,
+		ToDate:   toDate,
+		Balances: make([]InterLevelBalance, 0, len(balances)),
+	}
+
+	names := make(map[string]string)
+	nameFor := func(ownerType string, ownerID int64) string {
+		key := ownerType + ":" + strconv.FormatInt(ownerID, 10)
+		if name, ok := names[key]; ok {
+			return name
+		}
+		name := s.reportsService.ownerName(ownerType, ownerID)
+		names[key] = name
+		return name
+	}
+
+	for _, balance := range balances {
+		balance.AmountDue = roundAmount(balance.AmountDue)
+		balance.AmountRemitted = roundAmount(balance.AmountRemitted)
+		balance.PendingConfirmation = roundAmount(balance.PendingConfirmation)
+		balance.Outstanding = roundAmount(balance.AmountDue - balance.AmountRemitted - balance.PendingConfirmation)
+		balance.FromName = nameFor(balance.FromOwnerType, balance.FromOwnerId)
+		balance.ToName = nameFor(balance.ToOwnerType, balance.ToOwnerId)
+
+		fromInScope := scope.owners.Covers(balance.FromOwnerType, balance.FromOwnerId)
+		toInScope := scope.owners.Covers(balance.ToOwnerType, balance.ToOwnerId)
+		switch {
+		case fromInScope && !toInScope:
+			report.TotalPayable += balance.Outstanding
+		case toInScope && !fromInScope:
+			report.TotalReceivable += balance.Outstanding
+		}
+		report.TotalOutstanding += balance.Outstanding
+
+		report.Balances = append(report.Balances, *balance)
+	}
+
+	sort.Slice(report.Balances, func(i, j int) bool {
+		a, b := report.Balances[i], report.Balances[j]
+		if a.FromName != b.FromName {
+			return a.FromName < b.FromName
+		}
+		return a.ToName < b.ToName
+	})
+
+	report.TotalPayable = roundAmount(report.TotalPayable)
+	report.TotalReceivable = roundAmount(report.TotalReceivable)
+	report.TotalOutstanding = roundAmount(report.TotalOutstanding)
+	return report, nil
+}
+
+// ============================================
+// Remittances
+// ============================================
+
+// GetRemittance retrieves a remittance paid or received within a scope
+func (s *RemittanceService) GetRemittance(id uint, scope *ReportScope) (*models.Remittance, error) {
+	remittance, err := s.remittanceRepo.FindByIDForScope(id, scope.owners)
+	if err != nil {
+		return nil, errors.New("remittance not found")
+	}
+	return remittance, nil
+}
+
+// ListRemittances lists remittances paid or received within a scope
+func (s *RemittanceService) ListRemittances(filters map[string]interface{}, scope *ReportScope, page, limit int) ([]models.Remittance, int64, error) {
+	return s.remittanceRepo.ListForScope(filters, scope.owners, page, limit)
+}
+
+// CreateRemittance records a remittance from the caller's owner to another level. It stays
+// pending until the receiving owner confirms it
+func (s *RemittanceService) CreateRemittance(remittance *models.Remittance, userID *int64, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+	if ownerCtx == nil || !ownerCtx.IsValid() {
+		return errors.New("access denied")
+	}
+
+	if remittance.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+
+	switch remittance.ToOwnerType {
+	case utils.OwnerTypeNational:
+		remittance.ToOwnerId = utils.DefaultNationalOwnerID
+	case utils.OwnerTypeRegion, utils.OwnerTypeZone:
+		if remittance.ToOwnerId <= 0 || !s.remittanceRepo.OwnerExists(remittance.ToOwnerType, remittance.ToOwnerId) {
+			return errors.New("receiving owner not found")
+		}
+	default:
+		return errors.New("to_owner_type must be national, region or zone")
+	}
+
+	ownerType, ownerID := ownerCtx.GetOwnerValues()
+	if remittance.ToOwnerType == ownerType && remittance.ToOwnerId == ownerID {
+		return errors.New("cannot remit to your own level")
+	}
+
+	if remittance.RemittanceDate.IsZero() {
+		remittance.RemittanceDate = time.Now()
+	}
+
+	remittance.ID = 0
+	remittance.Amount = roundAmount(remittance.Amount)
+	remittance.Status = RemittanceStatusPending
+	remittance.RecordedBy = userID
+	remittance.ConfirmedBy = nil
+	remittance.ConfirmedAt = nil
+	remittance.RejectedReason = nil
+	remittance.IsDeleted = false
+
+	// The reference number is issued in the transaction that saves the remittance
+	return s.remittanceRepo.Transaction(func(tx *gorm.DB) error {
+		referenceNo, err := s.remittanceRepo.WithTx(tx).GenerateReferenceNo()
+		if err != nil {
+			return err
+		}
+		remittance.ReferenceNo = referenceNo
+		return s.remittanceRepo.WithTx(tx).CreateWithOwner(remittance, ownerCtx)
+	})
+}
+
+// ConfirmRemittance marks a pending remittance as received. Only the receiving owner can confirm
+func (s *RemittanceService) ConfirmRemittance(id uint, userID *int64, ownerCtx *utils.OwnerContext) error {
+	remittance, err := s.receivedRemittance(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.remittanceRepo.Update(remittance.ID, map[string]interface{}{
+		"status":       RemittanceStatusConfirmed,
+		"confirmed_by": userID,
+		"confirmed_at": &now,
+	})
+}
+
+// RejectRemittance records that a pending remittance was not received, so it no longer
+// reduces the payer's outstanding balance. Only the receiving owner can reject
+func (s *RemittanceService) RejectRemittance(id uint, reason string, userID *int64, ownerCtx *utils.OwnerContext) error {
+	if strings.TrimSpace(reason) == "" {
+		return errors.New("rejection reason is required")
+	}
+
+	remittance, err := s.receivedRemittance(id, ownerCtx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return s.remittanceRepo.Update(remittance.ID, map[string]interface{}{
+		"status":          RemittanceStatusRejected,
+		"rejected_reason": reason,
+		"confirmed_by":    userID,
+		"confirmed_at":    &now,
+	})
+}
+
+// DeleteRemittance removes a pending remittance recorded by the caller's owner
+func (s *RemittanceService) DeleteRemittance(id uint, ownerCtx *utils.OwnerContext) error {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return err
+	}
+
+	remittance, err := s.remittanceRepo.FindByIDForScope(id, nil)
+	if err != nil {
+		return errors.New("remittance not found")
+	}
+
+	ownerType, ownerID := repositories.GetOwnerValues(ownerCtx)
+	if remittance.OwnerType == nil || remittance.OwnerId == nil || *remittance.OwnerType != ownerType || *remittance.OwnerId != ownerID {
+		return errors.New("access denied")
+	}
+	if remittance.Status != RemittanceStatusPending {
+		return errors.New("only pending remittances can be deleted")
+	}
+
+	return s.remittanceRepo.Update(remittance.ID, map[string]interface{}{"is_deleted": true})
+}
+
+// receivedRemittance loads a pending remittance addressed to the caller's owner
+func (s *RemittanceService) receivedRemittance(id uint, ownerCtx *utils.OwnerContext) (*models.Remittance, error) {
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return nil, err
+	}
+
+	remittance, err := s.remittanceRepo.FindByIDForScope(id, nil)
+	if err != nil {
+		return nil, errors.New("remittance not found")
+	}
+
+	ownerType, ownerID := repositories.GetOwnerValues(ownerCtx)
+	if remittance.ToOwnerType != ownerType || remittance.ToOwnerId != ownerID {
+		return nil, errors.New("access denied")
+	}
+	if remittance.Status != RemittanceStatusPending {
+		return nil, errors.New("remittance is not pending")
+	}
+	return remittance, nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"gnaps-api/internal/testdb"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"testing"
+)
+
+var allocationTestTables = []interface{}{&models.Zone{}, &models.School{}, &models.BillParticular{}, &models.SchoolBillingParticular{}, &models.PaymentAllocation{}}
+
+// allocationTestRows is school bill 100 of school 5 in zone 10, region 1, with the given amounts
+// already paid. Particulars are allocated national dues, then the regional levy, then zonal dues,
+// whose level comes from the bill particular it was generated from. The deleted particular is
+// never allocated.
+func allocationTestRows(paid map[string]float64) []interface{} {
+	particular := func(name string, priority int, amount, discount float64, receiverType string) *models.SchoolBillingParticular {
+		return &models.SchoolBillingParticular{
+			SchoolBillingId: testdb.Ptr(int64(100)),
+			ParticularName:  testdb.Ptr(name),
+			Priority:        testdb.Ptr(priority),
+			Amount:          testdb.Ptr(amount),
+			DiscountAmount:  testdb.Ptr(discount),
+			AmountPaid:      testdb.Ptr(paid[name]),
+			RecieverType:    testdb.Ptr(receiverType),
+		}
+	}
+	deleted := particular("Deleted", 0, 500, 0, "National")
+	deleted.IsDeleted = testdb.Ptr(true)
+	zonal := particular("Zonal dues", 3, 30, 0, "")
+	zonal.BillParticularId = testdb.Ptr(int64(7))
+
+	return []interface{}{
+		&models.Zone{ID: 10, RegionId: testdb.Ptr(int64(1))},
+		&models.School{ID: 5, Name: "Test School", ZoneId: testdb.Ptr(int64(10))},
+		&models.BillParticular{ID: 7, OwnerType: testdb.Ptr(utils.OwnerTypeZone)},
+		deleted,
+		particular("National dues", 1, 100, 0, "National"),
+		particular("Regional levy", 2, 50, 10, "Regional"),
+		zonal,
+	}
+}
+
+func TestAllocateTransaction(t *testing.T) {
+	type share struct {
+		particular   string
+		amount       float64
+		receiverType string
+		receiverID   int64
+	}
+	national, region, zone := utils.OwnerTypeNational, utils.OwnerTypeRegion, utils.OwnerTypeZone
+	fullyPaid := map[string]float64{"National dues": 100, "Regional levy": 40, "Zonal dues": 30}
+	unpaid := map[string]float64{"National dues": 0, "Regional levy": 0, "Zonal dues": 0}
+
+	tests := []struct {
+		name        string
+		financeType string
+		amount      float64
+		paid        map[string]float64
+		seed        []interface{}
+		want        []share
+		wantPaid    map[string]float64
+	}{
+		{
+			name:        "part payment goes to the first particular",
+			financeType: "SchoolBill",
+			amount:      60,
+			want:        []share{{"National dues", 60, national, 1}},
+			wantPaid:    map[string]float64{"National dues": 60, "Regional levy": 0, "Zonal dues": 0},
+		},
+		{
+			name:        "payment fills particulars in priority order net of discounts",
+			financeType: "SchoolBill",
+			amount:      150,
+			want: []share{
+				{"National dues", 100, national, 1},
+				{"Regional levy", 40, region, 1},
+				{"Zonal dues", 10, zone, 10},
+			},
+			wantPaid: map[string]float64{"National dues": 100, "Regional levy": 40, "Zonal dues": 10},
+		},
+		{
+			name:        "payment skips particulars already paid",
+			financeType: "SchoolBill",
+			amount:      50,
+			paid:        map[string]float64{"National dues": 100},
+			want: []share{
+				{"Regional levy", 40, region, 1},
+				{"Zonal dues", 10, zone, 10},
+			},
+			wantPaid: map[string]float64{"National dues": 100, "Regional levy": 40, "Zonal dues": 10},
+		},
+		{
+			name:        "overpayment stays with the collector",
+			financeType: "SchoolBill",
+			amount:      200,
+			want: []share{
+				{"National dues", 100, national, 1},
+				{"Regional levy", 40, region, 1},
+				{"Zonal dues", 30, zone, 10},
+				{"", 30, zone, 10},
+			},
+			wantPaid: fullyPaid,
+		},
+		{
+			name:        "refund comes back from the last particulars first",
+			financeType: "Refund",
+			amount:      50,
+			paid:        fullyPaid,
+			want: []share{
+				{"Zonal dues", -30, zone, 10},
+				{"Regional levy", -20, region, 1},
+			},
+			wantPaid: map[string]float64{"National dues": 100, "Regional levy": 20, "Zonal dues": 0},
+		},
+		{
+			name:        "refund beyond what was paid comes from the collector",
+			financeType: "Refund",
+			amount:      200,
+			paid:        fullyPaid,
+			want: []share{
+				{"Zonal dues", -30, zone, 10},
+				{"Regional levy", -40, region, 1},
+				{"National dues", -100, national, 1},
+				{"", -30, zone, 10},
+			},
+			wantPaid: unpaid,
+		},
+		{
+			name:        "other finance types are not allocated",
+			financeType: "Event",
+			amount:      100,
+			wantPaid:    unpaid,
+		},
+		{
+			name:        "allocated transactions are skipped",
+			financeType: "SchoolBill",
+			amount:      100,
+			seed: []interface{}{
+				&models.PaymentAllocation{FinanceTransactionId: 500, SchoolBillId: 100, Amount: 1, ReceiverOwnerType: zone, ReceiverOwnerId: 10, OwnerType: &zone, OwnerId: testdb.Ptr(int64(10))},
+			},
+			want:     []share{{"", 1, zone, 10}},
+			wantPaid: unpaid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.Open(t, allocationTestTables...)
+			testdb.Seed(t, db, allocationTestRows(tt.paid)...)
+			testdb.Seed(t, db, tt.seed...)
+			s := NewRemittanceService(repositories.NewRemittanceRepository(db), nil)
+
+			transaction := &models.FinanceTransaction{
+				ID:          500,
+				Amount:      testdb.Ptr(tt.amount),
+				FinanceType: testdb.Ptr(tt.financeType),
+				FinanceId:   testdb.Ptr(int64(100)),
+				SchoolId:    testdb.Ptr(int64(5)),
+				OwnerType:   &zone,
+				OwnerId:     testdb.Ptr(int64(10)),
+			}
+			if err := s.AllocateTransaction(transaction); err != nil {
+				t.Fatalf("AllocateTransaction() error = %v", err)
+			}
+
+			var allocations []models.PaymentAllocation
+			if err := db.Order("id ASC").Find(&allocations).Error; err != nil {
+				t.Fatalf("load allocations: %v", err)
+			}
+			if len(allocations) != len(tt.want) {
+				t.Fatalf("got %d allocations, want %d", len(allocations), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := allocations[i]
+				if gotShare := (share{stringValue(got.ParticularName), got.Amount, got.ReceiverOwnerType, got.ReceiverOwnerId}); gotShare != want {
+					t.Errorf("allocation %d = %+v, want %+v", i+1, gotShare, want)
+				}
+				if stringValue(got.OwnerType) != zone || got.OwnerId == nil || *got.OwnerId != 10 {
+					t.Errorf("allocation %d is owned by %s/%v, want the collecting zone", i+1, stringValue(got.OwnerType), got.OwnerId)
+				}
+			}
+
+			var particulars []models.SchoolBillingParticular
+			if err := db.Find(&particulars).Error; err != nil {
+				t.Fatalf("load particulars: %v", err)
+			}
+			for _, particular := range particulars {
+				name := stringValue(particular.ParticularName)
+				if want, ok := tt.wantPaid[name]; ok && floatValue(particular.AmountPaid) != want {
+					t.Errorf("%s amount_paid = %.2f, want %.2f", name, floatValue(particular.AmountPaid), want)
+				}
+			}
+		})
+	}
+}
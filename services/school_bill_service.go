@@ -2,18 +2,34 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"gnaps-api/models"
 	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"log"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type SchoolBillService struct {
-	schoolBillRepo *repositories.SchoolBillRepository
+	schoolBillRepo      *repositories.SchoolBillRepository
+	ledgerService       *LedgerService
+	remittanceService   *RemittanceService
+	bankAccountService  *BankAccountService
+	fiscalPeriodService *FiscalPeriodService
+	statusService       *MembershipStatusService
 }
 
-func NewSchoolBillService(schoolBillRepo *repositories.SchoolBillRepository) *SchoolBillService {
+func NewSchoolBillService(schoolBillRepo *repositories.SchoolBillRepository, ledgerService *LedgerService, remittanceService *RemittanceService, bankAccountService *BankAccountService, fiscalPeriodService *FiscalPeriodService, statusService *MembershipStatusService) *SchoolBillService {
 	return &SchoolBillService{
-		schoolBillRepo: schoolBillRepo,
+		schoolBillRepo:      schoolBillRepo,
+		ledgerService:       ledgerService,
+		remittanceService:   remittanceService,
+		bankAccountService:  bankAccountService,
+		fiscalPeriodService: fiscalPeriodService,
+		statusService:       statusService,
 	}
 }
 
@@ -48,6 +64,9 @@ type PaymentRequest struct {
 	MomoNumber   string  `json:"momo_number"`
 	MomoNetwork  string  `json:"momo_network"` // MTN, TELECEL, AIRTELTIGO
 	UserID       int64   `json:"user_id"`
+	// BankAccountID is the bank or MoMo account the payment went into; when omitted the
+	// owner's default account for the payment mode is used
+	BankAccountID *int64 `json:"bank_account_id"`
 }
 
 // RecordPayment records a payment against a school bill
@@ -99,22 +118,277 @@ func (s *SchoolBillService) RecordPayment(req PaymentRequest) (*models.FinanceTr
 
 	// Add MoMo info if applicable
 	if req.PaymentMode == "MoMo" && req.MomoNumber != "" {
-		transaction.ModeInfo = &req.MomoNumber
+		// Recorded as "MTN - 0244123456" like gateway payments so collections can be split by network
+		modeInfo := req.MomoNumber
+		if req.MomoNetwork != "" {
+			modeInfo = fmt.Sprintf("%s - %s", strings.ToUpper(req.MomoNetwork), req.MomoNumber)
+		}
+		transaction.ModeInfo = &modeInfo
 	}
 
-	// Create the transaction
-	if err := s.schoolBillRepo.CreateFinanceTransaction(transaction); err != nil {
-		return nil, errors.New("failed to record payment")
+	if ownerInfo := s.schoolBillRepo.GetOwnerForSchoolBill(req.SchoolBillID); ownerInfo != nil {
+		transaction.SetOwner(ownerInfo.OwnerType, ownerInfo.OwnerID)
+	}
+	// Credit the income account of the bill particular being paid
+	if transaction.FinanceAccountId, err = s.ledgerService.IncomeAccountForPayment(financeType, schoolBillID, transaction.OwnerType, transaction.OwnerId); err != nil {
+		return nil, err
+	}
+	// Backdated payments cannot land in a closed fiscal period
+	if err := s.fiscalPeriodService.EnsureOpen(transaction.OwnerType, transaction.OwnerId, paymentDate); err != nil {
+		return nil, err
+	}
+	bankAccountId, err := s.bankAccountService.ResolveAccount(transaction.OwnerType, transaction.OwnerId, req.PaymentMode, req.BankAccountID)
+	if err != nil {
+		return nil, err
+	}
+	transaction.BankAccountId = bankAccountId
+
+	// The receipt, the bill balance, its journal entry and its allocation are saved together
+	err = s.schoolBillRepo.Transaction(func(tx *gorm.DB) error {
+		schoolBills := s.schoolBillRepo.WithTx(tx)
+		if err := schoolBills.CreateFinanceTransaction(transaction); err != nil {
+			return errors.New("failed to record payment")
+		}
+
+		// Update school bill with new payment
+		if err := schoolBills.UpdatePayment(req.SchoolBillID, req.Amount); err != nil {
+			return errors.New("failed to update school bill balance")
+		}
+
+		// Post the balanced journal entry for the receipt
+		if _, err := s.ledgerService.WithTx(tx).PostPayment(transaction); err != nil {
+			return fmt.Errorf("failed to post payment to the ledger: %w", err)
+		}
+
+		// Split the payment between the levels its bill particulars are owed to
+		if err := s.remittanceService.WithTx(tx).AllocateTransaction(transaction); err != nil {
+			return fmt.Errorf("failed to allocate payment: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Update school bill with new payment
-	if err := s.schoolBillRepo.UpdatePayment(req.SchoolBillID, req.Amount); err != nil {
-		return nil, errors.New("failed to update school bill balance")
+	// A lapsed school is reinstated once its overdue dues are paid; otherwise the nightly run catches up
+	if err := s.statusService.ReevaluateDues(uint(req.SchoolID)); err != nil {
+		log.Printf("Failed to re-evaluate membership status of school %d: %v", req.SchoolID, err)
 	}
 
 	return transaction, nil
 }
 
+// DiscountRequest represents a discount granted on a school bill
+type DiscountRequest struct {
+	SchoolBillID uint    `json:"school_bill_id"`
+	Amount       float64 `json:"amount"`
+	Reason       string  `json:"reason"`
+	UserID       int64   `json:"user_id"`
+}
+
+// ApplyDiscountWithOwner reduces what a school owes on a bill in the caller's region or zone and
+// posts the discount to the ledger
+func (s *SchoolBillService) ApplyDiscountWithOwner(req DiscountRequest, ownerCtx *utils.OwnerContext) (*models.SchoolBill, error) {
+	if _, err := s.manageableSchoolBill(req.SchoolBillID, ownerCtx); err != nil {
+		return nil, err
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("discount amount must be greater than 0")
+	}
+	if req.Reason == "" {
+		return nil, errors.New("discount reason is required")
+	}
+
+	balance, _, err := s.schoolBillRepo.GetBalance(req.SchoolBillID)
+	if err != nil {
+		return nil, errors.New("school bill not found")
+	}
+	if req.Amount > balance {
+		return nil, errors.New("discount amount exceeds outstanding balance")
+	}
+
+	schoolBill, err := s.schoolBillRepo.FindByID(req.SchoolBillID)
+	if err != nil {
+		return nil, errors.New("school bill not found")
+	}
+	if err := s.fiscalPeriodService.EnsureOpen(schoolBill.OwnerType, schoolBill.OwnerId, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// The discount and its journal entry are saved together
+	err = s.schoolBillRepo.Transaction(func(tx *gorm.DB) error {
+		schoolBills := s.schoolBillRepo.WithTx(tx)
+		if err := schoolBills.UpdateDiscount(req.SchoolBillID, req.Amount); err != nil {
+			return errors.New("failed to apply discount")
+		}
+
+		var err error
+		if schoolBill, err = schoolBills.FindByID(req.SchoolBillID); err != nil {
+			return errors.New("school bill not found")
+		}
+
+		if _, err := s.ledgerService.WithTx(tx).PostDiscount(schoolBill, req.Amount, req.Reason, &req.UserID); err != nil {
+			return fmt.Errorf("failed to post discount to the ledger: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schoolBill, nil
+}
+
+// RefundRequest represents money returned to a school against a school bill
+type RefundRequest struct {
+	SchoolBillID         uint    `json:"school_bill_id"`
+	FinanceTransactionID uint    `json:"finance_transaction_id"`
+	Amount               float64 `json:"amount"`
+	PaymentMode          string  `json:"payment_mode"`
+	ReferenceNo          string  `json:"reference_no"`
+	Reason               string  `json:"reason"`
+	UserID               int64   `json:"user_id"`
+	BankAccountID        *int64  `json:"bank_account_id"`
+}
+
+// RecordRefundWithOwner records a refund of an earlier payment on a school bill in the caller's
+// region or zone, restores the bill balance and posts to the ledger
+func (s *SchoolBillService) RecordRefundWithOwner(req RefundRequest, ownerCtx *utils.OwnerContext) (*models.FinanceTransaction, error) {
+	if _, err := s.manageableSchoolBill(req.SchoolBillID, ownerCtx); err != nil {
+		return nil, err
+	}
+	if req.Amount <= 0 {
+		return nil, errors.New("refund amount must be greater than 0")
+	}
+	if req.Reason == "" {
+		return nil, errors.New("refund reason is required")
+	}
+
+	schoolBill, err := s.schoolBillRepo.FindByID(req.SchoolBillID)
+	if err != nil {
+		return nil, errors.New("school bill not found")
+	}
+
+	amountPaid := float64(0)
+	if schoolBill.AmountPaid != nil {
+		amountPaid = *schoolBill.AmountPaid
+	}
+	if req.Amount > amountPaid {
+		return nil, errors.New("refund amount exceeds amount paid")
+	}
+
+	paymentMode := req.PaymentMode
+	bankAccountID := req.BankAccountID
+	if req.FinanceTransactionID > 0 {
+		original, err := s.schoolBillRepo.FindFinanceTransactionByID(req.FinanceTransactionID)
+		if err != nil || original.FinanceId == nil || uint(*original.FinanceId) != req.SchoolBillID || original.RefundedTransactionId != nil {
+			return nil, errors.New("original payment not found for this school bill")
+		}
+		if paymentMode == "" && original.PaymentMode != nil {
+			paymentMode = *original.PaymentMode
+		}
+		// Refund from the account the original payment went into unless told otherwise
+		if bankAccountID == nil && paymentMode == stringValue(original.PaymentMode) {
+			bankAccountID = original.BankAccountId
+		}
+	}
+	if paymentMode == "" {
+		return nil, errors.New("payment_mode is required")
+	}
+
+	title := "Refund"
+	financeType := "Refund"
+	schoolBillID := int64(req.SchoolBillID)
+	refund := &models.FinanceTransaction{
+		Title:           &title,
+		Description:     &req.Reason,
+		Amount:          &req.Amount,
+		TransactionDate: time.Now(),
+		FinanceId:       &schoolBillID,
+		FinanceType:     &financeType,
+		SchoolId:        schoolBill.SchoolId,
+		PaymentMode:     &paymentMode,
+		PaymentNote:     &req.Reason,
+		UserId:          &req.UserID,
+	}
+	if req.ReferenceNo != "" {
+		refund.ReferenceNo = &req.ReferenceNo
+	}
+	if req.FinanceTransactionID > 0 {
+		refundedTransactionID := int64(req.FinanceTransactionID)
+		refund.RefundedTransactionId = &refundedTransactionID
+	}
+	if ownerInfo := s.schoolBillRepo.GetOwnerForSchoolBill(req.SchoolBillID); ownerInfo != nil {
+		refund.SetOwner(ownerInfo.OwnerType, ownerInfo.OwnerID)
+	}
+	if err := s.fiscalPeriodService.EnsureOpen(refund.OwnerType, refund.OwnerId, refund.TransactionDate); err != nil {
+		return nil, err
+	}
+	if refund.BankAccountId, err = s.bankAccountService.ResolveAccount(refund.OwnerType, refund.OwnerId, paymentMode, bankAccountID); err != nil {
+		return nil, err
+	}
+
+	// The refund, the bill balance, its journal entry and its allocation are saved together
+	err = s.schoolBillRepo.Transaction(func(tx *gorm.DB) error {
+		schoolBills := s.schoolBillRepo.WithTx(tx)
+
+		// Refunds against a payment never return more than it paid. The payment stays locked
+		// until the refund is saved, so concurrent refunds against it are checked one at a time
+		if req.FinanceTransactionID > 0 {
+			original, err := schoolBills.LockFinanceTransaction(req.FinanceTransactionID)
+			if err != nil {
+				return errors.New("original payment not found for this school bill")
+			}
+			refunded, err := schoolBills.SumRefundsOf(original.ID)
+			if err != nil {
+				return err
+			}
+			if req.Amount > roundAmount(floatValue(original.Amount)-refunded) {
+				return errors.New("refund amount exceeds what is left of the original payment")
+			}
+		}
+
+		if err := schoolBills.CreateFinanceTransaction(refund); err != nil {
+			return errors.New("failed to record refund")
+		}
+
+		// A refund reduces the amount paid, which restores the outstanding balance
+		if err := schoolBills.UpdatePayment(req.SchoolBillID, -req.Amount); err != nil {
+			return errors.New("failed to update school bill balance")
+		}
+
+		if _, err := s.ledgerService.WithTx(tx).PostRefund(refund); err != nil {
+			return fmt.Errorf("failed to post refund to the ledger: %w", err)
+		}
+
+		if err := s.remittanceService.WithTx(tx).AllocateTransaction(refund); err != nil {
+			return fmt.Errorf("failed to allocate refund: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// manageableSchoolBill returns a school bill the caller may adjust: school and system admins may not,
+// and other admins only for schools in their region or zone
+func (s *SchoolBillService) manageableSchoolBill(id uint, ownerCtx *utils.OwnerContext) (*models.SchoolBill, error) {
+	if ownerCtx == nil || ownerCtx.Role == utils.RoleSchoolAdmin {
+		return nil, errors.New("access denied")
+	}
+	if err := repositories.CanWrite(ownerCtx); err != nil {
+		return nil, err
+	}
+	schoolBill, err := s.schoolBillRepo.FindByIDWithRoleFilter(id, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("school bill not found")
+	}
+	return schoolBill, nil
+}
+
 // GetSchoolBillingParticulars retrieves the particulars for a school bill
 func (s *SchoolBillService) GetSchoolBillingParticulars(schoolBillId uint) ([]models.SchoolBillingParticular, error) {
 	return s.schoolBillRepo.GetSchoolBillingParticulars(schoolBillId)
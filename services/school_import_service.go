@@ -0,0 +1,691 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// schoolImportMaxRows caps the data rows accepted in one import file
+const schoolImportMaxRows = 5000
+
+// schoolImportColumnAliases lists, per school field, the header names accepted in an import file
+var schoolImportColumnAliases = map[string][]string{
+	"name":                  {"school name", "name", "school"},
+	"member_no":             {"member no", "member number", "membership no", "membership number"},
+	"zone":                  {"zone", "zone name", "zone code"},
+	"region":                {"region", "region name", "region code"},
+	"email":                 {"email", "school email", "email address"},
+	"mobile_no":             {"mobile no", "phone", "phone number", "mobile", "mobile number", "telephone", "school phone"},
+	"address":               {"address", "postal address"},
+	"location":              {"location", "town"},
+	"gps_address":           {"gps address", "gps", "digital address", "ghana post gps"},
+	"latitude":              {"latitude", "lat"},
+	"longitude":             {"longitude", "lng", "long"},
+	"date_of_establishment": {"date of establishment", "established", "year established"},
+	"joining_date":          {"joining date", "date joined"},
+}
+
+// schoolImportContactColumn matches contact person headers such as "Contact First Name" or "Contact 2 Phone"
+var schoolImportContactColumn = regexp.MustCompile(`^contact ?([0-9]*) (first name|last name|email|email address|mobile no|mobile|phone|phone number|relation)$`)
+
+// schoolImportErrorSheet matches the error sheets written for a user: school-import-errors-<user>-<stamp>.xlsx
+var schoolImportErrorSheet = regexp.MustCompile(`^school-import-errors-([0-9]+)-[0-9]+\.xlsx$`)
+
+// SchoolImportTemplateHeaders are the columns of the downloadable import template
+var SchoolImportTemplateHeaders = []string{
+	"School Name", "Member No", "Zone", "Region", "Email", "Phone", "Address", "Location", "GPS Address",
+	"Latitude", "Longitude", "Date of Establishment", "Joining Date",
+	"Contact First Name", "Contact Last Name", "Contact Phone", "Contact Email", "Contact Relation",
+	"Contact 2 First Name", "Contact 2 Last Name", "Contact 2 Phone", "Contact 2 Email", "Contact 2 Relation",
+}
+
+// Import row statuses
+const (
+	ImportRowValid    = "valid"
+	ImportRowImported = "imported"
+	ImportRowFailed   = "failed"
+)
+
+// SchoolImportRow is the outcome for one data row of an import file
+type SchoolImportRow struct {
+	Row      int      `json:"row"` // row number in the file
+	Name     string   `json:"name"`
+	Zone     string   `json:"zone"`
+	MemberNo string   `json:"member_no"`
+	Contacts int      `json:"contacts"`
+	Status   string   `json:"status"`
+	Errors   []string `json:"errors,omitempty"`
+	SchoolId *uint    `json:"school_id,omitempty"`
+
+	school            *models.School
+	contacts          []ApplicationContactPerson
+	cells             []string
+	generatedMemberNo bool // the member number is a preview, allocated when the school is created
+}
+
+// SchoolImportResult reports a dry run or a committed import
+type SchoolImportResult struct {
+	DryRun     bool              `json:"dry_run"`
+	TotalRows  int               `json:"total_rows"`
+	Valid      int               `json:"valid"`
+	Imported   int               `json:"imported"`
+	Failed     int               `json:"failed"`
+	Rows       []SchoolImportRow `json:"rows"`
+	ErrorSheet string            `json:"error_sheet,omitempty"` // file name of the per-row error sheet
+}
+
+type SchoolImportService struct {
+	schoolRepo        *repositories.SchoolRepository
+	zoneRepo          *repositories.ZoneRepository
+	regionRepo        *repositories.RegionRepository
+	contactPersonRepo *repositories.ContactPersonRepository
+	schoolService     *SchoolService
+	importDir         string
+}
+
+func NewSchoolImportService(
+	schoolRepo *repositories.SchoolRepository,
+	zoneRepo *repositories.ZoneRepository,
+	regionRepo *repositories.RegionRepository,
+	contactPersonRepo *repositories.ContactPersonRepository,
+	schoolService *SchoolService,
+) *SchoolImportService {
+	importDir := os.Getenv("IMPORT_DIR")
+	if importDir == "" {
+		importDir = "./imports"
+	}
+
+	return &SchoolImportService{
+		schoolRepo:        schoolRepo,
+		zoneRepo:          zoneRepo,
+		regionRepo:        regionRepo,
+		contactPersonRepo: contactPersonRepo,
+		schoolService:     schoolService,
+		importDir:         importDir,
+	}
+}
+
+// ImportSchools validates a CSV or XLSX file of schools and their contact persons. A dry run only
+// reports what would be imported; otherwise the valid rows are created and invalid rows skipped.
+// Failed rows are written to an error sheet that can be corrected and re-imported.
+func (s *SchoolImportService) ImportSchools(file *multipart.FileHeader, dryRun bool, ownerCtx *utils.OwnerContext) (*SchoolImportResult, error) {
+	if err := canImportSchools(ownerCtx, dryRun); err != nil {
+		return nil, err
+	}
+
+	rows, err := readSpreadsheetRows(file, "import")
+	if err != nil {
+		return nil, err
+	}
+
+	headerIndex, columns, contactColumns := findSchoolImportHeader(rows)
+	if headerIndex < 0 {
+		return nil, errors.New("could not find the header row; the file needs a School Name column")
+	}
+	header := rows[headerIndex]
+
+	var dataRows []int
+	for i := headerIndex + 1; i < len(rows); i++ {
+		if !isBlankRow(rows[i]) {
+			dataRows = append(dataRows, i)
+		}
+	}
+	if len(dataRows) == 0 {
+		return nil, errors.New("the file has no school rows")
+	}
+	if len(dataRows) > schoolImportMaxRows {
+		return nil, fmt.Errorf("the file has %d rows; import at most %d schools at a time", len(dataRows), schoolImportMaxRows)
+	}
+
+	resolver, err := s.newZoneResolver(ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+	phones, err := s.existingPhones()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SchoolImportResult{DryRun: dryRun, TotalRows: len(dataRows)}
+	batch := &importBatch{
+		service:    s,
+		memberNos:  map[string]int{},
+		emails:     map[string]int{},
+		phones:     map[string]int{},
+		sequences:  map[int64]int64{},
+		plans:      map[int64]*repositories.MemberNoPlan{},
+		existingDB: phones,
+	}
+
+	for _, i := range dataRows {
+		row := batch.validateRow(i+1, rows[i], columns, contactColumns, resolver, ownerCtx)
+		result.Rows = append(result.Rows, row)
+	}
+
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		if row.Status != ImportRowValid {
+			continue
+		}
+		result.Valid++
+		if dryRun {
+			continue
+		}
+		if err := s.createSchool(row); err != nil {
+			row.Status = ImportRowFailed
+			row.Errors = append(row.Errors, err.Error())
+			continue
+		}
+		row.Status = ImportRowImported
+		result.Imported++
+	}
+
+	for _, row := range result.Rows {
+		if row.Status == ImportRowFailed {
+			result.Failed++
+		}
+	}
+	if result.Failed > 0 && ownerCtx != nil {
+		name, err := s.writeErrorSheet(header, result.Rows, ownerCtx.UserID)
+		if err != nil {
+			log.Printf("Failed to write school import error sheet: %v", err)
+		} else {
+			result.ErrorSheet = name
+		}
+	}
+
+	return result, nil
+}
+
+// WriteTemplate writes an empty import workbook with the expected columns
+func (s *SchoolImportService) WriteTemplate(w io.Writer) error {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+
+	headings := stringsToCells(SchoolImportTemplateHeaders)
+	if err := workbook.SetSheetRow(workbook.GetSheetName(0), "A1", &headings); err != nil {
+		return err
+	}
+	return workbook.Write(w)
+}
+
+// ErrorSheetPath returns the path of an error sheet written for the user
+func (s *SchoolImportService) ErrorSheetPath(name string, userID uint) (string, error) {
+	match := schoolImportErrorSheet.FindStringSubmatch(name)
+	if match == nil || match[1] != strconv.FormatUint(uint64(userID), 10) {
+		return "", errors.New("error sheet not found")
+	}
+	path := filepath.Join(s.importDir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", errors.New("error sheet not found")
+	}
+	return path, nil
+}
+
+// createSchool creates an import row's school (with its user when it has an email) and contact persons.
+// A previewed member number is replaced by one allocated from the zone's sequence.
+func (s *SchoolImportService) createSchool(row *SchoolImportRow) error {
+	if row.generatedMemberNo {
+		row.school.MemberNo = ""
+	}
+	if err := s.schoolService.CreateSchool(row.school); err != nil {
+		return err
+	}
+	row.SchoolId = &row.school.ID
+	row.MemberNo = row.school.MemberNo
+
+	schoolID := int64(row.school.ID)
+	for _, contact := range row.contacts {
+		contact := contact
+		contactPerson := &models.ContactPerson{
+			SchoolId:  &schoolID,
+			FirstName: &contact.FirstName,
+			LastName:  &contact.LastName,
+		}
+		if contact.Email != "" {
+			contactPerson.Email = &contact.Email
+		}
+		if contact.MobileNo != "" {
+			contactPerson.MobileNo = &contact.MobileNo
+		}
+		if contact.Relation != "" {
+			contactPerson.Relation = &contact.Relation
+		}
+		if err := s.contactPersonRepo.Create(contactPerson); err != nil {
+			// Log but don't fail - the school is already created
+			log.Printf("Failed to create contact person for school %d: %v", row.school.ID, err)
+		}
+	}
+	return nil
+}
+
+// writeErrorSheet saves the failed rows as they were in the file with an Errors column added
+func (s *SchoolImportService) writeErrorSheet(header []string, rows []SchoolImportRow, userID uint) (string, error) {
+	if err := os.MkdirAll(s.importDir, 0755); err != nil {
+		return "", err
+	}
+
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+	sheet := workbook.GetSheetName(0)
+
+	headings := append([]interface{}{"Row", "Errors"}, stringsToCells(header)...)
+	if err := workbook.SetSheetRow(sheet, "A1", &headings); err != nil {
+		return "", err
+	}
+
+	line := 2
+	for _, row := range rows {
+		if row.Status != ImportRowFailed {
+			continue
+		}
+		values := append([]interface{}{row.Row, strings.Join(row.Errors, "; ")}, stringsToCells(row.cells)...)
+		cell, _ := excelize.CoordinatesToCellName(1, line)
+		if err := workbook.SetSheetRow(sheet, cell, &values); err != nil {
+			return "", err
+		}
+		line++
+	}
+
+	name := fmt.Sprintf("school-import-errors-%d-%d.xlsx", userID, time.Now().UnixNano())
+	if err := workbook.SaveAs(filepath.Join(s.importDir, name)); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// existingPhones indexes the mobile numbers of existing schools
+func (s *SchoolImportService) existingPhones() (map[string]bool, error) {
+	numbers, err := s.schoolRepo.ListMobileNumbers()
+	if err != nil {
+		return nil, err
+	}
+	phones := make(map[string]bool, len(numbers))
+	for _, number := range numbers {
+		if key := phoneKey(number); key != "" {
+			phones[key] = true
+		}
+	}
+	return phones, nil
+}
+
+// importBatch tracks what earlier rows of the same file have claimed
+type importBatch struct {
+	service    *SchoolImportService
+	memberNos  map[string]int // member number -> file row
+	emails     map[string]int
+	phones     map[string]int
+	sequences  map[int64]int64 // next member number per zone
+	plans      map[int64]*repositories.MemberNoPlan
+	existingDB map[string]bool // phones of existing schools
+}
+
+// validateRow builds a row's school and contact persons and checks them with the rules of
+// SchoolService.CreateSchool, plus phone uniqueness and duplicates within the file
+func (b *importBatch) validateRow(line int, cells []string, columns map[string]int, contactColumns map[int]map[string]int, resolver *zoneResolver, ownerCtx *utils.OwnerContext) SchoolImportRow {
+	cell := func(field string) string { return statementCell(cells, columns[field]) }
+
+	row := SchoolImportRow{Row: line, Name: cell("name"), cells: cells}
+	fail := func(format string, args ...interface{}) {
+		row.Errors = append(row.Errors, fmt.Sprintf(format, args...))
+	}
+
+	if row.Name == "" {
+		fail("school name is required")
+	}
+
+	zone, err := resolver.resolve(cell("zone"), cell("region"), ownerCtx)
+	if err != nil {
+		fail("%s", err.Error())
+	}
+
+	school := &models.School{Name: row.Name, JoiningDate: time.Now()}
+	if zone != nil {
+		zoneID := int64(zone.ID)
+		school.ZoneId = &zoneID
+		if zone.Name != nil {
+			row.Zone = *zone.Name
+		}
+	}
+	if value := cell("address"); value != "" {
+		school.Address = &value
+	}
+	if value := cell("location"); value != "" {
+		school.Location = &value
+	}
+	if value := cell("gps_address"); value != "" {
+		school.GpsAddress = &value
+	}
+	if value := cell("latitude"); value != "" {
+		latitude, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fail("invalid latitude %q", value)
+		} else {
+			school.Latitude = &latitude
+		}
+	}
+	if value := cell("longitude"); value != "" {
+		longitude, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fail("invalid longitude %q", value)
+		} else {
+			school.Longitude = &longitude
+		}
+	}
+	if value := cell("date_of_establishment"); value != "" {
+		date, ok := parseImportDate(value)
+		if !ok {
+			fail("invalid date of establishment %q", value)
+		}
+		school.DateOfEstablishment = date
+	}
+	if value := cell("joining_date"); value != "" {
+		date, ok := parseImportDate(value)
+		if !ok {
+			fail("invalid joining date %q", value)
+		}
+		school.JoiningDate = date
+	}
+
+	if email := strings.ToLower(cell("email")); email != "" {
+		if !strings.Contains(email, "@") {
+			fail("invalid email %q", email)
+		} else if first, ok := b.emails[email]; ok {
+			fail("email %s is also used on row %d", email, first)
+		} else {
+			b.emails[email] = line
+		}
+		school.Email = &email
+	}
+
+	if phone := cell("mobile_no"); phone != "" {
+		key := phoneKey(phone)
+		if key == "" {
+			fail("invalid phone number %q", phone)
+		} else if b.existingDB[key] {
+			fail("school with this phone number already exists")
+		} else if first, ok := b.phones[key]; ok {
+			fail("phone number %s is also used on row %d", phone, first)
+		} else {
+			b.phones[key] = line
+		}
+		school.MobileNo = &phone
+	}
+
+	school.MemberNo = cell("member_no")
+	if school.MemberNo != "" {
+		if first, ok := b.memberNos[school.MemberNo]; ok {
+			fail("member number %s is also used on row %d", school.MemberNo, first)
+		} else {
+			b.memberNos[school.MemberNo] = line
+		}
+	}
+
+	for _, number := range sortedContactNumbers(contactColumns) {
+		fields := contactColumns[number]
+		contact := ApplicationContactPerson{
+			FirstName: statementCell(cells, fields["first_name"]),
+			LastName:  statementCell(cells, fields["last_name"]),
+			Email:     statementCell(cells, fields["email"]),
+			MobileNo:  statementCell(cells, fields["mobile_no"]),
+			Relation:  statementCell(cells, fields["relation"]),
+		}
+		if contact == (ApplicationContactPerson{}) {
+			continue
+		}
+		if contact.FirstName == "" || contact.LastName == "" {
+			fail("contact person %d needs a first and last name", number)
+			continue
+		}
+		if contact.MobileNo != "" && phoneKey(contact.MobileNo) == "" {
+			fail("invalid phone number %q for contact person %d", contact.MobileNo, number)
+		}
+		row.contacts = append(row.contacts, contact)
+	}
+	row.Contacts = len(row.contacts)
+
+	// Rows without a member number continue the zone's sequence; failed rows don't use one up
+	if school.MemberNo == "" && school.ZoneId != nil && len(row.Errors) == 0 {
+		memberNo, err := b.nextMemberNo(*school.ZoneId)
+		if err != nil {
+			fail("failed to generate member number")
+		} else {
+			school.MemberNo = memberNo
+			b.memberNos[memberNo] = line
+			row.generatedMemberNo = true
+		}
+	}
+	row.MemberNo = school.MemberNo
+
+	// Only check against the database once the row itself is sound
+	if len(row.Errors) == 0 {
+		if err := b.service.schoolService.ValidateNewSchool(school); err != nil {
+			fail("%s", err.Error())
+		}
+	}
+
+	row.school = school
+	row.Status = ImportRowValid
+	if len(row.Errors) > 0 {
+		row.Status = ImportRowFailed
+	}
+	return row
+}
+
+// nextMemberNo previews the continuation of a zone's member number sequence, skipping numbers already taken
+func (b *importBatch) nextMemberNo(zoneID int64) (string, error) {
+	if _, ok := b.plans[zoneID]; !ok {
+		plan, err := b.service.schoolService.memberNoRepo.Plan(zoneID)
+		if err != nil {
+			return "", err
+		}
+		b.plans[zoneID], b.sequences[zoneID] = plan, plan.Next
+	}
+
+	for {
+		memberNo := b.plans[zoneID].Format(b.sequences[zoneID])
+		b.sequences[zoneID]++
+		if _, claimed := b.memberNos[memberNo]; claimed {
+			continue
+		}
+		exists, err := b.service.schoolRepo.MemberNoExists(memberNo, nil)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return memberNo, nil
+		}
+	}
+}
+
+// zoneResolver matches zone names or codes in an import file to the zones the user may import into
+type zoneResolver struct {
+	zones   []models.Zone
+	regions map[int64]models.Region
+}
+
+func (s *SchoolImportService) newZoneResolver(ownerCtx *utils.OwnerContext) (*zoneResolver, error) {
+	zones, _, err := s.zoneRepo.ListWithRoleFilter(map[string]interface{}{}, 1, 10000, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, err
+	}
+	regions, _, err := s.regionRepo.List(map[string]interface{}{}, 1, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	resolver := &zoneResolver{zones: zones, regions: make(map[int64]models.Region, len(regions))}
+	for _, region := range regions {
+		resolver.regions[int64(region.ID)] = region
+	}
+	return resolver, nil
+}
+
+// resolve finds the zone named or coded by value, narrowed by region when the name is shared.
+// Zone admins may leave the zone blank to import into their own zone.
+func (r *zoneResolver) resolve(value, region string, ownerCtx *utils.OwnerContext) (*models.Zone, error) {
+	if value == "" {
+		if ownerCtx != nil && ownerCtx.IsZoneAdmin() && len(r.zones) == 1 {
+			return &r.zones[0], nil
+		}
+		return nil, errors.New("zone is required")
+	}
+
+	var matches []models.Zone
+	for _, zone := range r.zones {
+		if (zone.Code != nil && strings.EqualFold(*zone.Code, value)) || (zone.Name != nil && strings.EqualFold(*zone.Name, value)) {
+			if region != "" && !r.inRegion(zone, region) {
+				continue
+			}
+			matches = append(matches, zone)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		if region != "" {
+			return nil, fmt.Errorf("zone %q not found in region %q or not accessible", value, region)
+		}
+		return nil, fmt.Errorf("zone %q not found or not accessible", value)
+	case 1:
+		return &matches[0], nil
+	}
+	return nil, fmt.Errorf("zone %q matches %d zones; use the zone code or add the region", value, len(matches))
+}
+
+func (r *zoneResolver) inRegion(zone models.Zone, value string) bool {
+	if zone.RegionId == nil {
+		return false
+	}
+	region, ok := r.regions[*zone.RegionId]
+	if !ok {
+		return false
+	}
+	return (region.Code != nil && strings.EqualFold(*region.Code, value)) || (region.Name != nil && strings.EqualFold(*region.Name, value))
+}
+
+// findSchoolImportHeader finds the first of the opening rows with a school name column, returning
+// its index, each school field's column and each numbered contact person's columns
+func findSchoolImportHeader(rows [][]string) (int, map[string]int, map[int]map[string]int) {
+	for i := 0; i < len(rows) && i < 20; i++ {
+		columns := map[string]int{}
+		for field := range schoolImportColumnAliases {
+			columns[field] = -1
+		}
+		contacts := map[int]map[string]int{}
+
+		for col, cell := range rows[i] {
+			header := normalizeStatementHeader(cell)
+			if match := schoolImportContactColumn.FindStringSubmatch(header); match != nil {
+				number := 1
+				if match[1] != "" {
+					number, _ = strconv.Atoi(match[1])
+				}
+				if contacts[number] == nil {
+					contacts[number] = map[string]int{"first_name": -1, "last_name": -1, "email": -1, "mobile_no": -1, "relation": -1}
+				}
+				contacts[number][contactField(match[2])] = col
+				continue
+			}
+			for field, aliases := range schoolImportColumnAliases {
+				for _, alias := range aliases {
+					if header == alias && columns[field] < 0 {
+						columns[field] = col
+					}
+				}
+			}
+		}
+
+		if columns["name"] >= 0 {
+			return i, columns, contacts
+		}
+	}
+	return -1, nil, nil
+}
+
+func contactField(header string) string {
+	switch header {
+	case "first name":
+		return "first_name"
+	case "last name":
+		return "last_name"
+	case "email", "email address":
+		return "email"
+	case "relation":
+		return "relation"
+	}
+	return "mobile_no"
+}
+
+func sortedContactNumbers(contacts map[int]map[string]int) []int {
+	numbers := make([]int, 0, len(contacts))
+	for number := range contacts {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	return numbers
+}
+
+// parseImportDate accepts the statement date formats and a bare year such as "1998"
+func parseImportDate(value string) (time.Time, bool) {
+	if len(value) == 4 {
+		if year, err := strconv.Atoi(value); err == nil && year > 1800 && year < 2200 {
+			return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), true
+		}
+	}
+	return parseStatementDate(value)
+}
+
+// phoneKey reduces a Ghanaian phone number to its last nine digits so "0244123456",
+// "+233 24 412 3456" and "233244123456" compare equal; it is empty for invalid numbers
+func phoneKey(value string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, value)
+	if len(digits) < 9 || len(digits) > 12 {
+		return ""
+	}
+	return digits[len(digits)-9:]
+}
+
+func stringsToCells(values []string) []interface{} {
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+	return cells
+}
+
+// canImportSchools allows national, region and zone admins to import; system admins may only dry run
+func canImportSchools(ownerCtx *utils.OwnerContext, dryRun bool) error {
+	if ownerCtx == nil {
+		return errors.New("access denied")
+	}
+	switch ownerCtx.Role {
+	case utils.RoleNationalAdmin, utils.RoleRegionAdmin, utils.RoleZoneAdmin:
+		return nil
+	case utils.RoleSystemAdmin:
+		if dryRun {
+			return nil
+		}
+	}
+	return errors.New("access denied")
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"math"
+	"sort"
+)
+
+// Limits on location searches
+const (
+	defaultSearchRadiusKm = 10.0
+	maxSearchRadiusKm     = 200.0
+	defaultLocationLimit  = 50
+	maxLocationLimit      = 500
+)
+
+// NearbySchool is a school found by a radius search with its distance from the search point
+type NearbySchool struct {
+	models.School
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// GeoJSONFeatureCollection is a GeoJSON FeatureCollection of schools
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature is a school as a GeoJSON Point feature
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONPoint is a GeoJSON Point; coordinates are longitude, latitude
+type GeoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type SchoolLocationService struct {
+	schoolRepo *repositories.SchoolRepository
+}
+
+func NewSchoolLocationService(schoolRepo *repositories.SchoolRepository) *SchoolLocationService {
+	return &SchoolLocationService{schoolRepo: schoolRepo}
+}
+
+// SearchNearby returns the schools within radiusKm of a point, nearest first
+func (s *SchoolLocationService) SearchNearby(latitude, longitude, radiusKm float64, limit int, ownerCtx *utils.OwnerContext) ([]NearbySchool, error) {
+	if err := utils.ValidateCoordinates(&latitude, &longitude); err != nil {
+		return nil, err
+	}
+	if radiusKm <= 0 {
+		radiusKm = defaultSearchRadiusKm
+	}
+	if radiusKm > maxSearchRadiusKm {
+		return nil, errors.New("radius cannot be more than 200 km")
+	}
+	limit = locationLimit(limit)
+
+	// The bounding box narrows the search on the coordinate index; the exact distance decides
+	minLat, minLng, maxLat, maxLng := utils.BoundingBox(latitude, longitude, radiusKm)
+	candidates, err := s.schoolRepo.ListWithinBox(minLat, minLng, maxLat, maxLng, maxLocationLimit*4, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	nearby := make([]NearbySchool, 0, len(candidates))
+	for _, school := range candidates {
+		distance := utils.DistanceKm(latitude, longitude, *school.Latitude, *school.Longitude)
+		if distance <= radiusKm {
+			nearby = append(nearby, NearbySchool{School: school, DistanceKm: math.Round(distance*100) / 100})
+		}
+	}
+	sort.SliceStable(nearby, func(i, j int) bool { return nearby[i].DistanceKm < nearby[j].DistanceKm })
+	if len(nearby) > limit {
+		nearby = nearby[:limit]
+	}
+	return nearby, nil
+}
+
+// SearchWithinBox returns the schools inside a bounding box, e.g. the visible area of a map
+func (s *SchoolLocationService) SearchWithinBox(minLat, minLng, maxLat, maxLng float64, limit int, ownerCtx *utils.OwnerContext) ([]models.School, error) {
+	if minLat >= maxLat || minLng >= maxLng {
+		return nil, errors.New("min_lat and min_lng must be less than max_lat and max_lng")
+	}
+	return s.schoolRepo.ListWithinBox(minLat, minLng, maxLat, maxLng, locationLimit(limit), ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+}
+
+// ExportGeoJSON returns the located schools of a zone or region as a GeoJSON FeatureCollection
+func (s *SchoolLocationService) ExportGeoJSON(zoneID, regionID *int64, ownerCtx *utils.OwnerContext) (*GeoJSONFeatureCollection, error) {
+	if zoneID == nil && regionID == nil {
+		return nil, errors.New("zone_id or region_id is required")
+	}
+
+	schools, err := s.schoolRepo.ListLocated(zoneID, regionID, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &GeoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]GeoJSONFeature, 0, len(schools))}
+	for _, school := range schools {
+		properties := map[string]interface{}{
+			"id":                school.ID,
+			"name":              school.Name,
+			"member_no":         school.MemberNo,
+			"zone_id":           school.ZoneId,
+			"gps_address":       school.GpsAddress,
+			"location":          school.Location,
+			"mobile_no":         school.MobileNo,
+			"membership_status": school.MembershipStatus,
+		}
+		if school.Zone != nil {
+			properties["zone_name"] = school.Zone.Name
+		}
+		collection.Features = append(collection.Features, GeoJSONFeature{
+			Type:       "Feature",
+			Geometry:   GeoJSONPoint{Type: "Point", Coordinates: [2]float64{*school.Longitude, *school.Latitude}},
+			Properties: properties,
+		})
+	}
+	return collection, nil
+}
+
+func locationLimit(limit int) int {
+	if limit <= 0 {
+		return defaultLocationLimit
+	}
+	if limit > maxLocationLimit {
+		return maxLocationLimit
+	}
+	return limit
+}
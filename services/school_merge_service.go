@@ -0,0 +1,318 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/datatypes"
+)
+
+// Weights of each matching signal in a duplicate score out of 100
+const (
+	duplicateNameWeight  = 50
+	duplicatePhoneWeight = 20
+	duplicateEmailWeight = 20
+	duplicateGPSWeight   = 10
+)
+
+// defaultDuplicateMinScore is the score from which a pair of schools is reported as a likely duplicate
+const defaultDuplicateMinScore = 60
+
+// schoolNameNoise are words dropped before comparing school names
+var schoolNameNoise = map[string]bool{
+	"the": true, "school": true, "schools": true, "sch": true, "complex": true, "academy": true,
+	"international": true, "intl": true, "int": true, "ltd": true, "limited": true, "co": true,
+	"basic": true, "jhs": true, "primary": true, "prep": true, "preparatory": true, "and": true, "of": true,
+}
+
+// DuplicateSchoolPair is a pair of schools in a zone that look like the same school
+type DuplicateSchoolPair struct {
+	Score          int              `json:"score"`
+	NameSimilarity float64          `json:"name_similarity"`
+	Reasons        []string         `json:"reasons"`
+	Schools        [2]models.School `json:"schools"`
+}
+
+type SchoolMergeService struct {
+	mergeRepo  *repositories.SchoolMergeRepository
+	schoolRepo *repositories.SchoolRepository
+	zoneRepo   *repositories.ZoneRepository
+}
+
+func NewSchoolMergeService(mergeRepo *repositories.SchoolMergeRepository, schoolRepo *repositories.SchoolRepository, zoneRepo *repositories.ZoneRepository) *SchoolMergeService {
+	return &SchoolMergeService{
+		mergeRepo:  mergeRepo,
+		schoolRepo: schoolRepo,
+		zoneRepo:   zoneRepo,
+	}
+}
+
+// FindDuplicates compares every pair of schools in a zone on name, phone, email and GPS address
+// and returns the pairs scoring at least minScore, most likely first. Zone admins check their own zone.
+func (s *SchoolMergeService) FindDuplicates(zoneID int64, minScore int, ownerCtx *utils.OwnerContext) ([]DuplicateSchoolPair, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	if ownerCtx.IsZoneAdmin() {
+		zoneID = ownerCtx.OwnerID
+	}
+	if zoneID == 0 {
+		return nil, errors.New("zone_id is required")
+	}
+	if _, err := s.zoneRepo.FindByIDWithRoleFilter(uint(zoneID), ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()); err != nil {
+		return nil, errors.New("zone not found")
+	}
+	if minScore <= 0 {
+		minScore = defaultDuplicateMinScore
+	}
+
+	schools, err := s.mergeRepo.ListByZone(zoneID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(schools))
+	for i, school := range schools {
+		names[i] = normalizeSchoolName(school.Name)
+	}
+
+	var pairs []DuplicateSchoolPair
+	for i := 0; i < len(schools); i++ {
+		for j := i + 1; j < len(schools); j++ {
+			pair := scoreDuplicatePair(schools[i], schools[j], names[i], names[j])
+			if pair.Score >= minScore {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].Score > pairs[b].Score })
+	return pairs, nil
+}
+
+// MergeSchools merges a duplicate school into the surviving school. Both must be in the same
+// zone and accessible to the user; the duplicate is retired and a merge record is kept.
+func (s *SchoolMergeService) MergeSchools(survivingID, mergedID uint, reason string, performedBy *int64, ownerCtx *utils.OwnerContext) (*models.SchoolMerge, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	if survivingID == 0 || mergedID == 0 {
+		return nil, errors.New("surviving_school_id and merged_school_id are required")
+	}
+	if survivingID == mergedID {
+		return nil, errors.New("a school cannot be merged into itself")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("a reason is required to merge schools")
+	}
+
+	regionID, zoneID := ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter()
+	surviving, err := s.schoolRepo.FindByIDWithRoleFilter(survivingID, regionID, zoneID)
+	if err != nil {
+		return nil, errors.New("school not found")
+	}
+	merged, err := s.schoolRepo.FindByIDWithRoleFilter(mergedID, regionID, zoneID)
+	if err != nil {
+		return nil, errors.New("school not found")
+	}
+	if surviving.ZoneId == nil || merged.ZoneId == nil || *surviving.ZoneId != *merged.ZoneId {
+		return nil, errors.New("only schools in the same zone can be merged; transfer the school first")
+	}
+
+	snapshot, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	snapshotJSON := datatypes.JSON(snapshot)
+	memberNo, name := merged.MemberNo, merged.Name
+	audit := &models.SchoolMerge{
+		SurvivingSchoolId:    int64(surviving.ID),
+		MergedSchoolId:       int64(merged.ID),
+		MergedMemberNo:       &memberNo,
+		MergedSchoolName:     &name,
+		Reason:               &reason,
+		MergedSchoolSnapshot: &snapshotJSON,
+		PerformedBy:          performedBy,
+		PerformedRole:        &ownerCtx.Role,
+	}
+
+	if err := s.mergeRepo.Merge(surviving, merged, audit); err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
+
+// ListMerges returns the merge records of a school, or of all schools the user can see
+func (s *SchoolMergeService) ListMerges(schoolID int64, page, limit int, ownerCtx *utils.OwnerContext) ([]models.SchoolMerge, int64, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+
+	var zoneIDs []int64
+	if !ownerCtx.CanViewAllHierarchyData() {
+		zones, _, err := s.zoneRepo.ListWithRoleFilter(map[string]interface{}{}, 1, 10000, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+		if err != nil {
+			return nil, 0, err
+		}
+		zoneIDs = []int64{}
+		for _, zone := range zones {
+			zoneIDs = append(zoneIDs, int64(zone.ID))
+		}
+	}
+	return s.mergeRepo.List(schoolID, zoneIDs, page, limit)
+}
+
+// scoreDuplicatePair weighs how alike two schools' names are with exact matches on phone, email and GPS address
+func scoreDuplicatePair(a, b models.School, nameA, nameB string) DuplicateSchoolPair {
+	pair := DuplicateSchoolPair{Schools: [2]models.School{a, b}}
+
+	pair.NameSimilarity = math.Round(nameSimilarity(nameA, nameB)*100) / 100
+	score := pair.NameSimilarity * duplicateNameWeight
+	if pair.NameSimilarity >= 0.8 {
+		pair.Reasons = append(pair.Reasons, "similar name")
+	}
+
+	if phoneA, phoneB := phoneKey(stringValue(a.MobileNo)), phoneKey(stringValue(b.MobileNo)); phoneA != "" && phoneA == phoneB {
+		score += duplicatePhoneWeight
+		pair.Reasons = append(pair.Reasons, "same phone number")
+	} else if sharesContactPhone(a.ContactPersons, b.ContactPersons) {
+		score += duplicatePhoneWeight / 2
+		pair.Reasons = append(pair.Reasons, "contact person with the same phone number")
+	}
+
+	if emailA, emailB := strings.ToLower(strings.TrimSpace(stringValue(a.Email))), strings.ToLower(strings.TrimSpace(stringValue(b.Email))); emailA != "" && emailA == emailB {
+		score += duplicateEmailWeight
+		pair.Reasons = append(pair.Reasons, "same email")
+	}
+
+	if gpsA, gpsB := gpsKey(stringValue(a.GpsAddress)), gpsKey(stringValue(b.GpsAddress)); gpsA != "" && gpsA == gpsB {
+		score += duplicateGPSWeight
+		pair.Reasons = append(pair.Reasons, "same GPS address")
+	}
+
+	// A near-identical name alone is enough to flag the pair
+	if pair.NameSimilarity >= 0.9 && score < defaultDuplicateMinScore {
+		score = defaultDuplicateMinScore
+	}
+	pair.Score = int(math.Round(score))
+	return pair
+}
+
+// normalizeSchoolName lowercases a name and drops punctuation and words such as "school" or "ltd"
+func normalizeSchoolName(name string) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return ' '
+	}, name)
+
+	var words []string
+	for _, word := range strings.Fields(cleaned) {
+		if !schoolNameNoise[word] {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		return strings.Join(strings.Fields(cleaned), " ")
+	}
+	return strings.Join(words, " ")
+}
+
+// nameSimilarity is the better of the edit-distance similarity of the normalized names and the
+// overlap of their words, so both "St. Mary's" / "St Marys" and reordered names score high
+func nameSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(strings.ReplaceAll(a, " ", "")), []rune(strings.ReplaceAll(b, " ", ""))
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	edit := 1 - float64(levenshtein(ra, rb))/float64(longest)
+
+	wordsA, wordsB := strings.Fields(a), strings.Fields(b)
+	set := map[string]bool{}
+	for _, word := range wordsA {
+		set[word] = true
+	}
+	shared := 0
+	for _, word := range wordsB {
+		if set[word] {
+			shared++
+			delete(set, word)
+		}
+	}
+	union := len(wordsA) + len(wordsB) - shared
+	overlap := 0.0
+	if union > 0 {
+		overlap = float64(shared) / float64(union)
+	}
+
+	return math.Max(edit, overlap)
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// gpsKey normalizes a Ghana Post GPS address such as "GA-123-4567" for comparison
+func gpsKey(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, value)
+}
+
+func sharesContactPhone(a, b []models.ContactPerson) bool {
+	phones := map[string]bool{}
+	for _, contact := range a {
+		if key := phoneKey(stringValue(contact.MobileNo)); key != "" {
+			phones[key] = true
+		}
+	}
+	for _, contact := range b {
+		if key := phoneKey(stringValue(contact.MobileNo)); key != "" && phones[key] {
+			return true
+		}
+	}
+	return false
+}
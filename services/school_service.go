@@ -6,17 +6,32 @@ import (
 	"gnaps-api/models"
 	"gnaps-api/repositories"
 	"gnaps-api/utils"
+	"strings"
+
+	"gorm.io/gorm"
 )
 
 type SchoolService struct {
-	schoolRepo *repositories.SchoolRepository
-	userRepo   *repositories.UserRepository
+	schoolRepo   *repositories.SchoolRepository
+	userRepo     *repositories.UserRepository
+	memberNoRepo *repositories.MemberNoSchemeRepository
 }
 
-func NewSchoolService(schoolRepo *repositories.SchoolRepository, userRepo *repositories.UserRepository) *SchoolService {
+func NewSchoolService(schoolRepo *repositories.SchoolRepository, userRepo *repositories.UserRepository, memberNoRepo *repositories.MemberNoSchemeRepository) *SchoolService {
 	return &SchoolService{
-		schoolRepo: schoolRepo,
-		userRepo:   userRepo,
+		schoolRepo:   schoolRepo,
+		userRepo:     userRepo,
+		memberNoRepo: memberNoRepo,
+	}
+}
+
+// WithTx returns a copy of the service whose repositories run in tx; a member number allocated in
+// tx is released again if tx rolls back
+func (s *SchoolService) WithTx(tx *gorm.DB) *SchoolService {
+	return &SchoolService{
+		schoolRepo:   s.schoolRepo.WithTx(tx),
+		userRepo:     s.userRepo.WithTx(tx),
+		memberNoRepo: s.memberNoRepo.WithTx(tx),
 	}
 }
 
@@ -39,8 +54,55 @@ func (s *SchoolService) ListSchools(filters map[string]interface{}, page, limit
 	return s.schoolRepo.List(filters, page, limit)
 }
 
-// CreateSchool creates a new school with validation
+// CreateSchool creates a new school with validation. A school without a member number gets the next
+// one of its zone's numbering scheme.
 func (s *SchoolService) CreateSchool(school *models.School) error {
+	if school.MemberNo == "" && school.ZoneId != nil && school.Name != "" {
+		memberNo, err := s.memberNoRepo.AllocateMemberNo(*school.ZoneId)
+		if err != nil {
+			return errors.New("failed to generate member number")
+		}
+		school.MemberNo = memberNo
+	}
+
+	if err := s.ValidateNewSchool(school); err != nil {
+		return err
+	}
+
+	// Set defaults
+	isDeleted := false
+	school.IsDeleted = &isDeleted
+	school.MembershipStatus = MembershipStatusActive
+	school.StatusReason = nil
+
+	// Create user account for the school if email is provided
+	if school.Email != nil && *school.Email != "" {
+		mobileNo := ""
+		if school.MobileNo != nil {
+			mobileNo = *school.MobileNo
+		}
+
+		user, err := s.userRepo.CreateUserForSchool(
+			school.Name,
+			*school.Email,
+			mobileNo,
+			school.MemberNo, // Password will be member_no + "123"
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create user account: %v", err)
+		}
+
+		// Set the user_id on the school
+		userID := int64(user.ID)
+		school.UserId = &userID
+	}
+
+	return s.schoolRepo.Create(school)
+}
+
+// ValidateNewSchool checks the required fields, zone and the uniqueness of the member number
+// and email of a school about to be created
+func (s *SchoolService) ValidateNewSchool(school *models.School) error {
 	// Validate required fields
 	if school.Name == "" {
 		return errors.New("name is required")
@@ -57,6 +119,15 @@ func (s *SchoolService) CreateSchool(school *models.School) error {
 		}
 	}
 
+	gpsAddress, err := normalizeGPSAddress(school.GpsAddress)
+	if err != nil {
+		return err
+	}
+	school.GpsAddress = gpsAddress
+	if err := utils.ValidateCoordinates(school.Latitude, school.Longitude); err != nil {
+		return err
+	}
+
 	// Check if member_no already exists
 	exists, err := s.schoolRepo.MemberNoExists(school.MemberNo, nil)
 	if err != nil {
@@ -77,33 +148,7 @@ func (s *SchoolService) CreateSchool(school *models.School) error {
 		}
 	}
 
-	// Set defaults
-	isDeleted := false
-	school.IsDeleted = &isDeleted
-
-	// Create user account for the school if email is provided
-	if school.Email != nil && *school.Email != "" {
-		mobileNo := ""
-		if school.MobileNo != nil {
-			mobileNo = *school.MobileNo
-		}
-
-		user, err := s.userRepo.CreateUserForSchool(
-			school.Name,
-			*school.Email,
-			mobileNo,
-			school.MemberNo, // Password will be member_no + "123"
-		)
-		if err != nil {
-			return fmt.Errorf("failed to create user account: %v", err)
-		}
-
-		// Set the user_id on the school
-		userID := int64(user.ID)
-		school.UserId = &userID
-	}
-
-	return s.schoolRepo.Create(school)
+	return nil
 }
 
 // UpdateSchool updates an existing school with validation
@@ -114,10 +159,14 @@ func (s *SchoolService) UpdateSchool(id uint, updates map[string]interface{}) er
 		return errors.New("school not found")
 	}
 
-	// If zone_id is being changed, verify the new zone exists
+	// A school only changes zone through a transfer, which keeps its bills and reports with the
+	// right zone; a zone can still be set on a school that has none
 	if zoneId, ok := updates["zone_id"]; ok {
 		zoneIdVal := zoneId.(int64)
-		if school.ZoneId == nil || zoneIdVal != *school.ZoneId {
+		if school.ZoneId != nil && zoneIdVal != *school.ZoneId {
+			return errors.New("use a school transfer to move a school to another zone")
+		}
+		if school.ZoneId == nil {
 			exists, err := s.schoolRepo.VerifyZoneExists(zoneIdVal)
 			if err != nil || !exists {
 				return errors.New("invalid zone ID - Zone does not exist")
@@ -125,6 +174,30 @@ func (s *SchoolService) UpdateSchool(id uint, updates map[string]interface{}) er
 		}
 	}
 
+	// GPS addresses are stored normalized; coordinates are checked together with the ones kept
+	if value, ok := updates["gps_address"]; ok {
+		gpsAddress, _ := value.(*string)
+		normalized, err := normalizeGPSAddress(gpsAddress)
+		if err != nil {
+			return err
+		}
+		updates["gps_address"] = normalized
+	}
+	_, latitudeChanged := updates["latitude"]
+	_, longitudeChanged := updates["longitude"]
+	if latitudeChanged || longitudeChanged {
+		latitude, longitude := school.Latitude, school.Longitude
+		if latitudeChanged {
+			latitude, _ = updates["latitude"].(*float64)
+		}
+		if longitudeChanged {
+			longitude, _ = updates["longitude"].(*float64)
+		}
+		if err := utils.ValidateCoordinates(latitude, longitude); err != nil {
+			return err
+		}
+	}
+
 	// Check if member_no is being changed and if new member_no already exists
 	if memberNo, ok := updates["member_no"]; ok {
 		memberNoStr := memberNo.(string)
@@ -202,9 +275,10 @@ func (s *SchoolService) DeleteSchool(id uint) error {
 	return s.schoolRepo.Delete(id)
 }
 
-// GetNextMemberNoForZone returns the next member number for a zone
+// GetNextMemberNoForZone previews the next member number of a zone's numbering scheme; it is only
+// allocated when a school is created without a member number
 func (s *SchoolService) GetNextMemberNoForZone(zoneID int64) (string, error) {
-	return s.schoolRepo.GetNextMemberNoForZone(zoneID)
+	return s.memberNoRepo.NextMemberNo(zoneID)
 }
 
 // ============================================
@@ -228,3 +302,40 @@ func (s *SchoolService) GetSchoolByIDWithRole(id uint, ownerCtx *utils.OwnerCont
 	}
 	return school, nil
 }
+
+// normalizeGPSAddress validates a Ghana Post GPS address and returns it in its normal form; a blank
+// address is stored as none
+func normalizeGPSAddress(value *string) (*string, error) {
+	if value == nil || strings.TrimSpace(*value) == "" {
+		return nil, nil
+	}
+	address, err := utils.ParseGhanaPostGPS(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &address.Code, nil
+}
+
+// canViewSchoolRecords allows admins to see membership records (applications, merges) in their zones
+func canViewSchoolRecords(ownerCtx *utils.OwnerContext) error {
+	if ownerCtx == nil {
+		return errors.New("access denied")
+	}
+	switch ownerCtx.Role {
+	case utils.RoleSystemAdmin, utils.RoleNationalAdmin, utils.RoleRegionAdmin, utils.RoleZoneAdmin:
+		return nil
+	}
+	return errors.New("access denied")
+}
+
+// canManageSchoolRecords allows national, region and zone admins to change membership records;
+// system admins have view-only access
+func canManageSchoolRecords(ownerCtx *utils.OwnerContext) error {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return err
+	}
+	if ownerCtx.IsSystemAdmin() {
+		return errors.New("access denied")
+	}
+	return nil
+}
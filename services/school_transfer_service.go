@@ -0,0 +1,348 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gnaps-api/models"
+	"gnaps-api/repositories"
+	"gnaps-api/utils"
+	"log"
+	"strings"
+	"time"
+)
+
+// School transfer statuses
+const (
+	TransferStatusPending   = "pending"
+	TransferStatusAccepted  = "accepted"
+	TransferStatusRejected  = "rejected"
+	TransferStatusCancelled = "cancelled"
+)
+
+// SchoolTransferRequest is a sending zone's request to move a school to another zone
+type SchoolTransferRequest struct {
+	SchoolId         uint   `json:"school_id"`
+	ToZoneId         int64  `json:"to_zone_id"`
+	Reason           string `json:"reason"`
+	BillHandling     string `json:"bill_handling"`       // keep (default), move or settle_first
+	IssueNewMemberNo *bool  `json:"issue_new_member_no"` // defaults to true
+}
+
+// SchoolTransferDetail is a transfer with the school's outstanding bills at the time it is viewed
+type SchoolTransferDetail struct {
+	models.SchoolTransfer
+	OutstandingBills   int64   `json:"outstanding_bills"`
+	OutstandingBalance float64 `json:"outstanding_balance"`
+}
+
+type SchoolTransferService struct {
+	transferRepo   *repositories.SchoolTransferRepository
+	schoolRepo     *repositories.SchoolRepository
+	schoolBillRepo *repositories.SchoolBillRepository
+	zoneRepo       *repositories.ZoneRepository
+	smsService     *SmsService
+}
+
+func NewSchoolTransferService(
+	transferRepo *repositories.SchoolTransferRepository,
+	schoolRepo *repositories.SchoolRepository,
+	schoolBillRepo *repositories.SchoolBillRepository,
+	zoneRepo *repositories.ZoneRepository,
+	smsService *SmsService,
+) *SchoolTransferService {
+	return &SchoolTransferService{
+		transferRepo:   transferRepo,
+		schoolRepo:     schoolRepo,
+		schoolBillRepo: schoolBillRepo,
+		zoneRepo:       zoneRepo,
+		smsService:     smsService,
+	}
+}
+
+// RequestTransfer asks the receiving zone to take over a school. The school must be in one of the
+// user's zones; the receiving zone accepts or rejects the request.
+func (s *SchoolTransferService) RequestTransfer(req SchoolTransferRequest, requestedBy *int64, ownerCtx *utils.OwnerContext) (*models.SchoolTransfer, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	if req.SchoolId == 0 || req.ToZoneId == 0 {
+		return nil, errors.New("school_id and to_zone_id are required")
+	}
+
+	billHandling := strings.TrimSpace(req.BillHandling)
+	if billHandling == "" {
+		billHandling = repositories.TransferBillsKeep
+	}
+	switch billHandling {
+	case repositories.TransferBillsKeep, repositories.TransferBillsMove, repositories.TransferBillsSettleFirst:
+	default:
+		return nil, errors.New("bill_handling must be keep, move or settle_first")
+	}
+
+	school, err := s.schoolRepo.FindByIDWithRoleFilter(req.SchoolId, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("school not found")
+	}
+	if school.ZoneId == nil {
+		return nil, errors.New("school is not assigned to a zone")
+	}
+	if *school.ZoneId == req.ToZoneId {
+		return nil, errors.New("school is already in this zone")
+	}
+	if exists, err := s.schoolRepo.VerifyZoneExists(req.ToZoneId); err != nil || !exists {
+		return nil, errors.New("zone not found")
+	}
+
+	pending, err := s.transferRepo.PendingExists(int64(school.ID))
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("school already has a transfer awaiting acceptance")
+	}
+
+	if billHandling == repositories.TransferBillsSettleFirst {
+		count, balance, err := s.schoolBillRepo.OutstandingBills(int64(school.ID))
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("school has %d outstanding bills (GH₵ %.2f) to settle before it can be transferred", count, balance)
+		}
+	}
+
+	issueNewMemberNo := true
+	if req.IssueNewMemberNo != nil {
+		issueNewMemberNo = *req.IssueNewMemberNo
+	}
+	memberNo := school.MemberNo
+	transfer := &models.SchoolTransfer{
+		SchoolId:         int64(school.ID),
+		FromZoneId:       *school.ZoneId,
+		ToZoneId:         req.ToZoneId,
+		Status:           TransferStatusPending,
+		BillHandling:     billHandling,
+		IssueNewMemberNo: issueNewMemberNo,
+		OldMemberNo:      &memberNo,
+		RequestedBy:      requestedBy,
+	}
+	if reason := strings.TrimSpace(req.Reason); reason != "" {
+		transfer.Reason = &reason
+	}
+
+	if err := s.transferRepo.Create(transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// ListTransfers returns transfers into or out of the user's zones. direction narrows the list to
+// "incoming" or "outgoing" transfers of the user's zones.
+func (s *SchoolTransferService) ListTransfers(filters map[string]interface{}, direction string, page, limit int, ownerCtx *utils.OwnerContext) ([]models.SchoolTransfer, int64, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, 0, err
+	}
+	zoneIDs, err := s.accessibleZoneIDs(ownerCtx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.transferRepo.List(filters, zoneIDs, direction, page, limit)
+}
+
+// GetTransfer returns a transfer into or out of the user's zones with the school's outstanding bills
+func (s *SchoolTransferService) GetTransfer(id uint, ownerCtx *utils.OwnerContext) (*SchoolTransferDetail, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	transfer, sending, receiving, err := s.transferWithSides(id, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+	if !sending && !receiving {
+		return nil, errors.New("transfer not found")
+	}
+
+	detail := &SchoolTransferDetail{SchoolTransfer: *transfer}
+	if transfer.Status == TransferStatusPending {
+		detail.OutstandingBills, detail.OutstandingBalance, err = s.schoolBillRepo.OutstandingBills(transfer.SchoolId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return detail, nil
+}
+
+// AcceptTransfer moves the school into the receiving zone; only the receiving zone (or a region or
+// national admin over it) can accept. The school is told its new member number.
+func (s *SchoolTransferService) AcceptTransfer(id uint, notes string, decidedBy *int64, ownerCtx *utils.OwnerContext) (*models.SchoolTransfer, error) {
+	transfer, err := s.pendingTransfer(id, ownerCtx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var decisionNotes *string
+	if notes = strings.TrimSpace(notes); notes != "" {
+		decisionNotes = &notes
+	}
+
+	if err := s.transferRepo.Accept(transfer, decidedBy, decisionNotes); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrSchoolTransferNotPending):
+			return nil, errors.New("transfer has already been decided")
+		case errors.Is(err, repositories.ErrSchoolLeftSendingZone), errors.Is(err, repositories.ErrSchoolHasOutstandingBills):
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to transfer school: %v", err)
+	}
+
+	s.notifyAccepted(transfer)
+	return transfer, nil
+}
+
+// RejectTransfer declines a transfer on behalf of the receiving zone; a reason is required
+func (s *SchoolTransferService) RejectTransfer(id uint, reason string, decidedBy *int64, ownerCtx *utils.OwnerContext) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return errors.New("a reason is required to reject a transfer")
+	}
+	if _, err := s.pendingTransfer(id, ownerCtx, true); err != nil {
+		return err
+	}
+	return s.decide(id, TransferStatusRejected, reason, decidedBy)
+}
+
+// CancelTransfer withdraws a pending transfer on behalf of the sending zone
+func (s *SchoolTransferService) CancelTransfer(id uint, notes string, decidedBy *int64, ownerCtx *utils.OwnerContext) error {
+	if _, err := s.pendingTransfer(id, ownerCtx, false); err != nil {
+		return err
+	}
+	return s.decide(id, TransferStatusCancelled, strings.TrimSpace(notes), decidedBy)
+}
+
+// GetZoneHistory returns the zones a school has belonged to and the member numbers it held.
+// A school that was never transferred has no history rows and is reported in its current zone.
+func (s *SchoolTransferService) GetZoneHistory(schoolID uint, ownerCtx *utils.OwnerContext) ([]models.SchoolZoneHistory, error) {
+	if err := canViewSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	school, err := s.schoolRepo.FindByIDWithRoleFilter(schoolID, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, errors.New("school not found")
+	}
+
+	history, err := s.transferRepo.ListHistory(int64(school.ID))
+	if err != nil {
+		return nil, err
+	}
+	if len(history) == 0 && school.ZoneId != nil {
+		memberNo := school.MemberNo
+		history = append(history, models.SchoolZoneHistory{
+			SchoolId: int64(school.ID),
+			ZoneId:   *school.ZoneId,
+			MemberNo: &memberNo,
+			Zone:     school.Zone,
+		})
+	}
+	return history, nil
+}
+
+// pendingTransfer loads a pending transfer the user may decide: the receiving side accepts or
+// rejects, the sending side cancels
+func (s *SchoolTransferService) pendingTransfer(id uint, ownerCtx *utils.OwnerContext, receivingSide bool) (*models.SchoolTransfer, error) {
+	if err := canManageSchoolRecords(ownerCtx); err != nil {
+		return nil, err
+	}
+	transfer, sending, receiving, err := s.transferWithSides(id, ownerCtx)
+	if err != nil {
+		return nil, err
+	}
+	if !sending && !receiving {
+		return nil, errors.New("transfer not found")
+	}
+	if receivingSide && !receiving {
+		return nil, errors.New("only the receiving zone can accept or reject a transfer")
+	}
+	if !receivingSide && !sending {
+		return nil, errors.New("only the sending zone can cancel a transfer")
+	}
+	if transfer.Status != TransferStatusPending {
+		return nil, errors.New("transfer has already been decided")
+	}
+	return transfer, nil
+}
+
+// transferWithSides loads a transfer and reports whether the user administers its sending and receiving zones
+func (s *SchoolTransferService) transferWithSides(id uint, ownerCtx *utils.OwnerContext) (*models.SchoolTransfer, bool, bool, error) {
+	transfer, err := s.transferRepo.FindByID(id)
+	if err != nil {
+		return nil, false, false, errors.New("transfer not found")
+	}
+	zoneIDs, err := s.accessibleZoneIDs(ownerCtx)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if zoneIDs == nil {
+		return transfer, true, true, nil
+	}
+
+	sending, receiving := false, false
+	for _, zoneID := range zoneIDs {
+		sending = sending || zoneID == transfer.FromZoneId
+		receiving = receiving || zoneID == transfer.ToZoneId
+	}
+	return transfer, sending, receiving, nil
+}
+
+// accessibleZoneIDs lists the zones a region or zone admin covers; nil means every zone
+func (s *SchoolTransferService) accessibleZoneIDs(ownerCtx *utils.OwnerContext) ([]int64, error) {
+	if ownerCtx.CanViewAllHierarchyData() {
+		return nil, nil
+	}
+	zones, _, err := s.zoneRepo.ListWithRoleFilter(map[string]interface{}{}, 1, 10000, ownerCtx.GetRegionIDFilter(), ownerCtx.GetZoneIDFilter())
+	if err != nil {
+		return nil, err
+	}
+	zoneIDs := []int64{}
+	for _, zone := range zones {
+		zoneIDs = append(zoneIDs, int64(zone.ID))
+	}
+	return zoneIDs, nil
+}
+
+func (s *SchoolTransferService) decide(id uint, status, notes string, decidedBy *int64) error {
+	updates := map[string]interface{}{
+		"status":     status,
+		"decided_by": decidedBy,
+		"decided_at": time.Now(),
+	}
+	if notes != "" {
+		updates["decision_notes"] = notes
+	}
+	if err := s.transferRepo.Decide(id, updates); err != nil {
+		if errors.Is(err, repositories.ErrSchoolTransferNotPending) {
+			return errors.New("transfer has already been decided")
+		}
+		return err
+	}
+	return nil
+}
+
+// notifyAccepted texts the school through the receiving zone's SMS package; failures are logged, not returned
+func (s *SchoolTransferService) notifyAccepted(transfer *models.SchoolTransfer) {
+	if s.smsService == nil || transfer.School == nil || transfer.School.MobileNo == nil || *transfer.School.MobileNo == "" {
+		return
+	}
+
+	zoneName := "the new"
+	if transfer.ToZone != nil && transfer.ToZone.Name != nil {
+		zoneName = *transfer.ToZone.Name
+	}
+	message := fmt.Sprintf("%s has been transferred to %s zone.", transfer.School.Name, zoneName)
+	if transfer.NewMemberNo != nil {
+		message += fmt.Sprintf(" Your new member number is %s; use it as your username to sign in.", *transfer.NewMemberNo)
+	}
+
+	if err := s.smsService.EnqueueSMS(message, *transfer.School.MobileNo, utils.OwnerTypeZone, transfer.ToZoneId, "", false); err != nil {
+		log.Printf("Failed to notify school %d about transfer %d: %v", transfer.SchoolId, transfer.ID, err)
+	}
+}
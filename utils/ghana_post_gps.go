@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// GhanaPostAddress is a parsed Ghana Post GPS digital address such as GA-492-7381
+type GhanaPostAddress struct {
+	Code         string `json:"code"`          // normalized, e.g. GA-492-7381
+	DistrictCode string `json:"district_code"` // e.g. GA
+	Region       string `json:"region"`        // region the first letter of the district code stands for
+	AreaCode     string `json:"area_code"`
+	UniqueCode   string `json:"unique_code"`
+}
+
+// ghanaPostRegions maps the first letter of a district code to its region. The letters predate the
+// 2019 regions, so e.g. Oti addresses still start with V (Volta).
+var ghanaPostRegions = map[byte]string{
+	'A': "Ashanti",
+	'B': "Brong Ahafo",
+	'C': "Central",
+	'E': "Eastern",
+	'G': "Greater Accra",
+	'N': "Northern",
+	'U': "Upper East",
+	'V': "Volta",
+	'W': "Western",
+	'X': "Upper West",
+}
+
+// ghanaPostPattern matches a digital address once spaces and dashes are removed: two letters, a
+// 3 or 4 digit area code and a 4 digit unique code
+var ghanaPostPattern = regexp.MustCompile(`^([A-Z]{2})([0-9]{3,4})([0-9]{4})$`)
+
+// Bounds of Ghana, with a small margin, used to reject coordinates that cannot be a member school
+const (
+	ghanaMinLatitude  = 4.5
+	ghanaMaxLatitude  = 11.3
+	ghanaMinLongitude = -3.4
+	ghanaMaxLongitude = 1.3
+)
+
+const earthRadiusKm = 6371.0
+
+// ParseGhanaPostGPS validates and parses a Ghana Post GPS digital address. Case, spaces and dashes
+// are ignored, so "ga 492 7381" and "GA4927381" both parse as GA-492-7381.
+func ParseGhanaPostGPS(value string) (*GhanaPostAddress, error) {
+	compact := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '–' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(value)))
+
+	match := ghanaPostPattern.FindStringSubmatch(compact)
+	if match == nil {
+		return nil, errors.New("invalid Ghana Post GPS address (expected e.g. GA-492-7381)")
+	}
+	region, ok := ghanaPostRegions[match[1][0]]
+	if !ok {
+		return nil, errors.New("invalid Ghana Post GPS address: unknown district code " + match[1])
+	}
+
+	return &GhanaPostAddress{
+		Code:         match[1] + "-" + match[2] + "-" + match[3],
+		DistrictCode: match[1],
+		Region:       region,
+		AreaCode:     match[2],
+		UniqueCode:   match[3],
+	}, nil
+}
+
+// ValidateCoordinates requires a latitude and longitude to be given together and to lie in Ghana
+func ValidateCoordinates(latitude, longitude *float64) error {
+	if latitude == nil && longitude == nil {
+		return nil
+	}
+	if latitude == nil || longitude == nil {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if *latitude < ghanaMinLatitude || *latitude > ghanaMaxLatitude ||
+		*longitude < ghanaMinLongitude || *longitude > ghanaMaxLongitude {
+		return errors.New("coordinates must be within Ghana")
+	}
+	return nil
+}
+
+// DistanceKm is the great-circle distance between two points
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// BoundingBox returns the box around a point that contains every point within radiusKm of it
+func BoundingBox(latitude, longitude, radiusKm float64) (minLat, minLng, maxLat, maxLng float64) {
+	latDelta := radiusKm / earthRadiusKm * 180 / math.Pi
+	lngDelta := latDelta / math.Cos(latitude*math.Pi/180)
+	return latitude - latDelta, longitude - lngDelta, latitude + latDelta, longitude + lngDelta
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// MailConfigured reports whether outgoing email is set up. Mail is sent through the SMTP server in
+// SMTP_HOST (port SMTP_PORT, default 587) as SMTP_FROM, logging in with SMTP_USERNAME and
+// SMTP_PASSWORD when they are set.
+func MailConfigured() bool {
+	return os.Getenv("SMTP_HOST") != "" && os.Getenv("SMTP_FROM") != ""
+}
+
+// SendMail sends a plain-text email to one recipient
+func SendMail(to, subject, body string) error {
+	if !MailConfigured() {
+		return errors.New("email is not configured")
+	}
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return errors.New("invalid email header")
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SMTP_FROM")
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		from, to, subject, time.Now().Format(time.RFC1123Z), strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return smtp.SendMail(net.JoinHostPort(host, port), auth, senderAddress(from), []string{to}, []byte(message))
+}
+
+// senderAddress takes the bare address out of a "Name <address>" sender
+func senderAddress(from string) string {
+	if start, end := strings.LastIndex(from, "<"), strings.LastIndex(from, ">"); start >= 0 && end > start {
+		return from[start+1 : end]
+	}
+	return from
+}
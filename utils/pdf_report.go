@@ -0,0 +1,193 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PDFReport builds simple tabular A4 reports (statements, exports)
+type PDFReport struct {
+	pdf          *fpdf.Fpdf
+	tableHeaders []string
+	tableWidths  []float64
+}
+
+// NewPDFReport starts a portrait report with a title and an optional subtitle line
+func NewPDFReport(title, subtitle string) *PDFReport {
+	return NewPDFReportWithOrientation("P", title, subtitle)
+}
+
+// NewPDFReportWithOrientation starts a report in portrait ("P") or landscape ("L") orientation
+func NewPDFReportWithOrientation(orientation, title, subtitle string) *PDFReport {
+	pdf := fpdf.New(orientation, "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Generated %s - Page %d", time.Now().Format("2006-01-02 15:04"), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 15)
+	pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+	if subtitle != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(0, 6, subtitle, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	return &PDFReport{pdf: pdf}
+}
+
+// Heading writes a section heading
+func (r *PDFReport) Heading(text string) {
+	r.pdf.Ln(2)
+	r.pdf.SetFont("Helvetica", "B", 11)
+	r.pdf.CellFormat(0, 7, text, "B", 1, "L", false, 0, "")
+	r.pdf.Ln(1)
+}
+
+// AmountRow writes a label with a right-aligned amount; totals are bold with a rule above
+func (r *PDFReport) AmountRow(label string, amount float64, total bool) {
+	border := ""
+	style := ""
+	if total {
+		border = "T"
+		style = "B"
+	}
+	r.pdf.SetFont("Helvetica", style, 10)
+	r.pdf.CellFormat(130, 6, label, border, 0, "L", false, 0, "")
+	r.pdf.CellFormat(0, 6, FormatAmount(amount), border, 1, "R", false, 0, "")
+}
+
+// Table writes a table with a shaded header row; columns without a width share the remaining space
+func (r *PDFReport) Table(headers []string, widths []float64, rows [][]string) {
+	r.BeginTable(headers, widths)
+	for _, row := range rows {
+		r.TableRow(row)
+	}
+}
+
+// BeginTable writes a table header row; rows added with TableRow repeat it on every new page
+func (r *PDFReport) BeginTable(headers []string, widths []float64) {
+	pageWidth, _ := r.pdf.GetPageSize()
+	left, _, right, _ := r.pdf.GetMargins()
+
+	r.tableHeaders = headers
+	r.tableWidths = columnWidths(len(headers), widths, pageWidth-left-right)
+	r.writeTableHeader()
+}
+
+// TableRow writes one row of the current table
+func (r *PDFReport) TableRow(row []string) {
+	r.writeTableRow(row, "")
+}
+
+// TableTotalRow writes a bold totals row of the current table
+func (r *PDFReport) TableTotalRow(row []string) {
+	r.writeTableRow(row, "B")
+}
+
+func (r *PDFReport) writeTableHeader() {
+	r.pdf.SetFont("Helvetica", "B", 9)
+	r.pdf.SetFillColor(230, 230, 230)
+	for i, header := range r.tableHeaders {
+		r.pdf.CellFormat(r.tableWidths[i], 7, header, "1", 0, "L", true, 0, "")
+	}
+	r.pdf.Ln(-1)
+}
+
+func (r *PDFReport) writeTableRow(row []string, style string) {
+	const rowHeight = 6
+
+	_, pageHeight := r.pdf.GetPageSize()
+	_, _, _, bottom := r.pdf.GetMargins()
+	if r.pdf.GetY()+rowHeight > pageHeight-bottom {
+		r.pdf.AddPage()
+		r.writeTableHeader()
+	}
+
+	r.pdf.SetFont("Helvetica", style, 8)
+	for i := range r.tableHeaders {
+		value := ""
+		if i < len(row) {
+			value = row[i]
+		}
+		r.pdf.CellFormat(r.tableWidths[i], rowHeight, truncateToWidth(r.pdf, value, r.tableWidths[i]-2), "1", 0, "L", false, 0, "")
+	}
+	r.pdf.Ln(-1)
+}
+
+// Text writes a paragraph of plain text
+func (r *PDFReport) Text(text string) {
+	r.pdf.SetFont("Helvetica", "", 10)
+	r.pdf.MultiCell(0, 5, text, "", "L", false)
+}
+
+// Output writes the finished PDF
+func (r *PDFReport) Output(w io.Writer) error {
+	return r.pdf.Output(w)
+}
+
+// FormatAmount formats a money amount with thousands separators and brackets for negatives
+func FormatAmount(amount float64) string {
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+
+	formatted := fmt.Sprintf("%.2f", amount)
+	whole, fraction := formatted[:len(formatted)-3], formatted[len(formatted)-3:]
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	b.WriteString(fraction)
+
+	if negative {
+		return "(" + b.String() + ")"
+	}
+	return b.String()
+}
+
+func columnWidths(count int, widths []float64, available float64) []float64 {
+	result := make([]float64, count)
+	used, unset := 0.0, 0
+	for i := 0; i < count; i++ {
+		if i < len(widths) && widths[i] > 0 {
+			result[i] = widths[i]
+			used += widths[i]
+		} else {
+			unset++
+		}
+	}
+	if unset > 0 {
+		share := (available - used) / float64(unset)
+		for i := range result {
+			if result[i] == 0 {
+				result[i] = share
+			}
+		}
+	}
+	return result
+}
+
+func truncateToWidth(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"...") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
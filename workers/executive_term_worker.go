@@ -0,0 +1,78 @@
+package workers
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultExecutiveTermHour is the hour of the night expired terms are closed when
+// EXECUTIVE_TERM_JOB_HOUR is not set
+const defaultExecutiveTermHour = 0
+
+// ExecutiveTermWorker runs the nightly closing of expired executive terms of office
+type ExecutiveTermWorker struct {
+	stopChan  chan struct{}
+	isRunning bool
+}
+
+// NewExecutiveTermWorker creates a new executive term worker
+func NewExecutiveTermWorker() *ExecutiveTermWorker {
+	return &ExecutiveTermWorker{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// ExecutiveTermCloserFunc is the function type for closing expired terms
+type ExecutiveTermCloserFunc func() error
+
+// StartNightlyClosing starts a background goroutine that ends expired terms once a day at
+// EXECUTIVE_TERM_JOB_HOUR (0-23, server time, default 0). Access stops when a term's end date
+// passes whether or not the job runs; the job records the terms as completed and updates the
+// executives' records.
+// Set ENABLE_EXECUTIVE_TERM_JOB=false in .env to disable this feature
+func (w *ExecutiveTermWorker) StartNightlyClosing(closeFunc ExecutiveTermCloserFunc) {
+	enableJob := os.Getenv("ENABLE_EXECUTIVE_TERM_JOB")
+	if enableJob == "false" || enableJob == "0" {
+		log.Println("Executive term job is DISABLED. Set ENABLE_EXECUTIVE_TERM_JOB=true to enable.")
+		return
+	}
+
+	hour := defaultExecutiveTermHour
+	if h, err := strconv.Atoi(os.Getenv("EXECUTIVE_TERM_JOB_HOUR")); err == nil && h >= 0 && h < 24 {
+		hour = h
+	}
+
+	// Mark as running
+	w.isRunning = true
+
+	go func() {
+		log.Printf("Executive term job is ENABLED (runs daily at %02d:00)...", hour)
+
+		for {
+			timer := time.NewTimer(time.Until(nextRunAt(time.Now(), hour)))
+			select {
+			case <-w.stopChan:
+				timer.Stop()
+				log.Println("Executive term job received stop signal")
+				return
+			case <-timer.C:
+				log.Println("Closing expired executive terms...")
+				if err := closeFunc(); err != nil {
+					log.Printf("Error closing expired executive terms: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the nightly closing
+func (w *ExecutiveTermWorker) Close() error {
+	if w.isRunning {
+		close(w.stopChan)
+		w.isRunning = false
+		log.Println("Executive term job stopped")
+	}
+	return nil
+}
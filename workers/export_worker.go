@@ -0,0 +1,163 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+const (
+	TypeExportGenerate = "export:generate"
+)
+
+// ExportGeneratePayload contains the data for generating a background export
+type ExportGeneratePayload struct {
+	ExportJobID uint `json:"export_job_id"`
+}
+
+// exportCleanupInterval is how often expired export files are swept
+const exportCleanupInterval = time.Hour
+
+// ExportWorker handles background report exports
+type ExportWorker struct {
+	client    *asynq.Client
+	server    *asynq.Server
+	stopChan  chan struct{} // Channel to signal the expired file cleanup to stop
+	isRunning bool
+}
+
+// NewExportWorker creates a new export worker
+func NewExportWorker() *ExportWorker {
+	redisAddr := os.Getenv("REDIS_URL")
+	if redisAddr == "" {
+		redisAddr = "localhost:6379"
+	}
+
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr})
+
+	server := asynq.NewServer(
+		asynq.RedisClientOpt{Addr: redisAddr},
+		asynq.Config{
+			Concurrency: 2,
+			Queues: map[string]int{
+				"exports": 1,
+			},
+		},
+	)
+
+	return &ExportWorker{
+		client:   client,
+		server:   server,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// EnqueueExport enqueues an export generation task
+func (w *ExportWorker) EnqueueExport(exportJobID uint) error {
+	payload, err := json.Marshal(ExportGeneratePayload{ExportJobID: exportJobID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	task := asynq.NewTask(TypeExportGenerate, payload)
+
+	info, err := w.client.Enqueue(task,
+		asynq.Queue("exports"),
+		asynq.MaxRetry(1),
+		asynq.Timeout(30*time.Minute),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Printf("Enqueued export task: id=%s queue=%s export_job_id=%d", info.ID, info.Queue, exportJobID)
+	return nil
+}
+
+// ExportGenerateHandler handles the export generation task
+type ExportGenerateHandler struct {
+	ProcessFunc func(exportJobID uint) error
+}
+
+func (h *ExportGenerateHandler) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	var payload ExportGeneratePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	log.Printf("Generating export job %d at %s", payload.ExportJobID, time.Now().Format(time.RFC3339))
+
+	if h.ProcessFunc != nil {
+		if err := h.ProcessFunc(payload.ExportJobID); err != nil {
+			log.Printf("Export job %d failed: %v", payload.ExportJobID, err)
+			return err
+		}
+	}
+
+	log.Printf("Export job %d completed", payload.ExportJobID)
+	return nil
+}
+
+// StartWorker starts the asynq worker server
+func (w *ExportWorker) StartWorker(processFunc func(exportJobID uint) error) error {
+	mux := asynq.NewServeMux()
+	mux.Handle(TypeExportGenerate, &ExportGenerateHandler{ProcessFunc: processFunc})
+
+	log.Println("Starting export worker...")
+	return w.server.Run(mux)
+}
+
+// ExportCleanupFunc is the function type for deleting expired export files
+type ExportCleanupFunc func() error
+
+// StartExpiredFileCleanup starts a background goroutine that deletes expired export files on
+// start and then every hour
+func (w *ExportWorker) StartExpiredFileCleanup(cleanupFunc ExportCleanupFunc) {
+	// Mark as running
+	w.isRunning = true
+
+	go func() {
+		ticker := time.NewTicker(exportCleanupInterval)
+		defer ticker.Stop()
+
+		log.Println("Export file cleanup is ENABLED (runs every hour)...")
+		if err := cleanupFunc(); err != nil {
+			log.Printf("Error deleting expired export files: %v", err)
+		}
+
+		for {
+			select {
+			case <-w.stopChan:
+				log.Println("Export file cleanup received stop signal")
+				return
+			case <-ticker.C:
+				if err := cleanupFunc(); err != nil {
+					log.Printf("Error deleting expired export files: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close closes the worker connections
+func (w *ExportWorker) Close() error {
+	// Signal the expired file cleanup to stop
+	if w.isRunning {
+		close(w.stopChan)
+		w.isRunning = false
+		log.Println("Export file cleanup stopped")
+	}
+
+	if err := w.client.Close(); err != nil {
+		log.Printf("Error closing asynq client: %v", err)
+	}
+
+	w.server.Shutdown()
+	log.Println("Export asynq server shutdown complete")
+	return nil
+}
@@ -0,0 +1,85 @@
+package workers
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultMembershipStatusHour is the hour of the night the evaluation runs when
+// MEMBERSHIP_STATUS_JOB_HOUR is not set
+const defaultMembershipStatusHour = 1
+
+// MembershipStatusWorker runs the nightly evaluation of school membership statuses
+type MembershipStatusWorker struct {
+	stopChan  chan struct{}
+	isRunning bool
+}
+
+// NewMembershipStatusWorker creates a new membership status worker
+func NewMembershipStatusWorker() *MembershipStatusWorker {
+	return &MembershipStatusWorker{
+		stopChan: make(chan struct{}),
+	}
+}
+
+// MembershipStatusEvaluatorFunc is the function type for evaluating membership statuses
+type MembershipStatusEvaluatorFunc func() error
+
+// StartNightlyEvaluation starts a background goroutine that lapses and reinstates schools once a day
+// at MEMBERSHIP_STATUS_JOB_HOUR (0-23, server time, default 1)
+// Set ENABLE_MEMBERSHIP_STATUS_JOB=true in .env to enable this feature
+func (w *MembershipStatusWorker) StartNightlyEvaluation(evaluateFunc MembershipStatusEvaluatorFunc) {
+	enableJob := os.Getenv("ENABLE_MEMBERSHIP_STATUS_JOB")
+	if enableJob != "true" && enableJob != "1" {
+		log.Println("Membership status job is DISABLED. Set ENABLE_MEMBERSHIP_STATUS_JOB=true to enable.")
+		return
+	}
+
+	hour := defaultMembershipStatusHour
+	if h, err := strconv.Atoi(os.Getenv("MEMBERSHIP_STATUS_JOB_HOUR")); err == nil && h >= 0 && h < 24 {
+		hour = h
+	}
+
+	// Mark as running
+	w.isRunning = true
+
+	go func() {
+		log.Printf("Membership status job is ENABLED (runs daily at %02d:00)...", hour)
+
+		for {
+			timer := time.NewTimer(time.Until(nextRunAt(time.Now(), hour)))
+			select {
+			case <-w.stopChan:
+				timer.Stop()
+				log.Println("Membership status job received stop signal")
+				return
+			case <-timer.C:
+				log.Println("Evaluating school membership statuses...")
+				if err := evaluateFunc(); err != nil {
+					log.Printf("Error evaluating membership statuses: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the nightly evaluation
+func (w *MembershipStatusWorker) Close() error {
+	if w.isRunning {
+		close(w.stopChan)
+		w.isRunning = false
+		log.Println("Membership status job stopped")
+	}
+	return nil
+}
+
+// nextRunAt is the next time after now that the clock reads hour:00
+func nextRunAt(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}